@@ -0,0 +1,360 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package opus
+
+import (
+	"time"
+
+	"github.com/pion/opus/internal/celt"
+	"github.com/pion/opus/internal/silk"
+)
+
+// maxAnalyzedPacketSamples is the largest packet, 120 ms of 48 kHz stereo, an
+// Analyzer decodes.
+const maxAnalyzedPacketSamples = maxOpusPacketDurationNanosecond / 1000000 * celtSampleRate / 1000 * 2
+
+// Mode is the coding mode of an Opus packet, RFC 6716 Section 3.1.
+type Mode int
+
+const (
+	// ModeSILK packets are coded by the linear prediction layer alone.
+	ModeSILK Mode = iota + 1
+	// ModeHybrid packets code up to 8 kHz with SILK and the rest with CELT.
+	ModeHybrid
+	// ModeCELT packets are coded by the MDCT layer alone.
+	ModeCELT
+)
+
+func (m Mode) String() string {
+	switch m {
+	case ModeSILK:
+		return "SILK"
+	case ModeHybrid:
+		return "Hybrid"
+	case ModeCELT:
+		return "CELT"
+	}
+
+	return "Invalid Mode"
+}
+
+func (c configurationMode) public() Mode {
+	switch c {
+	case configurationModeSilkOnly:
+		return ModeSILK
+	case configurationModeHybrid:
+		return ModeHybrid
+	case configurationModeCELTOnly:
+		return ModeCELT
+	}
+
+	return 0
+}
+
+// SILKSignalType is the signal type of a SILK frame, RFC 6716 Section 4.2.7.3.
+type SILKSignalType int
+
+// SILKSignalType values.
+const (
+	SILKSignalInactive SILKSignalType = silk.SignalTypeInactive
+	SILKSignalUnvoiced SILKSignalType = silk.SignalTypeUnvoiced
+	SILKSignalVoiced   SILKSignalType = silk.SignalTypeVoiced
+)
+
+// PacketReport describes an Opus packet and the parameters coded in each of
+// its frames.
+type PacketReport struct {
+	Mode          Mode
+	Bandwidth     Bandwidth
+	Stereo        bool
+	FrameDuration time.Duration
+	Frames        []FrameReport
+}
+
+// FrameReport describes one Opus frame of a packet.
+type FrameReport struct {
+	// Bytes is the compressed size of the frame.
+	Bytes int
+	// SILK holds the regular SILK frames of SILK and Hybrid frames, in
+	// coding order: one per 20 ms, and for stereo the mid channel then the
+	// side channel when it is coded.
+	SILK []SILKFrameReport
+	// CELT is the CELT frame of CELT and Hybrid frames. It is nil when the
+	// frame is too short to code one.
+	CELT *CELTFrameReport
+	// Redundancy is set when the frame ends with a redundant CELT frame for
+	// a mode switch, RFC 6716 Section 4.5.1, and RedundancyCELTToSILK when
+	// that switch is from CELT to SILK.
+	Redundancy           bool
+	RedundancyCELTToSILK bool
+}
+
+// SILKFrameReport holds the parameters of one SILK frame of one channel,
+// RFC 6716 Section 4.2.7.
+type SILKFrameReport struct {
+	// Side is set for the side channel of a mid-side stereo frame.
+	Side          bool
+	VoiceActivity bool
+	// LBRR is set when the packet also carries a low-bitrate redundant copy
+	// of this frame for forward error correction.
+	LBRR bool
+
+	SignalType             SILKSignalType
+	QuantizationOffsetHigh bool
+	// GainsQ16 holds the quantization gain of each 5 ms subframe.
+	GainsQ16 []int32
+	// NLSFsQ15 are the normalized line spectral frequencies, and
+	// NLSFInterpolationQ2 how much of the previous frame's are mixed into
+	// the first half of a 20 ms frame, where 4 means none.
+	NLSFsQ15            []int16
+	NLSFInterpolationQ2 int
+	// PitchLags, in samples at the SILK rate, LTPFiltersQ7 and LTPScaleQ14
+	// are set for voiced frames only, the first two with one entry per
+	// subframe.
+	PitchLags    []int
+	LTPFiltersQ7 [][5]int8
+	LTPScaleQ14  int
+
+	// StereoWeightsQ13 and MidOnly are set on the mid channel of a stereo
+	// frame.
+	StereoWeightsQ13 [2]int32
+	MidOnly          bool
+
+	// Bits is how many eighth bits the frame took.
+	Bits int
+}
+
+// CELTFrameReport holds the parameters of one CELT frame, RFC 6716 Section
+// 4.3. The per-band slices have EndBand entries, of which those from
+// StartBand on are coded; per-channel ones have Channels entries.
+type CELTFrameReport struct {
+	StartBand int
+	EndBand   int
+	Channels  int
+	Silence   bool
+
+	PostFilter       bool
+	PostFilterPeriod int
+	PostFilterGain   float32
+	PostFilterTapset int
+
+	Transient   bool
+	IntraEnergy bool
+	// CoarseEnergy is the energy of each band, in base-2 log units, after
+	// coarse quantization, and FineEnergy after the fine refinements.
+	CoarseEnergy [][]float32
+	FineEnergy   [][]float32
+
+	// TFResolution is the time-frequency resolution change of each band.
+	TFResolution []int
+	TFSelect     int
+	// Spread is the PVQ rotation: 0 for none, then light, normal and
+	// aggressive.
+	Spread int
+	// BandBoost is the dynamic allocation boost of each band in eighth bits.
+	BandBoost      []int
+	AllocationTrim int
+	// Intensity is the first band coded with intensity stereo, and
+	// DualStereo is set when the channels are coded separately.
+	Intensity  int
+	DualStereo bool
+	CodedBands int
+
+	// ShapeBits is the budget of each band's shape in eighth bits, FineBits
+	// its fine energy bits per channel, and Pulses the PVQ pulses it was
+	// coded with, summed over channels and splits.
+	ShapeBits    []int
+	FineBits     []int
+	Pulses       []int
+	AntiCollapse bool
+
+	SymbolBits CELTSymbolBits
+}
+
+// CELTSymbolBits breaks a CELT frame down by how many eighth bits each group
+// of symbols took, in coding order.
+type CELTSymbolBits struct {
+	// Header is the silence, post-filter, transient and intra flags.
+	Header       int
+	CoarseEnergy int
+	// Allocation is the time-frequency, spread, dynamic allocation, trim,
+	// skip and stereo symbols.
+	Allocation int
+	FineEnergy int
+	// Residual is the PVQ shapes and stereo angles.
+	Residual        int
+	AntiCollapse    int
+	FinalFineEnergy int
+}
+
+// Analyzer decodes a stream of Opus packets and reports the parameters coded
+// in each frame. Most of them are predicted from the previous frames, so like
+// a Decoder it keeps state between packets, which must be given in order.
+type Analyzer struct {
+	decoder Decoder
+	pcm     []float32
+}
+
+// packetAnalysis collects the internal reports of the frame being decoded.
+type packetAnalysis struct {
+	report *PacketReport
+	silk   []silk.FrameReport
+	celt   celt.FrameReport
+}
+
+// NewAnalyzer creates an Analyzer.
+func NewAnalyzer() Analyzer {
+	decoder, _ := NewDecoderWithOutput(celtSampleRate, 2)
+
+	return Analyzer{decoder: decoder}
+}
+
+// Analyze reports the parameters coded in a single packet, decoded without
+// any history.
+func Analyze(packet []byte) (*PacketReport, error) {
+	analyzer := NewAnalyzer()
+
+	return analyzer.Analyze(packet)
+}
+
+// Analyze decodes the next packet of the stream and reports the parameters
+// coded in it.
+func (a *Analyzer) Analyze(packet []byte) (*PacketReport, error) {
+	if len(packet) < 1 {
+		return nil, errTooShortForTableOfContentsHeader
+	}
+	cfg := tableOfContentsHeader(packet[0]).configuration()
+	report := &PacketReport{
+		Bandwidth:     cfg.bandwidth(),
+		Stereo:        tableOfContentsHeader(packet[0]).isStereo(),
+		Mode:          cfg.mode().public(),
+		FrameDuration: time.Duration(cfg.frameDuration().nanoseconds()),
+	}
+
+	if a.pcm == nil {
+		a.pcm = make([]float32, maxAnalyzedPacketSamples)
+	}
+	a.decoder.analysis = &packetAnalysis{report: report}
+	defer a.decoder.stopAnalysis()
+	if _, _, _, err := a.decoder.decodeToFloat32(packet, a.pcm); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// stopAnalysis detaches the decoder from the packet being analyzed, even if
+// decoding it failed half way.
+func (d *Decoder) stopAnalysis() {
+	d.analysis = nil
+	d.silkDecoder.SetReport(nil)
+	d.celtDecoder.SetReport(nil)
+}
+
+// beginFrameAnalysis starts collecting the SILK frames of the next frame.
+func (d *Decoder) beginFrameAnalysis() {
+	if d.analysis == nil {
+		return
+	}
+	d.analysis.silk = d.analysis.silk[:0]
+	d.silkDecoder.SetReport(&d.analysis.silk)
+}
+
+// beginCELTAnalysis starts collecting the main CELT frame of the next frame,
+// as opposed to a redundant one.
+func (d *Decoder) beginCELTAnalysis() {
+	if d.analysis == nil {
+		return
+	}
+	d.analysis.celt = celt.FrameReport{}
+	d.celtDecoder.SetReport(&d.analysis.celt)
+}
+
+// endFrameAnalysis adds the frame collected since beginFrameAnalysis to the
+// report. redundancy is nil for CELT frames.
+func (d *Decoder) endFrameAnalysis(encodedFrame []byte, redundancy *hybridRedundancy) {
+	if d.analysis == nil {
+		return
+	}
+	d.silkDecoder.SetReport(nil)
+	d.celtDecoder.SetReport(nil)
+
+	frame := FrameReport{Bytes: len(encodedFrame)}
+	for i := range d.analysis.silk {
+		frame.SILK = append(frame.SILK, newSILKFrameReport(&d.analysis.silk[i]))
+	}
+	// Only a decoded CELT frame has a channel count.
+	if d.analysis.celt.Channels != 0 {
+		frame.CELT = newCELTFrameReport(&d.analysis.celt)
+	}
+	if redundancy != nil {
+		frame.Redundancy = redundancy.present
+		frame.RedundancyCELTToSILK = redundancy.present && redundancy.celtToSilk
+	}
+	d.analysis.report.Frames = append(d.analysis.report.Frames, frame)
+	d.analysis.silk = d.analysis.silk[:0]
+	d.analysis.celt = celt.FrameReport{}
+}
+
+func newSILKFrameReport(in *silk.FrameReport) SILKFrameReport {
+	return SILKFrameReport{
+		Side:                   in.Side,
+		VoiceActivity:          in.VoiceActivity,
+		LBRR:                   in.LBRR,
+		SignalType:             SILKSignalType(in.SignalType),
+		QuantizationOffsetHigh: in.QuantizationOffsetHigh,
+		GainsQ16:               in.GainsQ16,
+		NLSFsQ15:               in.NLSFsQ15,
+		NLSFInterpolationQ2:    in.NLSFInterpolationQ2,
+		PitchLags:              in.PitchLags,
+		LTPFiltersQ7:           in.LTPFiltersQ7,
+		LTPScaleQ14:            in.LTPScaleQ14,
+		StereoWeightsQ13:       in.StereoWeightsQ13,
+		MidOnly:                in.MidOnly,
+		Bits:                   in.Bits,
+	}
+}
+
+func newCELTFrameReport(in *celt.FrameReport) *CELTFrameReport {
+	bands := in.EndBand
+	out := &CELTFrameReport{
+		StartBand:        in.StartBand,
+		EndBand:          in.EndBand,
+		Channels:         in.Channels,
+		Silence:          in.Silence,
+		PostFilter:       in.PostFilter,
+		PostFilterPeriod: in.PostFilterPeriod,
+		PostFilterGain:   in.PostFilterGain,
+		PostFilterTapset: in.PostFilterTapset,
+		Transient:        in.Transient,
+		IntraEnergy:      in.IntraEnergy,
+		TFResolution:     append([]int(nil), in.TFResolution[:bands]...),
+		TFSelect:         in.TFSelect,
+		Spread:           in.Spread,
+		BandBoost:        append([]int(nil), in.BandBoost[:bands]...),
+		AllocationTrim:   in.AllocationTrim,
+		Intensity:        in.Intensity,
+		DualStereo:       in.DualStereo,
+		CodedBands:       in.CodedBands,
+		ShapeBits:        append([]int(nil), in.ShapeBits[:bands]...),
+		FineBits:         append([]int(nil), in.FineBits[:bands]...),
+		Pulses:           append([]int(nil), in.Pulses[:bands]...),
+		AntiCollapse:     in.AntiCollapse,
+		SymbolBits: CELTSymbolBits{
+			Header:          in.SymbolBits[celt.SymbolGroupHeader],
+			CoarseEnergy:    in.SymbolBits[celt.SymbolGroupCoarseEnergy],
+			Allocation:      in.SymbolBits[celt.SymbolGroupAllocation],
+			FineEnergy:      in.SymbolBits[celt.SymbolGroupFineEnergy],
+			Residual:        in.SymbolBits[celt.SymbolGroupResidual],
+			AntiCollapse:    in.SymbolBits[celt.SymbolGroupAntiCollapse],
+			FinalFineEnergy: in.SymbolBits[celt.SymbolGroupFinalFineEnergy],
+		},
+	}
+	for channel := range in.Channels {
+		out.CoarseEnergy = append(out.CoarseEnergy, append([]float32(nil), in.CoarseEnergy[channel][:bands]...))
+		out.FineEnergy = append(out.FineEnergy, append([]float32(nil), in.FineEnergy[channel][:bands]...))
+	}
+
+	return out
+}
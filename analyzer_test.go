@@ -0,0 +1,144 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package opus
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzeSILK(t *testing.T) {
+	// A 160 Hz tone has a 100-sample period at 16 kHz.
+	encoder, err := NewEncoder()
+	require.NoError(t, err)
+	analyzer := NewAnalyzer()
+	var report *PacketReport
+	for frame := range 3 {
+		pcm := make([]int16, 320)
+		for i := range pcm {
+			phase := 2 * math.Pi * float64(frame*320+i) / 100
+			pcm[i] = int16(6000*math.Sin(phase) + 2500*math.Sin(2*phase))
+		}
+		packet := make([]byte, maxOpusFrameSize)
+		n, err := encoder.EncodeSILK(pcm, BandwidthWideband, packet)
+		require.NoError(t, err)
+		report, err = analyzer.Analyze(packet[:n])
+		require.NoError(t, err)
+
+		require.Len(t, report.Frames, 1)
+		assert.Equal(t, n-1, report.Frames[0].Bytes)
+		require.Len(t, report.Frames[0].SILK, 1)
+		silkFrame := report.Frames[0].SILK[0]
+		assert.LessOrEqual(t, silkFrame.Bits, 8*8*(n-1))
+		assert.Greater(t, silkFrame.Bits, 8*8*(n-2))
+	}
+
+	assert.Equal(t, ModeSILK, report.Mode)
+	assert.Equal(t, BandwidthWideband, report.Bandwidth)
+	assert.Equal(t, 20*time.Millisecond, report.FrameDuration)
+	assert.Nil(t, report.Frames[0].CELT)
+
+	silkFrame := report.Frames[0].SILK[0]
+	assert.True(t, silkFrame.VoiceActivity)
+	assert.Equal(t, SILKSignalVoiced, silkFrame.SignalType)
+	assert.Len(t, silkFrame.GainsQ16, 4)
+	assert.Len(t, silkFrame.NLSFsQ15, 16)
+	require.Len(t, silkFrame.PitchLags, 4)
+	for _, lag := range silkFrame.PitchLags {
+		assert.InDelta(t, 100, lag, 3)
+	}
+	assert.Len(t, silkFrame.LTPFiltersQ7, 4)
+	assert.Positive(t, silkFrame.LTPScaleQ14)
+}
+
+func TestAnalyzeCELT(t *testing.T) {
+	analyzer := NewAnalyzer()
+	for _, packet := range testLoudStereoPackets(t) {
+		report, err := analyzer.Analyze(packet)
+		require.NoError(t, err)
+
+		assert.Equal(t, ModeCELT, report.Mode)
+		assert.True(t, report.Stereo)
+		require.Len(t, report.Frames, 1)
+		assert.Empty(t, report.Frames[0].SILK)
+		celtFrame := report.Frames[0].CELT
+		require.NotNil(t, celtFrame)
+
+		assert.Equal(t, 0, celtFrame.StartBand)
+		assert.Equal(t, 21, celtFrame.EndBand)
+		assert.Equal(t, 2, celtFrame.Channels)
+		require.Len(t, celtFrame.CoarseEnergy, 2)
+		require.Len(t, celtFrame.FineEnergy, 2)
+		assert.Len(t, celtFrame.CoarseEnergy[1], 21)
+		assert.Len(t, celtFrame.Pulses, 21)
+		assert.Len(t, celtFrame.TFResolution, 21)
+
+		pulses := 0
+		for band := range celtFrame.EndBand {
+			pulses += celtFrame.Pulses[band]
+			if celtFrame.FineBits[band] > 0 {
+				assert.NotEqual(t, celtFrame.CoarseEnergy[0][band], celtFrame.FineEnergy[0][band])
+			}
+		}
+		assert.Positive(t, pulses)
+
+		// The symbol groups add up to the whole frame, less the padding the
+		// range coder rounds up to.
+		bits := celtFrame.SymbolBits
+		total := bits.Header + bits.CoarseEnergy + bits.Allocation + bits.FineEnergy +
+			bits.Residual + bits.AntiCollapse + bits.FinalFineEnergy
+		assert.Positive(t, bits.CoarseEnergy)
+		assert.Positive(t, bits.Residual)
+		assert.LessOrEqual(t, total, 8*8*(len(packet)-1))
+		assert.Greater(t, total, 8*8*(len(packet)-2))
+	}
+}
+
+func TestAnalyzeHybrid(t *testing.T) {
+	// Any payload decodes to some hybrid frame.
+	packet := make([]byte, 80)
+	packet[0] = byte(15<<3) | byte(frameCodeOneFrame)
+	seed := uint32(1)
+	for i := range packet[1:] {
+		seed = seed*1664525 + 1013904223
+		packet[i+1] = byte(seed >> 24)
+	}
+
+	report, err := Analyze(packet)
+	require.NoError(t, err)
+	assert.Equal(t, ModeHybrid, report.Mode)
+	assert.Equal(t, BandwidthFullband, report.Bandwidth)
+	require.Len(t, report.Frames, 1)
+	assert.Len(t, report.Frames[0].SILK, 1)
+	require.NotNil(t, report.Frames[0].CELT)
+	assert.Equal(t, 17, report.Frames[0].CELT.StartBand)
+	assert.Equal(t, 21, report.Frames[0].CELT.EndBand)
+}
+
+func TestAnalyzeDoesNotChangeDecoding(t *testing.T) {
+	packets := testLoudStereoPackets(t)
+	decoder, err := NewDecoderWithOutput(48000, 2)
+	require.NoError(t, err)
+	analyzer := NewAnalyzer()
+	want := make([]float32, 2*encoderTestFrameSampleCount)
+	for _, packet := range packets {
+		_, err = decoder.DecodeToFloat32(packet, want)
+		require.NoError(t, err)
+		_, err = analyzer.Analyze(packet)
+		require.NoError(t, err)
+		assert.Equal(t, want, analyzer.pcm[:len(want)])
+	}
+}
+
+func TestAnalyzeInvalidPacket(t *testing.T) {
+	_, err := Analyze(nil)
+	assert.ErrorIs(t, err, errTooShortForTableOfContentsHeader)
+
+	_, err = Analyze([]byte{byte(frameCodeArbitraryFrames)})
+	assert.Error(t, err)
+}
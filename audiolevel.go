@@ -0,0 +1,85 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package opus
+
+import "math"
+
+// maxAudioLevel is the RFC 6464 audio level of digital silence, -127 dBov.
+const maxAudioLevel = 127
+
+// AudioLevel is the audio level of a packet as the client-to-mixer RTP header
+// extension of RFC 6464 carries it.
+type AudioLevel struct {
+	// Level is the level of the whole packet in -dBov, from 0 for a full
+	// scale square wave down to 127 for silence.
+	Level uint8
+	// Voice is the V flag: set when the packet is believed to hold speech.
+	Voice bool
+}
+
+// newAudioLevel returns the RFC 6464 level of audio with the given mean square,
+// at full scale 1. RFC 6464 Appendix A averages the power over every sample of
+// the packet, not the level of its frames or channels.
+func newAudioLevel(meanSquare float64, voice bool) AudioLevel {
+	level := AudioLevel{Level: maxAudioLevel, Voice: voice}
+	if meanSquare > 0 {
+		dBov := math.Round(-10 * math.Log10(meanSquare))
+		level.Level = uint8(min(maxAudioLevel, max(0, dBov)))
+	}
+
+	return level
+}
+
+func meanSquareFloat32(pcm []float32) float64 {
+	if len(pcm) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, sample := range pcm {
+		sum += float64(sample) * float64(sample)
+	}
+
+	return sum / float64(len(pcm))
+}
+
+func meanSquareInt16(pcm []int16) float64 {
+	if len(pcm) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, sample := range pcm {
+		sum += float64(sample) * float64(sample)
+	}
+
+	return sum / float64(len(pcm)) / (32768 * 32768)
+}
+
+// AudioLevel returns the RFC 6464 audio level of the frame encoded last,
+// measured on the input. Voice is the SILK encoder's voice activity decision
+// for EncodeSILK, and false for CELT packets, which are coded without one; a
+// stream of them should signal vad=off.
+func (e *Encoder) AudioLevel() AudioLevel {
+	return e.audioLevel
+}
+
+// AudioLevel returns the RFC 6464 audio level of pcm, the audio decoded from
+// the packet decoded last, matching Encoder.AudioLevel. Voice is set when that
+// packet had SILK frames and the encoder flagged any of them as active speech.
+func (d *Decoder) AudioLevel(pcm []float32) AudioLevel {
+	return newAudioLevel(meanSquareFloat32(pcm), d.voiceActivity())
+}
+
+// AudioLevelInt16 is AudioLevel for 16-bit PCM.
+func (d *Decoder) AudioLevelInt16(pcm []int16) AudioLevel {
+	return newAudioLevel(meanSquareInt16(pcm), d.voiceActivity())
+}
+
+func (d *Decoder) voiceActivity() bool {
+	switch d.previousMode {
+	case configurationModeSilkOnly, configurationModeHybrid:
+		return d.silkDecoder.VoiceActivity()
+	default:
+		return false
+	}
+}
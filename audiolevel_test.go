@@ -0,0 +1,105 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package opus
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAudioLevel(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		pcm   []float32
+		level uint8
+	}{
+		{"square wave", []float32{1, -1, 1, -1}, 0},
+		{"clipping", []float32{2, -2}, 0},
+		{"sine", testSine(1, 480), 3},
+		{"-20 dBFS sine", testSine(0.1, 480), 23},
+		{"quiet", []float32{1e-7, -1e-7}, 127},
+		{"silence", make([]float32, 480), 127},
+		{"empty", nil, 127},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.level, newAudioLevel(meanSquareFloat32(test.pcm), false).Level)
+		})
+	}
+
+	assert.Equal(t, uint8(0), newAudioLevel(meanSquareInt16([]int16{-32768, -32768}), false).Level)
+}
+
+func TestAudioLevelSILK(t *testing.T) {
+	encoder, err := NewEncoder()
+	require.NoError(t, err)
+	decoder, err := NewDecoderWithOutput(16000, 1)
+	require.NoError(t, err)
+
+	random := rand.New(rand.NewSource(1)) //nolint:gosec
+	pcm := make([]int16, 320)
+	decoded := make([]int16, 320)
+	for frame := range 10 {
+		amplitude := 3000.0
+		if frame >= 5 {
+			amplitude = 0
+		}
+		for i := range pcm {
+			pcm[i] = int16(amplitude * random.NormFloat64())
+		}
+		packet := make([]byte, maxOpusFrameSize)
+		n, err := encoder.EncodeSILK(pcm, BandwidthWideband, packet)
+		require.NoError(t, err)
+		sent := encoder.AudioLevel()
+		_, err = decoder.DecodeToInt16(packet[:n], decoded)
+		require.NoError(t, err)
+		received := decoder.AudioLevelInt16(decoded)
+
+		assert.Equal(t, sent.Voice, received.Voice)
+		switch {
+		case frame >= 2 && frame < 5:
+			assert.True(t, sent.Voice)
+			assert.InDelta(t, 21, sent.Level, 1)
+			assert.InDelta(t, sent.Level, received.Level, 3)
+		case frame >= 7:
+			assert.False(t, sent.Voice)
+			assert.Equal(t, uint8(maxAudioLevel), sent.Level)
+		}
+	}
+}
+
+func TestAudioLevelCELT(t *testing.T) {
+	encoder, err := NewEncoder()
+	require.NoError(t, err)
+	decoder, err := NewDecoderWithOutput(48000, 1)
+	require.NoError(t, err)
+
+	pcm := testSine(0.1, encoderTestFrameSampleCount)
+	decoded := make([]float32, encoderTestFrameSampleCount)
+	for range 5 {
+		packet := make([]byte, maxOpusFrameSize)
+		n, err := encoder.EncodeFloat32(pcm, packet)
+		require.NoError(t, err)
+		_, err = decoder.DecodeToFloat32(packet[:n], decoded)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, AudioLevel{Level: 23}, encoder.AudioLevel())
+	received := decoder.AudioLevel(decoded)
+	assert.False(t, received.Voice)
+	assert.InDelta(t, 23, received.Level, 1)
+}
+
+// testSine returns a 1 kHz sine wave at 48 kHz.
+func testSine(amplitude float64, sampleCount int) []float32 {
+	pcm := make([]float32, sampleCount)
+	for i := range pcm {
+		pcm[i] = float32(amplitude * math.Sin(2*math.Pi*1000*float64(i)/48000))
+	}
+
+	return pcm
+}
@@ -0,0 +1,98 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"unicode"
+)
+
+var (
+	errInvalidLossRate    = errors.New("packet loss must be from 0 to 100 percent")
+	errInvalidLossPattern = errors.New("loss pattern must be a sequence of 0 and 1")
+	errConflictingLoss    = errors.New("-packet-loss and -loss-pattern are mutually exclusive")
+)
+
+// lossModel decides which packets of the stream to drop.
+type lossModel interface {
+	// lost reports whether the next packet is lost.
+	lost() bool
+}
+
+// noLoss delivers every packet.
+type noLoss struct{}
+
+func (noLoss) lost() bool { return false }
+
+// randomLoss drops each packet independently with the same probability.
+type randomLoss struct {
+	rate   float64
+	random *rand.Rand
+}
+
+func (r *randomLoss) lost() bool {
+	return r.random.Float64()*100 < r.rate
+}
+
+// patternLoss drops packets following a fixed pattern, repeated once it runs
+// out.
+type patternLoss struct {
+	pattern []bool
+	next    int
+}
+
+func (p *patternLoss) lost() bool {
+	lost := p.pattern[p.next]
+	p.next = (p.next + 1) % len(p.pattern)
+
+	return lost
+}
+
+// newLossModel returns the loss model the command line selects.
+func newLossModel(opts *options) (lossModel, error) {
+	switch {
+	case opts.lossPattern != "" && opts.packetLoss != 0:
+		return nil, errConflictingLoss
+	case opts.lossPattern != "":
+		return readLossPattern(opts.lossPattern)
+	case opts.packetLoss < 0 || opts.packetLoss > 100:
+		return nil, fmt.Errorf("%w: %v", errInvalidLossRate, opts.packetLoss)
+	case opts.packetLoss > 0:
+		return &randomLoss{
+			rate:   opts.packetLoss,
+			random: rand.New(rand.NewSource(opts.seed)), // #nosec G404 -- a reproducible simulation.
+		}, nil
+	default:
+		return noLoss{}, nil
+	}
+}
+
+// readLossPattern reads a loss pattern file: one character per packet, 1 for
+// lost and 0 for received. Whitespace is ignored, so a pattern may be split
+// across lines.
+func readLossPattern(name string) (*patternLoss, error) {
+	data, err := os.ReadFile(name) // #nosec G304 -- the user names the pattern file.
+	if err != nil {
+		return nil, err
+	}
+
+	pattern := &patternLoss{}
+	for _, char := range string(data) {
+		switch {
+		case char == '0' || char == '1':
+			pattern.pattern = append(pattern.pattern, char == '1')
+		case unicode.IsSpace(char):
+		default:
+			return nil, fmt.Errorf("%w: unexpected %q", errInvalidLossPattern, char)
+		}
+	}
+	if len(pattern.pattern) == 0 {
+		return nil, fmt.Errorf("%w: %s is empty", errInvalidLossPattern, name)
+	}
+
+	return pattern, nil
+}
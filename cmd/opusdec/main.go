@@ -0,0 +1,398 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+// Command opusdec decodes an Ogg Opus file into a WAV file.
+//
+//	opusdec [flags] input.opus output.wav
+//
+// Either file may be "-" for standard input or output. The audio is decoded
+// at the rate the stream was encoded from, or the one -rate asks for, with
+// the stream's pre-skip removed and its output gain, plus any -gain, applied.
+//
+// -packet-loss and -loss-pattern drop packets before they reach the decoder,
+// to audition packet loss concealment offline. A lost packet is recovered from
+// the in-band FEC data of the packet after it when that packet arrived, and
+// concealed otherwise.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"time"
+
+	"github.com/pion/opus"
+	"github.com/pion/opus/internal/wav"
+	"github.com/pion/opus/pkg/oggreader"
+)
+
+const (
+	// opusSampleRate is the rate granule positions and the pre-skip count at.
+	opusSampleRate = 48000
+	// maxPacketDuration is the longest packet RFC 6716 allows, in samples at
+	// 48 kHz.
+	maxPacketDuration = 5760
+)
+
+var (
+	errUsage              = errors.New("usage: opusdec [flags] input output")
+	errUnsupportedMapping = errors.New("only channel mapping family 0 (mono and stereo) is supported")
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "opusdec:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+// options holds the command line.
+type options struct {
+	rate     int
+	channels int
+	gain     float64
+	float    bool
+
+	packetLoss  float64
+	lossPattern string
+	seed        int64
+	noFEC       bool
+
+	quiet bool
+}
+
+func parseFlags(args []string, stderr io.Writer) (*options, []string, error) {
+	opts := &options{}
+	flags := flag.NewFlagSet("opusdec", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, errUsage)
+		flags.PrintDefaults()
+	}
+
+	flags.IntVar(&opts.rate, "rate", 0, "output sample rate in Hz (default the rate of the original input)")
+	flags.IntVar(&opts.channels, "channels", 0, "output channel count, 1 or 2 (default that of the stream)")
+	flags.Float64Var(&opts.gain, "gain", 0, "gain in dB, on top of the stream's output gain")
+	flags.BoolVar(&opts.float, "float", false, "write 32-bit float samples instead of 16-bit integers")
+	flags.Float64Var(&opts.packetLoss, "packet-loss", 0, "simulate random packet loss, in percent")
+	flags.StringVar(&opts.lossPattern, "loss-pattern", "",
+		"simulate packet loss following a file of 0 (received) and 1 (lost), one per packet, repeated")
+	flags.Int64Var(&opts.seed, "seed", 1, "random seed for -packet-loss")
+	flags.BoolVar(&opts.noFEC, "no-fec", false, "conceal lost packets without using in-band FEC")
+	flags.BoolVar(&opts.quiet, "quiet", false, "do not print statistics")
+
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, err
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+
+		return nil, nil, errUsage
+	}
+
+	return opts, flags.Args(), nil
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	opts, files, err := parseFlags(args, stderr)
+	if err != nil {
+		return err
+	}
+	loss, err := newLossModel(opts)
+	if err != nil {
+		return err
+	}
+
+	in, closeIn, err := openInput(files[0], stdin)
+	if err != nil {
+		return err
+	}
+	defer closeIn()
+	reader, header, err := oggreader.NewWith(in)
+	if err != nil {
+		return err
+	}
+	if header.ChannelMap != 0 {
+		return fmt.Errorf("%w: family %d", errUnsupportedMapping, header.ChannelMap)
+	}
+
+	dec, err := newDecoding(opts, header)
+	if err != nil {
+		return err
+	}
+
+	out, closeOut, err := createOutput(files[1], stdout)
+	if err != nil {
+		return err
+	}
+	err = dec.decodeAll(reader, loss, out)
+	if closeErr := closeOut(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if !opts.quiet {
+		dec.stats.print(stderr, dec.rate)
+	}
+
+	return nil
+}
+
+func openInput(name string, stdin io.Reader) (io.Reader, func(), error) {
+	if name == "-" {
+		return stdin, func() {}, nil
+	}
+	file, err := os.Open(name) // #nosec G304 -- the user names the file to decode.
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return file, func() { _ = file.Close() }, nil
+}
+
+func createOutput(name string, stdout io.Writer) (io.Writer, func() error, error) {
+	if name == "-" {
+		return stdout, func() error { return nil }, nil
+	}
+	file, err := os.Create(name) // #nosec G304 -- the user names the file to write.
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return file, file.Close, nil
+}
+
+// packet is one Opus packet read from the stream.
+type packet struct {
+	data []byte
+	// granule is the granule position of the page the packet ends on.
+	granule uint64
+	lost    bool
+}
+
+// decoding carries one stream through the decoder into the WAV file.
+type decoding struct {
+	decoder  opus.Decoder
+	wav      *wav.Writer
+	rate     int
+	channels int
+	gain     float32
+	fec      bool
+	float    bool
+	preSkip  uint64
+
+	// skip is how many samples per channel are still to be dropped from the
+	// start: the pre-skip and the delay of the decoder's resampler.
+	skip int
+	// limit is how many samples per channel the file holds once the end of
+	// the stream is known, and -1 before.
+	limit   int64
+	written int64
+
+	pcm   []float32
+	plc   []float32
+	stats statistics
+}
+
+func newDecoding(opts *options, header *oggreader.OggHeader) (*decoding, error) {
+	channels := opts.channels
+	if channels == 0 {
+		channels = int(header.Channels)
+	}
+	rate := opts.rate
+	if rate == 0 {
+		rate = int(header.SampleRate)
+		// Streams from rates the decoder cannot produce, or that do not say,
+		// decode at 48 kHz, as RFC 7845 Section 5.1 suggests.
+		if _, err := opus.NewDecoderWithOutput(rate, channels); err != nil {
+			rate = opusSampleRate
+		}
+	}
+	decoder, err := opus.NewDecoderWithOutput(rate, channels)
+	if err != nil {
+		return nil, fmt.Errorf("%d Hz, %d channels: %w", rate, channels, err)
+	}
+
+	// The output gain is a Q7.8 number of dB (RFC 7845 Section 5.1).
+	gain := float64(int16(header.OutputGain))/256 + opts.gain //nolint:gosec // G115
+
+	return &decoding{
+		decoder:  decoder,
+		rate:     rate,
+		channels: channels,
+		gain:     float32(math.Pow(10, gain/20)),
+		fec:      !opts.noFEC,
+		float:    opts.float,
+		preSkip:  uint64(header.PreSkip),
+		skip:     (int(header.PreSkip)*rate+opusSampleRate/2)/opusSampleRate + decoder.ResampleDelay(),
+		limit:    -1,
+		pcm:      make([]float32, maxPacketDuration*rate/opusSampleRate*channels),
+		plc:      make([]float32, rate/50*channels),
+	}, nil
+}
+
+// decodeAll decodes every packet of reader into a WAV file on out. Each packet
+// is held back until the one after it has been read, so that a lost packet can
+// be recovered from the FEC data of the next, and the last packet, whose page
+// sets where the stream ends, is known as such.
+func (d *decoding) decodeAll(reader *oggreader.OggReader, loss lossModel, out io.Writer) error {
+	var err error
+	if d.wav, err = wav.NewWriter(out, d.rate, d.channels, d.float); err != nil {
+		return err
+	}
+
+	var pending *packet
+	for {
+		data, pageHeader, err := reader.ParseNextPacket()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if bytes.HasPrefix(data, []byte("OpusTags")) {
+			continue
+		}
+
+		next := &packet{data: data, granule: pageHeader.GranulePosition, lost: loss.lost()}
+		if pending != nil {
+			if err = d.decodePacket(pending, next); err != nil {
+				return err
+			}
+		}
+		pending = next
+	}
+
+	if pending != nil {
+		d.setEnd(pending.granule)
+		if err = d.decodePacket(pending, nil); err != nil {
+			return err
+		}
+		if err = d.flush(); err != nil {
+			return err
+		}
+	}
+
+	return d.wav.Close()
+}
+
+// setEnd limits the output to the samples before granule, the granule
+// position of the stream's last page (RFC 7845 Section 4).
+func (d *decoding) setEnd(granule uint64) {
+	samples := uint64(0)
+	if granule > d.preSkip {
+		samples = granule - d.preSkip
+	}
+	rate := uint64(d.rate)                                                // #nosec G115
+	d.limit = int64((samples*rate + opusSampleRate - 1) / opusSampleRate) // #nosec G115
+}
+
+// decodePacket decodes p, or recovers it from next, the packet after it, when
+// it was lost. next is nil for the last packet.
+func (d *decoding) decodePacket(p, next *packet) error {
+	d.stats.packets++
+	if !p.lost {
+		n, err := d.decoder.DecodeToFloat32(p.data, d.pcm)
+		if err != nil {
+			return err
+		}
+		d.stats.samples += n
+
+		return d.write(d.pcm[:n*d.channels])
+	}
+
+	// The lost packet is still at hand, so the gap is as long as it was.
+	samples, err := opus.PacketSampleCount(p.data, d.rate)
+	if err != nil {
+		return err
+	}
+	d.stats.samples += samples
+	d.stats.lost++
+	if d.fec && next != nil && !next.lost && samples%(d.rate/100) == 0 {
+		if hasLBRR, _ := opus.PacketHasLBRR(next.data); hasLBRR {
+			d.stats.recovered++
+			out := d.pcm[:samples*d.channels]
+			if err = d.decoder.DecodeFECToFloat32(next.data, out); err != nil {
+				return err
+			}
+
+			return d.write(out)
+		}
+	}
+
+	return d.conceal(samples)
+}
+
+// conceal fills a gap of samples per channel with packet loss concealment.
+// DecodePLC works in 20 ms steps, so a gap that is not a whole number of them
+// ends with part of one.
+func (d *decoding) conceal(samples int) error {
+	step := d.rate / 50
+	for samples > 0 {
+		if err := d.decoder.DecodePLCToFloat32(d.plc); err != nil {
+			return err
+		}
+		n := min(samples, step)
+		if err := d.write(d.plc[:n*d.channels]); err != nil {
+			return err
+		}
+		samples -= n
+	}
+
+	return nil
+}
+
+// flush pushes the audio the decoder's resampler still holds out with one
+// step of concealment, of which only the resampler's delay is kept.
+func (d *decoding) flush() error {
+	if d.decoder.ResampleDelay() == 0 || d.written >= d.limit {
+		return nil
+	}
+
+	return d.conceal(d.rate / 50)
+}
+
+// write applies the gain to pcm and writes what is left of it once the
+// pre-skip is dropped and the end of the stream is cut.
+func (d *decoding) write(pcm []float32) error {
+	skip := min(d.skip, len(pcm)/d.channels)
+	d.skip -= skip
+	pcm = pcm[skip*d.channels:]
+	if d.limit >= 0 {
+		pcm = pcm[:min(int64(len(pcm)/d.channels), max(0, d.limit-d.written))*int64(d.channels)]
+	}
+	if d.gain != 1 {
+		for i := range pcm {
+			pcm[i] *= d.gain
+		}
+	}
+	d.written += int64(len(pcm) / d.channels)
+
+	return d.wav.Write(pcm)
+}
+
+// statistics summarizes the decoding of a stream.
+type statistics struct {
+	packets int
+	samples int
+	// lost counts the packets the loss simulation dropped, and recovered
+	// those of them the next packet's FEC data stood in for.
+	lost      int
+	recovered int
+}
+
+func (s *statistics) print(w io.Writer, rate int) {
+	duration := time.Duration(s.samples) * time.Second / time.Duration(rate)
+	fmt.Fprintf(w, "Decoded %d packets, %v of audio at %d Hz\n", s.packets, duration, rate)
+	if s.lost > 0 {
+		fmt.Fprintf(w, "Lost %d packets (%.1f%%): %d recovered with FEC, %d concealed\n",
+			s.lost, 100*float64(s.lost)/float64(s.packets), s.recovered, s.lost-s.recovered)
+	}
+}
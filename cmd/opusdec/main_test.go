@@ -0,0 +1,262 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pion/opus"
+	"github.com/pion/opus/internal/wav"
+	"github.com/pion/opus/pkg/oggreader"
+	"github.com/pion/opus/pkg/oggwriter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeOgg writes packets of 20 ms each as an Ogg Opus file, ending trim
+// samples before the end of the last packet.
+func writeOgg(t *testing.T, header oggreader.OggHeader, packets [][]byte, trim uint64) []byte {
+	t.Helper()
+
+	var out bytes.Buffer
+	writer, err := oggwriter.NewWith(&out, header)
+	require.NoError(t, err)
+	granule := uint64(0)
+	for i, packet := range packets {
+		granule += 960
+		if i == len(packets)-1 {
+			granule -= trim
+		}
+		require.NoError(t, writer.WritePacket(packet, granule))
+	}
+	require.NoError(t, writer.Close())
+
+	return out.Bytes()
+}
+
+// celtStream encodes count packets of a 1 kHz sine at amplitude 0.25.
+func celtStream(t *testing.T, count int, header oggreader.OggHeader, trim uint64) []byte {
+	t.Helper()
+
+	encoder, err := opus.NewEncoder()
+	require.NoError(t, err)
+	pcm := make([]float32, 960)
+	packets := make([][]byte, 0, count)
+	for frame := range count {
+		for i := range pcm {
+			pcm[i] = float32(0.25 * math.Sin(2*math.Pi*1000*float64(frame*960+i)/48000))
+		}
+		packet := make([]byte, 1276)
+		n, err := encoder.EncodeFloat32(pcm, packet)
+		require.NoError(t, err)
+		packets = append(packets, packet[:n])
+	}
+	header.PreSkip = uint16(encoder.Lookahead()) //nolint:gosec // G115
+
+	return writeOgg(t, header, packets, trim)
+}
+
+// silkStream encodes count packets of a voiced tone at 16 kHz, with in-band
+// FEC for 20% loss.
+func silkStream(t *testing.T, count int) []byte {
+	t.Helper()
+
+	encoder, err := opus.NewEncoder(opus.WithInbandFEC(true))
+	require.NoError(t, err)
+	require.NoError(t, encoder.SetLossRate(20))
+	pcm := make([]int16, 320)
+	packets := make([][]byte, 0, count)
+	for frame := range count {
+		for i := range pcm {
+			phase := 2 * math.Pi * 140 * float64(frame*320+i) / 16000
+			pcm[i] = int16(6000*math.Sin(phase) + 3000*math.Sin(2*phase))
+		}
+		packet := make([]byte, 1276)
+		n, err := encoder.EncodeSILK(pcm, opus.BandwidthWideband, packet)
+		require.NoError(t, err)
+		packets = append(packets, packet[:n])
+	}
+
+	return writeOgg(t, oggreader.OggHeader{Channels: 1, SampleRate: 16000}, packets, 0)
+}
+
+// readWAV parses a WAV file written by wav.Writer into float32 samples.
+func readWAV(t *testing.T, file []byte) (tag uint16, sampleRate, channels int, dataSize uint32, pcm []float32) {
+	t.Helper()
+
+	require.GreaterOrEqual(t, len(file), wav.HeaderSize)
+	assert.Equal(t, "RIFF", string(file[0:4]))
+	assert.Equal(t, "WAVEfmt ", string(file[8:16]))
+	assert.Equal(t, "data", string(file[36:40]))
+	tag = binary.LittleEndian.Uint16(file[20:])
+	channels = int(binary.LittleEndian.Uint16(file[22:]))
+	sampleRate = int(binary.LittleEndian.Uint32(file[24:]))
+	dataSize = binary.LittleEndian.Uint32(file[40:])
+
+	data := file[wav.HeaderSize:]
+	if tag == wav.FormatFloat {
+		for i := 0; i+4 <= len(data); i += 4 {
+			pcm = append(pcm, math.Float32frombits(binary.LittleEndian.Uint32(data[i:])))
+		}
+	} else {
+		for i := 0; i+2 <= len(data); i += 2 {
+			pcm = append(pcm, float32(int16(binary.LittleEndian.Uint16(data[i:])))/(1<<15)) //nolint:gosec // G115
+		}
+	}
+
+	return tag, sampleRate, channels, dataSize, pcm
+}
+
+func rms(pcm []float32) float64 {
+	var sum float64
+	for _, sample := range pcm {
+		sum += float64(sample) * float64(sample)
+	}
+
+	return math.Sqrt(sum / float64(len(pcm)))
+}
+
+func TestDecode(t *testing.T) {
+	stream := celtStream(t, 10, oggreader.OggHeader{Channels: 1, SampleRate: 48000}, 500)
+	dir := t.TempDir()
+	input := filepath.Join(dir, "in.opus")
+	require.NoError(t, os.WriteFile(input, stream, 0o600))
+
+	for _, test := range []struct {
+		name       string
+		args       []string
+		tag        uint16
+		sampleRate int
+		channels   int
+	}{
+		{"original rate", nil, wav.FormatPCM, 48000, 1},
+		{"resampled stereo float", []string{"-rate", "44100", "-channels", "2", "-float"}, wav.FormatFloat, 44100, 2},
+		{"native 16 kHz", []string{"-rate", "16000"}, wav.FormatPCM, 16000, 1},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			output := filepath.Join(dir, "out.wav")
+			var stderr bytes.Buffer
+			require.NoError(t, run(append(test.args, input, output), nil, nil, &stderr))
+			assert.Contains(t, stderr.String(), "Decoded 10 packets")
+
+			file, err := os.ReadFile(output) // #nosec G304
+			require.NoError(t, err)
+			tag, sampleRate, channels, dataSize, pcm := readWAV(t, file)
+			assert.Equal(t, test.tag, tag)
+			assert.Equal(t, test.sampleRate, sampleRate)
+			assert.Equal(t, test.channels, channels)
+			assert.Equal(t, uint32(len(file)-wav.HeaderSize), dataSize)
+
+			// The pre-skip, the encoder's 2.5 ms lookahead, and the trimmed end
+			// are gone.
+			wantSamples := ((10*960-500-120)*test.sampleRate + 47999) / 48000
+			require.Len(t, pcm, wantSamples*test.channels)
+			assert.InDelta(t, 0.25/math.Sqrt2, rms(pcm[len(pcm)/4:]), 0.02)
+			// The sine starts at 0 right where the pre-skip ends.
+			assert.InDelta(t, 0, pcm[0], 0.03)
+		})
+	}
+}
+
+func TestDecodeGain(t *testing.T) {
+	header := oggreader.OggHeader{Channels: 1, SampleRate: 48000}
+	decode := func(stream []byte, args ...string) []float32 {
+		var stdout bytes.Buffer
+		require.NoError(t, run(append(args, "-quiet", "-float", "-", "-"), bytes.NewReader(stream), &stdout, io.Discard))
+		_, _, _, dataSize, pcm := readWAV(t, stdout.Bytes())
+		assert.Equal(t, uint32(wav.StreamingSize), dataSize, "standard output cannot be rewound")
+
+		return pcm
+	}
+
+	plain := decode(celtStream(t, 5, header, 0))
+	louder := decode(celtStream(t, 5, header, 0), "-gain", "6")
+	assert.InDelta(t, math.Pow(10, 6.0/20), rms(louder)/rms(plain), 1e-3)
+
+	// A +6 dB output gain in the header, undone by -gain.
+	header.OutputGain = 6 * 256
+	assert.InDelta(t, rms(louder), rms(decode(celtStream(t, 5, header, 0))), 1e-6)
+	assert.Equal(t, plain, decode(celtStream(t, 5, header, 0), "-gain", "-6"))
+}
+
+func TestDecodeLoss(t *testing.T) {
+	stream := silkStream(t, 10)
+	dir := t.TempDir()
+	pattern := filepath.Join(dir, "pattern")
+	// The fourth packet is lost alone, and the seventh and eighth together:
+	// the seventh has no FEC data to come from.
+	require.NoError(t, os.WriteFile(pattern, []byte("000 1\n00 11 00\n"), 0o600))
+
+	decode := func(args ...string) (string, []float32) {
+		var stdout, stderr bytes.Buffer
+		require.NoError(t, run(append(args, "-", "-"), bytes.NewReader(stream), &stdout, &stderr))
+		_, _, _, _, pcm := readWAV(t, stdout.Bytes())
+
+		return stderr.String(), pcm
+	}
+
+	clean, cleanPCM := decode()
+	assert.NotContains(t, clean, "Lost")
+	require.Len(t, cleanPCM, 10*320)
+
+	withFEC, fecPCM := decode("-loss-pattern", pattern)
+	assert.Contains(t, withFEC, "Lost 3 packets (30.0%): 2 recovered with FEC, 1 concealed")
+	require.Len(t, fecPCM, len(cleanPCM))
+	withoutFEC, plcPCM := decode("-loss-pattern", pattern, "-no-fec")
+	assert.Contains(t, withoutFEC, "Lost 3 packets (30.0%): 0 recovered with FEC, 3 concealed")
+	require.Len(t, plcPCM, len(cleanPCM))
+
+	// The FEC frame stands in for the fourth packet better than concealment.
+	lostFrame := cleanPCM[3*320 : 4*320]
+	errorRMS := func(pcm []float32) float64 {
+		diff := make([]float32, len(lostFrame))
+		for i := range diff {
+			diff[i] = pcm[3*320+i] - lostFrame[i]
+		}
+
+		return rms(diff)
+	}
+	assert.Less(t, errorRMS(fecPCM), errorRMS(plcPCM))
+
+	// Losing everything conceals from nothing, which is silence.
+	lostAll, silence := decode("-packet-loss", "100")
+	assert.Contains(t, lostAll, "Lost 10 packets (100.0%): 0 recovered with FEC, 10 concealed")
+	require.Len(t, silence, len(cleanPCM))
+	assert.Zero(t, rms(silence))
+}
+
+func TestDecodeErrors(t *testing.T) {
+	stream := celtStream(t, 2, oggreader.OggHeader{Channels: 1, SampleRate: 48000}, 0)
+	dir := t.TempDir()
+	badPattern := filepath.Join(dir, "bad")
+	require.NoError(t, os.WriteFile(badPattern, []byte("0101x"), 0o600))
+	emptyPattern := filepath.Join(dir, "empty")
+	require.NoError(t, os.WriteFile(emptyPattern, []byte("\n"), 0o600))
+
+	for _, test := range []struct {
+		name string
+		args []string
+		err  error
+	}{
+		{"missing output", []string{"-"}, errUsage},
+		{"loss rate", []string{"-packet-loss", "150", "-", "-"}, errInvalidLossRate},
+		{"both loss models", []string{"-packet-loss", "5", "-loss-pattern", badPattern, "-", "-"}, errConflictingLoss},
+		{"bad pattern", []string{"-loss-pattern", badPattern, "-", "-"}, errInvalidLossPattern},
+		{"empty pattern", []string{"-loss-pattern", emptyPattern, "-", "-"}, errInvalidLossPattern},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := run(test.args, bytes.NewReader(stream), io.Discard, io.Discard)
+			assert.ErrorIs(t, err, test.err)
+		})
+	}
+
+	err := run([]string{"-rate", "11025", "-", "-"}, bytes.NewReader(stream), io.Discard, io.Discard)
+	assert.Error(t, err)
+}
@@ -0,0 +1,234 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/pion/opus"
+)
+
+// WAVE format tags, from the fmt chunk.
+const (
+	wavFormatPCM        = 0x0001
+	wavFormatFloat      = 0x0003
+	wavFormatExtensible = 0xfffe
+)
+
+var (
+	errNotWAV            = errors.New("not a RIFF/WAVE file")
+	errNoDataChunk       = errors.New("WAV file has no data chunk")
+	errNoFormatChunk     = errors.New("WAV data chunk comes before its fmt chunk")
+	errUnsupportedWAV    = errors.New("unsupported WAV sample format")
+	errInvalidRawFormat  = errors.New("invalid raw sample format")
+	errInvalidInputRate  = errors.New("invalid input sample rate")
+	errInvalidInputCount = errors.New("invalid input channel count")
+)
+
+// pcmInput reads interleaved samples, converted to float32 at full scale 1.
+type pcmInput struct {
+	reader     *bufio.Reader
+	sampleRate int
+	channels   int
+	format     opus.SampleFormat
+	// remaining is how many bytes of samples are left to read, or -1 to read
+	// until the end of the input.
+	remaining int64
+	buffer    []byte
+}
+
+// newRawInput reads headerless samples of format.
+func newRawInput(r io.Reader, format string, sampleRate, channels int) (*pcmInput, error) {
+	sampleFormat, ok := rawFormats[format]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", errInvalidRawFormat, format)
+	}
+
+	return newPCMInput(bufio.NewReader(r), sampleFormat, sampleRate, channels, -1)
+}
+
+//nolint:gochecknoglobals
+var rawFormats = map[string]opus.SampleFormat{
+	"s16le": opus.SampleFormatS16LE,
+	"s24le": opus.SampleFormatS24LE,
+	"s32le": opus.SampleFormatS32LE,
+	"f32le": opus.SampleFormatF32LE,
+	"f64le": opus.SampleFormatF64LE,
+}
+
+// newWAVInput parses a RIFF/WAVE header up to the start of its samples. A
+// data chunk size of 0 or 0xffffffff, as written by tools streaming to a pipe,
+// reads until the end of the input.
+func newWAVInput(r io.Reader) (*pcmInput, error) {
+	reader := bufio.NewReader(r)
+	var header [12]byte
+	if _, err := io.ReadFull(reader, header[:]); err != nil {
+		return nil, fmt.Errorf("%w: %w", errNotWAV, err)
+	}
+	if string(header[0:4]) != "RIFF" || string(header[8:12]) != "WAVE" {
+		return nil, errNotWAV
+	}
+
+	var format opus.SampleFormat
+	sampleRate, channels := 0, 0
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(reader, chunk[:]); err != nil {
+			return nil, fmt.Errorf("%w: %w", errNoDataChunk, err)
+		}
+		size := int64(binary.LittleEndian.Uint32(chunk[4:]))
+		switch string(chunk[:4]) {
+		case "fmt ":
+			body := make([]byte, size+size%2)
+			if _, err := io.ReadFull(reader, body); err != nil {
+				return nil, err
+			}
+			var err error
+			if format, sampleRate, channels, err = parseWAVFormat(body[:size]); err != nil {
+				return nil, err
+			}
+		case "data":
+			if channels == 0 {
+				return nil, errNoFormatChunk
+			}
+			if size == 0 || size == math.MaxUint32 {
+				size = -1
+			}
+
+			return newPCMInput(reader, format, sampleRate, channels, size)
+		default:
+			if _, err := reader.Discard(int(size + size%2)); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+func parseWAVFormat(body []byte) (format opus.SampleFormat, sampleRate, channels int, err error) {
+	if len(body) < 16 {
+		return 0, 0, 0, fmt.Errorf("%w: fmt chunk of %d bytes", errUnsupportedWAV, len(body))
+	}
+	tag := binary.LittleEndian.Uint16(body[0:])
+	channels = int(binary.LittleEndian.Uint16(body[2:]))
+	sampleRate = int(binary.LittleEndian.Uint32(body[4:]))
+	bits := binary.LittleEndian.Uint16(body[14:])
+	// WAVE_FORMAT_EXTENSIBLE keeps the real tag at the start of its
+	// subformat GUID.
+	if tag == wavFormatExtensible && len(body) >= 26 {
+		tag = binary.LittleEndian.Uint16(body[24:])
+	}
+
+	switch {
+	case tag == wavFormatPCM && bits == 16:
+		format = opus.SampleFormatS16LE
+	case tag == wavFormatPCM && bits == 24:
+		format = opus.SampleFormatS24LE
+	case tag == wavFormatPCM && bits == 32:
+		format = opus.SampleFormatS32LE
+	case tag == wavFormatFloat && bits == 32:
+		format = opus.SampleFormatF32LE
+	case tag == wavFormatFloat && bits == 64:
+		format = opus.SampleFormatF64LE
+	default:
+		return 0, 0, 0, fmt.Errorf("%w: format tag %#x with %d bits", errUnsupportedWAV, tag, bits)
+	}
+
+	return format, sampleRate, channels, nil
+}
+
+func newPCMInput(
+	reader *bufio.Reader,
+	format opus.SampleFormat,
+	sampleRate, channels int,
+	remaining int64,
+) (*pcmInput, error) {
+	if sampleRate < 8000 || sampleRate > 192000 {
+		return nil, fmt.Errorf("%w: %d", errInvalidInputRate, sampleRate)
+	}
+	if channels < 1 || channels > 2 {
+		return nil, fmt.Errorf("%w: %d, only mono and stereo are supported", errInvalidInputCount, channels)
+	}
+
+	return &pcmInput{
+		reader:     reader,
+		sampleRate: sampleRate,
+		channels:   channels,
+		format:     format,
+		remaining:  remaining,
+	}, nil
+}
+
+// Read fills out with whole interleaved samples and returns how many values it
+// stored, or io.EOF once the input is exhausted. A final partial sample is
+// dropped.
+func (p *pcmInput) Read(out []float32) (int, error) {
+	size := bytesPerSample(p.format)
+	count := len(out) / p.channels * p.channels
+	want := int64(count * size)
+	if p.remaining >= 0 {
+		want = min(want, p.remaining/int64(size*p.channels)*int64(size*p.channels))
+	}
+	if want == 0 {
+		return 0, io.EOF
+	}
+	if cap(p.buffer) < int(want) {
+		p.buffer = make([]byte, want)
+	}
+
+	n, err := io.ReadFull(p.reader, p.buffer[:want])
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		err = nil
+	}
+	n = n / (size * p.channels) * size * p.channels
+	if n == 0 {
+		return 0, io.EOF
+	}
+	if p.remaining >= 0 {
+		p.remaining -= int64(n)
+	}
+	for i := range n / size {
+		out[i] = sampleValue(p.format, p.buffer[i*size:])
+	}
+
+	return n / size, err
+}
+
+func bytesPerSample(format opus.SampleFormat) int {
+	switch format {
+	case opus.SampleFormatS16LE:
+		return 2
+	case opus.SampleFormatS24LE:
+		return 3
+	case opus.SampleFormatS32LE, opus.SampleFormatF32LE:
+		return 4
+	case opus.SampleFormatF64LE:
+		return 8
+	default:
+		return 0
+	}
+}
+
+func sampleValue(format opus.SampleFormat, in []byte) float32 {
+	switch format {
+	case opus.SampleFormatS16LE:
+		return float32(int16(binary.LittleEndian.Uint16(in))) / (1 << 15) //nolint:gosec // G115
+	case opus.SampleFormatS24LE:
+		// Shift the 24 bits to the top of an int32 to sign-extend them.
+		value := int32(uint32(in[0])<<8|uint32(in[1])<<16|uint32(in[2])<<24) >> 8 //nolint:gosec // G115
+		return float32(value) / (1 << 23)
+	case opus.SampleFormatS32LE:
+		return float32(float64(int32(binary.LittleEndian.Uint32(in))) / (1 << 31)) //nolint:gosec // G115
+	case opus.SampleFormatF32LE:
+		return math.Float32frombits(binary.LittleEndian.Uint32(in))
+	case opus.SampleFormatF64LE:
+		return float32(math.Float64frombits(binary.LittleEndian.Uint64(in)))
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,483 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+// Command opusenc encodes WAV or raw PCM into an Ogg Opus file.
+//
+//	opusenc [flags] input.wav output.opus
+//
+// Either file may be "-" for standard input or output. WAV input may hold 16,
+// 24 or 32-bit integer or 32 or 64-bit float samples; raw input is described
+// with -raw-format, -raw-rate and -raw-chan. Audio at a rate other than 48 kHz
+// is resampled first. When it is done, opusenc prints the average bitrate and
+// how many packets used each mode and bandwidth.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pion/opus"
+	"github.com/pion/opus/internal/encoderwriter"
+	"github.com/pion/opus/pkg/oggreader"
+	"github.com/pion/opus/pkg/oggwriter"
+	"github.com/pion/opus/pkg/resample"
+)
+
+const (
+	// encoderSampleRate is the only rate the encoder takes.
+	encoderSampleRate = 48000
+	// readSamples is how many samples per channel are read at a time.
+	readSamples = 4096
+)
+
+var (
+	errUsage                = errors.New("usage: opusenc [flags] input output")
+	errInvalidBandwidth     = errors.New("invalid bandwidth")
+	errInvalidApp           = errors.New("invalid application")
+	errInvalidFrameSize     = errors.New("invalid frame size, the encoder only produces 20 ms frames")
+	errInvalidComment       = errors.New("comment must be of the form TAG=value")
+	errConflictingRateModes = errors.New("-hard-cbr and -cvbr are mutually exclusive")
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "opusenc:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+// options holds the command line.
+type options struct {
+	bitrate      float64
+	complexity   int
+	hardCBR      bool
+	cvbr         bool
+	bandwidth    string
+	maxBandwidth string
+	application  string
+	loss         int
+	frameSize    float64
+
+	raw       bool
+	rawFormat string
+	rawRate   int
+	rawChan   int
+
+	comments []string
+	serial   int64
+	quiet    bool
+}
+
+func parseFlags(args []string, stderr io.Writer) (*options, []string, error) {
+	opts := &options{}
+	flags := flag.NewFlagSet("opusenc", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, errUsage)
+		flags.PrintDefaults()
+	}
+
+	flags.Float64Var(&opts.bitrate, "bitrate", 0,
+		"target bitrate in kbit/s, 6 to 510 (default 64 for mono, 96 for stereo)")
+	flags.IntVar(&opts.complexity, "complexity", 10, "encoder complexity, 0 to 10")
+	flags.BoolVar(&opts.hardCBR, "hard-cbr", false, "use constant bitrate instead of VBR")
+	flags.BoolVar(&opts.cvbr, "cvbr", false, "use constrained VBR")
+	flags.StringVar(&opts.bandwidth, "bandwidth", "auto", "bandwidth: auto, nb, wb, swb or fb")
+	flags.StringVar(&opts.maxBandwidth, "max-bandwidth", "fb", "highest bandwidth auto may pick: nb, wb, swb or fb")
+	flags.StringVar(&opts.application, "application", "audio", "application: audio, voip or lowdelay")
+	flags.IntVar(&opts.loss, "expect-loss", 0, "expected packet loss in percent, 0 to 100")
+	flags.Float64Var(&opts.frameSize, "framesize", 20, "frame size in milliseconds; only 20 is supported")
+
+	flags.BoolVar(&opts.raw, "raw", false, "read headerless PCM instead of WAV")
+	flags.StringVar(&opts.rawFormat, "raw-format", "s16le", "raw sample format: s16le, s24le, s32le, f32le or f64le")
+	flags.IntVar(&opts.rawRate, "raw-rate", encoderSampleRate, "raw sample rate in Hz")
+	flags.IntVar(&opts.rawChan, "raw-chan", 2, "raw channel count")
+
+	addTag := func(tag string) func(string) error {
+		return func(value string) error {
+			opts.comments = append(opts.comments, tag+"="+value)
+
+			return nil
+		}
+	}
+	flags.Func("comment", "add the comment TAG=value; may be repeated", func(value string) error {
+		if tag, _, ok := strings.Cut(value, "="); !ok || tag == "" {
+			return errInvalidComment
+		}
+		opts.comments = append(opts.comments, value)
+
+		return nil
+	})
+	flags.Func("artist", "add an ARTIST comment", addTag("ARTIST"))
+	flags.Func("title", "add a TITLE comment", addTag("TITLE"))
+	flags.Func("album", "add an ALBUM comment", addTag("ALBUM"))
+	flags.Func("date", "add a DATE comment", addTag("DATE"))
+	flags.Func("genre", "add a GENRE comment", addTag("GENRE"))
+	flags.Int64Var(&opts.serial, "serial", -1, "Ogg stream serial number (default random)")
+	flags.BoolVar(&opts.quiet, "quiet", false, "do not print statistics")
+
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, err
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+
+		return nil, nil, errUsage
+	}
+
+	return opts, flags.Args(), nil
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	opts, files, err := parseFlags(args, stderr)
+	if err != nil {
+		return err
+	}
+
+	in, closeIn, err := openInput(files[0], stdin)
+	if err != nil {
+		return err
+	}
+	defer closeIn()
+	var input *pcmInput
+	if opts.raw {
+		input, err = newRawInput(in, opts.rawFormat, opts.rawRate, opts.rawChan)
+	} else {
+		input, err = newWAVInput(in)
+	}
+	if err != nil {
+		return err
+	}
+
+	encoder, err := newEncoder(opts, input.channels)
+	if err != nil {
+		return err
+	}
+
+	out, closeOut, err := createOutput(files[1], stdout)
+	if err != nil {
+		return err
+	}
+	stats, err := encode(input, encoder, out, opts)
+	if closeErr := closeOut(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if !opts.quiet {
+		stats.print(stderr)
+	}
+
+	return nil
+}
+
+func openInput(name string, stdin io.Reader) (io.Reader, func(), error) {
+	if name == "-" {
+		return stdin, func() {}, nil
+	}
+	file, err := os.Open(name) // #nosec G304 -- the user names the file to encode.
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return file, func() { _ = file.Close() }, nil
+}
+
+func createOutput(name string, stdout io.Writer) (io.Writer, func() error, error) {
+	if name == "-" {
+		return stdout, func() error { return nil }, nil
+	}
+	file, err := os.Create(name) // #nosec G304 -- the user names the file to write.
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return file, file.Close, nil
+}
+
+func newEncoder(opts *options, channels int) (*opus.Encoder, error) {
+	if opts.frameSize != 20 {
+		return nil, fmt.Errorf("%w: %v ms", errInvalidFrameSize, opts.frameSize)
+	}
+	if opts.hardCBR && opts.cvbr {
+		return nil, errConflictingRateModes
+	}
+	bitrate := int(math.Round(opts.bitrate * 1000))
+	if bitrate == 0 {
+		bitrate = 32000 + 32000*channels
+	}
+	application, err := parseApplication(opts.application)
+	if err != nil {
+		return nil, err
+	}
+	maxBandwidth, err := parseBandwidth(opts.maxBandwidth)
+	if err != nil || maxBandwidth == opus.BandwidthAuto {
+		return nil, fmt.Errorf("%w: %q", errInvalidBandwidth, opts.maxBandwidth)
+	}
+
+	encoderOptions := []opus.EncoderOption{
+		opus.WithChannels(channels),
+		opus.WithBitrate(bitrate),
+		opus.WithComplexity(opts.complexity),
+		opus.WithApplication(application),
+		opus.WithVBR(!opts.hardCBR),
+		opus.WithConstrainedVBR(opts.cvbr),
+		opus.WithMaxBandwidth(maxBandwidth),
+	}
+	bandwidth, err := parseBandwidth(opts.bandwidth)
+	if err != nil {
+		return nil, err
+	}
+	if bandwidth != opus.BandwidthAuto {
+		encoderOptions = append(encoderOptions, opus.WithBandwidth(bandwidth))
+	}
+
+	encoder, err := opus.NewEncoder(encoderOptions...)
+	if err != nil {
+		return nil, err
+	}
+	if err = encoder.SetLossRate(opts.loss); err != nil {
+		return nil, err
+	}
+
+	return encoder, nil
+}
+
+func parseBandwidth(name string) (opus.Bandwidth, error) {
+	switch name {
+	case "auto":
+		return opus.BandwidthAuto, nil
+	case "nb":
+		return opus.BandwidthNarrowband, nil
+	case "wb":
+		return opus.BandwidthWideband, nil
+	case "swb":
+		return opus.BandwidthSuperwideband, nil
+	case "fb":
+		return opus.BandwidthFullband, nil
+	default:
+		return 0, fmt.Errorf("%w: %q", errInvalidBandwidth, name)
+	}
+}
+
+func parseApplication(name string) (opus.Application, error) {
+	switch name {
+	case "audio":
+		return opus.ApplicationAudio, nil
+	case "voip":
+		return opus.ApplicationVoIP, nil
+	case "lowdelay":
+		return opus.ApplicationRestrictedLowDelay, nil
+	default:
+		return 0, fmt.Errorf("%w: %q", errInvalidApp, name)
+	}
+}
+
+// encoding carries one file through the resampler into the encoder writer.
+type encoding struct {
+	writer    *encoderwriter.Writer
+	resampler *resample.Resampler
+	channels  int
+	resampled []float32
+	// written counts the 48 kHz samples per channel given to writer.
+	written uint64
+}
+
+// statsWriter counts the packets on their way into the Ogg stream.
+type statsWriter struct {
+	*oggwriter.OggWriter
+	frameSize int
+	stats     statistics
+}
+
+func (w *statsWriter) WritePacket(packet []byte, granulePosition uint64) error {
+	w.stats.add(packet, w.frameSize)
+
+	return w.OggWriter.WritePacket(packet, granulePosition)
+}
+
+// encode reads all of input and writes it to out as Ogg Opus. The pre-skip
+// covers the resampler's delay on top of the encoder's lookahead.
+func encode(input *pcmInput, encoder *opus.Encoder, out io.Writer, opts *options) (*statistics, error) {
+	enc := &encoding{channels: input.channels}
+	var delay uint64
+	if input.sampleRate != encoderSampleRate {
+		var err error
+		enc.resampler, err = resample.New(input.sampleRate, encoderSampleRate, input.channels)
+		if err != nil {
+			return nil, err
+		}
+		delay = uint64(math.Round(enc.resampler.Delay()))
+	}
+
+	oggOptions := []oggwriter.Option{oggwriter.WithComments(opts.comments...)}
+	if opts.serial >= 0 {
+		oggOptions = append(oggOptions, oggwriter.WithSerial(uint32(opts.serial))) // #nosec G115
+	}
+	ogg, err := oggwriter.NewWith(out, oggreader.OggHeader{
+		Channels:   uint8(input.channels),                               // #nosec G115 -- at most 2.
+		PreSkip:    uint16(encoderwriter.PreSkip(encoder) + int(delay)), // #nosec G115 -- a few milliseconds.
+		SampleRate: uint32(input.sampleRate),                            // #nosec G115
+	}, oggOptions...)
+	if err != nil {
+		return nil, err
+	}
+	sink := &statsWriter{OggWriter: ogg, frameSize: encoder.FrameSize()}
+	enc.writer = encoderwriter.New(sink, encoder)
+
+	inputSamples, err := enc.readAll(input)
+	if err != nil {
+		return nil, errors.Join(err, ogg.Close())
+	}
+	if err = enc.finish(delay, inputSamples, input.sampleRate); err != nil {
+		return nil, errors.Join(err, ogg.Close())
+	}
+
+	return &sink.stats, enc.writer.Close()
+}
+
+// readAll encodes every whole frame of input and returns its length in samples
+// per channel.
+func (e *encoding) readAll(input *pcmInput) (uint64, error) {
+	samples := make([]float32, readSamples*input.channels)
+	var total uint64
+	for {
+		n, err := input.Read(samples)
+		if n > 0 {
+			total += uint64(n / input.channels) // #nosec G115
+			resampled, resampleErr := e.resample(samples[:n])
+			if resampleErr != nil {
+				return 0, resampleErr
+			}
+			if writeErr := e.write(resampled); writeErr != nil {
+				return 0, writeErr
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return total, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+// resample returns in at 48 kHz.
+func (e *encoding) resample(in []float32) ([]float32, error) {
+	if e.resampler == nil {
+		return in, nil
+	}
+
+	size := e.resampler.OutputSamples(len(in)/e.channels) * e.channels
+	if cap(e.resampled) < size {
+		e.resampled = make([]float32, size)
+	}
+	n, err := e.resampler.Resample(in, e.resampled[:size])
+	if err != nil {
+		return nil, err
+	}
+
+	return e.resampled[:n*e.channels], nil
+}
+
+func (e *encoding) write(pcm []float32) error {
+	e.written += uint64(len(pcm) / e.channels) // #nosec G115
+
+	return e.writer.WriteFloat32(pcm)
+}
+
+// finish flushes the resampler with silence until the writer holds delay plus
+// the input's length at 48 kHz, so the writer's final granule position ends
+// the stream at the input's last sample.
+func (e *encoding) finish(delay, inputSamples uint64, inputRate int) error {
+	end := delay + (inputSamples*encoderSampleRate+uint64(inputRate)-1)/uint64(inputRate) // #nosec G115
+	if e.resampler != nil {
+		silence := make([]float32, (int(math.Ceil(e.resampler.Delay()))*inputRate/encoderSampleRate+2)*e.channels)
+		flushed, err := e.resample(silence)
+		if err != nil {
+			return err
+		}
+		remaining := int(end-min(e.written, end)) * e.channels // #nosec G115
+		if err = e.write(flushed[:min(len(flushed), remaining)]); err != nil {
+			return err
+		}
+	}
+	if e.written < end {
+		return e.write(make([]float32, int(end-e.written)*e.channels)) // #nosec G115
+	}
+
+	return nil
+}
+
+// statistics summarizes the packets of a file.
+type statistics struct {
+	packets    int
+	bytes      int
+	samples    int
+	maxPacket  int
+	modes      map[opus.Mode]int
+	bandwidths map[opus.Bandwidth]int
+}
+
+func (s *statistics) add(packet []byte, samples int) {
+	if s.modes == nil {
+		s.modes = map[opus.Mode]int{}
+		s.bandwidths = map[opus.Bandwidth]int{}
+	}
+	s.packets++
+	s.bytes += len(packet)
+	s.samples += samples
+	s.maxPacket = max(s.maxPacket, len(packet))
+	mode, _ := opus.PacketMode(packet)
+	s.modes[mode]++
+	bandwidth, _ := opus.PacketBandwidth(packet)
+	s.bandwidths[bandwidth]++
+}
+
+func (s *statistics) duration() time.Duration {
+	return time.Duration(s.samples) * time.Second / encoderSampleRate
+}
+
+// bitrate returns the average bitrate in bits per second.
+func (s *statistics) bitrate() float64 {
+	if s.samples == 0 {
+		return 0
+	}
+
+	return float64(s.bytes) * 8 * encoderSampleRate / float64(s.samples)
+}
+
+func (s *statistics) print(w io.Writer) {
+	fmt.Fprintf(w, "Encoded %d packets, %v of audio\n", s.packets, s.duration())
+	fmt.Fprintf(w, "Average bitrate: %.1f kbit/s, largest packet %d bytes\n", s.bitrate()/1000, s.maxPacket)
+	fmt.Fprintln(w, "Mode:")
+	for _, mode := range []opus.Mode{opus.ModeSILK, opus.ModeHybrid, opus.ModeCELT} {
+		s.printCount(w, mode.String(), s.modes[mode])
+	}
+	fmt.Fprintln(w, "Bandwidth:")
+	for _, bandwidth := range []opus.Bandwidth{
+		opus.BandwidthNarrowband,
+		opus.BandwidthMediumband,
+		opus.BandwidthWideband,
+		opus.BandwidthSuperwideband,
+		opus.BandwidthFullband,
+	} {
+		s.printCount(w, bandwidth.String(), s.bandwidths[bandwidth])
+	}
+}
+
+func (s *statistics) printCount(w io.Writer, name string, count int) {
+	if count == 0 {
+		return
+	}
+	fmt.Fprintf(w, "  %-14s %8d  %5.1f%%\n", name, count, 100*float64(count)/float64(s.packets))
+}
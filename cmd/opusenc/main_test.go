@@ -0,0 +1,227 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pion/opus"
+	"github.com/pion/opus/pkg/oggreader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testWAV builds a WAV file of a 440 Hz sine in every channel.
+func testWAV(tag, bits uint16, sampleRate, channels, samples int, extensible bool) []byte {
+	var data bytes.Buffer
+	for i := range samples {
+		value := 0.5 * math.Sin(2*math.Pi*440*float64(i)/float64(sampleRate))
+		for range channels {
+			switch {
+			case tag == wavFormatFloat && bits == 32:
+				_ = binary.Write(&data, binary.LittleEndian, float32(value))
+			case tag == wavFormatFloat:
+				_ = binary.Write(&data, binary.LittleEndian, value)
+			case bits == 16:
+				_ = binary.Write(&data, binary.LittleEndian, int16(value*(1<<15)))
+			case bits == 24:
+				sample := int32(value * (1 << 23))
+				data.Write([]byte{byte(sample), byte(sample >> 8), byte(sample >> 16)})
+			default:
+				_ = binary.Write(&data, binary.LittleEndian, int32(value*(1<<31)))
+			}
+		}
+	}
+
+	format := make([]byte, 16, 40)
+	formatTag := tag
+	if extensible {
+		formatTag = wavFormatExtensible
+	}
+	binary.LittleEndian.PutUint16(format[0:], formatTag)
+	binary.LittleEndian.PutUint16(format[2:], uint16(channels))
+	binary.LittleEndian.PutUint32(format[4:], uint32(sampleRate))
+	binary.LittleEndian.PutUint32(format[8:], uint32(sampleRate*channels*int(bits)/8))
+	binary.LittleEndian.PutUint16(format[12:], uint16(channels*int(bits)/8))
+	binary.LittleEndian.PutUint16(format[14:], bits)
+	if extensible {
+		format = binary.LittleEndian.AppendUint16(format, 22)
+		format = binary.LittleEndian.AppendUint16(format, bits)
+		format = binary.LittleEndian.AppendUint32(format, 0)
+		format = binary.LittleEndian.AppendUint16(format, tag)
+		format = append(format, "\x00\x00\x00\x00\x10\x00\x80\x00\x00\xaa\x00\x38\x9b\x71"...)
+	}
+
+	var out bytes.Buffer
+	out.WriteString("RIFF")
+	_ = binary.Write(&out, binary.LittleEndian, uint32(4+8+len(format)+8+5+1+8+data.Len()))
+	out.WriteString("WAVE")
+	out.WriteString("fmt ")
+	_ = binary.Write(&out, binary.LittleEndian, uint32(len(format)))
+	out.Write(format)
+	// An odd-sized chunk to skip, with its pad byte.
+	out.WriteString("LIST")
+	_ = binary.Write(&out, binary.LittleEndian, uint32(5))
+	out.WriteString("info\x00\x00")
+	out.WriteString("data")
+	_ = binary.Write(&out, binary.LittleEndian, uint32(data.Len()))
+	out.Write(data.Bytes())
+
+	return out.Bytes()
+}
+
+// decodeOgg decodes an Ogg Opus file, dropping the pre-skip and the samples
+// past the final granule position.
+func decodeOgg(t *testing.T, file []byte) (*oggreader.OggHeader, []string, []float32) {
+	t.Helper()
+
+	reader, header, err := oggreader.NewWith(bytes.NewReader(file))
+	require.NoError(t, err)
+	decoder, err := opus.NewDecoderWithOutput(48000, int(header.Channels))
+	require.NoError(t, err)
+
+	var tags []string
+	var pcm []float32
+	out := make([]float32, 5760*int(header.Channels))
+	var granule uint64
+	for {
+		packet, pageHeader, err := reader.ParseNextPacket()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		if bytes.HasPrefix(packet, []byte("OpusTags")) {
+			tags = parseTags(packet)
+
+			continue
+		}
+		n, err := decoder.DecodeToFloat32(packet, out)
+		require.NoError(t, err)
+		pcm = append(pcm, out[:n*int(header.Channels)]...)
+		granule = pageHeader.GranulePosition
+	}
+	pcm = pcm[int(header.PreSkip)*int(header.Channels):]
+	require.LessOrEqual(t, granule, uint64(len(pcm)/int(header.Channels))+uint64(header.PreSkip))
+
+	return header, tags, pcm[:(int(granule)-int(header.PreSkip))*int(header.Channels)]
+}
+
+func parseTags(packet []byte) []string {
+	packet = packet[8:]
+	vendorLength := binary.LittleEndian.Uint32(packet)
+	packet = packet[4+vendorLength:]
+	count := binary.LittleEndian.Uint32(packet)
+	packet = packet[4:]
+	tags := make([]string, 0, count)
+	for range count {
+		length := binary.LittleEndian.Uint32(packet)
+		tags = append(tags, string(packet[4:4+length]))
+		packet = packet[4+length:]
+	}
+
+	return tags
+}
+
+func TestEncodeWAV(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		tag        uint16
+		bits       uint16
+		sampleRate int
+		channels   int
+		extensible bool
+	}{
+		{"s16 mono", wavFormatPCM, 16, 48000, 1, false},
+		{"s24 stereo 44.1 kHz", wavFormatPCM, 24, 44100, 2, false},
+		{"s32 extensible 16 kHz", wavFormatPCM, 32, 16000, 1, true},
+		{"f32 stereo", wavFormatFloat, 32, 48000, 2, false},
+		{"f64 extensible 32 kHz", wavFormatFloat, 64, 32000, 2, true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			input := filepath.Join(dir, "in.wav")
+			output := filepath.Join(dir, "out.opus")
+			// A second and a bit, not a whole number of frames.
+			samples := test.sampleRate + test.sampleRate/7
+			require.NoError(t, os.WriteFile(input, testWAV(
+				test.tag, test.bits, test.sampleRate, test.channels, samples, test.extensible,
+			), 0o600))
+
+			var stderr bytes.Buffer
+			require.NoError(t, run(
+				[]string{"-bitrate", "64", "-artist", "Pion", "-comment", "GENRE=Test", input, output},
+				nil, nil, &stderr,
+			))
+			assert.Contains(t, stderr.String(), "Average bitrate")
+			assert.Contains(t, stderr.String(), "CELT")
+
+			file, err := os.ReadFile(output) // #nosec G304
+			require.NoError(t, err)
+			header, tags, pcm := decodeOgg(t, file)
+			assert.Equal(t, uint8(test.channels), header.Channels)
+			assert.Equal(t, uint32(test.sampleRate), header.SampleRate)
+			assert.Equal(t, []string{"ARTIST=Pion", "GENRE=Test"}, tags)
+
+			// Every input sample, and no more, comes back at 48 kHz.
+			wantSamples := (samples*48000 + test.sampleRate - 1) / test.sampleRate
+			require.Len(t, pcm, wantSamples*test.channels)
+			var power float64
+			for _, sample := range pcm[len(pcm)/4 : len(pcm)*3/4] {
+				power += float64(sample) * float64(sample)
+			}
+			power /= float64(len(pcm) / 2)
+			assert.InDelta(t, 0.125, power, 0.02)
+		})
+	}
+}
+
+func TestEncodeRawFromStdin(t *testing.T) {
+	pcm := make([]byte, 4*960*3)
+	for i := range 960 * 3 {
+		value := float32(0.3 * math.Sin(2*math.Pi*1000*float64(i)/48000))
+		binary.LittleEndian.PutUint32(pcm[4*i:], math.Float32bits(value))
+	}
+
+	var stdout, stderr bytes.Buffer
+	require.NoError(t, run(
+		[]string{"-raw", "-raw-format", "f32le", "-raw-chan", "1", "-hard-cbr", "-quiet", "-", "-"},
+		bytes.NewReader(pcm), &stdout, &stderr,
+	))
+	assert.Empty(t, stderr.String())
+	header, _, decoded := decodeOgg(t, stdout.Bytes())
+	assert.Equal(t, uint8(1), header.Channels)
+	assert.Len(t, decoded, 960*3)
+}
+
+func TestEncodeErrors(t *testing.T) {
+	wav := testWAV(wavFormatPCM, 16, 48000, 1, 960, false)
+	for _, test := range []struct {
+		name  string
+		args  []string
+		input []byte
+		err   error
+	}{
+		{"missing output", []string{"-"}, wav, errUsage},
+		{"frame size", []string{"-framesize", "10", "-", "-"}, wav, errInvalidFrameSize},
+		{"rate control", []string{"-hard-cbr", "-cvbr", "-", "-"}, wav, errConflictingRateModes},
+		{"bandwidth", []string{"-bandwidth", "mb", "-", "-"}, wav, errInvalidBandwidth},
+		{"application", []string{"-application", "music", "-", "-"}, wav, errInvalidApp},
+		{"not WAV", []string{"-", "-"}, []byte("OggS and more bytes"), errNotWAV},
+		{"8-bit WAV", []string{"-", "-"}, testWAV(wavFormatPCM, 8, 48000, 1, 960, false), errUnsupportedWAV},
+		{"raw format", []string{"-raw", "-raw-format", "u8", "-", "-"}, wav, errInvalidRawFormat},
+		{"channels", []string{"-raw", "-raw-chan", "6", "-", "-"}, wav, errInvalidInputCount},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := run(test.args, bytes.NewReader(test.input), io.Discard, io.Discard)
+			assert.ErrorIs(t, err, test.err)
+		})
+	}
+}
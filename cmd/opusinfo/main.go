@@ -0,0 +1,387 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+// Command opusinfo inspects and validates Ogg Opus files.
+//
+//	opusinfo [flags] file...
+//
+// For each file it prints the ID header fields, the tags, and statistics of
+// the packets: duration, bitrate, and the modes, bandwidths, frame sizes and
+// padding they use. Every packet is checked against the framing rules of RFC
+// 6716 Section 3.4 and decoded, and the granule positions of the pages are
+// checked against the packets they hold (RFC 7845 Section 4). opusinfo exits
+// with status 1 when any file has a problem.
+//
+// With -raw the files are packet dumps in the opus_demo bitstream format
+// instead: each packet is preceded by its length and the encoder's final
+// range, both 4-byte big-endian, and a length of 0 marks a lost packet. The
+// final range of every decoded packet is checked against the decoder's.
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"github.com/pion/opus/pkg/oggreader"
+	"github.com/pion/opus/pkg/opusdemo"
+)
+
+var (
+	errUsage         = errors.New("usage: opusinfo [flags] file...")
+	errProblems      = errors.New("problems found")
+	errMultistream   = errors.New("multistream streams are not supported")
+	errNoTags        = errors.New("missing OpusTags signature")
+	errTruncatedTags = errors.New("truncated comment header")
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, flag.ErrHelp) && !errors.Is(err, errProblems) {
+			fmt.Fprintln(os.Stderr, "opusinfo:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+// options holds the command line.
+type options struct {
+	raw      bool
+	channels int
+	noDecode bool
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	opts := &options{}
+	flags := flag.NewFlagSet("opusinfo", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, errUsage)
+		flags.PrintDefaults()
+	}
+	flags.BoolVar(&opts.raw, "raw", false, "read opus_demo packet dumps instead of Ogg Opus")
+	flags.IntVar(&opts.channels, "channels", 2, "channel count to decode packet dumps with")
+	flags.BoolVar(&opts.noDecode, "no-decode", false, "only check the framing, do not decode")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+
+		return errUsage
+	}
+
+	failed := false
+	for _, name := range flags.Args() {
+		fmt.Fprintf(stdout, "Processing file %q...\n", name)
+		ok, err := inspectFile(name, stdin, stdout, opts)
+		if err != nil {
+			fmt.Fprintf(stdout, "\tError: %v\n", err)
+		}
+		failed = failed || !ok || err != nil
+		fmt.Fprintln(stdout)
+	}
+	if failed {
+		return errProblems
+	}
+
+	return nil
+}
+
+func inspectFile(name string, stdin io.Reader, w io.Writer, opts *options) (bool, error) {
+	in := stdin
+	if name != "-" {
+		file, err := os.Open(name) // #nosec G304 -- the user names the files to inspect.
+		if err != nil {
+			return false, err
+		}
+		defer func() { _ = file.Close() }()
+		in = file
+	}
+	counter := &countingReader{reader: in}
+	if opts.raw {
+		return inspectDump(counter, w, opts)
+	}
+
+	return inspectOgg(counter, w, opts)
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.reader.Read(p)
+	c.count += int64(n)
+
+	return n, err
+}
+
+func inspectOgg(in *countingReader, w io.Writer, opts *options) (bool, error) {
+	reader, header, err := oggreader.NewWith(in)
+	if err != nil {
+		return false, err
+	}
+	tags, tagsPage, err := reader.ParseNextPacket()
+	if err != nil {
+		return false, err
+	}
+	ok := printHeader(w, reader, header, tagsPage.Serial())
+	if header.ChannelMap != 0 && reader.ChannelMapping().StreamCount > 1 {
+		return false, errMultistream
+	}
+
+	report, err := newReport(int(max(1, min(header.Channels, 2))), !opts.noDecode)
+	if err != nil {
+		return false, err
+	}
+	if vendor, comments, tagsErr := parseTags(tags); tagsErr != nil {
+		report.problem("%v", tagsErr)
+	} else {
+		fmt.Fprintf(w, "\tVendor: %s\n", vendor)
+		if len(comments) > 0 {
+			fmt.Fprintln(w, "\tTags:")
+		}
+		for _, comment := range comments {
+			fmt.Fprintf(w, "\t\t%s\n", comment)
+		}
+	}
+
+	granules := &granuleCheck{
+		report:       report,
+		preSkip:      int64(header.PreSkip),
+		serial:       tagsPage.Serial(),
+		lastSequence: tagsPage.Sequence(),
+	}
+	for index := 0; ; index++ {
+		packet, pageHeader, err := reader.ParseNextPacket()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			report.problem("after packet %d: %v", index, err)
+
+			break
+		}
+		granules.startPacket(pageHeader)
+		granules.endPacket(report.addPacket(index, packet), pageHeader)
+	}
+	granules.finish()
+
+	report.print(w, in.count)
+
+	return ok && report.ok(), nil
+}
+
+// printHeader prints the ID header, and reports whether its fields are valid.
+func printHeader(w io.Writer, reader *oggreader.OggReader, header *oggreader.OggHeader, serial uint32) bool {
+	fmt.Fprintf(w, "Ogg Opus stream, serial %#08x:\n", serial)
+	fmt.Fprintf(w, "\tVersion: %d\n", header.Version)
+	fmt.Fprintf(w, "\tChannels: %d\n", header.Channels)
+	fmt.Fprintf(w, "\tChannel mapping family: %d\n", header.ChannelMap)
+	if header.ChannelMap != 0 {
+		mapping := reader.ChannelMapping()
+		fmt.Fprintf(w, "\tStreams: %d (%d coupled), mapping %v\n",
+			mapping.StreamCount, mapping.CoupledCount, mapping.Mapping)
+	}
+	fmt.Fprintf(w, "\tPre-skip: %d\n", header.PreSkip)
+	fmt.Fprintf(w, "\tOriginal sample rate: %d Hz\n", header.SampleRate)
+	// The output gain is a Q7.8 number of dB (RFC 7845 Section 5.1).
+	fmt.Fprintf(w, "\tOutput gain: %g dB\n", float64(int16(header.OutputGain))/256) //nolint:gosec // G115
+
+	ok := true
+	// A change of the major version, the top 4 bits, is incompatible
+	// (RFC 7845 Section 5.1).
+	if header.Version>>4 != 0 {
+		fmt.Fprintf(w, "\tProblem: unsupported version %d\n", header.Version)
+		ok = false
+	}
+	if header.Channels == 0 || header.ChannelMap == 0 && header.Channels > 2 {
+		fmt.Fprintf(w, "\tProblem: %d channels with channel mapping family %d\n", header.Channels, header.ChannelMap)
+		ok = false
+	}
+
+	return ok
+}
+
+// parseTags parses an OpusTags packet (RFC 7845 Section 5.2).
+func parseTags(packet []byte) (vendor string, comments []string, err error) {
+	if !bytes.HasPrefix(packet, []byte("OpusTags")) {
+		return "", nil, errNoTags
+	}
+	packet = packet[8:]
+	readString := func() (string, error) {
+		if len(packet) < 4 {
+			return "", errTruncatedTags
+		}
+		length := binary.LittleEndian.Uint32(packet)
+		if uint64(length) > uint64(len(packet)-4) {
+			return "", errTruncatedTags
+		}
+		value := string(packet[4 : 4+length])
+		packet = packet[4+length:]
+
+		return value, nil
+	}
+
+	if vendor, err = readString(); err != nil {
+		return "", nil, err
+	}
+	if len(packet) < 4 {
+		return "", nil, errTruncatedTags
+	}
+	count := binary.LittleEndian.Uint32(packet)
+	packet = packet[4:]
+	for range count {
+		comment, err := readString()
+		if err != nil {
+			return "", nil, err
+		}
+		comments = append(comments, comment)
+	}
+
+	return vendor, comments, nil
+}
+
+// granuleCheck compares the granule position of each page with the packets
+// that end on it. A page's granule position counts the samples, pre-skip
+// included, of every packet up to the last one it ends (RFC 7845 Section 4).
+type granuleCheck struct {
+	report  *report
+	preSkip int64
+	serial  uint32
+
+	// samples counts the samples of the packets so far.
+	samples int64
+	// offset is the granule position the stream starts from, known once its
+	// first audio page is over.
+	offset       int64
+	started      bool
+	lastPacket   int64
+	page         *oggreader.OggPageHeader
+	lastSequence uint32
+}
+
+// startPacket checks the page before pageHeader once a packet ends on a new
+// page.
+func (g *granuleCheck) startPacket(pageHeader *oggreader.OggPageHeader) {
+	if g.page != nil && pageHeader.Sequence() != g.page.Sequence() {
+		g.checkPage(false)
+	}
+	if pageHeader.Serial() != g.serial {
+		g.report.problem("page %d belongs to another logical stream, serial %#08x",
+			pageHeader.Sequence(), pageHeader.Serial())
+	}
+}
+
+func (g *granuleCheck) endPacket(samples int64, pageHeader *oggreader.OggPageHeader) {
+	if g.page == nil && pageHeader.Sequence() != g.lastSequence+1 {
+		g.report.problem("the first audio page is %d, expected %d right after the comment header",
+			pageHeader.Sequence(), g.lastSequence+1)
+	}
+	g.samples += samples
+	g.lastPacket = samples
+	g.page = pageHeader
+}
+
+func (g *granuleCheck) finish() {
+	if g.page == nil {
+		g.report.problem("the stream has no audio packets")
+
+		return
+	}
+	g.checkPage(true)
+}
+
+// checkPage checks the granule position of g.page, the page the last packet
+// ended on.
+func (g *granuleCheck) checkPage(last bool) {
+	sequence := g.page.Sequence()
+	if g.page.GranulePosition == math.MaxUint64 {
+		g.report.problem("page %d ends a packet but has no granule position", sequence)
+
+		return
+	}
+	granule := int64(g.page.GranulePosition) //nolint:gosec // G115
+	if granule < 0 {
+		g.report.problem("page %d: granule position %d is negative", sequence, granule)
+
+		return
+	}
+
+	if !g.started {
+		g.started = true
+		// Only a stream's last page may have a granule position below its
+		// packets' duration, to trim the end of the audio.
+		if !last {
+			if granule < g.samples {
+				g.report.problem("page %d: granule position %d is less than the %d samples of its packets",
+					sequence, granule, g.samples)
+			}
+			g.offset = max(0, granule-g.samples)
+
+			return
+		}
+	}
+
+	expected := g.offset + g.samples
+	switch {
+	case last && granule > expected:
+		g.report.problem("last page %d: granule position %d is past the end of its packets at %d",
+			sequence, granule, expected)
+	case last && expected-granule >= g.lastPacket:
+		g.report.problem("last page %d: granule position %d trims %d samples, more than the last packet's %d",
+			sequence, granule, expected-granule, g.lastPacket)
+	case !last && granule != expected:
+		g.report.problem("page %d: granule position %d, expected %d", sequence, granule, expected)
+	}
+	if last && granule-g.offset < g.preSkip {
+		g.report.problem("last page %d: the stream ends within the pre-skip of %d samples", sequence, g.preSkip)
+	}
+}
+
+// inspectDump inspects a packet dump in the opus_demo bitstream format.
+func inspectDump(in *countingReader, w io.Writer, opts *options) (bool, error) {
+	fmt.Fprintln(w, "opus_demo packet dump:")
+	report, err := newReport(opts.channels, !opts.noDecode)
+	if err != nil {
+		return false, err
+	}
+
+	reader, err := opusdemo.NewReader(in)
+	if err != nil {
+		return false, err
+	}
+	for index := 0; ; index++ {
+		packet, err := reader.ReadPacket()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return false, err
+		}
+		if packet.Lost() {
+			report.addLost()
+
+			continue
+		}
+		decoded := report.decoded
+		report.addPacket(index, packet.Payload)
+		// A decoded packet must leave the decoder where it left the encoder.
+		if report.decoded > decoded {
+			if err = reader.Verify(report.decoder.FinalRange()); err != nil {
+				report.problem("%v", err)
+			}
+		}
+	}
+
+	report.print(w, in.count)
+
+	return report.ok(), nil
+}
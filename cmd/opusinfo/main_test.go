@@ -0,0 +1,207 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/pion/opus"
+	"github.com/pion/opus/pkg/oggreader"
+	"github.com/pion/opus/pkg/oggwriter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPackets encodes count 20 ms CELT packets of a 1 kHz sine.
+func testPackets(t *testing.T, count int) [][]byte {
+	t.Helper()
+
+	encoder, err := opus.NewEncoder()
+	require.NoError(t, err)
+	pcm := make([]float32, 960)
+	packets := make([][]byte, 0, count)
+	for frame := range count {
+		for i := range pcm {
+			pcm[i] = float32(0.25 * math.Sin(2*math.Pi*1000*float64(frame*960+i)/48000))
+		}
+		packet := make([]byte, 1276)
+		n, err := encoder.EncodeFloat32(pcm, packet)
+		require.NoError(t, err)
+		packets = append(packets, packet[:n])
+	}
+
+	return packets
+}
+
+// padPacket turns a code 0 packet into a code 3 packet of one frame with
+// padding bytes of padding.
+func padPacket(packet []byte, padding int) []byte {
+	padded := []byte{packet[0] | 3, 0x41, byte(padding)}
+	padded = append(padded, packet[1:]...)
+
+	return append(padded, make([]byte, padding)...)
+}
+
+// writeOgg writes packets to an Ogg Opus file, each on its own page unless
+// opts say otherwise, with the granule positions granules.
+func writeOgg(t *testing.T, packets [][]byte, granules []uint64, opts ...oggwriter.Option) []byte {
+	t.Helper()
+
+	var out bytes.Buffer
+	opts = append([]oggwriter.Option{
+		oggwriter.WithComments("ARTIST=Pion"),
+		oggwriter.WithMaxPageDuration(time.Millisecond),
+	}, opts...)
+	writer, err := oggwriter.NewWith(&out, oggreader.OggHeader{Channels: 1, PreSkip: 120, SampleRate: 48000}, opts...)
+	require.NoError(t, err)
+	for i, packet := range packets {
+		require.NoError(t, writer.WritePacket(packet, granules[i]))
+	}
+	require.NoError(t, writer.Close())
+
+	return out.Bytes()
+}
+
+func granules(count int, trim uint64) []uint64 {
+	positions := make([]uint64, count)
+	for i := range positions {
+		positions[i] = uint64(960 * (i + 1)) //nolint:gosec // G115
+	}
+	positions[count-1] -= trim
+
+	return positions
+}
+
+func inspect(t *testing.T, file []byte, args ...string) (string, error) {
+	t.Helper()
+
+	var stdout bytes.Buffer
+	err := run(append(args, "-"), bytes.NewReader(file), &stdout, io.Discard)
+
+	return stdout.String(), err
+}
+
+func TestInspectOgg(t *testing.T) {
+	packets := testPackets(t, 10)
+	packets[4] = padPacket(packets[4], 10)
+
+	out, err := inspect(t, writeOgg(t, packets, granules(10, 500)))
+	require.NoError(t, err, out)
+	for _, want := range []string{
+		"Channels: 1",
+		"Pre-skip: 120",
+		"Vendor: pion/opus",
+		"ARTIST=Pion",
+		"Packets: 10",
+		"Playback length: 200ms",
+		"CELT                 10  100.0%",
+		"Fullband             10  100.0%",
+		"20ms                 10  100.0%",
+		"Padding: 1 packets, 10 bytes",
+		"Decoded: 10 of 10 valid packets",
+		"No problems found",
+	} {
+		assert.Contains(t, out, want)
+	}
+}
+
+func TestInspectOggProblems(t *testing.T) {
+	packets := testPackets(t, 6)
+
+	t.Run("malformed packet", func(t *testing.T) {
+		malformed := append([][]byte{}, packets...)
+		// A code 1 packet whose two frames cannot be the same size.
+		malformed[2] = []byte{packets[2][0] | 1, 1, 2, 3}
+		out, err := inspect(t, writeOgg(t, malformed, granules(6, 0)))
+		assert.ErrorIs(t, err, errProblems)
+		assert.Contains(t, out, "packet 2: malformed packet: code 1 packet payload must be even-sized")
+		assert.Contains(t, out, "Decoded: 5 of 5 valid packets")
+	})
+
+	t.Run("granule jump", func(t *testing.T) {
+		positions := granules(6, 0)
+		for i := 3; i < len(positions); i++ {
+			positions[i] += 480
+		}
+		out, err := inspect(t, writeOgg(t, packets, positions))
+		assert.ErrorIs(t, err, errProblems)
+		assert.Contains(t, out, "page 5: granule position 4320, expected 3840")
+	})
+
+	t.Run("end trimmed too far", func(t *testing.T) {
+		// All on one page, which only keeps the last packet's granule
+		// position, as the writer will not let it go back.
+		positions := granules(6, 1000)
+		for i := range positions {
+			positions[i] = min(positions[i], positions[len(positions)-1])
+		}
+		out, err := inspect(t, writeOgg(t, packets, positions, oggwriter.WithMaxPageDuration(time.Second)))
+		assert.ErrorIs(t, err, errProblems)
+		assert.Contains(t, out, "granule position 4760 trims 1000 samples, more than the last packet's 960")
+	})
+
+	t.Run("stream starting at an offset", func(t *testing.T) {
+		positions := granules(6, 0)
+		for i := range positions {
+			positions[i] += 48000
+		}
+		out, err := inspect(t, writeOgg(t, packets, positions))
+		require.NoError(t, err, out)
+	})
+
+	t.Run("framing only", func(t *testing.T) {
+		out, err := inspect(t, writeOgg(t, packets, granules(6, 0)), "-no-decode")
+		require.NoError(t, err)
+		assert.NotContains(t, out, "Decoded")
+	})
+}
+
+func TestInspectDump(t *testing.T) {
+	// dump writes the packets as an opus_demo bitstream with the final range
+	// finalRange, losing the third.
+	dump := func(finalRange uint32) []byte {
+		var dump bytes.Buffer
+		for i, packet := range testPackets(t, 4) {
+			if i == 2 {
+				dump.Write(make([]byte, 8))
+
+				continue
+			}
+			dump.Write(binary.BigEndian.AppendUint32(nil, uint32(len(packet)))) //nolint:gosec // G115
+			dump.Write(binary.BigEndian.AppendUint32(nil, finalRange))
+			dump.Write(packet)
+		}
+
+		return dump.Bytes()
+	}
+
+	// A final range of 0 is not checked.
+	out, err := inspect(t, dump(0), "-raw")
+	require.NoError(t, err, out)
+	assert.Contains(t, out, "Packets: 4 (1 lost)")
+	assert.Contains(t, out, "Playback length: 60ms")
+	assert.Contains(t, out, "No problems found")
+
+	out, err = inspect(t, dump(0x12345678), "-raw")
+	assert.ErrorIs(t, err, errProblems)
+	assert.Contains(t, out, "final range mismatch: packet 0: want 0x12345678")
+	assert.Contains(t, out, "Problems: 3")
+
+	out, err = inspect(t, dump(0)[:len(dump(0))-1], "-raw")
+	assert.ErrorIs(t, err, errProblems)
+	assert.Contains(t, out, "Error: truncated bitstream: packet 3")
+}
+
+func TestInspectErrors(t *testing.T) {
+	assert.ErrorIs(t, run(nil, nil, io.Discard, io.Discard), errUsage)
+
+	out, err := inspect(t, []byte("not an Ogg file at all"))
+	assert.ErrorIs(t, err, errProblems)
+	assert.Contains(t, out, "Error:")
+}
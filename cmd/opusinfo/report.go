@@ -0,0 +1,220 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/pion/opus"
+)
+
+const (
+	// opusSampleRate is the rate packet durations and granule positions
+	// count at.
+	opusSampleRate = 48000
+	// maxPrintedProblems caps how many problems are listed one by one.
+	maxPrintedProblems = 20
+)
+
+// report gathers what one stream's packets show.
+type report struct {
+	channels int
+	decode   bool
+	decoder  opus.Decoder
+	pcm      []float32
+
+	packets    int
+	lost       int
+	bytes      int64
+	samples    int64
+	minPacket  int64 // duration in samples
+	maxPacket  int64
+	minBitrate float64
+	maxBitrate float64
+	modes      map[opus.Mode]int
+	bandwidths map[opus.Bandwidth]int
+	frameSizes map[int]int // samples per frame at 48 kHz
+	padded     int
+	padding    int64
+	invalid    int
+	decoded    int
+	problems   []string
+}
+
+// newReport returns a report for a stream of channels channels, which decodes
+// every valid packet when decode is set.
+func newReport(channels int, decode bool) (*report, error) {
+	r := &report{
+		channels:   channels,
+		decode:     decode,
+		minPacket:  math.MaxInt64,
+		minBitrate: math.Inf(1),
+		modes:      map[opus.Mode]int{},
+		bandwidths: map[opus.Bandwidth]int{},
+		frameSizes: map[int]int{},
+	}
+	if decode {
+		var err error
+		if r.decoder, err = opus.NewDecoderWithOutput(opusSampleRate, channels); err != nil {
+			return nil, err
+		}
+		r.pcm = make([]float32, 5760*channels)
+	}
+
+	return r, nil
+}
+
+func (r *report) problem(format string, args ...any) {
+	r.problems = append(r.problems, fmt.Sprintf(format, args...))
+}
+
+// addPacket validates, counts and decodes one packet, and returns its
+// duration in samples at 48 kHz, or 0 when it is invalid.
+func (r *report) addPacket(index int, packet []byte) int64 {
+	r.packets++
+	r.bytes += int64(len(packet))
+
+	samples, err := opus.PacketSampleCount(packet, opusSampleRate)
+	if err != nil {
+		r.invalid++
+		r.problem("packet %d: %v", index, err)
+
+		return 0
+	}
+	duration := int64(samples)
+	r.samples += duration
+	r.minPacket = min(r.minPacket, duration)
+	r.maxPacket = max(r.maxPacket, duration)
+	bitrate := float64(len(packet)) * 8 * opusSampleRate / float64(samples)
+	r.minBitrate = min(r.minBitrate, bitrate)
+	r.maxBitrate = max(r.maxBitrate, bitrate)
+
+	// PacketSampleCount has checked the framing, so these cannot fail.
+	mode, _ := opus.PacketMode(packet)
+	r.modes[mode]++
+	bandwidth, _ := opus.PacketBandwidth(packet)
+	r.bandwidths[bandwidth]++
+	frameSize, _ := opus.PacketSamplesPerFrame(packet, opusSampleRate)
+	r.frameSizes[frameSize] += samples / frameSize
+	if padding, _ := opus.PacketPadding(packet); padding > 0 {
+		r.padded++
+		r.padding += int64(padding)
+	}
+
+	if r.decode {
+		if _, err = r.decoder.DecodeToFloat32(packet, r.pcm); err != nil {
+			r.problem("packet %d: decoding failed: %v", index, err)
+		} else {
+			r.decoded++
+		}
+	}
+
+	return duration
+}
+
+// addLost counts a packet the dump marks as lost.
+func (r *report) addLost() {
+	r.packets++
+	r.lost++
+}
+
+func (r *report) ok() bool {
+	return len(r.problems) == 0
+}
+
+func formatDuration(samples int64) string {
+	return (time.Duration(samples) * time.Second / opusSampleRate).String()
+}
+
+func formatMilliseconds(samples int64) string {
+	return fmt.Sprintf("%gms", float64(samples)*1000/opusSampleRate)
+}
+
+// print writes the statistics and problems. fileBytes is the size of the
+// whole input, container included.
+func (r *report) print(w io.Writer, fileBytes int64) {
+	valid := r.packets - r.lost - r.invalid
+	fmt.Fprintf(w, "\tPackets: %d", r.packets)
+	if r.lost > 0 {
+		fmt.Fprintf(w, " (%d lost)", r.lost)
+	}
+	fmt.Fprintln(w)
+	if valid > 0 {
+		fmt.Fprintf(w, "\tPacket duration: %s (min), %s (avg), %s (max)\n",
+			formatMilliseconds(r.minPacket), formatMilliseconds(r.samples/int64(valid)), formatMilliseconds(r.maxPacket))
+	}
+	fmt.Fprintf(w, "\tTotal data length: %d bytes", fileBytes)
+	if fileBytes > 0 {
+		fmt.Fprintf(w, " (overhead: %.1f%%)", 100*float64(fileBytes-r.bytes)/float64(fileBytes))
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "\tPlayback length: %s\n", formatDuration(r.samples))
+	if r.samples > 0 {
+		seconds := float64(r.samples) / opusSampleRate
+		fmt.Fprintf(w, "\tAverage bitrate: %.1f kbit/s, w/o overhead: %.1f kbit/s\n",
+			float64(fileBytes)*8/seconds/1000, float64(r.bytes)*8/seconds/1000)
+		fmt.Fprintf(w, "\tPacket bitrate: %.1f kbit/s (min), %.1f kbit/s (max)\n", r.minBitrate/1000, r.maxBitrate/1000)
+	}
+
+	r.printHistogram(w, "Mode", valid, []string{"SILK", "Hybrid", "CELT"}, []int{
+		r.modes[opus.ModeSILK], r.modes[opus.ModeHybrid], r.modes[opus.ModeCELT],
+	})
+	bandwidths := []opus.Bandwidth{
+		opus.BandwidthNarrowband,
+		opus.BandwidthMediumband,
+		opus.BandwidthWideband,
+		opus.BandwidthSuperwideband,
+		opus.BandwidthFullband,
+	}
+	names := make([]string, len(bandwidths))
+	counts := make([]int, len(bandwidths))
+	for i, bandwidth := range bandwidths {
+		names[i], counts[i] = bandwidth.String(), r.bandwidths[bandwidth]
+	}
+	r.printHistogram(w, "Bandwidth", valid, names, counts)
+	frameSizes := []int{120, 240, 480, 960, 1920, 2880}
+	names = make([]string, len(frameSizes))
+	counts = make([]int, len(frameSizes))
+	frames := 0
+	for i, size := range frameSizes {
+		names[i], counts[i] = formatMilliseconds(int64(size)), r.frameSizes[size]
+		frames += counts[i]
+	}
+	r.printHistogram(w, "Frame size", frames, names, counts)
+
+	fmt.Fprintf(w, "\tPadding: %d packets, %d bytes\n", r.padded, r.padding)
+	if r.decode {
+		fmt.Fprintf(w, "\tDecoded: %d of %d valid packets\n", r.decoded, valid)
+	}
+
+	if r.ok() {
+		fmt.Fprintln(w, "\tNo problems found")
+
+		return
+	}
+	fmt.Fprintf(w, "\tProblems: %d\n", len(r.problems))
+	for _, problem := range r.problems[:min(len(r.problems), maxPrintedProblems)] {
+		fmt.Fprintf(w, "\t\t%s\n", problem)
+	}
+	if len(r.problems) > maxPrintedProblems {
+		fmt.Fprintf(w, "\t\t... and %d more\n", len(r.problems)-maxPrintedProblems)
+	}
+}
+
+// printHistogram prints the counts that are not zero, as shares of total.
+func (r *report) printHistogram(w io.Writer, title string, total int, names []string, counts []int) {
+	if total == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\t%s:\n", title)
+	for i, count := range counts {
+		if count == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\t\t%-14s %8d  %5.1f%%\n", names[i], count, 100*float64(count)/float64(total))
+	}
+}
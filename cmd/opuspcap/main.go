@@ -0,0 +1,398 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+// Command opuspcap extracts an Opus RTP stream from a packet capture into an
+// Ogg Opus file.
+//
+//	opuspcap [flags] capture.pcap output.opus
+//	opuspcap -list capture.pcap
+//
+// The capture may be pcap or pcapng, and "-" reads it from standard input.
+// Every UDP datagram that parses as an RTP packet belongs to a stream, told
+// apart by SSRC, payload type and addresses, which -list prints. The stream
+// extracted is the one with the most Opus packets among those -ssrc and -pt
+// select.
+//
+// Its packets are put in sequence number order, without duplicates, and laid
+// out on the timeline of their RTP timestamps (RFC 7587 Section 4.1). A gap,
+// from lost packets or discontinuous transmission, is filled in the Ogg file
+// with packets of empty frames, which decoders conceal (RFC 6716 Section
+// 3.2.1), so the granule positions follow the RTP timestamps.
+//
+// -wav also decodes the stream to a WAV file the way a receiver would have:
+// each gap is concealed, with its last frame recovered from the in-band FEC
+// data of the packet after it when that carries any.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pion/opus"
+	"github.com/pion/opus/internal/wav"
+	"github.com/pion/opus/pkg/oggreader"
+	"github.com/pion/opus/pkg/oggwriter"
+	"github.com/pion/opus/pkg/rtpopus"
+)
+
+const (
+	// opusSampleRate is the rate RTP timestamps and granule positions count
+	// at.
+	opusSampleRate = rtpopus.ClockRate
+	// maxPacketDuration is the longest packet RFC 6716 allows, in samples at
+	// 48 kHz.
+	maxPacketDuration = 5760
+	// fecStep is the granularity DecodeFEC works in, 10 ms.
+	fecStep = opusSampleRate / 100
+	// concealStep is the most audio DecodePLCToFloat32 produces at once, 20 ms.
+	concealStep = opusSampleRate / 50
+
+	// frameCodeArbitrary is the frame count code of code 3 packets, and
+	// maxFrameCount the most frames they may hold (RFC 6716 Section 3.2.5).
+	frameCodeArbitrary = 0x03
+	maxFrameCount      = 48
+	// celtFullband10ms is the TOC configuration of 10 ms fullband CELT
+	// frames; the two before it are those of 5 and 2.5 ms frames (RFC 6716
+	// Section 3.1).
+	celtFullband10ms = 30
+)
+
+var (
+	errUsage           = errors.New("usage: opuspcap [flags] capture output")
+	errNoStream        = errors.New("no RTP stream with Opus packets in the capture")
+	errInvalidSSRC     = errors.New("invalid SSRC")
+	errInvalidPreSkip  = errors.New("pre-skip must be from 0 to 65535")
+	errInvalidPayload  = errors.New("payload type must be from 0 to 127")
+	errEmptyStream     = errors.New("the stream holds no valid Opus packet")
+	errListWithOutputs = errors.New("-list takes the capture only")
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "opuspcap:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+// options holds the command line.
+type options struct {
+	list        bool
+	ssrc        string
+	payloadType int
+	wav         string
+	float       bool
+	preSkip     int
+	quiet       bool
+}
+
+func parseFlags(args []string, stderr io.Writer) (*options, []string, error) {
+	opts := &options{}
+	flags := flag.NewFlagSet("opuspcap", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, errUsage)
+		flags.PrintDefaults()
+	}
+
+	flags.BoolVar(&opts.list, "list", false, "list the RTP streams of the capture")
+	flags.StringVar(&opts.ssrc, "ssrc", "", "SSRC of the stream to extract, such as 0x1234abcd")
+	flags.IntVar(&opts.payloadType, "pt", -1, "payload type of the stream to extract")
+	flags.StringVar(&opts.wav, "wav", "", "also decode the stream to this WAV file")
+	flags.BoolVar(&opts.float, "float", false, "write 32-bit float samples to the WAV file")
+	flags.IntVar(&opts.preSkip, "pre-skip", 0,
+		"samples at 48 kHz to drop from the start, such as the encoder's lookahead of 312")
+	flags.BoolVar(&opts.quiet, "quiet", false, "do not print statistics")
+
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, err
+	}
+	switch {
+	case opts.list && flags.NArg() != 1:
+		return nil, nil, errListWithOutputs
+	case !opts.list && flags.NArg() != 2:
+		flags.Usage()
+
+		return nil, nil, errUsage
+	case opts.payloadType > 127:
+		return nil, nil, errInvalidPayload
+	case opts.preSkip < 0 || opts.preSkip > 0xFFFF:
+		return nil, nil, errInvalidPreSkip
+	}
+
+	return opts, flags.Args(), nil
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	opts, files, err := parseFlags(args, stderr)
+	if err != nil {
+		return err
+	}
+
+	in := stdin
+	if files[0] != "-" {
+		file, err := os.Open(files[0]) // #nosec G304 -- the user names the capture to read.
+		if err != nil {
+			return err
+		}
+		defer func() { _ = file.Close() }()
+		in = file
+	}
+	streams, err := readStreams(in)
+	if err != nil {
+		return err
+	}
+	if opts.list {
+		return listStreams(stdout, streams)
+	}
+
+	selected, err := selectStream(streams, opts)
+	if err != nil {
+		return err
+	}
+	line := newTimeline(selected)
+	if len(line.packets) == 0 {
+		return fmt.Errorf("%w: %v", errEmptyStream, selected.key)
+	}
+
+	err = writeFile(files[1], stdout, func(out io.Writer) error { return writeOgg(out, line, opts.preSkip) })
+	if err != nil {
+		return err
+	}
+	if opts.wav != "" {
+		err = writeFile(opts.wav, stdout, func(out io.Writer) error { return writeWAV(out, line, opts) })
+		if err != nil {
+			return err
+		}
+	}
+	if !opts.quiet {
+		printStatistics(stderr, selected, line)
+	}
+
+	return nil
+}
+
+func listStreams(w io.Writer, streams []*stream) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "SSRC\tPT\tSOURCE\tDESTINATION\tPACKETS\tOPUS")
+	for _, s := range streams {
+		fmt.Fprintf(table, "%#08x\t%d\t%v\t%v\t%d\t%d\n", s.key.ssrc, s.key.payloadType,
+			s.key.source, s.key.destination, len(s.packets), s.opusPackets)
+	}
+
+	return table.Flush()
+}
+
+// selectStream returns the stream with the most Opus packets among those the
+// command line selects.
+func selectStream(streams []*stream, opts *options) (*stream, error) {
+	var ssrc uint64
+	if opts.ssrc != "" {
+		var err error
+		if ssrc, err = strconv.ParseUint(opts.ssrc, 0, 32); err != nil {
+			return nil, fmt.Errorf("%w: %q", errInvalidSSRC, opts.ssrc)
+		}
+	}
+
+	var selected *stream
+	for _, s := range streams {
+		if opts.ssrc != "" && uint64(s.key.ssrc) != ssrc ||
+			opts.payloadType >= 0 && int(s.key.payloadType) != opts.payloadType {
+			continue
+		}
+		if s.opusPackets > 0 && (selected == nil || s.opusPackets > selected.opusPackets) {
+			selected = s
+		}
+	}
+	if selected == nil {
+		return nil, errNoStream
+	}
+
+	return selected, nil
+}
+
+// writeFile creates name, or uses stdout for "-", and writes it.
+func writeFile(name string, stdout io.Writer, write func(io.Writer) error) error {
+	if name == "-" {
+		return write(stdout)
+	}
+	file, err := os.Create(name) // #nosec G304 -- the user names the file to write.
+	if err != nil {
+		return err
+	}
+	err = write(file)
+
+	return errors.Join(err, file.Close())
+}
+
+// writeOgg writes the packets of line as an Ogg Opus file, filling its gaps.
+func writeOgg(out io.Writer, line *timeline, preSkip int) error {
+	writer, err := oggwriter.NewWith(out, oggreader.OggHeader{
+		Channels:   uint8(line.channels), //nolint:gosec // 1 or 2.
+		SampleRate: opusSampleRate,
+		PreSkip:    uint16(preSkip), //nolint:gosec // checked by parseFlags.
+	})
+	if err != nil {
+		return err
+	}
+
+	end := int64(0)
+	for i, packet := range line.packets {
+		if gap := packet.start - end; gap > 0 && i > 0 {
+			for _, filler := range fillers(line.packets[i-1].data[0], gap) {
+				samples, _ := opus.PacketSampleCount(filler, opusSampleRate)
+				end += int64(samples)
+				if err = writer.WritePacket(filler, uint64(end)); err != nil { //nolint:gosec // G115
+					return errors.Join(err, writer.Close())
+				}
+			}
+		}
+		end += int64(packet.duration)
+		if err = writer.WritePacket(packet.data, uint64(end)); err != nil { //nolint:gosec // G115
+			return errors.Join(err, writer.Close())
+		}
+	}
+
+	return writer.Close()
+}
+
+// fillers returns packets of empty frames that last gap samples, or as nearly
+// as whole 2.5 ms frames can. They are code 3 packets of frames configured as
+// those of the packet before the gap, whose TOC byte toc is, for as long as
+// they fit, then fullband CELT frames of 10, 5 and 2.5 ms for the rest.
+func fillers(toc byte, gap int64) [][]byte {
+	frame, _ := opus.PacketSampleCount([]byte{toc &^ frameCodeArbitrary}, opusSampleRate)
+	perPacket := int64(min(maxFrameCount, maxPacketDuration/frame))
+
+	var packets [][]byte
+	for frames := gap / int64(frame); frames > 0; {
+		count := min(frames, perPacket)
+		packets = append(packets, []byte{toc | frameCodeArbitrary, byte(count)})
+		frames -= count
+	}
+	gap %= int64(frame)
+	stereo := toc & tocStereoFlag
+	for configuration, samples := byte(celtFullband10ms), int64(opusSampleRate/100); samples >= opusSampleRate/400; {
+		if gap >= samples {
+			packets = append(packets, []byte{configuration<<3 | stereo})
+			gap -= samples
+		} else {
+			configuration, samples = configuration-1, samples/2
+		}
+	}
+
+	return packets
+}
+
+// writeWAV decodes the packets of line into a WAV file, concealing its gaps.
+func writeWAV(out io.Writer, line *timeline, opts *options) error {
+	decoder, err := opus.NewDecoderWithOutput(opusSampleRate, line.channels)
+	if err != nil {
+		return err
+	}
+	writer, err := wav.NewWriter(out, opusSampleRate, line.channels, opts.float)
+	if err != nil {
+		return err
+	}
+
+	dec := &wavDecoding{
+		decoder:  decoder,
+		wav:      writer,
+		channels: line.channels,
+		skip:     opts.preSkip,
+		pcm:      make([]float32, maxPacketDuration*line.channels),
+		plc:      make([]float32, concealStep*line.channels),
+	}
+	end := int64(0)
+	for _, packet := range line.packets {
+		if gap := packet.start - end; gap > 0 {
+			if err = dec.fill(int(gap), packet.data); err != nil {
+				return err
+			}
+		}
+		end = max(end, packet.start) + int64(packet.duration)
+		n, err := decoder.DecodeToFloat32(packet.data, dec.pcm)
+		if err != nil {
+			return err
+		}
+		if err = dec.write(dec.pcm[:n*line.channels]); err != nil {
+			return err
+		}
+	}
+
+	return writer.Close()
+}
+
+// wavDecoding carries a timeline through the decoder into a WAV file.
+type wavDecoding struct {
+	decoder  opus.Decoder
+	wav      *wav.Writer
+	channels int
+	// skip is how many samples per channel are still to be dropped from the
+	// start.
+	skip int
+	pcm  []float32
+	plc  []float32
+}
+
+// fill fills a gap of samples per channel before next. The last whole 10 ms
+// of it, up to a packet's worth, go through DecodeFEC, which recovers a lost
+// frame from next's in-band FEC data or else conceals it; the rest is
+// concealed.
+func (d *wavDecoding) fill(samples int, next []byte) error {
+	recovered := min(samples, maxPacketDuration) / fecStep * fecStep
+	if err := d.conceal(samples - recovered); err != nil {
+		return err
+	}
+	if recovered == 0 {
+		return nil
+	}
+	out := d.pcm[:recovered*d.channels]
+	if err := d.decoder.DecodeFECToFloat32(next, out); err != nil {
+		return err
+	}
+
+	return d.write(out)
+}
+
+// conceal fills samples per channel with packet loss concealment, in 20 ms
+// steps, the last of which may be cut short.
+func (d *wavDecoding) conceal(samples int) error {
+	for samples > 0 {
+		if err := d.decoder.DecodePLCToFloat32(d.plc); err != nil {
+			return err
+		}
+		n := min(samples, concealStep)
+		if err := d.write(d.plc[:n*d.channels]); err != nil {
+			return err
+		}
+		samples -= n
+	}
+
+	return nil
+}
+
+// write writes what is left of pcm once the pre-skip is dropped.
+func (d *wavDecoding) write(pcm []float32) error {
+	skip := min(d.skip, len(pcm)/d.channels)
+	d.skip -= skip
+
+	return d.wav.Write(pcm[skip*d.channels:])
+}
+
+func printStatistics(w io.Writer, s *stream, line *timeline) {
+	last := line.packets[len(line.packets)-1]
+	duration := time.Duration(last.start+int64(last.duration)) * time.Second / opusSampleRate
+	fmt.Fprintf(w, "Extracted %v: %d packets, %v of audio\n", s.key, len(line.packets), duration)
+
+	expected := len(line.packets) + line.invalid + line.lost
+	fmt.Fprintf(w, "Lost %d packets (%.1f%%), %d reordered, %d duplicated, %d invalid\n",
+		line.lost, 100*float64(line.lost)/float64(expected), line.reordered, line.duplicates, line.invalid)
+}
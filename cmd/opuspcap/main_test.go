@@ -0,0 +1,223 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pion/opus"
+	"github.com/pion/opus/internal/wav"
+	"github.com/pion/opus/pkg/oggreader"
+	"github.com/pion/opus/pkg/rtpopus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testSSRC = 0x11111111
+	testPT   = 111
+)
+
+// capture builds a pcap file of raw IPv4 packets from UDP payloads.
+func capture(datagrams ...[]byte) []byte {
+	file := binary.LittleEndian.AppendUint32(nil, 0xA1B2C3D4)
+	file = binary.LittleEndian.AppendUint16(file, 2)
+	file = binary.LittleEndian.AppendUint16(file, 4)
+	file = append(file, make([]byte, 8)...)
+	file = binary.LittleEndian.AppendUint32(file, 65535)
+	file = binary.LittleEndian.AppendUint32(file, 101) // raw IP
+	for i, payload := range datagrams {
+		packet := []byte{0x45, 0}
+		packet = binary.BigEndian.AppendUint16(packet, uint16(28+len(payload))) //nolint:gosec // G115
+		packet = append(packet, 0, 0, 0, 0, 64, 17, 0, 0, 192, 0, 2, 1, 198, 51, 100, 2)
+		packet = binary.BigEndian.AppendUint16(packet, 5004)
+		packet = binary.BigEndian.AppendUint16(packet, 6000)
+		packet = binary.BigEndian.AppendUint16(packet, uint16(8+len(payload))) //nolint:gosec // G115
+		packet = append(packet, 0, 0)
+		packet = append(packet, payload...)
+
+		file = binary.LittleEndian.AppendUint32(file, uint32(i/50)) //nolint:gosec // G115
+		file = binary.LittleEndian.AppendUint32(file, uint32(i%50*20000))
+		file = binary.LittleEndian.AppendUint32(file, uint32(len(packet))) //nolint:gosec // G115
+		file = binary.LittleEndian.AppendUint32(file, uint32(len(packet))) //nolint:gosec // G115
+		file = append(file, packet...)
+	}
+
+	return file
+}
+
+func rtp(t *testing.T, ssrc uint32, payloadType uint8, sequence uint16, timestamp uint32, payload []byte) []byte {
+	t.Helper()
+
+	packet := rtpopus.Packet{
+		Header: rtpopus.Header{
+			PayloadType:    payloadType,
+			SequenceNumber: sequence,
+			Timestamp:      timestamp,
+			SSRC:           ssrc,
+		},
+		Payload: payload,
+	}
+	data, err := packet.Marshal()
+	require.NoError(t, err)
+
+	return data
+}
+
+// testCapture encodes 50 packets of a 1 kHz sine, whose sequence numbers and
+// timestamps wrap around, and captures them with packets 3 and 4 swapped,
+// packet 10 twice, packet 20 lost, and 100 ms of silence left out before
+// packet 30. A stream of another payload type and an RTCP packet come along.
+func testCapture(t *testing.T) []byte {
+	t.Helper()
+
+	encoder, err := opus.NewEncoder()
+	require.NoError(t, err)
+	pcm := make([]float32, 960)
+	var datagrams [][]byte
+	for frame := range 50 {
+		for i := range pcm {
+			pcm[i] = float32(0.25 * math.Sin(2*math.Pi*1000*float64(frame*960+i)/48000))
+		}
+		packet := make([]byte, 1276)
+		n, err := encoder.EncodeFloat32(pcm, packet)
+		require.NoError(t, err)
+
+		timestamp := uint32(math.MaxUint32-2000) + uint32(frame*960) //nolint:gosec // G115
+		if frame >= 30 {
+			timestamp += 5 * 960
+		}
+		sequence := uint16(65530 + frame) //nolint:gosec // G115
+		datagrams = append(datagrams,
+			rtp(t, testSSRC, testPT, sequence, timestamp, packet[:n]),
+			rtp(t, 0x22222222, 0, uint16(frame), uint32(frame*160), bytes.Repeat([]byte{0xFF}, 160))) //nolint:gosec // G115
+	}
+	datagrams = append(datagrams, []byte{0x80, 0xC8, 0, 6, 0x11, 0x11, 0x11, 0x11})
+
+	datagrams[6], datagrams[8] = datagrams[8], datagrams[6]
+	datagrams = append(datagrams[:40], datagrams[41:]...)
+	datagrams = append(datagrams[:21], append([][]byte{datagrams[20]}, datagrams[21:]...)...)
+
+	return capture(datagrams...)
+}
+
+func TestList(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "in.pcap")
+	require.NoError(t, os.WriteFile(name, testCapture(t), 0o600))
+
+	var stdout bytes.Buffer
+	require.NoError(t, run([]string{"-list", name}, nil, &stdout, io.Discard))
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, []string{"SSRC", "PT", "SOURCE", "DESTINATION", "PACKETS", "OPUS"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"0x11111111", "111", "192.0.2.1:5004", "198.51.100.2:6000", "50", "50"},
+		strings.Fields(lines[1]))
+	assert.Equal(t, []string{"0x22222222", "0", "192.0.2.1:5004", "198.51.100.2:6000", "50", "0"},
+		strings.Fields(lines[2]))
+}
+
+func TestExtract(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "in.pcap")
+	output := filepath.Join(dir, "out.opus")
+	wavName := filepath.Join(dir, "out.wav")
+	require.NoError(t, os.WriteFile(input, testCapture(t), 0o600))
+
+	var stderr bytes.Buffer
+	require.NoError(t, run([]string{"-pre-skip", "312", "-wav", wavName, input, output}, nil, io.Discard, &stderr))
+	assert.Contains(t, stderr.String(), "SSRC 0x11111111, payload type 111")
+	assert.Contains(t, stderr.String(), "Lost 1 packets (2.0%), 1 reordered, 1 duplicated, 0 invalid")
+
+	file, err := os.Open(output) // #nosec G304
+	require.NoError(t, err)
+	defer func() { require.NoError(t, file.Close()) }()
+	reader, header, err := oggreader.NewWith(file)
+	require.NoError(t, err)
+	assert.Equal(t, uint8(1), header.Channels)
+	assert.Equal(t, uint16(312), header.PreSkip)
+
+	// 49 packets and two fillers, one for the lost packet and one for the
+	// silence, on a timeline of 55 frames.
+	var packets [][]byte
+	var granule uint64
+	for {
+		packet, pageHeader, err := reader.ParseNextPacket()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		if bytes.HasPrefix(packet, []byte("OpusTags")) {
+			continue
+		}
+		packets = append(packets, packet)
+		granule = pageHeader.GranulePosition
+	}
+	require.Len(t, packets, 51)
+	total := 0
+	for _, packet := range packets {
+		samples, err := opus.PacketSampleCount(packet, opusSampleRate)
+		require.NoError(t, err)
+		total += samples
+	}
+	assert.Equal(t, 55*960, total)
+	assert.Equal(t, uint64(55*960), granule)
+	assert.Equal(t, []byte{packets[19][0] | frameCodeArbitrary, 1}, packets[20], "the lost packet")
+	assert.Equal(t, []byte{packets[19][0] | frameCodeArbitrary, 5}, packets[30], "the silence")
+
+	data, err := os.ReadFile(wavName) // #nosec G304
+	require.NoError(t, err)
+	assert.Equal(t, "RIFF", string(data[:4]))
+	assert.Equal(t, uint32(2*(55*960-312)), binary.LittleEndian.Uint32(data[40:]))
+	var sum float64
+	for i := wav.HeaderSize; i+2 <= len(data); i += 2 {
+		sample := float64(int16(binary.LittleEndian.Uint16(data[i:]))) / (1 << 15) //nolint:gosec // G115
+		sum += sample * sample
+	}
+	assert.InDelta(t, 0.25/math.Sqrt2, math.Sqrt(sum/float64(55*960-312)), 0.08)
+}
+
+func TestSelect(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "in.pcap")
+	require.NoError(t, os.WriteFile(input, testCapture(t), 0o600))
+	output := filepath.Join(dir, "out.opus")
+
+	require.NoError(t, run([]string{"-quiet", "-ssrc", "0x11111111", "-pt", "111", input, output}, nil, nil, nil))
+	for _, args := range [][]string{{"-ssrc", "0x22222222"}, {"-pt", "0"}, {"-ssrc", "7"}} {
+		err := run(append(args, input, output), nil, io.Discard, io.Discard)
+		assert.ErrorIs(t, err, errNoStream, args)
+	}
+
+	assert.ErrorIs(t, run([]string{"-ssrc", "x", input, output}, nil, io.Discard, io.Discard), errInvalidSSRC)
+	assert.ErrorIs(t, run([]string{"-pre-skip", "70000", input, output}, nil, io.Discard, io.Discard),
+		errInvalidPreSkip)
+	assert.ErrorIs(t, run([]string{"-list", input, output}, nil, io.Discard, io.Discard), errListWithOutputs)
+	assert.ErrorIs(t, run([]string{input}, nil, io.Discard, io.Discard), errUsage)
+}
+
+func TestFillers(t *testing.T) {
+	// 120 ms packets of 20 ms stereo CELT frames, then 10 and 2.5 ms ones,
+	// leaving 60 samples.
+	gap := int64(50*960 + 480 + 120 + 60)
+	packets := fillers(0xFC, gap)
+	require.Len(t, packets, 11)
+	assert.Equal(t, []byte{0xFF, 6}, packets[0])
+	assert.Equal(t, []byte{0xFF, 2}, packets[8])
+	total := 0
+	for _, packet := range packets {
+		samples, err := opus.PacketSampleCount(packet, opusSampleRate)
+		require.NoError(t, err)
+		assert.NotZero(t, packet[0]&tocStereoFlag)
+		total += samples
+	}
+	assert.Equal(t, int(gap-60), total)
+}
@@ -0,0 +1,187 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"slices"
+
+	"github.com/pion/opus"
+	"github.com/pion/opus/pkg/pcapreader"
+	"github.com/pion/opus/pkg/rtpopus"
+)
+
+const (
+	// RTCP packets multiplexed onto the RTP port parse as RTP packets with
+	// these payload types (RFC 5761 Section 4).
+	firstRTCPPayloadType = 72
+	lastRTCPPayloadType  = 76
+
+	tocStereoFlag = 0x04
+)
+
+// streamKey tells the RTP streams of a capture apart.
+type streamKey struct {
+	ssrc                uint32
+	payloadType         uint8
+	source, destination netip.AddrPort
+}
+
+// capturedPacket is an RTP packet as the capture holds it.
+type capturedPacket struct {
+	header  rtpopus.Header
+	payload []byte
+}
+
+// stream is an RTP stream of the capture, its packets in arrival order.
+type stream struct {
+	key     streamKey
+	packets []capturedPacket
+	// opusPackets counts the packets whose payload is a valid Opus packet.
+	opusPackets int
+}
+
+// readStreams reads every UDP datagram of a capture that parses as an RTP
+// packet, and returns the streams they make up in the order they start.
+func readStreams(in io.Reader) ([]*stream, error) {
+	reader, err := pcapreader.NewWith(in)
+	if err != nil {
+		return nil, err
+	}
+
+	var streams []*stream
+	byKey := map[streamKey]*stream{}
+	for {
+		packet, err := reader.ReadPacket()
+		if errors.Is(err, io.EOF) {
+			return streams, nil
+		} else if err != nil {
+			return nil, err
+		}
+		datagram, err := pcapreader.ParseUDP(packet)
+		if err != nil {
+			continue // anything but UDP
+		}
+		var rtp rtpopus.Packet
+		if rtp.Unmarshal(datagram.Payload) != nil ||
+			rtp.PayloadType >= firstRTCPPayloadType && rtp.PayloadType <= lastRTCPPayloadType {
+			continue
+		}
+
+		key := streamKey{
+			ssrc:        rtp.SSRC,
+			payloadType: rtp.PayloadType,
+			source:      datagram.Source,
+			destination: datagram.Destination,
+		}
+		s, ok := byKey[key]
+		if !ok {
+			s = &stream{key: key}
+			byKey[key] = s
+			streams = append(streams, s)
+		}
+		s.packets = append(s.packets, capturedPacket{
+			header:  rtp.Header,
+			payload: rtp.Payload,
+		})
+		if _, err = opus.PacketSampleCount(rtp.Payload, rtpopus.ClockRate); err == nil {
+			s.opusPackets++
+		}
+	}
+}
+
+func (k streamKey) String() string {
+	return fmt.Sprintf("SSRC %#08x, payload type %d, %v -> %v", k.ssrc, k.payloadType, k.source, k.destination)
+}
+
+// timedPacket is an Opus packet of a stream, placed on its timeline.
+type timedPacket struct {
+	data []byte
+	// start is the RTP timestamp of the first sample, counted from that of
+	// the stream's first packet, and duration the samples the packet holds.
+	start    int64
+	duration int
+}
+
+// timeline is the Opus packets of a stream in sequence number order.
+type timeline struct {
+	packets  []timedPacket
+	channels int
+
+	// lost counts the sequence numbers missing from the capture, reordered
+	// the packets that arrived after one with a later sequence number,
+	// duplicates those that arrived more than once, and invalid those whose
+	// payload is no valid Opus packet.
+	lost, reordered, duplicates, invalid int
+}
+
+// sequenced is a captured packet with its extended sequence number, which
+// counts on past 16 bits.
+type sequenced struct {
+	*capturedPacket
+	sequence int64
+}
+
+// newTimeline puts the packets of s in sequence number order, dropping
+// duplicates and invalid packets.
+func newTimeline(s *stream) *timeline {
+	line := &timeline{channels: 1}
+	if len(s.packets) == 0 {
+		return line
+	}
+
+	// Sequence numbers are extended in arrival order, nearest the highest
+	// one so far.
+	packets := make([]sequenced, len(s.packets))
+	highest := int64(s.packets[0].header.SequenceNumber)
+	for i := range s.packets {
+		packet := &s.packets[i]
+		sequence := highest + int64(int16(packet.header.SequenceNumber-uint16(highest))) //nolint:gosec // G115
+		if sequence < highest {
+			line.reordered++
+		}
+		highest = max(highest, sequence)
+		packets[i] = sequenced{capturedPacket: packet, sequence: sequence}
+	}
+	slices.SortStableFunc(packets, func(a, b sequenced) int {
+		return int(a.sequence - b.sequence)
+	})
+
+	depacketizer := rtpopus.NewDepacketizer()
+	first, last := packets[0].sequence, packets[len(packets)-1].sequence
+	// Timestamps are extended in sequence number order.
+	base := int64(packets[0].header.Timestamp)
+	timestamp := base
+	received := 0
+	for i, packet := range packets {
+		if i > 0 && packet.sequence == packets[i-1].sequence {
+			line.duplicates++
+
+			continue
+		}
+		received++
+		timestamp += int64(int32(packet.header.Timestamp - uint32(timestamp))) //nolint:gosec // G115
+
+		sample, err := depacketizer.DepacketizePacket(&rtpopus.Packet{Header: packet.header, Payload: packet.payload})
+		if err != nil {
+			line.invalid++
+
+			continue
+		}
+		if sample.Packet[0]&tocStereoFlag != 0 {
+			line.channels = 2
+		}
+		line.packets = append(line.packets, timedPacket{
+			data:     sample.Packet,
+			start:    timestamp - base,
+			duration: sample.Duration,
+		})
+	}
+	line.lost = int(last-first+1) - received
+
+	return line
+}
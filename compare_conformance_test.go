@@ -0,0 +1,173 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build conformance
+
+package opus
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/pion/opus/pkg/opuscompare"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// compareParityTolerance is how far apart, in quality points, opus_compare
+// and opuscompare.Compare may score the same pair.
+const compareParityTolerance = 0.1
+
+// TestOpusCompareParity scores the same pairs of signals with the reference
+// opus_compare and with opuscompare.Compare, which the conformance tests use
+// in its place. The pairs run from identical to failing, at every rate and
+// channel count opus_compare takes, with real codec output among them.
+func TestOpusCompareParity(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the reference tools use a POSIX-oriented Makefile")
+	}
+	refDir := os.Getenv(envRFC6716Reference)
+	if refDir == "" {
+		t.Skipf("%s is required to run opus_compare", envRFC6716Reference)
+	}
+	_, opusCompare := buildRFC6716ReferenceTools(t, refDir)
+
+	const frames = 50
+	original := make([]byte, frames*encoderConformanceFrameSize*4)
+	for i := range frames * encoderConformanceFrameSize {
+		left := int16(math.Round(32767 * encoderConformanceTone(i, 440, 17)))
+		right := int16(math.Round(32767 * encoderConformanceTone(i, 3000, 23)))
+		binary.LittleEndian.PutUint16(original[4*i:], uint16(left))    //nolint:gosec // G115
+		binary.LittleEndian.PutUint16(original[4*i+2:], uint16(right)) //nolint:gosec // G115
+	}
+	dir := t.TempDir()
+	refPath := filepath.Join(dir, "ref.pcm")
+	writeQualityBytes(t, refPath, original)
+
+	for _, rate := range []int{8000, 12000, 16000, 24000, 48000} {
+		for _, channels := range []int{1, 2} {
+			ref, err := readConformanceReference(refPath, channels)
+			require.NoError(t, err)
+			// Line the decode up with the original, as opus_compare does not
+			// align its inputs.
+			decoded, skip := decodeParitySignal(t, original, rate, channels)
+			decodedRef := ref[:len(ref)-skip*48000/rate*channels]
+
+			pairs := map[string][]int16{"decoded": decoded[skip*channels:]}
+			if rate == 48000 {
+				pairs["identical"] = ref
+				for _, amplitude := range []float64{4, 64, 1024, 8192} {
+					pairs["noise_"+strconv.Itoa(int(amplitude))] = addParityNoise(ref, amplitude)
+				}
+			}
+			for name, test := range pairs {
+				reference := ref
+				if name == "decoded" {
+					reference = decodedRef
+				}
+				t.Run(fmt.Sprintf("rate_%d/channels_%d/%s", rate, channels, name), func(t *testing.T) {
+					scoreParityPair(t, opusCompare, reference, test, rate, channels)
+				})
+			}
+		}
+	}
+}
+
+// scoreParityPair runs both implementations on one pair. opus_compare always
+// reads the reference as stereo, so a mono one is written with both channels
+// alike, which it downmixes back to the same signal.
+func scoreParityPair(t *testing.T, opusCompare string, ref, test []int16, rate, channels int) {
+	t.Helper()
+
+	refBytes := make([]byte, 0, len(ref)*4/channels)
+	for _, sample := range ref {
+		for range 3 - channels {
+			refBytes = binary.LittleEndian.AppendUint16(refBytes, uint16(sample)) //nolint:gosec // G115
+		}
+	}
+	testBytes := make([]byte, 0, len(test)*2)
+	for _, sample := range test {
+		testBytes = binary.LittleEndian.AppendUint16(testBytes, uint16(sample)) //nolint:gosec // G115
+	}
+	dir := t.TempDir()
+	refPath := filepath.Join(dir, "ref.pcm")
+	testPath := filepath.Join(dir, "test.pcm")
+	writeQualityBytes(t, refPath, refBytes)
+	writeQualityBytes(t, testPath, testBytes)
+
+	out, _ := runOpusCompare(opusCompare, rate, channels, refPath, testPath)
+	reported := opusCompareInternalError(out)
+	require.NotEmptyf(t, reported, "opus_compare produced no score:\n%s", out)
+	weightedError, err := strconv.ParseFloat(reported, 64)
+	require.NoError(t, err)
+	want := 100 * (1 - 0.5*math.Log(1+weightedError)/math.Log(1.13))
+
+	quality, pass := opuscompare.Compare(ref, test, rate, channels)
+	t.Logf("opus_compare %.3f, Compare %.3f", want, quality)
+	assert.InDelta(t, want, quality, compareParityTolerance)
+	if math.Abs(want) > compareParityTolerance {
+		assert.Equal(t, want >= 0, pass)
+	}
+}
+
+// opusCompareInternalError returns the weighted error opus_compare prints,
+// whether the pair passed or not.
+func opusCompareInternalError(opusCompareOutput []byte) string {
+	const marker = "weighted error is "
+
+	output := string(opusCompareOutput)
+	index := strings.Index(output, marker)
+	if index < 0 {
+		return ""
+	}
+	fields := strings.Fields(output[index+len(marker):])
+	if len(fields) == 0 {
+		return ""
+	}
+
+	return strings.TrimSuffix(fields[0], ")")
+}
+
+// decodeParitySignal encodes the 48 kHz stereo s16le original with the Go
+// encoder and decodes it at rate with channels. It returns the decode and
+// the codec delay in its samples per channel.
+func decodeParitySignal(t *testing.T, original []byte, rate, channels int) ([]int16, int) {
+	t.Helper()
+
+	encoder, err := NewEncoder(WithChannels(2), WithBitrate(64000))
+	require.NoError(t, err)
+	decoder, err := NewDecoderWithOutput(rate, channels)
+	require.NoError(t, err)
+
+	frameBytes := encoderConformanceFrameSize * 4
+	packet := make([]byte, maxOpusFrameSize+1)
+	pcm := make([]int16, rate/50*channels)
+	var decoded []int16
+	for offset := 0; offset+frameBytes <= len(original); offset += frameBytes {
+		n, err := encoder.Encode(original[offset:offset+frameBytes], packet)
+		require.NoError(t, err)
+		_, err = decoder.DecodeToInt16(packet[:n], pcm)
+		require.NoError(t, err)
+		decoded = append(decoded, pcm...)
+	}
+
+	return decoded, encoder.Lookahead()*rate/48000 + decoder.ResampleDelay()
+}
+
+func addParityNoise(pcm []int16, amplitude float64) []int16 {
+	random := rand.New(rand.NewSource(1)) //nolint:gosec
+	noisy := make([]int16, len(pcm))
+	for i, sample := range pcm {
+		noisy[i] = int16(max(math.MinInt16, min(math.MaxInt16, float64(sample)+amplitude*(2*random.Float64()-1))))
+	}
+
+	return noisy
+}
@@ -7,8 +7,10 @@ package opus
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -17,6 +19,9 @@ import (
 	"strings"
 	"sync"
 	"testing"
+
+	"github.com/pion/opus/pkg/opuscompare"
+	"github.com/pion/opus/pkg/opusdemo"
 )
 
 type conformanceKey struct {
@@ -31,10 +36,9 @@ type conformanceResult struct {
 }
 
 const (
-	envRFC6716Reference      = "OPUS_RFC6716_REFERENCE"
-	envRFC6716Testvectors    = "OPUS_RFC6716_TESTVECTORS"
-	envConformanceMarkdown   = "OPUS_CONFORMANCE_MARKDOWN"
-	maxConformancePacketSize = maxOpusFrameSize * 48
+	envRFC6716Reference    = "OPUS_RFC6716_REFERENCE"
+	envRFC6716Testvectors  = "OPUS_RFC6716_TESTVECTORS"
+	envConformanceMarkdown = "OPUS_CONFORMANCE_MARKDOWN"
 )
 
 func TestRFC6716Conformance(t *testing.T) {
@@ -49,9 +53,7 @@ func TestRFC6716Conformance(t *testing.T) {
 		"07", "08", "09", "10", "11", "12",
 	}
 
-	refDir, vectorRoot := conformanceDataPaths(t)
-
-	_, opusCompare := buildRFC6716ReferenceTools(t, refDir)
+	vectorRoot := conformanceVectorRoot(t)
 	results := make(map[conformanceKey]conformanceResult)
 	var resultsMu sync.Mutex
 
@@ -78,17 +80,9 @@ func TestRFC6716Conformance(t *testing.T) {
 
 							bitstream := conformanceBitstreamPath(t, vectorRoot, vector)
 							referencePCMs := conformanceReferencePCMs(vectorRoot, vector)
-							goPCM := filepath.Join(t.TempDir(), "go.pcm")
-
-							decodeRFC6716Vector(t, rate, channels, bitstream, goPCM)
-							quality = compareRFC6716Output(
-								t,
-								opusCompare,
-								rate,
-								channels,
-								referencePCMs,
-								goPCM,
-							)
+
+							goPCM := decodeRFC6716Vector(t, rate, channels, bitstream)
+							quality = compareRFC6716Output(t, rate, channels, referencePCMs, goPCM)
 						},
 					)
 				}
@@ -100,52 +94,71 @@ func TestRFC6716Conformance(t *testing.T) {
 	writeConformanceMarkdown(t, os.Getenv(envConformanceMarkdown), results, rates, channelCounts, vectors)
 }
 
-func conformanceDataPaths(t *testing.T) (refDir, vectorRoot string) {
+func conformanceVectorRoot(t *testing.T) string {
 	t.Helper()
 
-	refDir = os.Getenv(envRFC6716Reference)
-	vectorRoot = os.Getenv(envRFC6716Testvectors)
-	if refDir == "" || vectorRoot == "" {
-		t.Skipf("%s and %s are required to run RFC 6716 conformance", envRFC6716Reference, envRFC6716Testvectors)
+	vectorRoot := os.Getenv(envRFC6716Testvectors)
+	if vectorRoot == "" {
+		t.Skipf("%s is required to run RFC 6716 conformance", envRFC6716Testvectors)
 	}
 
-	return refDir, vectorRoot
+	return vectorRoot
 }
 
-func compareRFC6716Output(
-	t *testing.T,
-	opusCompare string,
-	rate, channels int,
-	referencePCMs []string,
-	goPCM string,
-) string {
+// compareRFC6716Output scores goPCM against each reference in turn with the
+// opus_compare metric and returns the first passing quality.
+func compareRFC6716Output(t *testing.T, rate, channels int, referencePCMs []string, goPCM []int16) string {
 	t.Helper()
 
 	checkedReference := false
 	var failures []string
 	for _, referencePCM := range referencePCMs {
-		if _, err := os.Stat(referencePCM); err != nil {
+		reference, err := readConformanceReference(referencePCM, channels)
+		if err != nil {
 			continue
 		}
 		checkedReference = true
-		out, err := runOpusCompare(opusCompare, rate, channels, referencePCM, goPCM)
-		if err == nil {
-			quality := opusCompareQuality(out)
+		score, pass := opuscompare.Compare(reference, goPCM, rate, channels)
+		quality := fmt.Sprintf("%.1f", score)
+		if pass {
 			printOpusCompareQuality(t, quality)
 
 			return quality
 		}
-		failures = append(failures, fmt.Sprintf("%s: %v\n%s", referencePCM, err, out))
+		failures = append(failures, fmt.Sprintf("%s: quality %s", referencePCM, quality))
 	}
 	if !checkedReference {
 		t.Fatalf("no reference PCM found among %v", referencePCMs)
 	}
 
-	t.Fatalf("opus_compare failed for all references:\n%s", strings.Join(failures, "\n"))
+	t.Fatalf("opus_compare metric failed for all references:\n%s", strings.Join(failures, "\n"))
 
 	return ""
 }
 
+// readConformanceReference reads a reference decoder output, which is always
+// 48 kHz stereo, and like opus_compare downmixes it when channels is 1.
+func readConformanceReference(path string, channels int) ([]int16, error) {
+	data, err := os.ReadFile(path) // #nosec G304
+	if err != nil {
+		return nil, err
+	}
+
+	frames := len(data) / 4
+	reference := make([]int16, frames*channels)
+	for i := range frames {
+		left := int16(binary.LittleEndian.Uint16(data[4*i:]))    //nolint:gosec // G115
+		right := int16(binary.LittleEndian.Uint16(data[4*i+2:])) //nolint:gosec // G115
+		if channels == 1 {
+			reference[i] = int16((int32(left) + int32(right)) / 2)
+		} else {
+			reference[2*i], reference[2*i+1] = left, right
+		}
+	}
+
+	return reference, nil
+}
+
 func conformanceBitstreamPath(t *testing.T, vectorRoot, vector string) string {
 	t.Helper()
 
@@ -215,7 +228,7 @@ func buildRFC6716ReferenceTools(t *testing.T, refDir string) (opusDemo, opusComp
 	return opusDemo, opusCompare
 }
 
-func decodeRFC6716Vector(t *testing.T, rate, channels int, bitstream, outPath string) {
+func decodeRFC6716Vector(t *testing.T, rate, channels int, bitstream string) []int16 {
 	t.Helper()
 
 	in, err := os.Open(bitstream)
@@ -224,57 +237,36 @@ func decodeRFC6716Vector(t *testing.T, rate, channels int, bitstream, outPath st
 	}
 	defer in.Close()
 
-	out, err := os.Create(outPath)
+	decoder, err := NewDecoderWithOutput(rate, channels)
 	if err != nil {
-		t.Fatalf("create Go PCM output: %v", err)
+		t.Fatalf("create Go decoder: %v", err)
 	}
-	defer out.Close()
 
-	decoder, err := NewDecoderWithOutput(rate, channels)
+	reader, err := opusdemo.NewReader(in)
 	if err != nil {
-		t.Fatalf("create Go decoder: %v", err)
+		t.Fatalf("create bitstream reader: %v", err)
 	}
 
 	pcm := make([]byte, 5760*2*2)
+	var out []int16
 	for frame := 0; ; frame++ {
-		var payloadLen uint32
-		if err := binary.Read(in, binary.BigEndian, &payloadLen); err != nil {
-			if err == io.EOF {
-				return
-			}
-			t.Fatalf("frame %d: read payload length: %v", frame, err)
-		}
-		var wantFinalRange uint32
-		if err := binary.Read(in, binary.BigEndian, &wantFinalRange); err != nil {
-			t.Fatalf("frame %d: read final range: %v", frame, err)
-		}
-
-		if payloadLen > maxConformancePacketSize {
-			t.Fatalf("frame %d: payload length %d exceeds %d", frame, payloadLen, maxConformancePacketSize)
-		}
-		payload := make([]byte, payloadLen)
-		if _, err := io.ReadFull(in, payload); err != nil {
-			t.Fatalf("frame %d: read payload: %v", frame, err)
+		packet, err := reader.ReadPacket()
+		if errors.Is(err, io.EOF) {
+			return out
+		} else if err != nil {
+			t.Fatalf("frame %d: %v", frame, err)
 		}
+		payload := packet.Payload
 
 		if _, _, err := decoder.Decode(payload, pcm); err != nil {
 			t.Fatalf("frame %d: Go decode: %v", frame, err)
 		}
 
-		gotFinalRange, err := conformanceFinalRange(&decoder)
-		if err != nil {
-			t.Fatalf("frame %d: final range unavailable: %v", frame, err)
-		}
-		if wantFinalRange != 0 && gotFinalRange != wantFinalRange {
-			t.Fatalf(
-				"frame %d: final range mismatch: want 0x%08x got 0x%08x",
-				frame,
-				wantFinalRange,
-				gotFinalRange,
-			)
+		if err := reader.Verify(decoder.FinalRange()); err != nil {
+			t.Fatalf("frame %d: %v", frame, err)
 		}
 
-		samplesPerChannel, err := conformancePacketSamplesPerChannel(payload, rate)
+		samplesPerChannel, err := PacketSampleCount(payload, rate)
 		if err != nil {
 			t.Fatalf("frame %d: packet duration: %v", frame, err)
 		}
@@ -283,8 +275,8 @@ func decodeRFC6716Vector(t *testing.T, rate, channels int, bitstream, outPath st
 		if outBytes > len(pcm) {
 			t.Fatalf("frame %d: PCM output length %d exceeds buffer length %d", frame, outBytes, len(pcm))
 		}
-		if _, err := out.Write(pcm[:outBytes]); err != nil {
-			t.Fatalf("frame %d: write Go PCM output: %v", frame, err)
+		for i := 0; i < outBytes; i += 2 {
+			out = append(out, int16(binary.LittleEndian.Uint16(pcm[i:]))) //nolint:gosec // G115
 		}
 	}
 }
@@ -300,21 +292,34 @@ func runOpusCompare(opusCompare string, rate, channels int, referencePCM, goPCM
 	return cmd.CombinedOutput()
 }
 
-func opusCompareQuality(opusCompareOutput []byte) string {
-	const prefix = "Opus quality metric:"
+// compareStereoS16LE scores test against ref, both 48 kHz interleaved stereo
+// s16le, with opuscompare.Compare the way opus_compare -s would. It also
+// returns the weighted error opus_compare prints, which unlike the quality is
+// still reported below the conformance threshold.
+func compareStereoS16LE(t *testing.T, ref, test []byte) (quality, weightedError float64, pass bool) {
+	t.Helper()
 
-	for _, line := range strings.Split(string(opusCompareOutput), "\n") {
-		if strings.HasPrefix(line, prefix) {
-			fields := strings.Fields(strings.TrimSpace(strings.TrimPrefix(line, prefix)))
-			if len(fields) == 0 {
-				return ""
-			}
+	quality, pass = opuscompare.Compare(s16LEToInt16(ref), s16LEToInt16(test), 48000, 2)
+	if math.IsNaN(quality) {
+		t.Fatalf("cannot compare %d bytes of PCM against %d", len(test), len(ref))
+	}
 
-			return fields[0]
-		}
+	return quality, opusCompareWeightedError(quality), pass
+}
+
+// opusCompareWeightedError turns a quality back into the weighted error
+// opus_compare derives it from, Q = 100*(1 - 0.5*ln(1+err)/ln(1.13)).
+func opusCompareWeightedError(quality float64) float64 {
+	return math.Pow(1.13, 2*(1-quality/100)) - 1
+}
+
+func s16LEToInt16(pcm []byte) []int16 {
+	out := make([]int16, len(pcm)/2)
+	for i := range out {
+		out[i] = int16(binary.LittleEndian.Uint16(pcm[2*i:])) //nolint:gosec // G115
 	}
 
-	return ""
+	return out
 }
 
 func printOpusCompareQuality(t *testing.T, quality string) {
@@ -436,32 +441,3 @@ func conformanceMatrixCell(results map[conformanceKey]conformanceResult, key con
 
 	return "PASS"
 }
-
-func conformanceFinalRange(d *Decoder) (uint32, error) {
-	switch d.previousMode {
-	case configurationModeCELTOnly:
-		return d.celtDecoder.FinalRange(), nil
-	case configurationModeSilkOnly:
-		return d.rangeFinal, nil
-	case configurationModeHybrid:
-		return d.rangeFinal, nil
-	default:
-		return 0, fmt.Errorf("unsupported final range mode: %s", d.previousMode)
-	}
-}
-
-func conformancePacketSamplesPerChannel(packet []byte, rate int) (int, error) {
-	if len(packet) == 0 {
-		return 0, errTooShortForTableOfContentsHeader
-	}
-
-	tocHeader := tableOfContentsHeader(packet[0])
-	frames, err := parsePacketFrames(packet, tocHeader)
-	if err != nil {
-		return 0, err
-	}
-
-	nanoseconds := tocHeader.configuration().frameDuration().nanoseconds()
-
-	return int(int64(len(frames)) * int64(nanoseconds) * int64(rate) / 1000000000), nil
-}
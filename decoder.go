@@ -9,9 +9,10 @@ import (
 
 	"github.com/pion/opus/internal/bitdepth"
 	"github.com/pion/opus/internal/celt"
-	"github.com/pion/opus/internal/rangecoding"
 	silkresample "github.com/pion/opus/internal/resample/silk"
+	sincresample "github.com/pion/opus/internal/resample/sinc"
 	"github.com/pion/opus/internal/silk"
+	"github.com/pion/opus/pkg/rangecoding"
 )
 
 const (
@@ -50,10 +51,18 @@ type Decoder struct {
 	softClipMem            softClipMemory
 	plcBuffer              []float32
 	plcOutputBuffer        []float32
-	sampleRate             int
-	channels               int
-	lastPacketBandwidth    Bandwidth
-	lastPacketIsStereo     bool
+	outputResampler        sincresample.Resampler
+	outputResampleBuffer   []float32
+	resampleQuality        ResampleQuality
+	// sampleRate is the rate Opus decodes at. outputSampleRate is the rate
+	// the caller asked for when it is not one of those, and 0 otherwise.
+	sampleRate          int
+	outputSampleRate    int
+	channels            int
+	lastPacketBandwidth Bandwidth
+	lastPacketIsStereo  bool
+	// analysis is set while an Analyzer decodes a packet.
+	analysis *packetAnalysis
 }
 
 type silkRedundancyFade struct {
@@ -78,6 +87,12 @@ func NewDecoder() Decoder {
 }
 
 // NewDecoderWithOutput creates a new Opus Decoder with the requested output sample rate and channel count.
+//
+// Opus decodes natively at 8, 12, 16, 24 and 48 kHz. Any other rate from 8 to
+// 192 kHz that holds a whole number of samples per 10 ms, such as 44.1 kHz, is
+// reached by resampling 48 kHz output with a windowed sinc filter, which keeps
+// its state across packets and concealment; see SetResampleQuality and
+// ResampleDelay.
 func NewDecoderWithOutput(sampleRate, channels int) (Decoder, error) {
 	decoder := Decoder{
 		silkDecoder: silk.NewDecoder(),
@@ -93,9 +108,7 @@ func NewDecoderWithOutput(sampleRate, channels int) (Decoder, error) {
 
 // Init initializes a pre-allocated Opus decoder.
 func (d *Decoder) Init(sampleRate, channels int) error {
-	switch sampleRate {
-	case 8000, 12000, 16000, 24000, 48000:
-	default:
+	if !isNativeSampleRate(sampleRate) && !isResampledOutputRate(sampleRate) {
 		return errInvalidSampleRate
 	}
 	switch channels {
@@ -105,7 +118,15 @@ func (d *Decoder) Init(sampleRate, channels int) error {
 	}
 
 	d.sampleRate = sampleRate
+	d.outputSampleRate = 0
 	d.channels = channels
+	if !isNativeSampleRate(sampleRate) {
+		d.sampleRate = celtSampleRate
+		d.outputSampleRate = sampleRate
+		if err := d.initOutputResampler(); err != nil {
+			return err
+		}
+	}
 	d.silkDecoder = silk.NewDecoder()
 	d.celtDecoder.Reset()
 	d.celtBuffer = d.celtBuffer[:0]
@@ -343,6 +364,17 @@ func parseFrameLength(in []byte) (frameLength int, bytesRead int, err error) {
 	return int(in[0]) + 4*int(in[1]), 2, nil
 }
 
+// appendFrameLength appends the one or two byte coding of frameLength, which
+// must not exceed 1275 (RFC 6716 Section 3.2.1).
+func appendFrameLength(dst []byte, frameLength int) []byte {
+	if frameLength < 252 {
+		return append(dst, byte(frameLength))
+	}
+	first := 252 + frameLength&3
+
+	return append(dst, byte(first), byte((frameLength-first)/4)) //nolint:gosec // G115
+}
+
 func parsePacketFramesCode0(in []byte) ([][]byte, error) {
 	// [R2] Code 0 uses an implicit frame length for the whole payload, so it
 	// must not exceed the 1275-byte maximum.
@@ -549,6 +581,79 @@ func parsePacketFrames(in []byte, tocHeader tableOfContentsHeader) ([][]byte, er
 	}
 }
 
+// parseSelfDelimitedPacket reads the self-delimited packet at the start of in
+// (RFC 6716 Appendix B) and returns it in the undelimited form the other
+// parsers take, along with how many bytes of in it takes up. Self-delimiting
+// adds the length of the last frame, or of every frame of a CBR packet, right
+// before the frame data; dropping it and whatever follows the packet gives
+// the undelimited form.
+func parseSelfDelimitedPacket(in []byte) (packet []byte, size int, err error) {
+	// [R1] A self-delimited packet still starts with a TOC byte.
+	if len(in) < 1 {
+		return nil, 0, fmt.Errorf("%w: %w", errMalformedPacket, errTooShortForTableOfContentsHeader)
+	}
+
+	tocHeader := tableOfContentsHeader(in[0])
+	offset, padding, frameCount, isVBR := 1, 0, 1, false
+	if tocHeader.frameCode() == frameCodeArbitraryFrames {
+		if len(in) < 2 {
+			return nil, 0, fmt.Errorf("%w: code 3 packet missing frame count byte", errMalformedPacket)
+		}
+		var hasPadding bool
+		var count byte
+		isVBR, hasPadding, count = parseFrameCountByte(in[1])
+		frameCount = int(count)
+		offset = 2
+		if hasPadding {
+			var payloadEnd int
+			offset, payloadEnd, err = parsePacketPadding(in, offset)
+			if err != nil {
+				return nil, 0, err
+			}
+			padding = len(in) - payloadEnd
+		}
+	} else if tocHeader.frameCode() != frameCodeOneFrame {
+		frameCount = 2
+		isVBR = tocHeader.frameCode() == frameCodeTwoDifferentFrames
+	}
+
+	// The lengths of all but the last frame of a VBR packet come first.
+	frameData := 0
+	if isVBR {
+		for range frameCount - 1 {
+			frameSize, bytesRead, err := parseFrameLength(in[offset:])
+			if err != nil {
+				return nil, 0, err
+			}
+			offset += bytesRead
+			frameData += frameSize
+		}
+	}
+	lengthStart := offset
+	frameSize, bytesRead, err := parseFrameLength(in[offset:])
+	if err != nil {
+		return nil, 0, err
+	}
+	offset += bytesRead
+	if isVBR {
+		frameData += frameSize
+	} else {
+		frameData = frameCount * frameSize
+	}
+
+	size = offset + frameData + padding
+	if size > len(in) {
+		return nil, 0, fmt.Errorf("%w: self-delimited packet overruns buffer", errMalformedPacket)
+	}
+	packet = append(append(make([]byte, 0, size-bytesRead), in[:lengthStart]...), in[offset:size]...)
+	// The undelimited form must follow the framing rules as well.
+	if _, err = parsePacketFrames(packet, tocHeader); err != nil {
+		return nil, 0, err
+	}
+
+	return packet, size, nil
+}
+
 func (d *Decoder) decode(
 	in []byte,
 	out []float32,
@@ -650,6 +755,7 @@ func (d *Decoder) decodeCeltFrames(
 	frameOutputSamples := outputFrameSampleCount * decodedChannelCount
 	for i, encodedFrame := range encodedFrames {
 		frameOut := out[i*frameOutputSamples : (i+1)*frameOutputSamples]
+		d.beginCELTAnalysis()
 		if err = d.celtDecoder.DecodeToSampleRate(
 			encodedFrame,
 			frameOut,
@@ -662,6 +768,7 @@ func (d *Decoder) decodeCeltFrames(
 		); err != nil {
 			return 0, 0, false, 0, 0, err
 		}
+		d.endFrameAnalysis(encodedFrame, nil)
 		d.previousMode = configurationModeCELTOnly
 		d.previousRedundancy = false
 		if len(encodedFrame) <= 1 {
@@ -768,6 +875,7 @@ func (d *Decoder) decodeHybridFrame(
 
 	silkOutputChannelCount := min(streamChannelCount, outputChannelCount)
 	silkInternal := resizeFloat32Buffer(&d.hybridSilkBuffer, silkSamplesPerChannel*silkOutputChannelCount)
+	d.beginFrameAnalysis()
 	if err := d.silkDecoder.DecodeWithRangeToChannels(
 		&d.rangeDecoder,
 		silkInternal,
@@ -790,6 +898,7 @@ func (d *Decoder) decodeHybridFrame(
 		d.celtDecoder.Reset()
 		clear(d.celtBuffer)
 	}
+	d.beginCELTAnalysis()
 	if err = d.celtDecoder.DecodeWithRangeToSampleRate(
 		encodedFrame[:redundancy.celtDataLen],
 		out,
@@ -803,6 +912,7 @@ func (d *Decoder) decodeHybridFrame(
 	); err != nil {
 		return err
 	}
+	d.endFrameAnalysis(encodedFrame, &redundancy)
 
 	silkPCM := resizeFloat32Buffer(&d.hybridSilkPCM, outputFrameSampleCount*silkOutputChannelCount)
 	if err = d.resampleHybridSilk(silkInternal, silkPCM, silkOutputChannelCount); err != nil {
@@ -1082,6 +1192,7 @@ func (d *Decoder) decodeSilkFrames(
 		previousRedundancy := d.previousRedundancy
 		frameOut := out[i*frameSampleCount : (i+1)*frameSampleCount]
 		d.rangeDecoder.Init(encodedFrame)
+		d.beginFrameAnalysis()
 		err := d.silkDecoder.DecodeWithRangeToChannels(
 			&d.rangeDecoder,
 			frameOut,
@@ -1097,6 +1208,7 @@ func (d *Decoder) decodeSilkFrames(
 		if err != nil {
 			return 0, 0, false, 0, 0, err
 		}
+		d.endFrameAnalysis(encodedFrame, &redundancy)
 		if redundancy.present {
 			if !redundancy.celtToSilk {
 				d.celtDecoder.Reset()
@@ -1166,18 +1278,38 @@ func silkOutputChannelCount(isStereo bool, requestedChannelCount int) int {
 	return 1
 }
 
-//nolint:cyclop
+// validateState reports whether the decoder was set up with a sample rate and
+// channel count, which a zero Decoder lacks.
+func (d *Decoder) validateState() error {
+	switch {
+	case d.sampleRate == 0:
+		return errInvalidSampleRate
+	case d.channels == 0:
+		return errInvalidChannelCount
+	default:
+		return nil
+	}
+}
+
 func (d *Decoder) decodeToFloat32(
 	in []byte,
 	out []float32,
 ) (samplesPerChannel int, bandwidth Bandwidth, isStereo bool, err error) {
-	if d.sampleRate == 0 {
-		return 0, 0, false, errInvalidSampleRate
+	if err := d.validateState(); err != nil {
+		return 0, 0, false, err
 	}
-	if d.channels == 0 {
-		return 0, 0, false, errInvalidChannelCount
+	if d.outputSampleRate != 0 {
+		return d.decodeResampledToFloat32(in, out)
 	}
 
+	return d.decodePacketToFloat32(in, out)
+}
+
+// decodePacketToFloat32 decodes in at the decoding rate.
+func (d *Decoder) decodePacketToFloat32(
+	in []byte,
+	out []float32,
+) (samplesPerChannel int, bandwidth Bandwidth, isStereo bool, err error) {
 	bandwidth, decodedSampleRate, isStereo, sampleCount, decodedChannelCount, err := d.decode(in, d.silkBuffer)
 	if err != nil {
 		return 0, 0, false, err
@@ -1203,9 +1335,21 @@ func (d *Decoder) decodePLCToFloat32(out []float32) error {
 	if err := d.validatePLCOutput(len(out)); err != nil {
 		return err
 	}
+	samplesPerChannel := d.sampleRate / 50
+	if d.outputSampleRate != 0 {
+		return d.resampleOutput(out, samplesPerChannel, func(decoded []float32) error {
+			return d.concealToFloat32(decoded, samplesPerChannel)
+		})
+	}
+
+	return d.concealToFloat32(out, samplesPerChannel)
+}
 
+// concealToFloat32 conceals samplesPerChannel lost samples, a multiple of
+// 10 ms, in pieces of at most 20 ms, the longest a single PLC step covers.
+func (d *Decoder) concealToFloat32(out []float32, samplesPerChannel int) error {
 	if d.previousMode == 0 {
-		clear(out)
+		clear(out[:samplesPerChannel*d.channels])
 
 		return nil
 	}
@@ -1214,20 +1358,24 @@ func (d *Decoder) decodePLCToFloat32(out []float32) error {
 	if d.previousRedundancy {
 		mode = configurationModeCELTOnly
 	}
-	samplesPerChannel := d.sampleRate / 50
-	var err error
-	switch mode {
-	case configurationModeSilkOnly:
-		err = d.decodeSilkPLCFrame(out, samplesPerChannel, d.lastPacketBandwidth, false)
-	case configurationModeCELTOnly:
-		err = d.decodeCeltPLCFrame(out, samplesPerChannel, false)
-	case configurationModeHybrid:
-		err = d.decodeHybridPLCFrame(out, samplesPerChannel)
-	default:
-		err = fmt.Errorf("%w: %d", errUnsupportedConfigurationMode, mode)
-	}
-	if err != nil {
-		return err
+	maxChunk := d.sampleRate / 50
+	for offset := 0; offset < samplesPerChannel; offset += maxChunk {
+		chunk := min(samplesPerChannel-offset, maxChunk)
+		chunkOut := out[offset*d.channels : (offset+chunk)*d.channels]
+		var err error
+		switch mode {
+		case configurationModeSilkOnly:
+			err = d.decodeSilkPLCFrame(chunkOut, chunk, d.lastPacketBandwidth, false)
+		case configurationModeCELTOnly:
+			err = d.decodeCeltPLCFrame(chunkOut, chunk, false)
+		case configurationModeHybrid:
+			err = d.decodeHybridPLCFrame(chunkOut, chunk)
+		default:
+			err = fmt.Errorf("%w: %d", errUnsupportedConfigurationMode, mode)
+		}
+		if err != nil {
+			return err
+		}
 	}
 	d.rangeFinal = 0
 
@@ -1235,16 +1383,14 @@ func (d *Decoder) decodePLCToFloat32(out []float32) error {
 }
 
 func (d *Decoder) validatePLCOutput(sampleCount int) error {
-	switch {
-	case d.sampleRate == 0:
-		return errInvalidSampleRate
-	case d.channels == 0:
-		return errInvalidChannelCount
-	case sampleCount != d.sampleRate/50*d.channels:
+	if err := d.validateState(); err != nil {
+		return err
+	}
+	if sampleCount != d.outputRate()/50*d.channels {
 		return errInvalidPLCFrameSize
-	default:
-		return nil
 	}
+
+	return nil
 }
 
 func (d *Decoder) decodeCeltPLCFrame(out []float32, samplesPerChannel int, hybrid bool) error {
@@ -1295,6 +1441,20 @@ func (d *Decoder) decodeSilkPLCFrame(
 		return err
 	}
 
+	return d.writeSilkPLCOutput(out, internal, samplesPerChannel, internalChannelCount, bandwidth, hybrid)
+}
+
+// writeSilkPLCOutput lifts SILK audio decoded without a regular frame, by PLC
+// or from LBRR data, to the output rate and writes, or in hybrid mode adds,
+// it to out.
+func (d *Decoder) writeSilkPLCOutput(
+	out []float32,
+	internal []float32,
+	samplesPerChannel int,
+	internalChannelCount int,
+	bandwidth Bandwidth,
+	hybrid bool,
+) error {
 	resampled := resizeFloat32Buffer(
 		&d.plcOutputBuffer,
 		samplesPerChannel*internalChannelCount,
@@ -1514,9 +1674,13 @@ func (d *Decoder) copyResampledSamples(out []float32, channelCount int) {
 	}
 }
 
-func float32ToInt16(in []float32, out []int16, sampleCount int) {
-	for i := range sampleCount {
-		out[i] = bitdepth.Float32ToSigned16(in[i])
+// float32ToInt16 quantizes in through the soft clip libopus applies on its
+// integer path, the one Decode and DecodeToPCM apply too, so all integer
+// output of a stream shares one clip state.
+func (d *Decoder) float32ToInt16(in []float32, out []int16) {
+	softClip(in, d.channels, &d.softClipMem)
+	for i, sample := range in {
+		out[i] = bitdepth.Float32ToSigned16(sample)
 	}
 }
 
@@ -1564,6 +1728,7 @@ func (d *Decoder) DecodeFloat32(in []byte, out []float32) (bandwidth Bandwidth,
 }
 
 // DecodeToInt16 decodes Opus data into signed 16-bit PCM and returns the sample count per channel.
+// Like Decode, it soft-clips peaks past full scale rather than clamping them.
 func (d *Decoder) DecodeToInt16(in []byte, out []int16) (int, error) {
 	if cap(d.floatBuffer) < len(out) {
 		d.floatBuffer = make([]float32, len(out))
@@ -1575,22 +1740,63 @@ func (d *Decoder) DecodeToInt16(in []byte, out []int16) (int, error) {
 		return 0, err
 	}
 
-	float32ToInt16(d.floatBuffer, out, sampleCount*d.channels)
+	d.float32ToInt16(d.floatBuffer[:sampleCount*d.channels], out)
 
 	return sampleCount, nil
 }
 
-// DecodePLC recovers one missing 20 ms packet into signed 16-bit PCM.
+// DecodePLC recovers one missing 20 ms packet into signed 16-bit PCM. The
+// concealed audio goes through the same soft clip as DecodeToInt16, and
+// carries its state on to the packets decoded next.
 func (d *Decoder) DecodePLC(out []int16) error {
 	d.floatBuffer = resizeFloat32Buffer(&d.floatBuffer, len(out))
 	if err := d.decodePLCToFloat32(d.floatBuffer); err != nil {
 		return err
 	}
-	float32ToInt16(d.floatBuffer, out, len(out))
+	d.float32ToInt16(d.floatBuffer, out)
 
 	return nil
 }
 
+// DecodePLCToFloat32 is DecodePLC with float32 output, which is neither
+// soft-clipped nor quantized.
+func (d *Decoder) DecodePLCToFloat32(out []float32) error {
+	return d.decodePLCToFloat32(out)
+}
+
+// PitchLag returns the pitch period of the audio decoded last, in samples at
+// the output rate, when it came from a voiced SILK frame of a SILK-only or
+// Hybrid packet. voiced is false for CELT-only audio and unvoiced frames.
+func (d *Decoder) PitchLag() (samples int, voiced bool) {
+	var internalSampleRate int
+	switch d.previousMode {
+	case configurationModeSilkOnly:
+		internalSampleRate = d.lastPacketBandwidth.SampleRate()
+	case configurationModeHybrid:
+		internalSampleRate = BandwidthWideband.SampleRate()
+	default:
+		return 0, false
+	}
+	lag, voiced := d.silkDecoder.PitchLag()
+	if !voiced {
+		return 0, false
+	}
+
+	return lag * d.outputRate() / internalSampleRate, true
+}
+
+// FinalRange returns the final state of the range decoder after the packet
+// decoded last, OPUS_GET_FINAL_RANGE in libopus. It matches the encoder's
+// final range for the packet whenever the two are in sync (RFC 6716 Section
+// 4.1), so a mismatch points to a corrupted packet or a desynchronized
+// decoder. For a packet with several frames it is the last frame's; for a
+// Hybrid packet, or one with a redundant CELT frame, it is the XOR of the
+// final ranges of the layers. It is 0 after concealment and after a frame of
+// at most one byte, which carries no range-coded data.
+func (d *Decoder) FinalRange() uint32 {
+	return d.rangeFinal
+}
+
 // DecodeToFloat32 decodes Opus data into float32 PCM and returns the sample count per channel.
 func (d *Decoder) DecodeToFloat32(in []byte, out []float32) (int, error) {
 	sampleCount, _, _, err := d.decodeToFloat32(in, out)
@@ -137,7 +137,7 @@ func TestNewDecoderWithOutput(t *testing.T) {
 	assert.Equal(t, 16000, decoder.sampleRate)
 	assert.Equal(t, 2, decoder.channels)
 
-	_, err = NewDecoderWithOutput(44100, 1)
+	_, err = NewDecoderWithOutput(44050, 1)
 	assert.ErrorIs(t, err, errInvalidSampleRate)
 
 	_, err = NewDecoderWithOutput(48000, 3)
@@ -252,6 +252,34 @@ func TestDecodePLC(t *testing.T) {
 	}
 }
 
+func TestDecodePLCToFloat32(t *testing.T) {
+	packet := firstTinyOggPacket(t)
+
+	decoder, err := NewDecoderWithOutput(48000, 2)
+	require.NoError(t, err)
+	reference, err := NewDecoderWithOutput(48000, 2)
+	require.NoError(t, err)
+
+	decoded := make([]float32, 5760*2)
+	_, err = decoder.DecodeToFloat32(packet, decoded)
+	require.NoError(t, err)
+	_, err = reference.DecodeToInt16(packet, make([]int16, len(decoded)))
+	require.NoError(t, err)
+
+	plc := make([]float32, 960*2)
+	referencePLC := make([]int16, len(plc))
+	for range 2 {
+		require.NoError(t, decoder.DecodePLCToFloat32(plc))
+		require.NoError(t, reference.DecodePLC(referencePLC))
+		assert.NotEqual(t, make([]float32, len(plc)), plc)
+		for i, sample := range referencePLC {
+			assert.InDelta(t, float64(sample), float64(plc[i])*32768, 1)
+		}
+	}
+
+	assert.ErrorIs(t, decoder.DecodePLCToFloat32(make([]float32, 959)), errInvalidPLCFrameSize)
+}
+
 func TestDecodePLCFrameSizeValidation(t *testing.T) {
 	decoder, err := NewDecoderWithOutput(24000, 1)
 	require.NoError(t, err)
@@ -494,7 +522,7 @@ func TestDecodeSilkOnlyRedundancyHeader(t *testing.T) {
 	frame := make([]byte, 32)
 	// Start after the SILK payload so the remaining bytes become redundant CELT
 	// data, as described by RFC 6716 Section 4.5.1.2.
-	decoder.rangeDecoder.SetInternalValues(frame, 32, 1<<30, 0)
+	decoder.rangeDecoder.SetState(frame, 32, 1<<30, 0)
 
 	redundancy, err := decoder.decodeSilkOnlyRedundancyHeader(frame, BandwidthMediumband)
 
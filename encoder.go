@@ -9,6 +9,7 @@ import (
 	"math"
 
 	"github.com/pion/opus/internal/celt"
+	silkresample "github.com/pion/opus/internal/resample/silk"
 	"github.com/pion/opus/internal/silk"
 )
 
@@ -54,7 +55,7 @@ const (
 	encodeMaxChannels  = 2
 )
 
-// encodeScratch holds what Encode and EncodeFloat32 would otherwise allocate on
+// encodeScratch holds what the Encode methods would otherwise allocate on
 // every call. The CELT layer already reuses its own analysis buffers; without
 // this the wrapper around it still churned ~16 kB per frame, which at 50 frames
 // a second is a lot of garbage for a server carrying many streams at once.
@@ -62,6 +63,10 @@ type encodeScratch struct {
 	pcm      [encodeFrameSamples * encodeMaxChannels]float32
 	deinter  [encodeMaxChannels][encodeFrameSamples]float32
 	channels [encodeMaxChannels][]float32
+	// silkFiltered holds EncodeSILK input once DC is removed, and silk once
+	// downsampled to the internal rate.
+	silkFiltered [encodeFrameSamples]int16
+	silk         [encodeFrameSamples]int16
 }
 
 // celtOnlyFullband20msConfig is the TOC config number (bits 3..7) for
@@ -90,6 +95,13 @@ const silkComplexityInterpolationThreshold = 4
 // dcBlockCutoffHz rather than living in internal/silk.
 const silkDCBlockCutoffHz = 3.0
 
+// silkDelayCompensationDivisor sets how long EncodeSILK holds back input it
+// downsamples: a sample rate's worth divided by it, 1.25 ms. The encoder-side
+// and decoder-side SILK resamplers add about 1.25 ms between them, so this
+// brings the SILK path to the 2.5 ms Lookahead reports for CELT, the way
+// libopus's delay_compensation lines its SILK and CELT paths up.
+const silkDelayCompensationDivisor = 800
+
 // Encoder encodes PCM into Opus packets.
 type Encoder struct {
 	celtEncoder    celt.Encoder
@@ -102,11 +114,29 @@ type Encoder struct {
 	vbr            bool
 	constrainedVBR bool
 	lossRate       int
+	inbandFEC      bool
 	bandwidth      Bandwidth
 	maxBandwidth   Bandwidth
 	silkDCBlockMem float32
 	stereoWidth    int
 	scratch        encodeScratch
+	audioLevel     AudioLevel
+	rangeFinal     uint32
+	// packetExtensions is the padding SetPacketExtensions codes.
+	packetExtensions []byte
+
+	// silkInputRate is the rate EncodeSILK takes its PCM at, 0 for the
+	// internal rate of the bandwidth asked for.
+	silkInputRate int
+	// silkInputResampler brings EncodeSILK input above the SILK internal
+	// rate down to it; silkResamplerInputRate and silkResamplerOutputRate are
+	// the rates it was set up for.
+	silkInputResampler      silkresample.Resampler
+	silkResamplerInputRate  int
+	silkResamplerOutputRate int
+	silkInputFloat          []float32
+	silkResampled           []float32
+	silkDelayLine           []int16
 }
 
 // EncoderOption configures an Encoder during construction.
@@ -207,6 +237,20 @@ func WithConstrainedVBR(cvbr bool) EncoderOption {
 	}
 }
 
+// WithInbandFEC enables in-band forward error correction: each SILK packet
+// also carries a coarser copy of the previous packet's audio, the LBRR frames
+// of RFC 6716 Section 4.2.4, which a decoder that lost that packet recovers
+// with DecodeFEC. As in libopus the copies are only sent while SetLossRate
+// reports expected loss above zero. Only EncodeSILK produces them; CELT
+// packets have no room for LBRR data.
+func WithInbandFEC(enabled bool) EncoderOption {
+	return func(e *Encoder) error {
+		e.inbandFEC = enabled
+
+		return nil
+	}
+}
+
 // WithBandwidth sets the encoder bandwidth explicitly (Narrowband through
 // Fullband; Mediumband is SILK-only and not supported here). Use
 // WithMaxBandwidth instead to cap auto-selection rather than fixing it.
@@ -227,6 +271,23 @@ func WithBandwidth(bw Bandwidth) EncoderOption {
 	}
 }
 
+// WithSILKInputRate sets the rate EncodeSILK takes its PCM at: 8, 12, 16, 24
+// or 48 kHz. Input above the internal rate of the SILK bandwidth asked for is
+// downsampled to it; input below is rejected. The default of 0 takes PCM at
+// the internal rate of whichever bandwidth is asked for.
+func WithSILKInputRate(rate int) EncoderOption {
+	return func(e *Encoder) error {
+		switch rate {
+		case 0, 8000, 12000, 16000, 24000, celtSampleRate:
+			e.silkInputRate = rate
+
+			return nil
+		default:
+			return fmt.Errorf("%w: %d", errInvalidSampleRate, rate)
+		}
+	}
+}
+
 // WithMaxBandwidth sets the maximum bandwidth the auto-select algorithm may
 // choose. Has no effect when an explicit bandwidth is set via WithBandwidth.
 func WithMaxBandwidth(bw Bandwidth) EncoderOption {
@@ -278,6 +339,8 @@ func NewEncoder(opts ...EncoderOption) (*Encoder, error) {
 	encoder.celtEncoder.SetVBR(encoder.vbr)
 	encoder.celtEncoder.SetConstrainedVBR(encoder.constrainedVBR)
 	encoder.celtEncoder.SetLossRate(encoder.lossRate)
+	encoder.silkEncoder.SetPacketLossPercentage(encoder.lossRate)
+	encoder.silkEncoder.SetInbandFEC(encoder.inbandFEC)
 	encoder.celtEncoder.SetComplexity(encoder.complexity)
 	encoder.celtEncoder.SetBitrate(encoder.bitrate)
 	encoder.silkEncoder.SetUseInterpolatedNLSFs(encoder.complexity >= silkComplexityInterpolationThreshold)
@@ -335,10 +398,17 @@ func (e *Encoder) SetLossRate(rate int) error {
 	}
 	e.lossRate = rate
 	e.celtEncoder.SetLossRate(rate)
+	e.silkEncoder.SetPacketLossPercentage(rate)
 
 	return nil
 }
 
+// SetInbandFEC enables or disables in-band FEC; see WithInbandFEC.
+func (e *Encoder) SetInbandFEC(enabled bool) {
+	e.inbandFEC = enabled
+	e.silkEncoder.SetInbandFEC(enabled)
+}
+
 // SetBandwidth sets the encoder bandwidth, overriding auto-selection.
 func (e *Encoder) SetBandwidth(bw Bandwidth) error {
 	return WithBandwidth(bw)(e)
@@ -351,6 +421,15 @@ func (e *Encoder) SetMaxBandwidth(bw Bandwidth) error {
 	return WithMaxBandwidth(bw)(e)
 }
 
+// SetSILKInputRate updates the rate EncodeSILK takes its PCM at.
+func (e *Encoder) SetSILKInputRate(rate int) error {
+	return WithSILKInputRate(rate)(e)
+}
+
+// SILKInputRate returns the rate EncodeSILK takes its PCM at, 0 for the
+// internal rate of the bandwidth asked for.
+func (e *Encoder) SILKInputRate() int { return e.silkInputRate }
+
 // Application returns the current encoder application mode.
 func (e *Encoder) Application() Application { return e.application }
 
@@ -365,6 +444,9 @@ func (e *Encoder) ConstrainedVBR() bool { return e.constrainedVBR }
 // LossRate returns the expected packet loss rate (0-100 percent).
 func (e *Encoder) LossRate() int { return e.lossRate }
 
+// InbandFEC returns whether in-band FEC is enabled.
+func (e *Encoder) InbandFEC() bool { return e.inbandFEC }
+
 // Bandwidth returns the configured bandwidth (BandwidthAuto by default).
 func (e *Encoder) Bandwidth() Bandwidth { return e.bandwidth }
 
@@ -372,6 +454,92 @@ func (e *Encoder) Bandwidth() Bandwidth { return e.bandwidth }
 // choose.
 func (e *Encoder) MaxBandwidth() Bandwidth { return e.maxBandwidth }
 
+// SampleRate returns the input sample rate in Hz.
+func (e *Encoder) SampleRate() int { return e.sampleRate }
+
+// Channels returns the input channel count.
+func (e *Encoder) Channels() int { return e.channels }
+
+// FrameSize returns the number of samples per channel Encode and
+// EncodeFloat32 take for one packet.
+func (e *Encoder) FrameSize() int { return e.frameSampleCount() }
+
+// Lookahead returns the encoder's algorithmic delay in samples per channel at
+// the input rate, the value libopus reports as OPUS_GET_LOOKAHEAD. A decoder
+// must discard this many samples from the start of the stream to line its
+// output up with the input; it is the pre-skip an Ogg Opus file carries
+// (RFC 7845 Section 4.2).
+//
+// The delay is the 2.5 ms CELT MDCT overlap. libopus adds another 4 ms of
+// delay compensation so it can switch to SILK or Hybrid mid-stream; this
+// encoder does not, so it reports the overlap alone. EncodeSILK delays input
+// it downsamples so that the SILK path lines up with it too; PCM handed to it
+// at the SILK internal rate is encoded as is.
+func (e *Encoder) Lookahead() int { return e.sampleRate / 400 }
+
+// FinalRange returns the final state of the range encoder after the packet
+// encoded last, OPUS_GET_FINAL_RANGE in libopus. A decoder that has decoded
+// the packet reports the same value from its FinalRange unless the two are
+// out of sync or the packet was corrupted on the way. Like the decoder's, it
+// is 0 for a frame of at most one byte.
+func (e *Encoder) FinalRange() uint32 { return e.rangeFinal }
+
+// SetPacketExtensions sets the extensions every packet encoded from now on
+// carries in its padding, which makes them code 3 packets. nil drops them.
+// Packets hold a single frame, so the Frame of each must be 0. In CBR mode the
+// frame gives up the bytes they take, so packets keep their size.
+func (e *Encoder) SetPacketExtensions(extensions []PacketExtension) error {
+	for _, extension := range extensions {
+		if extension.Frame != 0 {
+			return fmt.Errorf("%w: frame %d of a packet of 1", errInvalidPacketExtension, extension.Frame)
+		}
+	}
+	padding, err := AppendPacketExtensions(nil, extensions)
+	if err != nil {
+		return err
+	}
+	e.packetExtensions = padding
+
+	return nil
+}
+
+// packetExtensionsOverhead returns how many bytes the packet extensions add
+// to a packet: the frame count byte, the padding length and the padding.
+func (e *Encoder) packetExtensionsOverhead() int {
+	if len(e.packetExtensions) == 0 {
+		return 0
+	}
+
+	return 1 + len(appendPaddingLength(nil, len(e.packetExtensions))) + len(e.packetExtensions)
+}
+
+// addPacketExtensions turns the code 0 packet in out[:n] into a code 3 one
+// that carries the packet extensions, and returns its length. out must have
+// room for packetExtensionsOverhead more bytes.
+func (e *Encoder) addPacketExtensions(out []byte, n int) int {
+	if len(e.packetExtensions) == 0 {
+		return n
+	}
+
+	header := appendPaddingLength([]byte{out[0] | byte(frameCodeArbitraryFrames), 0b01000001}, len(e.packetExtensions))
+	copy(out[len(header):], out[tocHeaderBytes:n])
+	copy(out, header)
+	n += len(header) - tocHeaderBytes
+
+	return n + copy(out[n:], e.packetExtensions)
+}
+
+// finalRange returns rng as the final range of a frame of frameBytes bytes
+// after the TOC byte, or 0 when the frame is too short for the decoder to
+// range-decode it.
+func finalRange(frameBytes int, rng uint32) uint32 {
+	if frameBytes <= 1 {
+		return 0
+	}
+
+	return rng
+}
+
 // Encode encodes S16LE PCM into a single Opus packet.
 //
 // The input must contain exactly one 20 ms mono 48 kHz frame.
@@ -410,11 +578,15 @@ func (e *Encoder) EncodeFloat32(in []float32, out []byte) (int, error) {
 	channels := e.splitChannels(in, e.channels, frameSamples)
 	e.narrowStereo(channels)
 
+	overhead := e.packetExtensionsOverhead()
 	frameBytes := e.frameBytes()
+	if !e.vbr {
+		frameBytes -= overhead
+	}
 	if frameBytes <= 0 || frameBytes > maxOpusFrameSize {
 		return 0, fmt.Errorf("%w: %d", errInvalidFrameByteBudget, frameBytes)
 	}
-	if len(out) < frameBytes+tocHeaderBytes {
+	if len(out) < frameBytes+tocHeaderBytes+overhead {
 		return 0, errOutBufferTooSmall
 	}
 	out[0] = byte(e.tocHeader())
@@ -426,7 +598,7 @@ func (e *Encoder) EncodeFloat32(in []float32, out []byte) (int, error) {
 	// VBR gets the whole buffer the caller supplied: a demanding frame may run
 	// past the nominal rate and the bit reservoir wins it back later. CBR is
 	// pinned to its share.
-	payload := out[tocHeaderBytes:]
+	payload := out[tocHeaderBytes : len(out)-overhead]
 	if !e.vbr && len(payload) > frameBytes {
 		payload = payload[:frameBytes]
 	}
@@ -434,14 +606,19 @@ func (e *Encoder) EncodeFloat32(in []float32, out []byte) (int, error) {
 	if err != nil {
 		return 0, err
 	}
+	e.audioLevel = newAudioLevel(meanSquareFloat32(in), false)
+	e.rangeFinal = finalRange(n, e.celtEncoder.FinalRange())
 
-	return 1 + n, nil
+	return e.addPacketExtensions(out, 1+n), nil
 }
 
 // EncodeSILK encodes one 20 ms mono SILK frame into a SILK-only Opus packet.
-// pcm must hold exactly one 20 ms frame of mono s16 samples at the
-// bandwidth's internal rate: 160 (Narrowband/8 kHz), 240 (Mediumband/12 kHz),
-// or 320 (Wideband/16 kHz) samples. This is a separate entry point from
+// pcm must hold exactly one 20 ms frame of mono s16 samples at the rate set
+// with WithSILKInputRate, by default the bandwidth's internal rate: 160
+// (Narrowband/8 kHz), 240 (Mediumband/12 kHz), or 320 (Wideband/16 kHz)
+// samples. Input above the internal rate goes through the SILK encoder's downsampler (RFC 6716
+// reference silk/resampler.c), whose filter state carries over between calls
+// at the same rates. This is a separate entry point from
 // Encode/EncodeFloat32 — bitrate-based auto-selection always picks CELT
 // bandwidths (Wideband and up); SILK is for callers who specifically want a
 // SILK-only voice packet (VoIP/narrowband use cases), not an automatic
@@ -465,30 +642,83 @@ func (e *Encoder) EncodeSILK(pcm []int16, bandwidth Bandwidth, out []byte) (int,
 		return 0, fmt.Errorf("%w: %d", errInvalidBandwidth, bandwidth)
 	}
 
-	want := bandwidth.SampleRate() / 50 // 20 ms
-	if len(pcm) != want {
-		return 0, fmt.Errorf("%w: got %d samples, want %d", errInvalidFrameSize, len(pcm), want)
+	internalRate := bandwidth.SampleRate()
+	inputRate := e.silkInputRate
+	if inputRate == 0 {
+		inputRate = internalRate
+	}
+	if inputRate < internalRate {
+		return 0, fmt.Errorf("%w: %d Hz input below the %d Hz internal rate", errInvalidSampleRate, inputRate, internalRate)
+	}
+	if len(pcm) != inputRate/50 { // 20 ms
+		return 0, fmt.Errorf("%w: got %d samples, want %d", errInvalidFrameSize, len(pcm), inputRate/50)
 	}
 
-	filtered := applySILKDCBlock(pcm, bandwidth.SampleRate(), &e.silkDCBlockMem)
+	// libopus rejects DC on the PCM it is handed, ahead of the resampler.
+	filtered := applySILKDCBlock(pcm, e.scratch.silkFiltered[:], inputRate, &e.silkDCBlockMem)
+	if inputRate != internalRate {
+		var err error
+		if filtered, err = e.prepareSILKInput(filtered, inputRate, internalRate); err != nil {
+			return 0, err
+		}
+	}
 	payload := e.silkEncoder.Encode(filtered, silk.Bandwidth(bandwidth), e.bitrate)
-	if len(out) < len(payload)+1 {
+	if len(out) < len(payload)+1+e.packetExtensionsOverhead() {
 		return 0, errOutBufferTooSmall
 	}
 
 	out[0] = byte(config<<3) | byte(frameCodeOneFrame) // mono, one frame
 	n := copy(out[1:], payload)
+	e.audioLevel = newAudioLevel(meanSquareInt16(pcm), e.silkEncoder.VoiceActivity())
+	e.rangeFinal = finalRange(n, e.silkEncoder.FinalRange())
+
+	return e.addPacketExtensions(out, n+1), nil
+}
+
+// prepareSILKInput delays one frame of pcm by the delay compensation and
+// converts it from inputRate to the SILK internal rate. The resampler and the
+// delay line restart whenever either rate changes.
+func (e *Encoder) prepareSILKInput(pcm []int16, inputRate, internalRate int) ([]int16, error) {
+	if e.silkResamplerInputRate != inputRate || e.silkResamplerOutputRate != internalRate {
+		if err := e.silkInputResampler.InitEncoder(inputRate, internalRate); err != nil {
+			return nil, err
+		}
+		e.silkResamplerInputRate = inputRate
+		e.silkResamplerOutputRate = internalRate
+		e.silkDelayLine = make([]int16, inputRate/silkDelayCompensationDivisor)
+	}
+
+	in := resizeFloat32Buffer(&e.silkInputFloat, len(pcm))
+	delay := len(e.silkDelayLine)
+	for i, sample := range e.silkDelayLine {
+		in[i] = float32(sample) / 32768
+	}
+	for i, sample := range pcm[:len(pcm)-delay] {
+		in[delay+i] = float32(sample) / 32768
+	}
+	copy(e.silkDelayLine, pcm[len(pcm)-delay:])
+
+	resampled := resizeFloat32Buffer(&e.silkResampled, len(pcm)*internalRate/inputRate)
+	if err := e.silkInputResampler.Resample(in, resampled); err != nil {
+		return nil, err
+	}
+	out := e.scratch.silk[:len(resampled)]
+	for i, sample := range resampled {
+		// The resampler works in 16 bits, so this is exact.
+		out[i] = int16(sample * 32768)
+	}
 
-	return n + 1, nil
+	return out, nil
 }
 
 // applySILKDCBlock removes DC bias from pcm with a first-order IIR high-pass
-// at silkDCBlockCutoffHz, returning a new slice (pcm is left untouched). mem
-// must persist across calls for the same stream.
-func applySILKDCBlock(pcm []int16, sampleRate int, mem *float32) []int16 {
+// at silkDCBlockCutoffHz into out, which must hold as many samples, and
+// returns the filtered samples (pcm is left untouched). mem must persist
+// across calls for the same stream.
+func applySILKDCBlock(pcm, out []int16, sampleRate int, mem *float32) []int16 {
 	coef := float32(6.3) * silkDCBlockCutoffHz / float32(sampleRate)
 	coef2 := 1 - coef
-	out := make([]int16, len(pcm))
+	out = out[:len(pcm)]
 	for i, sample := range pcm {
 		x := float32(sample)
 		y := x - *mem
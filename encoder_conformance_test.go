@@ -8,16 +8,19 @@ package opus
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"io"
 	"math"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strconv"
-	"strings"
 	"sync"
 	"testing"
+
+	"github.com/pion/opus/pkg/opusdemo"
 )
 
 const (
@@ -50,7 +53,7 @@ func TestRFC6716ConformanceEncoder(t *testing.T) {
 		t.Skipf("%s is required to run RFC 6716 encoder conformance", envRFC6716Reference)
 	}
 
-	opusDemo, opusCompare := buildRFC6716ReferenceTools(t, refDir)
+	opusDemo, _ := buildRFC6716ReferenceTools(t, refDir)
 
 	cases := []encoderConformanceCase{
 		{
@@ -98,7 +101,7 @@ func TestRFC6716ConformanceEncoder(t *testing.T) {
 
 	for _, testCase := range cases {
 		t.Run(testCase.name, func(t *testing.T) {
-			runEncoderConformanceCase(t, opusDemo, opusCompare, testCase)
+			runEncoderConformanceCase(t, opusDemo, testCase)
 		})
 	}
 }
@@ -113,11 +116,7 @@ func encoderConformanceTone(i int, freq, modFreq float64) float64 {
 	return 0.5 * envelope * math.Sin(2*math.Pi*freq*tSeconds)
 }
 
-func runEncoderConformanceCase(
-	t *testing.T,
-	opusDemo, opusCompare string,
-	testCase encoderConformanceCase,
-) {
+func runEncoderConformanceCase(t *testing.T, opusDemo string, testCase encoderConformanceCase) {
 	t.Helper()
 
 	dir := t.TempDir()
@@ -139,30 +138,21 @@ func runEncoderConformanceCase(
 	)
 	decodeBitstreamWithGo(t, goBitstream, goDecodePCM)
 
-	// The reference decode and the Go decode of the same bitstream must match;
-	// opus_compare exits non-zero below its conformance threshold.
-	out, err := runOpusCompare(
-		opusCompare, encoderConformanceRate, 2,
-		referenceDecodePCM, goDecodePCM,
-	)
-	if err != nil {
-		t.Fatalf("opus_compare reference decode vs Go decode: %v\n%s", err, out)
+	// The reference decode and the Go decode of the same bitstream must pass
+	// the conformance threshold against each other.
+	quality, _, pass := compareStereoS16LE(t, readQualityBytes(t, referenceDecodePCM), readQualityBytes(t, goDecodePCM))
+	if !pass {
+		t.Fatalf("reference decode vs Go decode: quality %.1f below the conformance threshold", quality)
 	}
-	printOpusCompareQuality(t, opusCompareQuality(out))
+	printOpusCompareQuality(t, fmt.Sprintf("%.1f", quality))
 
-	logEncoderQuality(
-		t, opusCompare, "Go encoder vs original",
-		originalStereo, referenceDecodePCM, dir, "go",
-	)
-	logReferenceEncoderBaseline(
-		t, opusDemo, opusCompare, testCase, originalStereo, originalPCM, dir,
-	)
+	logEncoderQuality(t, "Go encoder vs original", originalStereo, referenceDecodePCM)
+	logReferenceEncoderBaseline(t, opusDemo, testCase, originalStereo, originalPCM, dir)
 }
 
 // writeEncoderConformanceSignal writes the s16le encode input plus a stereo
-// copy: opus_compare always reads its first file as interleaved stereo (mono
-// mode downmixes it), so every comparison runs on stereo PCM and mono cases
-// duplicate the channel.
+// copy: every comparison runs on stereo PCM, as the reference decodes are
+// stereo, so mono cases duplicate the channel.
 func writeEncoderConformanceSignal(
 	t *testing.T,
 	path, stereoPath string,
@@ -213,6 +203,11 @@ func encodeWithGo(t *testing.T, pcm []byte, channels, bitrate int, path string)
 	}
 	defer out.Close()
 
+	writer, err := opusdemo.NewWriter(out)
+	if err != nil {
+		t.Fatalf("create Go bitstream writer: %v", err)
+	}
+
 	frameBytes := encoderConformanceFrameSize * channels * 2
 	packet := make([]byte, maxOpusFrameSize+1)
 	for offset := 0; offset+frameBytes <= len(pcm); offset += frameBytes {
@@ -221,14 +216,8 @@ func encodeWithGo(t *testing.T, pcm []byte, channels, bitrate int, path string)
 			t.Fatalf("frame at byte %d: Go encode: %v", offset, err)
 		}
 
-		if err := binary.Write(out, binary.BigEndian, uint32(n)); err != nil {
-			t.Fatalf("write payload length: %v", err)
-		}
-		if err := binary.Write(out, binary.BigEndian, encoder.celtEncoder.FinalRange()); err != nil {
-			t.Fatalf("write final range: %v", err)
-		}
-		if _, err := out.Write(packet[:n]); err != nil {
-			t.Fatalf("write payload: %v", err)
+		if err := writer.WritePacket(packet[:n], encoder.FinalRange()); err != nil {
+			t.Fatalf("write packet: %v", err)
 		}
 	}
 }
@@ -236,10 +225,11 @@ func encodeWithGo(t *testing.T, pcm []byte, channels, bitrate int, path string)
 func decodeBitstreamWithGo(t *testing.T, bitPath, outPath string) {
 	t.Helper()
 
-	bitstream, err := os.ReadFile(bitPath)
+	in, err := os.Open(bitPath)
 	if err != nil {
-		t.Fatalf("read Go bitstream: %v", err)
+		t.Fatalf("open Go bitstream: %v", err)
 	}
+	defer in.Close()
 
 	out, err := os.Create(outPath)
 	if err != nil {
@@ -252,34 +242,26 @@ func decodeBitstreamWithGo(t *testing.T, bitPath, outPath string) {
 		t.Fatalf("create Go decoder: %v", err)
 	}
 
-	pcm := make([]byte, encoderConformanceFrameSize*4)
-	for offset, frame := 0, 0; offset < len(bitstream); frame++ {
-		if offset+8 > len(bitstream) {
-			t.Fatalf("frame %d: truncated bitstream header", frame)
-		}
-		payloadLen := int(binary.BigEndian.Uint32(bitstream[offset:]))
-		wantFinalRange := binary.BigEndian.Uint32(bitstream[offset+4:])
-		offset += 8
+	reader, err := opusdemo.NewReader(in)
+	if err != nil {
+		t.Fatalf("create Go bitstream reader: %v", err)
+	}
 
-		if offset+payloadLen > len(bitstream) {
-			t.Fatalf("frame %d: truncated payload", frame)
+	pcm := make([]byte, encoderConformanceFrameSize*4)
+	for frame := 0; ; frame++ {
+		packet, err := reader.ReadPacket()
+		if errors.Is(err, io.EOF) {
+			return
+		} else if err != nil {
+			t.Fatalf("frame %d: %v", frame, err)
 		}
-		payload := bitstream[offset : offset+payloadLen]
-		offset += payloadLen
 
-		if _, _, err := decoder.Decode(payload, pcm); err != nil {
+		if _, _, err := decoder.Decode(packet.Payload, pcm); err != nil {
 			t.Fatalf("frame %d: Go decode: %v", frame, err)
 		}
 
-		gotFinalRange, err := conformanceFinalRange(&decoder)
-		if err != nil {
-			t.Fatalf("frame %d: final range unavailable: %v", frame, err)
-		}
-		if gotFinalRange != wantFinalRange {
-			t.Fatalf(
-				"frame %d: encoder/decoder final range mismatch: want 0x%08x got 0x%08x",
-				frame, wantFinalRange, gotFinalRange,
-			)
+		if err := reader.Verify(decoder.FinalRange()); err != nil {
+			t.Fatalf("frame %d: encoder/decoder %v", frame, err)
 		}
 
 		if _, err := out.Write(pcm); err != nil {
@@ -300,76 +282,27 @@ func runReferenceOpusDemo(t *testing.T, opusDemo, description string, args ...st
 // logEncoderQuality compares a decoded output against the original signal,
 // compensating the constant codec delay first: opus_compare does not align
 // its inputs and a few ms of offset wrecks the score.
-func logEncoderQuality(
-	t *testing.T,
-	opusCompare, label string,
-	originalStereo []byte,
-	decodedPCM, dir, prefix string,
-) {
+func logEncoderQuality(t *testing.T, label string, originalStereo []byte, decodedPCM string) {
 	t.Helper()
 
-	decoded, err := os.ReadFile(decodedPCM)
-	if err != nil {
-		t.Fatalf("read decoded PCM: %v", err)
-	}
-
-	lag := estimateCodecDelay(originalStereo, decoded)
-
-	trimmedOriginal := filepath.Join(dir, prefix+"-original-trimmed.pcm")
-	trimmedDecoded := filepath.Join(dir, prefix+"-decoded-trimmed.pcm")
-	trimBytes := lag * 4
-	common := min(len(originalStereo), len(decoded)) - trimBytes
-	if common <= 0 {
-		t.Fatalf("decoded output too short to align: lag %d samples", lag)
-	}
-	if err := os.WriteFile(trimmedOriginal, originalStereo[:common], 0o600); err != nil {
-		t.Fatalf("write trimmed original: %v", err)
-	}
-	if err := os.WriteFile(trimmedDecoded, decoded[trimBytes:trimBytes+common], 0o600); err != nil {
-		t.Fatalf("write trimmed decoded: %v", err)
-	}
-
-	out, err := runOpusCompare(
-		opusCompare, encoderConformanceRate, 2,
-		trimmedOriginal, trimmedDecoded,
-	)
-	// The 0-100 quality metric is only printed above the conformance
-	// threshold; the weighted error (lower is better) is always printed.
-	quality := opusCompareQuality(out)
-	weightedError := opusCompareInternalError(out)
-	switch {
-	case err == nil:
-		fmt.Printf("%s: %s: quality %s %% (weighted error %s, delay %d samples)\n",
+	original, decoded, lag := alignPCM(t, originalStereo, readQualityBytes(t, decodedPCM))
+	quality, weightedError, pass := compareStereoS16LE(t, original, decoded)
+	// Like opus_compare, report the 0-100 quality metric only above the
+	// conformance threshold; the weighted error (lower is better) always.
+	if pass {
+		fmt.Printf("%s: %s: quality %.1f %% (weighted error %f, delay %d samples)\n",
 			t.Name(), label, quality, weightedError, lag)
-	case weightedError != "":
-		fmt.Printf("%s: %s: below quality threshold, weighted error %s (delay %d samples)\n",
+	} else {
+		fmt.Printf("%s: %s: below quality threshold, weighted error %f (delay %d samples)\n",
 			t.Name(), label, weightedError, lag)
-	default:
-		t.Fatalf("opus_compare %s: %v\n%s", label, err, out)
 	}
 }
 
-func opusCompareInternalError(opusCompareOutput []byte) string {
-	const marker = "weighted error is "
-
-	output := string(opusCompareOutput)
-	index := strings.Index(output, marker)
-	if index < 0 {
-		return ""
-	}
-	fields := strings.Fields(output[index+len(marker):])
-	if len(fields) == 0 {
-		return ""
-	}
-
-	return strings.TrimSuffix(fields[0], ")")
-}
-
 // logReferenceEncoderBaseline runs the same pipeline with the reference
 // encoder so the Go score has a baseline in the same run.
 func logReferenceEncoderBaseline(
 	t *testing.T,
-	opusDemo, opusCompare string,
+	opusDemo string,
 	testCase encoderConformanceCase,
 	originalStereo []byte,
 	originalPCM, dir string,
@@ -390,10 +323,7 @@ func logReferenceEncoderBaseline(
 		referenceBitstream, referenceDecodePCM,
 	)
 
-	logEncoderQuality(
-		t, opusCompare, "reference encoder vs original",
-		originalStereo, referenceDecodePCM, dir, "reference",
-	)
+	logEncoderQuality(t, "reference encoder vs original", originalStereo, referenceDecodePCM)
 }
 
 func TestEncoderQualityVsReference(t *testing.T) {
@@ -406,7 +336,7 @@ func TestEncoderQualityVsReference(t *testing.T) {
 		t.Skipf("%s is required for Tier 2 quality tests", envRFC6716Reference)
 	}
 
-	opusDemo, opusCompare := buildRFC6716ReferenceTools(t, refDir)
+	opusDemo, _ := buildRFC6716ReferenceTools(t, refDir)
 	baseline := loadQualityBaseline(t)
 	signals := qualityTestSignals()
 
@@ -430,22 +360,10 @@ func TestEncoderQualityVsReference(t *testing.T) {
 			originalStereo := toStereoS16LEBytes(original, sig.channels)
 			decodedStereo := toStereoS16LEBytes(decoded, sig.channels)
 
-			originalStereoPath := filepath.Join(dir, "original-stereo.pcm")
-			decodedPath := filepath.Join(dir, "decoded.pcm")
-			writeQualityBytes(t, originalStereoPath, originalStereo)
-			writeQualityBytes(t, decodedPath, decodedStereo)
-
-			trimmedOriginal := filepath.Join(dir, "original-trimmed.pcm")
-			trimmedDecoded := filepath.Join(dir, "decoded-trimmed.pcm")
-			trimAndAlignPCM(t, originalStereo, decodedPath, trimmedOriginal, trimmedDecoded)
-
-			goOut, err := runOpusCompare(opusCompare, qualityTestRate, 2, trimmedOriginal, trimmedDecoded)
-			goQuality := opusCompareQuality(goOut)
-			goWSNR := opusCompareInternalError(goOut)
-			if err != nil && goWSNR == "" {
-				t.Fatalf("opus_compare Go encoder: %v\n%s", err, goOut)
-			}
-			t.Logf("pion quality=%s weighted_error=%s", goQuality, goWSNR)
+			alignedOriginal, alignedDecoded, _ := alignPCM(t, originalStereo, decodedStereo)
+			goQuality, goError, _ := compareStereoS16LE(t, alignedOriginal, alignedDecoded)
+			goWSNR := strconv.FormatFloat(goError, 'f', 6, 64)
+			t.Logf("pion quality=%.1f weighted_error=%s", goQuality, goWSNR)
 
 			refBitstream := filepath.Join(dir, "reference.bit")
 			refDecoded := filepath.Join(dir, "reference-dec.pcm")
@@ -460,17 +378,10 @@ func TestEncoderQualityVsReference(t *testing.T) {
 				refBitstream, refDecoded,
 			)
 
-			trimmedRefOriginal := filepath.Join(dir, "ref-original-trimmed.pcm")
-			trimmedRefDecoded := filepath.Join(dir, "ref-decoded-trimmed.pcm")
-			trimAndAlignPCM(t, originalStereo, refDecoded, trimmedRefOriginal, trimmedRefDecoded)
-
-			refOut, err := runOpusCompare(opusCompare, qualityTestRate, 2, trimmedRefOriginal, trimmedRefDecoded)
-			refQuality := opusCompareQuality(refOut)
-			refWSNR := opusCompareInternalError(refOut)
-			if err != nil && refWSNR == "" {
-				t.Fatalf("opus_compare reference encoder: %v\n%s", err, refOut)
-			}
-			t.Logf("libopus quality=%s weighted_error=%s", refQuality, refWSNR)
+			alignedRefOriginal, alignedRefDecoded, _ := alignPCM(t, originalStereo, readQualityBytes(t, refDecoded))
+			refQuality, refError, _ := compareStereoS16LE(t, alignedRefOriginal, alignedRefDecoded)
+			refWSNR := strconv.FormatFloat(refError, 'f', 6, 64)
+			t.Logf("libopus quality=%.1f weighted_error=%s", refQuality, refWSNR)
 
 			if sigData, ok := baseline.Signals[sig.name]; ok && sigData.Tier2WSNRDB != 0 {
 				t.Logf("baseline tier2_wsnr_db=%.1f", sigData.Tier2WSNRDB)
@@ -520,7 +431,7 @@ func TestEncoderQualityVsReference(t *testing.T) {
 
 // clampToS16 saturates a float32 sample to the int16 range: CELT ringing can
 // push a decoded sample slightly past ±1, and an unclamped round trips wraps
-// around instead of saturating, injecting large artifacts into opus_compare.
+// around instead of saturating, injecting large artifacts into the comparison.
 func clampToS16(s float32) int16 {
 	v := math.Round(float64(s) * 32767)
 	switch {
@@ -558,25 +469,29 @@ func toStereoS16LEBytes(samples []float32, channels int) []byte {
 	return out
 }
 
-// trimAndAlignPCM aligns decodedPCM against originalStereo by cross-correlation,
-// trims both to the same length, and writes the results.
-func trimAndAlignPCM(t *testing.T, originalStereo []byte, decodedPCM, outOriginal, outDecoded string) {
+// alignPCM aligns decoded against originalStereo by cross-correlation and
+// trims both to the same length. It returns the codec delay it found too.
+func alignPCM(t *testing.T, originalStereo, decoded []byte) (original, aligned []byte, lag int) {
 	t.Helper()
 
-	decoded, err := os.ReadFile(decodedPCM)
-	if err != nil {
-		t.Fatalf("read decoded PCM: %v", err)
-	}
-
-	lag := estimateCodecDelay(originalStereo, decoded)
+	lag = estimateCodecDelay(originalStereo, decoded)
 	trimBytes := lag * 4
 	common := min(len(originalStereo), len(decoded)) - trimBytes
 	if common <= 0 {
 		t.Fatalf("decoded too short to align: lag %d samples", lag)
 	}
 
-	writeQualityBytes(t, outOriginal, originalStereo[:common])
-	writeQualityBytes(t, outDecoded, decoded[trimBytes:trimBytes+common])
+	return originalStereo[:common], decoded[trimBytes : trimBytes+common], lag
+}
+
+func readQualityBytes(t *testing.T, path string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(path) // #nosec G304 -- a path the test built.
+	if err != nil {
+		t.Fatalf("read %s: %v", filepath.Base(path), err)
+	}
+
+	return data
 }
 
 func writeQualityBytes(t *testing.T, path string, data []byte) {
@@ -11,9 +11,7 @@ import (
 	"math"
 	"os"
 	"path/filepath"
-	"runtime"
 	"sort"
-	"strconv"
 	"strings"
 	"testing"
 
@@ -22,8 +20,7 @@ import (
 )
 
 const (
-	conformanceWindowsGOOS = "windows"
-	envQualityCorpus       = "OPUS_QUALITY_CORPUS"
+	envQualityCorpus = "OPUS_QUALITY_CORPUS"
 	// corpusWindowSamples is 10 ms per channel. A codec artifact that is
 	// audible as a click lives in one or two of these, and averaging over a
 	// whole clip hides it, so they are counted separately from the mean score.
@@ -54,31 +51,23 @@ type corpusBaseline struct {
 // the CELT encoder work: a change could lift one synthetic score, drop another,
 // and still be a large win on music.
 //
-// It reports two numbers per clip. The weighted error from opus_compare is the
-// aggregate perceptual score. The bad-window count is the tail: brief spans
+// It reports two numbers per clip. The opus_compare weighted error, as
+// opuscompare.Compare measures it, is the aggregate perceptual score. The bad-window count is the tail: brief spans
 // where the error is as large as the signal, which is what a listener hears as
 // a click even when the aggregate barely moves.
 //
-// Set OPUS_QUALITY_CORPUS to a directory of 48 kHz stereo s16le .pcm files and
-// OPUS_RFC6716_REFERENCE to a libopus checkout. Set OPUS_QUALITY_CORPUS_UPDATE
-// to rewrite the baseline instead of asserting against it.
+// Set OPUS_QUALITY_CORPUS to a directory of 48 kHz stereo s16le .pcm files.
+// Set OPUS_QUALITY_CORPUS_UPDATE to rewrite the baseline instead of asserting
+// against it.
 //
 // The committed baseline was measured on the corpus that
 // .github/scripts/fetch-quality-corpus.sh builds; a different corpus needs its
 // own baseline.
 func TestEncoderQualityRealCorpus(t *testing.T) {
-	if runtime.GOOS == conformanceWindowsGOOS {
-		t.Skip("the reference tools use a POSIX-oriented Makefile")
-	}
 	corpusDir := os.Getenv(envQualityCorpus)
 	if corpusDir == "" {
 		t.Skipf("%s is required to measure quality on real audio", envQualityCorpus)
 	}
-	refDir := os.Getenv(envRFC6716Reference)
-	if refDir == "" {
-		t.Skipf("%s is required for opus_compare", envRFC6716Reference)
-	}
-	_, opusCompare := buildRFC6716ReferenceTools(t, refDir)
 
 	clips, err := filepath.Glob(filepath.Join(corpusDir, "*.pcm"))
 	require.NoError(t, err, "scan corpus")
@@ -95,7 +84,7 @@ func TestEncoderQualityRealCorpus(t *testing.T) {
 			require.NoError(t, readErr)
 
 			decoded := corpusRoundTrip(t, original)
-			result := scoreCorpusClip(t, opusCompare, t.TempDir(), original, decoded)
+			result := scoreCorpusClip(t, original, decoded)
 
 			t.Logf("weighted error %.4f, bad windows %d/%d",
 				result.WeightedError, result.BadWindows, result.TotalWindows)
@@ -155,28 +144,14 @@ func corpusRoundTrip(t *testing.T, original []byte) []byte {
 
 // scoreCorpusClip aligns the decode against the original and returns both the
 // aggregate opus_compare score and the tail metric.
-func scoreCorpusClip(t *testing.T, opusCompare, dir string, original, decoded []byte) corpusBaselineClip {
+func scoreCorpusClip(t *testing.T, original, decoded []byte) corpusBaselineClip {
 	t.Helper()
 
-	decodedPath := filepath.Join(dir, "decoded.pcm")
-	writeQualityBytes(t, decodedPath, decoded)
-	trimmedOriginal := filepath.Join(dir, "original-trimmed.pcm")
-	trimmedDecoded := filepath.Join(dir, "decoded-trimmed.pcm")
-	trimAndAlignPCM(t, original, decodedPath, trimmedOriginal, trimmedDecoded)
-
-	out, err := runOpusCompare(opusCompare, qualityTestRate, 2, trimmedOriginal, trimmedDecoded)
-	weighted := opusCompareInternalError(out)
-	require.NotEmptyf(t, weighted, "opus_compare produced no score: %v\n%s", err, out)
-	score, parseErr := strconv.ParseFloat(weighted, 64)
-	require.NoError(t, parseErr, "parse weighted error %q", weighted)
-
-	alignedOriginal, err := os.ReadFile(trimmedOriginal) //nolint:gosec // G304: path built above.
-	require.NoError(t, err)
-	alignedDecoded, err := os.ReadFile(trimmedDecoded) //nolint:gosec // G304: path built above.
-	require.NoError(t, err)
+	alignedOriginal, alignedDecoded, _ := alignPCM(t, original, decoded)
+	_, weightedError, _ := compareStereoS16LE(t, alignedOriginal, alignedDecoded)
 	bad, total := countBadWindows(alignedOriginal, alignedDecoded)
 
-	return corpusBaselineClip{WeightedError: score, BadWindows: bad, TotalWindows: total}
+	return corpusBaselineClip{WeightedError: weightedError, BadWindows: bad, TotalWindows: total}
 }
 
 // countBadWindows returns how many 10 ms windows carry as much error as
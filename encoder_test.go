@@ -4,10 +4,14 @@
 package opus
 
 import (
+	"bytes"
 	"encoding/binary"
+	"errors"
+	"io"
 	"math"
 	"testing"
 
+	"github.com/pion/opus/pkg/opusdemo"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -821,6 +825,55 @@ func TestLossRateGetterSetter(t *testing.T) {
 	assert.Equal(t, 25, encoder.LossRate())
 }
 
+func TestEncoderStreamGetters(t *testing.T) {
+	encoder, err := NewEncoder(WithChannels(2))
+	require.NoError(t, err)
+
+	assert.Equal(t, 48000, encoder.SampleRate())
+	assert.Equal(t, 2, encoder.Channels())
+	assert.Equal(t, encoderTestFrameSampleCount, encoder.FrameSize())
+	assert.Equal(t, 120, encoder.Lookahead())
+}
+
+// TestEncoderLookaheadMatchesDelay checks Lookahead against the delay actually
+// measured through an encode/decode round trip, since containers use it as the
+// pre-skip that lines decoded audio up with the input.
+func TestEncoderLookaheadMatchesDelay(t *testing.T) {
+	encoder, err := NewEncoder(WithBitrate(128000))
+	require.NoError(t, err)
+	decoder, err := NewDecoderWithOutput(48000, 1)
+	require.NoError(t, err)
+
+	const frames = 10
+	in := make([]float32, frames*encoderTestFrameSampleCount)
+	for i := range in {
+		// A windowed tone burst has one unambiguous cross-correlation peak.
+		offset := float64(i - len(in)/2)
+		in[i] = float32(0.5 * math.Sin(2*math.Pi*440*float64(i)/48000) * math.Exp(-offset*offset/2e5))
+	}
+	out := make([]float32, len(in))
+	packet := make([]byte, 1500)
+	for frame := range frames {
+		start := frame * encoderTestFrameSampleCount
+		n, err := encoder.EncodeFloat32(in[start:start+encoderTestFrameSampleCount], packet)
+		require.NoError(t, err)
+		_, err = decoder.DecodeToFloat32(packet[:n], out[start:start+encoderTestFrameSampleCount])
+		require.NoError(t, err)
+	}
+
+	best, bestLag := math.Inf(-1), 0
+	for lag := range encoderTestFrameSampleCount {
+		var correlation float64
+		for i := 0; i+lag < len(out); i++ {
+			correlation += float64(in[i]) * float64(out[i+lag])
+		}
+		if correlation > best {
+			best, bestLag = correlation, lag
+		}
+	}
+	assert.Equal(t, encoder.Lookahead(), bestLag)
+}
+
 func testEncoderSineFloat32() []float32 {
 	pcm := make([]float32, encoderTestFrameSampleCount)
 	for i := range pcm {
@@ -1011,6 +1064,88 @@ func TestEncodeDoesNotAllocate(t *testing.T) {
 	})
 	require.NoError(t, encErr)
 	assert.Zerof(t, allocs, "EncodeFloat32 allocated %v times per frame", allocs)
+
+	// EncodeSILK's own input conditioning, ahead of the SILK layer.
+	silkIn := make([]int16, 960)
+	var filtered []int16
+	allocs = testing.AllocsPerRun(50, func() {
+		filtered = applySILKDCBlock(silkIn, enc.scratch.silkFiltered[:], 48000, &enc.silkDCBlockMem)
+		_, encErr = enc.prepareSILKInput(filtered, 48000, 16000)
+	})
+	require.NoError(t, encErr)
+	assert.Zerof(t, allocs, "EncodeSILK input conditioning allocated %v times per frame", allocs)
+}
+
+// TestFinalRangeCELT carries CELT packets through an opus_demo bitstream with
+// the encoder's final ranges, and checks the decoder ends every packet in the
+// same state.
+func TestFinalRangeCELT(t *testing.T) {
+	encoder, err := NewEncoder(WithChannels(2), WithBitrate(64000), WithVBR(true))
+	require.NoError(t, err)
+	decoder, err := NewDecoderWithOutput(48000, 2)
+	require.NoError(t, err)
+	assert.Zero(t, encoder.FinalRange())
+	assert.Zero(t, decoder.FinalRange())
+
+	var bitstream bytes.Buffer
+	writer, err := opusdemo.NewWriter(&bitstream)
+	require.NoError(t, err)
+	pcm := make([]float32, encoderTestFrameSampleCount*2)
+	packet := make([]byte, maxOpusFrameSize)
+	for frame := range 20 {
+		for i := range encoderTestFrameSampleCount {
+			phase := 2 * math.Pi * float64(frame*encoderTestFrameSampleCount+i) / 48000
+			pcm[2*i] = float32(0.3 * math.Sin(440*phase))
+			pcm[2*i+1] = float32(0.2*math.Sin(660*phase) + 0.05*math.Sin(float64(frame)*phase*97))
+		}
+		n, err := encoder.EncodeFloat32(pcm, packet)
+		require.NoError(t, err)
+		assert.NotZero(t, encoder.FinalRange())
+		require.NoError(t, writer.WritePacket(packet[:n], encoder.FinalRange()))
+	}
+
+	reader, err := opusdemo.NewReader(&bitstream)
+	require.NoError(t, err)
+	for frame := 0; ; frame++ {
+		packet, err := reader.ReadPacket()
+		if errors.Is(err, io.EOF) {
+			assert.Equal(t, 20, frame)
+
+			break
+		}
+		require.NoError(t, err)
+		_, err = decoder.DecodeToFloat32(packet.Payload, pcm)
+		require.NoError(t, err)
+		require.NoError(t, reader.Verify(decoder.FinalRange()), "frame %d", frame)
+	}
+}
+
+func TestFinalRangeSILK(t *testing.T) {
+	encoder, err := NewEncoder(WithInbandFEC(true))
+	require.NoError(t, err)
+	require.NoError(t, encoder.SetLossRate(20))
+	decoder, err := NewDecoderWithOutput(16000, 1)
+	require.NoError(t, err)
+
+	pcm := make([]int16, 320)
+	decoded := make([]int16, 320)
+	packet := make([]byte, maxOpusFrameSize)
+	for frame := range 10 {
+		for i := range pcm {
+			phase := 2 * math.Pi * 140 * float64(frame*320+i) / 16000
+			pcm[i] = int16(6000*math.Sin(phase) + 3000*math.Sin(2*phase))
+		}
+		n, err := encoder.EncodeSILK(pcm, BandwidthWideband, packet)
+		require.NoError(t, err)
+		_, err = decoder.DecodeToInt16(packet[:n], decoded)
+		require.NoError(t, err)
+		assert.NotZero(t, decoder.FinalRange())
+		assert.Equal(t, encoder.FinalRange(), decoder.FinalRange(), "frame %d", frame)
+	}
+
+	// Concealment decodes nothing.
+	require.NoError(t, decoder.DecodePLC(decoded))
+	assert.Zero(t, decoder.FinalRange())
 }
 
 func BenchmarkEncode(b *testing.B) {
@@ -1030,3 +1165,56 @@ func BenchmarkEncode(b *testing.B) {
 		}
 	}
 }
+
+func TestEncoderPacketExtensions(t *testing.T) {
+	encoder, err := NewEncoder()
+	require.NoError(t, err)
+	plain, err := NewEncoder()
+	require.NoError(t, err)
+	decoder, err := NewDecoderWithOutput(48000, 1)
+	require.NoError(t, err)
+
+	extensions := []PacketExtension{{ID: 3, Data: []byte{7}}, {ID: 40, Data: []byte("speaker")}}
+	require.NoError(t, encoder.SetPacketExtensions(extensions))
+	pcm := testEncoderSineFloat32()
+	packet := make([]byte, 256)
+	n, err := encoder.EncodeFloat32(pcm, packet)
+	require.NoError(t, err)
+	want, err := plain.EncodeFloat32(pcm, make([]byte, 256))
+	require.NoError(t, err)
+	assert.Equal(t, want, n, "CBR packets keep their size")
+
+	assert.Equal(t, byte(celtOnlyFullband20msConfig<<3)|byte(frameCodeArbitraryFrames), packet[0])
+	got, err := PacketExtensions(packet[:n])
+	require.NoError(t, err)
+	assert.Equal(t, extensions, got)
+	out := make([]float32, encoderTestFrameSampleCount)
+	_, _, err = decoder.DecodeFloat32(packet[:n], out)
+	require.NoError(t, err)
+	assert.Greater(t, vectorEnergyFloat32(out), 1e-6)
+
+	encoder.SetVBR(true)
+	n, err = encoder.EncodeFloat32(pcm, packet)
+	require.NoError(t, err)
+	got, err = PacketExtensions(packet[:n])
+	require.NoError(t, err)
+	assert.Equal(t, extensions, got)
+
+	silkPacket := make([]byte, maxOpusFrameSize)
+	n, err = encoder.EncodeSILK(make([]int16, 320), BandwidthWideband, silkPacket)
+	require.NoError(t, err)
+	got, err = PacketExtensions(silkPacket[:n])
+	require.NoError(t, err)
+	assert.Equal(t, extensions, got)
+
+	require.NoError(t, encoder.SetPacketExtensions(nil))
+	n, err = encoder.EncodeFloat32(pcm, packet)
+	require.NoError(t, err)
+	assert.Equal(t, byte(frameCodeOneFrame), packet[0]&0b11)
+	got, err = PacketExtensions(packet[:n])
+	require.NoError(t, err)
+	assert.Empty(t, got)
+
+	assert.ErrorIs(t, encoder.SetPacketExtensions([]PacketExtension{{ID: 3, Frame: 1}}), errInvalidPacketExtension)
+	assert.ErrorIs(t, encoder.SetPacketExtensions([]PacketExtension{{ID: 1}}), errInvalidPacketExtension)
+}
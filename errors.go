@@ -32,9 +32,23 @@ var (
 
 	errInvalidPLCFrameSize = errors.New("PLC output must contain exactly 20 ms of interleaved samples")
 
+	errInvalidFECFrameSize = errors.New("FEC output must contain a multiple of 10 ms of interleaved samples")
+
 	errInvalidApplication = errors.New("invalid application")
 
 	errInvalidLossRate = errors.New("loss rate must be 0-100")
 
 	errInvalidBandwidth = errors.New("invalid bandwidth")
+
+	errInvalidResampleQuality = errors.New("invalid resample quality")
+
+	errInvalidSampleFormat = errors.New("invalid sample format")
+
+	errInvalidPacketExtension = errors.New("invalid packet extension")
+
+	errRepacketizerMismatch = errors.New("packet configuration differs from the packets before")
+
+	errRepacketizerTooLong = errors.New("repacketized duration exceeds 120 ms")
+
+	errInvalidFrameRange = errors.New("invalid frame range")
 )
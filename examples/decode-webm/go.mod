@@ -1,8 +0,0 @@
-module github.com/pion/opus/examples/decode-webm
-
-go 1.24.0
-
-require (
-	github.com/at-wat/ebml-go v0.19.1
-	github.com/pion/opus v0.1.0
-)
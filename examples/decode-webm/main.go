@@ -5,13 +5,17 @@
 package main
 
 import (
+	"errors"
+	"io"
 	"os"
+	"time"
 
-	"github.com/at-wat/ebml-go"
-	"github.com/at-wat/ebml-go/webm"
 	"github.com/pion/opus"
+	"github.com/pion/opus/pkg/webmreader"
 )
 
+const sampleRate = 48000
+
 func main() { // nolint:cyclop
 	if len(os.Args) != 3 {
 		panic("Usage: <in-file> <out-file>")
@@ -22,50 +26,54 @@ func main() { // nolint:cyclop
 		panic(err)
 	}
 
-	var webmData struct {
-		Segment webm.Segment `ebml:"Segment"`
-	}
-
-	err = ebml.Unmarshal(inputFile, &webmData)
+	webm, track, err := webmreader.NewWith(inputFile)
 	if err != nil {
 		panic(err)
 	}
 
-	var opusTrackNumber uint64
-	for _, trackEntry := range webmData.Segment.Tracks.TrackEntry {
-		if trackEntry.CodecID == "A_OPUS" {
-			opusTrackNumber = trackEntry.TrackNumber
-		}
-	}
-
-	if opusTrackNumber == 0 {
-		panic("Missing opus track")
+	channels := int(track.Header.Channels)
+	decoder, err := opus.NewDecoderWithOutput(sampleRate, channels)
+	if err != nil {
+		panic(err)
 	}
 
-	buffer := make([]byte, 1920)
 	outputFile, err := os.Create(os.Args[2]) // #nosec G703
 	if err != nil {
 		panic(err)
 	}
 
-	decoder := opus.NewDecoder()
-	for _, cluster := range webmData.Segment.Cluster {
-		for _, simpleBlock := range cluster.SimpleBlock {
-			if simpleBlock.TrackNumber != opusTrackNumber {
-				continue
-			}
+	// CodecDelay is dropped from the start and each block's DiscardPadding
+	// from its end, so the output is as long as the recorded audio.
+	skip := samples(track.CodecDelay)
+	pcm := make([]int16, 5760*channels)
+	buffer := make([]byte, len(pcm)*2)
+	for {
+		packet, err := webm.ParseNextPacket()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			panic(err)
+		}
 
-			for _, data := range simpleBlock.Data {
-				_, _, err = decoder.Decode(data, buffer)
-				if err != nil {
-					panic(err)
-				}
+		n, err := decoder.DecodeToInt16(packet.Data, pcm)
+		if err != nil {
+			panic(err)
+		}
+		n -= min(samples(packet.DiscardPadding), n)
+		start := min(skip, n)
+		skip -= start
 
-				_, err := outputFile.Write(buffer)
-				if err != nil {
-					panic(err)
-				}
-			}
+		for i, sample := range pcm[start*channels : n*channels] {
+			buffer[2*i] = byte(sample)
+			buffer[2*i+1] = byte(sample >> 8)
+		}
+		if _, err := outputFile.Write(buffer[:(n-start)*channels*2]); err != nil {
+			panic(err)
 		}
 	}
 }
+
+// samples converts a duration to samples at 48 kHz.
+func samples(duration time.Duration) int {
+	return int((duration*sampleRate + time.Second/2) / time.Second)
+}
@@ -0,0 +1,338 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package opus
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Extension IDs with a meaning of their own. Those from 3 to 31 are short
+// extensions, with at most one byte of data, and those from 32 to 127 long
+// ones.
+const (
+	extensionPadding         = 0
+	extensionFrameSeparator  = 1
+	extensionRepeat          = 2
+	minPacketExtensionID     = 3
+	minLongPacketExtensionID = 32
+	maxPacketExtensionID     = 127
+
+	// maxPacketFrames is the most frames a packet holds, 48 of 2.5 ms.
+	maxPacketFrames = 48
+)
+
+// PacketExtension is a piece of data a packet carries in its padding,
+// following the Opus extension format libopus implements. Decoders, older
+// ones included, skip the padding, so extensions travel in-band without
+// breaking them.
+type PacketExtension struct {
+	// ID tells the kind of extension, from 3 to 127. Extensions with an ID
+	// below 32 carry at most one byte of Data.
+	ID int
+	// Frame is the index of the frame of the packet the extension belongs to.
+	Frame int
+	Data  []byte
+}
+
+// PacketExtensions returns the extensions packet carries in its padding, in
+// the order they are coded, which is that of their frames. Padding without
+// extensions, such as zeros, holds none. The packet is checked against the
+// framing rules of RFC 6716 Section 3.4 but not decoded. Data of the
+// extensions returned points into packet.
+func PacketExtensions(packet []byte) ([]PacketExtension, error) {
+	if len(packet) < 1 {
+		return nil, errTooShortForTableOfContentsHeader
+	}
+
+	frames, err := parsePacketFrames(packet, tableOfContentsHeader(packet[0]))
+	if err != nil {
+		return nil, err
+	}
+	padding, err := PacketPadding(packet)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseExtensions(packet[len(packet)-padding:], len(frames))
+}
+
+// skipExtensionPayload returns where the payload of the extension whose ID
+// byte is idByte ends, given that it starts at offset of data, and how many
+// bytes of it code its length. A long extension without a length runs up to
+// the trailingShort bytes of data that short extensions after it take up.
+func skipExtensionPayload(data []byte, offset int, idByte byte, trailingShort int) (end, headerSize int, err error) {
+	id, hasLength := int(idByte>>1), idByte&1 == 1
+	switch {
+	case id == extensionPadding && hasLength, id == extensionRepeat:
+		return offset, 0, nil
+	case id > extensionPadding && id < minLongPacketExtensionID:
+		if !hasLength {
+			return offset, 0, nil
+		}
+		if offset >= len(data) {
+			return 0, 0, fmt.Errorf("%w: truncated extension", errMalformedPacket)
+		}
+
+		return offset + 1, 0, nil
+	case !hasLength:
+		// Padding to the end, or a long extension that runs there.
+		if len(data)-offset < trailingShort {
+			return 0, 0, fmt.Errorf("%w: truncated extension", errMalformedPacket)
+		}
+
+		return len(data) - trailingShort, 0, nil
+	}
+
+	// The length of a long extension is coded as a run of 255s, which each
+	// add 255 and another byte, ended by a byte of 0 to 254.
+	length := 0
+	for {
+		if offset >= len(data) {
+			return 0, 0, fmt.Errorf("%w: truncated extension length", errMalformedPacket)
+		}
+		lacing := int(data[offset])
+		offset++
+		headerSize++
+		length += lacing
+		if lacing != 255 {
+			break
+		}
+	}
+	if length > len(data)-offset {
+		return 0, 0, fmt.Errorf("%w: extension overruns padding", errMalformedPacket)
+	}
+
+	return offset + length, headerSize, nil
+}
+
+// parseExtensions reads the extensions coded in data, the padding of a packet
+// of frameCount frames, the way libopus's opus_extension_iterator_next does.
+//
+// The extensions of each frame follow those of the frame before, after a
+// frame separator. A repeat extension codes the payloads of the extensions of
+// the current frame coded before it once more for each frame after it, with
+// their IDs and lengths left out.
+func parseExtensions(data []byte, frameCount int) ([]PacketExtension, error) {
+	var extensions []PacketExtension
+	frame := 0
+	// repeatStart is where the extensions a repeat extension repeats start,
+	// lastLong is the end of the last long extension among them, -1 for none,
+	// and trailingShort counts the payload bytes of short extensions after it.
+	repeatStart, lastLong, trailingShort := 0, -1, 0
+	for offset := 0; offset < len(data); {
+		start := offset
+		idByte := data[offset]
+		id, hasLength := int(idByte>>1), idByte&1 == 1
+		end, headerSize, err := skipExtensionPayload(data, offset+1, idByte, 0)
+		if err != nil {
+			return nil, err
+		}
+		offset = end
+
+		switch {
+		case id == extensionFrameSeparator:
+			increment := 1
+			if hasLength {
+				increment = int(data[start+1])
+			}
+			if increment == 0 {
+				continue
+			}
+			frame += increment
+			if frame >= frameCount {
+				return nil, fmt.Errorf("%w: extension for frame %d of %d", errMalformedPacket, frame, frameCount)
+			}
+			repeatStart, lastLong, trailingShort = offset, -1, 0
+		case id == extensionRepeat:
+			for repeatFrame := frame + 1; repeatFrame < frameCount; repeatFrame++ {
+				for source := repeatStart; source < start; {
+					repeatedIDByte := data[source]
+					source, _, _ = skipExtensionPayload(data[:start], source+1, repeatedIDByte, 0)
+					// Padding and frame separators are not repeated.
+					if int(repeatedIDByte>>1) < minPacketExtensionID {
+						continue
+					}
+					// Without a length of its own, the repeat extension is the
+					// last one, and so is the final repetition of the last long
+					// extension, which runs to the end.
+					if !hasLength && repeatFrame == frameCount-1 && source == lastLong {
+						repeatedIDByte &^= 1
+					}
+					payloadStart := offset
+					offset, headerSize, err = skipExtensionPayload(data, offset, repeatedIDByte, trailingShort)
+					if err != nil {
+						return nil, err
+					}
+					extensions = append(extensions, PacketExtension{
+						ID:    int(repeatedIDByte >> 1),
+						Frame: repeatFrame,
+						Data:  data[payloadStart+headerSize : offset],
+					})
+				}
+			}
+			repeatStart, lastLong = offset, -1
+			if !hasLength {
+				frame++
+				if frame >= frameCount {
+					return extensions, nil
+				}
+			}
+		case id >= minPacketExtensionID:
+			if id >= minLongPacketExtensionID {
+				lastLong, trailingShort = offset, 0
+			} else if hasLength {
+				trailingShort++
+			}
+			extensions = append(extensions, PacketExtension{
+				ID:    id,
+				Frame: frame,
+				Data:  data[start+1+headerSize : offset],
+			})
+		}
+	}
+
+	return extensions, nil
+}
+
+// AppendPacketExtensions appends the padding that carries extensions to dst
+// and returns the extended buffer. Extensions are coded in the order of their
+// frames, and otherwise in the order given. The padding goes at the end of a
+// code 3 packet whose frame count exceeds the Frame of every extension;
+// SetPacketExtensions builds one.
+func AppendPacketExtensions(dst []byte, extensions []PacketExtension) ([]byte, error) {
+	for _, extension := range extensions {
+		switch {
+		case extension.ID < minPacketExtensionID || extension.ID > maxPacketExtensionID:
+			return dst, fmt.Errorf("%w: ID %d", errInvalidPacketExtension, extension.ID)
+		case extension.Frame < 0 || extension.Frame >= maxPacketFrames:
+			return dst, fmt.Errorf("%w: frame %d", errInvalidPacketExtension, extension.Frame)
+		case extension.ID < minLongPacketExtensionID && len(extension.Data) > 1:
+			return dst, fmt.Errorf("%w: %d bytes of data for ID %d", errInvalidPacketExtension,
+				len(extension.Data), extension.ID)
+		}
+	}
+
+	sorted := slices.Clone(extensions)
+	slices.SortStableFunc(sorted, func(a, b PacketExtension) int {
+		return a.Frame - b.Frame
+	})
+	frame := 0
+	for i, extension := range sorted {
+		switch increment := extension.Frame - frame; {
+		case increment == 1:
+			dst = append(dst, extensionFrameSeparator<<1)
+		case increment > 1:
+			dst = append(dst, extensionFrameSeparator<<1|1, byte(increment))
+		}
+		frame = extension.Frame
+
+		id := byte(extension.ID) //nolint:gosec // G115, checked above.
+		switch {
+		case extension.ID < minLongPacketExtensionID && len(extension.Data) == 0:
+			dst = append(dst, id<<1)
+		case extension.ID < minLongPacketExtensionID:
+			dst = append(dst, id<<1|1, extension.Data[0])
+		case i == len(sorted)-1:
+			// The last long extension runs to the end, and needs no length.
+			dst = append(append(dst, id<<1), extension.Data...)
+		default:
+			dst = append(dst, id<<1|1)
+			for length := len(extension.Data); ; length -= 255 {
+				if length < 255 {
+					dst = append(dst, byte(length))
+
+					break
+				}
+				dst = append(dst, 255)
+			}
+			dst = append(dst, extension.Data...)
+		}
+	}
+
+	return dst, nil
+}
+
+// SetPacketExtensions returns packet with extensions in its padding in place
+// of whatever padding it had, and with its frames unchanged. A packet with
+// extensions is a code 3 one; without, it is coded the most compact way. The
+// packet returned does not share memory with packet.
+func SetPacketExtensions(packet []byte, extensions []PacketExtension) ([]byte, error) {
+	if len(packet) < 1 {
+		return nil, errTooShortForTableOfContentsHeader
+	}
+
+	frames, err := parsePacketFrames(packet, tableOfContentsHeader(packet[0]))
+	if err != nil {
+		return nil, err
+	}
+	for _, extension := range extensions {
+		if extension.Frame >= len(frames) {
+			return nil, fmt.Errorf("%w: frame %d of a packet of %d", errInvalidPacketExtension,
+				extension.Frame, len(frames))
+		}
+	}
+	padding, err := AppendPacketExtensions(nil, extensions)
+	if err != nil {
+		return nil, err
+	}
+
+	return appendPacket(nil, packet[0], frames, padding), nil
+}
+
+// appendPacket appends a packet of frames with the configuration and stereo
+// flag of toc to dst, in the most compact coding that carries padding, as
+// libopus's repacketizer does.
+func appendPacket(dst []byte, toc byte, frames [][]byte, padding []byte) []byte {
+	toc &^= 0b11
+	isCBR := true
+	for _, frame := range frames[1:] {
+		isCBR = isCBR && len(frame) == len(frames[0])
+	}
+
+	switch {
+	case len(padding) > 0 || len(frames) > 2:
+	case len(frames) == 1:
+		return append(append(dst, toc|byte(frameCodeOneFrame)), frames[0]...)
+	case isCBR:
+		return append(append(append(dst, toc|byte(frameCodeTwoEqualFrames)), frames[0]...), frames[1]...)
+	default:
+		dst = appendFrameLength(append(dst, toc|byte(frameCodeTwoDifferentFrames)), len(frames[0]))
+
+		return append(append(dst, frames[0]...), frames[1]...)
+	}
+
+	countByte := byte(len(frames)) //nolint:gosec // G115, at most 48 frames.
+	if !isCBR {
+		countByte |= 0b10000000
+	}
+	if len(padding) > 0 {
+		countByte |= 0b01000000
+	}
+	dst = append(dst, toc|byte(frameCodeArbitraryFrames), countByte)
+	if len(padding) > 0 {
+		dst = appendPaddingLength(dst, len(padding))
+	}
+	if !isCBR {
+		for _, frame := range frames[:len(frames)-1] {
+			dst = appendFrameLength(dst, len(frame))
+		}
+	}
+	for _, frame := range frames {
+		dst = append(dst, frame...)
+	}
+
+	return append(dst, padding...)
+}
+
+// appendPaddingLength appends the coding of a code 3 packet's padding length,
+// where each byte of 255 stands for 254 bytes and another length byte (RFC
+// 6716 Section 3.2.5).
+func appendPaddingLength(dst []byte, padding int) []byte {
+	for ; padding >= 255; padding -= 254 {
+		dst = append(dst, 255)
+	}
+
+	return append(dst, byte(padding))
+}
@@ -0,0 +1,160 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package opus
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// paddedPacket builds a VBR code 3 packet of three frames whose padding is
+// padding.
+func paddedPacket(padding []byte) []byte {
+	return appendPacket(nil, tocByte(frameCodeOneFrame), [][]byte{{1}, {2, 2}, {3}}, padding)
+}
+
+func TestSetPacketExtensions(t *testing.T) {
+	t.Parallel()
+
+	long := bytes.Repeat([]byte{0x5a}, 300)
+	extensions := []PacketExtension{
+		{ID: 5, Frame: 0, Data: []byte{0x42}},
+		{ID: 7, Frame: 2},
+		{ID: 100, Frame: 1, Data: long},
+		{ID: 40, Frame: 0, Data: []byte("speaker")},
+		{ID: 33, Frame: 2, Data: []byte("sync")},
+	}
+	packet, err := SetPacketExtensions(paddedPacket(nil), extensions)
+	require.NoError(t, err)
+
+	frames, err := parsePacketFrames(packet, tableOfContentsHeader(packet[0]))
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{{1}, {2, 2}, {3}}, frames)
+	got, err := PacketExtensions(packet)
+	require.NoError(t, err)
+	assert.Equal(t, []PacketExtension{
+		{ID: 5, Frame: 0, Data: []byte{0x42}},
+		{ID: 40, Frame: 0, Data: []byte("speaker")},
+		{ID: 100, Frame: 1, Data: long},
+		{ID: 7, Frame: 2, Data: []byte{}},
+		{ID: 33, Frame: 2, Data: []byte("sync")},
+	}, got)
+
+	// Without extensions the packet is coded the most compact way.
+	for _, test := range []struct {
+		frames [][]byte
+		want   []byte
+	}{
+		{[][]byte{{1, 2}}, []byte{tocByte(frameCodeOneFrame), 1, 2}},
+		{[][]byte{{1}, {2}}, []byte{tocByte(frameCodeTwoEqualFrames), 1, 2}},
+		{[][]byte{{1}, {2, 2}}, []byte{tocByte(frameCodeTwoDifferentFrames), 1, 1, 2, 2}},
+		{[][]byte{{1}, {2}, {3}}, []byte{tocByte(frameCodeArbitraryFrames), 3, 1, 2, 3}},
+		{[][]byte{{1}, {2, 2}, {3}}, []byte{tocByte(frameCodeArbitraryFrames), 0x83, 1, 2, 1, 2, 2, 3}},
+	} {
+		padded := appendPacket(nil, tocByte(frameCodeOneFrame), test.frames, []byte{0, 0})
+		packet, err := SetPacketExtensions(padded, nil)
+		require.NoError(t, err)
+		assert.Equal(t, test.want, packet)
+	}
+
+	for _, extension := range []PacketExtension{
+		{ID: 2},
+		{ID: 128},
+		{ID: 31, Data: []byte{1, 2}},
+		{ID: 40, Frame: 3},
+		{ID: 40, Frame: -1},
+	} {
+		_, err := SetPacketExtensions(paddedPacket(nil), []PacketExtension{extension})
+		assert.ErrorIs(t, err, errInvalidPacketExtension, extension)
+	}
+	_, err = AppendPacketExtensions(nil, []PacketExtension{{ID: 40, Frame: maxPacketFrames}})
+	assert.ErrorIs(t, err, errInvalidPacketExtension)
+}
+
+func TestPacketExtensions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		padding []byte
+		want    []PacketExtension
+	}{
+		{name: "legacy zero padding", padding: []byte{0, 0, 0}},
+		{
+			name:    "single byte padding and separators",
+			padding: []byte{0x01, 0x07, 0xaa, 0x03, 0, 0x03, 2, 0x08, 0x01},
+			want:    []PacketExtension{{ID: 3, Frame: 0, Data: []byte{0xaa}}, {ID: 4, Frame: 2, Data: []byte{}}},
+		},
+		{
+			name:    "padding after the extensions",
+			padding: []byte{0x51, 1, 'a', 0x00, 0x51, 1, 'b'},
+			want:    []PacketExtension{{ID: 40, Frame: 0, Data: []byte("a")}},
+		},
+		{
+			// A short and a long extension repeated for frames 1 and 2, after
+			// which the extensions of frame 0 go on.
+			name:    "repeat with lengths",
+			padding: []byte{0x07, 0xaa, 0x51, 2, 'a', 'b', 0x05, 0xbb, 1, 'c', 0xcc, 1, 'd', 0x08},
+			want: []PacketExtension{
+				{ID: 3, Frame: 0, Data: []byte{0xaa}},
+				{ID: 40, Frame: 0, Data: []byte("ab")},
+				{ID: 3, Frame: 1, Data: []byte{0xbb}},
+				{ID: 40, Frame: 1, Data: []byte("c")},
+				{ID: 3, Frame: 2, Data: []byte{0xcc}},
+				{ID: 40, Frame: 2, Data: []byte("d")},
+				{ID: 4, Frame: 0, Data: []byte{}},
+			},
+		},
+		{
+			// The last repetition of the long extension runs up to the short
+			// extension after it.
+			name:    "repeat to the end",
+			padding: []byte{0x51, 2, 'a', 'b', 0x07, 0xaa, 0x04, 1, 'c', 0xbb, 'd', 'e', 0xcc},
+			want: []PacketExtension{
+				{ID: 40, Frame: 0, Data: []byte("ab")},
+				{ID: 3, Frame: 0, Data: []byte{0xaa}},
+				{ID: 40, Frame: 1, Data: []byte("c")},
+				{ID: 3, Frame: 1, Data: []byte{0xbb}},
+				{ID: 40, Frame: 2, Data: []byte("de")},
+				{ID: 3, Frame: 2, Data: []byte{0xcc}},
+			},
+		},
+		{
+			// Frame separators start a new set of extensions to repeat.
+			name:    "repeat after a separator",
+			padding: []byte{0x07, 0xaa, 0x02, 0x07, 0xbb, 0x05, 0xcc},
+			want: []PacketExtension{
+				{ID: 3, Frame: 0, Data: []byte{0xaa}},
+				{ID: 3, Frame: 1, Data: []byte{0xbb}},
+				{ID: 3, Frame: 2, Data: []byte{0xcc}},
+			},
+		},
+	}
+	for _, test := range tests {
+		got, err := PacketExtensions(paddedPacket(test.padding))
+		require.NoError(t, err, test.name)
+		assert.Equal(t, test.want, got, test.name)
+	}
+
+	for _, padding := range [][]byte{
+		{0x02, 0x02, 0x02},
+		{0x03, 3},
+		{0x07},
+		{0x51, 255},
+		{0x51, 5, 'a'},
+		{0x51, 2, 'a', 'b', 0x05, 0xbb},
+	} {
+		_, err := PacketExtensions(paddedPacket(padding))
+		assert.ErrorIs(t, err, errMalformedPacket, padding)
+	}
+
+	extensions, err := PacketExtensions([]byte{tocByte(frameCodeOneFrame), 1, 2})
+	require.NoError(t, err)
+	assert.Empty(t, extensions)
+	_, err = PacketExtensions(nil)
+	assert.ErrorIs(t, err, errTooShortForTableOfContentsHeader)
+}
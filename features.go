@@ -0,0 +1,287 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package opus
+
+import (
+	"math"
+	"time"
+
+	"github.com/pion/opus/internal/celt"
+	"github.com/pion/opus/internal/silk"
+)
+
+// minFeatureLevel is the level, in dBov, FrameFeatures.Level reports for
+// silence: the quietest RFC 6464 audio level.
+const minFeatureLevel = -127
+
+// PacketFeatures holds the features FeatureExtractor reads from a packet.
+type PacketFeatures struct {
+	Mode          Mode
+	Bandwidth     Bandwidth
+	Stereo        bool
+	FrameDuration time.Duration
+	Frames        []FrameFeatures
+}
+
+// FrameFeatures holds the features of one Opus frame of a packet.
+type FrameFeatures struct {
+	// VoiceActivity holds the SILK VAD flag of each 20 ms of SILK and Hybrid
+	// frames, of the mid channel when stereo.
+	VoiceActivity []bool
+	// BandEnergy holds the energy of each CELT band of CELT and Hybrid
+	// frames, per channel and in base-2 log units like
+	// CELTFrameReport.FineEnergy. It has EndBand entries, of which those
+	// from StartBand on are coded.
+	StartBand  int
+	EndBand    int
+	BandEnergy [][]float32
+	// Level is the estimated loudness of the decoded frame in dBov, 10*log10
+	// of its mean square with full scale at 1, and no lower than -127. The
+	// CELT layer's is close to that of the decoded audio, while the SILK
+	// layer's comes from its quantization gains alone and only tracks it
+	// roughly: it reads high for noise and low for strongly predictable
+	// audio, such as a steady vowel.
+	Level float64
+}
+
+// FeatureExtractor reads the level, voice activity and band energies of each
+// frame of a stream of Opus packets without decoding them to audio. It stops
+// after the SILK gains and the CELT fine energy, skipping the SILK excitation
+// of the last 20 ms of SILK-only frames and the CELT residual, and synthesizes
+// nothing. Like a Decoder it keeps the state these are predicted from, so it
+// must be given every packet of the stream in order.
+//
+// Redundant CELT frames of SILK-only packets are skipped, so after a switch
+// from SILK to CELT with redundancy the CELT energies are off until their
+// prediction catches up, which takes a few frames.
+type FeatureExtractor struct {
+	decoder Decoder
+	silk    silk.Features
+}
+
+// NewFeatureExtractor creates a FeatureExtractor.
+func NewFeatureExtractor() FeatureExtractor {
+	return FeatureExtractor{decoder: NewDecoder()}
+}
+
+// Extract reads the features of the next packet of the stream.
+func (e *FeatureExtractor) Extract(packet []byte) (*PacketFeatures, error) {
+	if len(packet) < 1 {
+		return nil, errTooShortForTableOfContentsHeader
+	}
+	tocHeader := tableOfContentsHeader(packet[0])
+	cfg := tocHeader.configuration()
+	encodedFrames, err := parsePacketFrames(packet, tocHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	features := &PacketFeatures{
+		Mode:          cfg.mode().public(),
+		Bandwidth:     cfg.bandwidth(),
+		Stereo:        tocHeader.isStereo(),
+		FrameDuration: time.Duration(cfg.frameDuration().nanoseconds()),
+		Frames:        make([]FrameFeatures, len(encodedFrames)),
+	}
+	e.decoder.resetModeState(cfg.mode())
+	for i, encodedFrame := range encodedFrames {
+		switch cfg.mode() {
+		case configurationModeSilkOnly:
+			err = e.extractSilkFrame(cfg, tocHeader.isStereo(), encodedFrame, &features.Frames[i])
+		case configurationModeCELTOnly:
+			err = e.extractCeltFrame(cfg, tocHeader.isStereo(), encodedFrame, &features.Frames[i])
+		case configurationModeHybrid:
+			err = e.extractHybridFrame(cfg, tocHeader.isStereo(), encodedFrame, &features.Frames[i])
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return features, nil
+}
+
+// extractSilkFrame follows decodeSilkFrames, stopping after the gains of the
+// last SILK frame.
+func (e *FeatureExtractor) extractSilkFrame(
+	cfg Configuration,
+	isStereo bool,
+	encodedFrame []byte,
+	out *FrameFeatures,
+) error {
+	d := &e.decoder
+	d.rangeDecoder.Init(encodedFrame)
+	if err := d.silkDecoder.DecodeFeatures(
+		&d.rangeDecoder,
+		isStereo,
+		cfg.frameDuration().nanoseconds(),
+		silk.Bandwidth(cfg.bandwidth()),
+		true,
+		&e.silk,
+	); err != nil {
+		return err
+	}
+	if d.previousMode == configurationModeHybrid {
+		endBand, err := d.celtEndBandForSilkBandwidth(cfg.bandwidth())
+		if err != nil {
+			return err
+		}
+		if _, err = d.celtDecoder.DecodeEnergy(
+			[]byte{0xff, 0xff},
+			isStereo,
+			hybridFadeSampleCount,
+			0,
+			endBand,
+			nil,
+		); err != nil {
+			return err
+		}
+	}
+	d.previousMode = configurationModeSilkOnly
+	d.previousRedundancy = false
+
+	out.VoiceActivity = append([]bool(nil), e.silk.VoiceActivity...)
+	out.Level = featureLevel(silkGainPower(e.silk.GainsQ16))
+
+	return nil
+}
+
+func (e *FeatureExtractor) extractCeltFrame(
+	cfg Configuration,
+	isStereo bool,
+	encodedFrame []byte,
+	out *FrameFeatures,
+) error {
+	d := &e.decoder
+	startBand, endBand, err := d.celtDecoder.Mode().BandRangeForSampleRate(cfg.bandwidth().SampleRate())
+	if err != nil {
+		return err
+	}
+	energy, err := d.celtDecoder.DecodeEnergy(
+		encodedFrame,
+		isStereo,
+		cfg.celtFrameSampleCount(),
+		startBand,
+		endBand,
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+	d.previousMode = configurationModeCELTOnly
+	d.previousRedundancy = false
+
+	out.setBandEnergy(&energy)
+	out.Level = featureLevel(energy.Power())
+
+	return nil
+}
+
+// extractHybridFrame follows decodeHybridFrame, reading all of the SILK layer
+// to reach the CELT layer behind it.
+func (e *FeatureExtractor) extractHybridFrame(
+	cfg Configuration,
+	isStereo bool,
+	encodedFrame []byte,
+	out *FrameFeatures,
+) error {
+	d := &e.decoder
+	startBand, endBand, err := d.celtDecoder.Mode().HybridBandRange(cfg.bandwidth().SampleRate())
+	if err != nil {
+		return err
+	}
+	d.rangeDecoder.Init(encodedFrame)
+	if err = d.silkDecoder.DecodeFeatures(
+		&d.rangeDecoder,
+		isStereo,
+		cfg.frameDuration().nanoseconds(),
+		silk.Bandwidth(BandwidthWideband),
+		false,
+		&e.silk,
+	); err != nil {
+		return err
+	}
+
+	redundancy := d.decodeHybridRedundancyHeader(encodedFrame)
+	if redundancy.present && redundancy.celtToSilk {
+		if _, err = d.celtDecoder.DecodeEnergy(
+			redundancy.data,
+			isStereo,
+			hybridRedundantFrameSampleCount,
+			0,
+			endBand,
+			nil,
+		); err != nil {
+			return err
+		}
+	}
+	if d.previousMode != configurationModeHybrid && d.previousMode != 0 && !d.previousRedundancy {
+		d.celtDecoder.Reset()
+	}
+	energy, err := d.celtDecoder.DecodeEnergy(
+		encodedFrame[:redundancy.celtDataLen],
+		isStereo,
+		cfg.hybridFrameSampleCount(),
+		startBand,
+		endBand,
+		&d.rangeDecoder,
+	)
+	if err != nil {
+		return err
+	}
+	if redundancy.present && !redundancy.celtToSilk {
+		d.celtDecoder.Reset()
+		if _, err = d.celtDecoder.DecodeEnergy(
+			redundancy.data,
+			isStereo,
+			hybridRedundantFrameSampleCount,
+			0,
+			endBand,
+			nil,
+		); err != nil {
+			return err
+		}
+	}
+	d.previousMode = configurationModeHybrid
+	d.previousRedundancy = redundancy.present && !redundancy.celtToSilk
+
+	out.VoiceActivity = append([]bool(nil), e.silk.VoiceActivity...)
+	out.setBandEnergy(&energy)
+	out.Level = featureLevel(silkGainPower(e.silk.GainsQ16) + energy.Power())
+
+	return nil
+}
+
+func (f *FrameFeatures) setBandEnergy(energy *celt.FrameEnergy) {
+	f.StartBand = energy.StartBand
+	f.EndBand = energy.EndBand
+	f.BandEnergy = make([][]float32, energy.Channels)
+	for channel := range f.BandEnergy {
+		f.BandEnergy[channel] = append([]float32(nil), energy.LogE[channel][:energy.EndBand]...)
+	}
+}
+
+// silkGainPower estimates the mean square of a SILK frame, with full scale at
+// 1, as that of its subframe gains, which scale the excitation before the
+// prediction filters.
+func silkGainPower(gainsQ16 []int32) float64 {
+	if len(gainsQ16) == 0 {
+		return 0
+	}
+	var power float64
+	for _, gainQ16 := range gainsQ16 {
+		gain := float64(gainQ16) / (1 << 16) / 32768
+		power += gain * gain
+	}
+
+	return power / float64(len(gainsQ16))
+}
+
+func featureLevel(power float64) float64 {
+	if power <= 0 {
+		return minFeatureLevel
+	}
+
+	return max(minFeatureLevel, 10*math.Log10(power))
+}
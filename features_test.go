@@ -0,0 +1,157 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package opus
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeatureExtractorCELT(t *testing.T) {
+	// A lost frame in the middle must leave the energy prediction in step
+	// with the decoder's.
+	packets := testLoudStereoPackets(t)
+	packets = append(packets[:2:2], append([][]byte{packets[0][:1]}, packets[2:]...)...)
+
+	extractor := NewFeatureExtractor()
+	analyzer := NewAnalyzer()
+	for _, packet := range packets {
+		features, err := extractor.Extract(packet)
+		require.NoError(t, err)
+		report, err := analyzer.Analyze(packet)
+		require.NoError(t, err)
+
+		assert.Equal(t, ModeCELT, features.Mode)
+		assert.True(t, features.Stereo)
+		require.Len(t, features.Frames, 1)
+		frame := features.Frames[0]
+		assert.Empty(t, frame.VoiceActivity)
+		assert.Equal(t, 0, frame.StartBand)
+		assert.Equal(t, 21, frame.EndBand)
+		require.Len(t, frame.BandEnergy, 2)
+		if len(packet) == 1 {
+			continue
+		}
+
+		// Only the final fine energy bits, worth less than a fine step, are
+		// missing.
+		celtFrame := report.Frames[0].CELT
+		for channel := range 2 {
+			assert.InDeltaSlice(t, celtFrame.FineEnergy[channel], frame.BandEnergy[channel], 0.5)
+		}
+	}
+}
+
+func TestFeatureExtractorCELTLevel(t *testing.T) {
+	random := rand.New(rand.NewSource(1)) //nolint:gosec
+	for _, signal := range []func(n int) float64{
+		func(int) float64 { return 0.2 * random.NormFloat64() },
+		func(n int) float64 { return 0.3 * math.Sin(2*math.Pi*437*float64(n)/48000) },
+	} {
+		encoder, err := NewEncoder(WithBitrate(96000), WithApplication(ApplicationAudio))
+		require.NoError(t, err)
+		decoder, err := NewDecoderWithOutput(48000, 1)
+		require.NoError(t, err)
+		extractor := NewFeatureExtractor()
+
+		var power, decodedPower float64
+		pcm := make([]float32, encoderTestFrameSampleCount)
+		decoded := make([]float32, encoderTestFrameSampleCount)
+		for frame := range 20 {
+			for i := range pcm {
+				pcm[i] = float32(signal(frame*len(pcm) + i))
+			}
+			packet := make([]byte, maxOpusFrameSize)
+			n, err := encoder.EncodeFloat32(pcm, packet)
+			require.NoError(t, err)
+			features, err := extractor.Extract(packet[:n])
+			require.NoError(t, err)
+			_, err = decoder.DecodeToFloat32(packet[:n], decoded)
+			require.NoError(t, err)
+
+			if frame >= 5 {
+				power += math.Pow(10, features.Frames[0].Level/10)
+				for _, sample := range decoded {
+					decodedPower += float64(sample) * float64(sample) / float64(len(decoded))
+				}
+			}
+		}
+		assert.InDelta(t, 0, 10*math.Log10(power/decodedPower), 1)
+	}
+}
+
+func TestFeatureExtractorSILK(t *testing.T) {
+	encoder, err := NewEncoder()
+	require.NoError(t, err)
+	extractor := NewFeatureExtractor()
+	analyzer := NewAnalyzer()
+	random := rand.New(rand.NewSource(1)) //nolint:gosec
+	pcm := make([]int16, 320)
+	for index := range 10 {
+		amplitude := 3000.0
+		if index >= 5 {
+			amplitude = 300
+		}
+		for i := range pcm {
+			pcm[i] = int16(amplitude * random.NormFloat64())
+		}
+		packet := make([]byte, maxOpusFrameSize)
+		n, err := encoder.EncodeSILK(pcm, BandwidthWideband, packet)
+		require.NoError(t, err)
+		features, err := extractor.Extract(packet[:n])
+		require.NoError(t, err)
+		report, err := analyzer.Analyze(packet[:n])
+		require.NoError(t, err)
+
+		assert.Equal(t, ModeSILK, features.Mode)
+		require.Len(t, features.Frames, 1)
+		frame := features.Frames[0]
+		silkFrame := report.Frames[0].SILK[0]
+		assert.Equal(t, []bool{silkFrame.VoiceActivity}, frame.VoiceActivity)
+		assert.Empty(t, frame.BandEnergy)
+		assert.InDelta(t, featureLevel(silkGainPower(silkFrame.GainsQ16)), frame.Level, 1e-9)
+		// Past the step down, the gains of noise follow its level.
+		if index != 5 {
+			assert.InDelta(t, 20*math.Log10(amplitude/32768), frame.Level, 3)
+		}
+	}
+}
+
+func TestFeatureExtractorHybrid(t *testing.T) {
+	packet := make([]byte, 80)
+	packet[0] = byte(15<<3) | byte(frameCodeOneFrame)
+	seed := uint32(1)
+	for i := range packet[1:] {
+		seed = seed*1664525 + 1013904223
+		packet[i+1] = byte(seed >> 24)
+	}
+
+	extractor := NewFeatureExtractor()
+	features, err := extractor.Extract(packet)
+	require.NoError(t, err)
+	report, err := Analyze(packet)
+	require.NoError(t, err)
+
+	assert.Equal(t, ModeHybrid, features.Mode)
+	require.Len(t, features.Frames, 1)
+	frame := features.Frames[0]
+	assert.Len(t, frame.VoiceActivity, 1)
+	assert.Equal(t, 17, frame.StartBand)
+	assert.Equal(t, 21, frame.EndBand)
+	require.Len(t, frame.BandEnergy, 1)
+	assert.InDeltaSlice(t, report.Frames[0].CELT.FineEnergy[0][17:], frame.BandEnergy[0][17:], 0.5)
+}
+
+func TestFeatureExtractorInvalidPacket(t *testing.T) {
+	extractor := NewFeatureExtractor()
+	_, err := extractor.Extract(nil)
+	assert.ErrorIs(t, err, errTooShortForTableOfContentsHeader)
+
+	_, err = extractor.Extract([]byte{byte(frameCodeArbitraryFrames)})
+	assert.Error(t, err)
+}
@@ -0,0 +1,183 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package opus
+
+import (
+	"github.com/pion/opus/internal/silk"
+)
+
+// PacketHasLBRR reports whether packet carries in-band FEC data, the LBRR
+// frames of RFC 6716 Section 4.2.4, for the packet before it. Like libopus's
+// opus_packet_has_lbrr it only reads the LBRR flags at the start of the first
+// SILK frame, so nothing is decoded. CELT-only packets never carry LBRR data.
+func PacketHasLBRR(packet []byte) (bool, error) {
+	if len(packet) < 1 {
+		return false, errTooShortForTableOfContentsHeader
+	}
+	tocHeader := tableOfContentsHeader(packet[0])
+	cfg := tocHeader.configuration()
+	if cfg.mode() == configurationModeCELTOnly {
+		return false, nil
+	}
+	frames, err := parsePacketFrames(packet, tocHeader)
+	if err != nil {
+		return false, err
+	}
+	if len(frames) == 0 || len(frames[0]) == 0 {
+		return false, nil
+	}
+
+	// The first bits of a frame are the VAD flags and LBRR flag of the mid
+	// channel, then those of the side channel (RFC 6716 Section 4.2.3).
+	silkFrames := max(cfg.frameDuration().nanoseconds()/frame20msNS, 1)
+	first := frames[0][0]
+	lbrr := first>>(7-silkFrames)&1 == 1
+	if tocHeader.isStereo() {
+		lbrr = lbrr || first>>(6-2*silkFrames)&1 == 1
+	}
+
+	return lbrr, nil
+}
+
+// DecodeFEC recovers the audio of a lost packet from the in-band FEC data of
+// in, the packet that followed it, into signed 16-bit PCM. out must hold the
+// whole gap: a multiple of 10 ms of interleaved samples.
+//
+// As in libopus, the last frame's worth of out is decoded from the LBRR
+// frames in carries and anything before it is concealed. When in cannot help
+// (it is CELT-only, carries no LBRR data, the decoder last ran in CELT mode,
+// or out is shorter than one of its frames), all of out is concealed, as
+// DecodePLC would. Decode in itself afterward as usual. Like DecodePLC, it
+// soft-clips out the way DecodeToInt16 does.
+func (d *Decoder) DecodeFEC(in []byte, out []int16) error {
+	d.floatBuffer = resizeFloat32Buffer(&d.floatBuffer, len(out))
+	if err := d.decodeFECToFloat32(in, d.floatBuffer); err != nil {
+		return err
+	}
+	d.float32ToInt16(d.floatBuffer, out)
+
+	return nil
+}
+
+// DecodeFECToFloat32 is DecodeFEC with float32 output.
+func (d *Decoder) DecodeFECToFloat32(in []byte, out []float32) error {
+	return d.decodeFECToFloat32(in, out)
+}
+
+func (d *Decoder) decodeFECToFloat32(in []byte, out []float32) error {
+	if err := d.validateFECOutput(len(out)); err != nil {
+		return err
+	}
+	if d.outputSampleRate != 0 {
+		samplesPerChannel := len(out) / d.channels * d.sampleRate / d.outputSampleRate
+
+		return d.resampleOutput(out, samplesPerChannel, func(decoded []float32) error {
+			return d.recoverToFloat32(in, decoded)
+		})
+	}
+
+	return d.recoverToFloat32(in, out)
+}
+
+// recoverToFloat32 fills out, at the decoding rate, with the audio the LBRR
+// frames of in recover and concealment for whatever they do not cover.
+func (d *Decoder) recoverToFloat32(in []byte, out []float32) error {
+	samplesPerChannel := len(out) / d.channels
+
+	hasLBRR, err := PacketHasLBRR(in)
+	if err != nil {
+		return err
+	}
+	tocHeader := tableOfContentsHeader(in[0])
+	cfg := tocHeader.configuration()
+	packetFrameSamples := int(int64(cfg.frameDuration().nanoseconds()) * int64(d.sampleRate) / 1000000000)
+	if !hasLBRR || d.previousMode == configurationModeCELTOnly || samplesPerChannel < packetFrameSamples {
+		return d.concealToFloat32(out, samplesPerChannel)
+	}
+
+	concealed := samplesPerChannel - packetFrameSamples
+	if err = d.concealToFloat32(out[:concealed*d.channels], concealed); err != nil {
+		return err
+	}
+
+	frames, err := parsePacketFrames(in, tocHeader)
+	if err != nil {
+		return err
+	}
+	mode := cfg.mode()
+	d.resetModeState(mode)
+	d.lastPacketBandwidth = cfg.bandwidth()
+	d.lastPacketIsStereo = tocHeader.isStereo()
+	d.rangeDecoder.Init(frames[0])
+
+	frameOut := out[concealed*d.channels:]
+	if mode == configurationModeHybrid {
+		if err = d.decodeCeltPLCFrame(frameOut, packetFrameSamples, true); err != nil {
+			return err
+		}
+		err = d.decodeSilkFECFrame(frameOut, packetFrameSamples, cfg, BandwidthWideband, true)
+	} else {
+		err = d.decodeSilkFECFrame(frameOut, packetFrameSamples, cfg, cfg.bandwidth(), false)
+	}
+	if err != nil {
+		return err
+	}
+	d.previousMode = mode
+	d.previousRedundancy = false
+	d.rangeFinal = d.rangeDecoder.FinalRange()
+
+	return nil
+}
+
+// decodeSilkFECFrame decodes the SILK layer of one lost frame from the LBRR
+// data in d.rangeDecoder, concealing it if the sender sent none after all.
+func (d *Decoder) decodeSilkFECFrame(
+	out []float32,
+	samplesPerChannel int,
+	cfg Configuration,
+	bandwidth Bandwidth,
+	hybrid bool,
+) error {
+	nanoseconds := cfg.frameDuration().nanoseconds()
+	internalChannelCount := silkOutputChannelCount(d.lastPacketIsStereo, d.channels)
+	internalSamplesPerChannel := int(int64(bandwidth.SampleRate()) * int64(nanoseconds) / 1000000000)
+	internal := resizeFloat32Buffer(&d.plcBuffer, internalSamplesPerChannel*internalChannelCount)
+	recovered, err := d.silkDecoder.DecodeFECWithRangeToChannels(
+		&d.rangeDecoder,
+		internal,
+		d.lastPacketIsStereo,
+		internalChannelCount,
+		nanoseconds,
+		silk.Bandwidth(bandwidth),
+	)
+	if err != nil {
+		return err
+	}
+	if !recovered {
+		if err = d.silkDecoder.DecodePLC(
+			internal,
+			d.lastPacketIsStereo,
+			internalChannelCount,
+			nanoseconds,
+			silk.Bandwidth(bandwidth),
+		); err != nil {
+			return err
+		}
+	}
+
+	return d.writeSilkPLCOutput(out, internal, samplesPerChannel, internalChannelCount, bandwidth, hybrid)
+}
+
+func (d *Decoder) validateFECOutput(sampleCount int) error {
+	switch {
+	case d.sampleRate == 0:
+		return errInvalidSampleRate
+	case d.channels == 0:
+		return errInvalidChannelCount
+	case sampleCount == 0 || sampleCount%(d.outputRate()/100*d.channels) != 0:
+		return errInvalidFECFrameSize
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,161 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package opus
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// encodeSILKVoice encodes frameCount 20 ms frames of a vowel-like tone with
+// harmonics, loud enough that the encoder treats every frame as active speech.
+func encodeSILKVoice(t *testing.T, enc *Encoder, bandwidth Bandwidth, frameCount int) [][]byte {
+	t.Helper()
+
+	sampleRate := bandwidth.SampleRate()
+	frameSamples := sampleRate / 50
+	packets := make([][]byte, 0, frameCount)
+	for frame := range frameCount {
+		pcm := make([]int16, frameSamples)
+		for i := range pcm {
+			phase := 2 * math.Pi * 140 * float64(frame*frameSamples+i) / float64(sampleRate)
+			pcm[i] = int16(6000*math.Sin(phase) + 3000*math.Sin(2*phase) + 1500*math.Sin(3*phase))
+		}
+		packet := make([]byte, maxOpusFrameSize)
+		n, err := enc.EncodeSILK(pcm, bandwidth, packet)
+		require.NoError(t, err)
+		packets = append(packets, packet[:n])
+	}
+
+	return packets
+}
+
+func squaredError(a, b []int16) float64 {
+	var sum float64
+	for i := range a {
+		diff := float64(a[i]) - float64(b[i])
+		sum += diff * diff
+	}
+
+	return sum
+}
+
+func TestPacketHasLBRR(t *testing.T) {
+	enc, err := NewEncoder(WithInbandFEC(true))
+	require.NoError(t, err)
+	require.NoError(t, enc.SetLossRate(20))
+	packets := encodeSILKVoice(t, enc, BandwidthWideband, 4)
+
+	hasLBRR, err := PacketHasLBRR(packets[0])
+	require.NoError(t, err)
+	assert.False(t, hasLBRR, "the first packet has no previous frame to protect")
+	for _, packet := range packets[1:] {
+		hasLBRR, err = PacketHasLBRR(packet)
+		require.NoError(t, err)
+		assert.True(t, hasLBRR)
+	}
+
+	withoutLoss, err := NewEncoder(WithInbandFEC(true))
+	require.NoError(t, err)
+	for _, packet := range encodeSILKVoice(t, withoutLoss, BandwidthWideband, 3) {
+		hasLBRR, err = PacketHasLBRR(packet)
+		require.NoError(t, err)
+		assert.False(t, hasLBRR, "no LBRR frames without expected loss")
+	}
+
+	hasLBRR, err = PacketHasLBRR([]byte{0xfc, 0xff, 0xff})
+	require.NoError(t, err)
+	assert.False(t, hasLBRR, "CELT-only packets carry no LBRR data")
+
+	_, err = PacketHasLBRR(nil)
+	assert.ErrorIs(t, err, errTooShortForTableOfContentsHeader)
+}
+
+// TestDecodeFECRecoversLostPacket drops one packet of a SILK stream sent with
+// in-band FEC and checks that DecodeFEC, fed the packet after it, gets closer
+// to the lost audio than concealment does.
+func TestDecodeFECRecoversLostPacket(t *testing.T) {
+	const (
+		frameCount = 12
+		lost       = 6
+	)
+	enc, err := NewEncoder(WithInbandFEC(true))
+	require.NoError(t, err)
+	require.NoError(t, enc.SetLossRate(20))
+	assert.True(t, enc.InbandFEC())
+	packets := encodeSILKVoice(t, enc, BandwidthWideband, frameCount)
+
+	frameSamples := BandwidthWideband.SampleRate() / 50
+	decodeStream := func(recover func(dec *Decoder, next []byte, out []int16) error) [][]int16 {
+		dec, err := NewDecoderWithOutput(BandwidthWideband.SampleRate(), 1)
+		require.NoError(t, err)
+		frames := make([][]int16, frameCount)
+		for i, packet := range packets {
+			frames[i] = make([]int16, frameSamples)
+			if recover != nil && i == lost {
+				require.NoError(t, recover(&dec, packets[i+1], frames[i]))
+
+				continue
+			}
+			_, err = dec.DecodeToInt16(packet, frames[i])
+			require.NoError(t, err)
+		}
+
+		return frames
+	}
+
+	reference := decodeStream(nil)
+	fec := decodeStream(func(dec *Decoder, next []byte, out []int16) error {
+		return dec.DecodeFEC(next, out)
+	})
+	plc := decodeStream(func(dec *Decoder, _ []byte, out []int16) error {
+		return dec.DecodePLC(out)
+	})
+
+	fecError := squaredError(reference[lost], fec[lost])
+	plcError := squaredError(reference[lost], plc[lost])
+	assert.Less(t, fecError, plcError)
+
+	var referenceEnergy float64
+	for _, v := range reference[lost] {
+		referenceEnergy += float64(v) * float64(v)
+	}
+	assert.Less(t, fecError, referenceEnergy/4, "FEC output should be a recognizable copy")
+}
+
+func TestDecodeFECConcealsWithoutLBRR(t *testing.T) {
+	enc, err := NewEncoder()
+	require.NoError(t, err)
+	packets := encodeSILKVoice(t, enc, BandwidthWideband, 3)
+
+	frameSamples := BandwidthWideband.SampleRate() / 50
+	fecDecoder, err := NewDecoderWithOutput(BandwidthWideband.SampleRate(), 1)
+	require.NoError(t, err)
+	plcDecoder, err := NewDecoderWithOutput(BandwidthWideband.SampleRate(), 1)
+	require.NoError(t, err)
+	out := make([]int16, frameSamples)
+	_, err = fecDecoder.DecodeToInt16(packets[0], out)
+	require.NoError(t, err)
+	_, err = plcDecoder.DecodeToInt16(packets[0], out)
+	require.NoError(t, err)
+
+	fecOut := make([]int16, 2*frameSamples)
+	require.NoError(t, fecDecoder.DecodeFEC(packets[2], fecOut))
+	plcOut := make([]int16, 2*frameSamples)
+	require.NoError(t, plcDecoder.DecodePLC(plcOut[:frameSamples]))
+	require.NoError(t, plcDecoder.DecodePLC(plcOut[frameSamples:]))
+	assert.Equal(t, plcOut, fecOut)
+}
+
+func TestDecodeFECFrameSizeValidation(t *testing.T) {
+	dec := NewDecoder()
+	packet := []byte{byte(silkOnlyWideband20msConfig << 3), 0}
+
+	assert.ErrorIs(t, dec.DecodeFEC(packet, nil), errInvalidFECFrameSize)
+	assert.ErrorIs(t, dec.DecodeFEC(packet, make([]int16, 100)), errInvalidFECFrameSize)
+	assert.ErrorIs(t, dec.DecodeFECToFloat32(nil, make([]float32, 480)), errTooShortForTableOfContentsHeader)
+}
@@ -47,3 +47,23 @@ func FuzzDecoder(f *testing.F) {
 		}
 	})
 }
+
+func FuzzSelfDelimitedPacket(f *testing.F) {
+	f.Add([]byte{0x4b, 1, 2})
+	f.Add([]byte{0x4b, 0xc3, 2, 1, 0, 0xaa, 1, 2, 0, 0})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		selfDelimited, err := AppendSelfDelimitedPacket(nil, data)
+		if err != nil {
+			return
+		}
+
+		packet, size, err := ParseSelfDelimitedPacket(append(selfDelimited, data...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if size != len(selfDelimited) || !bytes.Equal(packet, data) {
+			t.Fatalf("%x did not round-trip through %x", data, selfDelimited)
+		}
+	})
+}
@@ -28,6 +28,16 @@ func Float32ToSigned16(sample float32) int16 {
 	return int16(sample64)
 }
 
+// Float32ToSigned24 quantizes a float32 PCM sample to signed 24-bit PCM.
+func Float32ToSigned24(sample float32) int32 {
+	return int32(min(max(math.Round(float64(sample)*(1<<23)), -(1<<23)), 1<<23-1))
+}
+
+// Float32ToSigned32 quantizes a float32 PCM sample to signed 32-bit PCM.
+func Float32ToSigned32(sample float32) int32 {
+	return int32(min(max(math.Round(float64(sample)*(1<<31)), -(1<<31)), 1<<31-1))
+}
+
 // ConvertFloat32LittleEndianToSigned16LittleEndian converts a f32le to s16le.
 func ConvertFloat32LittleEndianToSigned16LittleEndian(
 	in []float32,
@@ -4,6 +4,7 @@
 package bitdepth
 
 import (
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -58,3 +59,15 @@ func TestConvertFloat32LittleEndianToSigned16LittleEndianInvalidChannelCount(t *
 func TestConvertFloat32LittleEndianToSigned16LittleEndianInvalidResampleCount(t *testing.T) {
 	assert.Error(t, ConvertFloat32LittleEndianToSigned16LittleEndian([]float32{0.3}, make([]byte, 2), 1, 0))
 }
+
+func TestFloat32ToSigned24And32(t *testing.T) {
+	assert.Equal(t, int32(0), Float32ToSigned24(0))
+	assert.Equal(t, int32(1<<22), Float32ToSigned24(0.5))
+	assert.Equal(t, int32(-1<<23), Float32ToSigned24(-1))
+	assert.Equal(t, int32(1<<23-1), Float32ToSigned24(1))
+	assert.Equal(t, int32(-1<<23), Float32ToSigned24(-1.5))
+
+	assert.Equal(t, int32(-1<<30), Float32ToSigned32(-0.5))
+	assert.Equal(t, int32(math.MaxInt32), Float32ToSigned32(1))
+	assert.Equal(t, int32(math.MinInt32), Float32ToSigned32(-3))
+}
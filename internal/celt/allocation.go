@@ -7,7 +7,7 @@ package celt
 import (
 	"math"
 
-	"github.com/pion/opus/internal/rangecoding"
+	"github.com/pion/opus/pkg/rangecoding"
 )
 
 const (
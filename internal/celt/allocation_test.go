@@ -6,7 +6,7 @@ package celt
 import (
 	"testing"
 
-	"github.com/pion/opus/internal/rangecoding"
+	"github.com/pion/opus/pkg/rangecoding"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -76,7 +76,7 @@ func TestFinalizeFineEnergy(t *testing.T) {
 
 func rangeDecoderWithRawBits(bits byte) rangecoding.Decoder {
 	decoder := rangecoding.Decoder{}
-	decoder.SetInternalValues([]byte{bits}, 0, 1<<31, 0)
+	decoder.SetState([]byte{bits}, 0, 1<<31, 0)
 
 	return decoder
 }
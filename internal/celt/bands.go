@@ -8,8 +8,8 @@ import (
 	"math"
 	"math/bits"
 
-	"github.com/pion/opus/internal/rangecoding"
 	"github.com/pion/opus/internal/slicetools"
+	"github.com/pion/opus/pkg/rangecoding"
 )
 
 const (
@@ -33,6 +33,8 @@ type bandDecodeState struct {
 	lowScratch   []float32
 	maskScratch  []byte
 	cwrsRows     map[cwrsRowKey][]uint32
+	// pulses, when set, sums the PVQ pulses decoded in each band.
+	pulses *[maxBands]int
 }
 
 // quantAllBands drives RFC 6716 Section 4.3.4 shape decoding across the coded
@@ -482,6 +484,9 @@ func quantBand(
 			*remainingBits -= currentBits
 		}
 		if q != 0 {
+			if state.pulses != nil {
+				state.pulses[band] += getPulses(q)
+			}
 			collapseMask = algUnquant(x, n, getPulses(q), spread, blocks, state.rangeDecoder, gain, state)
 		} else {
 			mask := uint(1<<blocks) - 1
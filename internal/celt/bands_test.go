@@ -6,7 +6,7 @@ package celt
 import (
 	"testing"
 
-	"github.com/pion/opus/internal/rangecoding"
+	"github.com/pion/opus/pkg/rangecoding"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -240,7 +240,7 @@ func TestYBandSlice(t *testing.T) {
 
 func rangeDecoderForBandTests() *rangecoding.Decoder {
 	decoder := rangecoding.Decoder{}
-	decoder.SetInternalValues(make([]byte, 16), 0, 1<<31, 0)
+	decoder.SetState(make([]byte, 16), 0, 1<<31, 0)
 
 	return &decoder
 }
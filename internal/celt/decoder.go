@@ -4,7 +4,7 @@
 //nolint:cyclop,gosec,varnamelen // CELT decode keeps RFC/reference branch structure and vector naming.
 package celt
 
-import "github.com/pion/opus/internal/rangecoding"
+import "github.com/pion/opus/pkg/rangecoding"
 
 // Decoder maintains state for the RFC 6716 Section 4.3 CELT layer.
 type Decoder struct {
@@ -21,6 +21,7 @@ type Decoder struct {
 	lossCount      int
 	scratch        *decoderScratch
 	cwrsRows       map[cwrsRowKey][]uint32
+	report         *FrameReport
 }
 
 // NewDecoder creates a CELT decoder with the static Opus 48 kHz mode.
@@ -212,6 +213,9 @@ func (d *Decoder) decode(
 		if rangeDecoder != nil {
 			*rangeDecoder = d.rangeDecoder
 		}
+		if d.report != nil {
+			d.fillReport(&info, false)
+		}
 
 		return nil
 	}
@@ -235,14 +239,23 @@ func (d *Decoder) decode(
 		maskScratch:  scratch.collapseMasks[:],
 		cwrsRows:     d.cwrsRowCache(),
 	}
+	if d.report != nil {
+		state.pulses = &info.pulses
+	}
 	totalBits := (int(info.totalBits) << bitResolution) - info.antiCollapseRsv
 	collapseMasks := quantAllBands(&info, x, y, totalBits, &state)
+	d.markSymbolGroup(&info, SymbolGroupResidual)
 	antiCollapseOn := false
 	if info.antiCollapseRsv > 0 {
 		antiCollapseOn = d.rangeDecoder.DecodeRawBits(1) != 0
 	}
+	d.markSymbolGroup(&info, SymbolGroupAntiCollapse)
 	bitsLeft := int(info.totalBits) - int(d.rangeDecoder.Tell())
 	d.finalizeFineEnergy(&info, info.allocation.fineQuant, info.allocation.finePriority, bitsLeft)
+	d.markSymbolGroup(&info, SymbolGroupFinalFineEnergy)
+	if d.report != nil {
+		d.fillReport(&info, antiCollapseOn)
+	}
 	if antiCollapseOn {
 		d.antiCollapse(&info, x, y, collapseMasks, state.seed)
 	}
@@ -271,19 +284,7 @@ func (d *Decoder) cwrsRowCache() map[cwrsRowKey][]uint32 {
 }
 
 func (d *Decoder) decodeLostFrame(info *frameSideInfo, out []float32) {
-	decay := float32(1.5)
-	if d.lossCount > 0 {
-		decay = 0.5
-	}
-	for channel := range info.channelCount {
-		for band := info.startBand; band < info.endBand; band++ {
-			d.previousLogE[channel][band] -= decay
-		}
-	}
-	if info.channelCount == 1 {
-		copy(d.previousLogE[1][:], d.previousLogE[0][:])
-	}
-
+	d.decayLostEnergy(info)
 	info.postFilter = postFilter{
 		enabled: d.postfilter.gain != 0,
 		period:  d.postfilter.period,
@@ -321,6 +322,22 @@ func (d *Decoder) decodeLostFrame(info *frameSideInfo, out []float32) {
 	d.lossCount++
 }
 
+// decayLostEnergy fades the band energies a lost frame is concealed with.
+func (d *Decoder) decayLostEnergy(info *frameSideInfo) {
+	decay := float32(1.5)
+	if d.lossCount > 0 {
+		decay = 0.5
+	}
+	for channel := range info.channelCount {
+		for band := info.startBand; band < info.endBand; band++ {
+			d.previousLogE[channel][band] -= decay
+		}
+	}
+	if info.channelCount == 1 {
+		copy(d.previousLogE[1][:], d.previousLogE[0][:])
+	}
+}
+
 func infoFrameSampleCount(info *frameSideInfo) int {
 	return shortBlockSampleCount << info.lm
 }
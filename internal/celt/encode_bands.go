@@ -7,8 +7,8 @@ package celt
 import (
 	"math"
 
-	"github.com/pion/opus/internal/rangecoding"
 	"github.com/pion/opus/internal/slicetools"
+	"github.com/pion/opus/pkg/rangecoding"
 )
 
 // thetaRDOComplexity is the encoder complexity at which libopus turns on the
@@ -8,8 +8,8 @@ import (
 	"math"
 	"math/bits"
 
-	"github.com/pion/opus/internal/rangecoding"
 	"github.com/pion/opus/internal/slicetools"
+	"github.com/pion/opus/pkg/rangecoding"
 )
 
 // Encoder encodes PCM audio into CELT-only Opus frames.
@@ -0,0 +1,138 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package celt
+
+import (
+	"math"
+
+	"github.com/pion/opus/pkg/rangecoding"
+)
+
+// FrameEnergy is what DecodeEnergy reads from a CELT frame.
+type FrameEnergy struct {
+	// LogE is the energy of each band in base-2 log units, for Channels
+	// channels and the bands from StartBand up to EndBand.
+	LogE      [2][maxBands]float32
+	Channels  int
+	StartBand int
+	EndBand   int
+	// Blocks is the number of MDCTs the frame is split into: the short
+	// blocks of a transient frame, and 1 otherwise.
+	Blocks         int
+	PostFilterGain float32
+}
+
+// DecodeEnergy reads a CELT frame only as far as its fine energy, skipping the
+// PVQ residual, anti-collapse, final fine energy and synthesis. As in Decode, a
+// frame of a byte or less is lost, and rangeDecoder is nil unless it is shared
+// with the SILK layer.
+//
+// Coarse energy is predicted from the previous frame, so a Decoder used here
+// must see every frame of the stream, and only through DecodeEnergy. The final
+// fine energy bits it skips move each band by less than its fine step, and the
+// prediction lets that error decay rather than build up.
+func (d *Decoder) DecodeEnergy(
+	in []byte,
+	isStereo bool,
+	frameSampleCount int,
+	startBand int,
+	endBand int,
+	rangeDecoder *rangecoding.Decoder,
+) (FrameEnergy, error) {
+	channelCount := 1
+	if isStereo {
+		channelCount = 2
+	}
+	cfg := frameConfig{
+		frameSampleCount:   frameSampleCount,
+		startBand:          startBand,
+		endBand:            endBand,
+		channelCount:       channelCount,
+		outputChannelCount: channelCount,
+	}
+	if len(in) <= 1 {
+		info, err := d.validateFrameConfig(cfg)
+		if err != nil {
+			return FrameEnergy{}, err
+		}
+		d.decayLostEnergy(&info)
+		d.resetInactiveBandState(&info)
+		d.lossCount++
+
+		return d.frameEnergy(&info, d.postfilter.gain), nil
+	}
+
+	info, err := d.decodeFrameSideInfo(in, cfg, rangeDecoder)
+	if err != nil {
+		return FrameEnergy{}, err
+	}
+	if info.silence {
+		for channel := range info.channelCount {
+			for band := info.startBand; band < info.endBand; band++ {
+				d.previousLogE[channel][band] = -28
+			}
+		}
+	}
+	energy := d.frameEnergy(&info, info.postFilter.gain)
+	d.updatePostfilterState(&info)
+	d.updateLogEHistory(&info)
+	d.resetInactiveBandState(&info)
+	d.lossCount = 0
+	if rangeDecoder != nil {
+		*rangeDecoder = d.rangeDecoder
+	}
+
+	return energy, nil
+}
+
+func (d *Decoder) frameEnergy(info *frameSideInfo, postFilterGain float32) FrameEnergy {
+	return FrameEnergy{
+		LogE:           d.previousLogE,
+		Channels:       info.channelCount,
+		StartBand:      info.startBand,
+		EndBand:        info.endBand,
+		Blocks:         max(1, info.shortBlockCount),
+		PostFilterGain: postFilterGain,
+	}
+}
+
+// deemphasisBandGain is the power gain of the decoder's de-emphasis filter,
+// 1/(1 - 0.85z^-1), averaged over each band, as the coded energies are those
+// of the pre-emphasized signal.
+var deemphasisBandGain = func() (gain [maxBands]float64) { //nolint:gochecknoglobals
+	const steps = 16
+	for band := range gain {
+		for step := range steps {
+			// Band edges are in units of 200 Hz.
+			width := float64(bandEdges[band+1] - bandEdges[band])
+			omega := 2 * math.Pi * (float64(bandEdges[band]) + (float64(step)+0.5)/steps*width) * 200 / sampleRate
+			alpha := float64(preemphasisCoefficient)
+			gain[band] += 1 / (1 - 2*alpha*math.Cos(omega) + alpha*alpha)
+		}
+		gain[band] /= steps
+	}
+
+	return gain
+}()
+
+// Power returns the mean square, per sample and channel, of the audio the frame
+// decodes to at full scale 1, estimated from its band energies.
+func (e *FrameEnergy) Power() float64 {
+	// An inverse MDCT turns coefficients of total energy E into E/2 per
+	// sample, of a signal at the 32768 scale CELT works at.
+	const scale = 2 * 32768 * 32768
+
+	var power float64
+	for channel := range e.Channels {
+		for band := e.StartBand; band < e.EndBand; band++ {
+			lg := min(32, e.LogE[channel][band]+energyMeans[band])
+			power += math.Exp2(2*float64(lg)) * deemphasisBandGain[band]
+		}
+	}
+	// The post-filter is a comb filter with feedback, which raises the pitch
+	// harmonics that dominate a frame that enables it by 1/(1-g).
+	boost := 1 / (1 - float64(e.PostFilterGain))
+
+	return power * boost * boost / (scale * float64(e.Blocks*e.Channels))
+}
@@ -6,7 +6,7 @@ package celt
 import (
 	"fmt"
 
-	"github.com/pion/opus/internal/rangecoding"
+	"github.com/pion/opus/pkg/rangecoding"
 )
 
 const (
@@ -54,6 +54,12 @@ type frameSideInfo struct {
 	allocationTrim     int
 	allocation         allocationState
 	antiCollapseRsv    int
+
+	// pulses, symbolGroupStart and symbolGroupEnd are only kept for a
+	// FrameReport.
+	pulses           [maxBands]int
+	symbolGroupStart uint
+	symbolGroupEnd   [SymbolGroupCount]uint
 }
 
 type postFilter struct {
@@ -84,9 +90,12 @@ func (d *Decoder) decodeFrameSideInfo(
 	} else {
 		d.rangeDecoder.Init(data)
 	}
+	info.symbolGroupStart = d.rangeDecoder.TellFrac()
 
 	d.decodeSilenceFlag(&info)
 	if info.silence {
+		d.markSymbolGroup(&info, SymbolGroupHeader)
+
 		return info, nil
 	}
 
@@ -95,8 +104,10 @@ func (d *Decoder) decodeFrameSideInfo(
 	}
 	d.decodeTransientFlag(&info)
 	d.decodeIntraEnergyFlag(&info)
+	d.markSymbolGroup(&info, SymbolGroupHeader)
 	d.prepareCoarseEnergyHistory(&info)
 	d.decodeCoarseEnergy(&info)
+	d.markSymbolGroup(&info, SymbolGroupCoarseEnergy)
 	d.decodeAllocationHeader(&info)
 	d.decodeAllocationAndFineEnergy(&info)
 
@@ -337,7 +348,9 @@ func (d *Decoder) decodeAllocationAndFineEnergy(info *frameSideInfo) {
 	}
 	bits -= info.antiCollapseRsv
 	info.allocation = d.computeAllocation(info, bits)
+	d.markSymbolGroup(info, SymbolGroupAllocation)
 	d.decodeFineEnergy(info, info.allocation.fineQuant)
+	d.markSymbolGroup(info, SymbolGroupFineEnergy)
 }
 
 // decodeTimeFrequencyChanges decodes the RFC 6716 Section 4.3.1 per-band
@@ -6,7 +6,7 @@ package celt
 import (
 	"testing"
 
-	"github.com/pion/opus/internal/rangecoding"
+	"github.com/pion/opus/pkg/rangecoding"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -206,7 +206,7 @@ func TestDecodeFrameSideInfoRangeTrace(t *testing.T) {
 func TestDecodePostFilter(t *testing.T) {
 	decoder := NewDecoder()
 	decoder.rangeDecoder = rangeDecoderWithBinaryOne()
-	decoder.rangeDecoder.SetInternalValues(
+	decoder.rangeDecoder.SetState(
 		[]byte{0x5A, 0xA5},
 		40,
 		1<<31,
@@ -293,7 +293,7 @@ func TestDecodeTransientAndIntraFlags(t *testing.T) {
 func TestDecodeCoarseEnergy(t *testing.T) {
 	t.Run("decodes a Laplace-coded inter energy delta", func(t *testing.T) {
 		decoder := NewDecoder()
-		decoder.rangeDecoder.SetInternalValues(nil, 0, 1<<31, 32767<<16)
+		decoder.rangeDecoder.SetState(nil, 0, 1<<31, 32767<<16)
 		decoder.previousLogE[0][0] = 4
 		info := frameSideInfo{
 			lm:           1,
@@ -559,14 +559,14 @@ func TestDecodeAllocationTrim(t *testing.T) {
 
 func rangeDecoderWithBinaryOne() rangecoding.Decoder {
 	decoder := rangecoding.Decoder{}
-	decoder.SetInternalValues(nil, 40, 1<<31, 0)
+	decoder.SetState(nil, 40, 1<<31, 0)
 
 	return decoder
 }
 
 func rangeDecoderWithBinaryZero() rangecoding.Decoder {
 	decoder := rangecoding.Decoder{}
-	decoder.SetInternalValues(nil, 40, 1<<31, (1<<31)-1)
+	decoder.SetState(nil, 40, 1<<31, (1<<31)-1)
 
 	return decoder
 }
@@ -579,7 +579,7 @@ func rangeDecoderWithCDFSymbol(symbol, total uint32) rangecoding.Decoder {
 	const scale = 1 << 24
 
 	decoder := rangecoding.Decoder{}
-	decoder.SetInternalValues(nil, 0, total*scale, (total-symbol-1)*scale)
+	decoder.SetState(nil, 0, total*scale, (total-symbol-1)*scale)
 
 	return decoder
 }
@@ -7,7 +7,7 @@ import (
 	"math"
 	"testing"
 
-	"github.com/pion/opus/internal/rangecoding"
+	"github.com/pion/opus/pkg/rangecoding"
 	"github.com/stretchr/testify/assert"
 )
 
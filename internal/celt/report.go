@@ -0,0 +1,123 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package celt
+
+// Symbol groups of a CELT frame, in coding order, that FrameReport.SymbolBits
+// breaks the frame's bits down by.
+const (
+	// SymbolGroupHeader is the silence, post-filter, transient and intra
+	// flags.
+	SymbolGroupHeader = iota
+	SymbolGroupCoarseEnergy
+	// SymbolGroupAllocation is the tf_change, tf_select, spread, dynalloc,
+	// trim, skip, intensity and dual-stereo symbols.
+	SymbolGroupAllocation
+	SymbolGroupFineEnergy
+	// SymbolGroupResidual is the PVQ shapes and stereo angles.
+	SymbolGroupResidual
+	SymbolGroupAntiCollapse
+	SymbolGroupFinalFineEnergy
+	SymbolGroupCount
+)
+
+// FrameReport holds the parameters coded in one CELT frame, as the decoder read
+// them. Per-band arrays are indexed by band and only meaningful from StartBand
+// up to EndBand.
+type FrameReport struct {
+	StartBand int
+	EndBand   int
+	Channels  int
+	// LM is log2 of the frame size in 2.5 ms units.
+	LM      int
+	Silence bool
+
+	PostFilter       bool
+	PostFilterPeriod int
+	PostFilterGain   float32
+	PostFilterTapset int
+
+	Transient   bool
+	IntraEnergy bool
+	// CoarseEnergy is the band energy, in log2 units, after coarse
+	// quantization, and FineEnergy after the fine and final fine refinements.
+	CoarseEnergy [2][maxBands]float32
+	FineEnergy   [2][maxBands]float32
+
+	// TFResolution is the time-frequency resolution change of each band
+	// after mapping through tf_select, and BandBoost its dynalloc boost in
+	// eighth bits.
+	TFResolution   [maxBands]int
+	TFSelect       int
+	Spread         int
+	BandBoost      [maxBands]int
+	AllocationTrim int
+	// Intensity is the first band coded with intensity stereo, and
+	// DualStereo is set when the channels are coded separately.
+	Intensity  int
+	DualStereo bool
+	CodedBands int
+
+	// ShapeBits is the budget of each band's shape in eighth bits, FineBits
+	// the number of fine energy bits per channel, and Pulses the PVQ pulses
+	// the band was coded with, summed over its channels and splits.
+	ShapeBits    [maxBands]int
+	FineBits     [maxBands]int
+	Pulses       [maxBands]int
+	AntiCollapse bool
+
+	// SymbolBits is how many eighth bits each symbol group took, indexed by
+	// SymbolGroupHeader and the following constants.
+	SymbolBits [SymbolGroupCount]int
+}
+
+// SetReport makes the decoder fill report for every CELT frame it decodes,
+// until it is called with nil. Lost frames leave report untouched.
+func (d *Decoder) SetReport(report *FrameReport) {
+	d.report = report
+}
+
+// markSymbolGroup records that the range decoder has finished group.
+func (d *Decoder) markSymbolGroup(info *frameSideInfo, group int) {
+	info.symbolGroupEnd[group] = d.rangeDecoder.TellFrac()
+}
+
+func (d *Decoder) fillReport(info *frameSideInfo, antiCollapse bool) {
+	report := d.report
+	*report = FrameReport{
+		StartBand:        info.startBand,
+		EndBand:          info.endBand,
+		Channels:         info.channelCount,
+		LM:               info.lm,
+		Silence:          info.silence,
+		PostFilter:       info.postFilter.enabled,
+		PostFilterPeriod: info.postFilter.period,
+		PostFilterGain:   info.postFilter.gain,
+		PostFilterTapset: info.postFilter.tapset,
+		Transient:        info.transient,
+		IntraEnergy:      info.intraEnergy,
+		CoarseEnergy:     info.coarseEnergy,
+		FineEnergy:       d.previousLogE,
+		TFResolution:     info.tfChange,
+		TFSelect:         info.tfSelect,
+		Spread:           info.spread,
+		BandBoost:        info.bandBoost,
+		AllocationTrim:   info.allocationTrim,
+		Intensity:        info.allocation.intensity,
+		DualStereo:       info.allocation.dualStereo != 0,
+		CodedBands:       info.allocation.codedBands,
+		ShapeBits:        info.allocation.pulses,
+		FineBits:         info.allocation.fineQuant,
+		Pulses:           info.pulses,
+		AntiCollapse:     antiCollapse,
+	}
+
+	// Groups a frame stops short of, such as all but the header of a silent
+	// frame, took no bits.
+	previous := info.symbolGroupStart
+	for group, end := range info.symbolGroupEnd {
+		end = max(end, previous)
+		report.SymbolBits[group] = int(end - previous) //nolint:gosec // G115
+		previous = end
+	}
+}
@@ -6,7 +6,7 @@ package celt
 import (
 	"testing"
 
-	"github.com/pion/opus/internal/rangecoding"
+	"github.com/pion/opus/pkg/rangecoding"
 	"github.com/stretchr/testify/require"
 )
 
@@ -0,0 +1,354 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+// Package ebml holds the parts of EBML (RFC 8794) and of the Matroska
+// element set (RFC 9559) that WebM support needs: the element IDs of an
+// audio-only file and the variable-length integers elements are framed with,
+// read and written.
+package ebml
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+)
+
+// Element IDs, with their length markers, as they appear in the stream.
+const (
+	IDEBML               = 0x1A45DFA3
+	IDEBMLVersion        = 0x4286
+	IDEBMLReadVersion    = 0x42F7
+	IDEBMLMaxIDLength    = 0x42F2
+	IDEBMLMaxSizeLength  = 0x42F3
+	IDDocType            = 0x4282
+	IDDocTypeVersion     = 0x4287
+	IDDocTypeReadVersion = 0x4285
+	IDVoid               = 0xEC
+	IDCRC32              = 0xBF
+
+	IDSegment        = 0x18538067
+	IDSeekHead       = 0x114D9B74
+	IDSeek           = 0x4DBB
+	IDSeekID         = 0x53AB
+	IDSeekPosition   = 0x53AC
+	IDInfo           = 0x1549A966
+	IDTimestampScale = 0x2AD7B1
+	IDDuration       = 0x4489
+	IDMuxingApp      = 0x4D80
+	IDWritingApp     = 0x5741
+
+	IDTracks            = 0x1654AE6B
+	IDTrackEntry        = 0xAE
+	IDTrackNumber       = 0xD7
+	IDTrackUID          = 0x73C5
+	IDTrackType         = 0x83
+	IDCodecID           = 0x86
+	IDCodecPrivate      = 0x63A2
+	IDCodecDelay        = 0x56AA
+	IDSeekPreRoll       = 0x56BB
+	IDAudio             = 0xE1
+	IDSamplingFrequency = 0xB5
+	IDChannels          = 0x9F
+	IDBitDepth          = 0x6264
+
+	IDCluster        = 0x1F43B675
+	IDTimestamp      = 0xE7
+	IDSimpleBlock    = 0xA3
+	IDBlockGroup     = 0xA0
+	IDBlock          = 0xA1
+	IDBlockDuration  = 0x9B
+	IDDiscardPadding = 0x75A2
+
+	IDCues               = 0x1C53BB6B
+	IDCuePoint           = 0xBB
+	IDCueTime            = 0xB3
+	IDCueTrackPositions  = 0xB7
+	IDCueTrack           = 0xF7
+	IDCueClusterPosition = 0xF1
+)
+
+const (
+	// UnknownSize is the size of a master element whose end is only found
+	// by reading on, as live recordings write Segments and Clusters.
+	UnknownSize = -1
+
+	// TrackTypeAudio is the TrackType of audio tracks.
+	TrackTypeAudio = 2
+	// CodecIDOpus is the CodecID of Opus tracks.
+	CodecIDOpus = "A_OPUS"
+
+	maxIDLength   = 4
+	maxSizeLength = 8
+)
+
+var (
+	errInvalidVint  = errors.New("invalid EBML variable-length integer")
+	errInvalidFloat = errors.New("EBML float must be 0, 4 or 8 bytes")
+	errTruncated    = errors.New("EBML element runs past its parent")
+)
+
+// vintLength returns the length of the variable-length integer that starts
+// with first, from its leading zero bits.
+func vintLength(first byte, maxLength int) (int, error) {
+	for length := 1; length <= maxLength; length++ {
+		if first&(0x80>>(length-1)) != 0 {
+			return length, nil
+		}
+	}
+
+	return 0, errInvalidVint
+}
+
+// ReadID reads an element ID and returns it with its length marker, and the
+// number of bytes it took.
+func ReadID(r io.ByteReader) (id uint32, n int, err error) {
+	first, err := r.ReadByte()
+	if err != nil {
+		return 0, 0, err
+	}
+	if n, err = vintLength(first, maxIDLength); err != nil {
+		return 0, 1, err
+	}
+	id = uint32(first)
+	for i := 1; i < n; i++ {
+		next, err := r.ReadByte()
+		if err != nil {
+			return 0, i, noEOF(err)
+		}
+		id = id<<8 | uint32(next)
+	}
+
+	return id, n, nil
+}
+
+// ReadSize reads an element data size, which is UnknownSize when all its
+// value bits are set, and returns it with the number of bytes it took.
+func ReadSize(r io.ByteReader) (size int64, n int, err error) {
+	first, err := r.ReadByte()
+	if err != nil {
+		return 0, 0, noEOF(err)
+	}
+	if n, err = vintLength(first, maxSizeLength); err != nil {
+		return 0, 1, err
+	}
+	value := uint64(first & (0xff >> n))
+	allOnes := value == 0xff>>n
+	for i := 1; i < n; i++ {
+		next, err := r.ReadByte()
+		if err != nil {
+			return 0, i, noEOF(err)
+		}
+		value = value<<8 | uint64(next)
+		allOnes = allOnes && next == 0xff
+	}
+	if allOnes {
+		return UnknownSize, n, nil
+	}
+	if value > math.MaxInt64 {
+		return 0, n, errInvalidVint
+	}
+
+	return int64(value), n, nil
+}
+
+// ParseVint parses the variable-length integer at the start of data, without
+// its length marker, as Block headers and EBML lacing store them.
+func ParseVint(data []byte) (value uint64, n int, err error) {
+	if len(data) == 0 {
+		return 0, 0, errInvalidVint
+	}
+	if n, err = vintLength(data[0], maxSizeLength); err != nil {
+		return 0, 0, err
+	}
+	if len(data) < n {
+		return 0, 0, errInvalidVint
+	}
+	value = uint64(data[0] & (0xff >> n))
+	for _, next := range data[1:n] {
+		value = value<<8 | uint64(next)
+	}
+
+	return value, n, nil
+}
+
+// Uint parses the data of an unsigned integer element.
+func Uint(data []byte) uint64 {
+	var value uint64
+	for _, b := range data {
+		value = value<<8 | uint64(b)
+	}
+
+	return value
+}
+
+// Int parses the data of a signed integer element.
+func Int(data []byte) int64 {
+	if len(data) == 0 {
+		return 0
+	}
+	value := int64(int8(data[0]))
+	for _, b := range data[1:] {
+		value = value<<8 | int64(b)
+	}
+
+	return value
+}
+
+// Float parses the data of a float element.
+func Float(data []byte) (float64, error) {
+	switch len(data) {
+	case 0:
+		return 0, nil
+	case 4:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(data))), nil
+	case 8:
+		return math.Float64frombits(binary.BigEndian.Uint64(data)), nil
+	default:
+		return 0, errInvalidFloat
+	}
+}
+
+// Children calls fn with the ID and data of each element in data, the data
+// of a master element. None of them may have an unknown size.
+func Children(data []byte, fn func(id uint32, data []byte) error) error {
+	for len(data) > 0 {
+		reader := byteSlice{data: data}
+		id, idLength, err := ReadID(&reader)
+		if err != nil {
+			return noEOF(err)
+		}
+		size, sizeLength, err := ReadSize(&reader)
+		if err != nil {
+			return err
+		}
+		start := idLength + sizeLength
+		if size == UnknownSize || size > int64(len(data)-start) {
+			return errTruncated
+		}
+		end := start + int(size)
+		if err = fn(id, data[start:end]); err != nil {
+			return err
+		}
+		data = data[end:]
+	}
+
+	return nil
+}
+
+// AppendVint appends value as a variable-length integer of width bytes, or
+// of the fewest bytes that hold it for a width of 0. A value whose bits would
+// all be set, which reads as UnknownSize, gets a byte more.
+func AppendVint(b []byte, value uint64, width int) []byte {
+	if width == 0 {
+		width = 1
+		for width < maxSizeLength && value >= 1<<(7*width)-1 {
+			width++
+		}
+	}
+	value |= 1 << (7 * width)
+	for i := width - 1; i >= 0; i-- {
+		b = append(b, byte(value>>(8*i)))
+	}
+
+	return b
+}
+
+// AppendID appends an element ID, which includes its length marker.
+func AppendID(b []byte, id uint32) []byte {
+	for shift := 24; shift > 0; shift -= 8 {
+		if id>>shift != 0 {
+			b = append(b, byte(id>>shift))
+		}
+	}
+
+	return append(b, byte(id))
+}
+
+// AppendSize appends an element data size as AppendVint does, with all value
+// bits set for UnknownSize. A size written with a width of 8 can later be
+// overwritten in place with any other.
+func AppendSize(b []byte, size int64, width int) []byte {
+	if size == UnknownSize {
+		width = max(width, 1)
+
+		return AppendVint(b, 1<<(7*width)-1, width)
+	}
+
+	return AppendVint(b, uint64(size), width) //nolint:gosec // G115
+}
+
+// AppendElement appends an element with data.
+func AppendElement(b []byte, id uint32, data []byte) []byte {
+	b = AppendID(b, id)
+	b = AppendSize(b, int64(len(data)), 0)
+
+	return append(b, data...)
+}
+
+// AppendUint appends an unsigned integer element in as few bytes as hold it.
+func AppendUint(b []byte, id uint32, value uint64) []byte {
+	length := 1
+	for length < 8 && value>>(8*length) != 0 {
+		length++
+	}
+	b = AppendID(b, id)
+	b = AppendSize(b, int64(length), 0)
+	for i := length - 1; i >= 0; i-- {
+		b = append(b, byte(value>>(8*i)))
+	}
+
+	return b
+}
+
+// AppendInt appends a signed integer element in as few bytes as hold it.
+func AppendInt(b []byte, id uint32, value int64) []byte {
+	length := 1
+	for length < 8 && (value < -1<<(8*length-1) || value >= 1<<(8*length-1)) {
+		length++
+	}
+	b = AppendID(b, id)
+	b = AppendSize(b, int64(length), 0)
+	for i := length - 1; i >= 0; i-- {
+		b = append(b, byte(value>>(8*i)))
+	}
+
+	return b
+}
+
+// AppendFloat appends an 8-byte float element.
+func AppendFloat(b []byte, id uint32, value float64) []byte {
+	b = AppendID(b, id)
+	b = AppendSize(b, 8, 0)
+
+	return binary.BigEndian.AppendUint64(b, math.Float64bits(value))
+}
+
+// AppendString appends a string element.
+func AppendString(b []byte, id uint32, value string) []byte {
+	return AppendElement(b, id, []byte(value))
+}
+
+// noEOF turns an end of input inside an element into io.ErrUnexpectedEOF.
+func noEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
+
+// byteSlice reads bytes from a slice without copying it.
+type byteSlice struct {
+	data []byte
+	next int
+}
+
+func (b *byteSlice) ReadByte() (byte, error) {
+	if b.next >= len(b.data) {
+		return 0, io.EOF
+	}
+	b.next++
+
+	return b.data[b.next-1], nil
+}
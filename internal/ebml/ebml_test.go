@@ -0,0 +1,115 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package ebml
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadIDAndSize(t *testing.T) {
+	reader := bytes.NewReader([]byte{
+		0x1A, 0x45, 0xDF, 0xA3, 0x84, // EBML, 4 bytes
+		0x18, 0x53, 0x80, 0x67, 0x01, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, // Segment, unknown size
+		0xA3, 0x40, 0x02, // SimpleBlock, 2 bytes in a two-byte size
+		0xff, // unknown size in one byte
+	})
+
+	for _, want := range []struct {
+		id, idLength int
+		size         int64
+		sizeLength   int
+	}{
+		{IDEBML, 4, 4, 1},
+		{IDSegment, 4, UnknownSize, 8},
+		{IDSimpleBlock, 1, 2, 2},
+	} {
+		id, n, err := ReadID(reader)
+		require.NoError(t, err)
+		assert.Equal(t, uint32(want.id), id) //nolint:gosec // G115
+		assert.Equal(t, want.idLength, n)
+		size, n, err := ReadSize(reader)
+		require.NoError(t, err)
+		assert.Equal(t, want.size, size)
+		assert.Equal(t, want.sizeLength, n)
+	}
+	size, _, err := ReadSize(reader)
+	require.NoError(t, err)
+	assert.Equal(t, int64(UnknownSize), size)
+
+	_, _, err = ReadID(reader)
+	assert.ErrorIs(t, err, io.EOF)
+	_, _, err = ReadID(bytes.NewReader([]byte{0x1A, 0x45}))
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	_, _, err = ReadSize(bytes.NewReader([]byte{0x00}))
+	assert.ErrorIs(t, err, errInvalidVint)
+}
+
+func TestValues(t *testing.T) {
+	value, n, err := ParseVint([]byte{0x40, 0x02, 0xff})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), value)
+	assert.Equal(t, 2, n)
+	_, _, err = ParseVint([]byte{0x20, 0x01})
+	assert.ErrorIs(t, err, errInvalidVint)
+
+	assert.Equal(t, uint64(0x0f4240), Uint([]byte{0x0f, 0x42, 0x40}))
+	assert.Equal(t, int64(-2), Int([]byte{0xff, 0xfe}))
+	assert.Equal(t, int64(0x7ffe), Int([]byte{0x7f, 0xfe}))
+
+	float, err := Float([]byte{0x47, 0x3b, 0x80, 0x00})
+	require.NoError(t, err)
+	assert.InDelta(t, 48000, float, 0)
+	_, err = Float([]byte{1, 2})
+	assert.ErrorIs(t, err, errInvalidFloat)
+}
+
+func TestChildren(t *testing.T) {
+	var ids []uint32
+	require.NoError(t, Children([]byte{0xD7, 0x81, 0x01, 0x86, 0x82, 'A', 'B'}, func(id uint32, data []byte) error {
+		ids = append(ids, id)
+
+		return nil
+	}))
+	assert.Equal(t, []uint32{IDTrackNumber, IDCodecID}, ids)
+
+	err := Children([]byte{0xD7, 0x85, 0x01}, func(uint32, []byte) error { return nil })
+	assert.ErrorIs(t, err, errTruncated)
+}
+
+func TestAppend(t *testing.T) {
+	assert.Equal(t, []byte{0x81}, AppendVint(nil, 1, 0))
+	assert.Equal(t, []byte{0x40, 0x7f}, AppendVint(nil, 127, 0), "0xff would read as unknown")
+	assert.Equal(t, []byte{0x01, 0, 0, 0, 0, 0, 0, 0x02}, AppendSize(nil, 2, 8))
+	assert.Equal(t, []byte{0xff}, AppendSize(nil, UnknownSize, 0))
+	assert.Equal(t, []byte{0x01, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, AppendSize(nil, UnknownSize, 8))
+
+	assert.Equal(t, []byte{0x1A, 0x45, 0xDF, 0xA3}, AppendID(nil, IDEBML))
+	assert.Equal(t, []byte{0xA3}, AppendID(nil, IDSimpleBlock))
+	assert.Equal(t, []byte{0x2A, 0xD7, 0xB1, 0x83, 0x0f, 0x42, 0x40}, AppendUint(nil, IDTimestampScale, 1000000))
+	assert.Equal(t, []byte{0xD7, 0x81, 0x00}, AppendUint(nil, IDTrackNumber, 0))
+	assert.Equal(t, []byte{0x75, 0xA2, 0x82, 0xff, 0x7f}, AppendInt(nil, IDDiscardPadding, -129))
+	assert.Equal(t, []byte{0x75, 0xA2, 0x81, 0x7f}, AppendInt(nil, IDDiscardPadding, 127))
+	assert.Equal(t, []byte{0x86, 0x86, 'A', '_', 'O', 'P', 'U', 'S'}, AppendString(nil, IDCodecID, CodecIDOpus))
+
+	var element []byte
+	element = AppendFloat(element, IDSamplingFrequency, 48000)
+	element = AppendInt(element, IDDiscardPadding, -2)
+	require.NoError(t, Children(element, func(id uint32, data []byte) error {
+		switch id {
+		case IDSamplingFrequency:
+			value, err := Float(data)
+			require.NoError(t, err)
+			assert.InDelta(t, 48000, value, 0)
+		case IDDiscardPadding:
+			assert.Equal(t, int64(-2), Int(data))
+		}
+
+		return nil
+	}))
+}
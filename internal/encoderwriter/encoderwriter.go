@@ -0,0 +1,169 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+// Package encoderwriter encodes a stream of PCM with an opus.Encoder and hands
+// the packets to a container writer, the logic behind the EncoderWriter of
+// each container package and behind opusenc.
+package encoderwriter
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/pion/opus"
+)
+
+const (
+	// maxPacketSize is one TOC byte plus the largest frame RFC 6716 allows.
+	maxPacketSize = 1 + 1275
+
+	// granuleSampleRate is the rate granule positions count samples at.
+	granuleSampleRate = 48000
+)
+
+var errWriterClosed = errors.New("writer is closed")
+
+// PacketWriter is the container writer a Writer sends its packets to, such as
+// an oggwriter.OggWriter.
+type PacketWriter interface {
+	// WritePacket writes one packet whose last sample ends at granulePosition
+	// at 48 kHz, counting the pre-skip.
+	WritePacket(packet []byte, granulePosition uint64) error
+	Close() error
+}
+
+// Writer buffers interleaved PCM into whole encoder frames and writes each
+// encoded packet to a PacketWriter.
+//
+// The stream starts with PreSkip samples of the encoder's lookahead. Close
+// pads the last partial frame and gives the last packet the granule position
+// where the input ended, so the container can trim the padding off again.
+type Writer struct {
+	packets PacketWriter
+	encoder *opus.Encoder
+	frame   []float32
+	packet  []byte
+	// split holds the first byte of an S16LE sample split across calls to
+	// Write.
+	split []byte
+
+	frameSamples   int
+	frameDuration  uint64
+	preSkip        uint64
+	samplesWritten uint64
+	granule        uint64
+	closed         bool
+}
+
+// PreSkip returns the samples at 48 kHz that a stream encoded by encoder
+// starts with before its input, the encoder's lookahead.
+func PreSkip(encoder *opus.Encoder) int {
+	return encoder.Lookahead() * granuleSampleRate / encoder.SampleRate()
+}
+
+// New returns a Writer that encodes PCM with encoder and writes the packets
+// to packets, which already holds the headers for encoder's configuration.
+func New(packets PacketWriter, encoder *opus.Encoder) *Writer {
+	frameSamples := encoder.FrameSize() * encoder.Channels()
+
+	return &Writer{
+		packets:       packets,
+		encoder:       encoder,
+		frame:         make([]float32, 0, frameSamples),
+		packet:        make([]byte, maxPacketSize),
+		split:         make([]byte, 0, 2),
+		frameSamples:  frameSamples,
+		frameDuration: uint64(encoder.FrameSize() * granuleSampleRate / encoder.SampleRate()), // #nosec G115
+		preSkip:       uint64(PreSkip(encoder)),                                               // #nosec G115
+	}
+}
+
+// Write buffers p, which holds interleaved S16LE samples, and encodes every
+// frame it completes. A sample may be split across calls.
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, errWriterClosed
+	}
+
+	written := 0
+	if len(w.split) > 0 && len(p) > 0 {
+		w.split = append(w.split, p[0])
+		p = p[1:]
+		written++
+		if err := w.push(float32(int16(binary.LittleEndian.Uint16(w.split))) / 32768); err != nil { //nolint:gosec // G115
+			return written, err
+		}
+		w.split = w.split[:0]
+	}
+	for ; len(p) >= 2; p = p[2:] {
+		written += 2
+		if err := w.push(float32(int16(binary.LittleEndian.Uint16(p))) / 32768); err != nil { //nolint:gosec // G115
+			return written, err
+		}
+	}
+	w.split = append(w.split, p...)
+
+	return written + len(p), nil
+}
+
+// WriteFloat32 buffers pcm, which holds interleaved samples at full scale 1,
+// and encodes every frame it completes.
+func (w *Writer) WriteFloat32(pcm []float32) error {
+	if w.closed {
+		return errWriterClosed
+	}
+
+	for _, sample := range pcm {
+		if err := w.push(sample); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// push buffers one sample and encodes the frame it completes.
+func (w *Writer) push(sample float32) error {
+	w.frame = append(w.frame, sample)
+	w.samplesWritten++
+	if len(w.frame) < w.frameSamples {
+		return nil
+	}
+
+	return w.encodeFrame(w.granule + w.frameDuration)
+}
+
+// Close encodes the buffered partial frame, padded with silence, plus as
+// many silent frames as the lookahead needs to flush the last input samples
+// out of the encoder. It then closes the PacketWriter.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	samples := w.samplesWritten / uint64(w.encoder.Channels())                  // #nosec G115
+	end := w.preSkip + samples*granuleSampleRate/uint64(w.encoder.SampleRate()) // #nosec G115
+	for w.granule < end {
+		w.frame = append(w.frame, make([]float32, w.frameSamples-len(w.frame))...)
+
+		// The last packet's granule position is where the input ended, which
+		// tells the container where to cut the padding past it.
+		if err := w.encodeFrame(min(w.granule+w.frameDuration, end)); err != nil {
+			return errors.Join(err, w.packets.Close())
+		}
+	}
+
+	return w.packets.Close()
+}
+
+func (w *Writer) encodeFrame(granule uint64) error {
+	n, err := w.encoder.EncodeFloat32(w.frame, w.packet)
+	if err != nil {
+		return err
+	}
+	w.frame = w.frame[:0]
+	w.granule += w.frameDuration
+
+	return w.packets.WritePacket(w.packet[:n], granule)
+}
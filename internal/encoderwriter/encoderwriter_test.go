@@ -0,0 +1,165 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package encoderwriter
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/pion/opus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errTestSink = errors.New("sink failed")
+
+// packetRecorder is a PacketWriter that keeps what it is given.
+type packetRecorder struct {
+	packets  [][]byte
+	granules []uint64
+	closes   int
+	err      error
+}
+
+func (r *packetRecorder) WritePacket(packet []byte, granulePosition uint64) error {
+	r.packets = append(r.packets, slices.Clone(packet))
+	r.granules = append(r.granules, granulePosition)
+
+	return r.err
+}
+
+func (r *packetRecorder) Close() error {
+	r.closes++
+
+	return nil
+}
+
+func sineS16LE(samples, channels int) []byte {
+	pcm := make([]byte, 0, samples*channels*2)
+	for i := range samples {
+		sample := int16(8000 * math.Sin(2*math.Pi*440*float64(i)/48000))
+		for range channels {
+			pcm = binary.LittleEndian.AppendUint16(pcm, uint16(sample)) //nolint:gosec // Two's complement on purpose.
+		}
+	}
+
+	return pcm
+}
+
+func TestWriter(t *testing.T) {
+	for _, channels := range []int{1, 2} {
+		encoder, err := opus.NewEncoder(opus.WithChannels(channels))
+		require.NoError(t, err)
+
+		var recorder packetRecorder
+		writer := New(&recorder, encoder)
+
+		// 1.5 s plus a bit, in writes that split frames and samples alike.
+		const samples = 72000 + 333
+		pcm := sineS16LE(samples, channels)
+		for len(pcm) > 0 {
+			n := min(len(pcm), 1001)
+			written, err := writer.Write(pcm[:n])
+			require.NoError(t, err)
+			require.Equal(t, n, written)
+			pcm = pcm[n:]
+		}
+		require.NoError(t, writer.Close())
+		require.NoError(t, writer.Close())
+		assert.Equal(t, 1, recorder.closes)
+
+		_, err = writer.Write([]byte{0, 0})
+		assert.ErrorIs(t, err, errWriterClosed)
+
+		preSkip := PreSkip(encoder)
+		assert.Equal(t, encoder.Lookahead(), preSkip)
+		wantPackets := (preSkip + samples + encoder.FrameSize() - 1) / encoder.FrameSize()
+		require.Len(t, recorder.packets, wantPackets)
+		for i, granule := range recorder.granules[:wantPackets-1] {
+			assert.Equal(t, uint64((i+1)*encoder.FrameSize()), granule) //nolint:gosec // G115
+		}
+		assert.Equal(t, uint64(preSkip+samples), recorder.granules[wantPackets-1]) //nolint:gosec // G115
+
+		decoder, err := opus.NewDecoderWithOutput(48000, channels)
+		require.NoError(t, err)
+		decoded := make([]float32, encoder.FrameSize()*channels)
+		for _, packet := range recorder.packets {
+			n, err := decoder.DecodeToFloat32(packet, decoded)
+			require.NoError(t, err)
+			assert.Equal(t, encoder.FrameSize(), n)
+		}
+	}
+}
+
+func TestWriterShortInput(t *testing.T) {
+	encoder, err := opus.NewEncoder()
+	require.NoError(t, err)
+
+	var recorder packetRecorder
+	writer := New(&recorder, encoder)
+	_, err = writer.Write(sineS16LE(100, 1))
+	require.NoError(t, err)
+	assert.Empty(t, recorder.packets)
+	require.NoError(t, writer.Close())
+
+	require.Len(t, recorder.packets, 1)
+	assert.Equal(t, []uint64{uint64(encoder.Lookahead() + 100)}, recorder.granules) //nolint:gosec // G115
+}
+
+func TestWriterSinkError(t *testing.T) {
+	encoder, err := opus.NewEncoder()
+	require.NoError(t, err)
+
+	recorder := packetRecorder{err: errTestSink}
+	writer := New(&recorder, encoder)
+	_, err = writer.Write(sineS16LE(encoder.FrameSize(), 1))
+	require.ErrorIs(t, err, errTestSink)
+
+	assert.ErrorIs(t, writer.Close(), errTestSink)
+	assert.Equal(t, 1, recorder.closes)
+}
+
+func TestWriterFloat32MatchesWrite(t *testing.T) {
+	pcm := sineS16LE(4800+77, 2)
+	floats := make([]float32, len(pcm)/2)
+	for i := range floats {
+		floats[i] = float32(int16(binary.LittleEndian.Uint16(pcm[i*2:]))) / 32768 //nolint:gosec // G115
+	}
+
+	encode := func(write func(*Writer) error) packetRecorder {
+		encoder, err := opus.NewEncoder(opus.WithChannels(2))
+		require.NoError(t, err)
+
+		var recorder packetRecorder
+		writer := New(&recorder, encoder)
+		require.NoError(t, write(writer))
+		require.NoError(t, writer.Close())
+
+		return recorder
+	}
+
+	bytes := encode(func(w *Writer) error {
+		_, err := w.Write(pcm)
+
+		return err
+	})
+	float := encode(func(w *Writer) error {
+		if err := w.WriteFloat32(floats[:1001]); err != nil {
+			return err
+		}
+
+		return w.WriteFloat32(floats[1001:])
+	})
+	assert.Equal(t, bytes.packets, float.packets)
+	assert.Equal(t, bytes.granules, float.granules)
+
+	encoder, err := opus.NewEncoder()
+	require.NoError(t, err)
+	writer := New(&packetRecorder{}, encoder)
+	require.NoError(t, writer.Close())
+	assert.ErrorIs(t, writer.WriteFloat32(floats), errWriterClosed)
+}
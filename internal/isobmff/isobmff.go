@@ -0,0 +1,144 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+// Package isobmff holds the box framing of the ISO base media file format
+// (ISO/IEC 14496-12) that MP4 support needs: box headers, full box headers
+// and walking the children of a container box, read and written.
+package isobmff
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+)
+
+const (
+	// ToEnd is the size of a box that runs to the end of the file, as only
+	// the last box may.
+	ToEnd = -1
+
+	headerLength      = 8
+	largeHeaderLength = 16
+	fullHeaderLength  = 4
+)
+
+var (
+	errInvalidSize = errors.New("invalid box size")
+	errTruncated   = errors.New("box runs past its parent")
+)
+
+// ReadHeader reads a box header and returns the box type, the size of its
+// payload, which is ToEnd for a box that runs to the end of the file, and the
+// number of bytes the header took.
+func ReadHeader(r io.Reader) (boxType string, size int64, n int, err error) {
+	var header [largeHeaderLength]byte
+	if n, err = io.ReadFull(r, header[:headerLength]); err != nil {
+		if n > 0 {
+			err = io.ErrUnexpectedEOF
+		}
+
+		return "", 0, n, err
+	}
+	boxType = string(header[4:8])
+	switch size32 := binary.BigEndian.Uint32(header[:4]); size32 {
+	case 0:
+		return boxType, ToEnd, n, nil
+	case 1:
+		read, err := io.ReadFull(r, header[headerLength:])
+		n += read
+		if err != nil {
+			return "", 0, n, noEOF(err)
+		}
+		large := binary.BigEndian.Uint64(header[headerLength:])
+		if large < largeHeaderLength || large > math.MaxInt64 {
+			return "", 0, n, errInvalidSize
+		}
+
+		return boxType, int64(large) - largeHeaderLength, n, nil
+	default:
+		if size32 < headerLength {
+			return "", 0, n, errInvalidSize
+		}
+
+		return boxType, int64(size32) - headerLength, n, nil
+	}
+}
+
+// Children calls fn with the type and payload of each box in data, the
+// payload of a container box.
+func Children(data []byte, fn func(boxType string, data []byte) error) error {
+	for len(data) > 0 {
+		if len(data) < headerLength {
+			return errTruncated
+		}
+		boxType := string(data[4:8])
+		start, size := int64(headerLength), int64(binary.BigEndian.Uint32(data))
+		switch size {
+		case 0:
+			size = int64(len(data))
+		case 1:
+			if len(data) < largeHeaderLength {
+				return errTruncated
+			}
+			large := binary.BigEndian.Uint64(data[headerLength:])
+			if large > math.MaxInt64 {
+				return errInvalidSize
+			}
+			start, size = largeHeaderLength, int64(large)
+		}
+		if size < start {
+			return errInvalidSize
+		}
+		if size > int64(len(data)) {
+			return errTruncated
+		}
+		if err := fn(boxType, data[start:size]); err != nil {
+			return err
+		}
+		data = data[size:]
+	}
+
+	return nil
+}
+
+// FullBox splits the payload of a full box into its version, flags and the
+// rest.
+func FullBox(data []byte) (version uint8, flags uint32, rest []byte, err error) {
+	if len(data) < fullHeaderLength {
+		return 0, 0, nil, errTruncated
+	}
+
+	return data[0], binary.BigEndian.Uint32(data) & 0xffffff, data[fullHeaderLength:], nil
+}
+
+// AppendBox appends a box with the concatenation of payloads as its payload.
+func AppendBox(b []byte, boxType string, payloads ...[]byte) []byte {
+	size := headerLength
+	for _, payload := range payloads {
+		size += len(payload)
+	}
+	b = binary.BigEndian.AppendUint32(b, uint32(size)) //nolint:gosec // G115
+	b = append(b, boxType[:4]...)
+	for _, payload := range payloads {
+		b = append(b, payload...)
+	}
+
+	return b
+}
+
+// AppendFullBox appends a full box with version, flags and payloads.
+func AppendFullBox(b []byte, boxType string, version uint8, flags uint32, payloads ...[]byte) []byte {
+	header := binary.BigEndian.AppendUint32(nil, uint32(version)<<24|flags&0xffffff)
+
+	return AppendBox(b, boxType, append([][]byte{header}, payloads...)...)
+}
+
+// noEOF turns an end of input inside a box into io.ErrUnexpectedEOF.
+func noEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
@@ -0,0 +1,76 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package isobmff
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadHeader(t *testing.T) {
+	reader := bytes.NewReader([]byte{
+		0, 0, 0, 12, 'f', 't', 'y', 'p',
+		0, 0, 0, 1, 'm', 'd', 'a', 't', 0, 0, 0, 0, 0, 0, 0, 20,
+		0, 0, 0, 0, 'f', 'r', 'e', 'e',
+	})
+
+	for _, want := range []struct {
+		boxType string
+		size    int64
+		n       int
+	}{
+		{"ftyp", 4, 8},
+		{"mdat", 4, 16},
+		{"free", ToEnd, 8},
+	} {
+		boxType, size, n, err := ReadHeader(reader)
+		require.NoError(t, err)
+		assert.Equal(t, want.boxType, boxType)
+		assert.Equal(t, want.size, size)
+		assert.Equal(t, want.n, n)
+	}
+	_, _, _, err := ReadHeader(reader)
+	assert.ErrorIs(t, err, io.EOF)
+
+	_, _, _, err = ReadHeader(bytes.NewReader([]byte{0, 0, 0, 12}))
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	_, _, _, err = ReadHeader(bytes.NewReader([]byte{0, 0, 0, 7, 'f', 'r', 'e', 'e'}))
+	assert.ErrorIs(t, err, errInvalidSize)
+}
+
+func TestAppendAndChildren(t *testing.T) {
+	box := AppendBox(nil, "moov",
+		AppendFullBox(nil, "mvhd", 1, 0x000203, []byte{0xaa}),
+		AppendBox(nil, "trak"),
+	)
+	assert.Equal(t, []byte{
+		0, 0, 0, 29, 'm', 'o', 'o', 'v',
+		0, 0, 0, 13, 'm', 'v', 'h', 'd', 1, 0, 2, 3, 0xaa,
+		0, 0, 0, 8, 't', 'r', 'a', 'k',
+	}, box)
+
+	var types []string
+	require.NoError(t, Children(box[8:], func(boxType string, data []byte) error {
+		types = append(types, boxType)
+		if boxType == "mvhd" {
+			version, flags, rest, err := FullBox(data)
+			require.NoError(t, err)
+			assert.Equal(t, uint8(1), version)
+			assert.Equal(t, uint32(0x000203), flags)
+			assert.Equal(t, []byte{0xaa}, rest)
+		}
+
+		return nil
+	}))
+	assert.Equal(t, []string{"mvhd", "trak"}, types)
+
+	err := Children(box[8:20], func(string, []byte) error { return nil })
+	assert.ErrorIs(t, err, errTruncated)
+	_, _, _, err = FullBox([]byte{1, 0})
+	assert.ErrorIs(t, err, errTruncated)
+}
@@ -0,0 +1,210 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+// Package mpegts holds the parts of MPEG-2 transport streams (ISO/IEC
+// 13818-1) and of their Opus mapping that MPEG-TS support needs: the PSI
+// checksum, the Opus control header that starts every access unit, and the
+// channel configurations the Opus extension descriptor codes.
+package mpegts
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pion/opus/pkg/oggreader"
+)
+
+const (
+	// PacketSize is the size of a transport stream packet.
+	PacketSize = 188
+	// SyncByte starts every transport stream packet.
+	SyncByte = 0x47
+	// PIDPAT is the PID of the program association table.
+	PIDPAT = 0x0000
+
+	// TableIDPAT and TableIDPMT are the table IDs of the program association
+	// and program map sections.
+	TableIDPAT = 0x00
+	TableIDPMT = 0x02
+
+	// StreamTypePrivatePES is the stream type Opus is carried with.
+	StreamTypePrivatePES = 0x06
+	// StreamIDPrivate1 is the PES stream ID of Opus.
+	StreamIDPrivate1 = 0xBD
+
+	// DescriptorRegistration is the tag of the registration descriptor,
+	// whose format identifier is FormatIdentifierOpus for Opus.
+	DescriptorRegistration = 0x05
+	FormatIdentifierOpus   = "Opus"
+	// DescriptorExtension is the tag of the DVB extension descriptor, and
+	// ExtensionOpus the extension tag under which it holds the channel
+	// configuration code of Opus.
+	DescriptorExtension = 0x7F
+	ExtensionOpus       = 0x80
+
+	// MaxTrim is the largest start or end trim a control header holds.
+	MaxTrim = 0x1FFF
+
+	controlHeaderPrefix   = 0x7FE0
+	controlPrefixMask     = 0xFFE0
+	controlStartTrimFlag  = 0x10
+	controlEndTrimFlag    = 0x08
+	controlExtensionFlag  = 0x04
+	channelConfigDualMono = 0x00
+	maxChannelConfig      = 0x08
+)
+
+var (
+	errNotControlHeader        = errors.New("missing Opus control header")
+	errTruncatedControlHeader  = errors.New("truncated Opus control header")
+	errUnsupportedChannelCode  = errors.New("unsupported Opus channel configuration code")
+	errUnsupportedChannelSetup = errors.New("channel mapping has no Opus channel configuration code")
+)
+
+// ControlHeader is the Opus control header of an access unit.
+type ControlHeader struct {
+	// Size is the size of the Opus packet that follows the header.
+	Size int
+	// StartTrim and EndTrim are the 48 kHz samples to drop from the start
+	// and the end of the decoded packet.
+	StartTrim, EndTrim uint16
+}
+
+// ParseControlHeader parses the control header at the start of data and
+// returns it with the number of bytes it took.
+func ParseControlHeader(data []byte) (header ControlHeader, n int, err error) {
+	if len(data) < 2 || (uint16(data[0])<<8|uint16(data[1]))&controlPrefixMask != controlHeaderPrefix {
+		return header, 0, errNotControlHeader
+	}
+	flags := data[1]
+	n = 2
+	for {
+		if n >= len(data) {
+			return header, 0, errTruncatedControlHeader
+		}
+		header.Size += int(data[n])
+		n++
+		if data[n-1] != 0xFF {
+			break
+		}
+	}
+	for _, trim := range []struct {
+		flag  byte
+		value *uint16
+	}{
+		{controlStartTrimFlag, &header.StartTrim},
+		{controlEndTrimFlag, &header.EndTrim},
+	} {
+		if flags&trim.flag == 0 {
+			continue
+		}
+		if n+2 > len(data) {
+			return header, 0, errTruncatedControlHeader
+		}
+		*trim.value = (uint16(data[n])<<8 | uint16(data[n+1])) & MaxTrim
+		n += 2
+	}
+	if flags&controlExtensionFlag != 0 {
+		if n >= len(data) || n+1+int(data[n]) > len(data) {
+			return header, 0, errTruncatedControlHeader
+		}
+		n += 1 + int(data[n])
+	}
+
+	return header, n, nil
+}
+
+// AppendControlHeader appends the control header of an access unit holding
+// an Opus packet of size bytes. Trims of 0 are left out, and larger ones
+// than MaxTrim capped.
+func AppendControlHeader(b []byte, size int, startTrim, endTrim uint16) []byte {
+	flags := byte(controlHeaderPrefix & 0xFF)
+	if startTrim != 0 {
+		flags |= controlStartTrimFlag
+	}
+	if endTrim != 0 {
+		flags |= controlEndTrimFlag
+	}
+	b = append(b, controlHeaderPrefix>>8, flags)
+	for ; size >= 0xFF; size -= 0xFF {
+		b = append(b, 0xFF)
+	}
+	b = append(b, byte(size))
+	for _, trim := range []uint16{startTrim, endTrim} {
+		if trim != 0 {
+			trim = min(trim, MaxTrim)
+			b = append(b, byte(trim>>8), byte(trim))
+		}
+	}
+
+	return b
+}
+
+// vorbisMappings are the channel mappings of channel mapping family 1, the
+// Vorbis channel order, by channel count (RFC 7845 Section 5.1.1.2).
+//
+//nolint:gochecknoglobals
+var vorbisMappings = [maxChannelConfig + 1]oggreader.OggChannelMapping{
+	3: {StreamCount: 2, CoupledCount: 1, Mapping: []uint8{0, 2, 1}},
+	4: {StreamCount: 2, CoupledCount: 2, Mapping: []uint8{0, 1, 2, 3}},
+	5: {StreamCount: 3, CoupledCount: 2, Mapping: []uint8{0, 4, 1, 2, 3}},
+	6: {StreamCount: 4, CoupledCount: 2, Mapping: []uint8{0, 4, 1, 2, 3, 5}},
+	7: {StreamCount: 4, CoupledCount: 3, Mapping: []uint8{0, 4, 1, 2, 3, 5, 6}},
+	8: {StreamCount: 5, CoupledCount: 3, Mapping: []uint8{0, 6, 1, 2, 3, 4, 5, 7}},
+}
+
+// dualMono is two mono streams in channel mapping family 255.
+//
+//nolint:gochecknoglobals
+var dualMono = oggreader.OggChannelMapping{StreamCount: 2, CoupledCount: 0, Mapping: []uint8{0, 1}}
+
+// ChannelConfig returns the channel count, the channel mapping family and
+// the channel mapping that a channel configuration code stands for: 1 to 8
+// channels in the Vorbis order, or 0 for dual mono.
+func ChannelConfig(code byte) (channels, family uint8, mapping oggreader.OggChannelMapping, err error) {
+	switch {
+	case code == channelConfigDualMono:
+		return 2, 255, dualMono, nil
+	case code <= 2:
+		return code, 0, mapping, nil
+	case code <= maxChannelConfig:
+		return code, 1, vorbisMappings[code], nil
+	default:
+		return 0, 0, mapping, fmt.Errorf("%w: 0x%02X", errUnsupportedChannelCode, code)
+	}
+}
+
+// ChannelConfigCode returns the channel configuration code of a stream with
+// header and mapping, which must be one ChannelConfig gives.
+func ChannelConfigCode(header oggreader.OggHeader, mapping oggreader.OggChannelMapping) (byte, error) {
+	for code := range byte(maxChannelConfig + 1) {
+		channels, family, want, _ := ChannelConfig(code)
+		if header.Channels != channels || header.ChannelMap != family {
+			continue
+		}
+		if family == 0 || (mapping.StreamCount == want.StreamCount && mapping.CoupledCount == want.CoupledCount &&
+			string(mapping.Mapping) == string(want.Mapping)) {
+			return code, nil
+		}
+	}
+
+	return 0, fmt.Errorf("%w: %d channels in family %d", errUnsupportedChannelSetup, header.Channels, header.ChannelMap)
+}
+
+// CRC32 returns the checksum of a PSI section, the CRC-32 of ISO/IEC 13818-1
+// Annex A.
+func CRC32(data []byte) uint32 {
+	crc := uint32(0xFFFFFFFF)
+	for _, b := range data {
+		crc ^= uint32(b) << 24
+		for range 8 {
+			if crc&0x80000000 != 0 {
+				crc = crc<<1 ^ 0x04C11DB7
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+
+	return crc
+}
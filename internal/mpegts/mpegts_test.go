@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package mpegts
+
+import (
+	"testing"
+
+	"github.com/pion/opus/pkg/oggreader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestControlHeader(t *testing.T) {
+	header := AppendControlHeader(nil, 300, 312, 0)
+	assert.Equal(t, []byte{0x7F, 0xF0, 0xFF, 45, 0x01, 0x38}, header)
+	parsed, n, err := ParseControlHeader(append(header, 0xf8))
+	require.NoError(t, err)
+	assert.Equal(t, ControlHeader{Size: 300, StartTrim: 312}, parsed)
+	assert.Equal(t, len(header), n)
+
+	header = AppendControlHeader(nil, 255, 0, 0x2000)
+	assert.Equal(t, []byte{0x7F, 0xE8, 0xFF, 0x00, 0x1F, 0xFF}, header, "255 bytes and a capped end trim")
+
+	// An extension is skipped, and reserved trim bits ignored.
+	parsed, n, err = ParseControlHeader([]byte{0x7F, 0xEC, 3, 0xE0, 0x10, 2, 0xAA, 0xBB, 0xf8})
+	require.NoError(t, err)
+	assert.Equal(t, ControlHeader{Size: 3, EndTrim: 0x10}, parsed)
+	assert.Equal(t, 8, n)
+
+	_, _, err = ParseControlHeader([]byte{0x7F, 0x00, 3})
+	assert.ErrorIs(t, err, errNotControlHeader)
+	for _, truncated := range [][]byte{{0x7F, 0xE0, 0xFF}, {0x7F, 0xF0, 3, 0}, {0x7F, 0xE4, 3, 2, 0}} {
+		_, _, err = ParseControlHeader(truncated)
+		assert.ErrorIs(t, err, errTruncatedControlHeader)
+	}
+}
+
+func TestChannelConfig(t *testing.T) {
+	for code := range byte(maxChannelConfig + 1) {
+		channels, family, mapping, err := ChannelConfig(code)
+		require.NoError(t, err)
+		back, err := ChannelConfigCode(oggreader.OggHeader{Channels: channels, ChannelMap: family}, mapping)
+		require.NoError(t, err)
+		assert.Equal(t, code, back)
+	}
+
+	channels, family, mapping, err := ChannelConfig(6)
+	require.NoError(t, err)
+	assert.Equal(t, uint8(6), channels)
+	assert.Equal(t, uint8(1), family)
+	assert.Equal(t, []uint8{0, 4, 1, 2, 3, 5}, mapping.Mapping)
+
+	_, _, _, err = ChannelConfig(0x81)
+	assert.ErrorIs(t, err, errUnsupportedChannelCode)
+	_, err = ChannelConfigCode(oggreader.OggHeader{Channels: 3, ChannelMap: 1},
+		oggreader.OggChannelMapping{StreamCount: 3, Mapping: []uint8{0, 1, 2}})
+	assert.ErrorIs(t, err, errUnsupportedChannelSetup)
+}
+
+func TestCRC32(t *testing.T) {
+	assert.Equal(t, uint32(0x0376E6E7), CRC32([]byte("123456789")))
+}
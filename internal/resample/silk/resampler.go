@@ -29,11 +29,13 @@ const (
 )
 
 var (
-	errInvalidInputSampleRate  = errors.New("input sample rate must be 8000, 12000, or 16000")
-	errInvalidOutputSampleRate = errors.New("output sample rate must be 8000, 12000, 16000, 24000, or 48000")
-	errInvalidInputLength      = errors.New("input length must be at least 1 ms")
-	errNonIntegralInputLength  = errors.New("input length must align to an integer output length")
-	errOutBufferTooSmall       = errors.New("out buffer too small")
+	errInvalidInputSampleRate        = errors.New("input sample rate must be 8000, 12000, or 16000")
+	errInvalidOutputSampleRate       = errors.New("output sample rate must be 8000, 12000, 16000, 24000, or 48000")
+	errInvalidEncoderInputSampleRate = errors.New("input sample rate must be 8000, 12000, 16000, 24000, or 48000")
+	errInvalidEncoderOutputRate      = errors.New("output sample rate must be 8000, 12000, or 16000")
+	errInvalidInputLength            = errors.New("input length must be at least 1 ms")
+	errNonIntegralInputLength        = errors.New("input length must align to an integer output length")
+	errOutBufferTooSmall             = errors.New("out buffer too small")
 )
 
 /*
@@ -44,6 +46,8 @@ var (
  *               8        C      UF     U      UF     UF
  *              12        AF     C      UF     U      UF
  * Fs_in (kHz)  16        D      AF     C      UF     UF
+ *              24        AF     D      AF     C      U
+ *              48        AF     AF     AF     D      C
  *
  * C   -> Copy (no resampling)
  * D   -> Allpass-based 2x downsampling
@@ -57,8 +61,20 @@ var delayMatrixDec = [3][5]int{ //nolint:gochecknoglobals
 	{0, 3, 12, 7, 7},
 }
 
+// delayMatrixEnc holds the encoder-side input delays, indexed by input and
+// then output rate. Together with delayMatrixDec they equalize the total
+// delay of the SILK path across rates.
+var delayMatrixEnc = [5][3]int{ //nolint:gochecknoglobals
+	{6, 0, 3},
+	{0, 7, 3},
+	{0, 1, 10},
+	{0, 2, 6},
+	{18, 10, 12},
+}
+
 // Resampler converts one SILK decoder channel from 8/12/16 kHz to
-// 8/12/16/24/48 kHz.
+// 8/12/16/24/48 kHz, or, set up with InitEncoder, one SILK encoder input
+// channel from 8/12/16/24/48 kHz to 8/12/16 kHz.
 type Resampler struct {
 	sIIR              [maxIIROrder]int32
 	sFIR              [maxFIROrder]int32
@@ -80,9 +96,44 @@ type Resampler struct {
 }
 
 // Init initializes the resampler state for one decoder channel.
-//
-//nolint:cyclop
 func (r *Resampler) Init(inputSampleRate, outputSampleRate int) error {
+	inputRateID, err := inputRateID(inputSampleRate)
+	if err != nil {
+		return err
+	}
+	outputRateID, err := outputRateID(outputSampleRate)
+	if err != nil {
+		return err
+	}
+
+	return r.init(inputSampleRate, outputSampleRate, delayMatrixDec[inputRateID][outputRateID])
+}
+
+// InitEncoder initializes the resampler state for one encoder input channel,
+// which converts the caller's PCM to the SILK internal rate.
+func (r *Resampler) InitEncoder(inputSampleRate, outputSampleRate int) error {
+	// The encoder's input rates are the decoder's output rates and the other
+	// way around.
+	inputID, err := outputRateID(inputSampleRate)
+	if err != nil {
+		return errInvalidEncoderInputSampleRate
+	}
+	outputID, err := inputRateID(outputSampleRate)
+	if err != nil {
+		return errInvalidEncoderOutputRate
+	}
+
+	return r.init(inputSampleRate, outputSampleRate, delayMatrixEnc[inputID][outputID])
+}
+
+// InputDelay returns the delay, in input samples, the resampler adds ahead of
+// its filters to line the SILK path up across rates.
+func (r *Resampler) InputDelay() int {
+	return r.inputDelay
+}
+
+//nolint:cyclop
+func (r *Resampler) init(inputSampleRate, outputSampleRate, inputDelay int) error {
 	in16 := r.in16[:0]
 	out16 := r.out16[:0]
 	iirFIRBuf := r.iirFIRBuf[:0]
@@ -94,16 +145,7 @@ func (r *Resampler) Init(inputSampleRate, outputSampleRate int) error {
 		downFIRBuf: downFIRBuf,
 	}
 
-	inputRateID, err := inputRateID(inputSampleRate)
-	if err != nil {
-		return err
-	}
-	outputRateID, err := outputRateID(outputSampleRate)
-	if err != nil {
-		return err
-	}
-
-	r.inputDelay = delayMatrixDec[inputRateID][outputRateID]
+	r.inputDelay = inputDelay
 	r.fsInKHz = inputSampleRate / 1000
 	r.fsOutKHz = outputSampleRate / 1000
 	r.batchSize = r.fsInKHz * maxBatchSizeMS
@@ -359,3 +359,69 @@ func compareSignalsWithBestLag(input, output []float32, maxLag int) (int, float6
 
 	return bestLag, bestMaxAbs, bestRMSE
 }
+
+func TestInitEncoder(t *testing.T) {
+	for _, test := range []struct {
+		inputSampleRate  int
+		outputSampleRate int
+		inputDelay       int
+	}{
+		{inputSampleRate: 8000, outputSampleRate: 8000, inputDelay: 6},
+		{inputSampleRate: 16000, outputSampleRate: 8000, inputDelay: 0},
+		{inputSampleRate: 16000, outputSampleRate: 16000, inputDelay: 10},
+		{inputSampleRate: 24000, outputSampleRate: 12000, inputDelay: 2},
+		{inputSampleRate: 24000, outputSampleRate: 16000, inputDelay: 6},
+		{inputSampleRate: 48000, outputSampleRate: 8000, inputDelay: 18},
+		{inputSampleRate: 48000, outputSampleRate: 12000, inputDelay: 10},
+		{inputSampleRate: 48000, outputSampleRate: 16000, inputDelay: 12},
+	} {
+		var resampler Resampler
+		assert.NoError(t, resampler.InitEncoder(test.inputSampleRate, test.outputSampleRate))
+		assert.Equal(t, test.inputDelay, resampler.InputDelay())
+	}
+
+	var resampler Resampler
+	assert.ErrorIs(t, resampler.InitEncoder(44100, 16000), errInvalidEncoderInputSampleRate)
+	assert.ErrorIs(t, resampler.InitEncoder(48000, 24000), errInvalidEncoderOutputRate)
+}
+
+// TestEncoderDownsampling checks the encoder-side paths from 48 and 24 kHz
+// keep the speech band and reject what would alias.
+func TestEncoderDownsampling(t *testing.T) {
+	for _, test := range []struct {
+		inputSampleRate  int
+		outputSampleRate int
+	}{
+		{inputSampleRate: 48000, outputSampleRate: 8000},
+		{inputSampleRate: 48000, outputSampleRate: 12000},
+		{inputSampleRate: 48000, outputSampleRate: 16000},
+		{inputSampleRate: 24000, outputSampleRate: 8000},
+		{inputSampleRate: 24000, outputSampleRate: 12000},
+		{inputSampleRate: 24000, outputSampleRate: 16000},
+	} {
+		t.Run(fmt.Sprintf("%d_to_%d", test.inputSampleRate, test.outputSampleRate), func(t *testing.T) {
+			in := makeTestSignal(test.inputSampleRate)
+			var resampler Resampler
+			assert.NoError(t, resampler.InitEncoder(test.inputSampleRate, test.outputSampleRate))
+			out := make([]float32, len(in)*test.outputSampleRate/test.inputSampleRate)
+			assert.NoError(t, resampler.Resample(in, out))
+
+			want := makeTestSignal(test.outputSampleRate)
+			lag, _, rmse := compareSignalsWithBestLag(want, out, test.outputSampleRate/100)
+			t.Logf("lag=%d rmse=%f", lag, rmse)
+			assert.LessOrEqual(t, rmse, 0.02)
+
+			// A tone just above the output Nyquist frequency is filtered out.
+			alias := make([]float32, test.inputSampleRate)
+			for i := range alias {
+				alias[i] = float32(0.3 * math.Sin(2*math.Pi*0.6*float64(test.outputSampleRate*i)/float64(test.inputSampleRate)))
+			}
+			assert.NoError(t, resampler.Resample(alias, out))
+			var energy float64
+			for _, sample := range out[len(out)/2:] {
+				energy += float64(sample) * float64(sample)
+			}
+			assert.Less(t, math.Sqrt(energy/float64(len(out)/2)), 0.3/math.Sqrt2/30, "at least 30 dB down")
+		})
+	}
+}
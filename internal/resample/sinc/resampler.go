@@ -0,0 +1,279 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+// Package sincresample converts interleaved float32 audio between arbitrary
+// sample rates with a polyphase Kaiser-windowed sinc filter.
+package sincresample
+
+import (
+	"errors"
+	"math"
+)
+
+// Quality selects the length and steepness of the interpolation filter.
+type Quality int
+
+const (
+	// QualityMedium passes up to about 90% of the lower Nyquist frequency and
+	// rejects aliases by about 80 dB. It is the default.
+	QualityMedium Quality = iota
+	// QualityLow passes up to about 80% of the lower Nyquist frequency and
+	// rejects aliases by about 55 dB, at a third of the cost of QualityMedium.
+	QualityLow
+	// QualityHigh passes up to about 93% of the lower Nyquist frequency and
+	// rejects aliases by about 100 dB, at twice the cost of QualityMedium.
+	QualityHigh
+)
+
+const (
+	minSampleRate = 1000
+	maxSampleRate = 384000
+	maxChannels   = 8
+
+	// maxPhases bounds the filter table. Ratios that would need more phases
+	// than this interpolate linearly between adjacent ones instead.
+	maxPhases = 512
+)
+
+var (
+	errInvalidSampleRate   = errors.New("invalid sample rate")
+	errInvalidChannelCount = errors.New("invalid channel count")
+	errInvalidQuality      = errors.New("invalid resampler quality")
+	errInvalidInputLength  = errors.New("input is not a whole number of interleaved samples")
+	errOutBufferTooSmall   = errors.New("out buffer too small")
+	errNotInitialized      = errors.New("resampler is not initialized")
+)
+
+// filterDesign is the filter of one Quality at a ratio of 1. taps is the
+// number of input samples each output sample is computed from, beta the Kaiser
+// window shape and cutoff the -6 dB point as a fraction of the Nyquist
+// frequency, chosen so the stopband starts near Nyquist.
+type filterDesign struct {
+	taps   int
+	beta   float64
+	cutoff float64
+}
+
+var filterDesigns = map[Quality]filterDesign{ //nolint:gochecknoglobals
+	QualityLow:    {taps: 16, beta: 5, cutoff: 0.8},
+	QualityMedium: {taps: 48, beta: 8, cutoff: 0.9},
+	QualityHigh:   {taps: 96, beta: 10, cutoff: 0.935},
+}
+
+// Resampler converts interleaved audio from one sample rate to another. It
+// keeps the tail of each input in its history, so a stream can be fed to it in
+// pieces of any length and comes out the same as if resampled in one go.
+type Resampler struct {
+	channels int
+	// upFactor and downFactor are the output and input rates divided by their
+	// greatest common divisor: each output sample advances the input by
+	// downFactor/upFactor samples.
+	upFactor   int
+	downFactor int
+	taps       int
+	phases     int
+	// table holds phases+1 rows of taps coefficients, row p for a position
+	// p/phases of the way between two input samples.
+	table []float32
+
+	// history holds buffered input frames; the next output's filter window
+	// starts at frame position, phase/upFactor of a sample further on.
+	history  []float32
+	buffered int
+	position int
+	phase    int
+}
+
+// Init sets the resampler up to convert channels interleaved channels from
+// inputSampleRate to outputSampleRate at quality, with empty history.
+func (r *Resampler) Init(inputSampleRate, outputSampleRate, channels int, quality Quality) error {
+	if inputSampleRate < minSampleRate || inputSampleRate > maxSampleRate ||
+		outputSampleRate < minSampleRate || outputSampleRate > maxSampleRate {
+		return errInvalidSampleRate
+	}
+	if channels < 1 || channels > maxChannels {
+		return errInvalidChannelCount
+	}
+	design, ok := filterDesigns[quality]
+	if !ok {
+		return errInvalidQuality
+	}
+
+	divisor := gcd(inputSampleRate, outputSampleRate)
+	r.channels = channels
+	r.upFactor = outputSampleRate / divisor
+	r.downFactor = inputSampleRate / divisor
+
+	// When downsampling the passband shrinks to the output's Nyquist
+	// frequency, and the filter stretches to keep its steepness.
+	scale := min(1, float64(r.upFactor)/float64(r.downFactor))
+	r.taps = int(math.Ceil(float64(design.taps)/scale/2)) * 2
+	r.phases = min(r.upFactor, maxPhases)
+	r.table = makeTable(r.taps, r.phases, design.cutoff*scale, design.beta)
+	r.history = make([]float32, (r.taps+maxSampleRate/100)*channels)
+	r.Reset()
+
+	return nil
+}
+
+// Reset clears the history, as if the resampler had just been initialized.
+func (r *Resampler) Reset() {
+	clear(r.history)
+	// The history starts with a window's worth of silence less one sample,
+	// so output starts at once and OutputSamples is exact from the start.
+	r.buffered = r.taps - 1
+	r.position = 0
+	r.phase = 0
+}
+
+// Delay returns the delay the filter adds, in output samples: the output
+// lags the input by half a filter window.
+func (r *Resampler) Delay() float64 {
+	if r.downFactor == 0 {
+		return 0
+	}
+
+	return float64(r.taps/2) * float64(r.upFactor) / float64(r.downFactor)
+}
+
+// OutputSamples returns how many samples per channel the next call to
+// Resample produces from inSamples samples per channel. Over a stream it adds
+// up to the input length times the rate ratio, rounded up, so input that
+// converts to a whole number of output samples always produces exactly that.
+func (r *Resampler) OutputSamples(inSamples int) int {
+	if r.upFactor == 0 {
+		return 0
+	}
+	// Output k is produced once its window, starting position+
+	// floor((phase+k*downFactor)/upFactor) frames in, fits in the history.
+	last := r.buffered + inSamples - r.taps - r.position
+	if last < 0 {
+		return 0
+	}
+	span := int64(last+1)*int64(r.upFactor) - int64(r.phase)
+
+	return int((span + int64(r.downFactor) - 1) / int64(r.downFactor))
+}
+
+// Resample converts in, interleaved, into out and returns the samples per
+// channel written. out must hold OutputSamples of them.
+func (r *Resampler) Resample(in, out []float32) (int, error) {
+	if r.upFactor == 0 {
+		return 0, errNotInitialized
+	}
+	if len(in)%r.channels != 0 {
+		return 0, errInvalidInputLength
+	}
+	inSamples := len(in) / r.channels
+	if len(out) < r.OutputSamples(inSamples)*r.channels {
+		return 0, errOutBufferTooSmall
+	}
+
+	written := 0
+	for len(in) > 0 {
+		// Feed the input through the history in pieces that fit.
+		room := len(r.history)/r.channels - r.buffered
+		chunk := min(room, len(in)/r.channels)
+		copy(r.history[r.buffered*r.channels:], in[:chunk*r.channels])
+		r.buffered += chunk
+		in = in[chunk*r.channels:]
+		written += r.filter(out[written*r.channels:])
+		r.discardConsumed()
+	}
+
+	return written, nil
+}
+
+// filter computes every output sample whose window is in the history.
+func (r *Resampler) filter(out []float32) int {
+	written := 0
+	for r.position+r.taps <= r.buffered {
+		row := r.phase * r.phases / r.upFactor
+		weight := float32(r.phase*r.phases%r.upFactor) / float32(r.upFactor)
+		coefficients := r.table[row*r.taps : (row+1)*r.taps]
+		next := r.table[(row+1)*r.taps : (row+2)*r.taps]
+		window := r.history[r.position*r.channels : (r.position+r.taps)*r.channels]
+		for channel := range r.channels {
+			var sum, nextSum float32
+			for tap, coefficient := range coefficients {
+				sample := window[tap*r.channels+channel]
+				sum += coefficient * sample
+				nextSum += next[tap] * sample
+			}
+			// weight is 0 whenever the table has a row per phase.
+			out[written*r.channels+channel] = sum + weight*(nextSum-sum)
+		}
+		written++
+
+		r.phase += r.downFactor
+		r.position += r.phase / r.upFactor
+		r.phase %= r.upFactor
+	}
+
+	return written
+}
+
+// discardConsumed drops the frames before the next output's window.
+func (r *Resampler) discardConsumed() {
+	consumed := min(r.position, r.buffered)
+	copy(r.history, r.history[consumed*r.channels:r.buffered*r.channels])
+	r.buffered -= consumed
+	r.position -= consumed
+}
+
+// makeTable computes the polyphase filter: row p holds the taps of a windowed
+// sinc lowpass with its -6 dB point at cutoff times Nyquist, centred p/phases
+// of a sample past tap taps/2-1. Each row is normalized to unity gain at DC.
+func makeTable(taps, phases int, cutoff, beta float64) []float32 {
+	table := make([]float32, (phases+1)*taps)
+	half := float64(taps / 2)
+	kaiserScale := 1 / besselI0(beta)
+	for row := 0; row <= phases; row++ {
+		offset := float64(row) / float64(phases)
+		coefficients := make([]float64, taps)
+		var sum float64
+		for tap := range taps {
+			t := float64(tap) - (half - 1) - offset
+			ratio := t / half
+			if math.Abs(ratio) >= 1 {
+				continue
+			}
+			window := besselI0(beta*math.Sqrt(1-ratio*ratio)) * kaiserScale
+			coefficients[tap] = cutoff * sinc(cutoff*t) * window
+			sum += coefficients[tap]
+		}
+		for tap, coefficient := range coefficients {
+			table[row*taps+tap] = float32(coefficient / sum)
+		}
+	}
+
+	return table
+}
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
+
+// besselI0 is the zeroth-order modified Bessel function of the first kind,
+// summed from its power series.
+func besselI0(x float64) float64 {
+	sum, term := 1.0, 1.0
+	for k := 1; term > sum*1e-12; k++ {
+		term *= (x / (2 * float64(k))) * (x / (2 * float64(k)))
+		sum += term
+	}
+
+	return sum
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+
+	return a
+}
@@ -0,0 +1,167 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package sincresample
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sine(frequency float64, sampleRate, samples, channels int) []float32 {
+	out := make([]float32, samples*channels)
+	for i := range samples {
+		value := float32(0.5 * math.Sin(2*math.Pi*frequency*float64(i)/float64(sampleRate)))
+		for channel := range channels {
+			out[i*channels+channel] = value / float32(channel+1)
+		}
+	}
+
+	return out
+}
+
+// sineError returns the RMS difference between channel 0 of out and the sine
+// it should hold, delayed by delay samples, skipping the filter's start-up.
+func sineError(out []float32, frequency float64, sampleRate, channels int, delay float64) float64 {
+	var sum float64
+	count := 0
+	for i := int(2*delay) + 1; i < len(out)/channels; i++ {
+		want := 0.5 * math.Sin(2*math.Pi*frequency*(float64(i)-delay)/float64(sampleRate))
+		diff := float64(out[i*channels]) - want
+		sum += diff * diff
+		count++
+	}
+
+	return math.Sqrt(sum / float64(count))
+}
+
+func TestResampleSine(t *testing.T) {
+	for _, test := range []struct {
+		inputRate, outputRate int
+		quality               Quality
+		maxError              float64
+	}{
+		{48000, 44100, QualityLow, 2e-3},
+		{48000, 44100, QualityMedium, 1e-4},
+		{48000, 44100, QualityHigh, 1e-5},
+		{48000, 96000, QualityMedium, 1e-4},
+		{48000, 32000, QualityMedium, 1e-4},
+		{16000, 44100, QualityMedium, 1e-4},
+		{48000, 44101, QualityMedium, 1e-4},
+	} {
+		t.Run(fmt.Sprintf("%d-%d-%d", test.inputRate, test.outputRate, test.quality), func(t *testing.T) {
+			var resampler Resampler
+			require.NoError(t, resampler.Init(test.inputRate, test.outputRate, 1, test.quality))
+
+			in := sine(1000, test.inputRate, test.inputRate/10, 1)
+			out := make([]float32, resampler.OutputSamples(len(in)))
+			n, err := resampler.Resample(in, out)
+			require.NoError(t, err)
+			assert.Len(t, out, n)
+			assert.Equal(t, int(math.Ceil(float64(len(in))*float64(test.outputRate)/float64(test.inputRate))), n)
+			assert.Less(t, sineError(out, 1000, test.outputRate, 1, resampler.Delay()), test.maxError)
+		})
+	}
+}
+
+func TestResampleRejectsAliases(t *testing.T) {
+	var resampler Resampler
+	require.NoError(t, resampler.Init(48000, 32000, 1, QualityMedium))
+
+	// 20 kHz is above the 16 kHz output Nyquist frequency and would alias to
+	// 12 kHz.
+	in := sine(20000, 48000, 4800, 1)
+	out := make([]float32, resampler.OutputSamples(len(in)))
+	n, err := resampler.Resample(in, out)
+	require.NoError(t, err)
+
+	var energy float64
+	for _, sample := range out[n/2 : n] {
+		energy += float64(sample) * float64(sample)
+	}
+	rms := math.Sqrt(energy / float64(n-n/2))
+	assert.Less(t, 20*math.Log10(rms/(0.5/math.Sqrt2)), -70.0)
+}
+
+func TestResampleStreamingMatchesOneShot(t *testing.T) {
+	var oneShot, streaming Resampler
+	require.NoError(t, oneShot.Init(48000, 44100, 2, QualityMedium))
+	require.NoError(t, streaming.Init(48000, 44100, 2, QualityMedium))
+
+	in := sine(440, 48000, 4800, 2)
+	want := make([]float32, 2*oneShot.OutputSamples(4800))
+	n, err := oneShot.Resample(in, want)
+	require.NoError(t, err)
+
+	got := make([]float32, 0, len(want))
+	for offset, chunk := 0, 1; offset < 4800; offset, chunk = offset+chunk, chunk*3%1001+1 {
+		chunk = min(chunk, 4800-offset)
+		expected := streaming.OutputSamples(chunk)
+		out := make([]float32, 2*expected)
+		written, err := streaming.Resample(in[2*offset:2*(offset+chunk)], out)
+		require.NoError(t, err)
+		assert.Equal(t, expected, written)
+		got = append(got, out[:2*written]...)
+	}
+	assert.Equal(t, want[:2*n], got)
+
+	// The channels stay independent.
+	for i := range n {
+		assert.InDelta(t, want[2*i]/2, want[2*i+1], 1e-6)
+	}
+}
+
+func TestResampleWholeOutputPerTenMilliseconds(t *testing.T) {
+	var resampler Resampler
+	require.NoError(t, resampler.Init(48000, 44100, 1, QualityMedium))
+
+	// Odd lengths leave the filter between samples, yet 10 ms of input still
+	// yields exactly 10 ms of output.
+	_, err := resampler.Resample(make([]float32, 120), make([]float32, 111))
+	require.NoError(t, err)
+	for range 5 {
+		assert.Equal(t, 441, resampler.OutputSamples(480))
+		_, err = resampler.Resample(make([]float32, 480), make([]float32, 441))
+		require.NoError(t, err)
+	}
+}
+
+func TestResampleReset(t *testing.T) {
+	var resampler Resampler
+	require.NoError(t, resampler.Init(48000, 44100, 1, QualityHigh))
+
+	in := sine(440, 48000, 960, 1)
+	first := make([]float32, resampler.OutputSamples(960))
+	_, err := resampler.Resample(in, first)
+	require.NoError(t, err)
+
+	resampler.Reset()
+	second := make([]float32, resampler.OutputSamples(960))
+	_, err = resampler.Resample(in, second)
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+	assert.InDelta(t, float64(resampler.taps/2)*44100/48000, resampler.Delay(), 1e-9)
+}
+
+func TestResampleValidation(t *testing.T) {
+	var resampler Resampler
+	_, err := resampler.Resample(nil, nil)
+	assert.ErrorIs(t, err, errNotInitialized)
+	assert.Zero(t, resampler.OutputSamples(100))
+	assert.Zero(t, resampler.Delay())
+
+	assert.ErrorIs(t, resampler.Init(0, 48000, 1, QualityMedium), errInvalidSampleRate)
+	assert.ErrorIs(t, resampler.Init(48000, 1000000, 1, QualityMedium), errInvalidSampleRate)
+	assert.ErrorIs(t, resampler.Init(48000, 44100, 0, QualityMedium), errInvalidChannelCount)
+	assert.ErrorIs(t, resampler.Init(48000, 44100, 1, Quality(7)), errInvalidQuality)
+
+	require.NoError(t, resampler.Init(48000, 44100, 2, QualityMedium))
+	_, err = resampler.Resample(make([]float32, 3), make([]float32, 100))
+	assert.ErrorIs(t, err, errInvalidInputLength)
+	_, err = resampler.Resample(make([]float32, 960), make([]float32, 10))
+	assert.ErrorIs(t, err, errOutBufferTooSmall)
+}
@@ -7,8 +7,8 @@ import (
 	"math"
 	"slices"
 
-	"github.com/pion/opus/internal/rangecoding"
 	"github.com/pion/opus/internal/slicetools"
+	"github.com/pion/opus/pkg/rangecoding"
 )
 
 // Decoder maintains the state needed to decode a stream
@@ -83,6 +83,14 @@ type Decoder struct {
 	plcLossCount          int
 	plcRandSeed           uint32
 	plcConcealedEnergy    float64
+
+	// reports collects a FrameReport per decoded frame, and frameReport is
+	// the one decodeFrame fills; see SetReport.
+	reports     *[]FrameReport
+	frameReport *FrameReport
+
+	// depth is how far decodeFrame goes; see DecodeFeatures.
+	depth decodeDepth
 }
 
 // NewDecoder creates a new Silk Decoder.
@@ -2057,12 +2065,18 @@ func (d *Decoder) decodeFrame(
 ) error {
 	d.resetPredictionForBandwidthChange(bandwidth)
 
+	startBits := d.rangeDecoder.TellFrac()
 	subframeCount := subframeCount(nanoseconds)
 
 	signalType, quantizationOffsetType := d.determineFrameType(voiceActivityDetected)
 
 	// https://datatracker.ietf.org/doc/html/rfc6716#section-4.2.7.4
 	gainQ16 := d.decodeSubframeQuantizations(signalType, subframeCount, isFirstSilkFrameInOpusFrame)
+	if d.depth == decodeDepthGains {
+		d.haveDecoded = true
+
+		return nil
+	}
 
 	// https://datatracker.ietf.org/doc/html/rfc6716#section-4.2.7.5.1
 	I1 := d.normalizeLineSpectralFrequencyStageOne(signalType == frameSignalTypeVoiced, bandwidth)
@@ -2114,6 +2128,19 @@ func (d *Decoder) decodeFrame(
 	// https://www.rfc-editor.org/rfc/rfc6716.html#section-4.2.7.8.6
 	eQ23 := d.decodeExcitation(signalType, quantizationOffsetType, lcgSeed, pulsecounts, lsbcounts)
 
+	if d.frameReport != nil {
+		d.frameReport.record(
+			voiceActivityDetected, signalType, quantizationOffsetType,
+			gainQ16, nlsfQ15, wQ2, pitchLags, bQ7, ltpScaleQ14,
+			d.rangeDecoder.TellFrac()-startBits,
+		)
+	}
+	if d.depth == decodeDepthParameters {
+		d.haveDecoded = true
+
+		return nil
+	}
+
 	// https://www.rfc-editor.org/rfc/rfc6716.html#section-4.2.7.9
 	d.silkFrameReconstruction(
 		signalType, bandwidth,
@@ -2277,14 +2304,17 @@ func (d *Decoder) decodeMono(
 ) error {
 	for i := range voiceActivityDetected {
 		frameOut := out[i*frameSampleCount : (i+1)*frameSampleCount]
-		if err := d.decodeFrame(
+		d.beginReport(d, false, d.midLBRRFlags[i])
+		err := d.decodeFrame(
 			frameOut,
 			voiceActivityDetected[i],
 			silkFrameNanoseconds,
 			bandwidth,
 			i == 0,
 			false,
-		); err != nil {
+		)
+		d.endReport(d)
+		if err != nil {
 			return err
 		}
 	}
@@ -2338,6 +2368,21 @@ func (d *Decoder) finishStereoOutput(out []float32, frameSampleCount int, frameC
 	d.wasStereo = true
 }
 
+// prepareStereo readies the side channel for a stereo frame. RFC 6716 Section
+// 4.2.7.1 resets previous stereo weights on transitions from mono to stereo.
+func (d *Decoder) prepareStereo() {
+	if d.sideDecoder == nil {
+		d.sideDecoder = newChannelDecoder()
+	}
+	if !d.wasStereo {
+		d.previousStereoWeights = [2]int32{}
+		d.previousSideValue = 0
+		d.sideDecoder = newChannelDecoder()
+		d.resetSideDecoderPrediction()
+		d.previousDecodeOnlyMid = false
+	}
+}
+
 // https://datatracker.ietf.org/doc/html/rfc6716#section-4.2.7.1
 // https://datatracker.ietf.org/doc/html/rfc6716#section-4.2.7.2
 // https://datatracker.ietf.org/doc/html/rfc6716#section-4.2.8
@@ -2350,19 +2395,7 @@ func (d *Decoder) decodeStereo(
 	bandwidth Bandwidth,
 	outputStereo bool,
 ) error {
-	if d.sideDecoder == nil {
-		d.sideDecoder = newChannelDecoder()
-	}
-	// RFC 6716 Section 4.2.7.1 resets previous stereo weights on transitions
-	// from mono to stereo.
-	if !d.wasStereo {
-		d.previousStereoWeights = [2]int32{}
-		d.previousSideValue = 0
-		d.sideDecoder = newChannelDecoder()
-		d.resetSideDecoderPrediction()
-		d.previousDecodeOnlyMid = false
-	}
-
+	d.prepareStereo()
 	mid, side := d.stereoScratchBuffers(frameSampleCount)
 
 	isFirstSideFrame := true
@@ -2376,27 +2409,36 @@ func (d *Decoder) decodeStereo(
 			d.resetSideDecoderPrediction()
 		}
 
-		if err := d.decodeFrame(
+		if report := d.beginReport(d, false, d.midLBRRFlags[i]); report != nil {
+			report.StereoWeightsQ13 = [2]int32{w0Q13, w1Q13}
+			report.MidOnly = midOnly
+		}
+		err := d.decodeFrame(
 			mid,
 			midVoiceActivityDetected[i],
 			silkFrameNanoseconds,
 			bandwidth,
 			i == 0,
 			false,
-		); err != nil {
+		)
+		d.endReport(d)
+		if err != nil {
 			return err
 		}
 
 		if !midOnly {
 			d.sideDecoder.rangeDecoder = d.rangeDecoder
-			if err := d.sideDecoder.decodeFrame(
+			d.beginReport(d.sideDecoder, true, d.sideLBRRFlags[i])
+			err := d.sideDecoder.decodeFrame(
 				side,
 				sideVoiceActivityDetected[i],
 				silkFrameNanoseconds,
 				bandwidth,
 				isFirstSideFrame,
 				d.previousDecodeOnlyMid,
-			); err != nil {
+			)
+			d.endReport(d.sideDecoder)
+			if err != nil {
 				return err
 			}
 			d.rangeDecoder = d.sideDecoder.rangeDecoder
@@ -2434,6 +2476,10 @@ func (d *Decoder) consumeLowBitrateRedundancy(
 
 	discard := NewDecoder()
 	discard.rangeDecoder = d.rangeDecoder
+	if d.depth != decodeDepthSynthesis {
+		discard.depth = decodeDepthParameters
+		discard.sideDecoder.depth = decodeDepthParameters
+	}
 	frameSampleCount := discard.samplesInSubframe(bandwidth) * subframeCount(silkFrameNanoseconds)
 	midScratch := make([]float32, frameSampleCount)
 	sideScratch := make([]float32, frameSampleCount)
@@ -2645,3 +2691,21 @@ func (d *Decoder) decodeWithInitializedRange(
 		outputStereo,
 	)
 }
+
+// VoiceActivity reports whether the VAD flag was set on any SILK frame of the
+// mid channel of the packet decoded last.
+func (d *Decoder) VoiceActivity() bool {
+	return slices.Contains(d.midVoiceActivity, true)
+}
+
+// PitchLag returns the pitch lag, in samples at the internal rate, of the last
+// subframe the decoder produced, and whether that frame was voiced. For a
+// stereo stream it describes the mid channel. The lag means nothing when the
+// frame was not voiced.
+func (d *Decoder) PitchLag() (lag int, voiced bool) {
+	if !d.isPreviousFrameVoiced || len(d.pitchLags) == 0 {
+		return 0, false
+	}
+
+	return d.pitchLags[len(d.pitchLags)-1], true
+}
@@ -6,8 +6,9 @@ package silk
 import (
 	"testing"
 
-	"github.com/pion/opus/internal/rangecoding"
+	"github.com/pion/opus/pkg/rangecoding"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const floatEqualityThreshold = 0.000001
@@ -33,7 +34,7 @@ func createRangeDecoder(
 	highAndCodedDifference uint32,
 ) rangecoding.Decoder {
 	d := rangecoding.Decoder{}
-	d.SetInternalValues(data, bitsRead, rangeSize, highAndCodedDifference)
+	d.SetState(data, bitsRead, rangeSize, highAndCodedDifference)
 
 	return d
 }
@@ -933,3 +934,19 @@ func TestDecode(t *testing.T) {
 		compareBuffer(t, out, expectedOut)
 	})
 }
+
+func TestPitchLag(t *testing.T) {
+	decoder := NewDecoder()
+	_, voiced := decoder.PitchLag()
+	assert.False(t, voiced)
+
+	// encodeVoicedFrames produces a tone with a 100-sample period at 16 kHz.
+	enc := NewEncoder()
+	out := make([]float32, 320)
+	for _, frame := range encodeVoicedFrames(t, &enc, BandwidthWideband, 3) {
+		require.NoError(t, decoder.Decode(frame, out, false, nanoseconds20Ms, BandwidthWideband))
+	}
+	lag, voiced := decoder.PitchLag()
+	assert.True(t, voiced)
+	assert.InDelta(t, 100, lag, 3)
+}
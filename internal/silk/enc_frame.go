@@ -60,6 +60,10 @@ func (e *Encoder) encodeSILKFrame(input []int16, bandwidth Bandwidth) {
 	frameLength := subfrCount * subfrLength
 	ltpMemLength := 20 * fsKHz
 
+	// The previous frame's LBRR copy goes out with this frame.
+	previousLBRR := e.lbrr
+	e.lbrr = nil
+
 	// Voice activity.
 	saQ8, tiltQ15, quality := e.vad.getSpeechActivityQ8(input, frameLength, fsKHz)
 	active := saQ8 > silkVADThreshold
@@ -125,7 +129,7 @@ func (e *Encoder) encodeSILKFrame(input []int16, bandwidth Bandwidth) {
 		findLTPFLP(xxLTP, xXLTP, res, ltpMemLength, pitchL, subfrLength, subfrCount)
 		ltpCoefQ14, filterIndices, periodicityIndex, predGainDB = e.quantLTPGains(xxLTP, xXLTP, subfrLength, subfrCount)
 		copy(nsqPitchL, pitchL)
-		ltpScaleIndex, ltpScaleQ14 = ltpScaleControl(predGainDB, snrDBQ7, e.packetLossPerc, 1, false)
+		ltpScaleIndex, ltpScaleQ14 = ltpScaleControl(predGainDB, snrDBQ7, e.packetLossPerc, 1, e.lbrrEnabled)
 
 		ltpCoefFloat := make([]float32, ltpOrder*subfrCount)
 		for i := range ltpCoefFloat {
@@ -178,7 +182,7 @@ func (e *Encoder) encodeSILKFrame(input []int16, bandwidth Bandwidth) {
 	// Noise-shaping quantization.
 	pulses := make([]int8, frameLength)
 	seed := uint32(e.frameCounter & 3) //nolint:gosec // G115
-	e.nsq.quantize(input, pulses, &nsqParams{
+	params := nsqParams{
 		predCoefQ12:      predCoef2,
 		ltpCoefQ14:       ltpCoefQ14,
 		arQ13:            sr.arQ13,
@@ -199,16 +203,41 @@ func (e *Encoder) encodeSILKFrame(input []int16, bandwidth Bandwidth) {
 		nbSubfr:          subfrCount,
 		predictLPCOrder:  order,
 		shapingLPCOrder:  shapeLPCOrderLowComplex,
-	})
+	}
+	if e.lbrrEnabled && signalType != frameSignalTypeInactive && saQ8 > lbrrSpeechActivityThresholdQ8 {
+		e.lbrr = e.encodeLBRRFrame(input, lbrrFrame{
+			signalType:       signalType,
+			quantOffsetType:  quantOffsetType,
+			gainIndices:      gainIndices,
+			nlsfIndex1:       index1,
+			nlsfIndices2:     indices2,
+			nlsfInterpQ2:     nlsfInterpQ2,
+			primaryLag:       int(lagIndex) + peMinLagMS*fsKHz,
+			contourIndex:     uint32(contourIndex),     //nolint:gosec // G115: contour index is non-negative.
+			periodicityIndex: uint32(periodicityIndex), //nolint:gosec // G115: periodicity index is non-negative.
+			filterIndices:    filterIndices,
+			ltpScaleIndex:    uint32(ltpScaleIndex), //nolint:gosec // G115: scale index is 0..2.
+			seed:             seed,
+		}, params)
+	}
+	e.nsq.quantize(input, pulses, &params)
 	e.frameCounter++
 
 	// Emit every field in the order the decoder reads it.
+	e.voiceActivity = active
 	vadBit := uint32(0)
 	if active {
 		vadBit = 1
 	}
+	lbrrBit := uint32(0)
+	if previousLBRR != nil {
+		lbrrBit = 1
+	}
 	e.rangeEncoder.EncodeSymbolLogP(1, vadBit)
-	e.rangeEncoder.EncodeSymbolLogP(1, 0) // LBRR flag (no redundancy)
+	e.rangeEncoder.EncodeSymbolLogP(1, lbrrBit)
+	if previousLBRR != nil {
+		e.emitLBRRFrame(previousLBRR, bandwidth)
+	}
 
 	e.emitFrameType(signalType, quantOffsetType, active)
 	e.emitGainIndices(gainIndices, signalType, false)
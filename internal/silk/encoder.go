@@ -3,7 +3,7 @@
 
 package silk
 
-import "github.com/pion/opus/internal/rangecoding"
+import "github.com/pion/opus/pkg/rangecoding"
 
 // Encoder quantizes and range-encodes a single SILK channel. It is the
 // counterpart to Decoder and is built up one stage at a time.
@@ -34,8 +34,10 @@ type Encoder struct {
 	// interpolation.
 	prevNLSFq []int16
 
-	// Analysis state for the frame encoder.
+	// Analysis state for the frame encoder. voiceActivity is the VAD flag
+	// of the frame encoded last.
 	vad               vadState
+	voiceActivity     bool
 	nsq               *nsqState
 	frameCounter      int
 	targetBitrate     int       // target bitrate in bps (drives control_SNR)
@@ -52,6 +54,15 @@ type Encoder struct {
 	// defaults to false (matching the low-complexity tiers) until the caller
 	// configures it.
 	useInterpolatedNLSFs bool
+
+	// In-band FEC (see lbrr_encode.go): useInbandFEC is the caller's request,
+	// lbrrEnabled and lbrrGainIncrease mirror psEncC->LBRR_enabled and
+	// LBRR_GainIncreases, and lbrr is the redundant copy of the previous
+	// frame that the next packet carries.
+	useInbandFEC     bool
+	lbrrEnabled      bool
+	lbrrGainIncrease int
+	lbrr             *lbrrFrame
 }
 
 // NewEncoder creates a SILK Encoder with its prediction state reset.
@@ -62,6 +73,18 @@ func NewEncoder() Encoder {
 	return e
 }
 
+// VoiceActivity reports whether the frame encoded last was flagged as active
+// speech.
+func (e *Encoder) VoiceActivity() bool {
+	return e.voiceActivity
+}
+
+// FinalRange returns the range coder state after the frame encoded last,
+// which the decoder's FinalRange matches once it has decoded the frame.
+func (e *Encoder) FinalRange() uint32 {
+	return e.rangeEncoder.FinalRange()
+}
+
 // SetUseInterpolatedNLSFs enables or disables the NLSF interpolation search
 // in findLPCNLSF, mirroring libopus's complexity-tier setting
 // (silk_setup_complexity: enabled for encoder complexity >= 4).
@@ -0,0 +1,120 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package silk
+
+import "github.com/pion/opus/pkg/rangecoding"
+
+// decodeDepth selects how much of a SILK frame decodeFrame reads.
+type decodeDepth byte
+
+const (
+	// decodeDepthSynthesis decodes every symbol and synthesizes the audio.
+	decodeDepthSynthesis decodeDepth = iota
+	// decodeDepthParameters decodes every symbol but skips synthesis.
+	decodeDepthParameters
+	// decodeDepthGains stops after the subframe gains.
+	decodeDepthGains
+)
+
+// Features holds what DecodeFeatures reads from a SILK packet.
+type Features struct {
+	// VoiceActivity holds the VAD flag of each SILK frame of the mid channel.
+	VoiceActivity []bool
+	// GainsQ16 holds the subframe gains of the mid channel, over all frames.
+	GainsQ16 []int32
+}
+
+// DecodeFeatures reads the VAD flags and mid channel gains of a SILK packet
+// without synthesizing it. Symbols past the gains are only decoded as far as
+// needed to reach the next frame, or the end of the SILK data when stopEarly
+// is not set, as a hybrid packet's CELT layer follows it.
+//
+// The gains are predicted from the previous frame, so a Decoder used here must
+// see every packet of the stream, and only through DecodeFeatures.
+func (d *Decoder) DecodeFeatures(
+	rangeDecoder *rangecoding.Decoder,
+	isStereo bool,
+	nanoseconds int,
+	bandwidth Bandwidth,
+	stopEarly bool,
+	features *Features,
+) error {
+	frameCount := silkFrameCount(nanoseconds)
+	silkFrameNanoseconds := min(nanoseconds, nanoseconds20Ms)
+	if frameCount == 0 || subframeCount(silkFrameNanoseconds) == 0 {
+		return errUnsupportedSilkFrameDuration
+	}
+	if d.sideDecoder == nil {
+		d.sideDecoder = newChannelDecoder()
+	}
+	d.rangeDecoder = *rangeDecoder
+	defer func() { *rangeDecoder = d.rangeDecoder }()
+
+	midVoiceActivity, midLBRR := d.decodeHeaderBitsInto(&d.midVoiceActivity, frameCount)
+	sideVoiceActivity := d.sideVoiceActivity[:0]
+	sideLBRR := false
+	if isStereo {
+		sideVoiceActivity, sideLBRR = d.decodeHeaderBitsInto(&d.sideVoiceActivity, frameCount)
+	}
+	midLBRRFlags := d.decodeLowBitrateRedundancyFlagsInto(&d.midLBRRFlags, frameCount, midLBRR)
+	var sideLBRRFlags []bool
+	if isStereo {
+		sideLBRRFlags = d.decodeLowBitrateRedundancyFlagsInto(&d.sideLBRRFlags, frameCount, sideLBRR)
+	}
+	d.depth = decodeDepthParameters
+	d.sideDecoder.depth = decodeDepthParameters
+	if err := d.consumeLowBitrateRedundancy(
+		midLBRRFlags,
+		sideLBRRFlags,
+		isStereo,
+		silkFrameNanoseconds,
+		bandwidth,
+	); err != nil {
+		return err
+	}
+
+	features.VoiceActivity = append(features.VoiceActivity[:0], midVoiceActivity...)
+	features.GainsQ16 = features.GainsQ16[:0]
+	isFirstSideFrame := true
+	for i := range frameCount {
+		last := stopEarly && i == frameCount-1
+		midOnly := false
+		if isStereo {
+			_, _ = d.decodeStereoPredictionWeights()
+			midOnly = !sideVoiceActivity[i] && d.decodeMidOnlyFlag()
+		}
+
+		if last {
+			d.depth = decodeDepthGains
+		}
+		if err := d.decodeFrame(nil, midVoiceActivity[i], silkFrameNanoseconds, bandwidth, i == 0, false); err != nil {
+			return err
+		}
+		for _, gain := range d.gainQ16 {
+			features.GainsQ16 = append(features.GainsQ16, int32(gain))
+		}
+
+		if isStereo && !midOnly && !last {
+			if d.previousDecodeOnlyMid {
+				d.resetSideDecoderPrediction()
+			}
+			d.sideDecoder.rangeDecoder = d.rangeDecoder
+			if err := d.sideDecoder.decodeFrame(
+				nil,
+				sideVoiceActivity[i],
+				silkFrameNanoseconds,
+				bandwidth,
+				isFirstSideFrame,
+				d.previousDecodeOnlyMid,
+			); err != nil {
+				return err
+			}
+			d.rangeDecoder = d.sideDecoder.rangeDecoder
+			isFirstSideFrame = false
+		}
+		d.previousDecodeOnlyMid = isStereo && midOnly
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package silk
+
+import "github.com/pion/opus/pkg/rangecoding"
+
+// DecodeFECWithRangeToChannels recovers a lost SILK frame from the LBRR frames
+// (RFC 6716 Section 4.2.4) the following packet carries for it. It decodes
+// the LBRR frames in place of the regular ones, continuing from the decoder's
+// own state as if the lost packet had arrived, and conceals the SILK frames
+// the sender sent no LBRR data for. The regular frames of the packet are not
+// decoded; decode the packet again for them.
+//
+// recovered reports whether any LBRR frame was present. When it is false
+// nothing has been decoded, so the caller can conceal the loss instead.
+func (d *Decoder) DecodeFECWithRangeToChannels(
+	rangeDecoder *rangecoding.Decoder,
+	out []float32,
+	isStereo bool,
+	outputChannelCount int,
+	nanoseconds int,
+	bandwidth Bandwidth,
+) (recovered bool, err error) {
+	if rangeDecoder == nil {
+		return false, errOutBufferTooSmall
+	}
+	frameCount := silkFrameCount(nanoseconds)
+	silkFrameNanoseconds := min(nanoseconds, nanoseconds20Ms)
+	sfCount := subframeCount(silkFrameNanoseconds)
+	subframeSize := d.samplesInSubframe(bandwidth)
+	outputStereo := isStereo && outputChannelCount == 2
+	channelCount := 1
+	if outputStereo {
+		channelCount = 2
+	}
+	switch {
+	case frameCount == 0 || sfCount == 0:
+		return false, errUnsupportedSilkFrameDuration
+	case subframeSize*sfCount*frameCount*channelCount > len(out):
+		return false, errOutBufferTooSmall
+	}
+
+	d.rangeDecoder = *rangeDecoder
+	defer func() { *rangeDecoder = d.rangeDecoder }()
+
+	_, midLowBitRateRedundancy := d.decodeHeaderBitsInto(&d.midVoiceActivity, frameCount)
+	sideLowBitRateRedundancy := false
+	if isStereo {
+		_, sideLowBitRateRedundancy = d.decodeHeaderBitsInto(&d.sideVoiceActivity, frameCount)
+	}
+	if !midLowBitRateRedundancy && !sideLowBitRateRedundancy {
+		return false, nil
+	}
+
+	midFlags := d.decodeLowBitrateRedundancyFlagsInto(&d.midLBRRFlags, frameCount, midLowBitRateRedundancy)
+	frameSampleCount := subframeSize * sfCount
+	if !isStereo {
+		return true, d.decodeMonoFEC(out, midFlags, frameSampleCount, silkFrameNanoseconds, bandwidth)
+	}
+
+	sideFlags := d.decodeLowBitrateRedundancyFlagsInto(&d.sideLBRRFlags, frameCount, sideLowBitRateRedundancy)
+
+	return true, d.decodeStereoFEC(
+		out,
+		midFlags,
+		sideFlags,
+		frameSampleCount,
+		silkFrameNanoseconds,
+		bandwidth,
+		outputStereo,
+	)
+}
+
+func (d *Decoder) decodeMonoFEC(
+	out []float32,
+	flags []bool,
+	frameSampleCount int,
+	silkFrameNanoseconds int,
+	bandwidth Bandwidth,
+) error {
+	for i, coded := range flags {
+		frameOut := out[i*frameSampleCount : (i+1)*frameSampleCount]
+		if !coded {
+			d.concealFrame(frameOut, bandwidth)
+
+			continue
+		}
+		// LBRR frames always use the VAD-active frame type table, and only
+		// one that follows another LBRR frame is coded conditionally.
+		if err := d.decodeFrame(
+			frameOut,
+			true,
+			silkFrameNanoseconds,
+			bandwidth,
+			i == 0 || !flags[i-1],
+			false,
+		); err != nil {
+			return err
+		}
+	}
+	d.delayMono(out[:frameSampleCount*len(flags)])
+
+	return nil
+}
+
+//nolint:cyclop
+func (d *Decoder) decodeStereoFEC(
+	out []float32,
+	midFlags []bool,
+	sideFlags []bool,
+	frameSampleCount int,
+	silkFrameNanoseconds int,
+	bandwidth Bandwidth,
+	outputStereo bool,
+) error {
+	d.prepareStereo()
+	mid, side := d.stereoScratchBuffers(frameSampleCount)
+
+	for i := range midFlags {
+		midCoded, sideCoded := midFlags[i], sideFlags[i]
+		w0Q13, w1Q13 := d.previousStereoWeights[0], d.previousStereoWeights[1]
+		midOnly := d.previousDecodeOnlyMid && !sideCoded
+		if midCoded {
+			w0Q13, w1Q13 = d.decodeStereoPredictionWeights()
+			midOnly = !sideCoded && d.decodeMidOnlyFlag()
+			if err := d.decodeFrame(
+				mid,
+				true,
+				silkFrameNanoseconds,
+				bandwidth,
+				i == 0 || !midFlags[i-1],
+				false,
+			); err != nil {
+				return err
+			}
+		} else {
+			d.concealFrame(mid, bandwidth)
+		}
+
+		switch {
+		case sideCoded:
+			if d.previousDecodeOnlyMid {
+				d.resetSideDecoderPrediction()
+			}
+			d.sideDecoder.rangeDecoder = d.rangeDecoder
+			if err := d.sideDecoder.decodeFrame(
+				side,
+				true,
+				silkFrameNanoseconds,
+				bandwidth,
+				i == 0 || !sideFlags[i-1],
+				false,
+			); err != nil {
+				return err
+			}
+			d.rangeDecoder = d.sideDecoder.rangeDecoder
+		case midOnly:
+			clear(side)
+		default:
+			d.sideDecoder.concealFrame(side, bandwidth)
+		}
+
+		d.writeStereoFrame(out, mid, side, i, frameSampleCount, w0Q13, w1Q13, bandwidth, outputStereo)
+		d.previousDecodeOnlyMid = midOnly
+	}
+	d.finishStereoOutput(out, frameSampleCount, len(midFlags), outputStereo)
+
+	return nil
+}
@@ -0,0 +1,72 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package silk
+
+import (
+	"math"
+	"testing"
+
+	"github.com/pion/opus/pkg/rangecoding"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodeVoicedFrames(t *testing.T, enc *Encoder, bandwidth Bandwidth, count int) [][]byte {
+	t.Helper()
+
+	frameLength := 20 * silkInternalRate(bandwidth)
+	frames := make([][]byte, 0, count)
+	for frame := range count {
+		input := make([]int16, frameLength)
+		for i := range input {
+			phase := 2 * math.Pi * float64(frame*frameLength+i) / 100
+			input[i] = int16(6000*math.Sin(phase) + 2500*math.Sin(2*phase))
+		}
+		frames = append(frames, enc.Encode(input, bandwidth, 24000))
+	}
+
+	return frames
+}
+
+func TestDecodeFECWithRangeToChannels(t *testing.T) {
+	bandwidth := BandwidthWideband
+	frameLength := 20 * silkInternalRate(bandwidth)
+
+	enc := NewEncoder()
+	enc.SetInbandFEC(true)
+	enc.SetPacketLossPercentage(10)
+	frames := encodeVoicedFrames(t, &enc, bandwidth, 4)
+
+	dec := NewDecoder()
+	out := make([]float32, frameLength)
+	for _, frame := range frames[:2] {
+		require.NoError(t, dec.Decode(frame, out, false, nanoseconds20Ms, bandwidth))
+	}
+
+	// Frame 2 is lost: recover it from the LBRR copy in frame 3, then carry on
+	// with frame 3 itself.
+	var rangeDecoder rangecoding.Decoder
+	rangeDecoder.Init(frames[3])
+	recovered, err := dec.DecodeFECWithRangeToChannels(&rangeDecoder, out, false, 1, nanoseconds20Ms, bandwidth)
+	require.NoError(t, err)
+	assert.True(t, recovered)
+
+	var energy float64
+	for _, v := range out {
+		energy += float64(v) * float64(v)
+	}
+	assert.Positive(t, energy, "recovered audio is silent")
+	require.NoError(t, dec.Decode(frames[3], out, false, nanoseconds20Ms, bandwidth))
+
+	// The first frame has nothing before it to protect.
+	rangeDecoder.Init(frames[0])
+	recovered, err = dec.DecodeFECWithRangeToChannels(&rangeDecoder, out, false, 1, nanoseconds20Ms, bandwidth)
+	require.NoError(t, err)
+	assert.False(t, recovered)
+
+	_, err = dec.DecodeFECWithRangeToChannels(&rangeDecoder, out[:10], false, 1, nanoseconds20Ms, bandwidth)
+	require.ErrorIs(t, err, errOutBufferTooSmall)
+	_, err = dec.DecodeFECWithRangeToChannels(&rangeDecoder, out, false, 1, 5000000, bandwidth)
+	require.ErrorIs(t, err, errUnsupportedSilkFrameDuration)
+}
@@ -0,0 +1,145 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package silk
+
+// This file produces the Low Bit-Rate Redundancy (LBRR) frames of RFC 6716
+// Section 4.2.4, following silk/LBRR_encode_FLP.c: each active frame is
+// quantized a second time with coarser gains, and that copy rides along in
+// the next packet so a decoder that lost this one can recover it (in-band
+// FEC).
+
+const (
+	// lbrrSpeechActivityThresholdQ8 is LBRR_SPEECH_ACTIVITY_THRES (0.3) in
+	// Q8; quieter frames are not worth a redundant copy.
+	lbrrSpeechActivityThresholdQ8 = 77
+
+	// lbrrLossGainScaleQ16 is the 0.4 in libopus's
+	// LBRR_GainIncreases = max(7 - 0.4*PacketLoss_perc, 2).
+	lbrrLossGainScaleQ16 = 26214
+	lbrrMaxGainIncrease  = 7
+	lbrrMinGainIncrease  = 2
+)
+
+// lbrrFrame holds the quantization indices and excitation of one redundant
+// frame until the next packet carries it.
+type lbrrFrame struct {
+	signalType       frameSignalType
+	quantOffsetType  frameQuantizationOffsetType
+	gainIndices      []int8
+	nlsfIndex1       int
+	nlsfIndices2     []int8
+	nlsfInterpQ2     int
+	primaryLag       int
+	contourIndex     uint32
+	periodicityIndex uint32
+	filterIndices    []int8
+	ltpScaleIndex    uint32
+	seed             uint32
+	pulses           []int8
+}
+
+// SetPacketLossPercentage sets the expected packet loss (0-100), which drives
+// LTP state scaling and how coarse the LBRR copies are.
+func (e *Encoder) SetPacketLossPercentage(percentage int) {
+	e.packetLossPerc = percentage
+	e.updateLowBitrateRedundancy()
+}
+
+// SetInbandFEC enables LBRR frames. As in libopus they are only produced
+// while the expected packet loss is above zero.
+func (e *Encoder) SetInbandFEC(enabled bool) {
+	e.useInbandFEC = enabled
+	e.updateLowBitrateRedundancy()
+}
+
+// updateLowBitrateRedundancy mirrors silk_setup_LBRR (control_codec.c): the
+// more loss is expected, the closer the LBRR gains stay to the regular ones.
+func (e *Encoder) updateLowBitrateRedundancy() {
+	e.lbrrEnabled = e.useInbandFEC && e.packetLossPerc > 0
+	if !e.lbrrEnabled {
+		e.lbrr = nil
+
+		return
+	}
+	//nolint:gosec // G115: the percentage is 0-100.
+	increase := lbrrMaxGainIncrease - int(smulwb(int32(e.packetLossPerc), lbrrLossGainScaleQ16))
+	e.lbrrGainIncrease = max(increase, lbrrMinGainIncrease)
+}
+
+// encodeLBRRFrame quantizes the current frame again for its LBRR copy. frame
+// carries the regular frame's indices, params its NSQ inputs; the copy only
+// differs in its first gain index, which is raised by lbrrGainIncrease, and
+// in the excitation the NSQ finds with those gains. It runs on a copy of the
+// NSQ state from before the regular frame, as a decoder recovering the frame
+// continues from the frame before it.
+func (e *Encoder) encodeLBRRFrame(input []int16, frame lbrrFrame, params nsqParams) *lbrrFrame {
+	frame.gainIndices = append([]int8(nil), frame.gainIndices...)
+	frame.gainIndices[0] = int8(min(int(frame.gainIndices[0])+e.lbrrGainIncrease, gainNLevels-1)) //nolint:gosec // G115
+
+	previousLogGain := e.previousLogGain
+	params.gainsQ16 = dequantizeGains(frame.gainIndices, &previousLogGain)
+
+	nsq := e.nsq.clone()
+	frame.pulses = make([]int8, params.frameLength)
+	nsq.quantize(input, frame.pulses, &params)
+
+	return &frame
+}
+
+// emitLBRRFrame range-encodes an LBRR frame. LBRR frames are always coded
+// independently and with the VAD-active frame type table (RFC 6716 Section
+// 4.2.7.3), and they leave the regular frame's coding state untouched.
+func (e *Encoder) emitLBRRFrame(frame *lbrrFrame, bandwidth Bandwidth) {
+	voiced := frame.signalType == frameSignalTypeVoiced
+	e.emitFrameType(frame.signalType, frame.quantOffsetType, true)
+	e.emitGainIndices(frame.gainIndices, frame.signalType, false)
+	e.emitNLSFIndices(frame.nlsfIndex1, frame.nlsfIndices2, bandwidth, voiced)
+	e.rangeEncoder.EncodeSymbolWithICDF(icdfNormalizedLSFInterpolationIndex, uint32(frame.nlsfInterpQ2)) //nolint:gosec // G115
+	if voiced {
+		lowPartICDF, lagScale, lagMin, _ := pitchLagCodebooks(bandwidth)
+		e.encodeAbsolutePitchLag(frame.primaryLag, lowPartICDF, lagScale, lagMin)
+		_, lagIcdf := pitchContourCodebooks(bandwidth, nanoseconds20Ms)
+		e.rangeEncoder.EncodeSymbolWithICDF(lagIcdf, frame.contourIndex)
+		e.encodeLTPFilter(frame.periodicityIndex, toUint32(frame.filterIndices))
+		e.encodeLTPScaling(frame.ltpScaleIndex)
+	}
+	e.encodeLCGSeed(frame.seed)
+	e.encodePulses(frame.signalType, frame.quantOffsetType, frame.pulses, len(frame.pulses))
+}
+
+// dequantizeGains is silk_gains_dequant for an independently coded frame: it
+// rebuilds the Q16 gains a decoder derives from indices, starting from
+// previousLogGain.
+func dequantizeGains(indices []int8, previousLogGain *int32) []int32 {
+	gainsQ16 := make([]int32, len(indices))
+	for subframeIndex, index := range indices {
+		if subframeIndex == 0 {
+			*previousLogGain = max(int32(index), *previousLogGain-16)
+		} else {
+			delta := int32(index) + gainMinDelta
+			doubleStepThreshold := 2*gainMaxDelta - gainNLevels + *previousLogGain
+			if delta > doubleStepThreshold {
+				*previousLogGain += (delta << 1) - doubleStepThreshold
+			} else {
+				*previousLogGain += delta
+			}
+		}
+		*previousLogGain = clamp(0, *previousLogGain, gainNLevels-1)
+
+		inLogQ7 := min((gainInvScaleQ16*(*previousLogGain)>>16)+gainOffsetQ7, gainMaxLogQ7)
+		gainsQ16[subframeIndex] = log2lin(inLogQ7)
+	}
+
+	return gainsQ16
+}
+
+// clone returns a deep copy of the NSQ state.
+func (nsq *nsqState) clone() *nsqState {
+	clone := *nsq
+	clone.xq = append([]int16(nil), nsq.xq...)
+	clone.sLTPShpQ14 = append([]int32(nil), nsq.sLTPShpQ14...)
+	clone.sLPCQ14 = append([]int32(nil), nsq.sLPCQ14...)
+
+	return &clone
+}
@@ -0,0 +1,43 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package silk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateLowBitrateRedundancy(t *testing.T) {
+	enc := NewEncoder()
+	enc.SetInbandFEC(true)
+	assert.False(t, enc.lbrrEnabled, "LBRR needs expected loss above zero")
+
+	for loss, want := range map[int]int{1: 7, 5: 6, 10: 4, 20: 2, 100: 2} {
+		enc.SetPacketLossPercentage(loss)
+		assert.True(t, enc.lbrrEnabled)
+		assert.Equal(t, want, enc.lbrrGainIncrease, "loss %d%%", loss)
+	}
+
+	enc.lbrr = &lbrrFrame{}
+	enc.SetInbandFEC(false)
+	assert.False(t, enc.lbrrEnabled)
+	assert.Nil(t, enc.lbrr, "a pending LBRR frame is dropped with FEC")
+}
+
+// TestDequantizeGainsMatchesDecoder checks the encoder rebuilds the gains a
+// decoder derives from independently coded gain indices (RFC 6716 Section
+// 4.2.7.4).
+func TestDequantizeGainsMatchesDecoder(t *testing.T) {
+	previousLogGain := int32(45)
+	gainsQ16 := dequantizeGains([]int8{20, 5, 12, 0}, &previousLogGain)
+
+	// The first index may not fall more than 16 below the previous gain; the
+	// others are deltas of index-4, doubled above the step threshold.
+	for i, logGain := range []int32{29, 30, 38, 34} {
+		inLogQ7 := min((gainInvScaleQ16*logGain>>16)+gainOffsetQ7, gainMaxLogQ7)
+		assert.Equal(t, log2lin(inLogQ7), gainsQ16[i], "subframe %d", i)
+	}
+	assert.Equal(t, int32(34), previousLogGain)
+}
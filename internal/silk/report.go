@@ -0,0 +1,100 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package silk
+
+// Signal types of a SILK frame, RFC 6716 Section 4.2.7.3.
+const (
+	SignalTypeInactive = iota
+	SignalTypeUnvoiced
+	SignalTypeVoiced
+)
+
+// FrameReport holds the parameters coded for one regular SILK frame of one
+// channel, as the decoder read them.
+type FrameReport struct {
+	// Side is set for the side channel of a mid-side stereo frame.
+	Side          bool
+	VoiceActivity bool
+	// LBRR is set when the packet also carries a low-bitrate redundant copy
+	// of this frame.
+	LBRR bool
+
+	SignalType             int
+	QuantizationOffsetHigh bool
+	// GainsQ16 holds one quantization gain per subframe.
+	GainsQ16 []int32
+	NLSFsQ15 []int16
+	// NLSFInterpolationQ2 weighs the previous frame's NLSFs into the first
+	// half of a 20 ms frame; 4 means no interpolation.
+	NLSFInterpolationQ2 int
+	// PitchLags, LTPFiltersQ7 and LTPScaleQ14 are only coded in voiced
+	// frames.
+	PitchLags    []int
+	LTPFiltersQ7 [][5]int8
+	LTPScaleQ14  int
+
+	// StereoWeightsQ13 and MidOnly are coded ahead of the mid channel of a
+	// stereo frame.
+	StereoWeightsQ13 [2]int32
+	MidOnly          bool
+
+	// Bits is how many eighth bits of the range coder the frame took.
+	Bits int
+}
+
+// SetReport makes the decoder append a FrameReport to reports for every
+// regular SILK frame it decodes, mid before side. A nil reports stops it.
+func (d *Decoder) SetReport(reports *[]FrameReport) {
+	d.reports = reports
+}
+
+// beginReport appends a report for the next frame and points decoder, the mid
+// or side channel decoder, at it.
+func (d *Decoder) beginReport(decoder *Decoder, side, lbrr bool) *FrameReport {
+	if d.reports == nil {
+		return nil
+	}
+	*d.reports = append(*d.reports, FrameReport{Side: side, LBRR: lbrr})
+	report := &(*d.reports)[len(*d.reports)-1]
+	decoder.frameReport = report
+
+	return report
+}
+
+// endReport stops decoder from filling a report.
+func (d *Decoder) endReport(decoder *Decoder) {
+	decoder.frameReport = nil
+}
+
+func (r *FrameReport) record(
+	voiceActivityDetected bool,
+	signalType frameSignalType,
+	quantizationOffsetType frameQuantizationOffsetType,
+	gainQ16 []float32,
+	nlsfQ15 []int16,
+	wQ2 int16,
+	pitchLags []int,
+	bQ7 [][]int8,
+	ltpScaleQ14 float32,
+	bits uint,
+) {
+	r.VoiceActivity = voiceActivityDetected
+	r.SignalType = int(signalType - frameSignalTypeInactive)
+	r.QuantizationOffsetHigh = quantizationOffsetType == frameQuantizationOffsetTypeHigh
+	r.GainsQ16 = make([]int32, len(gainQ16))
+	for i, gain := range gainQ16 {
+		r.GainsQ16[i] = int32(gain)
+	}
+	r.NLSFsQ15 = append([]int16(nil), nlsfQ15...)
+	r.NLSFInterpolationQ2 = int(wQ2)
+	if signalType == frameSignalTypeVoiced {
+		r.PitchLags = append([]int(nil), pitchLags...)
+		r.LTPFiltersQ7 = make([][5]int8, len(bQ7))
+		for i := range bQ7 {
+			copy(r.LTPFiltersQ7[i][:], bQ7[i])
+		}
+		r.LTPScaleQ14 = int(ltpScaleQ14)
+	}
+	r.Bits = int(bits) //nolint:gosec // G115
+}
@@ -0,0 +1,133 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+// Package wav writes decoded audio as WAV files for the command-line tools.
+package wav
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+const (
+	// HeaderSize is the size of the header a Writer writes: the RIFF header, a
+	// 16-byte fmt chunk and the data chunk header.
+	HeaderSize = 44
+	// StreamingSize stands in for the chunk sizes when the output cannot be
+	// rewound to fill them in, as tools writing WAV to a pipe do.
+	StreamingSize = math.MaxUint32
+)
+
+// WAVE format tags, for the fmt chunk.
+const (
+	FormatPCM   = 0x0001
+	FormatFloat = 0x0003
+)
+
+// Writer writes interleaved float32 samples at full scale 1 as a 16-bit
+// integer or 32-bit float WAV file.
+type Writer struct {
+	out        io.Writer
+	buffer     *bufio.Writer
+	float      bool
+	sampleRate int
+	channels   int
+	dataSize   int64
+	scratch    []byte
+}
+
+// NewWriter writes the WAV header to out. Its sizes are filled in by Close
+// when out is an io.WriteSeeker and left as 0xffffffff otherwise.
+func NewWriter(out io.Writer, sampleRate, channels int, float bool) (*Writer, error) {
+	w := &Writer{
+		out:        out,
+		buffer:     bufio.NewWriter(out),
+		float:      float,
+		sampleRate: sampleRate,
+		channels:   channels,
+	}
+	if _, err := w.buffer.Write(w.header(StreamingSize)); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func (w *Writer) bytesPerSample() int {
+	if w.float {
+		return 4
+	}
+
+	return 2
+}
+
+// header returns the 44-byte WAV header for dataSize bytes of samples.
+func (w *Writer) header(dataSize uint32) []byte {
+	riffSize := uint32(StreamingSize)
+	if dataSize != StreamingSize {
+		riffSize = HeaderSize - 8 + dataSize
+	}
+	tag := uint16(FormatPCM)
+	if w.float {
+		tag = FormatFloat
+	}
+	blockAlign := w.channels * w.bytesPerSample()
+
+	header := make([]byte, 0, HeaderSize)
+	header = append(header, "RIFF"...)
+	header = binary.LittleEndian.AppendUint32(header, riffSize)
+	header = append(header, "WAVEfmt "...)
+	header = binary.LittleEndian.AppendUint32(header, 16)
+	header = binary.LittleEndian.AppendUint16(header, tag)
+	header = binary.LittleEndian.AppendUint16(header, uint16(w.channels))              // #nosec G115 -- at most 2.
+	header = binary.LittleEndian.AppendUint32(header, uint32(w.sampleRate))            // #nosec G115
+	header = binary.LittleEndian.AppendUint32(header, uint32(w.sampleRate*blockAlign)) // #nosec G115
+	header = binary.LittleEndian.AppendUint16(header, uint16(blockAlign))              // #nosec G115
+	header = binary.LittleEndian.AppendUint16(header, uint16(8*w.bytesPerSample()))    // #nosec G115
+	header = append(header, "data"...)
+
+	return binary.LittleEndian.AppendUint32(header, dataSize)
+}
+
+// Write writes pcm, clipping it to full scale for 16-bit output.
+func (w *Writer) Write(pcm []float32) error {
+	size := len(pcm) * w.bytesPerSample()
+	if cap(w.scratch) < size {
+		w.scratch = make([]byte, size)
+	}
+	out := w.scratch[:size]
+	for i, sample := range pcm {
+		if w.float {
+			binary.LittleEndian.PutUint32(out[4*i:], math.Float32bits(sample))
+
+			continue
+		}
+		value := math.Round(float64(sample) * (1 << 15))
+		binary.LittleEndian.PutUint16(out[2*i:], uint16(int16(max(-(1<<15), min(1<<15-1, value))))) // #nosec G115
+	}
+	w.dataSize += int64(size)
+	_, err := w.buffer.Write(out)
+
+	return err
+}
+
+// Close flushes the samples and, when it can, rewrites the header with their
+// size. A file too long for the 32-bit sizes keeps the streaming sizes.
+func (w *Writer) Close() error {
+	if err := w.buffer.Flush(); err != nil {
+		return err
+	}
+	seeker, ok := w.out.(io.WriteSeeker)
+	if !ok || w.dataSize > StreamingSize-HeaderSize {
+		return nil
+	}
+	if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+		// A pipe or terminal passed as a file cannot seek.
+		return nil //nolint:nilerr
+	}
+	_, err := seeker.Write(w.header(uint32(w.dataSize))) // #nosec G115 -- checked above.
+
+	return err
+}
@@ -0,0 +1,55 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package wav
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriter(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "out.wav")
+	file, err := os.Create(name) // #nosec G304
+	require.NoError(t, err)
+
+	writer, err := NewWriter(file, 48000, 2, false)
+	require.NoError(t, err)
+	require.NoError(t, writer.Write([]float32{0.5, -0.5, 2, -2}))
+	require.NoError(t, writer.Close())
+	require.NoError(t, file.Close())
+
+	data, err := os.ReadFile(name) // #nosec G304
+	require.NoError(t, err)
+	require.Len(t, data, HeaderSize+8)
+	assert.Equal(t, uint32(HeaderSize-8+8), binary.LittleEndian.Uint32(data[4:]))
+	assert.Equal(t, uint16(FormatPCM), binary.LittleEndian.Uint16(data[20:]))
+	assert.Equal(t, uint32(48000*4), binary.LittleEndian.Uint32(data[28:]))
+	assert.Equal(t, uint32(8), binary.LittleEndian.Uint32(data[40:]))
+
+	samples := make([]int16, 4)
+	require.NoError(t, binary.Read(bytes.NewReader(data[HeaderSize:]), binary.LittleEndian, samples))
+	assert.Equal(t, []int16{1 << 14, -(1 << 14), 1<<15 - 1, -(1 << 15)}, samples)
+}
+
+func TestWriterStreaming(t *testing.T) {
+	var out bytes.Buffer
+	writer, err := NewWriter(&out, 16000, 1, true)
+	require.NoError(t, err)
+	require.NoError(t, writer.Write([]float32{0.25}))
+	require.NoError(t, writer.Close())
+
+	data := out.Bytes()
+	require.Len(t, data, HeaderSize+4)
+	assert.Equal(t, uint32(StreamingSize), binary.LittleEndian.Uint32(data[4:]))
+	assert.Equal(t, uint16(FormatFloat), binary.LittleEndian.Uint16(data[20:]))
+	assert.Equal(t, uint32(StreamingSize), binary.LittleEndian.Uint32(data[40:]))
+	assert.Equal(t, float32(0.25), math.Float32frombits(binary.LittleEndian.Uint32(data[HeaderSize:])))
+}
@@ -0,0 +1,135 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package opus
+
+// PacketSampleCount returns how many samples per channel packet decodes to at
+// sampleRate, the value libopus reports from opus_packet_get_nb_samples. The
+// duration comes from the TOC byte's frame size and the packet's frame count
+// (RFC 6716 Section 3.1), so the packet is checked against the framing rules
+// of RFC 6716 Section 3.4 but not decoded.
+func PacketSampleCount(packet []byte, sampleRate int) (int, error) {
+	if sampleRate <= 0 {
+		return 0, errInvalidSampleRate
+	}
+	if len(packet) < 1 {
+		return 0, errTooShortForTableOfContentsHeader
+	}
+
+	tocHeader := tableOfContentsHeader(packet[0])
+	frames, err := parsePacketFrames(packet, tocHeader)
+	if err != nil {
+		return 0, err
+	}
+	nanoseconds := tocHeader.configuration().frameDuration().nanoseconds()
+
+	return int(int64(len(frames)) * int64(nanoseconds) * int64(sampleRate) / 1000000000), nil
+}
+
+// PacketSamplesPerFrame returns how many samples per channel each frame of
+// packet decodes to at sampleRate, the value libopus reports from
+// opus_packet_get_samples_per_frame. Only the TOC byte is read.
+func PacketSamplesPerFrame(packet []byte, sampleRate int) (int, error) {
+	if sampleRate <= 0 {
+		return 0, errInvalidSampleRate
+	}
+	if len(packet) < 1 {
+		return 0, errTooShortForTableOfContentsHeader
+	}
+	nanoseconds := tableOfContentsHeader(packet[0]).configuration().frameDuration().nanoseconds()
+
+	return int(int64(nanoseconds) * int64(sampleRate) / 1000000000), nil
+}
+
+// PacketBandwidth returns the audio bandwidth packet is coded with, the value
+// libopus reports from opus_packet_get_bandwidth. Only the TOC byte is read.
+func PacketBandwidth(packet []byte) (Bandwidth, error) {
+	if len(packet) < 1 {
+		return 0, errTooShortForTableOfContentsHeader
+	}
+
+	return tableOfContentsHeader(packet[0]).configuration().bandwidth(), nil
+}
+
+// PacketMode returns the coding mode of packet. Only the TOC byte is read.
+func PacketMode(packet []byte) (Mode, error) {
+	if len(packet) < 1 {
+		return 0, errTooShortForTableOfContentsHeader
+	}
+
+	return tableOfContentsHeader(packet[0]).configuration().mode().public(), nil
+}
+
+// PacketPadding returns how many bytes of padding end packet, not counting
+// the bytes that signal their length. Only code 3 packets can be padded (RFC
+// 6716 Section 3.2.5). The packet is checked against the framing rules of RFC
+// 6716 Section 3.4 but not decoded.
+func PacketPadding(packet []byte) (int, error) {
+	if len(packet) < 1 {
+		return 0, errTooShortForTableOfContentsHeader
+	}
+
+	tocHeader := tableOfContentsHeader(packet[0])
+	if _, err := parsePacketFrames(packet, tocHeader); err != nil {
+		return 0, err
+	}
+	if tocHeader.frameCode() != frameCodeArbitraryFrames {
+		return 0, nil
+	}
+	if _, hasPadding, _ := parseFrameCountByte(packet[1]); !hasPadding {
+		return 0, nil
+	}
+	_, payloadEnd, err := parsePacketPadding(packet, 2)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(packet) - payloadEnd, nil
+}
+
+// SelfDelimitedPacketSize returns how many bytes of data the self-delimited
+// packet at its start takes up. Self-delimited packets code the length of
+// their last frame too (RFC 6716 Appendix B), so that several can follow one
+// another, as in multistream packets. The packet is checked against the
+// framing rules of RFC 6716 Section 3.4 but not decoded.
+func SelfDelimitedPacketSize(data []byte) (int, error) {
+	_, size, err := parseSelfDelimitedPacket(data)
+
+	return size, err
+}
+
+// ParseSelfDelimitedPacket reads the self-delimited packet at the start of data
+// and returns it in the undelimited form that the Decoder and the other Packet
+// functions take, along with how many bytes of data it takes up. The packet
+// returned does not share memory with data.
+func ParseSelfDelimitedPacket(data []byte) (packet []byte, size int, err error) {
+	return parseSelfDelimitedPacket(data)
+}
+
+// AppendSelfDelimitedPacket appends the self-delimited form of packet to dst
+// and returns the extended buffer. The packet is checked against the framing
+// rules of RFC 6716 Section 3.4 but not decoded.
+func AppendSelfDelimitedPacket(dst, packet []byte) ([]byte, error) {
+	if len(packet) < 1 {
+		return dst, errTooShortForTableOfContentsHeader
+	}
+
+	frames, err := parsePacketFrames(packet, tableOfContentsHeader(packet[0]))
+	if err != nil {
+		return dst, err
+	}
+	padding, err := PacketPadding(packet)
+	if err != nil {
+		return dst, err
+	}
+	// The frame data comes after every length, and before the padding.
+	frameDataStart := len(packet) - padding
+	for _, frame := range frames {
+		frameDataStart -= len(frame)
+	}
+
+	dst = append(dst, packet[:frameDataStart]...)
+	dst = appendFrameLength(dst, len(frames[len(frames)-1]))
+
+	return append(dst, packet[frameDataStart:]...), nil
+}
@@ -254,3 +254,209 @@ func TestDecodePacketFrames(t *testing.T) {
 		}
 	})
 }
+
+func TestPacketSampleCount(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		packet     []byte
+		sampleRate int
+		want       int
+	}{
+		{name: "CELT 20 ms", packet: []byte{31 << 3, 0xff}, sampleRate: 48000, want: 960},
+		{name: "CELT 2.5 ms", packet: []byte{28 << 3, 0xff}, sampleRate: 48000, want: 120},
+		{name: "SILK 60 ms at 16 kHz", packet: []byte{3 << 3, 0xff}, sampleRate: 16000, want: 960},
+		{name: "code 1 two 10 ms frames", packet: []byte{12<<3 | 1, 0xaa, 0xbb}, sampleRate: 48000, want: 960},
+		{
+			name:       "code 3 three 20 ms frames",
+			packet:     []byte{tocByte(frameCodeArbitraryFrames), 3, 0, 0, 0},
+			sampleRate: 8000,
+			want:       480,
+		},
+		{name: "TOC only", packet: []byte{31 << 3}, sampleRate: 48000, want: 960},
+	}
+	for _, test := range tests {
+		got, err := PacketSampleCount(test.packet, test.sampleRate)
+		require.NoError(t, err, test.name)
+		assert.Equal(t, test.want, got, test.name)
+	}
+
+	_, err := PacketSampleCount(nil, 48000)
+	assert.ErrorIs(t, err, errTooShortForTableOfContentsHeader)
+
+	_, err = PacketSampleCount([]byte{31 << 3}, 0)
+	assert.ErrorIs(t, err, errInvalidSampleRate)
+
+	// R5: a code 3 packet may not carry more than 120 ms of audio.
+	_, err = PacketSampleCount([]byte{3<<3 | byte(frameCodeArbitraryFrames), 3}, 48000)
+	assert.ErrorIs(t, err, errMalformedPacket)
+}
+
+func TestPacketSamplesPerFrame(t *testing.T) {
+	t.Parallel()
+
+	got, err := PacketSamplesPerFrame([]byte{12<<3 | 1, 0xaa, 0xbb}, 48000)
+	require.NoError(t, err)
+	assert.Equal(t, 480, got)
+
+	got, err = PacketSamplesPerFrame([]byte{3 << 3}, 16000)
+	require.NoError(t, err)
+	assert.Equal(t, 960, got)
+
+	_, err = PacketSamplesPerFrame(nil, 48000)
+	assert.ErrorIs(t, err, errTooShortForTableOfContentsHeader)
+
+	_, err = PacketSamplesPerFrame([]byte{31 << 3}, -1)
+	assert.ErrorIs(t, err, errInvalidSampleRate)
+}
+
+func TestPacketBandwidthAndMode(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range []struct {
+		config    byte
+		bandwidth Bandwidth
+		mode      Mode
+	}{
+		{0, BandwidthNarrowband, ModeSILK},
+		{5, BandwidthMediumband, ModeSILK},
+		{9, BandwidthWideband, ModeSILK},
+		{13, BandwidthSuperwideband, ModeHybrid},
+		{15, BandwidthFullband, ModeHybrid},
+		{16, BandwidthNarrowband, ModeCELT},
+		{31, BandwidthFullband, ModeCELT},
+	} {
+		packet := []byte{test.config<<3 | byte(frameCodeTwoEqualFrames)}
+		bandwidth, err := PacketBandwidth(packet)
+		require.NoError(t, err)
+		assert.Equal(t, test.bandwidth, bandwidth, "config %d", test.config)
+		mode, err := PacketMode(packet)
+		require.NoError(t, err)
+		assert.Equal(t, test.mode, mode, "config %d", test.config)
+	}
+
+	_, err := PacketBandwidth(nil)
+	assert.ErrorIs(t, err, errTooShortForTableOfContentsHeader)
+	_, err = PacketMode(nil)
+	assert.ErrorIs(t, err, errTooShortForTableOfContentsHeader)
+}
+
+func TestPacketPadding(t *testing.T) {
+	t.Parallel()
+
+	toc := tocByte(frameCodeArbitraryFrames)
+	tests := []struct {
+		name   string
+		packet []byte
+		want   int
+	}{
+		{name: "code 0", packet: []byte{tocByte(frameCodeOneFrame), 1, 2}, want: 0},
+		{name: "code 3 unpadded", packet: []byte{toc, 1, 0xaa}, want: 0},
+		{name: "code 3 padded", packet: []byte{toc, 0x41, 2, 0xaa, 0, 0}, want: 2},
+		{
+			name:   "code 3 with 255 padding length byte",
+			packet: append([]byte{toc, 0x41, 255, 1, 0xaa}, make([]byte, 255)...),
+			want:   255,
+		},
+	}
+	for _, test := range tests {
+		got, err := PacketPadding(test.packet)
+		require.NoError(t, err, test.name)
+		assert.Equal(t, test.want, got, test.name)
+	}
+
+	_, err := PacketPadding(nil)
+	assert.ErrorIs(t, err, errTooShortForTableOfContentsHeader)
+	_, err = PacketPadding([]byte{toc, 0x41, 5, 0xaa})
+	assert.ErrorIs(t, err, errMalformedPacket)
+}
+
+func TestSelfDelimitedPacket(t *testing.T) {
+	t.Parallel()
+
+	long := append([]byte{tocByte(frameCodeOneFrame)}, bytes.Repeat([]byte{0xbb}, 300)...)
+	tests := []struct {
+		name          string
+		packet        []byte
+		selfDelimited []byte
+	}{
+		{
+			name:          "code 0",
+			packet:        []byte{tocByte(frameCodeOneFrame), 1, 2},
+			selfDelimited: []byte{tocByte(frameCodeOneFrame), 2, 1, 2},
+		},
+		{
+			name:          "code 0 with a two byte length",
+			packet:        long,
+			selfDelimited: append([]byte{long[0], 252, 12}, long[1:]...),
+		},
+		{
+			name:          "code 0 empty",
+			packet:        []byte{tocByte(frameCodeOneFrame)},
+			selfDelimited: []byte{tocByte(frameCodeOneFrame), 0},
+		},
+		{
+			name:          "code 1",
+			packet:        []byte{tocByte(frameCodeTwoEqualFrames), 1, 2, 3, 4},
+			selfDelimited: []byte{tocByte(frameCodeTwoEqualFrames), 2, 1, 2, 3, 4},
+		},
+		{
+			name:          "code 2",
+			packet:        []byte{tocByte(frameCodeTwoDifferentFrames), 1, 0xaa, 1, 2},
+			selfDelimited: []byte{tocByte(frameCodeTwoDifferentFrames), 1, 2, 0xaa, 1, 2},
+		},
+		{
+			name:          "code 3 CBR padded",
+			packet:        []byte{tocByte(frameCodeArbitraryFrames), 0x42, 1, 1, 2, 0},
+			selfDelimited: []byte{tocByte(frameCodeArbitraryFrames), 0x42, 1, 1, 1, 2, 0},
+		},
+		{
+			name:          "code 3 VBR padded",
+			packet:        []byte{tocByte(frameCodeArbitraryFrames), 0xc3, 2, 1, 0, 0xaa, 1, 2, 0, 0},
+			selfDelimited: []byte{tocByte(frameCodeArbitraryFrames), 0xc3, 2, 1, 0, 2, 0xaa, 1, 2, 0, 0},
+		},
+	}
+	for _, test := range tests {
+		got, err := AppendSelfDelimitedPacket([]byte{0xff}, test.packet)
+		require.NoError(t, err, test.name)
+		assert.Equal(t, append([]byte{0xff}, test.selfDelimited...), got, test.name)
+
+		// Another packet follows, as in a multistream packet.
+		data := append(bytes.Clone(test.selfDelimited), test.packet...)
+		size, err := SelfDelimitedPacketSize(data)
+		require.NoError(t, err, test.name)
+		assert.Equal(t, len(test.selfDelimited), size, test.name)
+		packet, size, err := ParseSelfDelimitedPacket(data)
+		require.NoError(t, err, test.name)
+		assert.Equal(t, len(test.selfDelimited), size, test.name)
+		assert.Equal(t, test.packet, packet, test.name)
+	}
+
+	for _, data := range [][]byte{
+		nil,
+		{tocByte(frameCodeOneFrame)},
+		{tocByte(frameCodeOneFrame), 3, 1, 2},
+		{tocByte(frameCodeTwoDifferentFrames), 1, 252},
+		{tocByte(frameCodeArbitraryFrames), 0x00, 0},
+		{tocByte(frameCodeArbitraryFrames), 0x41, 5, 0, 0xaa},
+	} {
+		_, err := SelfDelimitedPacketSize(data)
+		assert.ErrorIs(t, err, errMalformedPacket, data)
+	}
+
+	_, err := AppendSelfDelimitedPacket(nil, []byte{tocByte(frameCodeTwoEqualFrames), 1})
+	assert.ErrorIs(t, err, errMalformedPacket)
+}
+
+func TestAppendFrameLength(t *testing.T) {
+	t.Parallel()
+
+	for frameLength := range maxOpusFrameSize + 1 {
+		coded := appendFrameLength(nil, frameLength)
+		got, bytesRead, err := parseFrameLength(coded)
+		require.NoError(t, err)
+		assert.Equal(t, len(coded), bytesRead)
+		assert.Equal(t, frameLength, got)
+	}
+}
@@ -0,0 +1,229 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package opus
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/pion/opus/internal/bitdepth"
+)
+
+// SampleFormat is how one PCM sample is stored in a byte buffer.
+type SampleFormat int
+
+const (
+	// SampleFormatS16LE is signed 16-bit little-endian PCM, the format of
+	// Decode and Encode.
+	SampleFormatS16LE SampleFormat = iota
+	// SampleFormatS24LE is signed 24-bit little-endian PCM packed in 3 bytes.
+	SampleFormatS24LE
+	// SampleFormatS32LE is signed 32-bit little-endian PCM.
+	SampleFormatS32LE
+	// SampleFormatF32LE is 32-bit little-endian IEEE 754 PCM, full scale at
+	// ±1.
+	SampleFormatF32LE
+	// SampleFormatF64LE is 64-bit little-endian IEEE 754 PCM, full scale at
+	// ±1.
+	SampleFormatF64LE
+)
+
+// PCMFormat describes a PCM byte buffer for DecodeToPCM and EncodePCM.
+type PCMFormat struct {
+	SampleFormat SampleFormat
+	// Planar stores each channel's samples together, one plane per channel,
+	// rather than interleaving them. The buffer is split into as many planes
+	// of equal size as there are channels.
+	Planar bool
+}
+
+// bytesPerSample returns the size of one sample, or 0 for an unknown format.
+func (f SampleFormat) bytesPerSample() int {
+	switch f {
+	case SampleFormatS16LE:
+		return 2
+	case SampleFormatS24LE:
+		return 3
+	case SampleFormatS32LE, SampleFormatF32LE:
+		return 4
+	case SampleFormatF64LE:
+		return 8
+	default:
+		return 0
+	}
+}
+
+// isInteger reports whether f quantizes samples, and so takes the soft clip.
+func (f SampleFormat) isInteger() bool {
+	return f == SampleFormatS16LE || f == SampleFormatS24LE || f == SampleFormatS32LE
+}
+
+func (f SampleFormat) put(out []byte, sample float32) {
+	switch f {
+	case SampleFormatS16LE:
+		binary.LittleEndian.PutUint16(out, uint16(bitdepth.Float32ToSigned16(sample))) //nolint:gosec // G115
+	case SampleFormatS24LE:
+		value := bitdepth.Float32ToSigned24(sample)
+		out[0] = byte(value)
+		out[1] = byte(value >> 8)
+		out[2] = byte(value >> 16)
+	case SampleFormatS32LE:
+		binary.LittleEndian.PutUint32(out, uint32(bitdepth.Float32ToSigned32(sample))) //nolint:gosec // G115
+	case SampleFormatF32LE:
+		binary.LittleEndian.PutUint32(out, math.Float32bits(sample))
+	case SampleFormatF64LE:
+		binary.LittleEndian.PutUint64(out, math.Float64bits(float64(sample)))
+	}
+}
+
+func (f SampleFormat) get(in []byte) float32 {
+	switch f {
+	case SampleFormatS16LE:
+		return float32(int16(binary.LittleEndian.Uint16(in))) / (1 << 15) //nolint:gosec // G115
+	case SampleFormatS24LE:
+		// Shift the 24 bits to the top of an int32 to sign-extend them.
+		value := int32(uint32(in[0])<<8|uint32(in[1])<<16|uint32(in[2])<<24) >> 8 //nolint:gosec // G115
+		return float32(value) / (1 << 23)
+	case SampleFormatS32LE:
+		return float32(float64(int32(binary.LittleEndian.Uint32(in))) / (1 << 31)) //nolint:gosec // G115
+	case SampleFormatF32LE:
+		return math.Float32frombits(binary.LittleEndian.Uint32(in))
+	case SampleFormatF64LE:
+		return float32(math.Float64frombits(binary.LittleEndian.Uint64(in)))
+	default:
+		return 0
+	}
+}
+
+// pcmIndex maps interleaved sample i to its position in a buffer of format
+// with planeSamples samples per plane.
+func (f PCMFormat) pcmIndex(i, channels, planeSamples int) int {
+	if !f.Planar {
+		return i
+	}
+
+	return i%channels*planeSamples + i/channels
+}
+
+// writePCM stores the interleaved samples of in into out.
+func (f PCMFormat) writePCM(in []float32, out []byte, channels, planeSamples int) {
+	size := f.SampleFormat.bytesPerSample()
+	for i, sample := range in {
+		f.SampleFormat.put(out[f.pcmIndex(i, channels, planeSamples)*size:], sample)
+	}
+}
+
+// readPCM loads the samples of in into out, interleaved.
+func (f PCMFormat) readPCM(in []byte, out []float32, channels, planeSamples int) {
+	size := f.SampleFormat.bytesPerSample()
+	for i := range out {
+		out[i] = f.SampleFormat.get(in[f.pcmIndex(i, channels, planeSamples)*size:])
+	}
+}
+
+// DecodeToPCM decodes Opus data into out, laid out as format, and returns the
+// sample count per channel. A planar out holds one plane per channel, each a
+// channel's share of its length.
+//
+// The integer formats go through the soft clip Decode applies, so peaks past
+// full scale are bent back rather than squared off; the float formats are left
+// unclipped, like DecodeToFloat32. As in libopus, samples are rounded to the
+// nearest integer without dither.
+func (d *Decoder) DecodeToPCM(in, out []byte, format PCMFormat) (int, error) {
+	size := format.SampleFormat.bytesPerSample()
+	if size == 0 {
+		return 0, errInvalidSampleFormat
+	}
+	if err := d.validateState(); err != nil {
+		return 0, err
+	}
+	planeSamples := len(out) / (size * d.channels)
+	d.floatBuffer = resizeFloat32Buffer(&d.floatBuffer, planeSamples*d.channels)
+
+	sampleCount, _, _, err := d.decodeToFloat32(in, d.floatBuffer)
+	if err != nil {
+		return 0, err
+	}
+
+	samples := d.floatBuffer[:sampleCount*d.channels]
+	if format.SampleFormat.isInteger() {
+		softClip(samples, d.channels, &d.softClipMem)
+	}
+	format.writePCM(samples, out, d.channels, planeSamples)
+
+	return sampleCount, nil
+}
+
+// DecodeToFloat32Planar decodes Opus data into one float32 slice per channel
+// and returns the sample count per channel.
+func (d *Decoder) DecodeToFloat32Planar(in []byte, out [][]float32) (int, error) {
+	if err := d.validateState(); err != nil {
+		return 0, err
+	}
+	if len(out) != d.channels {
+		return 0, errInvalidChannelCount
+	}
+	planeSamples := len(out[0])
+	for _, plane := range out[1:] {
+		planeSamples = min(planeSamples, len(plane))
+	}
+	d.floatBuffer = resizeFloat32Buffer(&d.floatBuffer, planeSamples*d.channels)
+
+	sampleCount, _, _, err := d.decodeToFloat32(in, d.floatBuffer)
+	if err != nil {
+		return 0, err
+	}
+	for channel, plane := range out {
+		for i := range sampleCount {
+			plane[i] = d.floatBuffer[i*d.channels+channel]
+		}
+	}
+
+	return sampleCount, nil
+}
+
+// EncodePCM encodes PCM laid out as format into a single Opus packet. in must
+// hold exactly FrameSize samples per channel; a planar in holds one plane per
+// channel, back to back.
+func (e *Encoder) EncodePCM(in []byte, format PCMFormat, out []byte) (int, error) {
+	size := format.SampleFormat.bytesPerSample()
+	if size == 0 {
+		return 0, errInvalidSampleFormat
+	}
+	if len(in)%size != 0 {
+		return 0, fmt.Errorf("%w: length %d not a multiple of %d", errInvalidInputLength, len(in), size)
+	}
+
+	frameSamples := e.frameSampleCount()
+	if len(in)/size != frameSamples*e.channels {
+		return 0, fmt.Errorf("%w: got %d samples, want %d", errInvalidFrameSize, len(in)/size, frameSamples*e.channels)
+	}
+
+	pcm := e.scratch.pcm[:frameSamples*e.channels]
+	format.readPCM(in, pcm, e.channels, frameSamples)
+
+	return e.EncodeFloat32(pcm, out)
+}
+
+// EncodeFloat32Planar encodes one float32 slice per channel, each holding
+// exactly FrameSize samples, into a single Opus packet.
+func (e *Encoder) EncodeFloat32Planar(in [][]float32, out []byte) (int, error) {
+	if len(in) != e.channels {
+		return 0, errInvalidChannelCount
+	}
+
+	frameSamples := e.frameSampleCount()
+	pcm := e.scratch.pcm[:frameSamples*e.channels]
+	for channel, plane := range in {
+		if len(plane) != frameSamples {
+			return 0, fmt.Errorf("%w: got %d samples, want %d", errInvalidFrameSize, len(plane), frameSamples)
+		}
+		for i, sample := range plane {
+			pcm[i*e.channels+channel] = sample
+		}
+	}
+
+	return e.EncodeFloat32(pcm, out)
+}
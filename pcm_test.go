@@ -0,0 +1,261 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package opus
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/pion/opus/internal/bitdepth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//nolint:gochecknoglobals
+var testPCMFormats = []PCMFormat{
+	{SampleFormat: SampleFormatS16LE},
+	{SampleFormat: SampleFormatS24LE},
+	{SampleFormat: SampleFormatS32LE},
+	{SampleFormat: SampleFormatF32LE},
+	{SampleFormat: SampleFormatF64LE},
+	{SampleFormat: SampleFormatS16LE, Planar: true},
+	{SampleFormat: SampleFormatS24LE, Planar: true},
+	{SampleFormat: SampleFormatS32LE, Planar: true},
+	{SampleFormat: SampleFormatF32LE, Planar: true},
+	{SampleFormat: SampleFormatF64LE, Planar: true},
+}
+
+// testLoudStereoPackets encodes a full-scale stereo square wave, which decodes
+// with peaks past full scale.
+func testLoudStereoPackets(t *testing.T) [][]byte {
+	t.Helper()
+
+	encoder, err := NewEncoder(WithChannels(2), WithBitrate(96000))
+	require.NoError(t, err)
+	packets := make([][]byte, 0, 4)
+	pcm := make([]float32, 2*encoderTestFrameSampleCount)
+	for frame := range 4 {
+		for i := range encoderTestFrameSampleCount {
+			n := frame*encoderTestFrameSampleCount + i
+			pcm[2*i] = float32(1 - 2*(n/60%2))
+			pcm[2*i+1] = float32(math.Sin(2 * math.Pi * 330 * float64(n) / 48000))
+		}
+		packet := make([]byte, 1500)
+		size, err := encoder.EncodeFloat32(pcm, packet)
+		require.NoError(t, err)
+		packets = append(packets, packet[:size])
+	}
+
+	return packets
+}
+
+func TestDecodeToPCM(t *testing.T) {
+	packets := testLoudStereoPackets(t)
+
+	reference, err := NewDecoderWithOutput(48000, 2)
+	require.NoError(t, err)
+	want := make([][]float32, len(packets))
+	peak := float32(0)
+	for i, packet := range packets {
+		want[i] = make([]float32, 2*encoderTestFrameSampleCount)
+		_, err := reference.DecodeToFloat32(packet, want[i])
+		require.NoError(t, err)
+		for _, sample := range want[i] {
+			peak = max(peak, absFloat32(sample))
+		}
+	}
+	require.Greater(t, peak, float32(1), "the test signal should overshoot full scale")
+
+	for _, format := range testPCMFormats {
+		t.Run(fmt.Sprintf("%d-%t", format.SampleFormat, format.Planar), func(t *testing.T) {
+			decoder, err := NewDecoderWithOutput(48000, 2)
+			require.NoError(t, err)
+			var clipMem softClipMemory
+			size := format.SampleFormat.bytesPerSample()
+			for i, packet := range packets {
+				// Leave room for more samples than the packet holds, so planar
+				// planes are spaced by the buffer, not by the packet.
+				out := make([]byte, 2*size*(encoderTestFrameSampleCount+7))
+				sampleCount, err := decoder.DecodeToPCM(packet, out, format)
+				require.NoError(t, err)
+				require.Equal(t, encoderTestFrameSampleCount, sampleCount)
+
+				expected := append([]float32(nil), want[i]...)
+				if format.SampleFormat.isInteger() {
+					softClip(expected, 2, &clipMem)
+				}
+				for j, sample := range expected {
+					index := j
+					if format.Planar {
+						index = j%2*(encoderTestFrameSampleCount+7) + j/2
+					}
+					got := format.SampleFormat.get(out[index*size:])
+					switch format.SampleFormat {
+					case SampleFormatS16LE:
+						assert.Equal(t, float32(bitdepth.Float32ToSigned16(sample))/(1<<15), got)
+					case SampleFormatS24LE:
+						assert.Equal(t, float32(bitdepth.Float32ToSigned24(sample))/(1<<23), got)
+					case SampleFormatS32LE:
+						assert.InDelta(t, sample, got, 1e-7)
+						assert.LessOrEqual(t, math.Abs(float64(got)), 1.0)
+					default:
+						assert.Equal(t, sample, got)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeToPCMMatchesDecode(t *testing.T) {
+	packets := testLoudStereoPackets(t)
+	decoder, err := NewDecoderWithOutput(48000, 2)
+	require.NoError(t, err)
+	pcmDecoder, err := NewDecoderWithOutput(48000, 2)
+	require.NoError(t, err)
+
+	for _, packet := range packets {
+		want := make([]byte, 4*encoderTestFrameSampleCount)
+		_, _, err := decoder.Decode(packet, want)
+		require.NoError(t, err)
+
+		got := make([]byte, len(want))
+		_, err = pcmDecoder.DecodeToPCM(packet, got, PCMFormat{SampleFormat: SampleFormatS16LE})
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+}
+
+func TestDecodeToInt16MatchesDecode(t *testing.T) {
+	packets := testLoudStereoPackets(t)
+	decoder, err := NewDecoderWithOutput(48000, 2)
+	require.NoError(t, err)
+	int16Decoder, err := NewDecoderWithOutput(48000, 2)
+	require.NoError(t, err)
+
+	for _, packet := range packets {
+		want := make([]byte, 4*encoderTestFrameSampleCount)
+		_, _, err := decoder.Decode(packet, want)
+		require.NoError(t, err)
+
+		got := make([]int16, 2*encoderTestFrameSampleCount)
+		_, err = int16Decoder.DecodeToInt16(packet, got)
+		require.NoError(t, err)
+		for i, sample := range got {
+			assert.Equal(t, int16(binary.LittleEndian.Uint16(want[2*i:])), sample) //nolint:gosec // G115
+		}
+	}
+}
+
+func TestDecodeToFloat32Planar(t *testing.T) {
+	packets := testLoudStereoPackets(t)
+	reference, err := NewDecoderWithOutput(48000, 2)
+	require.NoError(t, err)
+	decoder, err := NewDecoderWithOutput(48000, 2)
+	require.NoError(t, err)
+
+	for _, packet := range packets {
+		want := make([]float32, 2*encoderTestFrameSampleCount)
+		_, err := reference.DecodeToFloat32(packet, want)
+		require.NoError(t, err)
+
+		planes := [][]float32{
+			make([]float32, encoderTestFrameSampleCount),
+			make([]float32, encoderTestFrameSampleCount+10),
+		}
+		sampleCount, err := decoder.DecodeToFloat32Planar(packet, planes)
+		require.NoError(t, err)
+		require.Equal(t, encoderTestFrameSampleCount, sampleCount)
+		for i := range sampleCount {
+			assert.Equal(t, want[2*i], planes[0][i])
+			assert.Equal(t, want[2*i+1], planes[1][i])
+		}
+	}
+
+	_, err = decoder.DecodeToFloat32Planar(packets[0], make([][]float32, 1))
+	assert.ErrorIs(t, err, errInvalidChannelCount)
+	_, err = decoder.DecodeToFloat32Planar(packets[0], make([][]float32, 2))
+	assert.ErrorIs(t, err, errOutBufferTooSmall)
+}
+
+func TestEncodePCM(t *testing.T) {
+	// 16-bit values convert exactly in every format, so each one must encode
+	// to the packet EncodeFloat32 produces.
+	pcm := make([]float32, 2*encoderTestFrameSampleCount)
+	for i := range encoderTestFrameSampleCount {
+		pcm[2*i] = float32(math.Round(math.Sin(2*math.Pi*440*float64(i)/48000)*16000)) / (1 << 15)
+		pcm[2*i+1] = float32(math.Round(math.Sin(2*math.Pi*660*float64(i)/48000)*8000)) / (1 << 15)
+	}
+	reference, err := NewEncoder(WithChannels(2))
+	require.NoError(t, err)
+	want := make([]byte, 1500)
+	n, err := reference.EncodeFloat32(append([]float32(nil), pcm...), want)
+	require.NoError(t, err)
+
+	for _, format := range testPCMFormats {
+		t.Run(fmt.Sprintf("%d-%t", format.SampleFormat, format.Planar), func(t *testing.T) {
+			encoder, err := NewEncoder(WithChannels(2))
+			require.NoError(t, err)
+			in := make([]byte, len(pcm)*format.SampleFormat.bytesPerSample())
+			format.writePCM(pcm, in, 2, encoderTestFrameSampleCount)
+
+			out := make([]byte, 1500)
+			size, err := encoder.EncodePCM(in, format, out)
+			require.NoError(t, err)
+			assert.Equal(t, want[:n], out[:size])
+		})
+	}
+
+	encoder, err := NewEncoder(WithChannels(2))
+	require.NoError(t, err)
+	planes := [][]float32{make([]float32, encoderTestFrameSampleCount), make([]float32, encoderTestFrameSampleCount)}
+	for i := range encoderTestFrameSampleCount {
+		planes[0][i], planes[1][i] = pcm[2*i], pcm[2*i+1]
+	}
+	out := make([]byte, 1500)
+	size, err := encoder.EncodeFloat32Planar(planes, out)
+	require.NoError(t, err)
+	assert.Equal(t, want[:n], out[:size])
+}
+
+func TestPCMFormatValidation(t *testing.T) {
+	encoder, err := NewEncoder(WithChannels(2))
+	require.NoError(t, err)
+	out := make([]byte, 1500)
+
+	_, err = encoder.EncodePCM(make([]byte, 4*encoderTestFrameSampleCount), PCMFormat{SampleFormat: 9}, out)
+	assert.ErrorIs(t, err, errInvalidSampleFormat)
+	s24 := PCMFormat{SampleFormat: SampleFormatS24LE}
+	_, err = encoder.EncodePCM(make([]byte, 3*encoderTestFrameSampleCount+1), s24, out)
+	assert.ErrorIs(t, err, errInvalidInputLength)
+	_, err = encoder.EncodePCM(make([]byte, 3*encoderTestFrameSampleCount), s24, out)
+	assert.ErrorIs(t, err, errInvalidFrameSize)
+	_, err = encoder.EncodeFloat32Planar([][]float32{make([]float32, encoderTestFrameSampleCount)}, out)
+	assert.ErrorIs(t, err, errInvalidChannelCount)
+	_, err = encoder.EncodeFloat32Planar([][]float32{{0}, {0}}, out)
+	assert.ErrorIs(t, err, errInvalidFrameSize)
+
+	decoder := NewDecoder()
+	packet := []byte{byte(31<<3) | byte(frameCodeOneFrame)}
+	_, err = decoder.DecodeToPCM(packet, out, PCMFormat{SampleFormat: -1})
+	assert.ErrorIs(t, err, errInvalidSampleFormat)
+	_, err = decoder.DecodeToPCM(packet, out[:100], s24)
+	assert.ErrorIs(t, err, errOutBufferTooSmall)
+
+	// An uninitialized decoder fails the way Decode does.
+	var uninitialized Decoder
+	_, _, want := uninitialized.Decode(packet, out)
+	require.Error(t, want)
+	_, err = uninitialized.DecodeToPCM(packet, out, s24)
+	assert.ErrorIs(t, err, want)
+	_, err = uninitialized.DecodeToFloat32Planar(packet, nil)
+	assert.ErrorIs(t, err, want)
+	decoder.channels = 0
+	_, err = decoder.DecodeToPCM(packet, out, s24)
+	assert.ErrorIs(t, err, errInvalidChannelCount)
+	_, err = decoder.DecodeToFloat32Planar(packet, nil)
+	assert.ErrorIs(t, err, errInvalidChannelCount)
+}
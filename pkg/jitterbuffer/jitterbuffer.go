@@ -0,0 +1,516 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+// Package jitterbuffer plays out the Opus packets of one RTP stream through a
+// Decoder, reordering them and hiding network jitter and packet loss.
+//
+// Packets are pushed as they arrive, with their RTP sequence number and
+// timestamp, and audio is pulled with Pop at the pace the output device
+// consumes it. The buffer holds packets back by a playout delay it adapts to
+// the jitter it measures. When a packet is missing at its playout time it is
+// recovered from the in-band FEC data (LBRR frames) of the packet after it if
+// that has arrived, and concealed with packet loss concealment otherwise. A
+// packet that arrives out of order but before its playout time is simply
+// slotted into place.
+//
+// The delay grows when the buffer runs dry, as the concealment that fills the
+// underrun holds the playout position back. It shrinks when more audio is
+// buffered than the target calls for: the next packet is then played faster
+// by removing pitch periods from it (see package timestretch), or, past the
+// maximum delay, dropped.
+package jitterbuffer
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/pion/opus"
+	"github.com/pion/opus/internal/bitdepth"
+	"github.com/pion/opus/pkg/timestretch"
+)
+
+const (
+	// clockRate is the RTP clock rate of Opus, whatever the sampling rate of
+	// the audio (RFC 7587 Section 4.1). All durations below are in its units.
+	clockRate = 48000
+
+	// concealStep is the most audio DecodePLC produces at once, 20 ms.
+	concealStep = clockRate / 50
+	// fecStep is the granularity DecodeFEC works in, 10 ms.
+	fecStep = clockRate / 100
+	// maxPacketDuration is the longest Opus packet, 120 ms.
+	maxPacketDuration = clockRate * 120 / 1000
+
+	// jitterGain is the 1/16 smoothing of RFC 3550 Appendix A.8.
+	jitterGain = 16
+	// jitterMargin is how many times the mean jitter the playout delay covers
+	// on top of the minimum delay.
+	jitterMargin = 4
+
+	// firstCELTOnlyConfiguration is the lowest TOC configuration number of the
+	// CELT-only mode (RFC 6716 Section 3.1).
+	firstCELTOnlyConfiguration = 16
+
+	defaultMinDelay   = 20 * time.Millisecond
+	defaultMaxDelay   = 500 * time.Millisecond
+	defaultMaxPackets = 500
+)
+
+var (
+	errInvalidDelay      = errors.New("invalid playout delay")
+	errInvalidMaxPackets = errors.New("invalid maximum packet count")
+	errBufferFull        = errors.New("jitter buffer is full")
+	errOutBufferTooSmall = errors.New("out isn't large enough")
+)
+
+// Stats describes what a JitterBuffer has done so far.
+type Stats struct {
+	// PacketsReceived counts every packet pushed, late ones included.
+	PacketsReceived uint64
+	// PacketsLost counts packets that were not there at their playout time,
+	// including those recovered with FEC.
+	PacketsLost uint64
+	// PacketsLate counts packets that arrived after their playout time, or
+	// after a newer packet had been played, and were discarded.
+	PacketsLate uint64
+	// PacketsRecovered counts lost packets whose audio was decoded from the
+	// FEC data of the packet after them.
+	PacketsRecovered uint64
+	// PacketsDropped counts packets skipped to bring the delay back down.
+	PacketsDropped uint64
+	// Accelerated is how much audio time-scale modification has removed to
+	// bring the delay back down.
+	Accelerated time.Duration
+	// Concealed is how much audio packet loss concealment has produced, for
+	// lost packets, gaps in the stream and buffer underruns.
+	Concealed time.Duration
+	// Jitter is the RFC 3550 interarrival jitter estimate.
+	Jitter time.Duration
+	// Delay is how much audio is buffered ahead of the playout position.
+	Delay time.Duration
+	// TargetDelay is the delay the buffer currently aims for.
+	TargetDelay time.Duration
+}
+
+type bufferedPacket struct {
+	sequence      int64
+	timestamp     uint32
+	duration      uint32
+	frameDuration uint32
+	payload       []byte
+}
+
+// JitterBuffer reorders the packets of one Opus RTP stream and decodes them
+// at a playout delay adapted to the network jitter.
+type JitterBuffer struct {
+	decoder    opus.Decoder
+	sampleRate int
+	channels   int
+	minDelay   uint32
+	maxDelay   uint32
+	maxPackets int
+
+	packets         []bufferedPacket
+	receivedAny     bool
+	highestSequence int64
+
+	arrivalBase   time.Time
+	lastArrival   int64
+	lastTimestamp uint32
+	jitter        float64
+
+	started            bool
+	playoutTimestamp   uint32
+	playedAny          bool
+	lastPlayedSequence int64
+	lastPlayedCELTOnly bool
+
+	stats Stats
+	// concealed and accelerated are Stats.Concealed and Stats.Accelerated in
+	// clockRate units.
+	concealed   uint64
+	accelerated uint64
+	scratch     []int16
+	stretcher   *timestretch.Stretcher
+	decoded     []float32
+	stretched   []float32
+}
+
+// Option configures a JitterBuffer.
+type Option func(*JitterBuffer) error
+
+// WithMinDelay sets the smallest playout delay, 20 ms by default. The delay
+// never drops below it however steady the network is.
+func WithMinDelay(delay time.Duration) Option {
+	return func(j *JitterBuffer) error {
+		if delay < 0 || delay > time.Minute {
+			return errInvalidDelay
+		}
+		j.minDelay = durationToSamples(delay)
+
+		return nil
+	}
+}
+
+// WithMaxDelay sets the largest playout delay, 500 ms by default.
+func WithMaxDelay(delay time.Duration) Option {
+	return func(j *JitterBuffer) error {
+		if delay <= 0 || delay > time.Minute {
+			return errInvalidDelay
+		}
+		j.maxDelay = durationToSamples(delay)
+
+		return nil
+	}
+}
+
+// WithMaxPackets sets how many packets the buffer holds before Push fails,
+// 500 by default.
+func WithMaxPackets(count int) Option {
+	return func(j *JitterBuffer) error {
+		if count <= 0 {
+			return errInvalidMaxPackets
+		}
+		j.maxPackets = count
+
+		return nil
+	}
+}
+
+// New returns a JitterBuffer decoding to sampleRate and channels, which
+// follow the rules of opus.NewDecoderWithOutput.
+func New(sampleRate, channels int, opts ...Option) (*JitterBuffer, error) {
+	decoder, err := opus.NewDecoderWithOutput(sampleRate, channels)
+	if err != nil {
+		return nil, err
+	}
+
+	jitterBuffer := &JitterBuffer{
+		decoder:    decoder,
+		sampleRate: sampleRate,
+		channels:   channels,
+		minDelay:   durationToSamples(defaultMinDelay),
+		maxDelay:   durationToSamples(defaultMaxDelay),
+		maxPackets: defaultMaxPackets,
+	}
+	for _, opt := range opts {
+		if err := opt(jitterBuffer); err != nil {
+			return nil, err
+		}
+	}
+	if jitterBuffer.minDelay > jitterBuffer.maxDelay {
+		return nil, errInvalidDelay
+	}
+	jitterBuffer.stretcher, err = timestretch.New(sampleRate, channels)
+	if err != nil {
+		return nil, err
+	}
+	maxPacketSamples := jitterBuffer.outputSamples(maxPacketDuration)
+	jitterBuffer.scratch = make([]int16, maxPacketSamples*channels)
+	jitterBuffer.decoded = make([]float32, maxPacketSamples*channels)
+	jitterBuffer.stretched = make([]float32,
+		jitterBuffer.stretcher.MaxOutputSamples(maxPacketSamples, timestretch.MinRatio)*channels)
+
+	return jitterBuffer, nil
+}
+
+// Push adds the Opus packet carried by an RTP packet with sequenceNumber and
+// timestamp that arrived at arrival. The packet is copied. Packets that are
+// too late to be played are counted and discarded without an error, as are
+// duplicates.
+func (j *JitterBuffer) Push(sequenceNumber uint16, timestamp uint32, packet []byte, arrival time.Time) error {
+	duration, err := opus.PacketSampleCount(packet, clockRate)
+	if err != nil {
+		return err
+	}
+	frameDuration, err := opus.PacketSamplesPerFrame(packet, clockRate)
+	if err != nil {
+		return err
+	}
+
+	j.stats.PacketsReceived++
+	sequence := j.extendSequence(sequenceNumber)
+	j.updateJitter(timestamp, arrival)
+
+	//nolint:gosec // G115: durations are at most 120 ms of samples.
+	if (j.playedAny && sequence <= j.lastPlayedSequence) ||
+		(j.started && int32(timestamp+uint32(duration)-j.playoutTimestamp) <= 0) {
+		j.stats.PacketsLate++
+
+		return nil
+	}
+
+	index, found := slices.BinarySearchFunc(j.packets, sequence, func(p bufferedPacket, sequence int64) int {
+		switch {
+		case p.sequence < sequence:
+			return -1
+		case p.sequence > sequence:
+			return 1
+		default:
+			return 0
+		}
+	})
+	if found {
+		return nil
+	}
+	if len(j.packets) >= j.maxPackets {
+		return errBufferFull
+	}
+	j.packets = slices.Insert(j.packets, index, bufferedPacket{
+		sequence:      sequence,
+		timestamp:     timestamp,
+		duration:      uint32(duration),      // #nosec G115 -- at most 120 ms of samples.
+		frameDuration: uint32(frameDuration), // #nosec G115 -- at most 60 ms of samples.
+		payload:       append([]byte(nil), packet...),
+	})
+
+	return nil
+}
+
+// Pop decodes the next stretch of audio into out as interleaved signed 16-bit
+// PCM and returns its length in samples per channel. That is the next
+// packet's audio, or up to 20 ms of concealment, or silence while the buffer
+// first fills to its target delay, so the length varies from call to call.
+// out must hold 120 ms of audio, the longest Opus packet.
+//
+// A decoding error drops the packet that caused it.
+func (j *JitterBuffer) Pop(out []int16) (int, error) {
+	if len(out) < len(j.scratch) {
+		return 0, errOutBufferTooSmall
+	}
+
+	return j.pop(out, true)
+}
+
+//nolint:cyclop
+func (j *JitterBuffer) pop(out []int16, allowDrop bool) (int, error) {
+	if !j.started {
+		if len(j.packets) == 0 || j.bufferedDuration() < j.targetDelay() {
+			samples := j.outputSamples(concealStep)
+			clear(out[:samples*j.channels])
+
+			return samples, nil
+		}
+		j.started = true
+		j.playoutTimestamp = j.packets[0].timestamp
+	}
+
+	if len(j.packets) == 0 {
+		// An underrun: the playout position holds while concealment plays, so
+		// the delay grows by what was concealed.
+		return j.conceal(out)
+	}
+
+	head := &j.packets[0]
+	//nolint:gosec // G115: RTP timestamps wrap, so compare their difference.
+	gap := int32(head.timestamp - j.playoutTimestamp)
+	if gap < 0 {
+		j.playoutTimestamp = head.timestamp
+		gap = 0
+	}
+	if gap == 0 {
+		buffered := j.bufferedDuration()
+		if !allowDrop || len(j.packets) < 2 || buffered <= j.targetDelay()+2*head.duration {
+			return j.play(out)
+		}
+		if buffered <= j.maxDelay {
+			return j.accelerate(out)
+		}
+		if _, err := j.play(j.scratch); err != nil {
+			return 0, err
+		}
+		j.stats.PacketsDropped++
+
+		return j.pop(out, false)
+	}
+
+	missing := j.playedAny && head.sequence-j.lastPlayedSequence > 1
+	switch {
+	case missing && gap%fecStep == 0 && gap <= maxPacketDuration:
+		return j.recover(out, uint32(gap))
+	case gap >= concealStep:
+		// A gap longer than FEC can fill, or one the sender left in the
+		// timestamps (such as for DTX) rather than in the sequence numbers.
+		samples, err := j.conceal(out)
+		j.playoutTimestamp += concealStep
+
+		return samples, err
+	default:
+		// Too short to conceal on its own: play the next packet a little early.
+		j.playoutTimestamp = head.timestamp
+
+		return j.play(out)
+	}
+}
+
+// play decodes the packet at the head of the buffer.
+func (j *JitterBuffer) play(out []int16) (int, error) {
+	head := j.take()
+
+	return j.decoder.DecodeToInt16(head.payload, out)
+}
+
+// accelerate plays the packet at the head of the buffer with as many pitch
+// periods removed as will go unnoticed, up to half its audio.
+func (j *JitterBuffer) accelerate(out []int16) (int, error) {
+	head := j.take()
+	// Decoding to int16 soft-clips the packet with the same state as those
+	// played whole. The stretch only cross-fades it, which stays within full
+	// scale.
+	decoded, err := j.decoder.DecodeToInt16(head.payload, out)
+	if err != nil {
+		return 0, err
+	}
+	for i, sample := range out[:decoded*j.channels] {
+		j.decoded[i] = float32(sample) / (1 << 15)
+	}
+	pitchLag, voiced := j.decoder.PitchLag()
+	if !voiced {
+		pitchLag = 0
+	}
+	stretched, err := j.stretcher.StretchWithPitchLag(
+		j.decoded[:decoded*j.channels],
+		j.stretched,
+		timestretch.MinRatio,
+		pitchLag,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	j.accelerated += uint64(int64(decoded-stretched) * clockRate / int64(j.sampleRate)) //nolint:gosec // G115
+	for i, sample := range j.stretched[:stretched*j.channels] {
+		out[i] = bitdepth.Float32ToSigned16(sample)
+	}
+
+	return stretched, nil
+}
+
+// take removes the packet at the head of the buffer to play it, accounting
+// for any packets missing before it.
+func (j *JitterBuffer) take() bufferedPacket {
+	head := j.packets[0]
+	j.packets = j.packets[1:]
+	if j.playedAny && head.sequence-j.lastPlayedSequence > 1 {
+		j.stats.PacketsLost += uint64(head.sequence - j.lastPlayedSequence - 1)
+	}
+	j.playedAny = true
+	j.lastPlayedSequence = head.sequence
+	j.lastPlayedCELTOnly = int(head.payload[0]>>3) >= firstCELTOnlyConfiguration
+	j.playoutTimestamp = head.timestamp + head.duration
+
+	return head
+}
+
+// recover fills the gap before the head packet, whose duration is a multiple
+// of 10 ms, from its FEC data. DecodeFEC conceals whatever the FEC data does
+// not cover.
+func (j *JitterBuffer) recover(out []int16, gap uint32) (int, error) {
+	head := j.packets[0]
+	samples := j.outputSamples(gap)
+	if err := j.decoder.DecodeFEC(head.payload, out[:samples*j.channels]); err != nil {
+		return 0, err
+	}
+
+	concealed := gap
+	if hasLBRR, _ := opus.PacketHasLBRR(head.payload); hasLBRR && !j.lastPlayedCELTOnly && gap >= head.frameDuration {
+		j.stats.PacketsRecovered++
+		concealed -= head.frameDuration
+	}
+	j.concealed += uint64(concealed)
+	j.playoutTimestamp = head.timestamp
+
+	return samples, nil
+}
+
+// conceal produces 20 ms of packet loss concealment.
+func (j *JitterBuffer) conceal(out []int16) (int, error) {
+	samples := j.outputSamples(concealStep)
+	if err := j.decoder.DecodePLC(out[:samples*j.channels]); err != nil {
+		return 0, err
+	}
+	j.concealed += concealStep
+
+	return samples, nil
+}
+
+// Stats returns the buffer's statistics.
+func (j *JitterBuffer) Stats() Stats {
+	stats := j.stats
+	stats.Concealed = samplesToDuration(j.concealed)
+	stats.Accelerated = samplesToDuration(j.accelerated)
+	stats.Jitter = time.Duration(j.jitter * float64(time.Second) / clockRate)
+	stats.Delay = samplesToDuration(uint64(j.bufferedDuration()))
+	stats.TargetDelay = samplesToDuration(uint64(j.targetDelay()))
+
+	return stats
+}
+
+// extendSequence widens a 16-bit sequence number to one that keeps counting
+// across wraparounds, relative to the highest one seen so far.
+func (j *JitterBuffer) extendSequence(sequenceNumber uint16) int64 {
+	if !j.receivedAny {
+		j.receivedAny = true
+		j.highestSequence = int64(sequenceNumber)
+
+		return j.highestSequence
+	}
+	//nolint:gosec // G115: the signed difference is the point.
+	sequence := j.highestSequence + int64(int16(sequenceNumber-uint16(j.highestSequence)))
+	j.highestSequence = max(j.highestSequence, sequence)
+
+	return sequence
+}
+
+// updateJitter folds one packet into the interarrival jitter estimate of
+// RFC 3550 Section 6.4.1, in clockRate units.
+func (j *JitterBuffer) updateJitter(timestamp uint32, arrival time.Time) {
+	if j.arrivalBase.IsZero() {
+		j.arrivalBase = arrival
+		j.lastTimestamp = timestamp
+
+		return
+	}
+	arrivalSamples := int64(arrival.Sub(j.arrivalBase)) * clockRate / int64(time.Second)
+	//nolint:gosec // G115: RTP timestamps wrap, so compare their difference.
+	difference := arrivalSamples - j.lastArrival - int64(int32(timestamp-j.lastTimestamp))
+	j.jitter += (math.Abs(float64(difference)) - j.jitter) / jitterGain
+	j.lastArrival = arrivalSamples
+	j.lastTimestamp = timestamp
+}
+
+// targetDelay is the minimum delay plus a margin for the measured jitter.
+func (j *JitterBuffer) targetDelay() uint32 {
+	return min(j.minDelay+uint32(jitterMargin*j.jitter), j.maxDelay)
+}
+
+// bufferedDuration is how far the newest buffered packet reaches past the
+// playout position.
+func (j *JitterBuffer) bufferedDuration() uint32 {
+	if len(j.packets) == 0 {
+		return 0
+	}
+	start := j.packets[0].timestamp
+	if j.started {
+		start = j.playoutTimestamp
+	}
+	newest := j.packets[len(j.packets)-1]
+	//nolint:gosec // G115: RTP timestamps wrap, so compare their difference.
+	buffered := int32(newest.timestamp + newest.duration - start)
+
+	return uint32(max(buffered, 0))
+}
+
+func (j *JitterBuffer) outputSamples(samples uint32) int {
+	return int(uint64(samples) * uint64(j.sampleRate) / clockRate) //nolint:gosec // G115
+}
+
+func durationToSamples(duration time.Duration) uint32 {
+	return uint32(int64(duration) * clockRate / int64(time.Second)) //nolint:gosec // G115: at most a minute.
+}
+
+func samplesToDuration(samples uint64) time.Duration {
+	return time.Duration(samples * uint64(time.Second) / clockRate) //nolint:gosec // G115
+}
@@ -0,0 +1,297 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package jitterbuffer
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/pion/opus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testSampleRate   = 16000
+	testFrameSamples = testSampleRate / 50
+	testPacketTicks  = clockRate / 50
+)
+
+var testEpoch = time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// silkPackets encodes count 20 ms wideband SILK packets of a voiced tone,
+// with in-band FEC when fec is set.
+func silkPackets(t *testing.T, count int, fec bool) [][]byte {
+	t.Helper()
+
+	encoder, err := opus.NewEncoder(opus.WithInbandFEC(fec))
+	require.NoError(t, err)
+	require.NoError(t, encoder.SetLossRate(20))
+
+	packets := make([][]byte, 0, count)
+	for frame := range count {
+		pcm := make([]int16, testFrameSamples)
+		for i := range pcm {
+			phase := 2 * math.Pi * 140 * float64(frame*testFrameSamples+i) / testSampleRate
+			pcm[i] = int16(6000*math.Sin(phase) + 3000*math.Sin(2*phase))
+		}
+		packet := make([]byte, 1275)
+		n, err := encoder.EncodeSILK(pcm, opus.BandwidthWideband, packet)
+		require.NoError(t, err)
+		packets = append(packets, packet[:n])
+	}
+
+	return packets
+}
+
+// push sends packet index of a stream starting at sequence number 65530, so
+// the sequence numbers wrap, arriving on a steady 20 ms clock plus delay.
+func push(t *testing.T, jitterBuffer *JitterBuffer, packets [][]byte, index int, delay time.Duration) {
+	t.Helper()
+
+	arrival := testEpoch.Add(time.Duration(index)*20*time.Millisecond + delay)
+	require.NoError(t, jitterBuffer.Push(
+		uint16(65530+index),
+		uint32(1000+index*testPacketTicks),
+		packets[index],
+		arrival,
+	))
+}
+
+// popAll pops until the buffer is empty and returns how many samples per
+// channel came out.
+func popAll(t *testing.T, jitterBuffer *JitterBuffer) int {
+	t.Helper()
+
+	out := make([]int16, testSampleRate*120/1000)
+	total := 0
+	for len(jitterBuffer.packets) > 0 {
+		n, err := jitterBuffer.Pop(out)
+		require.NoError(t, err)
+		total += n
+	}
+
+	return total
+}
+
+// runStream delivers the packets listed in arrival, one per 20 ms tick with -1
+// for a tick where nothing arrives, and pops once per tick from the fourth
+// one on, so playout runs 60 ms behind the network, then drains the buffer.
+// It returns the samples per channel played.
+func runStream(t *testing.T, jitterBuffer *JitterBuffer, packets [][]byte, arrival []int) int {
+	t.Helper()
+
+	const lag = 3
+	out := make([]int16, testSampleRate*120/1000)
+	total := 0
+	for tick, index := range arrival {
+		if index >= 0 {
+			push(t, jitterBuffer, packets, index, time.Duration(tick-index)*20*time.Millisecond)
+		}
+		if tick >= lag {
+			n, err := jitterBuffer.Pop(out)
+			require.NoError(t, err)
+			total += n
+		}
+	}
+
+	return total + popAll(t, jitterBuffer)
+}
+
+func newTestJitterBuffer(t *testing.T) *JitterBuffer {
+	t.Helper()
+
+	jitterBuffer, err := New(testSampleRate, 1, WithMinDelay(60*time.Millisecond))
+	require.NoError(t, err)
+
+	return jitterBuffer
+}
+
+func TestInOrderStream(t *testing.T) {
+	packets := silkPackets(t, 10, true)
+	jitterBuffer := newTestJitterBuffer(t)
+
+	out := make([]int16, testSampleRate*120/1000)
+	n, err := jitterBuffer.Pop(out)
+	require.NoError(t, err)
+	assert.Equal(t, testFrameSamples, n, "silence while nothing is buffered")
+
+	assert.Equal(t, 10*testFrameSamples, runStream(t, jitterBuffer, packets, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}))
+
+	stats := jitterBuffer.Stats()
+	assert.Equal(t, uint64(10), stats.PacketsReceived)
+	assert.Zero(t, stats.PacketsLost)
+	assert.Zero(t, stats.PacketsLate)
+	assert.Zero(t, stats.PacketsDropped)
+	assert.Zero(t, stats.Concealed)
+	assert.Zero(t, stats.Jitter)
+	assert.Zero(t, stats.Delay)
+	assert.Equal(t, 60*time.Millisecond, stats.TargetDelay)
+}
+
+func TestReorderedPacketIsInserted(t *testing.T) {
+	packets := silkPackets(t, 6, true)
+	jitterBuffer := newTestJitterBuffer(t)
+
+	assert.Equal(t, 6*testFrameSamples, runStream(t, jitterBuffer, packets, []int{0, 2, 1, 3, 5, 4}))
+	require.NoError(t, jitterBuffer.Push(65533, 1000+3*testPacketTicks, packets[3], testEpoch))
+
+	stats := jitterBuffer.Stats()
+	assert.Zero(t, stats.PacketsLost)
+	assert.Equal(t, uint64(1), stats.PacketsLate, "only the duplicate of a played packet is late")
+	assert.Zero(t, stats.Concealed)
+}
+
+func TestLossRecoveredWithFEC(t *testing.T) {
+	packets := silkPackets(t, 8, true)
+	jitterBuffer := newTestJitterBuffer(t)
+
+	assert.Equal(t, 8*testFrameSamples, runStream(t, jitterBuffer, packets, []int{0, 1, 2, 3, -1, 5, 6, 7}))
+
+	stats := jitterBuffer.Stats()
+	assert.Equal(t, uint64(1), stats.PacketsLost)
+	assert.Equal(t, uint64(1), stats.PacketsRecovered)
+	assert.Zero(t, stats.Concealed)
+}
+
+func TestLossConcealedWithoutFEC(t *testing.T) {
+	packets := silkPackets(t, 8, false)
+	jitterBuffer := newTestJitterBuffer(t)
+
+	assert.Equal(t, 8*testFrameSamples, runStream(t, jitterBuffer, packets, []int{0, 1, 2, -1, -1, 5, 6, 7}))
+
+	stats := jitterBuffer.Stats()
+	assert.Equal(t, uint64(2), stats.PacketsLost)
+	assert.Zero(t, stats.PacketsRecovered)
+	assert.Equal(t, 40*time.Millisecond, stats.Concealed)
+}
+
+func TestLatePacketIsDiscarded(t *testing.T) {
+	packets := silkPackets(t, 6, true)
+	jitterBuffer := newTestJitterBuffer(t)
+
+	for i := range 4 {
+		if i != 2 {
+			push(t, jitterBuffer, packets, i, 0)
+		}
+	}
+	popAll(t, jitterBuffer)
+	push(t, jitterBuffer, packets, 2, 100*time.Millisecond)
+
+	stats := jitterBuffer.Stats()
+	assert.Equal(t, uint64(1), stats.PacketsLate)
+	assert.Equal(t, uint64(1), stats.PacketsLost)
+	assert.Empty(t, jitterBuffer.packets)
+}
+
+func TestUnderrunConcealsAndGrowsDelay(t *testing.T) {
+	packets := silkPackets(t, 4, true)
+	jitterBuffer, err := New(testSampleRate, 1)
+	require.NoError(t, err)
+
+	push(t, jitterBuffer, packets, 0, 0)
+	popAll(t, jitterBuffer)
+
+	out := make([]int16, testSampleRate*120/1000)
+	n, err := jitterBuffer.Pop(out)
+	require.NoError(t, err)
+	assert.Equal(t, testFrameSamples, n)
+	assert.Equal(t, 20*time.Millisecond, jitterBuffer.Stats().Concealed)
+
+	// The packet that was due during the underrun is still played.
+	push(t, jitterBuffer, packets, 1, 20*time.Millisecond)
+	assert.Equal(t, testFrameSamples, popAll(t, jitterBuffer))
+	assert.Zero(t, jitterBuffer.Stats().PacketsLate)
+}
+
+func TestJitterRaisesTargetDelay(t *testing.T) {
+	packets := silkPackets(t, 40, false)
+	jitterBuffer, err := New(testSampleRate, 1, WithMinDelay(20*time.Millisecond), WithMaxDelay(200*time.Millisecond))
+	require.NoError(t, err)
+
+	for i := range packets {
+		delay := time.Duration(0)
+		if i%2 == 1 {
+			delay = 30 * time.Millisecond
+		}
+		push(t, jitterBuffer, packets, i, delay)
+	}
+
+	stats := jitterBuffer.Stats()
+	assert.Greater(t, stats.Jitter, 20*time.Millisecond)
+	assert.Greater(t, stats.TargetDelay, 100*time.Millisecond)
+	assert.LessOrEqual(t, stats.TargetDelay, 200*time.Millisecond)
+}
+
+// burst pushes packets all at once, as after a stall, on a steady clock as
+// far as the jitter estimate can tell, and pops pops times.
+func burst(t *testing.T, jitterBuffer *JitterBuffer, packets [][]byte, pops int) {
+	t.Helper()
+
+	for i := range packets {
+		push(t, jitterBuffer, packets, i, 0)
+	}
+	out := make([]int16, testSampleRate*120/1000)
+	for range pops {
+		_, err := jitterBuffer.Pop(out)
+		require.NoError(t, err)
+	}
+}
+
+func TestExcessDelayIsAccelerated(t *testing.T) {
+	packets := silkPackets(t, 20, false)
+	jitterBuffer, err := New(testSampleRate, 1)
+	require.NoError(t, err)
+
+	burst(t, jitterBuffer, packets, 5)
+
+	stats := jitterBuffer.Stats()
+	assert.Zero(t, stats.PacketsDropped)
+	assert.Zero(t, stats.PacketsLost)
+	assert.Greater(t, stats.Accelerated, 20*time.Millisecond, "voiced audio loses pitch periods")
+	assert.LessOrEqual(t, stats.Accelerated, 50*time.Millisecond, "at most half of each packet")
+	assert.Equal(t, 300*time.Millisecond, stats.Delay)
+}
+
+func TestDelayBeyondMaxIsDropped(t *testing.T) {
+	packets := silkPackets(t, 20, false)
+	jitterBuffer, err := New(testSampleRate, 1, WithMaxDelay(200*time.Millisecond))
+	require.NoError(t, err)
+
+	burst(t, jitterBuffer, packets, 5)
+
+	stats := jitterBuffer.Stats()
+	assert.Equal(t, uint64(5), stats.PacketsDropped)
+	assert.Zero(t, stats.PacketsLost, "dropped packets are not lost")
+	assert.Zero(t, stats.Accelerated)
+	assert.Equal(t, 200*time.Millisecond, stats.Delay)
+}
+
+func TestOptions(t *testing.T) {
+	_, err := New(testSampleRate, 1, WithMinDelay(-time.Millisecond))
+	assert.ErrorIs(t, err, errInvalidDelay)
+
+	_, err = New(testSampleRate, 1, WithMaxDelay(0))
+	assert.ErrorIs(t, err, errInvalidDelay)
+
+	_, err = New(testSampleRate, 1, WithMinDelay(time.Second), WithMaxDelay(100*time.Millisecond))
+	assert.ErrorIs(t, err, errInvalidDelay)
+
+	_, err = New(testSampleRate, 1, WithMaxPackets(0))
+	assert.ErrorIs(t, err, errInvalidMaxPackets)
+
+	_, err = New(44050, 1)
+	assert.Error(t, err)
+
+	jitterBuffer, err := New(testSampleRate, 1, WithMaxPackets(1))
+	require.NoError(t, err)
+	packets := silkPackets(t, 2, false)
+	push(t, jitterBuffer, packets, 0, 0)
+	assert.ErrorIs(t, jitterBuffer.Push(1, 2000, packets[1], testEpoch), errBufferFull)
+
+	assert.Error(t, jitterBuffer.Push(2, 3000, nil, testEpoch))
+	_, err = jitterBuffer.Pop(make([]int16, 10))
+	assert.ErrorIs(t, err, errOutBufferTooSmall)
+}
@@ -0,0 +1,874 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+// Package mp4reader implements a reader for the Opus track of MP4 files,
+// as "Encapsulation of Opus in ISO Base Media File Format" describes it.
+//
+// The moov box gives the track: its dOps box, mapped onto the Opus ID
+// header, and its edit list. Packets then come from the sample table of the
+// moov box, which needs the input to be an io.ReadSeeker, and from the moof
+// and mdat boxes of fragmented files, which are read as they come.
+//
+// For gapless playback, drop decoded audio before Track.MediaTime, the
+// pre-skip, and past Track.MediaTime plus Track.Duration when the edit list
+// gives a duration.
+package mp4reader
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/pion/opus/internal/isobmff"
+	"github.com/pion/opus/pkg/oggreader"
+)
+
+const (
+	// maxBoxSize bounds the boxes read into memory whole: the moov box and
+	// the moof and mdat boxes of fragments.
+	maxBoxSize = 64 << 20
+
+	opusHeadVersion = 1
+	dOpsLength      = 11
+	// sampleEntryLength is the AudioSampleEntry fields before the boxes of
+	// the Opus sample entry.
+	sampleEntryLength = 28
+
+	// Flags of the track fragment and track run boxes.
+	tfhdBaseDataOffset         = 0x000001
+	tfhdSampleDescriptionIndex = 0x000002
+	tfhdDefaultSampleDuration  = 0x000008
+	tfhdDefaultSampleSize      = 0x000010
+	tfhdDefaultSampleFlags     = 0x000020
+	tfhdDefaultBaseIsMoof      = 0x020000
+	trunDataOffset             = 0x000001
+	trunFirstSampleFlags       = 0x000004
+	trunSampleDuration         = 0x000100
+	trunSampleSize             = 0x000200
+	trunSampleFlags            = 0x000400
+	trunSampleCompositionTime  = 0x000800
+)
+
+var (
+	errNilStream      = errors.New("stream is nil")
+	errNoMovie        = errors.New("missing moov box")
+	errNoOpusTrack    = errors.New("no Opus audio track")
+	errBoxTooLarge    = errors.New("box too large")
+	errMalformedBox   = errors.New("malformed box")
+	errInvalidDOps    = errors.New("invalid dOps box")
+	errNotSeekable    = errors.New("stream is not seekable")
+	errMissingSamples = errors.New("samples missing from the mdat boxes")
+)
+
+// Track describes the Opus track.
+type Track struct {
+	// ID is the track ID fragments refer to the track by.
+	ID uint32
+	// TimeScale is the number of track time units in a second.
+	TimeScale uint32
+	// Header is the Opus ID header the dOps box maps onto. Its Version is
+	// that of the Ogg header, 1.
+	Header oggreader.OggHeader
+	// ChannelMapping is the multistream channel mapping of the header, empty
+	// for mapping family 0.
+	ChannelMapping oggreader.OggChannelMapping
+	// MediaTime is where the edit list starts playback in the track, which
+	// skips the pre-skip. Without an edit list it is the pre-skip.
+	MediaTime time.Duration
+	// Duration is how long the edit list plays the track for, which trims
+	// its end, or 0 when it does not say.
+	Duration time.Duration
+}
+
+// Packet is an Opus packet of the track.
+type Packet struct {
+	Data []byte
+	// Timestamp is the packet's decode time in the track: MediaTime is yet
+	// to be skipped.
+	Timestamp time.Duration
+	// Duration is the duration of the packet's sample.
+	Duration time.Duration
+}
+
+type sample struct {
+	offset   int64 // in the file
+	size     uint32
+	time     uint64 // in track time units
+	duration uint32
+}
+
+// MP4Reader reads Opus packets from an MP4 or fragmented MP4 file.
+type MP4Reader struct {
+	buffered *bufio.Reader
+	position int64         // in the file, to find the offsets sample tables give
+	seeker   io.ReadSeeker // the input when it can seek
+
+	track           *Track
+	defaultDuration uint32
+	defaultSize     uint32
+
+	// movieSamples are those of the moov box's sample table yet to be read,
+	// and resume is where to go on with fragments after them.
+	movieSamples []sample
+	resume       int64
+
+	// samples are those of fragments whose mdat is yet to be read.
+	samples  []sample
+	pending  []*Packet
+	nextTime uint64 // decode time of a fragment without a tfdt box
+}
+
+// NewWith returns a new MP4 reader reading from in and the Opus track it
+// found. Files that are not fragmented need in to be an io.ReadSeeker.
+func NewWith(in io.Reader) (*MP4Reader, *Track, error) {
+	if in == nil {
+		return nil, nil, errNilStream
+	}
+
+	reader := &MP4Reader{buffered: bufio.NewReader(in)}
+	if seeker, ok := in.(io.ReadSeeker); ok {
+		if position, err := seeker.Seek(0, io.SeekCurrent); err == nil {
+			reader.seeker, reader.position = seeker, position
+		}
+	}
+
+	for {
+		boxType, size, err := reader.readHeader()
+		if errors.Is(err, io.EOF) {
+			return nil, nil, errNoMovie
+		} else if err != nil {
+			return nil, nil, err
+		}
+		if boxType != "moov" {
+			if err = reader.skip(size); err != nil {
+				return nil, nil, err
+			}
+
+			continue
+		}
+		data, err := reader.readData(boxType, size)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err = reader.parseMovie(data); err != nil {
+			return nil, nil, err
+		}
+
+		break
+	}
+	if len(reader.movieSamples) > 0 && reader.seeker == nil {
+		return nil, nil, errNotSeekable
+	}
+	reader.resume = reader.position
+
+	return reader, reader.track, nil
+}
+
+// readHeader reads the type and payload size of the next box.
+func (r *MP4Reader) readHeader() (string, int64, error) {
+	boxType, size, n, err := isobmff.ReadHeader(r.buffered)
+	r.position += int64(n)
+
+	return boxType, size, err
+}
+
+// readData reads the size bytes of a box's payload.
+func (r *MP4Reader) readData(boxType string, size int64) ([]byte, error) {
+	var data []byte
+	var err error
+	if size == isobmff.ToEnd {
+		data, err = io.ReadAll(io.LimitReader(r.buffered, maxBoxSize+1))
+		if err == nil && len(data) > maxBoxSize {
+			err = fmt.Errorf("%w: %s", errBoxTooLarge, boxType)
+		}
+	} else {
+		if size > maxBoxSize {
+			return nil, fmt.Errorf("%w: %s of %d bytes", errBoxTooLarge, boxType, size)
+		}
+		data = make([]byte, size)
+		var n int
+		n, err = io.ReadFull(r.buffered, data)
+		data = data[:n]
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+	}
+	r.position += int64(len(data))
+
+	return data, err
+}
+
+// skip skips the size bytes of a box's payload.
+func (r *MP4Reader) skip(size int64) error {
+	if r.seeker != nil {
+		if size == isobmff.ToEnd {
+			position, err := r.seeker.Seek(0, io.SeekEnd)
+			if err != nil {
+				return err
+			}
+			r.buffered.Reset(r.seeker)
+			r.position = position
+
+			return nil
+		}
+
+		return r.seek(r.position + size)
+	}
+	if size == isobmff.ToEnd {
+		n, err := io.Copy(io.Discard, r.buffered)
+		r.position += n
+
+		return err
+	}
+	for size > 0 {
+		n, err := r.buffered.Discard(int(min(size, math.MaxInt32)))
+		r.position += int64(n)
+		size -= int64(n)
+		if errors.Is(err, io.EOF) {
+			return io.ErrUnexpectedEOF
+		} else if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *MP4Reader) seek(position int64) error {
+	if _, err := r.seeker.Seek(position, io.SeekStart); err != nil {
+		return err
+	}
+	r.buffered.Reset(r.seeker)
+	r.position = position
+
+	return nil
+}
+
+func (r *MP4Reader) parseMovie(data []byte) error {
+	var (
+		movieTimeScale uint32
+		trackData      [][]byte
+		trex           = map[uint32][2]uint32{}
+	)
+	if err := isobmff.Children(data, func(boxType string, data []byte) error {
+		switch boxType {
+		case "mvhd":
+			timeScale, err := parseTimeScale(data)
+			movieTimeScale = timeScale
+
+			return err
+		case "trak":
+			trackData = append(trackData, data)
+		case "mvex":
+			return isobmff.Children(data, func(boxType string, data []byte) error {
+				if boxType != "trex" {
+					return nil
+				}
+				_, _, rest, err := isobmff.FullBox(data)
+				if err != nil || len(rest) < 20 {
+					return fmt.Errorf("%w: trex", errMalformedBox)
+				}
+				trex[binary.BigEndian.Uint32(rest)] = [2]uint32{
+					binary.BigEndian.Uint32(rest[8:]),
+					binary.BigEndian.Uint32(rest[12:]),
+				}
+
+				return nil
+			})
+		}
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	for _, data := range trackData {
+		if err := r.parseTrack(data, movieTimeScale); err != nil {
+			return err
+		}
+		if r.track != nil {
+			break
+		}
+	}
+	if r.track == nil {
+		return errNoOpusTrack
+	}
+	defaults := trex[r.track.ID]
+	r.defaultDuration, r.defaultSize = defaults[0], defaults[1]
+
+	return nil
+}
+
+// parseTimeScale parses the timescale of an mvhd or mdhd box.
+func parseTimeScale(data []byte) (uint32, error) {
+	version, _, rest, err := isobmff.FullBox(data)
+	offset := 8
+	if version == 1 {
+		offset = 16
+	}
+	if err != nil || len(rest) < offset+4 {
+		return 0, fmt.Errorf("%w: header without a timescale", errMalformedBox)
+	}
+
+	return binary.BigEndian.Uint32(rest[offset:]), nil
+}
+
+// trackBoxes gathers the boxes of a trak box the track is read from.
+type trackBoxes struct {
+	tkhd, elst, mdhd, hdlr, stsd []byte
+	stts, stsc, stsz, stz2, stco []byte
+	co64                         []byte
+}
+
+func collectTrackBoxes(data []byte, boxes *trackBoxes) error {
+	return isobmff.Children(data, func(boxType string, data []byte) error {
+		switch boxType {
+		case "edts", "mdia", "minf", "stbl":
+			return collectTrackBoxes(data, boxes)
+		case "tkhd":
+			boxes.tkhd = data
+		case "elst":
+			boxes.elst = data
+		case "mdhd":
+			boxes.mdhd = data
+		case "hdlr":
+			boxes.hdlr = data
+		case "stsd":
+			boxes.stsd = data
+		case "stts":
+			boxes.stts = data
+		case "stsc":
+			boxes.stsc = data
+		case "stsz":
+			boxes.stsz = data
+		case "stz2":
+			boxes.stz2 = data
+		case "stco":
+			boxes.stco = data
+		case "co64":
+			boxes.co64 = data
+		}
+
+		return nil
+	})
+}
+
+func (r *MP4Reader) parseTrack(data []byte, movieTimeScale uint32) error {
+	var boxes trackBoxes
+	if err := collectTrackBoxes(data, &boxes); err != nil {
+		return err
+	}
+	// The handler type follows the full box header and 4 reserved bytes.
+	if len(boxes.hdlr) < 12 || string(boxes.hdlr[8:12]) != "soun" {
+		return nil
+	}
+	dOps, err := findOpusSpecificBox(boxes.stsd)
+	if err != nil || dOps == nil {
+		return err
+	}
+
+	var track Track
+	if track.Header, track.ChannelMapping, err = parseOpusSpecificBox(dOps); err != nil {
+		return err
+	}
+	version, _, tkhd, err := isobmff.FullBox(boxes.tkhd)
+	idOffset := 8
+	if version == 1 {
+		idOffset = 16
+	}
+	if err != nil || len(tkhd) < idOffset+4 {
+		return fmt.Errorf("%w: tkhd", errMalformedBox)
+	}
+	track.ID = binary.BigEndian.Uint32(tkhd[idOffset:])
+	if track.TimeScale, err = parseTimeScale(boxes.mdhd); err != nil {
+		return err
+	}
+	if track.TimeScale == 0 {
+		return fmt.Errorf("%w: timescale of 0", errMalformedBox)
+	}
+
+	track.MediaTime = toDuration(uint64(track.Header.PreSkip), 48000)
+	if boxes.elst != nil {
+		if err = parseEditList(boxes.elst, &track, movieTimeScale); err != nil {
+			return err
+		}
+	}
+
+	if r.movieSamples, err = buildSamples(&boxes); err != nil {
+		return err
+	}
+	r.track = &track
+
+	return nil
+}
+
+// findOpusSpecificBox returns the dOps box of the Opus sample entry of stsd,
+// or nil when it has none.
+func findOpusSpecificBox(stsd []byte) ([]byte, error) {
+	_, _, rest, err := isobmff.FullBox(stsd)
+	if err != nil || len(rest) < 4 {
+		return nil, fmt.Errorf("%w: stsd", errMalformedBox)
+	}
+	var dOps []byte
+	err = isobmff.Children(rest[4:], func(boxType string, data []byte) error {
+		if boxType != "Opus" || dOps != nil {
+			return nil
+		}
+		if len(data) < sampleEntryLength {
+			return fmt.Errorf("%w: Opus sample entry", errMalformedBox)
+		}
+
+		return isobmff.Children(data[sampleEntryLength:], func(boxType string, data []byte) error {
+			if boxType == "dOps" {
+				dOps = data
+			}
+
+			return nil
+		})
+	})
+
+	return dOps, err
+}
+
+// parseOpusSpecificBox maps a dOps box, which holds the fields of the Opus ID
+// header in big-endian order, onto the header.
+func parseOpusSpecificBox(data []byte) (oggreader.OggHeader, oggreader.OggChannelMapping, error) {
+	var mapping oggreader.OggChannelMapping
+	if len(data) < dOpsLength || data[0] != 0 {
+		return oggreader.OggHeader{}, mapping, errInvalidDOps
+	}
+	header := oggreader.OggHeader{
+		Version:    opusHeadVersion,
+		Channels:   data[1],
+		PreSkip:    binary.BigEndian.Uint16(data[2:]),
+		SampleRate: binary.BigEndian.Uint32(data[4:]),
+		OutputGain: binary.BigEndian.Uint16(data[8:]),
+		ChannelMap: data[10],
+	}
+	if header.ChannelMap == 0 {
+		return header, mapping, nil
+	}
+	if len(data) < dOpsLength+2+int(header.Channels) {
+		return oggreader.OggHeader{}, mapping, errInvalidDOps
+	}
+	mapping.StreamCount = data[dOpsLength]
+	mapping.CoupledCount = data[dOpsLength+1]
+	mapping.Mapping = append([]uint8(nil), data[dOpsLength+2:dOpsLength+2+int(header.Channels)]...)
+
+	return header, mapping, nil
+}
+
+// parseEditList takes the first edit that plays media, past any empty edit
+// that delays the track.
+func parseEditList(data []byte, track *Track, movieTimeScale uint32) error {
+	version, _, rest, err := isobmff.FullBox(data)
+	if err != nil || len(rest) < 4 {
+		return fmt.Errorf("%w: elst", errMalformedBox)
+	}
+	entryLength := 12
+	if version == 1 {
+		entryLength = 20
+	}
+	count := int(binary.BigEndian.Uint32(rest))
+	rest = rest[4:]
+	if len(rest)/entryLength < count {
+		return fmt.Errorf("%w: elst", errMalformedBox)
+	}
+	for i := range count {
+		entry := rest[i*entryLength:]
+		var duration uint64
+		var mediaTime int64
+		if version == 1 {
+			duration = binary.BigEndian.Uint64(entry)
+			mediaTime = int64(binary.BigEndian.Uint64(entry[8:])) //nolint:gosec // G115
+		} else {
+			duration = uint64(binary.BigEndian.Uint32(entry))
+			mediaTime = int64(int32(binary.BigEndian.Uint32(entry[4:]))) //nolint:gosec // G115
+		}
+		if mediaTime < 0 {
+			continue
+		}
+		track.MediaTime = toDuration(uint64(mediaTime), track.TimeScale)
+		if movieTimeScale != 0 {
+			track.Duration = toDuration(duration, movieTimeScale)
+		}
+
+		break
+	}
+
+	return nil
+}
+
+// buildSamples lists the samples the sample table of a track gives.
+func buildSamples(boxes *trackBoxes) ([]sample, error) {
+	sizes, err := parseSampleSizes(boxes)
+	if err != nil || len(sizes) == 0 {
+		return nil, err
+	}
+	chunks, err := parseChunkOffsets(boxes)
+	if err != nil {
+		return nil, err
+	}
+	_, _, stsc, err := isobmff.FullBox(boxes.stsc)
+	if err != nil || len(stsc) < 4 || (len(stsc)-4)/12 < int(binary.BigEndian.Uint32(stsc)) {
+		return nil, fmt.Errorf("%w: stsc", errMalformedBox)
+	}
+	_, _, stts, err := isobmff.FullBox(boxes.stts)
+	if err != nil || len(stts) < 4 || (len(stts)-4)/8 < int(binary.BigEndian.Uint32(stts)) {
+		return nil, fmt.Errorf("%w: stts", errMalformedBox)
+	}
+
+	samples := make([]sample, len(sizes))
+	next := 0
+	stscCount := int(binary.BigEndian.Uint32(stsc))
+	for entry := range stscCount {
+		first := int(binary.BigEndian.Uint32(stsc[4+entry*12:]))
+		perChunk := int(binary.BigEndian.Uint32(stsc[8+entry*12:]))
+		last := len(chunks) + 1
+		if entry+1 < stscCount {
+			last = int(binary.BigEndian.Uint32(stsc[4+(entry+1)*12:]))
+		}
+		for chunk := first; chunk < last && next < len(samples); chunk++ {
+			if chunk < 1 || chunk > len(chunks) {
+				return nil, fmt.Errorf("%w: stsc chunk %d", errMalformedBox, chunk)
+			}
+			offset := chunks[chunk-1]
+			for range min(perChunk, len(samples)-next) {
+				samples[next].offset, samples[next].size = offset, sizes[next]
+				offset += int64(sizes[next])
+				next++
+			}
+		}
+	}
+	if next < len(samples) {
+		return nil, fmt.Errorf("%w: %d samples in no chunk", errMalformedBox, len(samples)-next)
+	}
+
+	var time uint64
+	next = 0
+	for entry := range int(binary.BigEndian.Uint32(stts)) {
+		count := int(binary.BigEndian.Uint32(stts[4+entry*8:]))
+		delta := binary.BigEndian.Uint32(stts[8+entry*8:])
+		for range min(count, len(samples)-next) {
+			samples[next].time, samples[next].duration = time, delta
+			time += uint64(delta)
+			next++
+		}
+	}
+
+	return samples, nil
+}
+
+func parseSampleSizes(boxes *trackBoxes) ([]uint32, error) {
+	if boxes.stz2 != nil {
+		_, _, rest, err := isobmff.FullBox(boxes.stz2)
+		if err != nil || len(rest) < 8 {
+			return nil, fmt.Errorf("%w: stz2", errMalformedBox)
+		}
+		fieldSize := int(rest[3])
+		count := int(binary.BigEndian.Uint32(rest[4:]))
+		if (fieldSize != 4 && fieldSize != 8 && fieldSize != 16) || (len(rest)-8)*8/fieldSize < count {
+			return nil, fmt.Errorf("%w: stz2", errMalformedBox)
+		}
+		sizes := make([]uint32, count)
+		for i := range sizes {
+			switch fieldSize {
+			case 4:
+				sizes[i] = uint32(rest[8+i/2]>>(4*(1-i%2))) & 0x0f
+			case 8:
+				sizes[i] = uint32(rest[8+i])
+			case 16:
+				sizes[i] = uint32(binary.BigEndian.Uint16(rest[8+2*i:]))
+			}
+		}
+
+		return sizes, nil
+	}
+
+	_, _, rest, err := isobmff.FullBox(boxes.stsz)
+	if err != nil || len(rest) < 8 {
+		return nil, fmt.Errorf("%w: stsz", errMalformedBox)
+	}
+	size := binary.BigEndian.Uint32(rest)
+	count := int(binary.BigEndian.Uint32(rest[4:]))
+	if size == 0 && (len(rest)-8)/4 < count {
+		return nil, fmt.Errorf("%w: stsz", errMalformedBox)
+	}
+	sizes := make([]uint32, count)
+	for i := range sizes {
+		if size != 0 {
+			sizes[i] = size
+		} else {
+			sizes[i] = binary.BigEndian.Uint32(rest[8+4*i:])
+		}
+	}
+
+	return sizes, nil
+}
+
+func parseChunkOffsets(boxes *trackBoxes) ([]int64, error) {
+	box, name, width := boxes.stco, "stco", 4
+	if boxes.co64 != nil {
+		box, name, width = boxes.co64, "co64", 8
+	}
+	_, _, rest, err := isobmff.FullBox(box)
+	if err != nil || len(rest) < 4 || (len(rest)-4)/width < int(binary.BigEndian.Uint32(rest)) {
+		return nil, fmt.Errorf("%w: %s", errMalformedBox, name)
+	}
+	offsets := make([]int64, binary.BigEndian.Uint32(rest))
+	for i := range offsets {
+		if width == 8 {
+			offsets[i] = int64(binary.BigEndian.Uint64(rest[4+8*i:])) //nolint:gosec // G115
+		} else {
+			offsets[i] = int64(binary.BigEndian.Uint32(rest[4+4*i:]))
+		}
+	}
+
+	return offsets, nil
+}
+
+// toDuration converts a time in units of timeScale per second.
+func toDuration(units uint64, timeScale uint32) time.Duration {
+	scale := uint64(timeScale)
+
+	return time.Duration(units/scale)*time.Second + //nolint:gosec // G115
+		time.Duration(units%scale*uint64(time.Second)/scale) //nolint:gosec // G115
+}
+
+// ParseNextPacket returns the next packet of the Opus track, and io.EOF at
+// the end of the file.
+func (r *MP4Reader) ParseNextPacket() (*Packet, error) {
+	if len(r.movieSamples) > 0 {
+		return r.readMovieSample()
+	}
+
+	for len(r.pending) == 0 {
+		start := r.position
+		boxType, size, err := r.readHeader()
+		if errors.Is(err, io.EOF) && len(r.samples) > 0 {
+			return nil, fmt.Errorf("%w: %d samples", errMissingSamples, len(r.samples))
+		} else if err != nil {
+			return nil, err
+		}
+		switch {
+		case boxType == "moof":
+			data, err := r.readData(boxType, size)
+			if err != nil {
+				return nil, err
+			}
+			if err = r.parseFragment(data, start); err != nil {
+				return nil, err
+			}
+		case boxType == "mdat" && len(r.samples) > 0:
+			dataStart := r.position
+			data, err := r.readData(boxType, size)
+			if err != nil {
+				return nil, err
+			}
+			r.takeSamples(data, dataStart)
+		default:
+			if err = r.skip(size); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	packet := r.pending[0]
+	r.pending = r.pending[1:]
+
+	return packet, nil
+}
+
+func (r *MP4Reader) packet(s sample, data []byte) *Packet {
+	return &Packet{
+		Data:      data,
+		Timestamp: toDuration(s.time, r.track.TimeScale),
+		Duration:  toDuration(uint64(s.duration), r.track.TimeScale),
+	}
+}
+
+// readMovieSample reads the next sample of the moov box's sample table, and
+// goes back to reading fragments after the last one.
+func (r *MP4Reader) readMovieSample() (*Packet, error) {
+	next := r.movieSamples[0]
+	r.movieSamples = r.movieSamples[1:]
+	if next.size > maxBoxSize {
+		return nil, fmt.Errorf("%w: sample of %d bytes", errBoxTooLarge, next.size)
+	}
+	if _, err := r.seeker.Seek(next.offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	data := make([]byte, next.size)
+	if _, err := io.ReadFull(r.seeker, data); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+
+		return nil, err
+	}
+	if len(r.movieSamples) == 0 {
+		if err := r.seek(r.resume); err != nil {
+			return nil, err
+		}
+	}
+
+	return r.packet(next, data), nil
+}
+
+// takeSamples queues the samples whose data lies in the mdat box payload
+// data, which starts at dataStart in the file.
+func (r *MP4Reader) takeSamples(data []byte, dataStart int64) {
+	rest := r.samples[:0]
+	for _, s := range r.samples {
+		start := s.offset - dataStart
+		if start < 0 || start > int64(len(data)) || int64(s.size) > int64(len(data))-start {
+			rest = append(rest, s)
+
+			continue
+		}
+		r.pending = append(r.pending, r.packet(s, data[start:start+int64(s.size)]))
+	}
+	r.samples = rest
+}
+
+// parseFragment lists the samples of the track in a moof box that starts at
+// moofStart in the file.
+func (r *MP4Reader) parseFragment(data []byte, moofStart int64) error {
+	// The data of a track fragment without a base follows that of the one
+	// before it.
+	dataEnd := moofStart
+
+	return isobmff.Children(data, func(boxType string, data []byte) error {
+		if boxType != "traf" {
+			return nil
+		}
+
+		return r.parseTrackFragment(data, moofStart, &dataEnd)
+	})
+}
+
+func (r *MP4Reader) parseTrackFragment(data []byte, moofStart int64, dataEnd *int64) error {
+	var tfhd, tfdt []byte
+	var truns [][]byte
+	if err := isobmff.Children(data, func(boxType string, data []byte) error {
+		switch boxType {
+		case "tfhd":
+			tfhd = data
+		case "tfdt":
+			tfdt = data
+		case "trun":
+			truns = append(truns, data)
+		}
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	_, flags, rest, err := isobmff.FullBox(tfhd)
+	if err != nil {
+		return fmt.Errorf("%w: tfhd", errMalformedBox)
+	}
+	var trackID uint32
+	base := *dataEnd
+	defaultDuration, defaultSize := r.defaultDuration, r.defaultSize
+	for _, field := range []struct {
+		flag   uint32
+		length int
+		value  func([]byte)
+	}{
+		{0, 4, func(b []byte) { trackID = binary.BigEndian.Uint32(b) }},
+		{tfhdBaseDataOffset, 8, func(b []byte) { base = int64(binary.BigEndian.Uint64(b)) }}, //nolint:gosec // G115
+		{tfhdSampleDescriptionIndex, 4, func([]byte) {}},
+		{tfhdDefaultSampleDuration, 4, func(b []byte) { defaultDuration = binary.BigEndian.Uint32(b) }},
+		{tfhdDefaultSampleSize, 4, func(b []byte) { defaultSize = binary.BigEndian.Uint32(b) }},
+		{tfhdDefaultSampleFlags, 4, func([]byte) {}},
+	} {
+		if field.flag != 0 && flags&field.flag == 0 {
+			continue
+		}
+		if len(rest) < field.length {
+			return fmt.Errorf("%w: tfhd", errMalformedBox)
+		}
+		field.value(rest)
+		rest = rest[field.length:]
+	}
+	if flags&tfhdBaseDataOffset == 0 && flags&tfhdDefaultBaseIsMoof != 0 {
+		base = moofStart
+	}
+
+	time := r.nextTime
+	if tfdt != nil {
+		version, _, rest, err := isobmff.FullBox(tfdt)
+		switch {
+		case err == nil && version == 1 && len(rest) >= 8:
+			time = binary.BigEndian.Uint64(rest)
+		case err == nil && version == 0 && len(rest) >= 4:
+			time = uint64(binary.BigEndian.Uint32(rest))
+		default:
+			return fmt.Errorf("%w: tfdt", errMalformedBox)
+		}
+	}
+
+	offset := base
+	var samples []sample
+	for _, trun := range truns {
+		_, flags, rest, err := isobmff.FullBox(trun)
+		if err != nil || len(rest) < 4 {
+			return fmt.Errorf("%w: trun", errMalformedBox)
+		}
+		count := int(binary.BigEndian.Uint32(rest))
+		rest = rest[4:]
+		if flags&trunDataOffset != 0 {
+			if len(rest) < 4 {
+				return fmt.Errorf("%w: trun", errMalformedBox)
+			}
+			offset = base + int64(int32(binary.BigEndian.Uint32(rest))) //nolint:gosec // G115
+			rest = rest[4:]
+		}
+		if flags&trunFirstSampleFlags != 0 {
+			if len(rest) < 4 {
+				return fmt.Errorf("%w: trun", errMalformedBox)
+			}
+			rest = rest[4:]
+		}
+		sampleLength := 0
+		for _, flag := range []uint32{trunSampleDuration, trunSampleSize, trunSampleFlags, trunSampleCompositionTime} {
+			if flags&flag != 0 {
+				sampleLength += 4
+			}
+		}
+		if sampleLength > 0 && len(rest)/sampleLength < count {
+			return fmt.Errorf("%w: trun of %d samples", errMalformedBox, count)
+		}
+		for range count {
+			s := sample{offset: offset, size: defaultSize, time: time, duration: defaultDuration}
+			if flags&trunSampleDuration != 0 {
+				s.duration, rest = binary.BigEndian.Uint32(rest), rest[4:]
+			}
+			if flags&trunSampleSize != 0 {
+				s.size, rest = binary.BigEndian.Uint32(rest), rest[4:]
+			}
+			if flags&trunSampleFlags != 0 {
+				rest = rest[4:]
+			}
+			if flags&trunSampleCompositionTime != 0 {
+				rest = rest[4:]
+			}
+			samples = append(samples, s)
+			offset += int64(s.size)
+			time += uint64(s.duration)
+		}
+	}
+	*dataEnd = offset
+	if trackID == r.track.ID {
+		r.samples = append(r.samples, samples...)
+		r.nextTime = time
+	}
+
+	return nil
+}
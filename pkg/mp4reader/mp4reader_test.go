@@ -0,0 +1,216 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package mp4reader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/pion/opus/internal/isobmff"
+	"github.com/pion/opus/pkg/oggreader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func uint32s(values ...uint32) []byte {
+	var b []byte
+	for _, value := range values {
+		b = binary.BigEndian.AppendUint32(b, value)
+	}
+
+	return b
+}
+
+// testPacket is a 20 ms CELT-only packet whose payload tells it apart.
+func testPacket(i int) []byte {
+	return []byte{0xf8, byte(i), byte(i >> 8)}
+}
+
+// opusSampleEntry is an Opus sample entry with the dOps box of a stereo
+// header with a pre-skip of 312.
+func opusSampleEntry() []byte {
+	entry := make([]byte, sampleEntryLength)
+	dOps := []byte{0, 2, 0x01, 0x38, 0, 0, 0xac, 0x44, 0xff, 0x00, 0}
+
+	return isobmff.AppendBox(nil, "Opus", entry, isobmff.AppendBox(nil, "dOps", dOps))
+}
+
+// track builds a trak box with a version 0 tkhd, mdhd and elst, and the boxes
+// of its sample table.
+func track(id uint32, handler string, sampleEntry []byte, elst []byte, table ...[]byte) []byte {
+	stsd := isobmff.AppendFullBox(nil, "stsd", 0, 0, uint32s(1), sampleEntry)
+	mdia := isobmff.AppendBox(nil, "mdia",
+		isobmff.AppendFullBox(nil, "mdhd", 0, 0, uint32s(0, 0, 48000, 0, 0)),
+		isobmff.AppendFullBox(nil, "hdlr", 0, 0, uint32s(0), []byte(handler), make([]byte, 13)),
+		isobmff.AppendBox(nil, "minf", isobmff.AppendBox(nil, "stbl", append([][]byte{stsd}, table...)...)),
+	)
+	boxes := [][]byte{isobmff.AppendFullBox(nil, "tkhd", 0, 3, uint32s(0, 0, id, 0, 0), make([]byte, 60))}
+	if elst != nil {
+		boxes = append(boxes, isobmff.AppendBox(nil, "edts", elst))
+	}
+
+	return isobmff.AppendBox(nil, "trak", append(boxes, mdia)...)
+}
+
+func movieHeader() []byte {
+	return isobmff.AppendFullBox(nil, "mvhd", 0, 0, uint32s(0, 0, 1000, 0), make([]byte, 80))
+}
+
+func readAll(t *testing.T, reader *MP4Reader) []*Packet {
+	t.Helper()
+
+	var packets []*Packet
+	for {
+		packet, err := reader.ParseNextPacket()
+		if errors.Is(err, io.EOF) {
+			return packets
+		}
+		require.NoError(t, err)
+		packets = append(packets, packet)
+	}
+}
+
+// testMovie builds an MP4 file with its mdat before its moov box, holding
+// five packets in two chunks, after a video track.
+func testMovie() []byte {
+	ftyp := isobmff.AppendBox(nil, "ftyp", []byte("isom\x00\x00\x00\x00isom"))
+	var payload []byte
+	for i := range 5 {
+		payload = append(payload, testPacket(i)...)
+		payload = append(payload, make([]byte, i)...)
+	}
+	file := isobmff.AppendBox(ftyp, "mdat", payload)
+	dataStart := uint32(len(ftyp) + 8) //nolint:gosec // G115
+
+	// An empty edit delays the track, then it plays 90 ms past the pre-skip.
+	elst := isobmff.AppendFullBox(nil, "elst", 0, 0, uint32s(2, 10, 0xffffffff, 0x10000, 90, 312, 0x10000))
+	opus := track(2, "soun", opusSampleEntry(), elst,
+		isobmff.AppendFullBox(nil, "stts", 0, 0, uint32s(1, 5, 960)),
+		isobmff.AppendFullBox(nil, "stsc", 0, 0, uint32s(2, 1, 2, 1, 2, 3, 1)),
+		isobmff.AppendFullBox(nil, "stsz", 0, 0, uint32s(0, 5, 3, 4, 5, 6, 7)),
+		isobmff.AppendFullBox(nil, "stco", 0, 0, uint32s(2, dataStart, dataStart+7)),
+	)
+	video := track(1, "vide", isobmff.AppendBox(nil, "avc1"), nil)
+
+	return isobmff.AppendBox(file, "moov", movieHeader(), video, opus)
+}
+
+func TestMP4Reader_Movie(t *testing.T) {
+	reader, track, err := NewWith(bytes.NewReader(testMovie()))
+	require.NoError(t, err)
+	assert.Equal(t, &Track{
+		ID:        2,
+		TimeScale: 48000,
+		Header: oggreader.OggHeader{
+			Version:    1,
+			Channels:   2,
+			PreSkip:    312,
+			SampleRate: 44100,
+			OutputGain: 0xff00,
+		},
+		MediaTime: 6500 * time.Microsecond,
+		Duration:  90 * time.Millisecond,
+	}, track)
+
+	packets := readAll(t, reader)
+	require.Len(t, packets, 5)
+	for i, packet := range packets {
+		assert.Equal(t, append(testPacket(i), make([]byte, i)...), packet.Data)
+		assert.Equal(t, time.Duration(i)*20*time.Millisecond, packet.Timestamp)
+		assert.Equal(t, 20*time.Millisecond, packet.Duration)
+	}
+
+	_, _, err = NewWith(io.MultiReader(bytes.NewReader(testMovie())))
+	assert.ErrorIs(t, err, errNotSeekable)
+}
+
+func TestMP4Reader_Fragments(t *testing.T) {
+	trex := isobmff.AppendFullBox(nil, "trex", 0, 0, uint32s(1, 1, 960, 3, 0))
+	moov := isobmff.AppendBox(nil, "moov", movieHeader(),
+		track(1, "soun", opusSampleEntry(), nil,
+			isobmff.AppendFullBox(nil, "stts", 0, 0, uint32s(0)),
+			isobmff.AppendFullBox(nil, "stsc", 0, 0, uint32s(0)),
+			isobmff.AppendFullBox(nil, "stsz", 0, 0, uint32s(0, 0)),
+			isobmff.AppendFullBox(nil, "stco", 0, 0, uint32s(0)),
+		),
+		isobmff.AppendBox(nil, "mvex", trex),
+	)
+
+	// A fragment using the trex defaults and the moof as base, without a
+	// tfdt box, then one with its own sizes and decode time.
+	fragment := func(sequence uint32, tfdt []byte, trun []byte, packets ...int) []byte {
+		boxes := [][]byte{isobmff.AppendFullBox(nil, "tfhd", 0, 0, uint32s(1))}
+		if tfdt != nil {
+			boxes = append(boxes, tfdt)
+		}
+		boxes = append(boxes, trun)
+		moof := isobmff.AppendBox(nil, "moof",
+			isobmff.AppendFullBox(nil, "mfhd", 0, 0, uint32s(sequence)),
+			isobmff.AppendBox(nil, "traf", boxes...),
+		)
+		var payload []byte
+		for _, i := range packets {
+			payload = append(payload, testPacket(i)...)
+		}
+		// The data offset is that of the trun box's first sample, which
+		// is the start of the mdat payload.
+		offset := len(moof) + 8
+		binary.BigEndian.PutUint32(moof[len(moof)-len(trun)+16:], uint32(offset)) //nolint:gosec // G115
+
+		return isobmff.AppendBox(moof, "mdat", payload)
+	}
+	file := append([]byte(nil), moov...)
+	file = append(file, fragment(1, nil,
+		isobmff.AppendFullBox(nil, "trun", 0, trunDataOffset, uint32s(2, 0)), 0, 1)...)
+	file = append(file, fragment(2, isobmff.AppendFullBox(nil, "tfdt", 1, 0, uint32s(0, 4800)),
+		isobmff.AppendFullBox(nil, "trun", 0, trunDataOffset|trunSampleDuration|trunSampleSize,
+			uint32s(1, 0, 480, 3)), 2)...)
+
+	reader, track, err := NewWith(io.MultiReader(bytes.NewReader(file)))
+	require.NoError(t, err)
+	assert.Equal(t, 6500*time.Microsecond, track.MediaTime, "the pre-skip without an edit list")
+	assert.Zero(t, track.Duration)
+
+	packets := readAll(t, reader)
+	require.Len(t, packets, 3)
+	for i, want := range []Packet{
+		{Data: testPacket(0), Timestamp: 0, Duration: 20 * time.Millisecond},
+		{Data: testPacket(1), Timestamp: 20 * time.Millisecond, Duration: 20 * time.Millisecond},
+		{Data: testPacket(2), Timestamp: 100 * time.Millisecond, Duration: 10 * time.Millisecond},
+	} {
+		assert.Equal(t, want, *packets[i])
+	}
+
+	// A fragment whose mdat is cut off.
+	reader, _, err = NewWith(bytes.NewReader(file[:len(file)-4]))
+	require.NoError(t, err)
+	_, err = reader.ParseNextPacket()
+	require.NoError(t, err)
+	_, err = reader.ParseNextPacket()
+	require.NoError(t, err)
+	_, err = reader.ParseNextPacket()
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
+
+func TestMP4Reader_Errors(t *testing.T) {
+	_, _, err := NewWith(nil)
+	assert.ErrorIs(t, err, errNilStream)
+
+	_, _, err = NewWith(bytes.NewReader(isobmff.AppendBox(nil, "ftyp", []byte("isom\x00\x00\x00\x00"))))
+	assert.ErrorIs(t, err, errNoMovie)
+
+	video := track(1, "vide", isobmff.AppendBox(nil, "avc1"), nil)
+	_, _, err = NewWith(bytes.NewReader(isobmff.AppendBox(nil, "moov", movieHeader(), video)))
+	assert.ErrorIs(t, err, errNoOpusTrack)
+
+	badEntry := isobmff.AppendBox(nil, "Opus", make([]byte, sampleEntryLength),
+		isobmff.AppendBox(nil, "dOps", []byte{1, 2, 0, 0}))
+	opus := track(1, "soun", badEntry, nil)
+	_, _, err = NewWith(bytes.NewReader(isobmff.AppendBox(nil, "moov", movieHeader(), opus)))
+	assert.ErrorIs(t, err, errInvalidDOps)
+}
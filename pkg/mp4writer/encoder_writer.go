@@ -0,0 +1,60 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package mp4writer
+
+import (
+	"errors"
+	"io"
+
+	"github.com/pion/opus"
+	"github.com/pion/opus/internal/encoderwriter"
+	"github.com/pion/opus/pkg/oggreader"
+)
+
+var errNilEncoder = errors.New("encoder is nil")
+
+// EncoderWriter is an io.WriteCloser that encodes interleaved S16LE PCM with
+// an opus.Encoder and writes the packets to a fragmented MP4 file.
+//
+// Writes of any length are buffered into whole encoder frames. The edit list
+// skips the encoder's lookahead, and Close pads the last partial frame; when
+// the output can seek, the edit list trims that padding off again, leaving
+// exactly the samples that were written.
+type EncoderWriter struct {
+	writer *encoderwriter.Writer
+}
+
+// NewEncoderWriter writes the initialization segment for encoder's
+// configuration to out and returns a writer that accepts its PCM. opts
+// configure the underlying MP4Writer.
+func NewEncoderWriter(out io.Writer, encoder *opus.Encoder, opts ...Option) (*EncoderWriter, error) {
+	if encoder == nil {
+		return nil, errNilEncoder
+	}
+
+	mp4, err := NewWith(out, oggreader.OggHeader{
+		Channels:   uint8(encoder.Channels()),              // #nosec G115 -- the encoder allows 1 or 2 channels.
+		PreSkip:    uint16(encoderwriter.PreSkip(encoder)), // #nosec G115 -- the lookahead is a few milliseconds.
+		SampleRate: uint32(encoder.SampleRate()),           // #nosec G115
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EncoderWriter{writer: encoderwriter.New(mp4, encoder)}, nil
+}
+
+// Write buffers p, which holds interleaved S16LE samples, and encodes every
+// frame it completes. A sample may be split across calls.
+func (w *EncoderWriter) Write(p []byte) (int, error) {
+	return w.writer.Write(p)
+}
+
+// Close encodes the buffered partial frame, padded with silence, plus as
+// many silent frames as the lookahead needs to flush the last input samples
+// out of the encoder. It then closes the MP4Writer. Close does not close the
+// io.Writer passed to NewEncoderWriter.
+func (w *EncoderWriter) Close() error {
+	return w.writer.Close()
+}
@@ -0,0 +1,46 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package mp4writer
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/pion/opus"
+	"github.com/pion/opus/pkg/mp4reader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncoderWriter(t *testing.T) {
+	for _, channels := range []int{1, 2} {
+		encoder, err := opus.NewEncoder(opus.WithChannels(channels))
+		require.NoError(t, err)
+
+		var out seekBuffer
+		writer, err := NewEncoderWriter(&out, encoder)
+		require.NoError(t, err)
+
+		const samples = 72000 + 333
+		_, err = writer.Write(make([]byte, samples*channels*2))
+		require.NoError(t, err)
+		require.NoError(t, writer.Close())
+
+		// The edit list plays exactly the input.
+		reader, track, err := mp4reader.NewWith(bytes.NewReader(out.data))
+		require.NoError(t, err)
+		preSkip := encoder.Lookahead()
+		assert.Equal(t, uint8(channels), track.Header.Channels) //nolint:gosec // 1 or 2.
+		assert.Equal(t, uint16(preSkip), track.Header.PreSkip)  //nolint:gosec // G115
+		assert.Equal(t, time.Duration(preSkip)*time.Second/48000, track.MediaTime)
+		assert.Equal(t, time.Duration(samples)*time.Second/48000, track.Duration)
+		assert.Len(t, readAll(t, reader), (preSkip+samples+encoder.FrameSize()-1)/encoder.FrameSize())
+	}
+}
+
+func TestEncoderWriterNilEncoder(t *testing.T) {
+	_, err := NewEncoderWriter(&bytes.Buffer{}, nil)
+	assert.ErrorIs(t, err, errNilEncoder)
+}
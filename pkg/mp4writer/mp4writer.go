@@ -0,0 +1,451 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+// Package mp4writer implements a writer of fragmented MP4 files with one Opus
+// track, as "Encapsulation of Opus in ISO Base Media File Format" lays them
+// out and DASH and HLS serve them.
+//
+// NewWith writes the initialization segment: an ftyp box and a moov box whose
+// Opus sample entry carries the dOps box, and whose edit list skips the
+// pre-skip. Packets are then gathered into fragments, a moof box and its mdat
+// each, of up to MaxFragmentDuration. A fragment is also ended by a gap in
+// the granule positions, and by Flush: taking the output after NewWith and
+// after each Flush splits it into the initialization segment and media
+// segments.
+//
+// When the output can seek, Close fills in the durations of the moov box and
+// the edit list, which then trims the end of the last packet too. Otherwise
+// they are left 0 and the edit runs to the end of the track.
+package mp4writer
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/pion/opus"
+	"github.com/pion/opus/internal/isobmff"
+	"github.com/pion/opus/pkg/oggreader"
+	"github.com/pion/opus/pkg/oggwriter"
+)
+
+const (
+	trackID        = 1
+	opusSampleRate = 48000 // the timescale of the movie and the track
+
+	defaultMaxFragmentDuration = 2 * time.Second
+
+	// Flags of the track fragment and track run boxes.
+	tfhdDefaultBaseIsMoof = 0x020000
+	trunDataOffset        = 0x000001
+	trunSampleDuration    = 0x000100
+	trunSampleSize        = 0x000200
+
+	// Offsets, from the start of their version 1 boxes, of the durations
+	// Close fills in.
+	mvhdDurationOffset = 32
+	tkhdDurationOffset = 36
+	elstDurationOffset = 16
+
+	// languageUndetermined is "und" packed into three 5-bit letters.
+	languageUndetermined = 0x55C4
+)
+
+var (
+	errNilStream                  = errors.New("stream is nil")
+	errWriterClosed               = errors.New("writer is closed")
+	errInvalidChannelCount        = errors.New("channel count must be positive")
+	errInvalidMaxFragmentDuration = errors.New("max fragment duration must be positive")
+	errGranulePositionRewinded    = errors.New("granule position must not decrease")
+	errInvalidPacket              = errors.New("invalid Opus packet")
+)
+
+// unityMatrix is the transformation matrix of mvhd and tkhd.
+//
+//nolint:gochecknoglobals
+var unityMatrix = []uint32{0x00010000, 0, 0, 0, 0x00010000, 0, 0, 0, 0x40000000}
+
+// MP4Writer is used to write Opus packets into a fragmented MP4 file.
+type MP4Writer struct {
+	stream io.Writer
+	fd     *os.File
+	// seeker is the stream when it can seek, and fileStart the position the
+	// file starts at in it.
+	seeker    io.WriteSeeker
+	fileStart int64
+
+	channelMapping      oggreader.OggChannelMapping
+	maxFragmentDuration uint64 // in 48 kHz samples
+	preSkip             uint64
+	closed              bool
+
+	written         int64
+	durationOffsets []int64 // of the durations Close fills in
+
+	// The fragment being assembled.
+	sequence      uint32
+	fragmentStart uint64
+	sizes         []uint32
+	durations     []uint32
+	data          []byte
+
+	end               uint64 // in 48 kHz samples, where the last packet ends
+	lastGranule       uint64
+	hasWrittenPackets bool
+}
+
+// Option configures an MP4Writer during construction.
+type Option func(*MP4Writer) error
+
+// WithChannelMapping sets the stream count, coupled count and mapping table
+// written for channel mapping families other than 0.
+func WithChannelMapping(mapping oggreader.OggChannelMapping) Option {
+	return func(w *MP4Writer) error {
+		w.channelMapping = oggreader.OggChannelMapping{
+			StreamCount:  mapping.StreamCount,
+			CoupledCount: mapping.CoupledCount,
+			Mapping:      append([]uint8(nil), mapping.Mapping...),
+		}
+
+		return nil
+	}
+}
+
+// WithMaxFragmentDuration bounds how much audio is gathered into one fragment
+// before it is written out.
+func WithMaxFragmentDuration(duration time.Duration) Option {
+	return func(w *MP4Writer) error {
+		if duration <= 0 {
+			return errInvalidMaxFragmentDuration
+		}
+		w.maxFragmentDuration = uint64(duration.Nanoseconds()) * opusSampleRate / uint64(time.Second)
+
+		return nil
+	}
+}
+
+// New builds a new fragmented MP4 writer that writes to fileName.
+func New(fileName string, header oggreader.OggHeader, opts ...Option) (*MP4Writer, error) {
+	fd, err := os.Create(fileName) // #nosec G304
+	if err != nil {
+		return nil, err
+	}
+	writer, err := NewWith(fd, header, opts...)
+	if err != nil {
+		return nil, errors.Join(err, fd.Close())
+	}
+	writer.fd = fd
+
+	return writer, nil
+}
+
+// NewWith builds a new fragmented MP4 writer on top of out and writes the
+// initialization segment.
+//
+// header is written as the dOps box, and its PreSkip as the start of the
+// edit list. header.SampleRate of 0 is written as 48000. A header.ChannelMap
+// other than 0 needs WithChannelMapping.
+func NewWith(out io.Writer, header oggreader.OggHeader, opts ...Option) (*MP4Writer, error) {
+	if out == nil {
+		return nil, errNilStream
+	}
+
+	writer := &MP4Writer{
+		stream:              out,
+		maxFragmentDuration: uint64(defaultMaxFragmentDuration.Nanoseconds()) * opusSampleRate / uint64(time.Second),
+		preSkip:             uint64(header.PreSkip),
+		sequence:            1,
+	}
+	for _, opt := range opts {
+		if err := opt(writer); err != nil {
+			return nil, err
+		}
+	}
+	if header.Channels == 0 {
+		return nil, errInvalidChannelCount
+	}
+	if seeker, ok := out.(io.WriteSeeker); ok {
+		// Files opened on pipes are io.WriteSeekers that fail to seek.
+		if position, err := seeker.Seek(0, io.SeekCurrent); err == nil {
+			writer.seeker, writer.fileStart = seeker, position
+		}
+	}
+
+	if err := writer.writeInitSegment(header); err != nil {
+		return nil, err
+	}
+
+	return writer, nil
+}
+
+// opusSpecificBox builds the dOps box, which holds the fields of the Opus ID
+// header in big-endian order.
+func opusSpecificBox(header oggreader.OggHeader, mapping oggreader.OggChannelMapping) ([]byte, error) {
+	opusHead, err := oggwriter.OpusHead(header, mapping)
+	if err != nil {
+		return nil, err
+	}
+	payload := []byte{0, opusHead[9]}
+	payload = binary.BigEndian.AppendUint16(payload, binary.LittleEndian.Uint16(opusHead[10:]))
+	payload = binary.BigEndian.AppendUint32(payload, binary.LittleEndian.Uint32(opusHead[12:]))
+	payload = binary.BigEndian.AppendUint16(payload, binary.LittleEndian.Uint16(opusHead[16:]))
+	payload = append(payload, opusHead[18:]...)
+
+	return isobmff.AppendBox(nil, "dOps", payload), nil
+}
+
+func (w *MP4Writer) writeInitSegment(header oggreader.OggHeader) error {
+	dOps, err := opusSpecificBox(header, w.channelMapping)
+	if err != nil {
+		return err
+	}
+
+	ftyp := isobmff.AppendBox(nil, "ftyp", []byte("iso6\x00\x00\x00\x00iso6mp41dashOpus"))
+
+	mvhd := make([]byte, 0, 108)
+	mvhd = binary.BigEndian.AppendUint64(mvhd, 0) // creation time
+	mvhd = binary.BigEndian.AppendUint64(mvhd, 0) // modification time
+	mvhd = binary.BigEndian.AppendUint32(mvhd, opusSampleRate)
+	mvhd = binary.BigEndian.AppendUint64(mvhd, 0)          // duration
+	mvhd = binary.BigEndian.AppendUint32(mvhd, 0x00010000) // rate 1.0
+	mvhd = binary.BigEndian.AppendUint16(mvhd, 0x0100)     // volume 1.0
+	mvhd = append(mvhd, make([]byte, 10)...)
+	mvhd = appendMatrix(mvhd)
+	mvhd = append(mvhd, make([]byte, 24)...)
+	mvhd = binary.BigEndian.AppendUint32(mvhd, trackID+1)
+
+	tkhd := make([]byte, 0, 92)
+	tkhd = binary.BigEndian.AppendUint64(tkhd, 0) // creation time
+	tkhd = binary.BigEndian.AppendUint64(tkhd, 0) // modification time
+	tkhd = binary.BigEndian.AppendUint32(tkhd, trackID)
+	tkhd = binary.BigEndian.AppendUint32(tkhd, 0)
+	tkhd = binary.BigEndian.AppendUint64(tkhd, 0) // duration
+	tkhd = append(tkhd, make([]byte, 12)...)      // reserved, layer and alternate group
+	tkhd = binary.BigEndian.AppendUint16(tkhd, 0x0100)
+	tkhd = binary.BigEndian.AppendUint16(tkhd, 0)
+	tkhd = appendMatrix(tkhd)
+	tkhd = append(tkhd, make([]byte, 8)...) // width and height
+
+	// The edit starts playback past the pre-skip ("Encapsulation of Opus in
+	// ISO Base Media File Format" Section 4.4).
+	elst := binary.BigEndian.AppendUint32(nil, 1)
+	elst = binary.BigEndian.AppendUint64(elst, 0) // segment duration
+	elst = binary.BigEndian.AppendUint64(elst, w.preSkip)
+	elst = binary.BigEndian.AppendUint32(elst, 0x00010000) // media rate 1.0
+
+	mdhd := make([]byte, 0, 32)
+	mdhd = binary.BigEndian.AppendUint64(mdhd, 0) // creation time
+	mdhd = binary.BigEndian.AppendUint64(mdhd, 0) // modification time
+	mdhd = binary.BigEndian.AppendUint32(mdhd, opusSampleRate)
+	mdhd = binary.BigEndian.AppendUint64(mdhd, 0) // duration
+	mdhd = binary.BigEndian.AppendUint16(mdhd, languageUndetermined)
+	mdhd = binary.BigEndian.AppendUint16(mdhd, 0)
+
+	hdlr := append(make([]byte, 4), "soun"...)
+	hdlr = append(hdlr, make([]byte, 12)...)
+	hdlr = append(hdlr, "SoundHandler\x00"...)
+
+	entry := make([]byte, 6, 28)
+	entry = binary.BigEndian.AppendUint16(entry, 1) // data reference index
+	entry = append(entry, make([]byte, 8)...)
+	entry = binary.BigEndian.AppendUint16(entry, uint16(header.Channels))
+	entry = binary.BigEndian.AppendUint16(entry, 16) // sample size
+	entry = binary.BigEndian.AppendUint32(entry, 0)
+	entry = binary.BigEndian.AppendUint32(entry, opusSampleRate<<16)
+	stsd := isobmff.AppendFullBox(nil, "stsd", 0, 0, binary.BigEndian.AppendUint32(nil, 1),
+		isobmff.AppendBox(nil, "Opus", entry, dOps))
+
+	empty := make([]byte, 4)
+	stbl := isobmff.AppendBox(nil, "stbl",
+		stsd,
+		isobmff.AppendFullBox(nil, "stts", 0, 0, empty),
+		isobmff.AppendFullBox(nil, "stsc", 0, 0, empty),
+		isobmff.AppendFullBox(nil, "stsz", 0, 0, empty, empty),
+		isobmff.AppendFullBox(nil, "stco", 0, 0, empty),
+	)
+	dinf := isobmff.AppendBox(nil, "dinf", isobmff.AppendFullBox(nil, "dref", 0, 0,
+		binary.BigEndian.AppendUint32(nil, 1), isobmff.AppendFullBox(nil, "url ", 0, 1)))
+	minf := isobmff.AppendBox(nil, "minf", isobmff.AppendFullBox(nil, "smhd", 0, 0, empty), dinf, stbl)
+	mdia := isobmff.AppendBox(nil, "mdia",
+		isobmff.AppendFullBox(nil, "mdhd", 1, 0, mdhd),
+		isobmff.AppendFullBox(nil, "hdlr", 0, 0, hdlr),
+		minf,
+	)
+
+	trex := binary.BigEndian.AppendUint32(nil, trackID)
+	trex = binary.BigEndian.AppendUint32(trex, 1) // sample description index
+	trex = append(trex, make([]byte, 12)...)
+
+	// Where the durations fall, counted from the start of the moov box.
+	mvhdBox := isobmff.AppendFullBox(nil, "mvhd", 1, 0, mvhd)
+	tkhdBox := isobmff.AppendFullBox(nil, "tkhd", 1, 0x000003, tkhd) // enabled, in movie
+	moovStart := int64(len(ftyp))
+	tkhdStart := moovStart + 8 + int64(len(mvhdBox)) + 8
+	w.durationOffsets = []int64{
+		moovStart + 8 + mvhdDurationOffset,
+		tkhdStart + tkhdDurationOffset,
+		tkhdStart + int64(len(tkhdBox)) + 8 + elstDurationOffset,
+	}
+	segment := isobmff.AppendBox(ftyp, "moov",
+		mvhdBox,
+		isobmff.AppendBox(nil, "trak",
+			tkhdBox,
+			isobmff.AppendBox(nil, "edts", isobmff.AppendFullBox(nil, "elst", 1, 0, elst)),
+			mdia,
+		),
+		isobmff.AppendBox(nil, "mvex", isobmff.AppendFullBox(nil, "trex", 0, 0, trex)),
+	)
+
+	return w.write(segment)
+}
+
+func appendMatrix(b []byte) []byte {
+	for _, value := range unityMatrix {
+		b = binary.BigEndian.AppendUint32(b, value)
+	}
+
+	return b
+}
+
+func (w *MP4Writer) write(data []byte) error {
+	n, err := w.stream.Write(data)
+	w.written += int64(n)
+
+	return err
+}
+
+// WritePacket writes one Opus packet whose last sample ends at
+// granulePosition, in 48 kHz samples from the start of the stream, the
+// pre-skip among them.
+//
+// Every sample lasts as long as its packet decodes to, so the only trimming
+// is the edit list's: its media_time skips the pre-skip, and its duration,
+// which Close fills in when the output can seek, ends playback at the last
+// granulePosition. On an output that cannot seek the last packet plays in
+// full. A packet starts its own duration before granulePosition, so a gap in
+// the positions starts a fragment at a later decode time, unless it would
+// overlap the packet before, in which case it starts where that one ends.
+func (w *MP4Writer) WritePacket(packet []byte, granulePosition uint64) error {
+	if w.closed {
+		return errWriterClosed
+	}
+	if w.hasWrittenPackets && granulePosition < w.lastGranule {
+		return errGranulePositionRewinded
+	}
+	samples, err := opus.PacketSampleCount(packet, opusSampleRate)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errInvalidPacket, err)
+	}
+
+	start := w.end
+	if granulePosition >= start+uint64(samples) { //nolint:gosec // G115
+		start = granulePosition - uint64(samples) //nolint:gosec // G115
+	}
+	// A gap starts a fragment, whose decode time the tfdt box gives.
+	if len(w.sizes) > 0 && (start != w.end || start-w.fragmentStart >= w.maxFragmentDuration) {
+		if err = w.flushFragment(); err != nil {
+			return err
+		}
+	}
+	if len(w.sizes) == 0 {
+		w.fragmentStart = start
+	}
+	w.sizes = append(w.sizes, uint32(len(packet)))     //nolint:gosec // G115
+	w.durations = append(w.durations, uint32(samples)) //nolint:gosec // G115
+	w.data = append(w.data, packet...)
+
+	w.end = start + uint64(samples) //nolint:gosec // G115
+	w.lastGranule = granulePosition
+	w.hasWrittenPackets = true
+
+	return nil
+}
+
+// Flush writes out the open fragment, if any, so everything written so far is
+// on the underlying stream.
+func (w *MP4Writer) Flush() error {
+	if w.closed {
+		return errWriterClosed
+	}
+
+	return w.flushFragment()
+}
+
+func (w *MP4Writer) flushFragment() error {
+	if len(w.sizes) == 0 {
+		return nil
+	}
+
+	mfhd := binary.BigEndian.AppendUint32(nil, w.sequence)
+	tfhd := binary.BigEndian.AppendUint32(nil, trackID)
+	tfdt := binary.BigEndian.AppendUint64(nil, w.fragmentStart)
+	trun := binary.BigEndian.AppendUint32(nil, uint32(len(w.sizes))) //nolint:gosec // G115
+	trun = binary.BigEndian.AppendUint32(trun, 0)                    // data offset, set below
+	for i, size := range w.sizes {
+		trun = binary.BigEndian.AppendUint32(trun, w.durations[i])
+		trun = binary.BigEndian.AppendUint32(trun, size)
+	}
+	moof := isobmff.AppendBox(nil, "moof",
+		isobmff.AppendFullBox(nil, "mfhd", 0, 0, mfhd),
+		isobmff.AppendBox(nil, "traf",
+			isobmff.AppendFullBox(nil, "tfhd", 0, tfhdDefaultBaseIsMoof, tfhd),
+			isobmff.AppendFullBox(nil, "tfdt", 1, 0, tfdt),
+			isobmff.AppendFullBox(nil, "trun", 0, trunDataOffset|trunSampleDuration|trunSampleSize, trun),
+		),
+	)
+	// The samples start right after the mdat header that follows the moof,
+	// which holds the data offset in the last 4 bytes before its samples.
+	dataOffsetAt := len(moof) - len(trun) + 4
+	binary.BigEndian.PutUint32(moof[dataOffsetAt:], uint32(len(moof)+8)) //nolint:gosec // G115
+
+	w.sequence++
+	w.sizes = w.sizes[:0]
+	w.durations = w.durations[:0]
+	fragment := isobmff.AppendBox(moof, "mdat", w.data)
+	w.data = w.data[:0]
+
+	return w.write(fragment)
+}
+
+// Close writes the open fragment and fills in the durations when the output
+// can seek. If the writer was created with New, the file is closed too.
+func (w *MP4Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	err := w.finish()
+	if w.fd != nil {
+		err = errors.Join(err, w.fd.Close())
+	}
+
+	return err
+}
+
+func (w *MP4Writer) finish() error {
+	if err := w.flushFragment(); err != nil {
+		return err
+	}
+	if w.seeker == nil || !w.hasWrittenPackets {
+		return nil
+	}
+
+	// The movie plays from the end of the pre-skip to the last granule
+	// position.
+	duration := binary.BigEndian.AppendUint64(nil, w.lastGranule-min(w.preSkip, w.lastGranule))
+	for _, offset := range w.durationOffsets {
+		if _, err := w.seeker.Seek(w.fileStart+offset, io.SeekStart); err != nil {
+			return err
+		}
+		if _, err := w.seeker.Write(duration); err != nil {
+			return err
+		}
+	}
+	_, err := w.seeker.Seek(w.fileStart+w.written, io.SeekStart)
+
+	return err
+}
@@ -0,0 +1,316 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package mp4writer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pion/opus/internal/isobmff"
+	"github.com/pion/opus/pkg/mp4reader"
+	"github.com/pion/opus/pkg/oggreader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seekBuffer is an in-memory io.WriteSeeker.
+type seekBuffer struct {
+	data     []byte
+	position int64
+}
+
+func (s *seekBuffer) Write(p []byte) (int, error) {
+	if end := s.position + int64(len(p)); end > int64(len(s.data)) {
+		s.data = append(s.data, make([]byte, end-int64(len(s.data)))...)
+	}
+	n := copy(s.data[s.position:], p)
+	s.position += int64(n)
+
+	return n, nil
+}
+
+func (s *seekBuffer) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += s.position
+	case io.SeekEnd:
+		offset += int64(len(s.data))
+	}
+	s.position = offset
+
+	return offset, nil
+}
+
+// testPacket is a 20 ms CELT-only packet whose payload tells it apart.
+func testPacket(i int) []byte {
+	return []byte{0xf8, byte(i), byte(i >> 8)}
+}
+
+func readAll(t *testing.T, reader *mp4reader.MP4Reader) []*mp4reader.Packet {
+	t.Helper()
+
+	var packets []*mp4reader.Packet
+	for {
+		packet, err := reader.ParseNextPacket()
+		if errors.Is(err, io.EOF) {
+			return packets
+		}
+		require.NoError(t, err)
+		packets = append(packets, packet)
+	}
+}
+
+// movieDurations are the durations Close fills in when it can seek, and
+// where the edit list starts playback.
+type movieDurations struct {
+	movie, track, edit uint64
+	mediaTime          int64
+}
+
+// readMovieDurations reads the durations out of the moov box of file.
+func readMovieDurations(t *testing.T, file []byte) movieDurations {
+	t.Helper()
+
+	var durations movieDurations
+	fullBox := func(data []byte) []byte {
+		version, _, rest, err := isobmff.FullBox(data)
+		require.NoError(t, err)
+		require.Equal(t, uint8(1), version)
+
+		return rest
+	}
+	var walk func(boxType string, data []byte) error
+	walk = func(boxType string, data []byte) error {
+		switch boxType {
+		case "moov", "trak", "edts":
+			return isobmff.Children(data, walk)
+		case "mvhd":
+			durations.movie = binary.BigEndian.Uint64(fullBox(data)[20:])
+		case "tkhd":
+			durations.track = binary.BigEndian.Uint64(fullBox(data)[24:])
+		case "elst":
+			elst := fullBox(data)
+			require.Equal(t, uint32(1), binary.BigEndian.Uint32(elst))
+			durations.edit = binary.BigEndian.Uint64(elst[4:])
+			durations.mediaTime = int64(binary.BigEndian.Uint64(elst[12:])) //nolint:gosec // G115
+		}
+
+		return nil
+	}
+	require.NoError(t, isobmff.Children(file, walk))
+
+	return durations
+}
+
+// writeEditListFile writes 1 s of 20 ms packets, the last of them trimmed to
+// 5 ms.
+func writeEditListFile(t *testing.T, out io.Writer) {
+	t.Helper()
+
+	writer, err := NewWith(out, oggreader.OggHeader{Channels: 2, PreSkip: 312})
+	require.NoError(t, err)
+	for i := range 49 {
+		require.NoError(t, writer.WritePacket(testPacket(i), uint64(i+1)*960)) //nolint:gosec // G115
+	}
+	require.NoError(t, writer.WritePacket(testPacket(49), 49*960+240))
+	require.NoError(t, writer.Close())
+	require.NoError(t, writer.Close())
+}
+
+// TestMP4Writer_EditList checks that the edit list skips the pre-skip
+// whatever the output, and trims the end of the last packet only where Close
+// can go back to fill in its duration.
+func TestMP4Writer_EditList(t *testing.T) {
+	const played = 49*960 + 240 - 312
+
+	var streamed bytes.Buffer
+	writeEditListFile(t, &streamed)
+
+	// A pipe is an io.WriteSeeker too, but one whose Seek fails.
+	pipeReader, pipeWriter, err := os.Pipe()
+	require.NoError(t, err)
+	piped := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(pipeReader)
+		piped <- data
+	}()
+	writeEditListFile(t, pipeWriter)
+	require.NoError(t, pipeWriter.Close())
+	pipedFile := <-piped
+	require.NoError(t, pipeReader.Close())
+
+	// An output that already holds something is patched where the file starts.
+	prefix := []byte("not part of the file")
+	seeker := &seekBuffer{data: bytes.Clone(prefix), position: int64(len(prefix))}
+	writeEditListFile(t, seeker)
+	assert.Equal(t, int64(len(seeker.data)), seeker.position, "Close leaves the output at its end")
+	require.Equal(t, prefix, seeker.data[:len(prefix)])
+
+	for name, test := range map[string]struct {
+		file      []byte
+		durations movieDurations
+	}{
+		"buffer": {streamed.Bytes(), movieDurations{mediaTime: 312}},
+		"pipe":   {pipedFile, movieDurations{mediaTime: 312}},
+		"seeker": {seeker.data[len(prefix):], movieDurations{played, played, played, 312}},
+	} {
+		assert.Equal(t, test.durations, readMovieDurations(t, test.file), name)
+
+		reader, track, err := mp4reader.NewWith(bytes.NewReader(test.file))
+		require.NoError(t, err, name)
+		assert.Equal(t, 6500*time.Microsecond, track.MediaTime, name)
+		assert.Equal(t, time.Duration(test.durations.edit)*time.Second/48000, track.Duration, name)
+		// The samples keep the duration of their packets, the last one too.
+		packets := readAll(t, reader)
+		require.Len(t, packets, 50, name)
+		for i, packet := range packets {
+			assert.Equal(t, testPacket(i), packet.Data, name)
+			assert.Equal(t, time.Duration(i)*20*time.Millisecond, packet.Timestamp, name)
+			assert.Equal(t, 20*time.Millisecond, packet.Duration, name)
+		}
+	}
+}
+
+// TestMP4Writer_EmptySeekable checks that Close leaves the durations of a
+// file without packets 0, even when it can seek.
+func TestMP4Writer_EmptySeekable(t *testing.T) {
+	var out seekBuffer
+	writer, err := NewWith(&out, oggreader.OggHeader{Channels: 1, PreSkip: 312})
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	assert.Equal(t, movieDurations{mediaTime: 312}, readMovieDurations(t, out.data))
+}
+
+// TestMP4Writer_Overlap checks that a packet whose granule position leaves
+// it overlapping the one before follows it at its full duration, and that
+// only the last granule position ends the edit.
+func TestMP4Writer_Overlap(t *testing.T) {
+	var out seekBuffer
+	writer, err := NewWith(&out, oggreader.OggHeader{Channels: 1, PreSkip: 312})
+	require.NoError(t, err)
+	require.NoError(t, writer.WritePacket(testPacket(0), 960))
+	require.NoError(t, writer.WritePacket(testPacket(1), 960+480))
+	require.NoError(t, writer.WritePacket(testPacket(2), 3*960))
+	require.NoError(t, writer.Close())
+
+	reader, track, err := mp4reader.NewWith(bytes.NewReader(out.data))
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(3*960-312)*time.Second/48000, track.Duration)
+	packets := readAll(t, reader)
+	require.Len(t, packets, 3)
+	for i, packet := range packets {
+		assert.Equal(t, time.Duration(i)*20*time.Millisecond, packet.Timestamp)
+		assert.Equal(t, 20*time.Millisecond, packet.Duration)
+	}
+}
+
+func TestMP4Writer_Segments(t *testing.T) {
+	var out bytes.Buffer
+	writer, err := NewWith(&out, oggreader.OggHeader{Channels: 1, PreSkip: 312})
+	require.NoError(t, err)
+	initSegment := bytes.Clone(out.Bytes())
+	out.Reset()
+
+	reader, _, err := mp4reader.NewWith(bytes.NewReader(initSegment))
+	require.NoError(t, err)
+	assert.Empty(t, readAll(t, reader))
+
+	var segments [][]byte
+	for segment := range 3 {
+		for i := range 10 {
+			packet := segment*10 + i
+			require.NoError(t, writer.WritePacket(testPacket(packet), uint64(packet+1)*960)) //nolint:gosec // G115
+		}
+		require.NoError(t, writer.Flush())
+		segments = append(segments, bytes.Clone(out.Bytes()))
+		out.Reset()
+	}
+	require.NoError(t, writer.Close())
+	assert.Zero(t, out.Len())
+
+	// Each media segment can be played after the initialization segment.
+	for i, segment := range segments {
+		assert.Equal(t, "moof", string(segment[4:8]))
+		reader, _, err := mp4reader.NewWith(bytes.NewReader(append(bytes.Clone(initSegment), segment...)))
+		require.NoError(t, err)
+		packets := readAll(t, reader)
+		require.Len(t, packets, 10)
+		assert.Equal(t, testPacket(i*10), packets[0].Data)
+		assert.Equal(t, time.Duration(i)*200*time.Millisecond, packets[0].Timestamp)
+	}
+}
+
+func TestMP4Writer_Gap(t *testing.T) {
+	var out bytes.Buffer
+	writer, err := NewWith(&out, oggreader.OggHeader{Channels: 1})
+	require.NoError(t, err)
+	require.NoError(t, writer.WritePacket(testPacket(0), 960))
+	require.NoError(t, writer.WritePacket(testPacket(1), 960+48000+960))
+	require.NoError(t, writer.Close())
+
+	reader, _, err := mp4reader.NewWith(&out)
+	require.NoError(t, err)
+	packets := readAll(t, reader)
+	require.Len(t, packets, 2)
+	assert.Zero(t, packets[0].Timestamp)
+	assert.Equal(t, 1020*time.Millisecond, packets[1].Timestamp)
+}
+
+func TestMP4Writer_ChannelMapping(t *testing.T) {
+	var out bytes.Buffer
+	mapping := oggreader.OggChannelMapping{StreamCount: 2, CoupledCount: 1, Mapping: []uint8{0, 1, 2}}
+	writer, err := NewWith(&out, oggreader.OggHeader{Channels: 3, ChannelMap: 1}, WithChannelMapping(mapping))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	_, track, err := mp4reader.NewWith(&out)
+	require.NoError(t, err)
+	assert.Equal(t, uint8(1), track.Header.ChannelMap)
+	assert.Equal(t, uint32(48000), track.Header.SampleRate)
+	assert.Equal(t, mapping, track.ChannelMapping)
+}
+
+func TestMP4Writer_Errors(t *testing.T) {
+	_, err := NewWith(nil, oggreader.OggHeader{Channels: 1})
+	assert.ErrorIs(t, err, errNilStream)
+
+	_, err = NewWith(io.Discard, oggreader.OggHeader{})
+	assert.ErrorIs(t, err, errInvalidChannelCount)
+
+	_, err = NewWith(io.Discard, oggreader.OggHeader{Channels: 1}, WithMaxFragmentDuration(0))
+	assert.ErrorIs(t, err, errInvalidMaxFragmentDuration)
+
+	writer, err := NewWith(io.Discard, oggreader.OggHeader{Channels: 1})
+	require.NoError(t, err)
+	assert.ErrorIs(t, writer.WritePacket(nil, 960), errInvalidPacket)
+	require.NoError(t, writer.WritePacket([]byte{0xf8}, 960))
+	assert.ErrorIs(t, writer.WritePacket([]byte{0xf8}, 480), errGranulePositionRewinded)
+
+	require.NoError(t, writer.Close())
+	assert.ErrorIs(t, writer.WritePacket([]byte{0xf8}, 1920), errWriterClosed)
+	assert.ErrorIs(t, writer.Flush(), errWriterClosed)
+}
+
+func TestMP4Writer_New(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "out.mp4")
+	writer, err := New(fileName, oggreader.OggHeader{Channels: 1})
+	require.NoError(t, err)
+	require.NoError(t, writer.WritePacket([]byte{0xf8}, 960))
+	require.NoError(t, writer.Close())
+
+	file, err := os.Open(fileName) // #nosec G304
+	require.NoError(t, err)
+	defer func() { require.NoError(t, file.Close()) }()
+	reader, track, err := mp4reader.NewWith(file)
+	require.NoError(t, err)
+	assert.Equal(t, 20*time.Millisecond, track.Duration)
+	assert.Len(t, readAll(t, reader), 1)
+}
@@ -0,0 +1,405 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+// Package mpegtsreader implements a demuxer for Opus carried in MPEG-2
+// transport streams.
+//
+// It finds the Opus stream through the program association and program map
+// tables: a private PES stream with an "Opus" registration descriptor, whose
+// DVB extension descriptor gives its channel configuration. It then
+// reassembles the stream's PES packets and splits them into access units,
+// each an Opus control header and the packet it frames.
+//
+// For gapless playback, drop each Packet's StartTrim of decoded audio at its
+// start and EndTrim at its end. Transport streams carry no pre-skip in their
+// descriptors; it is the StartTrim of the first packets.
+package mpegtsreader
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/pion/opus"
+	"github.com/pion/opus/internal/mpegts"
+	"github.com/pion/opus/pkg/oggreader"
+)
+
+const (
+	opusSampleRate = 48000
+	ptsClockRate   = 90000
+	ptsWrap        = 1 << 33
+
+	pesHeaderLength     = 9
+	pesPTSFlag          = 0x80
+	psiHeaderLength     = 8
+	defaultChannelsCode = 2 // stereo, for streams without an extension descriptor
+)
+
+var (
+	errNilStream     = errors.New("stream is nil")
+	errLostSync      = errors.New("transport stream packet without a sync byte")
+	errNoOpusStream  = errors.New("no Opus stream")
+	errMalformedTS   = errors.New("malformed transport stream packet")
+	errMalformedPSI  = errors.New("malformed PSI section")
+	errPSIChecksum   = errors.New("PSI section checksum mismatch")
+	errMalformedPES  = errors.New("malformed PES packet")
+	errMalformedUnit = errors.New("malformed Opus access unit")
+)
+
+// Track describes the Opus stream.
+type Track struct {
+	// PID is the PID of the stream's transport stream packets, and
+	// ProgramNumber that of the program it belongs to.
+	PID           uint16
+	ProgramNumber uint16
+	// Header is the Opus ID header the channel configuration stands for,
+	// with a SampleRate of 48000 and a PreSkip of 0.
+	Header oggreader.OggHeader
+	// ChannelMapping is the multistream channel mapping of the header, empty
+	// for mapping family 0.
+	ChannelMapping oggreader.OggChannelMapping
+}
+
+// Packet is an Opus packet of the stream.
+type Packet struct {
+	Data []byte
+	// Timestamp is the PTS of the packet's PES packet, advanced by the
+	// packets before it in the same PES packet.
+	Timestamp time.Duration
+	// StartTrim and EndTrim are the 48 kHz samples to drop from the start and
+	// the end of the decoded packet.
+	StartTrim, EndTrim int
+}
+
+// TSReader reads Opus packets from an MPEG-2 transport stream.
+type TSReader struct {
+	input  *bufio.Reader
+	packet [mpegts.PacketSize]byte
+	track  *Track
+
+	programs map[uint16]uint16 // program numbers by PMT PID
+
+	// The PES packet being reassembled, and the continuity counter its next
+	// transport stream packet carries.
+	pes        []byte
+	assembling bool
+	continuity byte
+
+	pending       []*Packet
+	nextTimestamp time.Duration
+	lastPTS       int64 // unwrapped, or -1 before the first
+}
+
+// NewWith returns a new transport stream reader reading from in and the
+// first Opus stream it found.
+func NewWith(in io.Reader) (*TSReader, *Track, error) {
+	if in == nil {
+		return nil, nil, errNilStream
+	}
+
+	reader := &TSReader{
+		input:    bufio.NewReader(in),
+		programs: map[uint16]uint16{},
+		lastPTS:  -1,
+	}
+	for reader.track == nil {
+		pid, start, payload, err := reader.readPacket()
+		if errors.Is(err, io.EOF) {
+			return nil, nil, errNoOpusStream
+		} else if err != nil {
+			return nil, nil, err
+		}
+		if !start {
+			continue
+		}
+		if pid == mpegts.PIDPAT {
+			err = reader.parsePAT(payload)
+		} else if program, ok := reader.programs[pid]; ok {
+			err = reader.parsePMT(payload, program)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return reader, reader.track, nil
+}
+
+// readPacket reads the next transport stream packet and returns its PID,
+// whether it starts a payload unit, and its payload.
+func (r *TSReader) readPacket() (pid uint16, start bool, payload []byte, err error) {
+	n, err := io.ReadFull(r.input, r.packet[:])
+	if err != nil {
+		if n > 0 {
+			err = io.ErrUnexpectedEOF
+		}
+
+		return 0, false, nil, err
+	}
+	packet := r.packet[:]
+	if packet[0] != mpegts.SyncByte {
+		return 0, false, nil, errLostSync
+	}
+	start = packet[1]&0x40 != 0
+	pid = uint16(packet[1]&0x1F)<<8 | uint16(packet[2])
+	control := packet[3] >> 4 & 0x03
+	payload = packet[4:]
+	if control&0x02 != 0 {
+		length := int(payload[0])
+		if 1+length > len(payload) {
+			return 0, false, nil, fmt.Errorf("%w: adaptation field of %d bytes", errMalformedTS, length)
+		}
+		payload = payload[1+length:]
+	}
+	if control&0x01 == 0 {
+		payload = nil
+	}
+	if pid == r.trackPID() {
+		r.checkContinuity(packet[3]&0x0F, len(payload) > 0, start)
+	}
+
+	return pid, start, payload, nil
+}
+
+func (r *TSReader) trackPID() uint16 {
+	if r.track == nil {
+		return 0x1FFF // the null packet PID, never the track's
+	}
+
+	return r.track.PID
+}
+
+// checkContinuity drops the PES packet being reassembled when a transport
+// stream packet of it went missing.
+func (r *TSReader) checkContinuity(counter byte, hasPayload, start bool) {
+	if !hasPayload {
+		return
+	}
+	if r.assembling && !start && counter != r.continuity {
+		r.assembling, r.pes = false, r.pes[:0]
+	}
+	r.continuity = (counter + 1) & 0x0F
+}
+
+// section returns the PSI section that starts in a payload.
+func section(payload []byte, tableID byte) ([]byte, error) {
+	if len(payload) == 0 || 1+int(payload[0])+3 > len(payload) {
+		return nil, errMalformedPSI
+	}
+	payload = payload[1+int(payload[0]):]
+	length := 3 + (int(payload[1]&0x0F)<<8 | int(payload[2]))
+	if payload[0] != tableID || length < psiHeaderLength+4 || length > len(payload) {
+		return nil, fmt.Errorf("%w: table 0x%02X of %d bytes", errMalformedPSI, payload[0], length)
+	}
+	if mpegts.CRC32(payload[:length]) != 0 {
+		return nil, errPSIChecksum
+	}
+
+	return payload[psiHeaderLength : length-4], nil
+}
+
+func (r *TSReader) parsePAT(payload []byte) error {
+	programs, err := section(payload, mpegts.TableIDPAT)
+	if err != nil {
+		return err
+	}
+	for ; len(programs) >= 4; programs = programs[4:] {
+		number := uint16(programs[0])<<8 | uint16(programs[1])
+		pid := uint16(programs[2]&0x1F)<<8 | uint16(programs[3])
+		if number != 0 { // program 0 gives the network PID
+			r.programs[pid] = number
+		}
+	}
+
+	return nil
+}
+
+func (r *TSReader) parsePMT(payload []byte, program uint16) error {
+	streams, err := section(payload, mpegts.TableIDPMT)
+	if err != nil {
+		return err
+	}
+	if len(streams) < 4 {
+		return errMalformedPSI
+	}
+	infoLength := int(streams[2]&0x0F)<<8 | int(streams[3])
+	if 4+infoLength > len(streams) {
+		return errMalformedPSI
+	}
+	for streams = streams[4+infoLength:]; len(streams) >= 5; {
+		streamType := streams[0]
+		pid := uint16(streams[1]&0x1F)<<8 | uint16(streams[2])
+		length := int(streams[3]&0x0F)<<8 | int(streams[4])
+		if 5+length > len(streams) {
+			return errMalformedPSI
+		}
+		descriptors := streams[5 : 5+length]
+		streams = streams[5+length:]
+		if streamType != mpegts.StreamTypePrivatePES {
+			continue
+		}
+		code, isOpus, err := parseDescriptors(descriptors)
+		if err != nil {
+			return err
+		}
+		if !isOpus {
+			continue
+		}
+		channels, family, mapping, err := mpegts.ChannelConfig(code)
+		if err != nil {
+			return err
+		}
+		r.track = &Track{
+			PID:           pid,
+			ProgramNumber: program,
+			Header: oggreader.OggHeader{
+				Version:    1,
+				Channels:   channels,
+				SampleRate: opusSampleRate,
+				ChannelMap: family,
+			},
+			ChannelMapping: mapping,
+		}
+
+		return nil
+	}
+
+	return nil
+}
+
+// parseDescriptors reports whether the descriptors of a stream register it
+// as Opus, and returns its channel configuration code.
+func parseDescriptors(descriptors []byte) (code byte, isOpus bool, err error) {
+	code = defaultChannelsCode
+	for len(descriptors) >= 2 {
+		tag, length := descriptors[0], int(descriptors[1])
+		if 2+length > len(descriptors) {
+			return 0, false, errMalformedPSI
+		}
+		data := descriptors[2 : 2+length]
+		descriptors = descriptors[2+length:]
+		switch {
+		case tag == mpegts.DescriptorRegistration && string(data) == mpegts.FormatIdentifierOpus:
+			isOpus = true
+		case tag == mpegts.DescriptorExtension && length >= 2 && data[0] == mpegts.ExtensionOpus:
+			code = data[1]
+		}
+	}
+
+	return code, isOpus, nil
+}
+
+// ParseNextPacket returns the next packet of the Opus stream, and io.EOF at
+// the end of the transport stream.
+func (r *TSReader) ParseNextPacket() (*Packet, error) {
+	for len(r.pending) == 0 {
+		pid, start, payload, err := r.readPacket()
+		if errors.Is(err, io.EOF) {
+			if !r.assembling {
+				return nil, io.EOF
+			}
+			r.assembling = false
+			if err = r.parsePES(r.pes); err != nil {
+				return nil, err
+			}
+
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if pid != r.track.PID || len(payload) == 0 {
+			continue
+		}
+
+		if start {
+			if r.assembling {
+				if err = r.parsePES(r.pes); err != nil {
+					return nil, err
+				}
+			}
+			r.pes, r.assembling = append(r.pes[:0], payload...), true
+		} else if r.assembling {
+			r.pes = append(r.pes, payload...)
+		}
+		// A PES packet of known length is done once it is all there.
+		if r.assembling && len(r.pes) >= 6 {
+			if length := int(r.pes[4])<<8 | int(r.pes[5]); length != 0 && len(r.pes) >= 6+length {
+				r.assembling = false
+				if err = r.parsePES(r.pes[:6+length]); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	packet := r.pending[0]
+	r.pending = r.pending[1:]
+
+	return packet, nil
+}
+
+// parsePES queues the access units of a PES packet.
+func (r *TSReader) parsePES(pes []byte) error {
+	if len(pes) < pesHeaderLength || pes[0] != 0 || pes[1] != 0 || pes[2] != 1 {
+		return fmt.Errorf("%w: missing start code", errMalformedPES)
+	}
+	headerLength := pesHeaderLength + int(pes[8])
+	if headerLength > len(pes) {
+		return fmt.Errorf("%w: header of %d bytes", errMalformedPES, headerLength)
+	}
+	if pes[7]&pesPTSFlag != 0 {
+		if headerLength < pesHeaderLength+5 {
+			return fmt.Errorf("%w: PTS missing", errMalformedPES)
+		}
+		r.nextTimestamp = r.timestamp(pes[pesHeaderLength:])
+	}
+
+	for units := pes[headerLength:]; len(units) > 0; {
+		header, n, err := mpegts.ParseControlHeader(units)
+		if err != nil {
+			return fmt.Errorf("%w: %w", errMalformedUnit, err)
+		}
+		if n+header.Size > len(units) {
+			return fmt.Errorf("%w: packet of %d bytes in %d", errMalformedUnit, header.Size, len(units)-n)
+		}
+		data := append([]byte(nil), units[n:n+header.Size]...)
+		units = units[n+header.Size:]
+
+		r.pending = append(r.pending, &Packet{
+			Data:      data,
+			Timestamp: r.nextTimestamp,
+			StartTrim: int(header.StartTrim),
+			EndTrim:   int(header.EndTrim),
+		})
+		samples, err := opus.PacketSampleCount(data, opusSampleRate)
+		if err != nil {
+			return fmt.Errorf("%w: %w", errMalformedUnit, err)
+		}
+		r.nextTimestamp += time.Duration(samples) * time.Second / opusSampleRate
+	}
+
+	return nil
+}
+
+// timestamp parses a PTS, unwrapping it from the 26.5 hours it wraps around
+// after.
+func (r *TSReader) timestamp(field []byte) time.Duration {
+	pts := int64(field[0]>>1&0x07)<<30 | int64(field[1])<<22 | int64(field[2]>>1)<<15 |
+		int64(field[3])<<7 | int64(field[4]>>1)
+	if r.lastPTS >= 0 {
+		// Take the PTS nearest the last one.
+		delta := (pts - r.lastPTS) % ptsWrap
+		if delta >= ptsWrap/2 {
+			delta -= ptsWrap
+		} else if delta < -ptsWrap/2 {
+			delta += ptsWrap
+		}
+		pts = r.lastPTS + delta
+	}
+	r.lastPTS = pts
+
+	return time.Duration(pts) * time.Second / ptsClockRate
+}
@@ -0,0 +1,207 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package mpegtsreader
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/pion/opus/internal/mpegts"
+	"github.com/pion/opus/pkg/oggreader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testPMTPID  = 0x1000
+	testOpusPID = 0x0101
+)
+
+// tsPacket builds a transport stream packet, padding a short payload with
+// adaptation field stuffing.
+func tsPacket(pid uint16, start bool, continuity byte, payload []byte) []byte {
+	packet := []byte{mpegts.SyncByte, byte(pid >> 8), byte(pid), 0x10 | continuity}
+	if start {
+		packet[1] |= 0x40
+	}
+	if stuffing := mpegts.PacketSize - 4 - len(payload); stuffing > 0 {
+		packet[3] |= 0x20
+		packet = append(packet, byte(stuffing-1))
+		if stuffing > 1 {
+			packet = append(packet, 0)
+			packet = append(packet, bytes.Repeat([]byte{0xFF}, stuffing-2)...)
+		}
+	}
+
+	return append(packet, payload...)
+}
+
+// psiPacket builds the transport stream packet of a PSI section.
+func psiPacket(pid uint16, tableID byte, extension uint16, data ...byte) []byte {
+	length := 5 + len(data) + 4
+	section := []byte{0, tableID, 0xB0 | byte(length>>8), byte(length), byte(extension >> 8), byte(extension), 0xC1, 0, 0}
+	section = append(section, data...)
+	crc := mpegts.CRC32(section[1:])
+	section = append(section, byte(crc>>24), byte(crc>>16), byte(crc>>8), byte(crc))
+
+	return tsPacket(pid, true, 0, section)
+}
+
+// programTables builds a PAT with a network PID entry and one program, whose
+// PMT lists a video stream and a private stream before an Opus stream
+// without an extension descriptor.
+func programTables() []byte {
+	pat := psiPacket(mpegts.PIDPAT, mpegts.TableIDPAT, 1,
+		0, 0, 0xE0, 0x10,
+		0, 7, 0xE0|testPMTPID>>8, testPMTPID&0xFF,
+	)
+	pmt := psiPacket(testPMTPID, mpegts.TableIDPMT, 7,
+		0xE1, 0x00, 0xF0, 0,
+		0x1B, 0xE1, 0x00, 0xF0, 0,
+		mpegts.StreamTypePrivatePES, 0xE1, 0x02, 0xF0, 3, 0x56, 1, 0,
+		mpegts.StreamTypePrivatePES, 0xE1, 0x01, 0xF0, 6, mpegts.DescriptorRegistration, 4, 'O', 'p', 'u', 's',
+	)
+
+	return append(pat, pmt...)
+}
+
+// pesPacket builds a PES packet of unbounded length from access units, with
+// a PTS unless pts is negative.
+func pesPacket(pts int64, units ...[]byte) []byte {
+	pes := []byte{0, 0, 1, mpegts.StreamIDPrivate1, 0, 0, 0x84, 0, 0}
+	if pts >= 0 {
+		pes[7], pes[8] = 0x80, 5
+		pes = append(pes, 0x21|byte(pts>>29)&0x0E, byte(pts>>22), byte(pts>>14)|1, byte(pts>>7), byte(pts<<1)|1)
+	}
+	for _, unit := range units {
+		pes = append(pes, unit...)
+	}
+
+	return pes
+}
+
+// accessUnit builds an access unit of a 20 ms CELT-only packet of size bytes.
+func accessUnit(size int, fill byte, startTrim, endTrim uint16) []byte {
+	packet := append([]byte{0xf8}, bytes.Repeat([]byte{fill}, size-1)...)
+
+	return append(mpegts.AppendControlHeader(nil, len(packet), startTrim, endTrim), packet...)
+}
+
+func readAll(t *testing.T, reader *TSReader) []*Packet {
+	t.Helper()
+
+	var packets []*Packet
+	for {
+		packet, err := reader.ParseNextPacket()
+		if errors.Is(err, io.EOF) {
+			return packets
+		}
+		require.NoError(t, err)
+		packets = append(packets, packet)
+	}
+}
+
+func TestTSReader(t *testing.T) {
+	// Two access units in a PES packet split over two transport stream
+	// packets, with a packet of another PID between them, then a PES
+	// packet without a PTS.
+	first := pesPacket(90000, accessUnit(200, 1, 312, 0), accessUnit(3, 2, 0, 0))
+	stream := programTables()
+	stream = append(stream, tsPacket(testOpusPID, true, 3, first[:184])...)
+	stream = append(stream, tsPacket(0x100, true, 0, []byte{0, 0, 1, 0xE0})...)
+	stream = append(stream, tsPacket(testOpusPID, false, 4, first[184:])...)
+	stream = append(stream, tsPacket(testOpusPID, true, 5, pesPacket(-1, accessUnit(3, 3, 0, 100)))...)
+
+	reader, track, err := NewWith(bytes.NewReader(stream))
+	require.NoError(t, err)
+	assert.Equal(t, &Track{
+		PID:           testOpusPID,
+		ProgramNumber: 7,
+		Header:        oggreader.OggHeader{Version: 1, Channels: 2, SampleRate: 48000},
+	}, track, "stereo without an extension descriptor")
+
+	packets := readAll(t, reader)
+	require.Len(t, packets, 3)
+	assert.Equal(t, Packet{
+		Data:      append([]byte{0xf8}, bytes.Repeat([]byte{1}, 199)...),
+		Timestamp: time.Second,
+		StartTrim: 312,
+	}, *packets[0])
+	assert.Equal(t, Packet{Data: []byte{0xf8, 2, 2}, Timestamp: 1020 * time.Millisecond}, *packets[1])
+	assert.Equal(t, Packet{Data: []byte{0xf8, 3, 3}, Timestamp: 1040 * time.Millisecond, EndTrim: 100}, *packets[2])
+}
+
+func TestTSReader_ChannelConfig(t *testing.T) {
+	pmt := psiPacket(testPMTPID, mpegts.TableIDPMT, 1,
+		0xE1, 0x01, 0xF0, 0,
+		mpegts.StreamTypePrivatePES, 0xE1, 0x01, 0xF0, 10,
+		mpegts.DescriptorExtension, 2, mpegts.ExtensionOpus, 6,
+		mpegts.DescriptorRegistration, 4, 'O', 'p', 'u', 's',
+	)
+	stream := append(programTables()[:mpegts.PacketSize], pmt...)
+
+	_, track, err := NewWith(bytes.NewReader(stream))
+	require.NoError(t, err)
+	assert.Equal(t, uint8(6), track.Header.Channels)
+	assert.Equal(t, uint8(1), track.Header.ChannelMap)
+	assert.Equal(t, oggreader.OggChannelMapping{
+		StreamCount: 4, CoupledCount: 2, Mapping: []uint8{0, 4, 1, 2, 3, 5},
+	}, track.ChannelMapping)
+}
+
+func TestTSReader_Continuity(t *testing.T) {
+	// The PES packet missing a transport stream packet is dropped.
+	lost := pesPacket(0, accessUnit(300, 1, 0, 0))
+	stream := programTables()
+	stream = append(stream, tsPacket(testOpusPID, true, 0, lost[:184])...)
+	stream = append(stream, tsPacket(testOpusPID, false, 2, lost[184:])...)
+	stream = append(stream, tsPacket(testOpusPID, true, 3, pesPacket(1800, accessUnit(3, 2, 0, 0)))...)
+
+	reader, _, err := NewWith(bytes.NewReader(stream))
+	require.NoError(t, err)
+	packets := readAll(t, reader)
+	require.Len(t, packets, 1)
+	assert.Equal(t, []byte{0xf8, 2, 2}, packets[0].Data)
+	assert.Equal(t, 20*time.Millisecond, packets[0].Timestamp)
+}
+
+func TestTSReader_PTSWrap(t *testing.T) {
+	stream := programTables()
+	stream = append(stream, tsPacket(testOpusPID, true, 0, pesPacket(1<<33-900, accessUnit(3, 1, 0, 0)))...)
+	stream = append(stream, tsPacket(testOpusPID, true, 1, pesPacket(900, accessUnit(3, 2, 0, 0)))...)
+
+	reader, _, err := NewWith(bytes.NewReader(stream))
+	require.NoError(t, err)
+	packets := readAll(t, reader)
+	require.Len(t, packets, 2)
+	assert.Equal(t, 20*time.Millisecond, packets[1].Timestamp-packets[0].Timestamp)
+}
+
+func TestTSReader_Errors(t *testing.T) {
+	_, _, err := NewWith(nil)
+	assert.ErrorIs(t, err, errNilStream)
+
+	_, _, err = NewWith(bytes.NewReader(programTables()[:mpegts.PacketSize]))
+	assert.ErrorIs(t, err, errNoOpusStream)
+
+	_, _, err = NewWith(bytes.NewReader(programTables()[:mpegts.PacketSize+10]))
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	_, _, err = NewWith(bytes.NewReader(make([]byte, mpegts.PacketSize)))
+	assert.ErrorIs(t, err, errLostSync)
+
+	stream := programTables()
+	stream[mpegts.PacketSize-1]++
+	_, _, err = NewWith(bytes.NewReader(stream))
+	assert.ErrorIs(t, err, errPSIChecksum)
+
+	stream = append(programTables(), tsPacket(testOpusPID, true, 0, pesPacket(0, []byte{0x7F, 0xE0, 10, 0xf8}))...)
+	reader, _, err := NewWith(bytes.NewReader(stream))
+	require.NoError(t, err)
+	_, err = reader.ParseNextPacket()
+	assert.ErrorIs(t, err, errMalformedUnit)
+}
@@ -0,0 +1,352 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+// Package mpegtswriter implements a muxer of Opus into MPEG-2 transport
+// streams.
+//
+// The stream holds a single program with the Opus stream as a private PES
+// stream, registered as "Opus" and with its channel configuration in a DVB
+// extension descriptor. The program association and program map tables are
+// written at the start and repeated every PSIInterval of audio.
+//
+// Every Opus packet is an access unit in its own PES packet, stamped with a
+// PTS, and the first transport stream packet of each also carries a PCR.
+// PCRs start at 0, and PTSs 100 ms later.
+// The pre-skip and the end trimming of the last packet go into the start and
+// end trims of the access units' control headers.
+package mpegtswriter
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/pion/opus"
+	"github.com/pion/opus/internal/mpegts"
+	"github.com/pion/opus/pkg/oggreader"
+)
+
+const (
+	// PSIInterval is how much audio is written between repetitions of the
+	// program association and program map tables.
+	PSIInterval = 400 * time.Millisecond
+
+	// DefaultPID is the PID of the Opus stream unless WithPID sets another.
+	DefaultPID = 0x0100
+
+	opusSampleRate = 48000
+	ptsClockRate   = 90000
+	ptsWrap        = 1 << 33
+	// ptsOffset is how far the PTS of a packet runs ahead of the PCR that
+	// comes with it, so players can buffer it.
+	ptsOffset = ptsClockRate / 10
+
+	pmtPID            = 0x1000
+	programNumber     = 1
+	transportStreamID = 1
+	minPID            = 0x0010
+	maxPID            = 0x1FFE
+
+	payloadSize      = mpegts.PacketSize - 4
+	pcrFieldLength   = 7 // the flags and the PCR
+	pesPTSHeaderSize = 3 + 5
+)
+
+var (
+	errNilStream               = errors.New("stream is nil")
+	errWriterClosed            = errors.New("writer is closed")
+	errInvalidChannelCount     = errors.New("channel count must be positive")
+	errInvalidPID              = errors.New("PID must be between 0x0010 and 0x1FFE, and not 0x1000")
+	errGranulePositionRewinded = errors.New("granule position must not decrease")
+	errInvalidPacket           = errors.New("invalid Opus packet")
+)
+
+// TSWriter is used to write Opus packets into an MPEG-2 transport stream.
+type TSWriter struct {
+	stream io.Writer
+	fd     *os.File
+
+	pid            uint16
+	channelMapping oggreader.OggChannelMapping
+	pat, pmt       []byte // the sections
+	preSkip        uint64
+	closed         bool
+
+	// The continuity counters of the PAT, PMT and Opus PIDs.
+	patContinuity, pmtContinuity, continuity byte
+
+	nextPSI           uint64 // in 48 kHz samples, when the tables are next written
+	end               uint64 // in 48 kHz samples, where the last packet ends
+	lastGranule       uint64
+	hasWrittenPackets bool
+}
+
+// Option configures a TSWriter during construction.
+type Option func(*TSWriter) error
+
+// WithChannelMapping sets the stream count, coupled count and mapping table
+// of channel mapping families other than 0.
+func WithChannelMapping(mapping oggreader.OggChannelMapping) Option {
+	return func(w *TSWriter) error {
+		w.channelMapping = oggreader.OggChannelMapping{
+			StreamCount:  mapping.StreamCount,
+			CoupledCount: mapping.CoupledCount,
+			Mapping:      append([]uint8(nil), mapping.Mapping...),
+		}
+
+		return nil
+	}
+}
+
+// WithPID sets the PID of the Opus stream.
+func WithPID(pid uint16) Option {
+	return func(w *TSWriter) error {
+		if pid < minPID || pid > maxPID || pid == pmtPID {
+			return errInvalidPID
+		}
+		w.pid = pid
+
+		return nil
+	}
+}
+
+// New builds a new transport stream writer that writes to fileName.
+func New(fileName string, header oggreader.OggHeader, opts ...Option) (*TSWriter, error) {
+	fd, err := os.Create(fileName) // #nosec G304
+	if err != nil {
+		return nil, err
+	}
+	writer, err := NewWith(fd, header, opts...)
+	if err != nil {
+		return nil, errors.Join(err, fd.Close())
+	}
+	writer.fd = fd
+
+	return writer, nil
+}
+
+// NewWith builds a new transport stream writer on top of out and writes the
+// program tables.
+//
+// The channels of header, with the mapping WithChannelMapping sets, must be
+// one of the channel configurations the Opus extension descriptor codes: 1
+// or 2 channels in family 0, 3 to 8 in the Vorbis order of family 1, or two
+// mono streams in family 255. Transport streams carry no input sample rate
+// or output gain, so header.SampleRate and header.OutputGain are not written.
+func NewWith(out io.Writer, header oggreader.OggHeader, opts ...Option) (*TSWriter, error) {
+	if out == nil {
+		return nil, errNilStream
+	}
+
+	writer := &TSWriter{
+		stream:  out,
+		pid:     DefaultPID,
+		preSkip: uint64(header.PreSkip),
+	}
+	for _, opt := range opts {
+		if err := opt(writer); err != nil {
+			return nil, err
+		}
+	}
+	if header.Channels == 0 {
+		return nil, errInvalidChannelCount
+	}
+	code, err := mpegts.ChannelConfigCode(header, writer.channelMapping)
+	if err != nil {
+		return nil, err
+	}
+
+	writer.pat = appendSection(nil, mpegts.TableIDPAT, transportStreamID,
+		programNumber>>8, programNumber&0xFF, 0xE0|pmtPID>>8, pmtPID&0xFF)
+	writer.pmt = appendSection(nil, mpegts.TableIDPMT, programNumber,
+		0xE0|byte(writer.pid>>8), byte(writer.pid), // PCR PID
+		0xF0, 0, // program info length
+		mpegts.StreamTypePrivatePES, 0xE0|byte(writer.pid>>8), byte(writer.pid),
+		0xF0, 10, // ES info length
+		mpegts.DescriptorRegistration, 4, 'O', 'p', 'u', 's',
+		mpegts.DescriptorExtension, 2, mpegts.ExtensionOpus, code,
+	)
+	if err = writer.write(writer.appendPSI(nil)); err != nil {
+		return nil, err
+	}
+	writer.nextPSI = uint64(PSIInterval.Nanoseconds()) * opusSampleRate / uint64(time.Second)
+
+	return writer, nil
+}
+
+// appendSection appends a version 0 PSI section, the only one of its table.
+func appendSection(b []byte, tableID byte, tableIDExtension uint16, data ...byte) []byte {
+	start := len(b)
+	length := 5 + len(data) + 4
+	b = append(b, tableID, 0xB0|byte(length>>8), byte(length),
+		byte(tableIDExtension>>8), byte(tableIDExtension), 0xC1, 0, 0)
+	b = append(b, data...)
+	crc := mpegts.CRC32(b[start:])
+
+	return append(b, byte(crc>>24), byte(crc>>16), byte(crc>>8), byte(crc))
+}
+
+// appendPSI appends a transport stream packet each for the program
+// association and program map tables.
+func (w *TSWriter) appendPSI(b []byte) []byte {
+	for _, table := range []struct {
+		pid        uint16
+		section    []byte
+		continuity *byte
+	}{
+		{mpegts.PIDPAT, w.pat, &w.patContinuity},
+		{pmtPID, w.pmt, &w.pmtContinuity},
+	} {
+		start := len(b)
+		b = append(b, mpegts.SyncByte, 0x40|byte(table.pid>>8), byte(table.pid), 0x10|*table.continuity, 0)
+		b = append(b, table.section...)
+		for len(b) < start+mpegts.PacketSize {
+			b = append(b, 0xFF)
+		}
+		*table.continuity = (*table.continuity + 1) & 0x0F
+	}
+
+	return b
+}
+
+func (w *TSWriter) write(data []byte) error {
+	_, err := w.stream.Write(data)
+
+	return err
+}
+
+// WritePacket writes one Opus packet whose last sample ends at
+// granulePosition, in 48 kHz samples from the start of the stream, the
+// pre-skip among them.
+//
+// Each packet is an access unit whose control header trims it on its own: the
+// start trim drops what of it falls in the pre-skip, over as many packets as
+// that takes, and the end trim what runs past granulePosition, the last
+// packet's or any other's. Its PTS counts the 90 kHz clock from where its own
+// duration before granulePosition falls, 100 ms ahead of the PCR, and both
+// wrap at 2^33. A packet that would overlap the one before starts where that
+// one ends instead.
+func (w *TSWriter) WritePacket(packet []byte, granulePosition uint64) error {
+	if w.closed {
+		return errWriterClosed
+	}
+	if w.hasWrittenPackets && granulePosition < w.lastGranule {
+		return errGranulePositionRewinded
+	}
+	samples, err := opus.PacketSampleCount(packet, opusSampleRate)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errInvalidPacket, err)
+	}
+
+	start := w.end
+	if granulePosition >= start+uint64(samples) { //nolint:gosec // G115
+		start = granulePosition - uint64(samples) //nolint:gosec // G115
+	}
+	end := start + uint64(samples) //nolint:gosec // G115
+
+	// The start trim drops what of the packet falls in the pre-skip, and the
+	// end trim what lies past the granule position.
+	var startTrim, endTrim uint64
+	if start < w.preSkip {
+		startTrim = min(w.preSkip, end) - start
+	}
+	if granulePosition < end {
+		endTrim = min(end-max(granulePosition, start), end-start-startTrim)
+	}
+
+	var data []byte
+	if start >= w.nextPSI {
+		data = w.appendPSI(data)
+		w.nextPSI = start + uint64(PSIInterval.Nanoseconds())*opusSampleRate/uint64(time.Second)
+	}
+	pcr := start * ptsClockRate / opusSampleRate
+	unit := mpegts.AppendControlHeader(nil, len(packet), uint16(startTrim), uint16(endTrim)) //nolint:gosec // G115
+	unit = append(unit, packet...)
+	data = w.appendTSPackets(data, append(appendPESHeader(nil, (pcr+ptsOffset)%ptsWrap, len(unit)), unit...), pcr%ptsWrap)
+	if err = w.write(data); err != nil {
+		return err
+	}
+
+	w.end = end
+	w.lastGranule = granulePosition
+	w.hasWrittenPackets = true
+
+	return nil
+}
+
+// appendPESHeader appends the header of a PES packet holding an access unit
+// of size bytes.
+func appendPESHeader(b []byte, pts uint64, size int) []byte {
+	length := pesPTSHeaderSize + size
+	if length > 0xFFFF {
+		length = 0 // unbounded
+	}
+	b = append(b, 0, 0, 1, mpegts.StreamIDPrivate1, byte(length>>8), byte(length),
+		0x84, // data aligned
+		0x80, // PTS only
+		5)
+
+	return append(b,
+		0x21|byte(pts>>29)&0x0E,
+		byte(pts>>22),
+		byte(pts>>14)|0x01,
+		byte(pts>>7),
+		byte(pts<<1)|0x01,
+	)
+}
+
+// appendTSPackets splits a PES packet into transport stream packets, the
+// first of them carrying a PCR and the last padded with adaptation field
+// stuffing.
+func (w *TSWriter) appendTSPackets(b, pes []byte, pcr uint64) []byte {
+	for first := true; len(pes) > 0; first = false {
+		space := payloadSize
+		if first {
+			space -= 1 + pcrFieldLength
+		}
+		n := min(len(pes), space)
+
+		start := len(b)
+		b = append(b, mpegts.SyncByte, byte(w.pid>>8), byte(w.pid), 0x10|w.continuity)
+		if first {
+			b[start+1] |= 0x40
+		}
+		if first || n < payloadSize {
+			b[start+3] |= 0x20
+			fieldLength := payloadSize - 1 - n
+			b = append(b, byte(fieldLength))
+			if fieldLength > 0 {
+				if first {
+					b = append(b, 0x10,
+						byte(pcr>>25), byte(pcr>>17), byte(pcr>>9), byte(pcr>>1), byte(pcr<<7)|0x7E, 0)
+				} else {
+					b = append(b, 0)
+				}
+			}
+			for len(b) < start+4+1+fieldLength {
+				b = append(b, 0xFF)
+			}
+		}
+		b = append(b, pes[:n]...)
+		pes = pes[n:]
+		w.continuity = (w.continuity + 1) & 0x0F
+	}
+
+	return b
+}
+
+// Close closes the writer. If it was created with New, the file is closed
+// too.
+func (w *TSWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	if w.fd != nil {
+		return w.fd.Close()
+	}
+
+	return nil
+}
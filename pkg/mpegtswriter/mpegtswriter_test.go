@@ -0,0 +1,369 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package mpegtswriter
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pion/opus/internal/mpegts"
+	"github.com/pion/opus/pkg/mpegtsreader"
+	"github.com/pion/opus/pkg/oggreader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPacket is a 20 ms CELT-only packet whose payload tells it apart.
+func testPacket(i int) []byte {
+	return []byte{0xf8, byte(i), byte(i >> 8)}
+}
+
+func readAll(t *testing.T, reader *mpegtsreader.TSReader) []*mpegtsreader.Packet {
+	t.Helper()
+
+	var packets []*mpegtsreader.Packet
+	for {
+		packet, err := reader.ParseNextPacket()
+		if errors.Is(err, io.EOF) {
+			return packets
+		}
+		require.NoError(t, err)
+		packets = append(packets, packet)
+	}
+}
+
+// countPID counts the transport stream packets of pid.
+func countPID(t *testing.T, stream []byte, pid uint16) int {
+	t.Helper()
+
+	require.Zero(t, len(stream)%mpegts.PacketSize)
+	count := 0
+	for ; len(stream) > 0; stream = stream[mpegts.PacketSize:] {
+		require.Equal(t, byte(mpegts.SyncByte), stream[0])
+		if uint16(stream[1]&0x1F)<<8|uint16(stream[2]) == pid {
+			count++
+		}
+	}
+
+	return count
+}
+
+// tsPacket is a transport stream packet of the Opus PID split into its
+// fields.
+type tsPacket struct {
+	unitStart   bool
+	continuity  byte
+	adaptation  []byte // nil without an adaptation field
+	payload     []byte
+	hasPCR      bool
+	programTime uint64
+}
+
+// readPID splits out the transport stream packets of pid.
+func readPID(t *testing.T, stream []byte, pid uint16) []tsPacket {
+	t.Helper()
+
+	require.Zero(t, len(stream)%mpegts.PacketSize)
+	var packets []tsPacket
+	for ; len(stream) > 0; stream = stream[mpegts.PacketSize:] {
+		b := stream[:mpegts.PacketSize]
+		require.Equal(t, byte(mpegts.SyncByte), b[0])
+		if uint16(b[1]&0x1F)<<8|uint16(b[2]) != pid {
+			continue
+		}
+		packet := tsPacket{unitStart: b[1]&0x40 != 0, continuity: b[3] & 0x0F, payload: b[4:]}
+		require.NotZero(t, b[3]&0x10, "every packet carries payload")
+		if b[3]&0x20 != 0 {
+			length := int(b[4])
+			packet.adaptation = b[5 : 5+length]
+			packet.payload = b[5+length:]
+			if length > 0 && b[5]&0x10 != 0 {
+				packet.hasPCR = true
+				packet.programTime = uint64(b[6])<<25 | uint64(b[7])<<17 | uint64(b[8])<<9 |
+					uint64(b[9])<<1 | uint64(b[10])>>7
+			}
+		}
+		packets = append(packets, packet)
+	}
+
+	return packets
+}
+
+// parsePTS parses the PTS of a PES packet.
+func parsePTS(pes []byte) uint64 {
+	return uint64(pes[9]>>1&0x07)<<30 | uint64(pes[10])<<22 | uint64(pes[11]>>1)<<15 |
+		uint64(pes[12])<<7 | uint64(pes[13]>>1)
+}
+
+// TestTSWriter_Trims checks the control header trims of the first packets,
+// which fall in the pre-skip, and of the last one, and the tables repeated
+// every PSIInterval.
+func TestTSWriter_Trims(t *testing.T) {
+	var out bytes.Buffer
+	writer, err := NewWith(&out, oggreader.OggHeader{Channels: 2, PreSkip: 312, SampleRate: 44100})
+	require.NoError(t, err)
+	for i := range 24 {
+		require.NoError(t, writer.WritePacket(testPacket(i), uint64(i+1)*960)) //nolint:gosec // G115
+	}
+	require.NoError(t, writer.WritePacket(testPacket(24), 24*960+240))
+	require.NoError(t, writer.Close())
+	require.NoError(t, writer.Close())
+
+	// The tables at 0 and 400 ms, and a transport stream packet for every
+	// Opus packet.
+	assert.Equal(t, 2, countPID(t, out.Bytes(), mpegts.PIDPAT))
+	assert.Equal(t, 2, countPID(t, out.Bytes(), pmtPID))
+	assert.Equal(t, 25, countPID(t, out.Bytes(), DefaultPID))
+
+	reader, track, err := mpegtsreader.NewWith(&out)
+	require.NoError(t, err)
+	assert.Equal(t, &mpegtsreader.Track{
+		PID:           DefaultPID,
+		ProgramNumber: programNumber,
+		Header:        oggreader.OggHeader{Version: 1, Channels: 2, SampleRate: 48000},
+	}, track)
+
+	packets := readAll(t, reader)
+	require.Len(t, packets, 25)
+	for i, packet := range packets {
+		assert.Equal(t, testPacket(i), packet.Data)
+		assert.Equal(t, 100*time.Millisecond+time.Duration(i)*20*time.Millisecond, packet.Timestamp)
+	}
+	assert.Equal(t, 312, packets[0].StartTrim, "the pre-skip")
+	assert.Zero(t, packets[0].EndTrim)
+	assert.Zero(t, packets[1].StartTrim)
+	assert.Equal(t, 720, packets[24].EndTrim, "the end trimming")
+}
+
+// TestTSWriter_PESSplitting checks how PES packets around the sizes where
+// they need another transport stream packet are split, the first packet
+// holding less for its PCR and the last one filled out by its adaptation
+// field, down to a field of its length byte alone.
+func TestTSWriter_PESSplitting(t *testing.T) {
+	const pesHeaderSize = 6 + 3 + 5
+
+	// payloads are how much of the PES packet each transport stream packet
+	// carries, by the size of the PES packet.
+	payloads := map[int][]int{
+		176: {176},
+		177: {176, 1},
+		359: {176, 183},
+		360: {176, 184},
+		361: {176, 184, 1},
+	}
+	var sizes []int
+	var opusPackets [][]byte
+	for _, pesSize := range []int{176, 177, 359, 360, 361} {
+		size := 1
+		for pesHeaderSize+len(mpegts.AppendControlHeader(nil, size, 0, 0))+size < pesSize {
+			size++
+		}
+		require.Equal(t, pesSize, pesHeaderSize+len(mpegts.AppendControlHeader(nil, size, 0, 0))+size)
+		sizes = append(sizes, pesSize)
+		opusPackets = append(opusPackets, append([]byte{0xf8}, bytes.Repeat([]byte{byte(pesSize)}, size-1)...))
+	}
+	// Twice over, for the continuity counter to wrap.
+	sizes = append(sizes, sizes...)
+	opusPackets = append(opusPackets, opusPackets...)
+
+	var out bytes.Buffer
+	writer, err := NewWith(&out, oggreader.OggHeader{Channels: 1})
+	require.NoError(t, err)
+	for i, packet := range opusPackets {
+		require.NoError(t, writer.WritePacket(packet, uint64(i+1)*960)) //nolint:gosec // G115
+	}
+	require.NoError(t, writer.Close())
+
+	tsPackets := readPID(t, out.Bytes(), DefaultPID)
+	for i, packet := range tsPackets {
+		assert.Equal(t, byte(i%16), packet.continuity)
+	}
+	for i, pesSize := range sizes {
+		var pes []byte
+		for j, n := range payloads[pesSize] {
+			require.NotEmpty(t, tsPackets)
+			packet := tsPackets[0]
+			tsPackets = tsPackets[1:]
+
+			assert.Equal(t, j == 0, packet.unitStart)
+			assert.Equal(t, j == 0, packet.hasPCR)
+			require.Len(t, packet.payload, n)
+			switch {
+			case j == 0:
+				assert.Len(t, packet.adaptation, 183-n)
+			case n < payloadSize:
+				require.NotNil(t, packet.adaptation)
+				assert.Len(t, packet.adaptation, 183-n)
+			default:
+				assert.Nil(t, packet.adaptation)
+			}
+			pes = append(pes, packet.payload...)
+		}
+		require.Len(t, pes, pesSize)
+		assert.Equal(t, []byte{0, 0, 1, mpegts.StreamIDPrivate1}, pes[:4])
+		header, n, err := mpegts.ParseControlHeader(pes[pesHeaderSize:])
+		require.NoError(t, err)
+		assert.Equal(t, len(opusPackets[i]), header.Size)
+		assert.Equal(t, opusPackets[i], pes[pesHeaderSize+n:])
+	}
+	assert.Empty(t, tsPackets)
+
+	reader, _, err := mpegtsreader.NewWith(&out)
+	require.NoError(t, err)
+	packets := readAll(t, reader)
+	require.Len(t, packets, len(opusPackets))
+	for i, packet := range packets {
+		assert.Equal(t, opusPackets[i], packet.Data)
+	}
+}
+
+// TestTSWriter_PTSWrap checks that the PTS and then the PCR wrap at 2^33
+// while the timestamps read back keep counting.
+func TestTSWriter_PTSWrap(t *testing.T) {
+	// The first packet starts 3602 ticks of the 90 kHz clock before the PTS
+	// wraps, and 12602 before the PCR does.
+	const start = (ptsWrap - 3602 - ptsOffset) * opusSampleRate / ptsClockRate
+
+	var out bytes.Buffer
+	writer, err := NewWith(&out, oggreader.OggHeader{Channels: 1})
+	require.NoError(t, err)
+	for k := range 10 {
+		require.NoError(t, writer.WritePacket(testPacket(k), start+uint64(k+1)*960)) //nolint:gosec // G115
+	}
+	require.NoError(t, writer.Close())
+
+	tsPackets := readPID(t, out.Bytes(), DefaultPID)
+	require.Len(t, tsPackets, 10)
+	for k, packet := range tsPackets {
+		pts := (ptsWrap - 3602 + uint64(k)*1800) % ptsWrap //nolint:gosec // G115
+		assert.Equal(t, pts, parsePTS(packet.payload), "PTS of packet %d", k)
+		assert.Equal(t, (pts+ptsWrap-ptsOffset)%ptsWrap, packet.programTime, "PCR of packet %d", k)
+	}
+	assert.Equal(t, uint64(1798), parsePTS(tsPackets[3].payload))
+	assert.Equal(t, uint64(1798), tsPackets[8].programTime)
+
+	reader, _, err := mpegtsreader.NewWith(&out)
+	require.NoError(t, err)
+	packets := readAll(t, reader)
+	require.Len(t, packets, 10)
+	for k, packet := range packets {
+		assert.Equal(t, testPacket(k), packet.Data)
+		assert.Equal(t, time.Duration(k)*20*time.Millisecond, packet.Timestamp-packets[0].Timestamp)
+	}
+}
+
+func TestTSWriter_LongPreSkip(t *testing.T) {
+	var out bytes.Buffer
+	writer, err := NewWith(&out, oggreader.OggHeader{Channels: 1, PreSkip: 1200}, WithPID(0x44))
+	require.NoError(t, err)
+	// A large packet spans several transport stream packets.
+	large := append([]byte{0xf8}, bytes.Repeat([]byte{0xAA}, 1000)...)
+	require.NoError(t, writer.WritePacket(large, 960))
+	require.NoError(t, writer.WritePacket(testPacket(1), 1920))
+	require.NoError(t, writer.WritePacket(testPacket(2), 2880))
+	require.NoError(t, writer.Close())
+
+	reader, track, err := mpegtsreader.NewWith(&out)
+	require.NoError(t, err)
+	assert.Equal(t, uint16(0x44), track.PID)
+	assert.Equal(t, uint8(1), track.Header.Channels)
+	packets := readAll(t, reader)
+	require.Len(t, packets, 3)
+	assert.Equal(t, large, packets[0].Data)
+	assert.Equal(t, 960, packets[0].StartTrim)
+	assert.Equal(t, 240, packets[1].StartTrim)
+	assert.Zero(t, packets[2].StartTrim)
+}
+
+func TestTSWriter_Gap(t *testing.T) {
+	var out bytes.Buffer
+	writer, err := NewWith(&out, oggreader.OggHeader{Channels: 1})
+	require.NoError(t, err)
+	require.NoError(t, writer.WritePacket(testPacket(0), 960))
+	require.NoError(t, writer.WritePacket(testPacket(1), 960+48000+960))
+	require.NoError(t, writer.WritePacket(testPacket(2), 960+48000+960+480))
+	require.NoError(t, writer.Close())
+
+	reader, _, err := mpegtsreader.NewWith(&out)
+	require.NoError(t, err)
+	packets := readAll(t, reader)
+	require.Len(t, packets, 3)
+	assert.Equal(t, 100*time.Millisecond, packets[0].Timestamp)
+	assert.Equal(t, 1120*time.Millisecond, packets[1].Timestamp)
+	assert.Equal(t, 1140*time.Millisecond, packets[2].Timestamp)
+	assert.Equal(t, 480, packets[2].EndTrim)
+}
+
+func TestTSWriter_ChannelMapping(t *testing.T) {
+	for _, test := range []struct {
+		header  oggreader.OggHeader
+		mapping oggreader.OggChannelMapping
+	}{
+		{
+			oggreader.OggHeader{Channels: 6, ChannelMap: 1},
+			oggreader.OggChannelMapping{StreamCount: 4, CoupledCount: 2, Mapping: []uint8{0, 4, 1, 2, 3, 5}},
+		},
+		{
+			oggreader.OggHeader{Channels: 2, ChannelMap: 255},
+			oggreader.OggChannelMapping{StreamCount: 2, CoupledCount: 0, Mapping: []uint8{0, 1}},
+		},
+	} {
+		var out bytes.Buffer
+		writer, err := NewWith(&out, test.header, WithChannelMapping(test.mapping))
+		require.NoError(t, err)
+		require.NoError(t, writer.Close())
+
+		_, track, err := mpegtsreader.NewWith(&out)
+		require.NoError(t, err)
+		assert.Equal(t, test.header.Channels, track.Header.Channels)
+		assert.Equal(t, test.header.ChannelMap, track.Header.ChannelMap)
+		assert.Equal(t, test.mapping, track.ChannelMapping)
+	}
+
+	_, err := NewWith(io.Discard, oggreader.OggHeader{Channels: 3, ChannelMap: 1},
+		WithChannelMapping(oggreader.OggChannelMapping{StreamCount: 3, Mapping: []uint8{0, 1, 2}}))
+	assert.Error(t, err, "a mapping the extension descriptor has no code for")
+}
+
+func TestTSWriter_Errors(t *testing.T) {
+	_, err := NewWith(nil, oggreader.OggHeader{Channels: 1})
+	assert.ErrorIs(t, err, errNilStream)
+
+	_, err = NewWith(io.Discard, oggreader.OggHeader{})
+	assert.ErrorIs(t, err, errInvalidChannelCount)
+
+	for _, pid := range []uint16{0x000F, pmtPID, 0x1FFF} {
+		_, err = NewWith(io.Discard, oggreader.OggHeader{Channels: 1}, WithPID(pid))
+		assert.ErrorIs(t, err, errInvalidPID)
+	}
+
+	writer, err := NewWith(io.Discard, oggreader.OggHeader{Channels: 1})
+	require.NoError(t, err)
+	assert.ErrorIs(t, writer.WritePacket(nil, 960), errInvalidPacket)
+	require.NoError(t, writer.WritePacket([]byte{0xf8}, 960))
+	assert.ErrorIs(t, writer.WritePacket([]byte{0xf8}, 480), errGranulePositionRewinded)
+
+	require.NoError(t, writer.Close())
+	assert.ErrorIs(t, writer.WritePacket([]byte{0xf8}, 1920), errWriterClosed)
+}
+
+func TestTSWriter_New(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "out.ts")
+	writer, err := New(fileName, oggreader.OggHeader{Channels: 1})
+	require.NoError(t, err)
+	require.NoError(t, writer.WritePacket([]byte{0xf8}, 960))
+	require.NoError(t, writer.Close())
+
+	file, err := os.Open(fileName) // #nosec G304
+	require.NoError(t, err)
+	defer func() { require.NoError(t, file.Close()) }()
+	reader, _, err := mpegtsreader.NewWith(file)
+	require.NoError(t, err)
+	assert.Len(t, readAll(t, reader), 1)
+}
@@ -84,6 +84,18 @@ type OggPageHeader struct {
 	segmentsCount uint8
 }
 
+// Serial returns the serial number of the logical bitstream the page belongs
+// to.
+func (p *OggPageHeader) Serial() uint32 {
+	return p.serial
+}
+
+// Sequence returns the page sequence number, which counts the pages of a
+// logical bitstream from 0.
+func (p *OggPageHeader) Sequence() uint32 {
+	return p.index
+}
+
 type oggPacket struct {
 	data   []byte
 	header *OggPageHeader
@@ -129,14 +141,19 @@ func (o *OggReader) readHeaders() (*OggHeader, error) {
 		return nil, err
 	}
 
-	header, err := parseIDHeader(pageHeader, packet)
-	if err != nil {
-		return nil, err
+	if string(pageHeader.sig[:]) != pageHeaderSignature {
+		return nil, errBadIDPageSignature
+	}
+
+	if pageHeader.headerType != pageHeaderTypeBeginningOfStream {
+		return nil, errBadIDPageType
 	}
 
-	if err = o.parseChannelMapping(header, packet); err != nil {
+	header, mapping, err := ParseOpusHead(packet)
+	if err != nil {
 		return nil, err
 	}
+	o.channelMapping = &mapping
 
 	return header, nil
 }
@@ -350,71 +367,69 @@ func generateChecksumTable() *[256]uint32 {
 	return &table
 }
 
-func parseIDHeader(pageHeader *OggPageHeader, packet []byte) (*OggHeader, error) {
-	if string(pageHeader.sig[:]) != pageHeaderSignature {
-		return nil, errBadIDPageSignature
-	}
-
-	if pageHeader.headerType != pageHeaderTypeBeginningOfStream {
-		return nil, errBadIDPageType
-	}
-
+// ParseOpusHead parses an Opus ID header, the OpusHead packet that Ogg
+// carries on its first page and Matroska as a track's CodecPrivate. The
+// channel mapping is empty for mapping family 0 and unknown families.
+//
+// https://tools.ietf.org/html/rfc7845.html#section-5.1
+func ParseOpusHead(packet []byte) (*OggHeader, OggChannelMapping, error) {
 	if len(packet) < idPagePayloadLength {
-		return nil, errBadIDPageLength
+		return nil, OggChannelMapping{}, errBadIDPageLength
 	}
 
 	if s := string(packet[:8]); s != idPageSignature {
-		return nil, errBadIDPagePayloadSignature
+		return nil, OggChannelMapping{}, errBadIDPagePayloadSignature
 	}
 
-	return &OggHeader{
+	header := &OggHeader{
 		Version:    packet[8],
 		Channels:   packet[9],
 		PreSkip:    binary.LittleEndian.Uint16(packet[10:12]),
 		SampleRate: binary.LittleEndian.Uint32(packet[12:16]),
 		OutputGain: binary.LittleEndian.Uint16(packet[16:18]),
 		ChannelMap: packet[18],
-	}, nil
+	}
+	mapping, err := parseChannelMapping(header, packet)
+	if err != nil {
+		return nil, OggChannelMapping{}, err
+	}
+
+	return header, mapping, nil
 }
 
-func (o *OggReader) parseChannelMapping(header *OggHeader, packet []byte) error {
+func parseChannelMapping(header *OggHeader, packet []byte) (OggChannelMapping, error) {
 	switch header.ChannelMap {
 	case 0:
 		if len(packet) != idPagePayloadLength {
-			return errBadIDPageLength
+			return OggChannelMapping{}, errBadIDPageLength
 		}
-		o.channelMapping = nil
 
-		return nil
+		return OggChannelMapping{}, nil
 	case 1, 2, 255:
-		return o.parseStreamChannelMapping(header, packet)
+		return parseStreamChannelMapping(header, packet)
 	case 3:
-		return o.parseDemixingChannelMapping(header, packet)
+		return parseDemixingChannelMapping(header, packet)
 	default:
-		o.channelMapping = nil
-
-		return nil
+		return OggChannelMapping{}, nil
 	}
 }
 
-func (o *OggReader) parseStreamChannelMapping(header *OggHeader, packet []byte) error {
+func parseStreamChannelMapping(header *OggHeader, packet []byte) (OggChannelMapping, error) {
 	expectedLength := idPageMappingIndex + int(header.Channels)
 	if len(packet) != expectedLength {
-		return errBadIDPageLength
+		return OggChannelMapping{}, errBadIDPageLength
 	}
 
-	o.channelMapping = &OggChannelMapping{
+	return OggChannelMapping{
 		StreamCount:  packet[idPageStreamCountIndex],
 		CoupledCount: packet[idPageCoupledCountIndex],
 		Mapping:      append([]uint8(nil), packet[idPageMappingIndex:]...),
-	}
-
-	return nil
+	}, nil
 }
 
-func (o *OggReader) parseDemixingChannelMapping(header *OggHeader, packet []byte) error {
+func parseDemixingChannelMapping(header *OggHeader, packet []byte) (OggChannelMapping, error) {
 	if len(packet) < idPageMappingIndex {
-		return errBadIDPageLength
+		return OggChannelMapping{}, errBadIDPageLength
 	}
 
 	streamCount := packet[idPageStreamCountIndex]
@@ -422,16 +437,14 @@ func (o *OggReader) parseDemixingChannelMapping(header *OggHeader, packet []byte
 	decodedChannels := int(streamCount) + int(coupledCount)
 	expectedLength := idPageMappingIndex + (2 * int(header.Channels) * decodedChannels)
 	if len(packet) != expectedLength {
-		return errBadIDPageLength
+		return OggChannelMapping{}, errBadIDPageLength
 	}
 
-	o.channelMapping = &OggChannelMapping{
+	return OggChannelMapping{
 		StreamCount:    streamCount,
 		CoupledCount:   coupledCount,
 		DemixingMatrix: parseDemixingMatrix(packet[idPageMappingIndex:]),
-	}
-
-	return nil
+	}, nil
 }
 
 func parseDemixingMatrix(in []byte) []int16 {
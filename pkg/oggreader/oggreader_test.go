@@ -57,6 +57,16 @@ func TestOggReader_ParseNextPage(t *testing.T) {
 	assert.ErrorIs(t, io.EOF, err)
 }
 
+func TestOggReader_PageHeaderFields(t *testing.T) {
+	reader, _, err := NewWith(bytes.NewReader(buildOggContainer()))
+	assert.NoError(t, err)
+
+	_, pageHeader, err := reader.ParseNextPacket()
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(0xaa209b8e), pageHeader.Serial())
+	assert.Equal(t, uint32(2), pageHeader.Sequence())
+}
+
 func TestOggReader_ParseErrors(t *testing.T) {
 	t.Run("Assert that Reader isn't nil", func(t *testing.T) {
 		_, _, err := NewWith(nil)
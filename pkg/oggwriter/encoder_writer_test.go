@@ -0,0 +1,47 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package oggwriter
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pion/opus"
+	"github.com/pion/opus/pkg/oggreader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncoderWriter(t *testing.T) {
+	for _, channels := range []int{1, 2} {
+		encoder, err := opus.NewEncoder(opus.WithChannels(channels))
+		require.NoError(t, err)
+
+		var out bytes.Buffer
+		writer, err := NewEncoderWriter(&out, encoder, WithSerial(1))
+		require.NoError(t, err)
+
+		const samples = 72000 + 333
+		_, err = writer.Write(make([]byte, samples*channels*2))
+		require.NoError(t, err)
+		require.NoError(t, writer.Close())
+
+		reader, header, err := oggreader.NewWith(bytes.NewReader(out.Bytes()))
+		require.NoError(t, err)
+		assert.Equal(t, uint8(channels), header.Channels) //nolint:gosec // 1 or 2.
+		assert.Equal(t, uint16(encoder.Lookahead()), header.PreSkip)
+		assert.Equal(t, uint32(48000), header.SampleRate)
+
+		// The final granule position trims the padding of the last frame.
+		packets, granules := readPackets(t, reader)
+		preSkip := encoder.Lookahead()
+		assert.Len(t, packets[1:], (preSkip+samples+encoder.FrameSize()-1)/encoder.FrameSize())
+		assert.Equal(t, uint64(preSkip+samples), granules[len(granules)-1])
+	}
+}
+
+func TestEncoderWriterNilEncoder(t *testing.T) {
+	_, err := NewEncoderWriter(&bytes.Buffer{}, nil)
+	assert.ErrorIs(t, err, errNilEncoder)
+}
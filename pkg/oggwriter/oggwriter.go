@@ -0,0 +1,455 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+// Package oggwriter implements the Ogg Opus media container writer
+package oggwriter
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"os"
+	"time"
+
+	"github.com/pion/opus/pkg/oggreader"
+)
+
+const (
+	pageHeaderTypeContinuedPacket   = 0x01
+	pageHeaderTypeBeginningOfStream = 0x02
+	pageHeaderTypeEndOfStream       = 0x04
+	pageHeaderSignature             = "OggS"
+	pageChecksumOffset              = 22
+
+	idPageSignature      = "OpusHead"
+	commentPageSignature = "OpusTags"
+	idPageVersion        = 1
+
+	pageHeaderLen       = 27
+	idPagePayloadLength = 19
+
+	maxPageSegments    = 255
+	maxPageSegmentSize = 255
+
+	// granuleSampleRate is the fixed rate Ogg Opus granule positions count in,
+	// whatever the input rate was (RFC 7845 Section 4).
+	granuleSampleRate = 48000
+
+	// defaultMaxPageDuration matches opusenc's default one-second page delay.
+	defaultMaxPageDuration = time.Second
+	defaultVendor          = "pion/opus"
+)
+
+// unknownGranulePosition marks a page on which no packet completes
+// (RFC 7845 Section 3).
+const unknownGranulePosition = ^uint64(0)
+
+var (
+	errNilStream               = errors.New("stream is nil")
+	errWriterClosed            = errors.New("writer is closed")
+	errInvalidChannelCount     = errors.New("channel count must be positive")
+	errChannelMappingMismatch  = errors.New("channel mapping does not match channel count")
+	errMissingChannelMapping   = errors.New("channel mapping family requires a channel mapping")
+	errInvalidMaxPageDuration  = errors.New("max page duration must be positive")
+	errGranulePositionRewinded = errors.New("granule position must not decrease")
+)
+
+// OggWriter is used to write Opus packets into an Ogg file.
+//
+// Packets are gathered into pages until a page holds MaxPageDuration of audio
+// or runs out of segments, so the container overhead stays small even for
+// 20 ms packets.
+type OggWriter struct {
+	stream          io.Writer
+	fd              *os.File
+	checksumTable   *[256]uint32
+	serial          uint32
+	pageIndex       uint32
+	vendor          string
+	comments        []string
+	channelMapping  oggreader.OggChannelMapping
+	maxPageDuration uint64
+	closed          bool
+
+	// The page being assembled. pageGranule is the granule position of the
+	// last packet completed on it, and pageContinued reports that it starts
+	// with the tail of a packet from the previous page.
+	segments          []byte
+	payload           []byte
+	pageGranule       uint64
+	pageHasPacketEnd  bool
+	pageContinued     bool
+	flushedGranule    uint64
+	lastGranule       uint64
+	hasWrittenPackets bool
+}
+
+// Option configures an OggWriter during construction.
+type Option func(*OggWriter) error
+
+// WithSerial sets the logical bitstream serial number. By default a random
+// serial is chosen, as RFC 3533 recommends.
+func WithSerial(serial uint32) Option {
+	return func(w *OggWriter) error {
+		w.serial = serial
+
+		return nil
+	}
+}
+
+// WithVendor sets the vendor string written to the comment header.
+func WithVendor(vendor string) Option {
+	return func(w *OggWriter) error {
+		w.vendor = vendor
+
+		return nil
+	}
+}
+
+// WithComments adds user comments, each of the form "TAG=value", to the
+// comment header (RFC 7845 Section 5.2).
+func WithComments(comments ...string) Option {
+	return func(w *OggWriter) error {
+		w.comments = append(w.comments, comments...)
+
+		return nil
+	}
+}
+
+// WithChannelMapping sets the stream count, coupled count and mapping table
+// written for channel mapping families other than 0.
+func WithChannelMapping(mapping oggreader.OggChannelMapping) Option {
+	return func(w *OggWriter) error {
+		w.channelMapping = oggreader.OggChannelMapping{
+			StreamCount:  mapping.StreamCount,
+			CoupledCount: mapping.CoupledCount,
+			Mapping:      append([]uint8(nil), mapping.Mapping...),
+		}
+
+		return nil
+	}
+}
+
+// WithMaxPageDuration bounds how much audio is gathered into one page before
+// it is written out. Live recorders that need each packet on disk promptly
+// can lower it; a duration shorter than one packet writes a page per packet.
+func WithMaxPageDuration(duration time.Duration) Option {
+	return func(w *OggWriter) error {
+		if duration <= 0 {
+			return errInvalidMaxPageDuration
+		}
+		w.maxPageDuration = uint64(duration.Nanoseconds()) * granuleSampleRate / uint64(time.Second)
+
+		return nil
+	}
+}
+
+// New builds a new Ogg Opus writer that writes to fileName.
+func New(fileName string, header oggreader.OggHeader, opts ...Option) (*OggWriter, error) {
+	fd, err := os.Create(fileName) // #nosec G304
+	if err != nil {
+		return nil, err
+	}
+
+	writer, err := NewWith(fd, header, opts...)
+	if err != nil {
+		return nil, errors.Join(err, fd.Close())
+	}
+	writer.fd = fd
+
+	return writer, nil
+}
+
+// NewWith builds a new Ogg Opus writer on top of out and writes the ID and
+// comment headers.
+//
+// header.Version of 0 is written as 1, and header.SampleRate of 0 as 48000.
+// A header.ChannelMap other than 0 needs WithChannelMapping.
+func NewWith(out io.Writer, header oggreader.OggHeader, opts ...Option) (*OggWriter, error) {
+	if out == nil {
+		return nil, errNilStream
+	}
+
+	writer := &OggWriter{
+		stream:          out,
+		checksumTable:   generateChecksumTable(),
+		serial:          rand.Uint32(), // #nosec G404
+		vendor:          defaultVendor,
+		maxPageDuration: uint64(defaultMaxPageDuration.Nanoseconds()) * granuleSampleRate / uint64(time.Second),
+	}
+	for _, opt := range opts {
+		if err := opt(writer); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writer.writeHeaders(header); err != nil {
+		return nil, err
+	}
+
+	return writer, nil
+}
+
+func (w *OggWriter) writeHeaders(header oggreader.OggHeader) error {
+	idHeader, err := OpusHead(header, w.channelMapping)
+	if err != nil {
+		return err
+	}
+
+	// RFC 7845 Section 3: the ID header sits alone on the first page, and the
+	// comment header finishes its own page before any audio data starts.
+	if err = w.writePage(pageHeaderTypeBeginningOfStream, 0, idHeader); err != nil {
+		return err
+	}
+	if err = w.writePacketPages(w.commentHeader()); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// OpusHead builds the Opus ID header (RFC 7845 Section 5.1) for header, as
+// the first Ogg packet and the CodecPrivate of WebM and Matroska carry it.
+// mapping is only written for a header.ChannelMap other than 0.
+//
+// header.Version of 0 is written as 1, and header.SampleRate of 0 as 48000.
+func OpusHead(header oggreader.OggHeader, mapping oggreader.OggChannelMapping) ([]byte, error) {
+	if header.Channels == 0 {
+		return nil, errInvalidChannelCount
+	}
+
+	version := header.Version
+	if version == 0 {
+		version = idPageVersion
+	}
+	sampleRate := header.SampleRate
+	if sampleRate == 0 {
+		sampleRate = granuleSampleRate
+	}
+
+	idHeader := make([]byte, idPagePayloadLength, idPagePayloadLength+2+int(header.Channels))
+	copy(idHeader, idPageSignature)
+	idHeader[8] = version
+	idHeader[9] = header.Channels
+	binary.LittleEndian.PutUint16(idHeader[10:], header.PreSkip)
+	binary.LittleEndian.PutUint32(idHeader[12:], sampleRate)
+	binary.LittleEndian.PutUint16(idHeader[16:], header.OutputGain)
+	idHeader[18] = header.ChannelMap
+
+	if header.ChannelMap == 0 {
+		return idHeader, nil
+	}
+
+	if len(mapping.Mapping) == 0 {
+		return nil, errMissingChannelMapping
+	}
+	if len(mapping.Mapping) != int(header.Channels) {
+		return nil, errChannelMappingMismatch
+	}
+	idHeader = append(idHeader, mapping.StreamCount, mapping.CoupledCount)
+
+	return append(idHeader, mapping.Mapping...), nil
+}
+
+func (w *OggWriter) commentHeader() []byte {
+	size := len(commentPageSignature) + 4 + len(w.vendor) + 4
+	for _, comment := range w.comments {
+		size += 4 + len(comment)
+	}
+
+	commentHeader := make([]byte, 0, size)
+	commentHeader = append(commentHeader, commentPageSignature...)
+	commentHeader = binary.LittleEndian.AppendUint32(commentHeader, uint32(len(w.vendor))) // #nosec G115
+	commentHeader = append(commentHeader, w.vendor...)
+	commentHeader = binary.LittleEndian.AppendUint32(commentHeader, uint32(len(w.comments))) // #nosec G115
+	for _, comment := range w.comments {
+		commentHeader = binary.LittleEndian.AppendUint32(commentHeader, uint32(len(comment))) // #nosec G115
+		commentHeader = append(commentHeader, comment...)
+	}
+
+	return commentHeader
+}
+
+// writePacketPages writes packet on pages of its own, used for the headers
+// that must not share a page with audio.
+func (w *OggWriter) writePacketPages(packet []byte) error {
+	if err := w.appendPacket(packet, 0); err != nil {
+		return err
+	}
+
+	return w.flushPage(0)
+}
+
+// WritePacket queues one Opus packet whose last sample ends at
+// granulePosition, counted in 48 kHz samples including the pre-skip
+// (RFC 7845 Section 4). Pages are written out as they fill up.
+func (w *OggWriter) WritePacket(packet []byte, granulePosition uint64) error {
+	if w.closed {
+		return errWriterClosed
+	}
+	if w.hasWrittenPackets && granulePosition < w.lastGranule {
+		return errGranulePositionRewinded
+	}
+
+	if err := w.appendPacket(packet, granulePosition); err != nil {
+		return err
+	}
+	w.lastGranule = granulePosition
+	w.hasWrittenPackets = true
+
+	if granulePosition-w.flushedGranule >= w.maxPageDuration {
+		return w.flushPage(0)
+	}
+
+	return nil
+}
+
+// appendPacket laces packet onto the open page, writing the page out and
+// continuing on a new one whenever the segment table fills up.
+func (w *OggWriter) appendPacket(packet []byte, granulePosition uint64) error {
+	for {
+		if len(w.segments) == maxPageSegments {
+			if err := w.flushPage(0); err != nil {
+				return err
+			}
+			w.pageContinued = true
+		}
+
+		segmentSize := min(len(packet), maxPageSegmentSize)
+		w.segments = append(w.segments, byte(segmentSize))
+		w.payload = append(w.payload, packet[:segmentSize]...)
+		packet = packet[segmentSize:]
+
+		// A packet ends on the first segment shorter than 255 bytes, so one
+		// that is an exact multiple of 255 needs a trailing empty segment.
+		if segmentSize < maxPageSegmentSize {
+			break
+		}
+	}
+
+	w.pageGranule = granulePosition
+	w.pageHasPacketEnd = true
+
+	return nil
+}
+
+// Flush writes out the open page, if any, so everything written so far is on
+// the underlying stream.
+func (w *OggWriter) Flush() error {
+	if w.closed {
+		return errWriterClosed
+	}
+
+	return w.flushPage(0)
+}
+
+func (w *OggWriter) flushPage(headerType uint8) error {
+	if len(w.segments) == 0 && headerType&pageHeaderTypeEndOfStream == 0 {
+		return nil
+	}
+
+	granulePosition := unknownGranulePosition
+	switch {
+	case !w.hasWrittenPackets:
+		// Header pages carry a granule position of zero (RFC 7845 Section 3).
+		granulePosition = 0
+	case w.pageHasPacketEnd:
+		granulePosition = w.pageGranule
+	case len(w.segments) == 0:
+		// An empty end-of-stream page repeats the last granule position.
+		granulePosition = w.lastGranule
+	}
+	if w.pageContinued {
+		headerType |= pageHeaderTypeContinuedPacket
+	}
+
+	if err := w.writePage(headerType, granulePosition, nil); err != nil {
+		return err
+	}
+	if w.pageHasPacketEnd {
+		w.flushedGranule = w.pageGranule
+	}
+	w.segments = w.segments[:0]
+	w.payload = w.payload[:0]
+	w.pageHasPacketEnd = false
+	w.pageContinued = false
+
+	return nil
+}
+
+// writePage writes one page. With packet set it is written as the only
+// packet on the page, otherwise the open page's segments are written.
+func (w *OggWriter) writePage(headerType uint8, granulePosition uint64, packet []byte) error {
+	segments, payload := w.segments, w.payload
+	if packet != nil {
+		segments = make([]byte, 0, len(packet)/maxPageSegmentSize+1)
+		for remaining := len(packet); ; remaining -= maxPageSegmentSize {
+			segments = append(segments, byte(min(remaining, maxPageSegmentSize)))
+			if remaining < maxPageSegmentSize {
+				break
+			}
+		}
+		payload = packet
+	}
+
+	page := make([]byte, pageHeaderLen+len(segments)+len(payload))
+	copy(page, pageHeaderSignature)
+	page[4] = 0
+	page[5] = headerType
+	binary.LittleEndian.PutUint64(page[6:], granulePosition)
+	binary.LittleEndian.PutUint32(page[14:], w.serial)
+	binary.LittleEndian.PutUint32(page[18:], w.pageIndex)
+	page[26] = byte(len(segments))
+	copy(page[pageHeaderLen:], segments)
+	copy(page[pageHeaderLen+len(segments):], payload)
+
+	var checksum uint32
+	for _, v := range page {
+		checksum = (checksum << 8) ^ w.checksumTable[byte(checksum>>24)^v]
+	}
+	binary.LittleEndian.PutUint32(page[pageChecksumOffset:], checksum)
+
+	if _, err := w.stream.Write(page); err != nil {
+		return err
+	}
+	w.pageIndex++
+
+	return nil
+}
+
+// Close writes the remaining packets on a final page flagged end-of-stream.
+// If the writer was created with New, the file is closed too.
+func (w *OggWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	err := w.flushPage(pageHeaderTypeEndOfStream)
+	if w.fd != nil {
+		err = errors.Join(err, w.fd.Close())
+	}
+
+	return err
+}
+
+func generateChecksumTable() *[256]uint32 {
+	var table [256]uint32
+	const poly = 0x04c11db7
+
+	for tableIndex := range uint32(256) {
+		r := tableIndex << 24
+
+		for range 8 {
+			if (r & 0x80000000) != 0 {
+				r = (r << 1) ^ poly
+			} else {
+				r <<= 1
+			}
+			table[tableIndex] = r
+		}
+	}
+
+	return &table
+}
@@ -0,0 +1,239 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package oggwriter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/pion/opus/pkg/oggreader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testPage struct {
+	headerType uint8
+	granule    uint64
+	index      uint32
+	segments   []byte
+}
+
+// splitPages walks the raw pages of an Ogg stream so tests can check the
+// header fields oggreader does not expose.
+func splitPages(t *testing.T, stream []byte) []testPage {
+	t.Helper()
+
+	var pages []testPage
+	for len(stream) > 0 {
+		require.GreaterOrEqual(t, len(stream), pageHeaderLen)
+		require.Equal(t, pageHeaderSignature, string(stream[:4]))
+
+		segmentCount := int(stream[26])
+		segments := stream[pageHeaderLen : pageHeaderLen+segmentCount]
+		payloadSize := 0
+		for _, size := range segments {
+			payloadSize += int(size)
+		}
+		pages = append(pages, testPage{
+			headerType: stream[5],
+			granule:    binary.LittleEndian.Uint64(stream[6:]),
+			index:      binary.LittleEndian.Uint32(stream[18:]),
+			segments:   append([]byte(nil), segments...),
+		})
+		stream = stream[pageHeaderLen+segmentCount+payloadSize:]
+	}
+
+	return pages
+}
+
+func readPackets(t *testing.T, reader *oggreader.OggReader) (packets [][]byte, granules []uint64) {
+	t.Helper()
+
+	for {
+		packet, header, err := reader.ParseNextPacket()
+		if errors.Is(err, io.EOF) {
+			return packets, granules
+		}
+		require.NoError(t, err)
+		packets = append(packets, packet)
+		granules = append(granules, header.GranulePosition)
+	}
+}
+
+func TestOggWriter_Headers(t *testing.T) {
+	var out bytes.Buffer
+	writer, err := NewWith(&out, oggreader.OggHeader{
+		Channels:   2,
+		PreSkip:    312,
+		SampleRate: 44100,
+		OutputGain: 256,
+	}, WithSerial(7), WithVendor("test"), WithComments("TITLE=tone", "ARTIST=pion"))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	reader, header, err := oggreader.NewWith(bytes.NewReader(out.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, &oggreader.OggHeader{
+		ChannelMap: 0,
+		Channels:   2,
+		OutputGain: 256,
+		PreSkip:    312,
+		SampleRate: 44100,
+		Version:    1,
+	}, header)
+
+	packets, _ := readPackets(t, reader)
+	require.Len(t, packets, 1)
+	want := []byte("OpusTags")
+	want = binary.LittleEndian.AppendUint32(want, 4)
+	want = append(want, "test"...)
+	want = binary.LittleEndian.AppendUint32(want, 2)
+	want = binary.LittleEndian.AppendUint32(want, 10)
+	want = append(want, "TITLE=tone"...)
+	want = binary.LittleEndian.AppendUint32(want, 11)
+	want = append(want, "ARTIST=pion"...)
+	assert.Equal(t, want, packets[0])
+
+	pages := splitPages(t, out.Bytes())
+	require.Len(t, pages, 3)
+	assert.Equal(t, uint8(pageHeaderTypeBeginningOfStream), pages[0].headerType)
+	assert.Equal(t, uint8(0), pages[1].headerType)
+	assert.Equal(t, uint8(pageHeaderTypeEndOfStream), pages[2].headerType)
+	for i, page := range pages {
+		assert.Equal(t, uint32(i), page.index) //nolint:gosec // Three pages.
+		assert.Zero(t, page.granule)
+	}
+}
+
+func TestOggWriter_ChannelMapping(t *testing.T) {
+	var out bytes.Buffer
+	mapping := oggreader.OggChannelMapping{StreamCount: 2, CoupledCount: 1, Mapping: []uint8{0, 1, 2}}
+	writer, err := NewWith(&out, oggreader.OggHeader{Channels: 3, ChannelMap: 1}, WithChannelMapping(mapping))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	reader, header, err := oggreader.NewWith(bytes.NewReader(out.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, uint8(1), header.ChannelMap)
+	assert.Equal(t, mapping, reader.ChannelMapping())
+
+	_, err = NewWith(&out, oggreader.OggHeader{Channels: 3, ChannelMap: 1})
+	assert.ErrorIs(t, err, errMissingChannelMapping)
+
+	_, err = NewWith(&out, oggreader.OggHeader{Channels: 2, ChannelMap: 1}, WithChannelMapping(mapping))
+	assert.ErrorIs(t, err, errChannelMappingMismatch)
+}
+
+func TestOggWriter_Errors(t *testing.T) {
+	_, err := NewWith(nil, oggreader.OggHeader{Channels: 1})
+	assert.ErrorIs(t, err, errNilStream)
+
+	_, err = NewWith(io.Discard, oggreader.OggHeader{})
+	assert.ErrorIs(t, err, errInvalidChannelCount)
+
+	_, err = NewWith(io.Discard, oggreader.OggHeader{Channels: 1}, WithMaxPageDuration(0))
+	assert.ErrorIs(t, err, errInvalidMaxPageDuration)
+
+	writer, err := NewWith(io.Discard, oggreader.OggHeader{Channels: 1})
+	require.NoError(t, err)
+	require.NoError(t, writer.WritePacket([]byte{0xf8}, 960))
+	assert.ErrorIs(t, writer.WritePacket([]byte{0xf8}, 480), errGranulePositionRewinded)
+
+	require.NoError(t, writer.Close())
+	require.NoError(t, writer.Close())
+	assert.ErrorIs(t, writer.WritePacket([]byte{0xf8}, 1920), errWriterClosed)
+	assert.ErrorIs(t, writer.Flush(), errWriterClosed)
+}
+
+func TestOggWriter_PagesGatherPackets(t *testing.T) {
+	var out bytes.Buffer
+	writer, err := NewWith(&out, oggreader.OggHeader{Channels: 1}, WithMaxPageDuration(100*time.Millisecond))
+	require.NoError(t, err)
+
+	var want [][]byte
+	for i := range 12 {
+		packet := bytes.Repeat([]byte{byte(i)}, 10+i)
+		want = append(want, packet)
+		require.NoError(t, writer.WritePacket(packet, uint64(i+1)*960)) //nolint:gosec // Small loop index.
+	}
+	require.NoError(t, writer.Close())
+
+	reader, _, err := oggreader.NewWith(bytes.NewReader(out.Bytes()))
+	require.NoError(t, err)
+	packets, granules := readPackets(t, reader)
+	require.Len(t, packets, 13)
+	assert.Equal(t, want, packets[1:])
+
+	// Five 20 ms packets fill each 100 ms page, and the page reports the
+	// granule position of the last packet that ends on it.
+	pages := splitPages(t, out.Bytes())
+	require.Len(t, pages, 5)
+	assert.Equal(t, uint64(5*960), pages[2].granule)
+	assert.Equal(t, uint64(10*960), pages[3].granule)
+	assert.Equal(t, uint64(12*960), pages[4].granule)
+	assert.Equal(t, uint8(pageHeaderTypeEndOfStream), pages[4].headerType)
+	assert.Equal(t, uint64(12*960), granules[len(granules)-1])
+}
+
+func TestOggWriter_PacketSpanningPages(t *testing.T) {
+	var out bytes.Buffer
+	writer, err := NewWith(&out, oggreader.OggHeader{Channels: 1})
+	require.NoError(t, err)
+
+	// 300 segments' worth of data cannot fit a single 255-entry segment table.
+	large := bytes.Repeat([]byte{0x5a}, 300*maxPageSegmentSize)
+	require.NoError(t, writer.WritePacket([]byte{1, 2, 3}, 960))
+	require.NoError(t, writer.WritePacket(large, 1920))
+	require.NoError(t, writer.WritePacket(bytes.Repeat([]byte{0x11}, maxPageSegmentSize), 2880))
+	require.NoError(t, writer.Close())
+
+	pages := splitPages(t, out.Bytes())
+	require.Len(t, pages, 4)
+	assert.Equal(t, uint64(960), pages[2].granule, "first packet ends on the page")
+	assert.Equal(t, uint8(0), pages[2].headerType)
+	assert.Equal(t, uint8(pageHeaderTypeContinuedPacket|pageHeaderTypeEndOfStream), pages[3].headerType)
+	// An exact multiple of 255 bytes ends with a zero-length segment.
+	assert.Equal(t, []byte{maxPageSegmentSize, 0}, pages[3].segments[len(pages[3].segments)-2:])
+
+	reader, _, err := oggreader.NewWith(bytes.NewReader(out.Bytes()))
+	require.NoError(t, err)
+	packets, granules := readPackets(t, reader)
+	require.Len(t, packets, 4)
+	assert.Equal(t, large, packets[2])
+	assert.Equal(t, uint64(2880), granules[3])
+}
+
+func TestOggWriter_FlushWritesOpenPage(t *testing.T) {
+	var out bytes.Buffer
+	writer, err := NewWith(&out, oggreader.OggHeader{Channels: 1})
+	require.NoError(t, err)
+
+	require.NoError(t, writer.WritePacket([]byte{0xf8, 0xff}, 960))
+	before := out.Len()
+	require.NoError(t, writer.Flush())
+	assert.Greater(t, out.Len(), before)
+
+	// Nothing is left to end the stream with, so Close writes an empty page.
+	require.NoError(t, writer.Close())
+	pages := splitPages(t, out.Bytes())
+	last := pages[len(pages)-1]
+	assert.Empty(t, last.segments)
+	assert.Equal(t, uint64(960), last.granule)
+	assert.Equal(t, uint8(pageHeaderTypeEndOfStream), last.headerType)
+}
+
+func TestOggWriter_New(t *testing.T) {
+	path := t.TempDir() + "/out.opus"
+	writer, err := New(path, oggreader.OggHeader{Channels: 1})
+	require.NoError(t, err)
+	require.NoError(t, writer.WritePacket([]byte{0xf8, 0xff}, 960))
+	require.NoError(t, writer.Close())
+
+	_, err = New(t.TempDir()+"/missing/out.opus", oggreader.OggHeader{Channels: 1})
+	assert.Error(t, err)
+}
@@ -0,0 +1,280 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+// Package opuscompare scores decoded audio against a reference the way the
+// opus_compare tool of the RFC 6716 reference implementation does, so the
+// decoder conformance test of RFC 6716 Section 6.1 needs no C toolchain.
+//
+// The score is a psychoacoustic one. Both signals are split into 21 bands of
+// short-time spectra, the reference's band energies are spread across
+// frequency and time as a masking threshold added to both spectra, and the
+// remaining per-bin error is weighted toward its worst frames. A quality of
+// 100 means the signals match; anything from 0 up passes the test vectors.
+package opuscompare
+
+import "math"
+
+const (
+	referenceRate = 48000
+	// bandCount and freqCount are the number of bands, and of the 100 Hz
+	// DFT bins they cover, at 48 kHz.
+	bandCount = 21
+	freqCount = 240
+	// windowSize and windowStep are the length and hop of the analysis
+	// window at 48 kHz: 10 ms windows every 2.5 ms.
+	windowSize = 480
+	windowStep = 120
+	// powerFloor is added to every bin's power so silence compares equal.
+	powerFloor = 100000
+)
+
+// bands are the bins, 100 Hz apart, each band starts at, derived from the
+// CELT bands.
+//
+//nolint:gochecknoglobals
+var bands = [bandCount + 1]int{
+	0, 2, 4, 6, 8, 10, 12, 14, 16, 20, 24, 28, 32, 40, 48, 56, 68, 80, 96, 120, 156, 200,
+}
+
+// Compare scores test, decoded at rate, against ref, both interleaved with
+// channels channels of 16-bit PCM. ref is at 48 kHz, and rate is 8000,
+// 12000, 16000, 24000 or 48000, so ref holds 48000/rate times as many
+// samples as test. pass reports whether quality is at least 0, the
+// threshold RFC 6716 Section 6.1 sets for conformance.
+//
+// Unlike opus_compare, whose first input is always stereo, a mono ref is
+// taken as is; downmix a stereo reference by averaging its channels. When
+// the inputs cannot be compared, because their lengths do not match, they
+// hold less than one 10 ms window or rate or channels is unsupported,
+// quality is NaN and pass is false.
+func Compare(ref, test []int16, rate, channels int) (quality float64, pass bool) {
+	downsample, testBands, ok := rateParameters(rate)
+	if !ok || channels < 1 || channels > 2 ||
+		len(ref)%channels != 0 || len(ref) != len(test)*downsample || len(ref)/channels < windowSize {
+		return math.NaN(), false
+	}
+
+	refSamples := len(ref) / channels
+	frameCount := (refSamples - windowSize + windowStep) / windowStep
+	testFreqs := freqCount / downsample
+
+	refBands := make([]float32, frameCount*bandCount*channels)
+	refPower := make([]float32, frameCount*freqCount*channels)
+	testPower := make([]float32, frameCount*testFreqs*channels)
+	bandEnergy(refBands, refPower, bandCount, toFloat32(ref), channels, frameCount, windowSize, windowStep, 1)
+	bandEnergy(nil, testPower, testBands, toFloat32(test), channels, frameCount,
+		windowSize/downsample, windowStep/downsample, downsample)
+
+	for frame := range frameCount {
+		applyMasking(refBands, refPower, testPower, frame, channels, testBands, testFreqs)
+	}
+	smoothFrames(refPower, freqCount, channels, frameCount, testBands)
+	smoothFrames(testPower, testFreqs, channels, frameCount, testBands)
+
+	err := weightedError(refPower, testPower, rate, channels, frameCount, testBands, testFreqs)
+	quality = float64(float32(100 * (1 - 0.5*math.Log(1+err)/math.Log(1.13))))
+
+	return quality, quality >= 0
+}
+
+// rateParameters returns how many times rate divides 48 kHz and how many of
+// the bands lie below its Nyquist frequency.
+func rateParameters(rate int) (downsample, testBands int, ok bool) {
+	switch rate {
+	case 8000:
+		return 6, 13, true
+	case 12000:
+		return 4, 15, true
+	case 16000:
+		return 3, 17, true
+	case 24000:
+		return 2, 19, true
+	case referenceRate:
+		return 1, bandCount, true
+	default:
+		return 0, 0, false
+	}
+}
+
+func toFloat32(in []int16) []float32 {
+	out := make([]float32, len(in))
+	for i, sample := range in {
+		out[i] = float32(sample)
+	}
+
+	return out
+}
+
+// bandEnergy computes the power spectrum of each windowed frame of in into
+// power, and when out is not nil, the mean power of each of the first
+// bandCount bands into out. A downsampled signal's spectrum is scaled up to
+// match that of the same signal at 48 kHz.
+func bandEnergy(
+	out, power []float32,
+	bandCount int,
+	in []float32,
+	channels, frameCount, windowSize, windowStep, downsample int,
+) {
+	window := make([]float32, windowSize)
+	cosine := make([]float32, windowSize)
+	sine := make([]float32, windowSize)
+	for i := range windowSize {
+		window[i] = 0.5 - 0.5*float32(math.Cos(float64(2*math.Pi/float32(windowSize-1)*float32(i))))
+		cosine[i] = float32(math.Cos(float64(2 * math.Pi / float32(windowSize) * float32(i))))
+		sine[i] = float32(math.Sin(float64(2 * math.Pi / float32(windowSize) * float32(i))))
+	}
+
+	powerSize := windowSize / 2
+	x := make([]float32, channels*windowSize)
+	for frame := range frameCount {
+		for channel := range channels {
+			for i := range windowSize {
+				x[channel*windowSize+i] = window[i] * in[(frame*windowStep+i)*channels+channel]
+			}
+		}
+
+		freq := 0
+		for band := range bandCount {
+			var bandPower [2]float32
+			for ; freq < bands[band+1]; freq++ {
+				for channel := range channels {
+					// A direct DFT of the one bin, stepping through the
+					// twiddle factors freq at a time.
+					var re, im float32
+					twiddle := 0
+					for i := range windowSize {
+						re += cosine[twiddle] * x[channel*windowSize+i]
+						im -= sine[twiddle] * x[channel*windowSize+i]
+						twiddle += freq
+						if twiddle >= windowSize {
+							twiddle -= windowSize
+						}
+					}
+					re *= float32(downsample)
+					im *= float32(downsample)
+					index := (frame*powerSize+freq)*channels + channel
+					power[index] = re*re + im*im + powerFloor
+					bandPower[channel] += power[index]
+				}
+			}
+			if out != nil {
+				width := float32(bands[band+1] - bands[band])
+				for channel := range channels {
+					out[(frame*bandCount+band)*channels+channel] = bandPower[channel] / width
+				}
+			}
+		}
+	}
+}
+
+// applyMasking spreads the reference's band energies of frame across
+// frequency, time and channels, and adds a tenth of the resulting threshold
+// to every bin of both spectra.
+func applyMasking(refBands, refPower, testPower []float32, frame, channels, testBands, testFreqs int) {
+	at := func(frame, band, channel int) *float32 {
+		return &refBands[(frame*bandCount+band)*channels+channel]
+	}
+
+	// Frequency masking, low to high: a 10 dB/Bark slope.
+	for band := 1; band < bandCount; band++ {
+		for channel := range channels {
+			*at(frame, band, channel) += 0.1 * *at(frame, band-1, channel)
+		}
+	}
+	// Frequency masking, high to low: a 15 dB/Bark slope.
+	for band := bandCount - 2; band >= 0; band-- {
+		for channel := range channels {
+			*at(frame, band, channel) += 0.03 * *at(frame, band+1, channel)
+		}
+	}
+	// Temporal masking: a -3 dB/2.5 ms slope.
+	if frame > 0 {
+		for band := range bandCount {
+			for channel := range channels {
+				*at(frame, band, channel) += 0.5 * *at(frame-1, band, channel)
+			}
+		}
+	}
+	// Some cross-talk between the channels.
+	if channels == 2 {
+		for band := range bandCount {
+			left, right := *at(frame, band, 0), *at(frame, band, 1)
+			*at(frame, band, 0) += 0.01 * right
+			*at(frame, band, 1) += 0.01 * left
+		}
+	}
+
+	for band := range testBands {
+		for freq := bands[band]; freq < bands[band+1]; freq++ {
+			for channel := range channels {
+				threshold := 0.1 * *at(frame, band, channel)
+				refPower[(frame*freqCount+freq)*channels+channel] += threshold
+				testPower[(frame*testFreqs+freq)*channels+channel] += threshold
+			}
+		}
+	}
+}
+
+// smoothFrames adds each frame's power to that of the frame after it, which
+// makes the comparison slightly less sensitive to timing.
+func smoothFrames(power []float32, freqs, channels, frameCount, testBands int) {
+	for freq := range bands[testBands] {
+		for channel := range channels {
+			previous := power[freq*channels+channel]
+			for frame := 1; frame < frameCount; frame++ {
+				index := (frame*freqs+freq)*channels + channel
+				current := power[index]
+				power[index] += previous
+				previous = current
+			}
+		}
+	}
+}
+
+// weightedError measures how far the masked test spectrum is from the
+// reference's, band by band, and pools the frames with an L16 norm so the
+// worst of them dominate.
+func weightedError(refPower, testPower []float32, rate, channels, frameCount, testBands, testFreqs int) float64 {
+	// Below 48 kHz the top 300 Hz are left out, to allow for different
+	// transition bands; at 12 kHz the last band already stops 400 Hz short.
+	maxCompare := bands[testBands] - 3
+	switch rate {
+	case referenceRate:
+		maxCompare = bands[bandCount]
+	case 12000:
+		maxCompare = bands[testBands]
+	}
+
+	var err float64
+	for frame := range frameCount {
+		var frameError float64
+		for band := range testBands {
+			var bandError float64
+			for freq := bands[band]; freq < bands[band+1] && freq < maxCompare; freq++ {
+				for channel := range channels {
+					ratio := testPower[(frame*testFreqs+freq)*channels+channel] /
+						refPower[(frame*freqCount+freq)*channels+channel]
+					binError := float32(float64(ratio) - math.Log(float64(ratio)) - 1)
+					// Be more lenient around the SILK/CELT crossover at 8 kHz,
+					// leaving the modes freedom in their filters.
+					if freq >= 79 && freq <= 81 {
+						binError *= 0.1
+					}
+					if freq == 80 {
+						binError *= 0.1
+					}
+					bandError += float64(binError)
+				}
+			}
+			bandError /= float64((bands[band+1] - bands[band]) * channels)
+			frameError += bandError * bandError
+		}
+		// A fixed normalization accepts slightly lower quality at lower
+		// rates.
+		frameError /= bandCount
+		frameError *= frameError
+		err += frameError * frameError
+	}
+
+	return math.Pow(err/float64(frameCount), 1.0/16)
+}
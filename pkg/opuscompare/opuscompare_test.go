@@ -0,0 +1,96 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package opuscompare
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// tone returns frames frames of a 440 Hz and 1.5 kHz chord at rate, with
+// the right channel at half the level when channels is 2.
+func tone(frames, rate, channels int) []int16 {
+	pcm := make([]int16, frames*channels)
+	for i := range frames {
+		t := float64(i) / float64(rate)
+		sample := 8000*math.Sin(2*math.Pi*440*t) + 4000*math.Sin(2*math.Pi*1500*t)
+		for channel := range channels {
+			pcm[i*channels+channel] = int16(sample / float64(channel+1))
+		}
+	}
+
+	return pcm
+}
+
+func addNoise(pcm []int16, amplitude float64) []int16 {
+	random := rand.New(rand.NewSource(1)) //nolint:gosec
+	noisy := make([]int16, len(pcm))
+	for i, sample := range pcm {
+		noisy[i] = int16(float64(sample) + amplitude*(2*random.Float64()-1))
+	}
+
+	return noisy
+}
+
+func TestCompare(t *testing.T) {
+	ref := tone(48000, 48000, 1)
+
+	quality, pass := Compare(ref, ref, 48000, 1)
+	assert.True(t, pass)
+	assert.InDelta(t, 100, quality, 1e-6)
+
+	quality, pass = Compare(ref, addNoise(ref, 8), 48000, 1)
+	assert.True(t, pass)
+	assert.Greater(t, quality, 80.0)
+	assert.Less(t, quality, 100.0)
+
+	quality, pass = Compare(ref, addNoise(ref, 1000), 48000, 1)
+	assert.False(t, pass)
+	assert.Less(t, quality, 0.0)
+
+	// Silence in place of the signal.
+	_, pass = Compare(ref, make([]int16, len(ref)), 48000, 1)
+	assert.False(t, pass)
+}
+
+func TestCompareRates(t *testing.T) {
+	ref := tone(48000, 48000, 2)
+	for _, rate := range []int{8000, 12000, 16000, 24000} {
+		quality, pass := Compare(ref, tone(rate, rate, 2), rate, 2)
+		assert.True(t, pass, "%d Hz", rate)
+		assert.Greater(t, quality, 50.0, "%d Hz", rate)
+	}
+
+	// Swapping the channels is wrong however good each one is.
+	swapped := make([]int16, len(ref))
+	for i := 0; i < len(ref); i += 2 {
+		swapped[i], swapped[i+1] = ref[i+1], ref[i]
+	}
+	_, pass := Compare(ref, swapped, 48000, 2)
+	assert.False(t, pass)
+}
+
+func TestCompareInvalid(t *testing.T) {
+	ref := tone(4800, 48000, 1)
+	for _, test := range []struct {
+		name           string
+		ref, test      []int16
+		rate, channels int
+	}{
+		{"length mismatch", ref, ref[:len(ref)-1], 48000, 1},
+		{"unsupported rate", ref, ref, 44100, 1},
+		{"unsupported channels", ref, ref, 48000, 3},
+		{"odd stereo length", ref[:len(ref)-1], ref[:len(ref)-1], 48000, 2},
+		{"shorter than a window", ref[:479], ref[:479], 48000, 1},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			quality, pass := Compare(test.ref, test.test, test.rate, test.channels)
+			assert.True(t, math.IsNaN(quality))
+			assert.False(t, pass)
+		})
+	}
+}
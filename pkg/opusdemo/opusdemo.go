@@ -0,0 +1,137 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+// Package opusdemo reads and writes the bitstream format of libopus's
+// opus_demo tool, in which the RFC 6716 test vectors are also stored.
+//
+// Each packet is preceded by its length and by the encoder's final range
+// (RFC 6716 Section 4.1), both 4-byte big-endian. A length of 0 marks a lost
+// packet. The final range lets a decoder check, packet by packet, that it
+// ended in the same state as the encoder, which holds only when the two are
+// bit-exact.
+package opusdemo
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	headerSize = 8
+	// maxPacketSize bounds the packet lengths read: 120 ms of 1275-byte
+	// frames and their framing.
+	maxPacketSize = 48 * 1276
+)
+
+var (
+	errNilStream          = errors.New("stream is nil")
+	errTruncatedBitstream = errors.New("truncated bitstream")
+	errPacketTooLong      = errors.New("packet longer than an Opus packet can be")
+	errFinalRangeMismatch = errors.New("final range mismatch")
+)
+
+// Packet is one packet of a bitstream.
+type Packet struct {
+	// Payload is the Opus packet, empty when the packet was lost.
+	Payload []byte
+	// FinalRange is the encoder's final range after coding the packet, or 0
+	// when the writer did not know it.
+	FinalRange uint32
+}
+
+// Lost reports whether the bitstream marks the packet as lost.
+func (p Packet) Lost() bool {
+	return len(p.Payload) == 0
+}
+
+// Reader reads packets from an opus_demo bitstream.
+type Reader struct {
+	stream io.Reader
+	index  int
+	last   Packet
+}
+
+// NewReader returns a Reader reading from in.
+func NewReader(in io.Reader) (*Reader, error) {
+	if in == nil {
+		return nil, errNilStream
+	}
+
+	return &Reader{stream: in, index: -1}, nil
+}
+
+// ReadPacket returns the next packet. At the end of the bitstream it returns
+// io.EOF, unless the bitstream ends within a packet.
+func (r *Reader) ReadPacket() (Packet, error) {
+	var header [headerSize]byte
+	if _, err := io.ReadFull(r.stream, header[:]); errors.Is(err, io.EOF) {
+		return Packet{}, io.EOF
+	} else if err != nil {
+		return Packet{}, fmt.Errorf("%w: packet %d header: %w", errTruncatedBitstream, r.index+1, err)
+	}
+	r.index++
+
+	length := binary.BigEndian.Uint32(header[:])
+	if length > maxPacketSize {
+		return Packet{}, fmt.Errorf("%w: packet %d of %d bytes", errPacketTooLong, r.index, length)
+	}
+	packet := Packet{Payload: make([]byte, length), FinalRange: binary.BigEndian.Uint32(header[4:])}
+	if _, err := io.ReadFull(r.stream, packet.Payload); err != nil {
+		return Packet{}, fmt.Errorf("%w: packet %d: %w", errTruncatedBitstream, r.index, err)
+	}
+	r.last = packet
+
+	return packet, nil
+}
+
+// Verify checks finalRange, the final range of the decoder or encoder that
+// has just coded the packet ReadPacket last returned, against the one the
+// bitstream records for it. Lost packets and packets recorded with a final
+// range of 0 are not checked.
+func (r *Reader) Verify(finalRange uint32) error {
+	if r.last.Lost() || r.last.FinalRange == 0 || r.last.FinalRange == finalRange {
+		return nil
+	}
+
+	return fmt.Errorf("%w: packet %d: want 0x%08x, got 0x%08x",
+		errFinalRangeMismatch, r.index, r.last.FinalRange, finalRange)
+}
+
+// Writer writes packets as an opus_demo bitstream.
+type Writer struct {
+	stream io.Writer
+}
+
+// NewWriter returns a Writer writing to out.
+func NewWriter(out io.Writer) (*Writer, error) {
+	if out == nil {
+		return nil, errNilStream
+	}
+
+	return &Writer{stream: out}, nil
+}
+
+// WritePacket writes payload with the encoder's final range after coding
+// it. An empty payload marks a lost packet.
+func (w *Writer) WritePacket(payload []byte, finalRange uint32) error {
+	if len(payload) > maxPacketSize {
+		return fmt.Errorf("%w: %d bytes", errPacketTooLong, len(payload))
+	}
+
+	var header [headerSize]byte
+	binary.BigEndian.PutUint32(header[:], uint32(len(payload))) //nolint:gosec // G115
+	binary.BigEndian.PutUint32(header[4:], finalRange)
+	if _, err := w.stream.Write(header[:]); err != nil {
+		return err
+	}
+	_, err := w.stream.Write(payload)
+
+	return err
+}
+
+// WriteLost writes a lost packet.
+func (w *Writer) WriteLost() error {
+	return w.WritePacket(nil, 0)
+}
@@ -0,0 +1,78 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package opusdemo
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundTrip(t *testing.T) {
+	var buffer bytes.Buffer
+	writer, err := NewWriter(&buffer)
+	require.NoError(t, err)
+	require.NoError(t, writer.WritePacket([]byte{0xfc, 0x01, 0x02}, 0x12345678))
+	require.NoError(t, writer.WriteLost())
+	require.NoError(t, writer.WritePacket([]byte{0x78}, 0))
+	assert.Equal(t, []byte{
+		0, 0, 0, 3, 0x12, 0x34, 0x56, 0x78, 0xfc, 0x01, 0x02,
+		0, 0, 0, 0, 0, 0, 0, 0,
+		0, 0, 0, 1, 0, 0, 0, 0, 0x78,
+	}, buffer.Bytes())
+
+	reader, err := NewReader(&buffer)
+	require.NoError(t, err)
+
+	packet, err := reader.ReadPacket()
+	require.NoError(t, err)
+	assert.Equal(t, Packet{Payload: []byte{0xfc, 0x01, 0x02}, FinalRange: 0x12345678}, packet)
+	assert.False(t, packet.Lost())
+	assert.NoError(t, reader.Verify(0x12345678))
+	assert.ErrorIs(t, reader.Verify(0x12345679), errFinalRangeMismatch)
+
+	packet, err = reader.ReadPacket()
+	require.NoError(t, err)
+	assert.True(t, packet.Lost())
+	assert.NoError(t, reader.Verify(0xdeadbeef), "lost packets are not checked")
+
+	packet, err = reader.ReadPacket()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x78}, packet.Payload)
+	assert.NoError(t, reader.Verify(0xdeadbeef), "a final range of 0 is not checked")
+
+	_, err = reader.ReadPacket()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestReaderErrors(t *testing.T) {
+	_, err := NewReader(nil)
+	assert.ErrorIs(t, err, errNilStream)
+	_, err = NewWriter(nil)
+	assert.ErrorIs(t, err, errNilStream)
+
+	for _, test := range []struct {
+		name      string
+		bitstream []byte
+		err       error
+	}{
+		{"truncated header", []byte{0, 0, 0, 1, 0}, errTruncatedBitstream},
+		{"truncated payload", []byte{0, 0, 0, 2, 0, 0, 0, 0, 0x78}, errTruncatedBitstream},
+		{"packet too long", []byte{0, 1, 0, 0, 0, 0, 0, 0}, errPacketTooLong},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			reader, err := NewReader(bytes.NewReader(test.bitstream))
+			require.NoError(t, err)
+			_, err = reader.ReadPacket()
+			assert.ErrorIs(t, err, test.err)
+		})
+	}
+
+	writer, err := NewWriter(io.Discard)
+	require.NoError(t, err)
+	assert.ErrorIs(t, writer.WritePacket(make([]byte, maxPacketSize+1), 0), errPacketTooLong)
+}
@@ -0,0 +1,381 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+// Package pcapreader reads packet captures in the pcap and pcapng formats, as
+// tcpdump and Wireshark write them, and extracts the UDP datagrams they hold.
+//
+// NewWith tells the two formats apart by their first bytes and handles either
+// byte order, microsecond and nanosecond pcap files, and pcapng files with
+// several sections and interfaces. ParseUDP then unwraps a packet's link
+// layer, IPv4 or IPv6, and UDP headers. Fragmented IP packets are not
+// reassembled.
+package pcapreader
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"time"
+)
+
+// LinkType is the link layer header type of a capture's packets, as
+// registered at tcpdump.org.
+type LinkType uint16
+
+// The link types ParseUDP understands.
+const (
+	LinkTypeNull      LinkType = 0
+	LinkTypeEthernet  LinkType = 1
+	LinkTypeRaw       LinkType = 101
+	LinkTypeLoop      LinkType = 108
+	LinkTypeLinuxSLL  LinkType = 113
+	LinkTypeIPv4      LinkType = 228
+	LinkTypeIPv6      LinkType = 229
+	LinkTypeLinuxSLL2 LinkType = 276
+)
+
+const (
+	pcapMagicMicroseconds = 0xA1B2C3D4
+	pcapMagicNanoseconds  = 0xA1B23C4D
+	pcapHeaderLength      = 24
+	pcapRecordLength      = 16
+
+	pcapngByteOrderMagic = 0x1A2B3C4D
+	blockHeaderLength    = 12 // the type and the length before the body, the length after it
+
+	blockSectionHeader  = 0x0A0D0D0A
+	blockInterface      = 0x00000001
+	blockPacket         = 0x00000002 // obsolete, but still read
+	blockSimplePacket   = 0x00000003
+	blockEnhancedPacket = 0x00000006
+
+	optionEndOfOptions = 0
+	optionTSResolution = 9
+	optionTSOffset     = 14
+
+	defaultTSResolution = 6 // microseconds
+	linkTypeMask        = 0xFFFF
+
+	// maxBlockLength bounds the memory a corrupt length can make the reader
+	// allocate.
+	maxBlockLength = 16 << 20
+)
+
+var (
+	errNilStream           = errors.New("stream is nil")
+	errUnknownFormat       = errors.New("neither a pcap nor a pcapng file")
+	errMalformedBlock      = errors.New("malformed pcapng block")
+	errBlockTooLarge       = errors.New("pcapng block too large")
+	errRecordTooLarge      = errors.New("pcap record too large")
+	errUnknownInterface    = errors.New("packet of an undescribed interface")
+	errInvalidTSResolution = errors.New("invalid timestamp resolution")
+)
+
+// Packet is a captured packet.
+type Packet struct {
+	Timestamp time.Time
+	LinkType  LinkType
+	// Data is the captured bytes, which may be fewer than Length, the size of
+	// the packet on the wire.
+	Data   []byte
+	Length int
+}
+
+// iface is an interface of a pcapng section.
+type iface struct {
+	linkType LinkType
+	// The timestamp resolution, a power of ten unless binary is set, and the
+	// seconds to add to the timestamps.
+	resolution uint8
+	binary     bool
+	offset     int64
+}
+
+// PCAPReader reads the packets of a pcap or pcapng file.
+type PCAPReader struct {
+	input     *bufio.Reader
+	pcapng    bool
+	byteOrder binary.ByteOrder
+
+	// The link type and timestamp resolution of a pcap file.
+	linkType    LinkType
+	nanoseconds bool
+
+	// The interfaces of the current pcapng section.
+	interfaces []iface
+}
+
+// NewWith returns a new capture reader reading from in, having read the pcap
+// file header or the first pcapng section header.
+func NewWith(in io.Reader) (*PCAPReader, error) {
+	if in == nil {
+		return nil, errNilStream
+	}
+
+	reader := &PCAPReader{input: bufio.NewReader(in)}
+	magic, err := reader.input.Peek(4)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errUnknownFormat, err)
+	}
+	if binary.BigEndian.Uint32(magic) == blockSectionHeader {
+		reader.pcapng = true
+		if err = reader.readSectionHeader(); err != nil {
+			return nil, err
+		}
+
+		return reader, nil
+	}
+
+	return reader, reader.readPCAPHeader()
+}
+
+func (r *PCAPReader) readPCAPHeader() error {
+	header := make([]byte, pcapHeaderLength)
+	if _, err := io.ReadFull(r.input, header); err != nil {
+		return fmt.Errorf("%w: %w", errUnknownFormat, err)
+	}
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		switch order.Uint32(header) {
+		case pcapMagicMicroseconds:
+		case pcapMagicNanoseconds:
+			r.nanoseconds = true
+		default:
+			continue
+		}
+		r.byteOrder = order
+		r.linkType = LinkType(order.Uint32(header[20:]) & linkTypeMask)
+
+		return nil
+	}
+
+	return errUnknownFormat
+}
+
+// ReadPacket returns the next packet of the capture, and io.EOF at its end.
+func (r *PCAPReader) ReadPacket() (*Packet, error) {
+	if r.pcapng {
+		return r.readBlocks()
+	}
+
+	record := make([]byte, pcapRecordLength)
+	if _, err := io.ReadFull(r.input, record); err != nil {
+		return nil, err
+	}
+	seconds := r.byteOrder.Uint32(record)
+	fraction := r.byteOrder.Uint32(record[4:])
+	captured := r.byteOrder.Uint32(record[8:])
+	if captured > maxBlockLength {
+		return nil, fmt.Errorf("%w: %d bytes", errRecordTooLarge, captured)
+	}
+	data := make([]byte, captured)
+	if _, err := io.ReadFull(r.input, data); err != nil {
+		return nil, noEOF(err)
+	}
+	if !r.nanoseconds {
+		fraction *= uint32(time.Microsecond)
+	}
+
+	return &Packet{
+		Timestamp: time.Unix(int64(seconds), int64(fraction)),
+		LinkType:  r.linkType,
+		Data:      data,
+		Length:    int(r.byteOrder.Uint32(record[12:])),
+	}, nil
+}
+
+// noEOF turns the end of the input inside a record or block into
+// io.ErrUnexpectedEOF.
+func noEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
+
+// readBlock reads a pcapng block and returns its type and body.
+func (r *PCAPReader) readBlock() (blockType uint32, body []byte, err error) {
+	header := make([]byte, 8)
+	if _, err = io.ReadFull(r.input, header); err != nil {
+		return 0, nil, err
+	}
+	blockType = r.byteOrder.Uint32(header)
+	length := r.byteOrder.Uint32(header[4:])
+	if length < blockHeaderLength || length%4 != 0 {
+		return 0, nil, fmt.Errorf("%w: length %d", errMalformedBlock, length)
+	}
+	if length > maxBlockLength {
+		return 0, nil, fmt.Errorf("%w: %d bytes", errBlockTooLarge, length)
+	}
+	body = make([]byte, length-8)
+	if _, err = io.ReadFull(r.input, body); err != nil {
+		return 0, nil, noEOF(err)
+	}
+
+	return blockType, body[:len(body)-4], nil
+}
+
+// readSectionHeader reads a section header block, which sets the byte order
+// of the section and starts it without interfaces.
+func (r *PCAPReader) readSectionHeader() error {
+	header, err := r.input.Peek(12)
+	if err != nil {
+		return noEOF(err)
+	}
+	switch uint32(pcapngByteOrderMagic) {
+	case binary.LittleEndian.Uint32(header[8:]):
+		r.byteOrder = binary.LittleEndian
+	case binary.BigEndian.Uint32(header[8:]):
+		r.byteOrder = binary.BigEndian
+	default:
+		return fmt.Errorf("%w: byte-order magic", errMalformedBlock)
+	}
+	if _, _, err = r.readBlock(); err != nil {
+		return err
+	}
+	r.interfaces = r.interfaces[:0]
+
+	return nil
+}
+
+// readBlocks reads pcapng blocks up to the next one holding a packet.
+func (r *PCAPReader) readBlocks() (*Packet, error) {
+	for {
+		typ, err := r.input.Peek(4)
+		if len(typ) > 0 && err != nil {
+			return nil, noEOF(err)
+		} else if err != nil {
+			return nil, err
+		}
+		if binary.BigEndian.Uint32(typ) == blockSectionHeader {
+			if err = r.readSectionHeader(); err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		blockType, body, err := r.readBlock()
+		if err != nil {
+			return nil, err
+		}
+		switch blockType {
+		case blockInterface:
+			err = r.parseInterface(body)
+		case blockEnhancedPacket, blockPacket, blockSimplePacket:
+			return r.parsePacket(blockType, body)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (r *PCAPReader) parseInterface(body []byte) error {
+	if len(body) < 8 {
+		return fmt.Errorf("%w: interface description", errMalformedBlock)
+	}
+	description := iface{linkType: LinkType(r.byteOrder.Uint16(body)), resolution: defaultTSResolution}
+	for options := body[8:]; len(options) >= 4; {
+		code := r.byteOrder.Uint16(options)
+		length := int(r.byteOrder.Uint16(options[2:]))
+		if 4+length > len(options) {
+			return fmt.Errorf("%w: option of %d bytes", errMalformedBlock, length)
+		}
+		value := options[4 : 4+length]
+		options = options[min(len(options), 4+(length+3)/4*4):]
+		switch {
+		case code == optionEndOfOptions:
+			options = nil
+		case code == optionTSResolution && length == 1:
+			description.resolution = value[0] & 0x7F
+			description.binary = value[0]&0x80 != 0
+			if !description.binary && description.resolution > 18 || description.binary && description.resolution > 63 {
+				return fmt.Errorf("%w: 0x%02X", errInvalidTSResolution, value[0])
+			}
+		case code == optionTSOffset && length == 8:
+			description.offset = int64(r.byteOrder.Uint64(value)) //nolint:gosec // G115, signed by definition.
+		}
+	}
+	r.interfaces = append(r.interfaces, description)
+
+	return nil
+}
+
+func (r *PCAPReader) parsePacket(blockType uint32, body []byte) (*Packet, error) {
+	var interfaceID, captured, length uint32
+	var units uint64
+	var data []byte
+	switch blockType {
+	case blockSimplePacket:
+		if len(body) < 4 {
+			return nil, fmt.Errorf("%w: simple packet", errMalformedBlock)
+		}
+		length = r.byteOrder.Uint32(body)
+		data = body[4:]
+		captured = min(length, uint32(len(data))) //nolint:gosec // G115, bounded by maxBlockLength.
+	case blockPacket:
+		if len(body) < 20 {
+			return nil, fmt.Errorf("%w: packet", errMalformedBlock)
+		}
+		interfaceID = uint32(r.byteOrder.Uint16(body))
+		units = uint64(r.byteOrder.Uint32(body[4:]))<<32 | uint64(r.byteOrder.Uint32(body[8:]))
+		captured, length, data = r.byteOrder.Uint32(body[12:]), r.byteOrder.Uint32(body[16:]), body[20:]
+	default:
+		if len(body) < 20 {
+			return nil, fmt.Errorf("%w: enhanced packet", errMalformedBlock)
+		}
+		interfaceID = r.byteOrder.Uint32(body)
+		units = uint64(r.byteOrder.Uint32(body[4:]))<<32 | uint64(r.byteOrder.Uint32(body[8:]))
+		captured, length, data = r.byteOrder.Uint32(body[12:]), r.byteOrder.Uint32(body[16:]), body[20:]
+	}
+	if int(interfaceID) >= len(r.interfaces) {
+		return nil, fmt.Errorf("%w: %d", errUnknownInterface, interfaceID)
+	}
+	if int(captured) > len(data) {
+		return nil, fmt.Errorf("%w: %d bytes captured in %d", errMalformedBlock, captured, len(data))
+	}
+	description := r.interfaces[interfaceID]
+
+	packet := &Packet{
+		LinkType: description.linkType,
+		Data:     data[:captured],
+		Length:   int(length),
+	}
+	if blockType != blockSimplePacket {
+		packet.Timestamp = description.time(units)
+	}
+
+	return packet, nil
+}
+
+// time converts a timestamp in units of the interface's resolution.
+func (i iface) time(units uint64) time.Time {
+	var seconds, nanoseconds uint64
+	if i.binary {
+		seconds = units >> i.resolution
+		high, low := bits.Mul64(units&(1<<i.resolution-1), uint64(time.Second))
+		nanoseconds, _ = bits.Div64(high, low, 1<<i.resolution)
+	} else {
+		scale := uint64(1)
+		for range i.resolution {
+			scale *= 10
+		}
+		seconds = units / scale
+		nanoseconds = units % scale
+		if i.resolution <= 9 {
+			for range 9 - i.resolution {
+				nanoseconds *= 10
+			}
+		} else {
+			for range i.resolution - 9 {
+				nanoseconds /= 10
+			}
+		}
+	}
+
+	return time.Unix(int64(seconds)+i.offset, int64(nanoseconds)) //nolint:gosec // G115
+}
@@ -0,0 +1,245 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package pcapreader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//nolint:gochecknoglobals
+var (
+	testSource       = netip.MustParseAddrPort("192.0.2.1:5004")
+	testDestination  = netip.MustParseAddrPort("198.51.100.2:6000")
+	testSource6      = netip.MustParseAddrPort("[2001:db8::1]:5004")
+	testDestination6 = netip.MustParseAddrPort("[2001:db8::2]:6000")
+)
+
+func udp(source, destination netip.AddrPort, payload []byte) []byte {
+	header := binary.BigEndian.AppendUint16(nil, source.Port())
+	header = binary.BigEndian.AppendUint16(header, destination.Port())
+	header = binary.BigEndian.AppendUint16(header, uint16(udpLength+len(payload))) //nolint:gosec // G115
+	header = binary.BigEndian.AppendUint16(header, 0)
+
+	return append(header, payload...)
+}
+
+func ipv4(protocol byte, fragment uint16, payload []byte) []byte {
+	header := []byte{0x45, 0}
+	header = binary.BigEndian.AppendUint16(header, uint16(ipv4MinLength+len(payload))) //nolint:gosec // G115
+	header = binary.BigEndian.AppendUint16(header, 0)
+	header = binary.BigEndian.AppendUint16(header, fragment)
+	header = append(header, 64, protocol, 0, 0)
+	header = append(header, testSource.Addr().AsSlice()...)
+	header = append(header, testDestination.Addr().AsSlice()...)
+
+	return append(header, payload...)
+}
+
+// ipv6 builds an IPv6 packet with a hop-by-hop options header.
+func ipv6(payload []byte) []byte {
+	header := []byte{0x60, 0, 0, 0}
+	header = binary.BigEndian.AppendUint16(header, uint16(8+len(payload))) //nolint:gosec // G115
+	header = append(header, protocolHopByHop, 64)
+	header = append(header, testSource6.Addr().AsSlice()...)
+	header = append(header, testDestination6.Addr().AsSlice()...)
+	header = append(header, protocolUDP, 0, 1, 4, 0, 0, 0, 0)
+
+	return append(header, payload...)
+}
+
+// ethernet builds an Ethernet frame with a VLAN tag.
+func ethernet(etherType uint16, payload []byte) []byte {
+	frame := make([]byte, 12, ethernetLength+vlanTagLength+len(payload))
+	frame = binary.BigEndian.AppendUint16(frame, etherTypeVLAN)
+	frame = binary.BigEndian.AppendUint16(frame, 42)
+	frame = binary.BigEndian.AppendUint16(frame, etherType)
+
+	return append(frame, payload...)
+}
+
+func TestPCAP(t *testing.T) {
+	for _, test := range []struct {
+		order   binary.AppendByteOrder
+		magic   uint32
+		seconds uint32
+		frac    uint32
+	}{
+		{binary.LittleEndian, pcapMagicMicroseconds, 1700000000, 250000},
+		{binary.BigEndian, pcapMagicNanoseconds, 1700000000, 250000000},
+	} {
+		file := test.order.AppendUint32(nil, test.magic)
+		file = test.order.AppendUint16(file, 2)
+		file = test.order.AppendUint16(file, 4)
+		file = append(file, make([]byte, 8)...)
+		file = test.order.AppendUint32(file, 65535)
+		file = test.order.AppendUint32(file, uint32(LinkTypeEthernet)|0x10000000) // with FCS length bits
+		for _, frame := range [][]byte{
+			ethernet(etherTypeIPv4, ipv4(protocolUDP, 0, udp(testSource, testDestination, []byte("rtp")))),
+			ethernet(etherTypeIPv4, ipv4(6, 0, make([]byte, 20))),
+		} {
+			file = test.order.AppendUint32(file, test.seconds)
+			file = test.order.AppendUint32(file, test.frac)
+			file = test.order.AppendUint32(file, uint32(len(frame))) //nolint:gosec // G115
+			file = test.order.AppendUint32(file, uint32(len(frame))) //nolint:gosec // G115
+			file = append(file, frame...)
+		}
+
+		reader, err := NewWith(bytes.NewReader(file))
+		require.NoError(t, err)
+		packet, err := reader.ReadPacket()
+		require.NoError(t, err)
+		assert.Equal(t, LinkTypeEthernet, packet.LinkType)
+		assert.True(t, time.Unix(1700000000, 250000000).Equal(packet.Timestamp))
+		datagram, err := ParseUDP(packet)
+		require.NoError(t, err)
+		assert.Equal(t, &Datagram{Source: testSource, Destination: testDestination, Payload: []byte("rtp")}, datagram)
+
+		packet, err = reader.ReadPacket()
+		require.NoError(t, err)
+		_, err = ParseUDP(packet)
+		assert.ErrorIs(t, err, errNotUDP, "TCP")
+
+		_, err = reader.ReadPacket()
+		assert.ErrorIs(t, err, io.EOF)
+	}
+}
+
+// block builds a big-endian pcapng block.
+func block(blockType uint32, body ...[]byte) []byte {
+	joined := bytes.Join(body, nil)
+	for len(joined)%4 != 0 {
+		joined = append(joined, 0)
+	}
+	length := uint32(blockHeaderLength + len(joined)) //nolint:gosec // G115
+	b := binary.BigEndian.AppendUint32(nil, blockType)
+	b = binary.BigEndian.AppendUint32(b, length)
+	b = append(b, joined...)
+
+	return binary.BigEndian.AppendUint32(b, length)
+}
+
+func sectionHeader() []byte {
+	return block(blockSectionHeader, binary.BigEndian.AppendUint32(nil, pcapngByteOrderMagic),
+		[]byte{0, 1, 0, 0}, bytes.Repeat([]byte{0xFF}, 8))
+}
+
+func interfaceDescription(linkType LinkType, options ...byte) []byte {
+	return block(blockInterface, binary.BigEndian.AppendUint16(nil, uint16(linkType)), make([]byte, 6), options)
+}
+
+func enhancedPacket(interfaceID uint32, units uint64, data []byte) []byte {
+	header := binary.BigEndian.AppendUint32(nil, interfaceID)
+	header = binary.BigEndian.AppendUint32(header, uint32(units>>32))
+	header = binary.BigEndian.AppendUint32(header, uint32(units))       //nolint:gosec // G115
+	header = binary.BigEndian.AppendUint32(header, uint32(len(data)))   //nolint:gosec // G115
+	header = binary.BigEndian.AppendUint32(header, uint32(len(data)+4)) //nolint:gosec // G115
+
+	return block(blockEnhancedPacket, header, data)
+}
+
+func TestPCAPNG(t *testing.T) {
+	sll2 := append(binary.BigEndian.AppendUint16(nil, etherTypeIPv6), make([]byte, 18)...)
+	sll2 = append(sll2, ipv6(udp(testSource6, testDestination6, []byte("six")))...)
+	raw := ipv4(protocolUDP, 0, udp(testSource, testDestination, []byte("four")))
+
+	file := sectionHeader()
+	// Nanoseconds with an offset of 10 s, and the default microseconds.
+	file = append(file, interfaceDescription(LinkTypeLinuxSLL2,
+		0, optionTSResolution, 0, 1, 9, 0, 0, 0,
+		0, optionTSOffset, 0, 8, 0, 0, 0, 0, 0, 0, 0, 10,
+		0, 0, 0, 0)...)
+	file = append(file, interfaceDescription(LinkTypeRaw)...)
+	file = append(file, block(0x00000005, []byte("statistics are skipped"))...)
+	file = append(file, enhancedPacket(0, 1500000000, sll2)...)
+	file = append(file, enhancedPacket(1, 2500000, raw)...)
+	file = append(file, block(blockSimplePacket, binary.BigEndian.AppendUint32(nil, uint32(len(sll2))), sll2)...)
+	// A second section, whose interface counts time in 1/1024 s.
+	file = append(file, sectionHeader()...)
+	file = append(file, interfaceDescription(LinkTypeIPv4, 0, optionTSResolution, 0, 1, 0x8A, 0, 0, 0)...)
+	file = append(file, enhancedPacket(0, 3*1024+512, raw)...)
+	file = append(file, enhancedPacket(1, 0, raw)...)
+
+	reader, err := NewWith(bytes.NewReader(file))
+	require.NoError(t, err)
+	for i, want := range []struct {
+		linkType  LinkType
+		timestamp time.Time
+		source    netip.AddrPort
+		payload   string
+	}{
+		{LinkTypeLinuxSLL2, time.Unix(11, 500000000), testSource6, "six"},
+		{LinkTypeRaw, time.Unix(2, 500000000), testSource, "four"},
+		{LinkTypeLinuxSLL2, time.Time{}, testSource6, "six"},
+		{LinkTypeIPv4, time.Unix(3, 500000000), testSource, "four"},
+	} {
+		packet, err := reader.ReadPacket()
+		require.NoError(t, err, i)
+		assert.Equal(t, want.linkType, packet.LinkType, i)
+		assert.True(t, want.timestamp.Equal(packet.Timestamp), i)
+		datagram, err := ParseUDP(packet)
+		require.NoError(t, err, i)
+		assert.Equal(t, want.source, datagram.Source, i)
+		assert.Equal(t, testDestination.Port(), datagram.Destination.Port(), i)
+		assert.Equal(t, want.payload, string(datagram.Payload), i)
+	}
+
+	_, err = reader.ReadPacket()
+	assert.ErrorIs(t, err, errUnknownInterface, "the first section's second interface is gone")
+
+	reader, err = NewWith(bytes.NewReader(file[:len(file)-5]))
+	require.NoError(t, err)
+	for {
+		if _, err = reader.ReadPacket(); err != nil {
+			break
+		}
+	}
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
+
+func TestParseUDP(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		packet Packet
+		err    error
+	}{
+		{"fragment", Packet{LinkType: LinkTypeRaw, Data: ipv4(protocolUDP, ipv4MoreFragments, udp(
+			testSource, testDestination, nil))}, errFragmented},
+		{"later fragment", Packet{LinkType: LinkTypeRaw, Data: ipv4(protocolUDP, 100, make([]byte, 8))}, errFragmented},
+		{"truncated", Packet{LinkType: LinkTypeRaw, Data: ipv4(protocolUDP, 0, udp(
+			testSource, testDestination, []byte("x")))[:25]}, errTruncatedPacket},
+		{"ARP", Packet{LinkType: LinkTypeEthernet, Data: ethernet(0x0806, make([]byte, 28))}, errNotUDP},
+		{"link type", Packet{LinkType: 147, Data: []byte{0x45}}, errUnsupportedLinkType},
+	} {
+		_, err := ParseUDP(&test.packet)
+		assert.ErrorIs(t, err, test.err, test.name)
+	}
+
+	// The loopback header holds an address family in host byte order.
+	loop := append([]byte{2, 0, 0, 0}, ipv4(protocolUDP, 0, udp(testSource, testDestination, []byte("lo")))...)
+	datagram, err := ParseUDP(&Packet{LinkType: LinkTypeNull, Data: loop})
+	require.NoError(t, err)
+	assert.Equal(t, "lo", string(datagram.Payload))
+}
+
+func TestNewWithErrors(t *testing.T) {
+	_, err := NewWith(nil)
+	assert.ErrorIs(t, err, errNilStream)
+
+	for _, data := range [][]byte{nil, []byte("RIFF\x00\x00\x00\x00WAVEfmt \x10\x00\x00\x00\x01\x00\x01\x00")} {
+		_, err = NewWith(bytes.NewReader(data))
+		assert.ErrorIs(t, err, errUnknownFormat)
+	}
+
+	_, err = NewWith(bytes.NewReader(block(blockSectionHeader, []byte{1, 2, 3, 4})))
+	assert.True(t, errors.Is(err, errMalformedBlock))
+}
@@ -0,0 +1,167 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package pcapreader
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/netip"
+)
+
+const (
+	etherTypeIPv4  = 0x0800
+	etherTypeIPv6  = 0x86DD
+	etherTypeVLAN  = 0x8100
+	etherTypeQinQ  = 0x88A8
+	ethernetLength = 14
+	vlanTagLength  = 4
+	sllLength      = 16
+	sll2Length     = 20
+	nullLength     = 4
+
+	ipv4MinLength      = 20
+	ipv4MoreFragments  = 0x2000
+	ipv4FragmentOffset = 0x1FFF
+	ipv6Length         = 40
+	udpLength          = 8
+
+	protocolHopByHop    = 0
+	protocolUDP         = 17
+	protocolRouting     = 43
+	protocolFragment    = 44
+	protocolDestination = 60
+)
+
+var (
+	errNotUDP              = errors.New("not a UDP datagram")
+	errFragmented          = errors.New("fragmented IP packet")
+	errTruncatedPacket     = errors.New("truncated packet")
+	errUnsupportedLinkType = errors.New("unsupported link type")
+)
+
+// Datagram is a UDP datagram.
+type Datagram struct {
+	Source, Destination netip.AddrPort
+	// Payload aliases the Data of the Packet it was parsed from.
+	Payload []byte
+}
+
+// ParseUDP returns the UDP datagram packet carries, or an error when it is
+// anything else or is cut short.
+func ParseUDP(packet *Packet) (*Datagram, error) {
+	data := packet.Data
+	var etherType uint16
+	switch packet.LinkType {
+	case LinkTypeEthernet:
+		if len(data) < ethernetLength {
+			return nil, errTruncatedPacket
+		}
+		etherType, data = binary.BigEndian.Uint16(data[12:]), data[ethernetLength:]
+		for etherType == etherTypeVLAN || etherType == etherTypeQinQ {
+			if len(data) < vlanTagLength {
+				return nil, errTruncatedPacket
+			}
+			etherType, data = binary.BigEndian.Uint16(data[2:]), data[vlanTagLength:]
+		}
+	case LinkTypeLinuxSLL:
+		if len(data) < sllLength {
+			return nil, errTruncatedPacket
+		}
+		etherType, data = binary.BigEndian.Uint16(data[14:]), data[sllLength:]
+	case LinkTypeLinuxSLL2:
+		if len(data) < sll2Length {
+			return nil, errTruncatedPacket
+		}
+		etherType, data = binary.BigEndian.Uint16(data), data[sll2Length:]
+	case LinkTypeNull, LinkTypeLoop:
+		// The address family is in the byte order of the capturing host, and
+		// its values differ between systems; the IP version tells as well.
+		if len(data) < nullLength {
+			return nil, errTruncatedPacket
+		}
+		data = data[nullLength:]
+	case LinkTypeRaw, LinkTypeIPv4, LinkTypeIPv6:
+	default:
+		return nil, fmt.Errorf("%w: %d", errUnsupportedLinkType, packet.LinkType)
+	}
+	if len(data) == 0 {
+		return nil, errTruncatedPacket
+	}
+
+	switch {
+	case etherType == etherTypeIPv4 || etherType == 0 && data[0]>>4 == 4:
+		return parseIPv4(data)
+	case etherType == etherTypeIPv6 || etherType == 0 && data[0]>>4 == 6:
+		return parseIPv6(data)
+	default:
+		return nil, errNotUDP
+	}
+}
+
+func parseIPv4(data []byte) (*Datagram, error) {
+	if len(data) < ipv4MinLength {
+		return nil, errTruncatedPacket
+	}
+	headerLength := int(data[0]&0x0F) * 4
+	totalLength := int(binary.BigEndian.Uint16(data[2:]))
+	if headerLength < ipv4MinLength || totalLength < headerLength || len(data) < totalLength {
+		return nil, errTruncatedPacket
+	}
+	if data[9] != protocolUDP {
+		return nil, errNotUDP
+	}
+	if fragment := binary.BigEndian.Uint16(data[6:]); fragment&(ipv4MoreFragments|ipv4FragmentOffset) != 0 {
+		return nil, errFragmented
+	}
+	source := netip.AddrFrom4([4]byte(data[12:16]))
+	destination := netip.AddrFrom4([4]byte(data[16:20]))
+
+	return parseUDP(data[headerLength:totalLength], source, destination)
+}
+
+func parseIPv6(data []byte) (*Datagram, error) {
+	if len(data) < ipv6Length {
+		return nil, errTruncatedPacket
+	}
+	payloadLength := int(binary.BigEndian.Uint16(data[4:]))
+	if len(data) < ipv6Length+payloadLength {
+		return nil, errTruncatedPacket
+	}
+	source := netip.AddrFrom16([16]byte(data[8:24]))
+	destination := netip.AddrFrom16([16]byte(data[24:40]))
+
+	next, payload := data[6], data[ipv6Length:ipv6Length+payloadLength]
+	for {
+		switch next {
+		case protocolUDP:
+			return parseUDP(payload, source, destination)
+		case protocolHopByHop, protocolRouting, protocolDestination:
+			if len(payload) < 2 || len(payload) < (int(payload[1])+1)*8 {
+				return nil, errTruncatedPacket
+			}
+			next, payload = payload[0], payload[(int(payload[1])+1)*8:]
+		case protocolFragment:
+			return nil, errFragmented
+		default:
+			return nil, errNotUDP
+		}
+	}
+}
+
+func parseUDP(data []byte, source, destination netip.Addr) (*Datagram, error) {
+	if len(data) < udpLength {
+		return nil, errTruncatedPacket
+	}
+	length := int(binary.BigEndian.Uint16(data[4:]))
+	if length < udpLength || length > len(data) {
+		return nil, errTruncatedPacket
+	}
+
+	return &Datagram{
+		Source:      netip.AddrPortFrom(source, binary.BigEndian.Uint16(data)),
+		Destination: netip.AddrPortFrom(destination, binary.BigEndian.Uint16(data[2:])),
+		Payload:     data[udpLength:length],
+	}, nil
+}
@@ -3,7 +3,12 @@
 
 package rangecoding
 
-import "math/bits"
+import (
+	"errors"
+	"math/bits"
+)
+
+var errOverrun = errors.New("range decoder read past the end of the frame")
 
 // Decoder implements rfc6716#section-4.1
 // Opus uses an entropy coder based on range coding [RANGE-CODING]
@@ -112,9 +117,15 @@ func (r *Decoder) Init(data []byte) {
 	r.normalize()
 }
 
-// SetStorageSize adjusts the logical frame size without resetting decoder
-// state. Opus hybrid redundancy removes tail bytes from the CELT range coder
-// after the shared decoder has already consumed SILK symbols.
+// SetStorageSize shortens the frame to its first size bytes without resetting
+// the decoder, for a frame whose last bytes turn out to hold something else.
+// An Opus hybrid frame learns only after its SILK symbols that it ends with a
+// redundant CELT frame (RFC 6716 Section 4.5.1), which its own CELT symbols
+// must not read.
+//
+// Raw bits are read from the new end on, and RemainingBits counts against
+// it. The symbols decoded before stay decoded. A size past the end leaves the
+// frame whole, and a negative one empties it.
 func (r *Decoder) SetStorageSize(size int) {
 	if size < 0 {
 		size = 0
@@ -378,14 +389,21 @@ func (r *Decoder) update(scale, low, high, total uint32) {
 	r.normalize()
 }
 
-// SetInternalValues is used when using the RangeDecoder when testing.
-func (r *Decoder) SetInternalValues(data []byte, bitsRead uint, rangeSize uint32, highAndCodedDifference uint32) {
+// SetState puts the decoder in the state (val, rng) of RFC 6716 Section 4.1,
+// as if it had read the first bitsRead bits of data with the range coder and
+// no raw bits from its end. It resumes decoding from a state recorded
+// elsewhere, such as in a trace of the reference decoder, or sets a decoder up
+// to yield chosen symbols.
+//
+// The state must be one a decoder can be in between symbols: rng above 1<<23
+// and at most 1<<31, and val below rng. Tell and TellFrac count from bitsRead.
+func (r *Decoder) SetState(data []byte, bitsRead uint, rng, val uint32) {
 	r.data = data
 	r.bitsRead = bitsRead
 	r.rawBitsRead = 0
 	r.nbitsTotal = bitsRead
-	r.rangeSize = rangeSize
-	r.highAndCodedDifference = highAndCodedDifference
+	r.rangeSize = rng
+	r.highAndCodedDifference = val
 }
 
 // DecodeRawBits decodes raw bits packed from the end of the frame.
@@ -443,11 +461,28 @@ func (r *Decoder) RemainingBits() int {
 	return len(r.data)*8 - int(r.bitsRead) - int(r.rawBitsRead) //nolint:gosec // G115: decode cursors are frame-sized.
 }
 
-// FinalRange exposes the current range coder range state for tests.
+// FinalRange returns the current range size, rng in RFC 6716. Once a frame is
+// decoded it equals the encoder's FinalRange, which the reference
+// implementation reports to check that both sides stayed in step.
 func (r *Decoder) FinalRange() uint32 {
 	return r.rangeSize
 }
 
+// Err returns an error once the symbols decoded so far take more bits than the
+// frame holds. The decoder reads zero bits past the end of the frame, as RFC
+// 6716 Section 4.1.2.1 requires, so everything decoded after that point is
+// made up. Frames an Encoder produced never trigger it when decoded with the
+// same symbols; a truncated or corrupt frame may.
+func (r *Decoder) Err() error {
+	// Tell starts at 1 before any symbol is decoded and rounds up, while
+	// Done trims the frame to the bits actually used.
+	if r.Tell() > uint(len(r.data))*8+1 {
+		return errOverrun
+	}
+
+	return nil
+}
+
 func localMin(a, b uint) uint {
 	if a < b {
 		return a
@@ -201,6 +201,32 @@ func TestDecoderInitEmptyInput(t *testing.T) {
 	})
 }
 
+func TestDecoderErrReportsOverrun(t *testing.T) {
+	encoder := &Encoder{}
+	encoder.Init()
+	for range 16 {
+		encoder.EncodeSymbolLogP(1, 1)
+	}
+	encoder.EncodeRawBits(8, 0xA5)
+	packet := encoder.Done()
+
+	decoder := &Decoder{}
+	decoder.Init(packet[:len(packet)-1])
+	for range 16 {
+		decoder.DecodeSymbolLogP(1)
+	}
+	assert.NoError(t, decoder.Err())
+	decoder.DecodeRawBits(8)
+	assert.ErrorIs(t, decoder.Err(), errOverrun)
+
+	decoder.Init(packet)
+	for range 16 {
+		assert.Equal(t, uint32(1), decoder.DecodeSymbolLogP(1))
+	}
+	assert.Equal(t, uint32(0xA5), decoder.DecodeRawBits(8))
+	assert.NoError(t, decoder.Err())
+}
+
 func TestGetBitsCrossesByteBoundary(t *testing.T) {
 	decoder := &Decoder{
 		data:     []byte{0b10101100, 0b01110010},
@@ -350,7 +376,7 @@ func decoderWithUniformSymbol(symbol, total uint32) *Decoder {
 	const scale = 1 << 24
 
 	decoder := &Decoder{}
-	decoder.SetInternalValues(nil, 0, total*scale, (total-symbol-1)*scale)
+	decoder.SetState(nil, 0, total*scale, (total-symbol-1)*scale)
 
 	return decoder
 }
@@ -359,7 +385,7 @@ func decoderWithBin15Symbol(symbol uint32) *Decoder {
 	const scale = 1 << 16
 
 	decoder := &Decoder{}
-	decoder.SetInternalValues(nil, 0, laplaceTotal*scale, (laplaceTotal-symbol-1)*scale)
+	decoder.SetState(nil, 0, laplaceTotal*scale, (laplaceTotal-symbol-1)*scale)
 
 	return decoder
 }
@@ -383,13 +409,13 @@ func TestTellFracDoesNotUnderflow(t *testing.T) {
 	assert.Zero(t, decoder.TellFrac())
 }
 
-func TestSetInternalValuesResetsBitAccounting(t *testing.T) {
+func TestSetState(t *testing.T) {
 	decoder := &Decoder{
 		rawBitsRead: 7,
 		nbitsTotal:  99,
 	}
 
-	decoder.SetInternalValues([]byte{0x00, 0x00}, 12, 1<<31, 0)
+	decoder.SetState([]byte{0x00, 0x00}, 12, 1<<31, 0)
 
 	assert.Equal(t, uint(12), decoder.bitsRead)
 	assert.Zero(t, decoder.rawBitsRead)
@@ -354,7 +354,7 @@ func (e *Encoder) TellFrac() uint {
 	return total - lg
 }
 
-// FinalRange exposes the current range coder range state for tests.
+// FinalRange returns the current range size, rng in RFC 6716.
 //
 // RFC 6716 Section 5.1 states that after encoding a sequence of symbols the
 // value of rng in the encoder should exactly match the value of rng in the
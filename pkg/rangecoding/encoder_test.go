@@ -46,6 +46,7 @@ func TestEncoderRoundTrip(t *testing.T) {
 	assert.Equal(t, uint32(0x16), decoder.DecodeRawBits(5))
 	assert.Equal(t, uint32(0x5A3), decoder.DecodeRawBits(11))
 	assert.NotZero(t, decoder.FinalRange())
+	assert.NoError(t, decoder.Err())
 }
 
 func TestEncoderCumulativeRoundTrip(t *testing.T) {
@@ -326,6 +327,8 @@ func FuzzEncoderRoundTrip(f *testing.F) {
 		for _, op := range ops {
 			assertFuzzOperation(t, decoder, op)
 		}
+		assert.Equal(t, encoder.FinalRange(), decoder.FinalRange())
+		assert.NoError(t, decoder.Err())
 	})
 }
 
@@ -0,0 +1,87 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package rangecoding_test
+
+import (
+	"fmt"
+
+	"github.com/pion/opus/pkg/rangecoding"
+)
+
+func Example() {
+	// A three-symbol context with probabilities 1/2, 1/4 and 1/4, as
+	// cumulative frequencies prefixed with their total.
+	icdf := []uint{4, 2, 3, 4}
+
+	var encoder rangecoding.Encoder
+	encoder.Init()
+	encoder.EncodeSymbolWithICDF(icdf, 1)
+	encoder.EncodeSymbolLogP(3, 1)
+	encoder.EncodeUniform(1000, 617)
+	encoder.EncodeLaplace(72<<7, 127<<6, -2)
+	encoder.EncodeRawBits(4, 0xA)
+	frame := encoder.Done()
+
+	var decoder rangecoding.Decoder
+	decoder.Init(frame)
+	symbol := decoder.DecodeSymbolWithICDF(icdf)
+	bit := decoder.DecodeSymbolLogP(3)
+	value, _ := decoder.DecodeUniform(1000)
+	delta := decoder.DecodeLaplace(72<<7, 127<<6)
+	raw := decoder.DecodeRawBits(4)
+
+	fmt.Println(symbol, bit, value, delta, raw)
+	fmt.Println(decoder.Err(), decoder.FinalRange() == encoder.FinalRange())
+	// Output:
+	// 1 1 617 -2 10
+	// <nil> true
+}
+
+func ExampleDecoder_Err() {
+	var encoder rangecoding.Encoder
+	encoder.Init()
+	for range 32 {
+		encoder.EncodeSymbolLogP(1, 1)
+	}
+	frame := encoder.Done()
+
+	// Half the frame is lost, so the second half of the symbols is decoded
+	// from zero bits.
+	var decoder rangecoding.Decoder
+	decoder.Init(frame[:len(frame)/2])
+	for i := range 32 {
+		decoder.DecodeSymbolLogP(1)
+		if err := decoder.Err(); err != nil {
+			fmt.Printf("symbol %d: %v\n", i, err)
+
+			break
+		}
+	}
+	// Output:
+	// symbol 16: range decoder read past the end of the frame
+}
+
+func ExampleEncoder_SaveInto() {
+	var encoder rangecoding.Encoder
+	encoder.Init()
+	encoder.EncodeUniform(16, 5)
+
+	// Try coding a value, then rewind if it costs too much.
+	var state rangecoding.State
+	encoder.SaveInto(&state)
+	before := encoder.TellFrac()
+	encoder.EncodeSymbolLogP(6, 1)
+	if encoder.TellFrac()-before > 4*8 {
+		encoder.Restore(&state)
+		encoder.EncodeSymbolLogP(6, 0)
+	}
+	frame := encoder.Done()
+
+	var decoder rangecoding.Decoder
+	decoder.Init(frame)
+	value, _ := decoder.DecodeUniform(16)
+	fmt.Println(value, decoder.DecodeSymbolLogP(6))
+	// Output:
+	// 5 0
+}
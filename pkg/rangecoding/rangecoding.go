@@ -0,0 +1,37 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+// Package rangecoding implements the range coder of RFC 6716 Section 4.1 and
+// Section 5.1, the entropy coder every layer of the Opus bitstream is written
+// with. It is bit-exact with the reference entenc.c and entdec.c, so it can
+// read and write Opus frames, and it can also serve as the bit-packer of other
+// codecs and tools built the same way.
+//
+// An Encoder codes a sequence of symbols into a frame and a Decoder reads them
+// back. Each Encode method has a Decode counterpart that must be called in the
+// same order with the same context:
+//
+//   - EncodeSymbolWithICDF and DecodeSymbolWithICDF code a symbol from a
+//     table of up to 8 bits, given as its cumulative frequencies prefixed
+//     with their total.
+//   - EncodeSymbolLogP and DecodeSymbolLogP code a binary symbol whose "1"
+//     has a probability of 1/(1<<logp).
+//   - EncodeCumulative, and DecodeCumulative followed by UpdateCumulative,
+//     code an arbitrary interval [low, high) of total.
+//   - EncodeUniform and DecodeUniform code one of total equiprobable values.
+//   - EncodeLaplace and DecodeLaplace code a Laplace-distributed integer.
+//   - EncodeRawBits and DecodeRawBits pack bits from the end of the frame,
+//     bypassing the range coder.
+//
+// Tell and TellFrac report, on both sides alike, how many bits the symbols
+// coded so far take, which is how Opus budgets a frame. Done, FlushInto and
+// FlushIntoPadded finish a frame, and SaveInto and Restore let an encoder try
+// out a choice of symbols and rewind.
+//
+// SetState sets a Decoder to a state recorded elsewhere, and SetStorageSize
+// cuts off the tail of a frame that turns out to hold other data.
+//
+// Like the reference decoder, a Decoder that runs out of data reads zero bits
+// rather than failing, so a truncated or corrupt frame decodes to something.
+// Err reports when that has happened.
+package rangecoding
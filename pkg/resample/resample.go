@@ -0,0 +1,298 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+// Package resample converts interleaved float32 audio between sample rates,
+// for instance to bring capture-side audio to a rate the Opus encoder takes.
+//
+// Two filters are offered. ModeSinc, the default, is a polyphase
+// Kaiser-windowed sinc filter that handles any pair of rates, at a selectable
+// Quality. ModeSILK is the fixed-point IIR/FIR resampler of the RFC 6716 SILK
+// reference: cheap and with little delay, but limited to the Opus rate set,
+// from 8, 12 or 16 kHz to 8, 12, 16, 24 or 48 kHz and from 24 or 48 kHz to 8,
+// 12 or 16 kHz, and, as it works in 16 bits, to audio within [-1, 1].
+//
+// A Resampler keeps its filter state between calls, so a stream can be fed to
+// it in pieces of any length.
+package resample
+
+import (
+	"errors"
+
+	silkresample "github.com/pion/opus/internal/resample/silk"
+	sincresample "github.com/pion/opus/internal/resample/sinc"
+)
+
+// Mode selects the filter a Resampler uses.
+type Mode int
+
+const (
+	// ModeSinc resamples between any two rates with a windowed sinc filter.
+	ModeSinc Mode = iota
+	// ModeSILK resamples within the Opus rate set with the SILK reference
+	// resampler.
+	ModeSILK
+)
+
+// Quality selects the length and steepness of the ModeSinc filter.
+type Quality = sincresample.Quality
+
+const (
+	// QualityMedium passes up to about 90% of the lower Nyquist frequency and
+	// rejects aliases by about 80 dB. It is the default.
+	QualityMedium = sincresample.QualityMedium
+	// QualityLow passes up to about 80% of the lower Nyquist frequency and
+	// rejects aliases by about 55 dB, at a third of the cost.
+	QualityLow = sincresample.QualityLow
+	// QualityHigh passes up to about 93% of the lower Nyquist frequency and
+	// rejects aliases by about 100 dB, at twice the cost.
+	QualityHigh = sincresample.QualityHigh
+)
+
+const (
+	maxChannels = 8
+
+	// delayProbeMs is how much of an impulse response New runs through a
+	// ModeSILK filter to measure its delay.
+	delayProbeMs = 20
+)
+
+var (
+	errInvalidChannelCount = errors.New("invalid channel count")
+	errInvalidMode         = errors.New("invalid resampler mode")
+	errInvalidQuality      = errors.New("invalid resampler quality")
+	errInvalidInputLength  = errors.New("input is not a whole number of interleaved samples")
+	errOutBufferTooSmall   = errors.New("out isn't large enough")
+)
+
+// Option configures a Resampler.
+type Option func(*Resampler) error
+
+// WithMode selects the filter, ModeSinc by default.
+func WithMode(mode Mode) Option {
+	return func(r *Resampler) error {
+		switch mode {
+		case ModeSinc, ModeSILK:
+		default:
+			return errInvalidMode
+		}
+		r.mode = mode
+
+		return nil
+	}
+}
+
+// WithQuality selects the quality of the ModeSinc filter, QualityMedium by
+// default. ModeSILK ignores it.
+func WithQuality(quality Quality) Option {
+	return func(r *Resampler) error {
+		switch quality {
+		case QualityLow, QualityMedium, QualityHigh:
+		default:
+			return errInvalidQuality
+		}
+		r.quality = quality
+
+		return nil
+	}
+}
+
+// Resampler converts interleaved audio from one sample rate to another.
+type Resampler struct {
+	inputRate  int
+	outputRate int
+	channels   int
+	mode       Mode
+	quality    Quality
+	delay      float64
+
+	sinc sincresample.Resampler
+
+	// silk holds one resampler per channel. It takes whole milliseconds of
+	// input, so up to a millisecond less one sample waits in pending.
+	silk           []silkresample.Resampler
+	silkEncoder    bool
+	pending        []float32
+	pendingSamples int
+	channelIn      []float32
+	channelOut     []float32
+}
+
+// New returns a Resampler converting channels interleaved channels from
+// inputRate to outputRate.
+func New(inputRate, outputRate, channels int, opts ...Option) (*Resampler, error) {
+	if channels < 1 || channels > maxChannels {
+		return nil, errInvalidChannelCount
+	}
+	resampler := &Resampler{
+		inputRate:  inputRate,
+		outputRate: outputRate,
+		channels:   channels,
+	}
+	for _, opt := range opts {
+		if err := opt(resampler); err != nil {
+			return nil, err
+		}
+	}
+
+	if resampler.mode == ModeSILK {
+		if err := resampler.initSILK(); err != nil {
+			return nil, err
+		}
+
+		return resampler, nil
+	}
+
+	if err := resampler.sinc.Init(inputRate, outputRate, channels, resampler.quality); err != nil {
+		return nil, err
+	}
+	resampler.delay = resampler.sinc.Delay()
+
+	return resampler, nil
+}
+
+// initSILK sets up the per-channel SILK resamplers, with the decoder-side
+// delay tables where the rates allow and the encoder-side ones otherwise,
+// and measures the resulting delay.
+func (r *Resampler) initSILK() error {
+	var probe silkresample.Resampler
+	if err := probe.Init(r.inputRate, r.outputRate); err != nil {
+		if err := probe.InitEncoder(r.inputRate, r.outputRate); err != nil {
+			return err
+		}
+		r.silkEncoder = true
+	}
+	r.silk = make([]silkresample.Resampler, r.channels)
+	r.pending = make([]float32, r.inputRate/1000*r.channels)
+	r.Reset()
+
+	// The delay at low frequencies is the centroid of the impulse response.
+	in := make([]float32, r.inputRate*delayProbeMs/1000)
+	in[0] = 0.5
+	out := make([]float32, r.outputRate*delayProbeMs/1000)
+	if err := probe.Resample(in, out); err != nil {
+		return err
+	}
+	var moment, sum float64
+	for i, sample := range out {
+		moment += float64(i) * float64(sample)
+		sum += float64(sample)
+	}
+	r.delay = moment / sum
+
+	return nil
+}
+
+// Reset clears the filter state, as if the Resampler had just been created.
+func (r *Resampler) Reset() {
+	if r.mode == ModeSinc {
+		r.sinc.Reset()
+
+		return
+	}
+	for i := range r.silk {
+		if r.silkEncoder {
+			_ = r.silk[i].InitEncoder(r.inputRate, r.outputRate)
+		} else {
+			_ = r.silk[i].Init(r.inputRate, r.outputRate)
+		}
+	}
+	r.pendingSamples = 0
+}
+
+// Delay returns the group delay the filter adds at low frequencies, in
+// output samples.
+func (r *Resampler) Delay() float64 {
+	return r.delay
+}
+
+// OutputSamples returns how many samples per channel the next call to
+// Resample produces from inSamples samples per channel.
+func (r *Resampler) OutputSamples(inSamples int) int {
+	if r.mode == ModeSinc {
+		return r.sinc.OutputSamples(inSamples)
+	}
+	milliseconds := (r.pendingSamples + inSamples) / (r.inputRate / 1000)
+
+	return milliseconds * (r.outputRate / 1000)
+}
+
+// Resample converts in, interleaved, into out and returns the samples per
+// channel written. out must hold OutputSamples of them. Over a stream the
+// output adds up to the input length times the rate ratio: rounded up with
+// ModeSinc, and less up to a millisecond of input held back with ModeSILK,
+// which works in whole milliseconds.
+func (r *Resampler) Resample(in, out []float32) (int, error) {
+	if len(in)%r.channels != 0 {
+		return 0, errInvalidInputLength
+	}
+	if len(out) < r.OutputSamples(len(in)/r.channels)*r.channels {
+		return 0, errOutBufferTooSmall
+	}
+	if r.mode == ModeSinc {
+		return r.sinc.Resample(in, out)
+	}
+
+	return r.resampleSILK(in, out)
+}
+
+func (r *Resampler) resampleSILK(in, out []float32) (int, error) {
+	inPerMs, outPerMs := r.inputRate/1000, r.outputRate/1000
+	written := 0
+	for len(in) > 0 {
+		// Top up the pending millisecond first; once it is full, it goes
+		// through the filter on its own, then whole milliseconds of in
+		// go straight through.
+		var source []float32
+		if r.pendingSamples > 0 || len(in) < inPerMs*r.channels {
+			taken := min(inPerMs-r.pendingSamples, len(in)/r.channels)
+			copy(r.pending[r.pendingSamples*r.channels:], in[:taken*r.channels])
+			r.pendingSamples += taken
+			in = in[taken*r.channels:]
+			if r.pendingSamples < inPerMs {
+				break
+			}
+			source = r.pending
+			r.pendingSamples = 0
+		} else {
+			source = in[:len(in)/(inPerMs*r.channels)*inPerMs*r.channels]
+			in = in[len(source):]
+		}
+
+		samples := len(source) / r.channels
+		outSamples := samples / inPerMs * outPerMs
+		if err := r.filterSILK(source, out[written*r.channels:], samples, outSamples); err != nil {
+			return 0, err
+		}
+		written += outSamples
+	}
+
+	return written, nil
+}
+
+// filterSILK runs samples samples per channel of in through each channel's
+// resampler.
+func (r *Resampler) filterSILK(in, out []float32, samples, outSamples int) error {
+	if r.channels == 1 {
+		return r.silk[0].Resample(in, out[:outSamples])
+	}
+	if cap(r.channelIn) < samples {
+		r.channelIn = make([]float32, samples)
+	}
+	if cap(r.channelOut) < outSamples {
+		r.channelOut = make([]float32, outSamples)
+	}
+	channelIn, channelOut := r.channelIn[:samples], r.channelOut[:outSamples]
+	for channel := range r.channels {
+		for i := range channelIn {
+			channelIn[i] = in[i*r.channels+channel]
+		}
+		if err := r.silk[channel].Resample(channelIn, channelOut); err != nil {
+			return err
+		}
+		for i, sample := range channelOut {
+			out[i*r.channels+channel] = sample
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package resample
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sine(frequency float64, sampleRate, samples, channels int) []float32 {
+	out := make([]float32, samples*channels)
+	for i := range samples {
+		value := float32(0.5 * math.Sin(2*math.Pi*frequency*float64(i)/float64(sampleRate)))
+		for channel := range channels {
+			out[i*channels+channel] = value / float32(channel+1)
+		}
+	}
+
+	return out
+}
+
+// sineError returns the RMS difference between channel 0 of out and the sine
+// it should hold once delayed by delay samples, past the filter's start-up.
+func sineError(out []float32, frequency float64, sampleRate, channels int, delay float64) float64 {
+	var sum float64
+	count := 0
+	for i := sampleRate / 100; i < len(out)/channels; i++ {
+		want := 0.5 * math.Sin(2*math.Pi*frequency*(float64(i)-delay)/float64(sampleRate))
+		diff := float64(out[i*channels]) - want
+		sum += diff * diff
+		count++
+	}
+
+	return math.Sqrt(sum / float64(count))
+}
+
+func resampleAll(t *testing.T, resampler *Resampler, in []float32, channels int) []float32 {
+	t.Helper()
+
+	out := make([]float32, resampler.OutputSamples(len(in)/channels)*channels)
+	n, err := resampler.Resample(in, out)
+	require.NoError(t, err)
+
+	return out[:n*channels]
+}
+
+func TestResampleSineWithReportedDelay(t *testing.T) {
+	for _, test := range []struct {
+		mode                  Mode
+		inputRate, outputRate int
+		maxError              float64
+	}{
+		{ModeSinc, 48000, 44100, 1e-4},
+		{ModeSinc, 44100, 48000, 1e-4},
+		{ModeSinc, 22050, 16000, 1e-4},
+		{ModeSILK, 16000, 48000, 1e-2},
+		{ModeSILK, 12000, 24000, 1e-2},
+		{ModeSILK, 48000, 16000, 1e-2},
+		{ModeSILK, 24000, 8000, 1e-2},
+	} {
+		t.Run(fmt.Sprintf("%d_%d_to_%d", test.mode, test.inputRate, test.outputRate), func(t *testing.T) {
+			resampler, err := New(test.inputRate, test.outputRate, 2, WithMode(test.mode))
+			require.NoError(t, err)
+			assert.Positive(t, resampler.Delay())
+
+			out := resampleAll(t, resampler, sine(440, test.inputRate, test.inputRate/5, 2), 2)
+			assert.InDelta(t, test.outputRate/5, len(out)/2, 1)
+			assert.Less(t, sineError(out, 440, test.outputRate, 2, resampler.Delay()), test.maxError)
+			for i := range len(out) / 2 {
+				assert.InDelta(t, out[2*i]/2, out[2*i+1], 1e-4)
+			}
+		})
+	}
+}
+
+func TestResampleStreamingMatchesOneShot(t *testing.T) {
+	for _, mode := range []Mode{ModeSinc, ModeSILK} {
+		oneShot, err := New(16000, 48000, 2, WithMode(mode), WithQuality(QualityHigh))
+		require.NoError(t, err)
+		streaming, err := New(16000, 48000, 2, WithMode(mode), WithQuality(QualityHigh))
+		require.NoError(t, err)
+
+		in := sine(300, 16000, 1600, 2)
+		want := resampleAll(t, oneShot, in, 2)
+		var got []float32
+		for offset, chunk := 0, 1; offset < 1600; offset, chunk = offset+chunk, chunk*7%97+1 {
+			chunk = min(chunk, 1600-offset)
+			got = append(got, resampleAll(t, streaming, in[2*offset:2*(offset+chunk)], 2)...)
+		}
+		assert.Equal(t, want, got, "mode %d", mode)
+	}
+}
+
+func TestResampleReset(t *testing.T) {
+	for _, mode := range []Mode{ModeSinc, ModeSILK} {
+		resampler, err := New(48000, 16000, 1, WithMode(mode))
+		require.NoError(t, err)
+
+		in := sine(300, 48000, 970, 1)
+		first := resampleAll(t, resampler, in, 1)
+		resampler.Reset()
+		assert.Equal(t, first, resampleAll(t, resampler, in, 1), "mode %d", mode)
+	}
+}
+
+func TestResampleValidation(t *testing.T) {
+	_, err := New(48000, 44100, 0)
+	assert.ErrorIs(t, err, errInvalidChannelCount)
+	_, err = New(48000, 44100, 1, WithMode(Mode(5)))
+	assert.ErrorIs(t, err, errInvalidMode)
+	_, err = New(48000, 44100, 1, WithQuality(Quality(5)))
+	assert.ErrorIs(t, err, errInvalidQuality)
+	_, err = New(48000, 44100, 1, WithMode(ModeSILK))
+	assert.Error(t, err)
+	_, err = New(48000, 24000, 1, WithMode(ModeSILK))
+	assert.Error(t, err)
+	_, err = New(0, 44100, 1)
+	assert.Error(t, err)
+
+	for _, mode := range []Mode{ModeSinc, ModeSILK} {
+		resampler, err := New(16000, 48000, 2, WithMode(mode))
+		require.NoError(t, err)
+		_, err = resampler.Resample(make([]float32, 3), make([]float32, 100))
+		assert.ErrorIs(t, err, errInvalidInputLength)
+		_, err = resampler.Resample(make([]float32, 320), make([]float32, 10))
+		assert.ErrorIs(t, err, errOutBufferTooSmall)
+	}
+}
@@ -0,0 +1,103 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package rtpopus
+
+import (
+	"github.com/pion/opus"
+)
+
+// maxForwardSequenceGap is how far ahead a sequence number may jump and still
+// count as newer; anything further is taken to be an old, reordered packet
+// (RFC 3550 Appendix A.1 uses the same half-range split).
+const maxForwardSequenceGap = 1 << 15
+
+// Sample is one Opus packet recovered from an RTP packet.
+type Sample struct {
+	// Packet is the Opus packet. It aliases the buffer passed to Depacketize.
+	Packet []byte
+	// Duration is the packet's length in 48 kHz samples.
+	Duration int
+	// Timestamp is the RTP timestamp of the packet's first sample.
+	Timestamp      uint32
+	SequenceNumber uint16
+	// Marker is set on the first packet of a talkspurt.
+	Marker bool
+	// Lost is the number of sequence numbers skipped since the newest packet
+	// seen before this one. It is 0 for in-order, reordered and duplicate
+	// packets.
+	Lost int
+	// Reordered is set when the packet is older than one already returned,
+	// which covers duplicates too.
+	Reordered bool
+}
+
+// Depacketizer extracts Opus packets from the RTP packets of one stream.
+type Depacketizer struct {
+	payloadType    uint8
+	anyType        bool
+	started        bool
+	newestSequence uint16
+}
+
+// NewDepacketizer returns a Depacketizer that accepts any payload type.
+func NewDepacketizer() *Depacketizer {
+	return &Depacketizer{anyType: true}
+}
+
+// NewDepacketizerForPayloadType returns a Depacketizer that rejects RTP
+// packets whose payload type is not payloadType.
+func NewDepacketizerForPayloadType(payloadType uint8) (*Depacketizer, error) {
+	if payloadType > rtpMaxPayloadType {
+		return nil, errInvalidPayloadType
+	}
+
+	return &Depacketizer{payloadType: payloadType}, nil
+}
+
+// Depacketize parses an RTP packet and returns the Opus packet it carries.
+// The payload is validated against RFC 6716 Section 3.4 so malformed packets
+// are rejected here rather than by the decoder.
+func (d *Depacketizer) Depacketize(buf []byte) (*Sample, error) {
+	var packet Packet
+	if err := packet.Unmarshal(buf); err != nil {
+		return nil, err
+	}
+
+	return d.DepacketizePacket(&packet)
+}
+
+// DepacketizePacket is Depacketize for an already parsed RTP packet.
+func (d *Depacketizer) DepacketizePacket(packet *Packet) (*Sample, error) {
+	if !d.anyType && packet.PayloadType != d.payloadType {
+		return nil, errUnexpectedPayload
+	}
+	if len(packet.Payload) == 0 {
+		return nil, errEmptyPayload
+	}
+	duration, err := opus.PacketSampleCount(packet.Payload, ClockRate)
+	if err != nil {
+		return nil, err
+	}
+
+	sample := &Sample{
+		Packet:         packet.Payload,
+		Duration:       duration,
+		Timestamp:      packet.Timestamp,
+		SequenceNumber: packet.SequenceNumber,
+		Marker:         packet.Marker,
+	}
+
+	switch delta := packet.SequenceNumber - d.newestSequence; {
+	case !d.started:
+		d.started = true
+		d.newestSequence = packet.SequenceNumber
+	case delta != 0 && delta < maxForwardSequenceGap:
+		sample.Lost = int(delta) - 1
+		d.newestSequence = packet.SequenceNumber
+	default:
+		sample.Reordered = true
+	}
+
+	return sample, nil
+}
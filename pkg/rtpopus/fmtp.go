@@ -0,0 +1,220 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package rtpopus
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pion/opus"
+)
+
+const (
+	minAverageBitrate = 6000
+	maxAverageBitrate = 510000
+)
+
+// FMTP holds the Opus media type parameters of an SDP a=fmtp line
+// (RFC 7587 Section 6.1). Zero values mean the parameter is absent, which
+// gives the default the RFC specifies for it.
+//
+// The parameters without a sprop- prefix describe what the party that wrote
+// them prefers to receive, so the remote description's values configure the
+// local Encoder. The sprop- parameters describe what that party sends, so
+// they configure the local Decoder.
+type FMTP struct {
+	// MaxPlaybackRate is the highest sample rate the receiver renders.
+	MaxPlaybackRate int
+	// SpropMaxCaptureRate is the highest sample rate the sender captures.
+	SpropMaxCaptureRate int
+	// MaxAverageBitrate caps the average bitrate the receiver accepts, in
+	// bits per second.
+	MaxAverageBitrate int
+	// Stereo is set when the receiver prefers stereo.
+	Stereo bool
+	// SpropStereo is set when the sender is likely to send stereo.
+	SpropStereo bool
+	// CBR is set when the receiver asks for constant bitrate.
+	CBR bool
+	// UseInbandFEC is set when the receiver can use in-band FEC (LBRR).
+	UseInbandFEC bool
+	// UseDTX is set when the receiver prefers discontinuous transmission.
+	UseDTX bool
+}
+
+// ParseFMTP parses the parameter list of an a=fmtp line, such as
+// "minptime=10;useinbandfec=1". Parameters this package does not know are
+// ignored, as RFC 7587 Section 6.1 asks.
+func ParseFMTP(line string) (FMTP, error) {
+	var fmtp FMTP
+	for _, parameter := range strings.Split(line, ";") {
+		parameter = strings.TrimSpace(parameter)
+		if parameter == "" {
+			continue
+		}
+		key, value, found := strings.Cut(parameter, "=")
+		if !found {
+			return FMTP{}, fmt.Errorf("%w: %q", errMalformedFMTP, parameter)
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		var err error
+		switch key {
+		case "maxplaybackrate":
+			fmtp.MaxPlaybackRate, err = parseRate(value, errInvalidPlaybackRate)
+		case "sprop-maxcapturerate":
+			fmtp.SpropMaxCaptureRate, err = parseRate(value, errInvalidCaptureRate)
+		case "maxaveragebitrate":
+			fmtp.MaxAverageBitrate, err = parseRate(value, errInvalidFMTPValue)
+		case "stereo":
+			fmtp.Stereo, err = parseFlag(value)
+		case "sprop-stereo":
+			fmtp.SpropStereo, err = parseFlag(value)
+		case "cbr":
+			fmtp.CBR, err = parseFlag(value)
+		case "useinbandfec":
+			fmtp.UseInbandFEC, err = parseFlag(value)
+		case "usedtx":
+			fmtp.UseDTX, err = parseFlag(value)
+		}
+		if err != nil {
+			return FMTP{}, fmt.Errorf("%s: %w", key, err)
+		}
+	}
+
+	return fmtp, nil
+}
+
+func parseRate(value string, errInvalid error) (int, error) {
+	rate, err := strconv.Atoi(value)
+	if err != nil || rate <= 0 {
+		return 0, fmt.Errorf("%w: %q", errInvalid, value)
+	}
+
+	return rate, nil
+}
+
+func parseFlag(value string) (bool, error) {
+	switch value {
+	case "0":
+		return false, nil
+	case "1":
+		return true, nil
+	default:
+		return false, fmt.Errorf("%w: %q", errInvalidFMTPValue, value)
+	}
+}
+
+// String formats the parameters that differ from their defaults as an a=fmtp
+// parameter list, in the order RFC 7587 Section 6.1 lists them.
+func (f FMTP) String() string {
+	var parameters []string
+	if f.MaxPlaybackRate != 0 {
+		parameters = append(parameters, "maxplaybackrate="+strconv.Itoa(f.MaxPlaybackRate))
+	}
+	if f.SpropMaxCaptureRate != 0 {
+		parameters = append(parameters, "sprop-maxcapturerate="+strconv.Itoa(f.SpropMaxCaptureRate))
+	}
+	if f.MaxAverageBitrate != 0 {
+		parameters = append(parameters, "maxaveragebitrate="+strconv.Itoa(f.MaxAverageBitrate))
+	}
+	for _, flag := range []struct {
+		name string
+		set  bool
+	}{
+		{"stereo", f.Stereo},
+		{"sprop-stereo", f.SpropStereo},
+		{"cbr", f.CBR},
+		{"useinbandfec", f.UseInbandFEC},
+		{"usedtx", f.UseDTX},
+	} {
+		if flag.set {
+			parameters = append(parameters, flag.name+"=1")
+		}
+	}
+
+	return strings.Join(parameters, ";")
+}
+
+// EncoderOptions returns the options that make an Encoder honor a remote
+// party's receive parameters:
+//
+//   - stereo selects one or two input channels;
+//   - maxplaybackrate caps the bandwidth at the narrowest one that covers
+//     what the receiver can play;
+//   - maxaveragebitrate sets the bitrate, clamped to 6-510 kbit/s;
+//   - cbr turns VBR off, should it have been enabled;
+//   - useinbandfec turns in-band FEC on, which only takes effect once the
+//     expected loss passed to SetLossRate is above zero.
+//
+// usedtx is a preference the encoder may ignore; this encoder produces no
+// DTX frames, so it changes nothing.
+func (f FMTP) EncoderOptions() []opus.EncoderOption {
+	channels := 1
+	if f.Stereo {
+		channels = 2
+	}
+	opts := []opus.EncoderOption{opus.WithChannels(channels)}
+
+	if f.MaxPlaybackRate != 0 {
+		opts = append(opts, opus.WithMaxBandwidth(bandwidthForRate(f.MaxPlaybackRate)))
+	}
+	if f.MaxAverageBitrate != 0 {
+		opts = append(opts, opus.WithBitrate(min(max(f.MaxAverageBitrate, minAverageBitrate), maxAverageBitrate)))
+	}
+	if f.CBR {
+		opts = append(opts, opus.WithVBR(false))
+	}
+	if f.UseInbandFEC {
+		opts = append(opts, opus.WithInbandFEC(true))
+	}
+
+	return opts
+}
+
+// NewEncoder returns an Encoder configured by EncoderOptions, followed by
+// opts, which therefore take precedence.
+func (f FMTP) NewEncoder(opts ...opus.EncoderOption) (*opus.Encoder, error) {
+	return opus.NewEncoder(append(f.EncoderOptions(), opts...)...)
+}
+
+// NewDecoder returns a Decoder for a remote party's stream: stereo output if
+// sprop-stereo is set, at the lowest Opus rate that holds everything
+// sprop-maxcapturerate says the sender captures.
+func (f FMTP) NewDecoder() (opus.Decoder, error) {
+	channels := 1
+	if f.SpropStereo {
+		channels = 2
+	}
+	sampleRate := opus.BandwidthFullband.SampleRate()
+	if f.SpropMaxCaptureRate != 0 {
+		for _, rate := range []int{8000, 12000, 16000, 24000} {
+			if f.SpropMaxCaptureRate <= rate {
+				sampleRate = rate
+
+				break
+			}
+		}
+	}
+
+	return opus.NewDecoderWithOutput(sampleRate, channels)
+}
+
+// bandwidthForRate maps maxplaybackrate onto a bandwidth the way libwebrtc
+// does, except that rates which would select mediumband get wideband, as
+// libopus's CELT layer codes mediumband as wideband anyway.
+func bandwidthForRate(rate int) opus.Bandwidth {
+	switch {
+	case rate <= opus.BandwidthNarrowband.SampleRate():
+		return opus.BandwidthNarrowband
+	case rate <= opus.BandwidthWideband.SampleRate():
+		return opus.BandwidthWideband
+	case rate <= opus.BandwidthSuperwideband.SampleRate():
+		return opus.BandwidthSuperwideband
+	default:
+		return opus.BandwidthFullband
+	}
+}
@@ -0,0 +1,102 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package rtpopus
+
+import (
+	"testing"
+
+	"github.com/pion/opus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFMTP(t *testing.T) {
+	fmtp, err := ParseFMTP("minptime=10; useinbandfec=1;Stereo=1;sprop-stereo=0;maxplaybackrate=16000;" +
+		"sprop-maxcapturerate=24000;maxaveragebitrate=40000;cbr=1;usedtx=1")
+	require.NoError(t, err)
+	assert.Equal(t, FMTP{
+		MaxPlaybackRate:     16000,
+		SpropMaxCaptureRate: 24000,
+		MaxAverageBitrate:   40000,
+		Stereo:              true,
+		CBR:                 true,
+		UseInbandFEC:        true,
+		UseDTX:              true,
+	}, fmtp)
+	assert.Equal(t, "maxplaybackrate=16000;sprop-maxcapturerate=24000;maxaveragebitrate=40000;"+
+		"stereo=1;cbr=1;useinbandfec=1;usedtx=1", fmtp.String())
+
+	reparsed, err := ParseFMTP(fmtp.String())
+	require.NoError(t, err)
+	assert.Equal(t, fmtp, reparsed)
+
+	empty, err := ParseFMTP("")
+	require.NoError(t, err)
+	assert.Equal(t, FMTP{}, empty)
+	assert.Empty(t, empty.String())
+
+	for line, want := range map[string]error{
+		"useinbandfec":         errMalformedFMTP,
+		"stereo=2":             errInvalidFMTPValue,
+		"maxplaybackrate=-1":   errInvalidPlaybackRate,
+		"sprop-maxcapturerate": errMalformedFMTP,
+		"maxaveragebitrate=x":  errInvalidFMTPValue,
+	} {
+		_, err := ParseFMTP(line)
+		assert.ErrorIs(t, err, want, line)
+	}
+}
+
+func TestFMTPEncoder(t *testing.T) {
+	encoder, err := FMTP{
+		MaxPlaybackRate:   12000,
+		MaxAverageBitrate: 1000000,
+		Stereo:            true,
+		CBR:               true,
+		UseInbandFEC:      true,
+	}.NewEncoder(opus.WithComplexity(3))
+	require.NoError(t, err)
+	assert.Equal(t, 2, encoder.Channels())
+	assert.Equal(t, opus.BandwidthWideband, encoder.MaxBandwidth())
+	assert.False(t, encoder.VBR())
+	assert.Equal(t, 3, encoder.Complexity())
+	assert.True(t, encoder.InbandFEC())
+
+	for rate, want := range map[int]opus.Bandwidth{
+		8000:  opus.BandwidthNarrowband,
+		16000: opus.BandwidthWideband,
+		22050: opus.BandwidthSuperwideband,
+		44100: opus.BandwidthFullband,
+	} {
+		assert.Equal(t, want, bandwidthForRate(rate), rate)
+	}
+
+	encoder, err = FMTP{}.NewEncoder(opus.WithVBR(true))
+	require.NoError(t, err)
+	assert.Equal(t, 1, encoder.Channels())
+	assert.True(t, encoder.VBR())
+	assert.False(t, encoder.InbandFEC())
+
+	encoder, err = FMTP{CBR: true}.NewEncoder(opus.WithVBR(true))
+	require.NoError(t, err)
+	assert.True(t, encoder.VBR(), "options passed to NewEncoder come last")
+}
+
+func TestFMTPDecoder(t *testing.T) {
+	decoder, err := FMTP{SpropStereo: true, SpropMaxCaptureRate: 16000}.NewDecoder()
+	require.NoError(t, err)
+
+	// A 20 ms CELT packet decodes to 320 stereo samples at 16 kHz.
+	out := make([]float32, 2*320)
+	n, err := decoder.DecodeToFloat32(celt20ms, out)
+	require.NoError(t, err)
+	assert.Equal(t, 320, n)
+
+	decoder, err = FMTP{}.NewDecoder()
+	require.NoError(t, err)
+	out = make([]float32, 960)
+	n, err = decoder.DecodeToFloat32(celt20ms, out)
+	require.NoError(t, err)
+	assert.Equal(t, 960, n)
+}
@@ -0,0 +1,132 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package rtpopus
+
+import (
+	"github.com/pion/opus"
+)
+
+// maxDTXPacketSize is the largest packet treated as a DTX frame. libopus
+// emits a bare TOC byte, or a TOC byte plus one byte, when it stops sending
+// audio during silence.
+const maxDTXPacketSize = 2
+
+// IsDTX reports whether packet is a discontinuous transmission frame, one
+// that carries no audio and only keeps the decoder's comfort noise going.
+func IsDTX(packet []byte) bool {
+	return len(packet) <= maxDTXPacketSize
+}
+
+// Packetizer wraps Opus packets in RTP packets for one stream.
+//
+// Each RTP timestamp is the 48 kHz position of the packet's first sample, and
+// the timestamp advances by the duration the packet's TOC byte and frame
+// count describe (RFC 7587 Section 4.1), so packets of any frame size may be
+// mixed. The marker bit is set on the first packet after a run of DTX frames,
+// where a new talkspurt begins (RFC 7587 Section 4.2).
+type Packetizer struct {
+	payloadType    uint8
+	ssrc           uint32
+	sequenceNumber uint16
+	timestamp      uint32
+	dropDTX        bool
+	inDTX          bool
+}
+
+// PacketizerOption configures a Packetizer.
+type PacketizerOption func(*Packetizer) error
+
+// WithSequenceNumber sets the sequence number of the first packet. RFC 3550
+// recommends a random value, which is left to the caller; the default is 0.
+func WithSequenceNumber(sequenceNumber uint16) PacketizerOption {
+	return func(p *Packetizer) error {
+		p.sequenceNumber = sequenceNumber
+
+		return nil
+	}
+}
+
+// WithTimestamp sets the RTP timestamp of the first packet. Like the sequence
+// number it defaults to 0.
+func WithTimestamp(timestamp uint32) PacketizerOption {
+	return func(p *Packetizer) error {
+		p.timestamp = timestamp
+
+		return nil
+	}
+}
+
+// WithDropDTX makes Packetize skip DTX frames instead of sending them. The
+// timestamp still advances over them, so the receiver sees the silence as a
+// timestamp jump without a sequence number gap.
+func WithDropDTX(drop bool) PacketizerOption {
+	return func(p *Packetizer) error {
+		p.dropDTX = drop
+
+		return nil
+	}
+}
+
+// NewPacketizer returns a Packetizer sending payloadType, the dynamic payload
+// type negotiated for Opus, from ssrc.
+func NewPacketizer(payloadType uint8, ssrc uint32, opts ...PacketizerOption) (*Packetizer, error) {
+	if payloadType > rtpMaxPayloadType {
+		return nil, errInvalidPayloadType
+	}
+
+	packetizer := &Packetizer{payloadType: payloadType, ssrc: ssrc}
+	for _, opt := range opts {
+		if err := opt(packetizer); err != nil {
+			return nil, err
+		}
+	}
+
+	return packetizer, nil
+}
+
+// Packetize returns the RTP packet carrying packet, whose payload aliases
+// packet. It returns a nil Packet when packet is a DTX frame and WithDropDTX
+// is set. packet must be a well-formed Opus packet so its duration can be
+// read.
+func (p *Packetizer) Packetize(packet []byte) (*Packet, error) {
+	samples, err := opus.PacketSampleCount(packet, ClockRate)
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp := p.timestamp
+	p.timestamp += uint32(samples) // #nosec G115 -- at most 120 ms of samples.
+
+	if IsDTX(packet) {
+		p.inDTX = true
+		if p.dropDTX {
+			return nil, nil //nolint:nilnil // A dropped frame has no packet and is not an error.
+		}
+	}
+
+	rtpPacket := &Packet{
+		Header: Header{
+			Marker:         p.inDTX && !IsDTX(packet),
+			PayloadType:    p.payloadType,
+			SequenceNumber: p.sequenceNumber,
+			Timestamp:      timestamp,
+			SSRC:           p.ssrc,
+		},
+		Payload: packet,
+	}
+	p.sequenceNumber++
+	if !IsDTX(packet) {
+		p.inDTX = false
+	}
+
+	return rtpPacket, nil
+}
+
+// SkipSamples advances the timestamp over samples 48 kHz samples that were
+// not encoded, such as while the source was muted, so the next packet lands
+// at the right position and starts a talkspurt.
+func (p *Packetizer) SkipSamples(samples uint32) {
+	p.timestamp += samples
+	p.inDTX = true
+}
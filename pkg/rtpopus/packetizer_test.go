@@ -0,0 +1,161 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package rtpopus
+
+import (
+	"testing"
+
+	"github.com/pion/opus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	// CELT fullband 20 ms, SILK wideband 60 ms and two CELT 10 ms frames.
+	celt20ms      = []byte{31 << 3, 0x11, 0x22, 0x33}
+	silk60ms      = []byte{11 << 3, 0x11, 0x22, 0x33}
+	celtTwo10ms   = []byte{30<<3 | 1, 0x11, 0x22}
+	dtxFrame      = []byte{31 << 3}
+	malformedCode = []byte{31<<3 | 3}
+)
+
+func TestPacketizerTimestamps(t *testing.T) {
+	start := uint32(0xffffff00)
+	packetizer, err := NewPacketizer(111, 42, WithSequenceNumber(0xffff), WithTimestamp(start))
+	require.NoError(t, err)
+
+	var got []Header
+	for _, packet := range [][]byte{celt20ms, silk60ms, celtTwo10ms, celt20ms} {
+		rtpPacket, err := packetizer.Packetize(packet)
+		require.NoError(t, err)
+		assert.Equal(t, packet, rtpPacket.Payload)
+		got = append(got, rtpPacket.Header)
+	}
+
+	assert.Equal(t, []Header{
+		{PayloadType: 111, SequenceNumber: 0xffff, Timestamp: start, SSRC: 42},
+		{PayloadType: 111, SequenceNumber: 0, Timestamp: start + 960, SSRC: 42},
+		{PayloadType: 111, SequenceNumber: 1, Timestamp: start + 960 + 2880, SSRC: 42},
+		{PayloadType: 111, SequenceNumber: 2, Timestamp: start + 960 + 2880 + 960, SSRC: 42},
+	}, got)
+
+	_, err = packetizer.Packetize(malformedCode)
+	assert.Error(t, err)
+}
+
+func TestPacketizerDTXMarker(t *testing.T) {
+	for _, drop := range []bool{false, true} {
+		packetizer, err := NewPacketizer(111, 1, WithDropDTX(drop))
+		require.NoError(t, err)
+
+		var sent []*Packet
+		for _, packet := range [][]byte{celt20ms, dtxFrame, dtxFrame, celt20ms, celt20ms} {
+			rtpPacket, err := packetizer.Packetize(packet)
+			require.NoError(t, err)
+			if rtpPacket != nil {
+				sent = append(sent, rtpPacket)
+			}
+		}
+
+		if drop {
+			require.Len(t, sent, 3)
+		} else {
+			require.Len(t, sent, 5)
+		}
+		speech := sent[len(sent)-2]
+		assert.True(t, speech.Marker, "first packet after DTX starts a talkspurt")
+		assert.Equal(t, uint32(3*960), speech.Timestamp)
+		assert.Equal(t, uint16(len(sent)-2), speech.SequenceNumber) //nolint:gosec // A handful of packets.
+		for i, packet := range sent {
+			assert.Equal(t, packet == speech, packet.Marker, "packet %d", i)
+		}
+	}
+
+	packetizer, err := NewPacketizer(111, 1)
+	require.NoError(t, err)
+	packetizer.SkipSamples(4800)
+	rtpPacket, err := packetizer.Packetize(celt20ms)
+	require.NoError(t, err)
+	assert.True(t, rtpPacket.Marker)
+	assert.Equal(t, uint32(4800), rtpPacket.Timestamp)
+
+	_, err = NewPacketizer(128, 1)
+	assert.ErrorIs(t, err, errInvalidPayloadType)
+}
+
+func TestDepacketizer(t *testing.T) {
+	packetizer, err := NewPacketizer(111, 1, WithSequenceNumber(0xfffe))
+	require.NoError(t, err)
+	var wire [][]byte
+	for _, packet := range [][]byte{celt20ms, silk60ms, celtTwo10ms, celt20ms, celt20ms} {
+		rtpPacket, err := packetizer.Packetize(packet)
+		require.NoError(t, err)
+		buf, err := rtpPacket.Marshal()
+		require.NoError(t, err)
+		wire = append(wire, buf)
+	}
+
+	depacketizer, err := NewDepacketizerForPayloadType(111)
+	require.NoError(t, err)
+
+	// Drop the third packet and deliver the fourth twice and late.
+	var samples []*Sample
+	for _, buf := range [][]byte{wire[0], wire[1], wire[3], wire[4], wire[3]} {
+		sample, err := depacketizer.Depacketize(buf)
+		require.NoError(t, err)
+		samples = append(samples, sample)
+	}
+
+	assert.Equal(t, celt20ms, samples[0].Packet)
+	assert.Equal(t, 960, samples[0].Duration)
+	assert.Equal(t, 2880, samples[1].Duration)
+	assert.Equal(t, uint32(960), samples[1].Timestamp)
+	assert.Equal(t, 1, samples[2].Lost)
+	assert.Equal(t, uint32(960+2880+960), samples[2].Timestamp)
+	assert.Zero(t, samples[3].Lost)
+	assert.False(t, samples[3].Reordered)
+	assert.True(t, samples[4].Reordered)
+	assert.Zero(t, samples[4].Lost)
+
+	wrongType := Packet{Header: Header{PayloadType: 96}, Payload: celt20ms}
+	_, err = depacketizer.DepacketizePacket(&wrongType)
+	assert.ErrorIs(t, err, errUnexpectedPayload)
+
+	_, err = NewDepacketizer().DepacketizePacket(&Packet{})
+	assert.ErrorIs(t, err, errEmptyPayload)
+
+	_, err = NewDepacketizer().DepacketizePacket(&Packet{Payload: malformedCode})
+	assert.Error(t, err)
+
+	_, err = NewDepacketizerForPayloadType(200)
+	assert.ErrorIs(t, err, errInvalidPayloadType)
+}
+
+func TestPacketizeEncoderOutput(t *testing.T) {
+	encoder, err := opus.NewEncoder()
+	require.NoError(t, err)
+	decoder := opus.NewDecoder()
+
+	packetizer, err := NewPacketizer(111, 1)
+	require.NoError(t, err)
+	depacketizer := NewDepacketizer()
+
+	pcm := make([]byte, encoder.FrameSize()*2)
+	out := make([]byte, 1500)
+	decoded := make([]float32, encoder.FrameSize())
+	for range 3 {
+		n, err := encoder.Encode(pcm, out)
+		require.NoError(t, err)
+		rtpPacket, err := packetizer.Packetize(out[:n])
+		require.NoError(t, err)
+		buf, err := rtpPacket.Marshal()
+		require.NoError(t, err)
+
+		sample, err := depacketizer.Depacketize(buf)
+		require.NoError(t, err)
+		assert.Equal(t, encoder.FrameSize(), sample.Duration)
+		_, err = decoder.DecodeToFloat32(sample.Packet, decoded)
+		require.NoError(t, err)
+	}
+}
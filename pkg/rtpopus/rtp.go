@@ -0,0 +1,134 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+// Package rtpopus implements the RTP payload format for Opus (RFC 7587)
+package rtpopus
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+const (
+	// ClockRate is the RTP timestamp rate RFC 7587 Section 4.1 fixes for
+	// Opus. It is 48 kHz whatever rate the audio is captured or played at.
+	ClockRate = 48000
+
+	rtpVersion         = 2
+	rtpHeaderLen       = 12
+	rtpCSRCLen         = 4
+	rtpExtensionLen    = 4
+	rtpMaxCSRCCount    = 15
+	rtpMaxPayloadType  = 127
+	rtpVersionShift    = 6
+	rtpPaddingFlag     = 0x20
+	rtpExtensionFlag   = 0x10
+	rtpCSRCCountMask   = 0x0f
+	rtpMarkerFlag      = 0x80
+	rtpPayloadTypeMask = 0x7f
+)
+
+var (
+	errShortHeader         = errors.New("RTP packet is shorter than its header")
+	errBadVersion          = errors.New("RTP version is not 2")
+	errBadPadding          = errors.New("RTP padding overruns the payload")
+	errTooManyCSRC         = errors.New("RTP header allows at most 15 CSRCs")
+	errInvalidPayloadType  = errors.New("RTP payload type must be 0-127")
+	errEmptyPayload        = errors.New("RTP packet carries no Opus packet")
+	errUnexpectedPayload   = errors.New("RTP payload type does not match")
+	errInvalidFMTPValue    = errors.New("invalid fmtp parameter value")
+	errMalformedFMTP       = errors.New("malformed fmtp parameter")
+	errInvalidCaptureRate  = errors.New("sprop-maxcapturerate must be positive")
+	errInvalidPlaybackRate = errors.New("maxplaybackrate must be positive")
+)
+
+// Header is the fixed RTP header (RFC 3550 Section 5.1) of a packet carrying
+// Opus. Header extensions are skipped when unmarshaling and never written.
+type Header struct {
+	Marker         bool
+	PayloadType    uint8
+	SequenceNumber uint16
+	Timestamp      uint32
+	SSRC           uint32
+	CSRC           []uint32
+}
+
+// Packet is an RTP packet whose payload is exactly one Opus packet
+// (RFC 7587 Section 4.2).
+type Packet struct {
+	Header
+	Payload []byte
+}
+
+// Marshal serializes the packet. Payload is copied into the result.
+func (p *Packet) Marshal() ([]byte, error) {
+	if len(p.CSRC) > rtpMaxCSRCCount {
+		return nil, errTooManyCSRC
+	}
+	if p.PayloadType > rtpMaxPayloadType {
+		return nil, errInvalidPayloadType
+	}
+
+	buf := make([]byte, 0, rtpHeaderLen+len(p.CSRC)*rtpCSRCLen+len(p.Payload))
+	buf = append(buf, rtpVersion<<rtpVersionShift|byte(len(p.CSRC)))
+	second := p.PayloadType
+	if p.Marker {
+		second |= rtpMarkerFlag
+	}
+	buf = append(buf, second)
+	buf = binary.BigEndian.AppendUint16(buf, p.SequenceNumber)
+	buf = binary.BigEndian.AppendUint32(buf, p.Timestamp)
+	buf = binary.BigEndian.AppendUint32(buf, p.SSRC)
+	for _, csrc := range p.CSRC {
+		buf = binary.BigEndian.AppendUint32(buf, csrc)
+	}
+
+	return append(buf, p.Payload...), nil
+}
+
+// Unmarshal parses buf into the packet. Payload aliases buf, with any RTP
+// padding removed.
+func (p *Packet) Unmarshal(buf []byte) error {
+	if len(buf) < rtpHeaderLen {
+		return errShortHeader
+	}
+	if buf[0]>>rtpVersionShift != rtpVersion {
+		return errBadVersion
+	}
+
+	offset := rtpHeaderLen + int(buf[0]&rtpCSRCCountMask)*rtpCSRCLen
+	if len(buf) < offset {
+		return errShortHeader
+	}
+	p.Marker = buf[1]&rtpMarkerFlag != 0
+	p.PayloadType = buf[1] & rtpPayloadTypeMask
+	p.SequenceNumber = binary.BigEndian.Uint16(buf[2:])
+	p.Timestamp = binary.BigEndian.Uint32(buf[4:])
+	p.SSRC = binary.BigEndian.Uint32(buf[8:])
+	p.CSRC = p.CSRC[:0]
+	for i := rtpHeaderLen; i < offset; i += rtpCSRCLen {
+		p.CSRC = append(p.CSRC, binary.BigEndian.Uint32(buf[i:]))
+	}
+
+	if buf[0]&rtpExtensionFlag != 0 {
+		if len(buf) < offset+rtpExtensionLen {
+			return errShortHeader
+		}
+		offset += rtpExtensionLen + int(binary.BigEndian.Uint16(buf[offset+2:]))*4
+		if len(buf) < offset {
+			return errShortHeader
+		}
+	}
+
+	end := len(buf)
+	if buf[0]&rtpPaddingFlag != 0 {
+		padding := int(buf[end-1])
+		if padding == 0 || end-padding < offset {
+			return errBadPadding
+		}
+		end -= padding
+	}
+	p.Payload = buf[offset:end]
+
+	return nil
+}
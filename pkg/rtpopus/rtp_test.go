@@ -0,0 +1,56 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package rtpopus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPacketMarshalRoundTrip(t *testing.T) {
+	packet := Packet{
+		Header: Header{
+			Marker:         true,
+			PayloadType:    111,
+			SequenceNumber: 0xfffe,
+			Timestamp:      0x01020304,
+			SSRC:           0xdeadbeef,
+			CSRC:           []uint32{1, 2},
+		},
+		Payload: []byte{0xfc, 0xff, 0xfe},
+	}
+	buf, err := packet.Marshal()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x82, 0xef, 0xff, 0xfe, 1, 2, 3, 4, 0xde, 0xad, 0xbe, 0xef}, buf[:12])
+
+	var parsed Packet
+	require.NoError(t, parsed.Unmarshal(buf))
+	assert.Equal(t, packet, parsed)
+}
+
+func TestPacketUnmarshalExtensionAndPadding(t *testing.T) {
+	buf := []byte{
+		0xb0, 111, 0, 1, 0, 0, 0, 2, 0, 0, 0, 3, // V=2, P, X, no CSRC
+		0xbe, 0xde, 0, 1, 0x10, 0xaa, 0, 0, // one-byte header extension, one word
+		0xfc, 0xff, // payload
+		0, 0, 3, // padding
+	}
+	var packet Packet
+	require.NoError(t, packet.Unmarshal(buf))
+	assert.Equal(t, []byte{0xfc, 0xff}, packet.Payload)
+	assert.Equal(t, uint16(1), packet.SequenceNumber)
+
+	buf[len(buf)-1] = 6
+	assert.ErrorIs(t, packet.Unmarshal(buf), errBadPadding)
+	assert.ErrorIs(t, packet.Unmarshal(buf[:10]), errShortHeader)
+	assert.ErrorIs(t, packet.Unmarshal(buf[:14]), errShortHeader)
+	assert.ErrorIs(t, packet.Unmarshal(append([]byte{0x40}, buf[1:]...)), errBadVersion)
+
+	_, err := (&Packet{Header: Header{PayloadType: 128}}).Marshal()
+	assert.ErrorIs(t, err, errInvalidPayloadType)
+	_, err = (&Packet{Header: Header{CSRC: make([]uint32, 16)}}).Marshal()
+	assert.ErrorIs(t, err, errTooManyCSRC)
+}
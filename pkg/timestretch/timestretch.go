@@ -0,0 +1,259 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+// Package timestretch shortens or lengthens decoded audio without changing
+// its pitch, so a jitter buffer can change its depth without audible gaps or
+// repeats, the way WebRTC NetEq's accelerate and preemptive expand do.
+//
+// It is pitch synchronous: audio is only ever removed or repeated in whole
+// pitch periods, each found by searching for the lag at which the signal best
+// matches itself, and every splice is cross-faded over that period. Passing
+// the pitch lag the SILK decoder already knows (opus.Decoder.PitchLag)
+// narrows the search.
+package timestretch
+
+import (
+	"errors"
+	"math"
+)
+
+const (
+	// minPitchPeriodMs and maxPitchPeriodMs bound the pitch periods searched,
+	// 400 Hz down to 50 Hz.
+	minPitchPeriodMs = 2.5
+	maxPitchPeriodMs = 20
+
+	// MinRatio and MaxRatio bound the ratio Stretch accepts. Each splice
+	// removes or repeats one period out of two periods of input, so audio
+	// can at most lose or gain half its length.
+	MinRatio = 0.5
+	MaxRatio = 1.5
+
+	// minCorrelation is how well a period must match the next for a splice
+	// to be inaudible; NetEq refuses to accelerate below a similar value.
+	minCorrelation = 0.7
+	// silenceEnergy is the mean square below which audio counts as silence,
+	// which may be spliced anywhere.
+	silenceEnergy = 1e-7
+
+	// hintTolerance is how far from a pitch hint the search may wander, as a
+	// fraction of it.
+	hintTolerance = 1.0 / 16
+)
+
+var (
+	errInvalidSampleRate   = errors.New("invalid sample rate")
+	errInvalidChannelCount = errors.New("invalid channel count")
+	errInvalidRatio        = errors.New("ratio must be between 0.5 and 1.5")
+	errInvalidInputLength  = errors.New("input is not a whole number of interleaved samples")
+	errOutBufferTooSmall   = errors.New("out isn't large enough")
+)
+
+// Stretcher time-scales interleaved float32 audio at one sample rate and
+// channel count. Each call stands alone: the first and last samples of the
+// input are always kept, so consecutive buffers can be stretched one by one.
+type Stretcher struct {
+	channels int
+	minLag   int
+	maxLag   int
+	mono     []float32
+}
+
+// New returns a Stretcher for audio at sampleRate with channels interleaved
+// channels.
+func New(sampleRate, channels int) (*Stretcher, error) {
+	if sampleRate < 8000 || sampleRate > 192000 {
+		return nil, errInvalidSampleRate
+	}
+	if channels < 1 || channels > 8 {
+		return nil, errInvalidChannelCount
+	}
+
+	return &Stretcher{
+		channels: channels,
+		minLag:   int(math.Ceil(float64(sampleRate) * minPitchPeriodMs / 1000)),
+		maxLag:   sampleRate * maxPitchPeriodMs / 1000,
+	}, nil
+}
+
+// MaxOutputSamples returns how many samples per channel out must be able to
+// hold for Stretch to take inSamples samples per channel at ratio: the longer
+// of the input and the target length, plus one pitch period, the most a
+// whole-period splice overshoots by.
+func (s *Stretcher) MaxOutputSamples(inSamples int, ratio float64) int {
+	return max(inSamples, int(math.Ceil(float64(inSamples)*ratio))) + s.maxLag
+}
+
+// Stretch writes in, time-scaled to about ratio times its length, to out and
+// returns the samples per channel written. A ratio below 1 shortens the
+// audio, above 1 lengthens it. Since only whole pitch periods are removed or
+// repeated, and only where a period matches the next closely enough to splice
+// unnoticed, the result only approaches the requested length; in particular
+// noise-like audio is left as it is. out must hold MaxOutputSamples.
+func (s *Stretcher) Stretch(in, out []float32, ratio float64) (int, error) {
+	return s.StretchWithPitchLag(in, out, ratio, 0)
+}
+
+// StretchWithPitchLag is Stretch for audio whose pitch period, in samples, is
+// already known, such as from opus.Decoder.PitchLag. A lag of 0 means it is
+// not known.
+//
+//nolint:cyclop
+func (s *Stretcher) StretchWithPitchLag(in, out []float32, ratio float64, pitchLag int) (int, error) {
+	if ratio < MinRatio || ratio > MaxRatio || math.IsNaN(ratio) {
+		return 0, errInvalidRatio
+	}
+	if len(in)%s.channels != 0 {
+		return 0, errInvalidInputLength
+	}
+	inSamples := len(in) / s.channels
+	if len(out) < s.MaxOutputSamples(inSamples, ratio)*s.channels {
+		return 0, errOutBufferTooSmall
+	}
+
+	delta := int(math.Round(float64(inSamples)*ratio)) - inSamples
+	minLag, maxLag := s.minLag, s.maxLag
+	if pitchLag > 0 {
+		tolerance := max(1, int(float64(pitchLag)*hintTolerance))
+		minLag = max(minLag, pitchLag-tolerance)
+		maxLag = min(maxLag, pitchLag+tolerance)
+	}
+	if delta == 0 || minLag > maxLag || inSamples < 2*minLag {
+		copy(out, in)
+
+		return inSamples, nil
+	}
+	s.mixDown(in, inSamples)
+
+	// Spread the splices over the buffer, planned for the typical period.
+	expectedLag := max(s.bestLag(0, inSamples, minLag, min(maxLag, inSamples/2)), 1)
+	splices := int(math.Round(float64(abs(delta)) / float64(expectedLag)))
+	splices = max(1, min(splices, inSamples/(2*expectedLag)))
+	spacing := inSamples / splices
+
+	remaining := abs(delta)
+	read, written := 0, 0
+	for splice := 0; splice < splices && remaining > 0; splice++ {
+		start := max(splice*spacing, read)
+		end := min(inSamples, (splice+1)*spacing)
+		if splice == splices-1 {
+			end = inSamples
+		}
+		lag := s.bestLag(start, end, minLag, min(maxLag, (end-start)/2))
+		if lag == 0 || 2*remaining < lag || !s.spliceable(start, lag) {
+			continue
+		}
+
+		if delta < 0 {
+			// Remove the period at start: fade it out into the one after it.
+			written += s.copyFrames(in, out, read, start, written)
+			s.crossFade(out[written*s.channels:], in[start*s.channels:], in[(start+lag)*s.channels:], lag)
+			written += lag
+			read = start + 2*lag
+		} else {
+			// Repeat the period at start: after it, fade what follows out
+			// into the period again, which leads back into what follows.
+			written += s.copyFrames(in, out, read, start+lag, written)
+			s.crossFade(out[written*s.channels:], in[(start+lag)*s.channels:], in[start*s.channels:], lag)
+			written += lag
+			read = start + lag
+		}
+		remaining -= lag
+	}
+	written += s.copyFrames(in, out, read, inSamples, written)
+
+	return written, nil
+}
+
+// mixDown averages the channels into s.mono, which the lag search runs on.
+func (s *Stretcher) mixDown(in []float32, samples int) {
+	if cap(s.mono) < samples {
+		s.mono = make([]float32, samples)
+	}
+	s.mono = s.mono[:samples]
+	for i := range samples {
+		var sum float32
+		for channel := range s.channels {
+			sum += in[i*s.channels+channel]
+		}
+		s.mono[i] = sum / float32(s.channels)
+	}
+}
+
+// bestLag returns the lag in [minLag, maxLag] at which the period starting at
+// start best matches the period after it, within [start, end), or 0 if no lag
+// fits.
+func (s *Stretcher) bestLag(start, end, minLag, maxLag int) int {
+	bestLag, bestCorrelation := 0, math.Inf(-1)
+	for lag := minLag; lag <= maxLag && start+2*lag <= end; lag++ {
+		if correlation := s.correlation(start, lag); correlation > bestCorrelation {
+			bestLag, bestCorrelation = lag, correlation
+		}
+	}
+
+	return bestLag
+}
+
+// correlation is the normalized cross-correlation of the period of length lag
+// at start with the period after it.
+func (s *Stretcher) correlation(start, lag int) float64 {
+	var cross, energyA, energyB float64
+	for i := range lag {
+		a := float64(s.mono[start+i])
+		b := float64(s.mono[start+lag+i])
+		cross += a * b
+		energyA += a * a
+		energyB += b * b
+	}
+	if energyA == 0 || energyB == 0 {
+		return 0
+	}
+
+	return cross / math.Sqrt(energyA*energyB)
+}
+
+// spliceable reports whether the two periods at start are similar enough, or
+// quiet enough, to splice without an audible seam.
+func (s *Stretcher) spliceable(start, lag int) bool {
+	var energy float64
+	for _, sample := range s.mono[start : start+2*lag] {
+		energy += float64(sample) * float64(sample)
+	}
+	if energy/float64(2*lag) < silenceEnergy {
+		return true
+	}
+
+	return s.correlation(start, lag) >= minCorrelation
+}
+
+// copyFrames copies the frames [from, to) of in to out at frame offset at and
+// returns how many it copied.
+func (s *Stretcher) copyFrames(in, out []float32, from, to, at int) int {
+	if to <= from {
+		return 0
+	}
+	copy(out[at*s.channels:], in[from*s.channels:to*s.channels])
+
+	return to - from
+}
+
+// crossFade writes length frames that fade linearly from fadeOut to fadeIn,
+// starting on fadeOut's first frame and ending on fadeIn's last, so the
+// splice joins the audio on either side of it exactly.
+func (s *Stretcher) crossFade(out, fadeOut, fadeIn []float32, length int) {
+	for i := range length {
+		weight := float32(i) / float32(max(length-1, 1))
+		for channel := range s.channels {
+			index := i*s.channels + channel
+			out[index] = (1-weight)*fadeOut[index] + weight*fadeIn[index]
+		}
+	}
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+
+	return value
+}
@@ -0,0 +1,165 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package timestretch
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSampleRate = 16000
+
+// voiced returns samples of a 160 Hz tone with a harmonic, a 100-sample
+// period at 16 kHz, interleaved over channels with each channel scaled
+// differently.
+func voiced(samples, channels int) []float32 {
+	out := make([]float32, samples*channels)
+	for i := range samples {
+		phase := 2 * math.Pi * float64(i) / 100
+		value := float32(0.4*math.Sin(phase) + 0.2*math.Sin(2*phase))
+		for channel := range channels {
+			out[i*channels+channel] = value / float32(channel+1)
+		}
+	}
+
+	return out
+}
+
+// zeroCrossings counts sign changes in channel 0, which tracks pitch.
+func zeroCrossings(samples []float32, channels int) int {
+	count := 0
+	for i := channels; i < len(samples); i += channels {
+		if (samples[i-channels] < 0) != (samples[i] < 0) {
+			count++
+		}
+	}
+
+	return count
+}
+
+// maxStep is the largest jump between consecutive samples of channel 0; a
+// badly placed splice shows up as a step the smooth input never makes.
+func maxStep(samples []float32, channels int) float64 {
+	var largest float64
+	for i := channels; i < len(samples); i += channels {
+		largest = max(largest, math.Abs(float64(samples[i]-samples[i-channels])))
+	}
+
+	return largest
+}
+
+func TestStretchVoiced(t *testing.T) {
+	for _, ratio := range []float64{0.5, 0.8, 0.95, 1.1, 1.25, 1.5} {
+		stretcher, err := New(testSampleRate, 1)
+		require.NoError(t, err)
+
+		in := voiced(1600, 1)
+		out := make([]float32, stretcher.MaxOutputSamples(1600, ratio))
+		n, err := stretcher.Stretch(in, out, ratio)
+		require.NoError(t, err)
+
+		target := 1600 * ratio
+		assert.InDelta(t, target, n, 50, "ratio %v: whole 100-sample periods get within half a period", ratio)
+		// The pitch is unchanged: the zero-crossing rate stays put.
+		inRate := float64(zeroCrossings(in, 1)) / 1600
+		outRate := float64(zeroCrossings(out[:n], 1)) / float64(n)
+		assert.InEpsilon(t, inRate, outRate, 0.05, "ratio %v", ratio)
+		assert.Less(t, maxStep(out[:n], 1), 1.1*maxStep(in, 1), "ratio %v: audible seam", ratio)
+		assert.Equal(t, in[0], out[0])
+		assert.Equal(t, in[len(in)-1], out[n-1])
+	}
+}
+
+func TestStretchStereoKeepsChannelsAligned(t *testing.T) {
+	stretcher, err := New(testSampleRate, 2)
+	require.NoError(t, err)
+
+	in := voiced(960, 2)
+	out := make([]float32, 2*stretcher.MaxOutputSamples(960, 0.75))
+	n, err := stretcher.Stretch(in, out, 0.75)
+	require.NoError(t, err)
+	assert.Less(t, n, 960)
+	for i := range n {
+		assert.InDelta(t, out[2*i]/2, out[2*i+1], 1e-6)
+	}
+}
+
+func TestStretchWithPitchLag(t *testing.T) {
+	stretcher, err := New(testSampleRate, 1)
+	require.NoError(t, err)
+
+	in := voiced(640, 1)
+	out := make([]float32, stretcher.MaxOutputSamples(640, 0.8))
+	n, err := stretcher.StretchWithPitchLag(in, out, 0.8, 100)
+	require.NoError(t, err)
+	assert.Equal(t, 540, n, "one period of 100 samples removed")
+
+	// A hint outside the searchable range leaves the audio alone.
+	n, err = stretcher.StretchWithPitchLag(in, out, 0.8, 1000)
+	require.NoError(t, err)
+	assert.Equal(t, 640, n)
+}
+
+func TestStretchLeavesNoiseAlone(t *testing.T) {
+	stretcher, err := New(testSampleRate, 1)
+	require.NoError(t, err)
+
+	random := rand.New(rand.NewSource(1)) //nolint:gosec // Deterministic test noise.
+	in := make([]float32, 1600)
+	for i := range in {
+		in[i] = float32(random.NormFloat64() * 0.3)
+	}
+	out := make([]float32, stretcher.MaxOutputSamples(1600, 0.7))
+	n, err := stretcher.Stretch(in, out, 0.7)
+	require.NoError(t, err)
+	assert.Equal(t, 1600, n)
+	assert.Equal(t, in, out[:n])
+}
+
+func TestStretchSilence(t *testing.T) {
+	stretcher, err := New(testSampleRate, 1)
+	require.NoError(t, err)
+
+	in := make([]float32, 1600)
+	out := make([]float32, stretcher.MaxOutputSamples(1600, 0.5))
+	n, err := stretcher.Stretch(in, out, 0.5)
+	require.NoError(t, err)
+	assert.Less(t, n, 1600)
+}
+
+func TestStretchUnity(t *testing.T) {
+	stretcher, err := New(testSampleRate, 1)
+	require.NoError(t, err)
+
+	in := voiced(320, 1)
+	out := make([]float32, stretcher.MaxOutputSamples(320, 1))
+	n, err := stretcher.Stretch(in, out, 1)
+	require.NoError(t, err)
+	assert.Equal(t, in, out[:n])
+}
+
+func TestStretchValidation(t *testing.T) {
+	_, err := New(4000, 1)
+	assert.ErrorIs(t, err, errInvalidSampleRate)
+	_, err = New(testSampleRate, 0)
+	assert.ErrorIs(t, err, errInvalidChannelCount)
+
+	stretcher, err := New(testSampleRate, 2)
+	require.NoError(t, err)
+	out := make([]float32, 4096)
+	_, err = stretcher.Stretch(make([]float32, 320), out, 0.4)
+	assert.ErrorIs(t, err, errInvalidRatio)
+	_, err = stretcher.Stretch(make([]float32, 320), out, 2)
+	assert.ErrorIs(t, err, errInvalidRatio)
+	_, err = stretcher.Stretch(make([]float32, 320), out, math.NaN())
+	assert.ErrorIs(t, err, errInvalidRatio)
+	_, err = stretcher.Stretch(make([]float32, 321), out, 1)
+	assert.ErrorIs(t, err, errInvalidInputLength)
+	_, err = stretcher.Stretch(make([]float32, 3200), out[:100], 1)
+	assert.ErrorIs(t, err, errOutBufferTooSmall)
+}
@@ -0,0 +1,718 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+// Package webmreader implements a reader for the Opus track of WebM and
+// Matroska files.
+//
+// It parses the EBML header, the Segment's SeekHead, Info and Tracks, and
+// then reads the Clusters' SimpleBlocks and BlockGroups as they come,
+// including Segments and Clusters of unknown size as live recorders such as
+// MediaRecorder write them. Cues, found through the SeekHead, allow seeking
+// when the input is an io.ReadSeeker.
+//
+// For gapless playback, drop Track.CodecDelay of decoded audio at the start
+// and each Packet's DiscardPadding at its end (RFC 9559 Section 5.1.4.1.28.19
+// and 10.3.2.1).
+package webmreader
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/pion/opus"
+	"github.com/pion/opus/internal/ebml"
+	"github.com/pion/opus/pkg/oggreader"
+)
+
+const (
+	defaultTimestampScale = 1000000 // ns, so timestamps count milliseconds
+	opusSampleRate        = 48000
+	// maxElementSize bounds the elements read into memory whole: blocks,
+	// the headers and the cues.
+	maxElementSize = 16 << 20
+
+	lacingMask  = 0x06
+	lacingNone  = 0x00
+	lacingXiph  = 0x02
+	lacingFixed = 0x04
+	lacingEBML  = 0x06
+)
+
+var (
+	errNilStream        = errors.New("stream is nil")
+	errNotEBML          = errors.New("not an EBML file")
+	errBadDocType       = errors.New("document type is neither webm nor matroska")
+	errNoSegment        = errors.New("missing Segment")
+	errNoOpusTrack      = errors.New("no Opus audio track")
+	errElementTooLarge  = errors.New("element too large")
+	errUnknownSize      = errors.New("element of unknown size")
+	errMalformedBlock   = errors.New("malformed block")
+	errNotSeekable      = errors.New("stream is not seekable")
+	errNoCues           = errors.New("no cues to seek with")
+	errInvalidTimestamp = errors.New("invalid timestamp scale")
+)
+
+// Track describes the Opus track.
+type Track struct {
+	// Number is the track number blocks refer to the track by.
+	Number uint64
+	// Header is the Opus ID header from CodecPrivate. Files without one get
+	// a header built from the Audio element and CodecDelay.
+	Header oggreader.OggHeader
+	// ChannelMapping is the multistream channel mapping of the header, empty
+	// for mapping family 0.
+	ChannelMapping oggreader.OggChannelMapping
+	// CodecDelay is the decoded audio to drop at the start, the pre-skip.
+	CodecDelay time.Duration
+	// SeekPreRoll is the audio to decode before the target of a seek for the
+	// decoder to have converged.
+	SeekPreRoll time.Duration
+}
+
+// Packet is an Opus packet of the track.
+type Packet struct {
+	Data []byte
+	// Timestamp is the packet's time in the Segment, as its block records
+	// it: the first CodecDelay of the track is yet to be dropped.
+	Timestamp time.Duration
+	// DiscardPadding is the decoded audio to drop at the end of the packet,
+	// which trims the last packet of the stream.
+	DiscardPadding time.Duration
+}
+
+type cuePoint struct {
+	time     uint64 // in timestamp scale units
+	position int64  // of the Cluster, from the start of the Segment data
+}
+
+// WebMReader reads Opus packets from a WebM or Matroska file.
+type WebMReader struct {
+	stream io.Reader
+	input  positionReader
+
+	segmentStart   int64 // position of the Segment data
+	timestampScale uint64
+	duration       time.Duration
+	track          *Track
+
+	clusterTimestamp uint64
+	pending          []*Packet
+
+	cues         []cuePoint
+	cuesPosition int64 // from SeekHead, from the start of the Segment data, or -1
+	earlyCues    []byte
+}
+
+// NewWith returns a new WebM reader reading from in and the Opus track it
+// found. Seeking needs in to be an io.ReadSeeker positioned at the start of
+// the file.
+func NewWith(in io.Reader) (*WebMReader, *Track, error) {
+	if in == nil {
+		return nil, nil, errNilStream
+	}
+
+	reader := &WebMReader{
+		stream:         in,
+		input:          positionReader{buffered: bufio.NewReader(in)},
+		timestampScale: defaultTimestampScale,
+		cuesPosition:   -1,
+	}
+	if err := reader.readHeaders(); err != nil {
+		return nil, nil, err
+	}
+
+	return reader, reader.track, nil
+}
+
+// Duration returns the duration Info gives the Segment, or 0 when it gives
+// none.
+func (r *WebMReader) Duration() time.Duration {
+	return r.duration
+}
+
+// readHeader reads the ID and size of the next element.
+func (r *WebMReader) readHeader() (id uint32, size int64, err error) {
+	if id, _, err = ebml.ReadID(&r.input); err != nil {
+		return 0, 0, err
+	}
+	if size, _, err = ebml.ReadSize(&r.input); err != nil {
+		return 0, 0, err
+	}
+
+	return id, size, nil
+}
+
+// readData reads the size bytes of an element's data.
+func (r *WebMReader) readData(id uint32, size int64) ([]byte, error) {
+	switch {
+	case size == ebml.UnknownSize:
+		return nil, fmt.Errorf("%w: 0x%X", errUnknownSize, id)
+	case size > maxElementSize:
+		return nil, fmt.Errorf("%w: 0x%X of %d bytes", errElementTooLarge, id, size)
+	}
+	data := make([]byte, size)
+	n, err := io.ReadFull(r.input.buffered, data)
+	r.input.position += int64(n)
+	if errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+
+	return data, err
+}
+
+// skip skips the size bytes of an element's data.
+func (r *WebMReader) skip(id uint32, size int64) error {
+	if size == ebml.UnknownSize {
+		return fmt.Errorf("%w: 0x%X", errUnknownSize, id)
+	}
+	for size > 0 {
+		n, err := r.input.buffered.Discard(int(min(size, math.MaxInt32)))
+		r.input.position += int64(n)
+		size -= int64(n)
+		if errors.Is(err, io.EOF) {
+			return io.ErrUnexpectedEOF
+		} else if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *WebMReader) readHeaders() error {
+	id, size, err := r.readHeader()
+	if err != nil || id != ebml.IDEBML {
+		return errNotEBML
+	}
+	data, err := r.readData(id, size)
+	if err != nil {
+		return err
+	}
+	docType := "matroska"
+	if err = ebml.Children(data, func(id uint32, data []byte) error {
+		if id == ebml.IDDocType {
+			docType = string(data)
+		}
+
+		return nil
+	}); err != nil {
+		return err
+	}
+	if docType != "webm" && docType != "matroska" {
+		return fmt.Errorf("%w: %q", errBadDocType, docType)
+	}
+
+	for {
+		id, size, err = r.readHeader()
+		if errors.Is(err, io.EOF) {
+			return errNoSegment
+		} else if err != nil {
+			return err
+		}
+		if id == ebml.IDSegment {
+			break
+		}
+		if err = r.skip(id, size); err != nil {
+			return err
+		}
+	}
+	r.segmentStart = r.input.position
+
+	// The Segment's children up to the first Cluster, whose header is left
+	// read for ParseNextPacket to go on from.
+	for {
+		id, size, err = r.readHeader()
+		if errors.Is(err, io.EOF) || id == ebml.IDCluster {
+			break
+		} else if err != nil {
+			return err
+		}
+		switch id {
+		case ebml.IDSeekHead, ebml.IDInfo, ebml.IDTracks, ebml.IDCues:
+			if data, err = r.readData(id, size); err != nil {
+				return err
+			}
+			if err = r.parseTopLevel(id, data); err != nil {
+				return err
+			}
+		default:
+			if err = r.skip(id, size); err != nil {
+				return err
+			}
+		}
+	}
+	if r.track == nil {
+		return errNoOpusTrack
+	}
+	if r.earlyCues != nil {
+		return r.parseCues(r.earlyCues)
+	}
+
+	return nil
+}
+
+func (r *WebMReader) parseTopLevel(id uint32, data []byte) error {
+	switch id {
+	case ebml.IDSeekHead:
+		return r.parseSeekHead(data)
+	case ebml.IDInfo:
+		return r.parseInfo(data)
+	case ebml.IDTracks:
+		if r.track != nil {
+			return nil
+		}
+
+		return ebml.Children(data, func(id uint32, data []byte) error {
+			if id != ebml.IDTrackEntry || r.track != nil {
+				return nil
+			}
+
+			return r.parseTrackEntry(data)
+		})
+	case ebml.IDCues:
+		if r.track == nil {
+			// Cues name their track, which is yet to be found.
+			r.earlyCues = data
+
+			return nil
+		}
+
+		return r.parseCues(data)
+	}
+
+	return nil
+}
+
+func (r *WebMReader) parseSeekHead(data []byte) error {
+	return ebml.Children(data, func(id uint32, data []byte) error {
+		if id != ebml.IDSeek {
+			return nil
+		}
+		var seekID, position uint64
+		hasPosition := false
+		if err := ebml.Children(data, func(id uint32, data []byte) error {
+			switch id {
+			case ebml.IDSeekID:
+				seekID = ebml.Uint(data)
+			case ebml.IDSeekPosition:
+				position, hasPosition = ebml.Uint(data), true
+			}
+
+			return nil
+		}); err != nil {
+			return err
+		}
+		if seekID == ebml.IDCues && hasPosition && position <= math.MaxInt64 {
+			r.cuesPosition = int64(position)
+		}
+
+		return nil
+	})
+}
+
+func (r *WebMReader) parseInfo(data []byte) error {
+	var duration float64
+	if err := ebml.Children(data, func(id uint32, data []byte) error {
+		var err error
+		switch id {
+		case ebml.IDTimestampScale:
+			r.timestampScale = ebml.Uint(data)
+		case ebml.IDDuration:
+			duration, err = ebml.Float(data)
+		}
+
+		return err
+	}); err != nil {
+		return err
+	}
+	if r.timestampScale == 0 {
+		return errInvalidTimestamp
+	}
+	r.duration = time.Duration(duration * float64(r.timestampScale))
+
+	return nil
+}
+
+func (r *WebMReader) parseTrackEntry(data []byte) error {
+	var (
+		track       Track
+		trackType   uint64
+		codecID     string
+		private     []byte
+		codecDelay  uint64
+		channels    uint64 = 1
+		sampleRate  float64
+		seekPreRoll uint64
+	)
+	if err := ebml.Children(data, func(id uint32, data []byte) error {
+		switch id {
+		case ebml.IDTrackNumber:
+			track.Number = ebml.Uint(data)
+		case ebml.IDTrackType:
+			trackType = ebml.Uint(data)
+		case ebml.IDCodecID:
+			codecID = string(data)
+		case ebml.IDCodecPrivate:
+			private = data
+		case ebml.IDCodecDelay:
+			codecDelay = ebml.Uint(data)
+		case ebml.IDSeekPreRoll:
+			seekPreRoll = ebml.Uint(data)
+		case ebml.IDAudio:
+			return ebml.Children(data, func(id uint32, data []byte) error {
+				var err error
+				switch id {
+				case ebml.IDChannels:
+					channels = ebml.Uint(data)
+				case ebml.IDSamplingFrequency:
+					sampleRate, err = ebml.Float(data)
+				}
+
+				return err
+			})
+		}
+
+		return nil
+	}); err != nil {
+		return err
+	}
+	if trackType != ebml.TrackTypeAudio || codecID != ebml.CodecIDOpus {
+		return nil
+	}
+
+	track.CodecDelay = time.Duration(codecDelay)   //nolint:gosec // G115
+	track.SeekPreRoll = time.Duration(seekPreRoll) //nolint:gosec // G115
+	if private != nil {
+		header, mapping, err := oggreader.ParseOpusHead(private)
+		if err != nil {
+			return fmt.Errorf("CodecPrivate: %w", err)
+		}
+		track.Header, track.ChannelMapping = *header, mapping
+	} else {
+		track.Header = oggreader.OggHeader{
+			Version:    1,
+			Channels:   uint8(min(channels, math.MaxUint8)),                                        //nolint:gosec // G115
+			PreSkip:    uint16(min(codecDelay*opusSampleRate/uint64(time.Second), math.MaxUint16)), //nolint:gosec // G115
+			SampleRate: uint32(sampleRate),
+		}
+	}
+	r.track = &track
+
+	return nil
+}
+
+func (r *WebMReader) parseCues(data []byte) error {
+	r.cues = r.cues[:0]
+
+	return ebml.Children(data, func(id uint32, data []byte) error {
+		if id != ebml.IDCuePoint {
+			return nil
+		}
+		var point cuePoint
+		found := false
+		if err := ebml.Children(data, func(id uint32, data []byte) error {
+			switch id {
+			case ebml.IDCueTime:
+				point.time = ebml.Uint(data)
+			case ebml.IDCueTrackPositions:
+				var track, position uint64
+				if err := ebml.Children(data, func(id uint32, data []byte) error {
+					switch id {
+					case ebml.IDCueTrack:
+						track = ebml.Uint(data)
+					case ebml.IDCueClusterPosition:
+						position = ebml.Uint(data)
+					}
+
+					return nil
+				}); err != nil {
+					return err
+				}
+				if track == r.track.Number && position <= math.MaxInt64 && !found {
+					point.position, found = int64(position), true
+				}
+			}
+
+			return nil
+		}); err != nil {
+			return err
+		}
+		if found {
+			r.cues = append(r.cues, point)
+		}
+
+		return nil
+	})
+}
+
+// positionReader counts the bytes read through it, to find the positions
+// Cues and SeekHead give.
+type positionReader struct {
+	buffered *bufio.Reader
+	position int64
+}
+
+func (p *positionReader) ReadByte() (byte, error) {
+	b, err := p.buffered.ReadByte()
+	if err == nil {
+		p.position++
+	}
+
+	return b, err
+}
+
+// timestamp converts a time in timestamp scale units.
+func (r *WebMReader) timestamp(units int64) time.Duration {
+	return time.Duration(units * int64(r.timestampScale)) //nolint:gosec // G115
+}
+
+// ParseNextPacket returns the next packet of the Opus track, and io.EOF at
+// the end of the file.
+func (r *WebMReader) ParseNextPacket() (*Packet, error) {
+	for len(r.pending) == 0 {
+		id, size, err := r.readHeader()
+		if err != nil {
+			return nil, err
+		}
+		switch id {
+		case ebml.IDSegment, ebml.IDCluster:
+			// Entered rather than skipped, so that their size, which may
+			// be unknown, does not matter.
+			r.clusterTimestamp = 0
+		case ebml.IDTimestamp, ebml.IDSimpleBlock, ebml.IDBlockGroup, ebml.IDCues:
+			data, err := r.readData(id, size)
+			if err != nil {
+				return nil, err
+			}
+			if err = r.parseClusterChild(id, data); err != nil {
+				return nil, err
+			}
+		default:
+			if err = r.skip(id, size); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	packet := r.pending[0]
+	r.pending = r.pending[1:]
+
+	return packet, nil
+}
+
+func (r *WebMReader) parseClusterChild(id uint32, data []byte) error {
+	switch id {
+	case ebml.IDTimestamp:
+		r.clusterTimestamp = ebml.Uint(data)
+	case ebml.IDSimpleBlock:
+		return r.parseBlock(data, 0)
+	case ebml.IDBlockGroup:
+		var block []byte
+		var discardPadding int64
+		if err := ebml.Children(data, func(id uint32, data []byte) error {
+			switch id {
+			case ebml.IDBlock:
+				block = data
+			case ebml.IDDiscardPadding:
+				discardPadding = ebml.Int(data)
+			}
+
+			return nil
+		}); err != nil {
+			return err
+		}
+		if block == nil {
+			return fmt.Errorf("%w: BlockGroup without a Block", errMalformedBlock)
+		}
+
+		return r.parseBlock(block, time.Duration(max(discardPadding, 0)))
+	case ebml.IDCues:
+		return r.parseCues(data)
+	}
+
+	return nil
+}
+
+// parseBlock queues the packets of a Block or SimpleBlock of the track.
+// discardPadding applies to the last of them.
+func (r *WebMReader) parseBlock(data []byte, discardPadding time.Duration) error {
+	track, n, err := ebml.ParseVint(data)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errMalformedBlock, err)
+	}
+	if track != r.track.Number {
+		return nil
+	}
+	if len(data) < n+3 {
+		return fmt.Errorf("%w: %d bytes", errMalformedBlock, len(data))
+	}
+	relative := int16(uint16(data[n])<<8 | uint16(data[n+1])) //nolint:gosec // G115
+	flags := data[n+2]
+	frames, err := splitLaces(data[n+3:], flags&lacingMask)
+	if err != nil {
+		return err
+	}
+
+	timestamp := r.timestamp(int64(r.clusterTimestamp) + int64(relative)) //nolint:gosec // G115
+	for i, frame := range frames {
+		packet := &Packet{Data: frame, Timestamp: timestamp}
+		if i == len(frames)-1 {
+			packet.DiscardPadding = discardPadding
+		} else {
+			// Laced packets share their block's timestamp, so the later ones
+			// are placed after the ones before them.
+			samples, err := opus.PacketSampleCount(frame, opusSampleRate)
+			if err != nil {
+				return fmt.Errorf("%w: laced packet: %w", errMalformedBlock, err)
+			}
+			timestamp += time.Duration(samples) * time.Second / opusSampleRate
+		}
+		r.pending = append(r.pending, packet)
+	}
+
+	return nil
+}
+
+// splitLaces splits the frames of a block by its lacing.
+func splitLaces(data []byte, lacing byte) ([][]byte, error) {
+	if lacing == lacingNone {
+		return [][]byte{data}, nil
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: missing lace count", errMalformedBlock)
+	}
+	count := int(data[0]) + 1
+	data = data[1:]
+
+	sizes := make([]int, count)
+	switch lacing {
+	case lacingXiph:
+		for i := range count - 1 {
+			// Each size is a run of 255s ended by a smaller byte.
+			for more := true; more; {
+				if len(data) == 0 {
+					return nil, fmt.Errorf("%w: truncated Xiph lacing", errMalformedBlock)
+				}
+				sizes[i] += int(data[0])
+				more = data[0] == 255
+				data = data[1:]
+			}
+		}
+	case lacingFixed:
+		if len(data)%count != 0 {
+			return nil, fmt.Errorf("%w: %d bytes of fixed lacing in %d frames", errMalformedBlock, len(data), count)
+		}
+		for i := range sizes {
+			sizes[i] = len(data) / count
+		}
+
+		return cutFrames(data, sizes), nil
+	case lacingEBML:
+		for i := range count - 1 {
+			value, n, err := ebml.ParseVint(data)
+			if err != nil {
+				return nil, fmt.Errorf("%w: EBML lacing: %w", errMalformedBlock, err)
+			}
+			if i == 0 {
+				sizes[i] = int(value) //nolint:gosec // G115
+			} else {
+				// Later sizes are signed differences from the previous one.
+				bias := int64(1)<<(7*n-1) - 1
+				sizes[i] = sizes[i-1] + int(int64(value)-bias) //nolint:gosec // G115
+			}
+			data = data[n:]
+		}
+	}
+
+	rest := len(data)
+	for _, size := range sizes[:count-1] {
+		if size < 0 || size > rest {
+			return nil, fmt.Errorf("%w: lace sizes exceed the block", errMalformedBlock)
+		}
+		rest -= size
+	}
+	sizes[count-1] = rest
+
+	return cutFrames(data, sizes), nil
+}
+
+// cutFrames cuts data into consecutive frames of sizes.
+func cutFrames(data []byte, sizes []int) [][]byte {
+	frames := make([][]byte, len(sizes))
+	for i, size := range sizes {
+		frames[i], data = data[:size], data[size:]
+	}
+
+	return frames
+}
+
+// SeekTo moves the reader to the Cluster to start decoding at to play from
+// target: the last one Cues place at least SeekPreRoll before it. It returns
+// the time of that Cluster. The input must be an io.ReadSeeker whose Cues
+// the SeekHead points at, or which have already been read.
+func (r *WebMReader) SeekTo(target time.Duration) (time.Duration, error) {
+	seeker, ok := r.stream.(io.ReadSeeker)
+	if !ok {
+		return 0, errNotSeekable
+	}
+	if len(r.cues) == 0 {
+		if r.cuesPosition < 0 {
+			return 0, errNoCues
+		}
+		if err := r.loadCues(seeker); err != nil {
+			return 0, err
+		}
+		if len(r.cues) == 0 {
+			return 0, errNoCues
+		}
+	}
+
+	start := max(target-r.track.SeekPreRoll, 0)
+	cue := r.cues[0]
+	for _, point := range r.cues[1:] {
+		if r.timestamp(int64(point.time)) > start { //nolint:gosec // G115
+			break
+		}
+		cue = point
+	}
+	if err := r.seek(seeker, r.segmentStart+cue.position); err != nil {
+		return 0, err
+	}
+	r.pending = nil
+
+	return r.timestamp(int64(cue.time)), nil //nolint:gosec // G115
+}
+
+func (r *WebMReader) seek(seeker io.ReadSeeker, position int64) error {
+	if _, err := seeker.Seek(position, io.SeekStart); err != nil {
+		return err
+	}
+	r.input.buffered.Reset(seeker)
+	r.input.position = position
+
+	return nil
+}
+
+// loadCues reads the Cues the SeekHead points at.
+func (r *WebMReader) loadCues(seeker io.ReadSeeker) error {
+	if err := r.seek(seeker, r.segmentStart+r.cuesPosition); err != nil {
+		return err
+	}
+	id, size, err := r.readHeader()
+	if err != nil {
+		return err
+	}
+	if id != ebml.IDCues {
+		return fmt.Errorf("%w: SeekHead points at element 0x%X", errNoCues, id)
+	}
+	data, err := r.readData(id, size)
+	if err != nil {
+		return err
+	}
+
+	return r.parseCues(data)
+}
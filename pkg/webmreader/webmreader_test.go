@@ -0,0 +1,315 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package webmreader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/pion/opus/internal/ebml"
+	"github.com/pion/opus/pkg/oggreader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// element encodes an EBML element of known size.
+func element(id uint32, children ...[]byte) []byte {
+	data := bytes.Join(children, nil)
+	out := binary.BigEndian.AppendUint32(nil, id)
+	for out[0] == 0 {
+		out = out[1:]
+	}
+	length := 1
+	for uint64(len(data)) >= 1<<(7*length)-1 {
+		length++
+	}
+	size := uint64(len(data)) | 1<<(7*length)
+	for i := length - 1; i >= 0; i-- {
+		out = append(out, byte(size>>(8*i)))
+	}
+
+	return append(out, data...)
+}
+
+// unknownSize encodes the header of an element of unknown size.
+func unknownSize(id uint32) []byte {
+	return append(binary.BigEndian.AppendUint32(nil, id), 0x01, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff)
+}
+
+func uintData(value uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, value)
+}
+
+func floatData(value float64) []byte {
+	return binary.BigEndian.AppendUint64(nil, math.Float64bits(value))
+}
+
+// block encodes a Block or SimpleBlock body with a one-byte track number.
+func block(track byte, relative int16, flags byte, frames ...[]byte) []byte {
+	return append([]byte{0x80 | track, byte(uint16(relative) >> 8), byte(relative), flags}, bytes.Join(frames, nil)...)
+}
+
+// testPacket returns a 20 ms CELT packet with a distinct byte.
+func testPacket(i int) []byte {
+	return []byte{0xfc, byte(i), 0xaa}
+}
+
+var testOpusHead = []byte{ //nolint:gochecknoglobals
+	'O', 'p', 'u', 's', 'H', 'e', 'a', 'd', 1, 2, 0x38, 0x01, 0x80, 0xbb, 0, 0, 0, 0, 0,
+}
+
+func ebmlHeader(docType string) []byte {
+	return element(ebml.IDEBML, element(ebml.IDEBMLVersion, []byte{1}), element(ebml.IDDocType, []byte(docType)))
+}
+
+func opusTrackEntry(children ...[]byte) []byte {
+	return element(ebml.IDTrackEntry, append([][]byte{
+		element(ebml.IDTrackNumber, []byte{1}),
+		element(ebml.IDTrackType, []byte{ebml.TrackTypeAudio}),
+		element(ebml.IDCodecID, []byte(ebml.CodecIDOpus)),
+	}, children...)...)
+}
+
+// testFile builds a WebM file of known sizes with a video track besides the
+// Opus one, two Clusters a second apart and Cues the SeekHead points at.
+func testFile() []byte {
+	tracks := element(ebml.IDTracks,
+		element(ebml.IDTrackEntry,
+			element(ebml.IDTrackNumber, []byte{2}),
+			element(ebml.IDTrackType, []byte{1}),
+			element(ebml.IDCodecID, []byte("V_VP8")),
+		),
+		opusTrackEntry(
+			element(ebml.IDCodecPrivate, testOpusHead),
+			element(ebml.IDCodecDelay, uintData(6500000)),
+			element(ebml.IDSeekPreRoll, uintData(80000000)),
+			element(ebml.IDAudio, element(ebml.IDSamplingFrequency, floatData(48000)), element(ebml.IDChannels, []byte{2})),
+		),
+	)
+	info := element(ebml.IDInfo,
+		element(ebml.IDTimestampScale, uintData(1000000)),
+		element(ebml.IDDuration, floatData(1040)),
+	)
+	clusters := [][]byte{
+		element(ebml.IDCluster,
+			element(ebml.IDTimestamp, []byte{0}),
+			element(ebml.IDSimpleBlock, block(1, 0, 0x80, testPacket(0))),
+			element(ebml.IDSimpleBlock, block(2, 0, 0x80, []byte{1, 2, 3})),
+			element(ebml.IDVoid, make([]byte, 5)),
+			element(ebml.IDSimpleBlock, block(1, 20, 0x80, testPacket(1))),
+		),
+		element(ebml.IDCluster,
+			element(ebml.IDTimestamp, uintData(1000)),
+			element(ebml.IDSimpleBlock, block(1, 0, 0x80, testPacket(2))),
+			element(ebml.IDBlockGroup,
+				element(ebml.IDBlock, block(1, 20, 0, testPacket(3))),
+				element(ebml.IDDiscardPadding, uintData(12500000)),
+			),
+		),
+	}
+	// The SeekHead is as long whatever the positions it holds.
+	seekHead := func(cues int) []byte {
+		return element(ebml.IDSeekHead, element(ebml.IDSeek,
+			element(ebml.IDSeekID, []byte{0x1c, 0x53, 0xbb, 0x6b}),
+			element(ebml.IDSeekPosition, uintData(uint64(cues))), //nolint:gosec // G115
+		))
+	}
+	cluster0 := len(seekHead(0)) + len(info) + len(tracks)
+	cluster1 := cluster0 + len(clusters[0])
+	cueTrack := func(time, position int) []byte {
+		return element(ebml.IDCuePoint,
+			element(ebml.IDCueTime, uintData(uint64(time))), //nolint:gosec // G115
+			element(ebml.IDCueTrackPositions,
+				element(ebml.IDCueTrack, []byte{1}),
+				element(ebml.IDCueClusterPosition, uintData(uint64(position))), //nolint:gosec // G115
+			),
+		)
+	}
+	cues := element(ebml.IDCues, cueTrack(0, cluster0), cueTrack(1000, cluster1))
+
+	segment := bytes.Join(append([][]byte{seekHead(cluster1 + len(clusters[1])), info, tracks}, append(clusters, cues)...), nil)
+
+	return append(ebmlHeader("webm"), element(ebml.IDSegment, segment)...)
+}
+
+func readAll(t *testing.T, reader *WebMReader) []*Packet {
+	t.Helper()
+
+	var packets []*Packet
+	for {
+		packet, err := reader.ParseNextPacket()
+		if err == io.EOF { //nolint:errorlint
+			return packets
+		}
+		require.NoError(t, err)
+		packets = append(packets, packet)
+	}
+}
+
+func TestWebMReader(t *testing.T) {
+	reader, track, err := NewWith(bytes.NewReader(testFile()))
+	require.NoError(t, err)
+
+	assert.Equal(t, &Track{
+		Number:      1,
+		Header:      oggreader.OggHeader{Version: 1, Channels: 2, PreSkip: 312, SampleRate: 48000},
+		CodecDelay:  6500 * time.Microsecond,
+		SeekPreRoll: 80 * time.Millisecond,
+	}, track)
+	assert.Equal(t, 1040*time.Millisecond, reader.Duration())
+
+	assert.Equal(t, []*Packet{
+		{Data: testPacket(0), Timestamp: 0},
+		{Data: testPacket(1), Timestamp: 20 * time.Millisecond},
+		{Data: testPacket(2), Timestamp: time.Second},
+		{Data: testPacket(3), Timestamp: 1020 * time.Millisecond, DiscardPadding: 12500 * time.Microsecond},
+	}, readAll(t, reader))
+}
+
+func TestWebMReaderUnknownSizes(t *testing.T) {
+	// A live recording: no CodecPrivate, Segment and Clusters of unknown
+	// size, and a Cluster Timestamp in 10 ms units.
+	file := ebmlHeader("webm")
+	file = append(file, unknownSize(ebml.IDSegment)...)
+	file = append(file, element(ebml.IDInfo, element(ebml.IDTimestampScale, uintData(10000000)))...)
+	file = append(file, element(ebml.IDTracks, opusTrackEntry(
+		element(ebml.IDCodecDelay, uintData(6500000)),
+		element(ebml.IDAudio, element(ebml.IDSamplingFrequency, floatData(48000)), element(ebml.IDChannels, []byte{1})),
+	))...)
+	for cluster := range 3 {
+		file = append(file, unknownSize(ebml.IDCluster)...)
+		file = append(file, element(ebml.IDTimestamp, []byte{byte(cluster * 4)})...)
+		for i := range 2 {
+			file = append(file, element(ebml.IDSimpleBlock, block(1, int16(2*i), 0x80, testPacket(2*cluster+i)))...)
+		}
+	}
+
+	reader, track, err := NewWith(bytes.NewReader(file))
+	require.NoError(t, err)
+	assert.Equal(t, oggreader.OggHeader{Version: 1, Channels: 1, PreSkip: 312, SampleRate: 48000}, track.Header)
+	assert.Zero(t, reader.Duration())
+
+	packets := readAll(t, reader)
+	require.Len(t, packets, 6)
+	for i, packet := range packets {
+		assert.Equal(t, testPacket(i), packet.Data)
+		assert.Equal(t, time.Duration(i)*20*time.Millisecond, packet.Timestamp)
+	}
+
+	_, err = reader.SeekTo(0)
+	assert.ErrorIs(t, err, errNoCues)
+}
+
+func TestWebMReaderLacing(t *testing.T) {
+	long := append(testPacket(1), make([]byte, 300)...)
+	for _, test := range []struct {
+		name   string
+		flags  byte
+		frames []byte
+		want   [][]byte
+	}{
+		{
+			"Xiph", lacingXiph,
+			bytes.Join([][]byte{{2, 3, 255, 48}, testPacket(0), long, testPacket(2)}, nil),
+			[][]byte{testPacket(0), long, testPacket(2)},
+		},
+		{
+			"EBML", lacingEBML,
+			// 3, then +300 as a two-byte signed difference (bias 8191).
+			bytes.Join([][]byte{{2, 0x83, 0x61, 0x2b}, testPacket(0), long, testPacket(2)}, nil),
+			[][]byte{testPacket(0), long, testPacket(2)},
+		},
+		{
+			"fixed", lacingFixed,
+			bytes.Join([][]byte{{2}, testPacket(0), testPacket(1), testPacket(2)}, nil),
+			[][]byte{testPacket(0), testPacket(1), testPacket(2)},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			file := append(ebmlHeader("matroska"), element(ebml.IDSegment,
+				element(ebml.IDTracks, opusTrackEntry(element(ebml.IDCodecPrivate, testOpusHead))),
+				element(ebml.IDCluster,
+					element(ebml.IDTimestamp, []byte{100}),
+					element(ebml.IDSimpleBlock, block(1, 0, 0x80|test.flags, test.frames)),
+				),
+			)...)
+			reader, _, err := NewWith(bytes.NewReader(file))
+			require.NoError(t, err)
+			packets := readAll(t, reader)
+			require.Len(t, packets, len(test.want))
+			for i, packet := range packets {
+				assert.Equal(t, test.want[i], packet.Data)
+				assert.Equal(t, time.Duration(100+20*i)*time.Millisecond, packet.Timestamp)
+			}
+		})
+	}
+}
+
+func TestWebMReaderSeek(t *testing.T) {
+	file := testFile()
+	reader, _, err := NewWith(bytes.NewReader(file))
+	require.NoError(t, err)
+
+	// Within SeekPreRoll of the second Cluster, so decoding starts before it.
+	position, err := reader.SeekTo(1050 * time.Millisecond)
+	require.NoError(t, err)
+	assert.Zero(t, position)
+	packet, err := reader.ParseNextPacket()
+	require.NoError(t, err)
+	assert.Equal(t, testPacket(0), packet.Data)
+
+	position, err = reader.SeekTo(1100 * time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, time.Second, position)
+	packets := readAll(t, reader)
+	require.Len(t, packets, 2)
+	assert.Equal(t, testPacket(2), packets[0].Data)
+
+	reader, _, err = NewWith(io.MultiReader(bytes.NewReader(file)))
+	require.NoError(t, err)
+	_, err = reader.SeekTo(time.Second)
+	assert.ErrorIs(t, err, errNotSeekable)
+}
+
+func TestWebMReaderErrors(t *testing.T) {
+	_, _, err := NewWith(nil)
+	assert.ErrorIs(t, err, errNilStream)
+
+	_, _, err = NewWith(bytes.NewReader([]byte("OggS")))
+	assert.ErrorIs(t, err, errNotEBML)
+
+	_, _, err = NewWith(bytes.NewReader(ebmlHeader("mkv3d")))
+	assert.ErrorIs(t, err, errBadDocType)
+
+	_, _, err = NewWith(bytes.NewReader(ebmlHeader("webm")))
+	assert.ErrorIs(t, err, errNoSegment)
+
+	noOpus := append(ebmlHeader("webm"), element(ebml.IDSegment, element(ebml.IDTracks,
+		element(ebml.IDTrackEntry,
+			element(ebml.IDTrackNumber, []byte{1}),
+			element(ebml.IDTrackType, []byte{ebml.TrackTypeAudio}),
+			element(ebml.IDCodecID, []byte("A_VORBIS")),
+		),
+	))...)
+	_, _, err = NewWith(bytes.NewReader(noOpus))
+	assert.ErrorIs(t, err, errNoOpusTrack)
+
+	badHead := append(ebmlHeader("webm"), element(ebml.IDSegment, element(ebml.IDTracks,
+		opusTrackEntry(element(ebml.IDCodecPrivate, []byte("OpusHead"))),
+	))...)
+	_, _, err = NewWith(bytes.NewReader(badHead))
+	assert.Error(t, err)
+
+	file := testFile()
+	reader, _, err := NewWith(bytes.NewReader(file[:len(file)-80]))
+	require.NoError(t, err)
+	for err == nil {
+		_, err = reader.ParseNextPacket()
+	}
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,54 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package webmwriter
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/pion/opus"
+	"github.com/pion/opus/pkg/webmreader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncoderWriter(t *testing.T) {
+	for _, channels := range []int{1, 2} {
+		encoder, err := opus.NewEncoder(opus.WithChannels(channels))
+		require.NoError(t, err)
+
+		var out seekBuffer
+		writer, err := NewEncoderWriter(&out, encoder)
+		require.NoError(t, err)
+
+		const samples = 72000 + 333
+		_, err = writer.Write(make([]byte, samples*channels*2))
+		require.NoError(t, err)
+		require.NoError(t, writer.Close())
+
+		reader, track, err := webmreader.NewWith(bytes.NewReader(out.data))
+		require.NoError(t, err)
+		preSkip := encoder.Lookahead()
+		assert.Equal(t, uint8(channels), track.Header.Channels) //nolint:gosec // 1 or 2.
+		assert.Equal(t, uint16(preSkip), track.Header.PreSkip)  //nolint:gosec // G115
+		assert.Equal(t, time.Duration(preSkip)*time.Second/48000, track.CodecDelay)
+		assert.Equal(t, time.Duration(preSkip+samples)*time.Second/48000, reader.Duration())
+
+		// The decoded audio less CodecDelay and DiscardPadding is exactly the
+		// input.
+		total := 0
+		for _, packet := range readAll(t, reader) {
+			n, err := opus.PacketSampleCount(packet.Data, 48000)
+			require.NoError(t, err)
+			total += n - int(packet.DiscardPadding*48000/time.Second)
+		}
+		assert.Equal(t, samples, total-preSkip)
+	}
+}
+
+func TestEncoderWriterNilEncoder(t *testing.T) {
+	_, err := NewEncoderWriter(&bytes.Buffer{}, nil)
+	assert.ErrorIs(t, err, errNilEncoder)
+}
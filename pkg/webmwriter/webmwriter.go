@@ -0,0 +1,448 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+// Package webmwriter implements a writer of audio-only WebM files with one
+// Opus track, laid out as browsers' MediaRecorder writes them.
+//
+// The track's CodecPrivate is the Opus ID header, and its CodecDelay and
+// SeekPreRoll are set as RFC 9559 Section 10.3.2.1 asks. Packets go into
+// SimpleBlocks, gathered into Clusters of up to MaxClusterDuration each, and
+// a packet trimmed at its end goes into a BlockGroup with DiscardPadding.
+// Close writes Cues pointing at every Cluster. When the output can seek,
+// Close also fills in the Segment size, the Duration and a SeekHead pointing
+// at the Cues; otherwise the Segment is left with an unknown size, as live
+// recordings are.
+package webmwriter
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"os"
+	"time"
+
+	"github.com/pion/opus"
+	"github.com/pion/opus/internal/ebml"
+	"github.com/pion/opus/pkg/oggreader"
+	"github.com/pion/opus/pkg/oggwriter"
+)
+
+const (
+	docType            = "webm"
+	docTypeVersion     = 4
+	docTypeReadVersion = 2
+
+	trackNumber    = 1
+	timestampScale = uint64(time.Millisecond) // ns, so timestamps count milliseconds
+	opusSampleRate = 48000
+
+	// seekPreRoll is the 80 ms RFC 7845 Section 4.6 recommends decoding
+	// ahead of a seek target.
+	seekPreRoll = 80 * time.Millisecond
+
+	// defaultMaxClusterDuration keeps Clusters short enough that seeking
+	// reads little, and matches what muxers commonly use for audio.
+	defaultMaxClusterDuration = 5 * time.Second
+	// maxClusterDuration is the most a block's int16 timestamp, relative to
+	// its Cluster, reaches.
+	maxClusterDuration = math.MaxInt16 * time.Millisecond
+
+	defaultWritingApp = "pion/opus"
+
+	// seekHeadSpace is reserved after the Segment start for the SeekHead
+	// Close writes when the output can seek.
+	seekHeadSpace = 96
+	// segmentSizeWidth makes the Segment size overwritable with any other.
+	segmentSizeWidth = 8
+	// durationElementSize is the ID, size and 8-byte float of Duration.
+	durationElementSize = 2 + 1 + 8
+)
+
+var (
+	errNilStream                 = errors.New("stream is nil")
+	errWriterClosed              = errors.New("writer is closed")
+	errInvalidChannelCount       = errors.New("channel count must be positive")
+	errInvalidMaxClusterDuration = errors.New("max cluster duration must be positive and at most 32767 ms")
+	errGranulePositionRewinded   = errors.New("granule position must not decrease")
+	errInvalidPacket             = errors.New("invalid Opus packet")
+)
+
+type cuePoint struct {
+	time     uint64 // in milliseconds
+	position int64  // of the Cluster, from the start of the Segment data
+}
+
+// WebMWriter is used to write Opus packets into a WebM file.
+type WebMWriter struct {
+	stream io.Writer
+	fd     *os.File
+	// seeker is the stream when it can seek, and fileStart the position the
+	// file starts at in it.
+	seeker    io.WriteSeeker
+	fileStart int64
+
+	channelMapping     oggreader.OggChannelMapping
+	maxClusterDuration uint64 // in milliseconds
+	writingApp         string
+	closed             bool
+
+	// Positions, counted in bytes written, of what Close fills in.
+	written          int64
+	segmentSizeAt    int64
+	segmentStart     int64
+	durationAt       int64
+	infoPosition     int64 // from the start of the Segment data
+	tracksPosition   int64 // from the start of the Segment data
+	seekHeadPosition int64 // from the start of the Segment data
+
+	// The Cluster being assembled, whose children are buffered so it is
+	// written with a known size.
+	cluster          []byte
+	clusterTimestamp uint64
+	hasCluster       bool
+	cues             []cuePoint
+
+	end               uint64 // in 48 kHz samples, where the last packet ends
+	lastGranule       uint64
+	hasWrittenPackets bool
+}
+
+// Option configures a WebMWriter during construction.
+type Option func(*WebMWriter) error
+
+// WithChannelMapping sets the stream count, coupled count and mapping table
+// written for channel mapping families other than 0.
+func WithChannelMapping(mapping oggreader.OggChannelMapping) Option {
+	return func(w *WebMWriter) error {
+		w.channelMapping = oggreader.OggChannelMapping{
+			StreamCount:  mapping.StreamCount,
+			CoupledCount: mapping.CoupledCount,
+			Mapping:      append([]uint8(nil), mapping.Mapping...),
+		}
+
+		return nil
+	}
+}
+
+// WithMaxClusterDuration bounds how much audio is gathered into one Cluster
+// before it is written out, and so how finely Cues allow seeking. It can be
+// at most 32767 ms, the reach of block timestamps.
+func WithMaxClusterDuration(duration time.Duration) Option {
+	return func(w *WebMWriter) error {
+		if duration <= 0 || duration > maxClusterDuration {
+			return errInvalidMaxClusterDuration
+		}
+		w.maxClusterDuration = uint64(duration / time.Millisecond)
+
+		return nil
+	}
+}
+
+// WithWritingApp sets the WritingApp written to the Segment Info.
+func WithWritingApp(app string) Option {
+	return func(w *WebMWriter) error {
+		w.writingApp = app
+
+		return nil
+	}
+}
+
+// New builds a new WebM writer that writes to fileName.
+func New(fileName string, header oggreader.OggHeader, opts ...Option) (*WebMWriter, error) {
+	fd, err := os.Create(fileName) // #nosec G304
+	if err != nil {
+		return nil, err
+	}
+	writer, err := NewWith(fd, header, opts...)
+	if err != nil {
+		return nil, errors.Join(err, fd.Close())
+	}
+	writer.fd = fd
+
+	return writer, nil
+}
+
+// NewWith builds a new WebM writer on top of out and writes the EBML header
+// and the Segment's Info and Tracks.
+//
+// header is written as the Opus ID header, and its PreSkip as the track's
+// CodecDelay. header.Version of 0 is written as 1, and header.SampleRate of 0
+// as 48000. A header.ChannelMap other than 0 needs WithChannelMapping.
+func NewWith(out io.Writer, header oggreader.OggHeader, opts ...Option) (*WebMWriter, error) {
+	if out == nil {
+		return nil, errNilStream
+	}
+
+	writer := &WebMWriter{
+		stream:             out,
+		maxClusterDuration: uint64(defaultMaxClusterDuration / time.Millisecond),
+		writingApp:         defaultWritingApp,
+	}
+	for _, opt := range opts {
+		if err := opt(writer); err != nil {
+			return nil, err
+		}
+	}
+	if header.Channels == 0 {
+		return nil, errInvalidChannelCount
+	}
+	if seeker, ok := out.(io.WriteSeeker); ok {
+		// Files opened on pipes are io.WriteSeekers that fail to seek.
+		if position, err := seeker.Seek(0, io.SeekCurrent); err == nil {
+			writer.seeker, writer.fileStart = seeker, position
+		}
+	}
+
+	if err := writer.writeHeaders(header); err != nil {
+		return nil, err
+	}
+
+	return writer, nil
+}
+
+func (w *WebMWriter) writeHeaders(header oggreader.OggHeader) error {
+	opusHead, err := oggwriter.OpusHead(header, w.channelMapping)
+	if err != nil {
+		return err
+	}
+
+	var ebmlHeader []byte
+	ebmlHeader = ebml.AppendUint(ebmlHeader, ebml.IDEBMLVersion, 1)
+	ebmlHeader = ebml.AppendUint(ebmlHeader, ebml.IDEBMLReadVersion, 1)
+	ebmlHeader = ebml.AppendUint(ebmlHeader, ebml.IDEBMLMaxIDLength, 4)
+	ebmlHeader = ebml.AppendUint(ebmlHeader, ebml.IDEBMLMaxSizeLength, 8)
+	ebmlHeader = ebml.AppendString(ebmlHeader, ebml.IDDocType, docType)
+	ebmlHeader = ebml.AppendUint(ebmlHeader, ebml.IDDocTypeVersion, docTypeVersion)
+	ebmlHeader = ebml.AppendUint(ebmlHeader, ebml.IDDocTypeReadVersion, docTypeReadVersion)
+	out := ebml.AppendElement(nil, ebml.IDEBML, ebmlHeader)
+
+	out = ebml.AppendID(out, ebml.IDSegment)
+	w.segmentSizeAt = int64(len(out))
+	out = ebml.AppendSize(out, ebml.UnknownSize, segmentSizeWidth)
+	w.segmentStart = int64(len(out))
+
+	// The SeekHead needs the position of the Cues, which only Close knows,
+	// so its place is held by a Void.
+	w.seekHeadPosition = int64(len(out)) - w.segmentStart
+	out = appendVoid(out, seekHeadSpace)
+
+	var info []byte
+	info = ebml.AppendUint(info, ebml.IDTimestampScale, timestampScale)
+	info = ebml.AppendString(info, ebml.IDMuxingApp, defaultWritingApp)
+	info = ebml.AppendString(info, ebml.IDWritingApp, w.writingApp)
+	if w.seeker != nil {
+		info = ebml.AppendFloat(info, ebml.IDDuration, 0)
+	}
+	w.infoPosition = int64(len(out)) - w.segmentStart
+	out = ebml.AppendElement(out, ebml.IDInfo, info)
+	// Duration, left last in Info, is filled in by Close.
+	w.durationAt = int64(len(out)) - durationElementSize
+
+	var audio []byte
+	audio = ebml.AppendFloat(audio, ebml.IDSamplingFrequency, opusSampleRate)
+	audio = ebml.AppendUint(audio, ebml.IDChannels, uint64(header.Channels))
+	var entry []byte
+	entry = ebml.AppendUint(entry, ebml.IDTrackNumber, trackNumber)
+	entry = ebml.AppendUint(entry, ebml.IDTrackUID, rand.Uint64()|1) // #nosec G404
+	entry = ebml.AppendUint(entry, ebml.IDTrackType, ebml.TrackTypeAudio)
+	entry = ebml.AppendString(entry, ebml.IDCodecID, ebml.CodecIDOpus)
+	entry = ebml.AppendElement(entry, ebml.IDCodecPrivate, opusHead)
+	entry = ebml.AppendUint(entry, ebml.IDCodecDelay, uint64(samplesToDuration(uint64(header.PreSkip))))
+	entry = ebml.AppendUint(entry, ebml.IDSeekPreRoll, uint64(seekPreRoll))
+	entry = ebml.AppendElement(entry, ebml.IDAudio, audio)
+	w.tracksPosition = int64(len(out)) - w.segmentStart
+	out = ebml.AppendElement(out, ebml.IDTracks, ebml.AppendElement(nil, ebml.IDTrackEntry, entry))
+
+	return w.write(out)
+}
+
+// appendVoid appends a Void element of size bytes in all, at least 2 and at
+// most 128.
+func appendVoid(b []byte, size int) []byte {
+	b = ebml.AppendID(b, ebml.IDVoid)
+	b = ebml.AppendSize(b, int64(size-2), 1)
+
+	return append(b, make([]byte, size-2)...)
+}
+
+func (w *WebMWriter) write(data []byte) error {
+	n, err := w.stream.Write(data)
+	w.written += int64(n)
+
+	return err
+}
+
+// samplesToDuration converts a count of 48 kHz samples to the nearest
+// nanosecond, which converts back exactly.
+func samplesToDuration(samples uint64) time.Duration {
+	return time.Duration((samples*uint64(time.Second) + opusSampleRate/2) / opusSampleRate) //nolint:gosec // G115
+}
+
+// WritePacket writes one Opus packet whose last sample ends at
+// granulePosition, in 48 kHz samples from the start of the stream, the
+// samples of CodecDelay among them, as Ogg granule positions count.
+//
+// The block is timestamped, to the millisecond, where the packet's own
+// duration before granulePosition falls, so gaps in the positions become
+// gaps in the timestamps. A block cannot skip the start of its packet, so a
+// packet that would overlap the one before starts where that one ends, and
+// what it runs past granulePosition goes into the DiscardPadding of a
+// BlockGroup. That is how the last packet of a stream is cut to length.
+func (w *WebMWriter) WritePacket(packet []byte, granulePosition uint64) error {
+	if w.closed {
+		return errWriterClosed
+	}
+	if w.hasWrittenPackets && granulePosition < w.lastGranule {
+		return errGranulePositionRewinded
+	}
+	samples, err := opus.PacketSampleCount(packet, opusSampleRate)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errInvalidPacket, err)
+	}
+
+	start := w.end
+	if granulePosition >= start+uint64(samples) { //nolint:gosec // G115
+		start = granulePosition - uint64(samples) //nolint:gosec // G115
+	}
+	end := max(granulePosition, start)
+	padding := start + uint64(samples) - end //nolint:gosec // G115
+
+	timestamp := start * 1000 / opusSampleRate
+	if !w.hasCluster || timestamp-w.clusterTimestamp >= w.maxClusterDuration {
+		if err = w.flushCluster(); err != nil {
+			return err
+		}
+		w.clusterTimestamp = timestamp
+		w.cluster = ebml.AppendUint(w.cluster, ebml.IDTimestamp, timestamp)
+		w.hasCluster = true
+	}
+
+	// RFC 9559 Section 10.2: the block header is the track number, the
+	// timestamp relative to the Cluster and the flags, keyframe for audio.
+	relative := uint16(timestamp - w.clusterTimestamp) //nolint:gosec // G115
+	block := ebml.AppendVint(nil, trackNumber, 0)
+	block = append(block, byte(relative>>8), byte(relative))
+	if padding == 0 {
+		block = append(block, 0x80)
+		w.cluster = ebml.AppendElement(w.cluster, ebml.IDSimpleBlock, append(block, packet...))
+	} else {
+		block = append(block, 0x00)
+		group := ebml.AppendElement(nil, ebml.IDBlock, append(block, packet...))
+		group = ebml.AppendInt(group, ebml.IDDiscardPadding, int64(samplesToDuration(padding)))
+		w.cluster = ebml.AppendElement(w.cluster, ebml.IDBlockGroup, group)
+	}
+
+	w.end = end
+	w.lastGranule = granulePosition
+	w.hasWrittenPackets = true
+
+	return nil
+}
+
+// Flush writes out the open Cluster, if any, so everything written so far is
+// on the underlying stream.
+func (w *WebMWriter) Flush() error {
+	if w.closed {
+		return errWriterClosed
+	}
+
+	return w.flushCluster()
+}
+
+func (w *WebMWriter) flushCluster() error {
+	if !w.hasCluster {
+		return nil
+	}
+	w.cues = append(w.cues, cuePoint{time: w.clusterTimestamp, position: w.written - w.segmentStart})
+	cluster := ebml.AppendElement(nil, ebml.IDCluster, w.cluster)
+	w.cluster = w.cluster[:0]
+	w.hasCluster = false
+
+	return w.write(cluster)
+}
+
+// Close writes the open Cluster and the Cues, and fills in what the headers
+// left open when the output can seek. If the writer was created with New, the
+// file is closed too.
+func (w *WebMWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	err := w.finish()
+	if w.fd != nil {
+		err = errors.Join(err, w.fd.Close())
+	}
+
+	return err
+}
+
+func (w *WebMWriter) finish() error {
+	if err := w.flushCluster(); err != nil {
+		return err
+	}
+
+	cuesPosition := w.written - w.segmentStart
+	if len(w.cues) > 0 {
+		var cues []byte
+		for _, cue := range w.cues {
+			var positions []byte
+			positions = ebml.AppendUint(positions, ebml.IDCueTrack, trackNumber)
+			positions = ebml.AppendUint(positions, ebml.IDCueClusterPosition, uint64(cue.position)) //nolint:gosec // G115
+			var point []byte
+			point = ebml.AppendUint(point, ebml.IDCueTime, cue.time)
+			point = ebml.AppendElement(point, ebml.IDCueTrackPositions, positions)
+			cues = ebml.AppendElement(cues, ebml.IDCuePoint, point)
+		}
+		if err := w.write(ebml.AppendElement(nil, ebml.IDCues, cues)); err != nil {
+			return err
+		}
+	}
+	if w.seeker == nil {
+		return nil
+	}
+
+	var seeks []byte
+	for _, seek := range []struct {
+		id       uint32
+		position int64
+	}{
+		{ebml.IDInfo, w.infoPosition},
+		{ebml.IDTracks, w.tracksPosition},
+		{ebml.IDCues, cuesPosition},
+	} {
+		if seek.id == ebml.IDCues && len(w.cues) == 0 {
+			continue
+		}
+		var entry []byte
+		entry = ebml.AppendElement(entry, ebml.IDSeekID, ebml.AppendID(nil, seek.id))
+		entry = ebml.AppendUint(entry, ebml.IDSeekPosition, uint64(seek.position)) //nolint:gosec // G115
+		seeks = ebml.AppendElement(seeks, ebml.IDSeek, entry)
+	}
+	seekHead := ebml.AppendElement(nil, ebml.IDSeekHead, seeks)
+	seekHead = appendVoid(seekHead, seekHeadSpace-len(seekHead))
+
+	duration := ebml.AppendFloat(nil, ebml.IDDuration, float64(w.end)*1000/opusSampleRate)
+	end := w.written
+	for _, patch := range []struct {
+		at   int64
+		data []byte
+	}{
+		{w.segmentStart + w.seekHeadPosition, seekHead},
+		{w.durationAt, duration},
+		{w.segmentSizeAt, ebml.AppendSize(nil, end-w.segmentStart, segmentSizeWidth)},
+	} {
+		if _, err := w.seeker.Seek(w.fileStart+patch.at, io.SeekStart); err != nil {
+			return err
+		}
+		if _, err := w.seeker.Write(patch.data); err != nil {
+			return err
+		}
+	}
+	_, err := w.seeker.Seek(w.fileStart+end, io.SeekStart)
+
+	return err
+}
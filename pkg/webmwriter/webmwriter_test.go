@@ -0,0 +1,400 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package webmwriter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pion/opus/internal/ebml"
+	"github.com/pion/opus/pkg/oggreader"
+	"github.com/pion/opus/pkg/webmreader"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seekBuffer is an in-memory io.WriteSeeker.
+type seekBuffer struct {
+	data     []byte
+	position int64
+}
+
+func (s *seekBuffer) Write(p []byte) (int, error) {
+	if end := s.position + int64(len(p)); end > int64(len(s.data)) {
+		s.data = append(s.data, make([]byte, end-int64(len(s.data)))...)
+	}
+	n := copy(s.data[s.position:], p)
+	s.position += int64(n)
+
+	return n, nil
+}
+
+func (s *seekBuffer) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += s.position
+	case io.SeekEnd:
+		offset += int64(len(s.data))
+	}
+	s.position = offset
+
+	return offset, nil
+}
+
+// testPacket is a 20 ms CELT-only packet whose payload tells it apart.
+func testPacket(i int) []byte {
+	return []byte{0xf8, byte(i), byte(i >> 8)}
+}
+
+func readAll(t *testing.T, reader *webmreader.WebMReader) []*webmreader.Packet {
+	t.Helper()
+
+	var packets []*webmreader.Packet
+	for {
+		packet, err := reader.ParseNextPacket()
+		if errors.Is(err, io.EOF) {
+			return packets
+		}
+		require.NoError(t, err)
+		packets = append(packets, packet)
+	}
+}
+
+// element is a child of the Segment, at position from the start of its data.
+type element struct {
+	id       uint32
+	position int64
+	data     []byte
+}
+
+// segmentChildren splits a WebM file into the children of its Segment. It
+// also returns the size the Segment declares and the size of what follows
+// its header.
+func segmentChildren(t *testing.T, file []byte) ([]element, int64, int64) {
+	t.Helper()
+
+	reader := bytes.NewReader(file)
+	readElement := func() (uint32, int64) {
+		id, _, err := ebml.ReadID(reader)
+		require.NoError(t, err)
+		size, _, err := ebml.ReadSize(reader)
+		require.NoError(t, err)
+
+		return id, size
+	}
+	id, size := readElement()
+	require.Equal(t, uint32(ebml.IDEBML), id)
+	_, err := reader.Seek(size, io.SeekCurrent)
+	require.NoError(t, err)
+	id, segmentSize := readElement()
+	require.Equal(t, uint32(ebml.IDSegment), id)
+	segmentStart := reader.Size() - int64(reader.Len())
+
+	var children []element
+	for reader.Len() > 0 {
+		position := reader.Size() - int64(reader.Len()) - segmentStart
+		id, size := readElement()
+		data := make([]byte, size)
+		_, err = io.ReadFull(reader, data)
+		require.NoError(t, err)
+		children = append(children, element{id: id, position: position, data: data})
+	}
+
+	return children, segmentSize, int64(len(file)) - segmentStart
+}
+
+// clusterLayout is what a Cluster holds: its Timestamp, and the timestamps
+// of its blocks relative to it.
+type clusterLayout struct {
+	timestamp uint64
+	blocks    []int16
+}
+
+func readCluster(t *testing.T, data []byte) clusterLayout {
+	t.Helper()
+
+	var cluster clusterLayout
+	require.NoError(t, ebml.Children(data, func(id uint32, data []byte) error {
+		switch id {
+		case ebml.IDTimestamp:
+			cluster.timestamp = ebml.Uint(data)
+		case ebml.IDSimpleBlock:
+			cluster.blocks = append(cluster.blocks, int16(binary.BigEndian.Uint16(data[1:]))) //nolint:gosec // G115
+		case ebml.IDBlockGroup:
+			return ebml.Children(data, func(id uint32, data []byte) error {
+				if id == ebml.IDBlock {
+					cluster.blocks = append(cluster.blocks, int16(binary.BigEndian.Uint16(data[1:]))) //nolint:gosec // G115
+				}
+
+				return nil
+			})
+		}
+
+		return nil
+	}))
+
+	return cluster
+}
+
+// readCues returns the CueTime and CueClusterPosition of each CuePoint.
+func readCues(t *testing.T, data []byte) (times []uint64, positions []int64) {
+	t.Helper()
+
+	require.NoError(t, ebml.Children(data, func(id uint32, data []byte) error {
+		require.Equal(t, uint32(ebml.IDCuePoint), id)
+
+		return ebml.Children(data, func(id uint32, data []byte) error {
+			switch id {
+			case ebml.IDCueTime:
+				times = append(times, ebml.Uint(data))
+			case ebml.IDCueTrackPositions:
+				return ebml.Children(data, func(id uint32, data []byte) error {
+					if id == ebml.IDCueClusterPosition {
+						positions = append(positions, int64(ebml.Uint(data))) //nolint:gosec // G115
+					}
+
+					return nil
+				})
+			}
+
+			return nil
+		})
+	}))
+
+	return times, positions
+}
+
+// TestWebMWriter_Clusters checks where Clusters break and that the Cues
+// point at each of them, whether Close can go back to fill in the SeekHead
+// or not.
+func TestWebMWriter_Clusters(t *testing.T) {
+	for _, seekable := range []bool{true, false} {
+		var out io.Writer = &bytes.Buffer{}
+		if seekable {
+			out = &seekBuffer{}
+		}
+		writer, err := NewWith(out, oggreader.OggHeader{Channels: 2, PreSkip: 312},
+			WithMaxClusterDuration(100*time.Millisecond))
+		require.NoError(t, err)
+		// Packet i starts at i*20 ms. A Cluster is full once a block would
+		// start 100 ms after it, and Flush ends one early, after packet 12.
+		for i := range 22 {
+			require.NoError(t, writer.WritePacket(testPacket(i), uint64(i+1)*960)) //nolint:gosec // G115
+			if i == 12 {
+				require.NoError(t, writer.Flush())
+			}
+		}
+		// The last packet is cut to 5 ms.
+		require.NoError(t, writer.WritePacket(testPacket(22), 22*960+240))
+		require.NoError(t, writer.Close())
+
+		var file []byte
+		if seekable {
+			file = out.(*seekBuffer).data //nolint:forcetypeassert
+		} else {
+			file = out.(*bytes.Buffer).Bytes() //nolint:forcetypeassert
+		}
+		children, segmentSize, dataSize := segmentChildren(t, file)
+
+		var clusters []clusterLayout
+		var clusterPositions []int64
+		var cues, seekHead *element
+		for i, child := range children {
+			switch child.id {
+			case ebml.IDCluster:
+				clusters = append(clusters, readCluster(t, child.data))
+				clusterPositions = append(clusterPositions, child.position)
+			case ebml.IDCues:
+				cues = &children[i]
+			case ebml.IDSeekHead:
+				seekHead = &children[i]
+			}
+		}
+		assert.Equal(t, []clusterLayout{
+			{0, []int16{0, 20, 40, 60, 80}},
+			{100, []int16{0, 20, 40, 60, 80}},
+			{200, []int16{0, 20, 40}},
+			{260, []int16{0, 20, 40, 60, 80}},
+			{360, []int16{0, 20, 40, 60, 80}},
+		}, clusters)
+
+		require.NotNil(t, cues, "Cues are written whether the output can seek or not")
+		cueTimes, cuePositions := readCues(t, cues.data)
+		assert.Equal(t, []uint64{0, 100, 200, 260, 360}, cueTimes)
+		assert.Equal(t, clusterPositions, cuePositions)
+
+		reader, _, err := webmreader.NewWith(bytes.NewReader(file))
+		require.NoError(t, err)
+		packets := readAll(t, reader)
+		require.Len(t, packets, 23)
+		assert.Equal(t, 15*time.Millisecond, packets[22].DiscardPadding)
+
+		if !seekable {
+			assert.Equal(t, int64(ebml.UnknownSize), segmentSize)
+			assert.Nil(t, seekHead, "the SeekHead's space is left a Void")
+			assert.Zero(t, reader.Duration())
+
+			continue
+		}
+		assert.Equal(t, dataSize, segmentSize)
+		require.NotNil(t, seekHead)
+		assert.Equal(t, int64(0), seekHead.position)
+		var cuesPosition int64 = -1
+		require.NoError(t, ebml.Children(seekHead.data, func(id uint32, data []byte) error {
+			var seekID []byte
+			var position uint64
+			require.NoError(t, ebml.Children(data, func(id uint32, data []byte) error {
+				switch id {
+				case ebml.IDSeekID:
+					seekID = data
+				case ebml.IDSeekPosition:
+					position = ebml.Uint(data)
+				}
+
+				return nil
+			}))
+			if bytes.Equal(seekID, ebml.AppendID(nil, ebml.IDCues)) {
+				cuesPosition = int64(position) //nolint:gosec // G115
+			}
+
+			return nil
+		}))
+		assert.Equal(t, cues.position, cuesPosition)
+		assert.Equal(t, (22*960+240)*time.Second/48000, reader.Duration())
+
+		// Seeking lands on the last Cluster that starts at least SeekPreRoll
+		// before the target.
+		start, err := reader.SeekTo(350 * time.Millisecond)
+		require.NoError(t, err)
+		assert.Equal(t, 260*time.Millisecond, start)
+		packet, err := reader.ParseNextPacket()
+		require.NoError(t, err)
+		assert.Equal(t, testPacket(13), packet.Data)
+	}
+}
+
+// TestWebMWriter_ClusterLimit checks that no block is timestamped past the
+// 32767 ms an int16 reaches from its Cluster.
+func TestWebMWriter_ClusterLimit(t *testing.T) {
+	var out bytes.Buffer
+	writer, err := NewWith(&out, oggreader.OggHeader{Channels: 1}, WithMaxClusterDuration(32767*time.Millisecond))
+	require.NoError(t, err)
+	// The packets start at 0, 32746, 32766 and 32786 ms.
+	for i, start := range []uint64{0, 32746, 32766, 32786} {
+		require.NoError(t, writer.WritePacket(testPacket(i), start*48+960))
+	}
+	require.NoError(t, writer.Close())
+
+	children, _, _ := segmentChildren(t, out.Bytes())
+	var clusters []clusterLayout
+	for _, child := range children {
+		if child.id == ebml.IDCluster {
+			clusters = append(clusters, readCluster(t, child.data))
+		}
+	}
+	assert.Equal(t, []clusterLayout{
+		{0, []int16{0, 32746, 32766}},
+		{32786, []int16{0}},
+	}, clusters)
+}
+
+// TestWebMWriter_Overlap checks that a packet whose granule position leaves
+// it overlapping the one before is moved after it and trimmed at its end.
+func TestWebMWriter_Overlap(t *testing.T) {
+	var out bytes.Buffer
+	writer, err := NewWith(&out, oggreader.OggHeader{Channels: 1, PreSkip: 312})
+	require.NoError(t, err)
+	require.NoError(t, writer.WritePacket(testPacket(0), 960))
+	require.NoError(t, writer.WritePacket(testPacket(1), 960+480))
+	require.NoError(t, writer.WritePacket(testPacket(2), 960+480+960))
+	require.NoError(t, writer.Close())
+
+	reader, track, err := webmreader.NewWith(&out)
+	require.NoError(t, err)
+	assert.Equal(t, 6500*time.Microsecond, track.CodecDelay)
+	packets := readAll(t, reader)
+	require.Len(t, packets, 3)
+	assert.Zero(t, packets[0].DiscardPadding)
+	assert.Equal(t, 20*time.Millisecond, packets[1].Timestamp)
+	assert.Equal(t, 10*time.Millisecond, packets[1].DiscardPadding)
+	// The next packet follows its granule position again.
+	assert.Equal(t, 30*time.Millisecond, packets[2].Timestamp)
+	assert.Zero(t, packets[2].DiscardPadding)
+}
+
+func TestWebMWriter_Gap(t *testing.T) {
+	var out seekBuffer
+	writer, err := NewWith(&out, oggreader.OggHeader{Channels: 1})
+	require.NoError(t, err)
+	require.NoError(t, writer.WritePacket(testPacket(0), 960))
+	// 40 s of silence, longer than a Cluster can span.
+	require.NoError(t, writer.WritePacket(testPacket(1), 960+40*48000+960))
+	require.NoError(t, writer.Close())
+
+	reader, _, err := webmreader.NewWith(bytes.NewReader(out.data))
+	require.NoError(t, err)
+	packets := readAll(t, reader)
+	require.Len(t, packets, 2)
+	assert.Zero(t, packets[0].Timestamp)
+	assert.Equal(t, 40020*time.Millisecond, packets[1].Timestamp)
+}
+
+func TestWebMWriter_ChannelMapping(t *testing.T) {
+	var out bytes.Buffer
+	mapping := oggreader.OggChannelMapping{StreamCount: 2, CoupledCount: 1, Mapping: []uint8{0, 1, 2}}
+	writer, err := NewWith(&out, oggreader.OggHeader{Channels: 3, ChannelMap: 1}, WithChannelMapping(mapping))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	_, track, err := webmreader.NewWith(&out)
+	require.NoError(t, err)
+	assert.Equal(t, uint8(1), track.Header.ChannelMap)
+	assert.Equal(t, mapping, track.ChannelMapping)
+}
+
+func TestWebMWriter_Errors(t *testing.T) {
+	_, err := NewWith(nil, oggreader.OggHeader{Channels: 1})
+	assert.ErrorIs(t, err, errNilStream)
+
+	_, err = NewWith(io.Discard, oggreader.OggHeader{})
+	assert.ErrorIs(t, err, errInvalidChannelCount)
+
+	_, err = NewWith(io.Discard, oggreader.OggHeader{Channels: 1}, WithMaxClusterDuration(0))
+	assert.ErrorIs(t, err, errInvalidMaxClusterDuration)
+	_, err = NewWith(io.Discard, oggreader.OggHeader{Channels: 1}, WithMaxClusterDuration(time.Minute))
+	assert.ErrorIs(t, err, errInvalidMaxClusterDuration)
+
+	writer, err := NewWith(io.Discard, oggreader.OggHeader{Channels: 1})
+	require.NoError(t, err)
+	assert.ErrorIs(t, writer.WritePacket(nil, 960), errInvalidPacket)
+	require.NoError(t, writer.WritePacket([]byte{0xf8}, 960))
+	assert.ErrorIs(t, writer.WritePacket([]byte{0xf8}, 480), errGranulePositionRewinded)
+
+	require.NoError(t, writer.Close())
+	assert.ErrorIs(t, writer.WritePacket([]byte{0xf8}, 1920), errWriterClosed)
+	assert.ErrorIs(t, writer.Flush(), errWriterClosed)
+}
+
+func TestWebMWriter_New(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "out.webm")
+	writer, err := New(fileName, oggreader.OggHeader{Channels: 1}, WithWritingApp("test"))
+	require.NoError(t, err)
+	require.NoError(t, writer.WritePacket([]byte{0xf8}, 960))
+	require.NoError(t, writer.Flush())
+	require.NoError(t, writer.Close())
+
+	file, err := os.Open(fileName) // #nosec G304
+	require.NoError(t, err)
+	defer func() { require.NoError(t, file.Close()) }()
+	reader, _, err := webmreader.NewWith(file)
+	require.NoError(t, err)
+	assert.Equal(t, 20*time.Millisecond, reader.Duration())
+	assert.Len(t, readAll(t, reader), 1)
+}
@@ -0,0 +1,110 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package opus
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Repacketizer merges the frames of packets into one packet, or splits them
+// out into packets of fewer frames, as libopus's OpusRepacketizer does. The
+// extensions the packets carry in their padding move with their frames.
+//
+// The zero value is ready to use.
+type Repacketizer struct {
+	toc        byte
+	frames     [][]byte
+	extensions []PacketExtension
+	// nanoseconds is the duration of the frames so far.
+	nanoseconds int
+}
+
+// NewRepacketizer creates a Repacketizer.
+func NewRepacketizer() Repacketizer {
+	return Repacketizer{}
+}
+
+// Reset drops the frames taken so far, opus_repacketizer_init in libopus.
+func (r *Repacketizer) Reset() {
+	r.frames = r.frames[:0]
+	r.extensions = r.extensions[:0]
+	r.nanoseconds = 0
+}
+
+// Cat adds the frames of packet, and the extensions in its padding, after
+// those taken so far, opus_repacketizer_cat in libopus. The packet must have
+// the configuration and stereo flag of the packets before, and all of them
+// together must last at most 120 ms. On error, nothing is added. The
+// Repacketizer keeps a copy of packet, which may be reused at once.
+func (r *Repacketizer) Cat(packet []byte) error {
+	if len(packet) < 1 {
+		return errTooShortForTableOfContentsHeader
+	}
+	if len(r.frames) > 0 && packet[0]&^0b11 != r.toc&^0b11 {
+		return errRepacketizerMismatch
+	}
+
+	packet = slices.Clone(packet)
+	tocHeader := tableOfContentsHeader(packet[0])
+	frames, err := parsePacketFrames(packet, tocHeader)
+	if err != nil {
+		return err
+	}
+	nanoseconds := r.nanoseconds + len(frames)*tocHeader.configuration().frameDuration().nanoseconds()
+	if nanoseconds > maxOpusPacketDurationNanosecond {
+		return errRepacketizerTooLong
+	}
+	extensions, err := PacketExtensions(packet)
+	if err != nil {
+		return err
+	}
+
+	for _, extension := range extensions {
+		extension.Frame += len(r.frames)
+		r.extensions = append(r.extensions, extension)
+	}
+	r.toc = packet[0]
+	r.frames = append(r.frames, frames...)
+	r.nanoseconds = nanoseconds
+
+	return nil
+}
+
+// NumFrames returns how many frames have been taken so far,
+// opus_repacketizer_get_nb_frames in libopus.
+func (r *Repacketizer) NumFrames() int {
+	return len(r.frames)
+}
+
+// Out returns a packet of all the frames taken so far,
+// opus_repacketizer_out in libopus.
+func (r *Repacketizer) Out() ([]byte, error) {
+	return r.OutRange(0, len(r.frames))
+}
+
+// OutRange returns a packet of the frames from begin up to end, counted over
+// the frames taken so far, opus_repacketizer_out_range in libopus. The
+// extensions of those frames go in its padding, moved to the indices their
+// frames get. The packet is coded the most compact way and does not share
+// memory with the Repacketizer.
+func (r *Repacketizer) OutRange(begin, end int) ([]byte, error) {
+	if begin < 0 || begin >= end || end > len(r.frames) {
+		return nil, fmt.Errorf("%w: %d to %d of %d frames", errInvalidFrameRange, begin, end, len(r.frames))
+	}
+
+	var extensions []PacketExtension
+	for _, extension := range r.extensions {
+		if extension.Frame >= begin && extension.Frame < end {
+			extension.Frame -= begin
+			extensions = append(extensions, extension)
+		}
+	}
+	padding, err := AppendPacketExtensions(nil, extensions)
+	if err != nil {
+		return nil, err
+	}
+
+	return appendPacket(nil, r.toc, r.frames[begin:end], padding), nil
+}
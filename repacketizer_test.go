@@ -0,0 +1,100 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package opus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRepacketizer merges encoded packets carrying extensions into one and
+// splits it again, the extensions following their frames both ways.
+func TestRepacketizer(t *testing.T) {
+	t.Parallel()
+
+	encoder, err := NewEncoder()
+	require.NoError(t, err)
+	pcm := testEncoderSineFloat32()
+	var packets [][]byte
+	for i := range 2 {
+		extensions := []PacketExtension{{ID: 3, Data: []byte{byte(i)}}, {ID: 40, Data: []byte{'a' + byte(i)}}}
+		require.NoError(t, encoder.SetPacketExtensions(extensions))
+		packet := make([]byte, maxOpusFrameSize)
+		n, err := encoder.EncodeFloat32(pcm, packet)
+		require.NoError(t, err)
+		packets = append(packets, packet[:n])
+	}
+
+	repacketizer := NewRepacketizer()
+	for _, packet := range packets {
+		require.NoError(t, repacketizer.Cat(packet))
+	}
+	assert.Equal(t, 2, repacketizer.NumFrames())
+	merged, err := repacketizer.Out()
+	require.NoError(t, err)
+	extensions, err := PacketExtensions(merged)
+	require.NoError(t, err)
+	assert.Equal(t, []PacketExtension{
+		{ID: 3, Frame: 0, Data: []byte{0}},
+		{ID: 40, Frame: 0, Data: []byte("a")},
+		{ID: 3, Frame: 1, Data: []byte{1}},
+		{ID: 40, Frame: 1, Data: []byte("b")},
+	}, extensions)
+
+	decoder, err := NewDecoderWithOutput(48000, 1)
+	require.NoError(t, err)
+	out := make([]float32, 2*encoderTestFrameSampleCount)
+	n, err := decoder.DecodeToFloat32(merged, out)
+	require.NoError(t, err)
+	assert.Equal(t, 2*encoderTestFrameSampleCount, n)
+
+	// Splitting the merged packet gives back the packets the encoder made.
+	var splitter Repacketizer
+	require.NoError(t, splitter.Cat(merged))
+	for i, packet := range packets {
+		split, err := splitter.OutRange(i, i+1)
+		require.NoError(t, err)
+		assert.Equal(t, packet, split)
+	}
+
+	// What Cat keeps does not depend on the packet passed to it.
+	clear(merged)
+	split, err := splitter.OutRange(1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, packets[1], split)
+}
+
+func TestRepacketizerErrors(t *testing.T) {
+	t.Parallel()
+
+	// 20 ms SILK-only wideband packets, mono and stereo.
+	mono := []byte{tocByte(frameCodeOneFrame), 1}
+	stereo := []byte{tocByte(frameCodeOneFrame) | 0b100, 1}
+
+	var repacketizer Repacketizer
+	_, err := repacketizer.Out()
+	assert.ErrorIs(t, err, errInvalidFrameRange)
+	assert.ErrorIs(t, repacketizer.Cat(nil), errTooShortForTableOfContentsHeader)
+	assert.ErrorIs(t, repacketizer.Cat([]byte{tocByte(frameCodeArbitraryFrames)}), errMalformedPacket)
+
+	for range 6 {
+		require.NoError(t, repacketizer.Cat(mono))
+	}
+	assert.ErrorIs(t, repacketizer.Cat(mono), errRepacketizerTooLong)
+	assert.Equal(t, 6, repacketizer.NumFrames())
+
+	repacketizer.Reset()
+	assert.Zero(t, repacketizer.NumFrames())
+	require.NoError(t, repacketizer.Cat(mono))
+	assert.ErrorIs(t, repacketizer.Cat(stereo), errRepacketizerMismatch)
+	for _, bounds := range [][2]int{{-1, 1}, {0, 0}, {1, 1}, {0, 2}} {
+		_, err = repacketizer.OutRange(bounds[0], bounds[1])
+		assert.ErrorIs(t, err, errInvalidFrameRange, bounds)
+	}
+	packet, err := repacketizer.Out()
+	require.NoError(t, err)
+	assert.Equal(t, mono, packet)
+}
@@ -0,0 +1,148 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package opus
+
+import (
+	"math"
+
+	sincresample "github.com/pion/opus/internal/resample/sinc"
+)
+
+const (
+	minResampledOutputRate = 8000
+	maxResampledOutputRate = 192000
+)
+
+// ResampleQuality selects the filter a Decoder resamples its output with when
+// the output rate is not one Opus decodes at natively.
+type ResampleQuality int
+
+const (
+	// ResampleQualityMedium passes up to about 90% of the Nyquist frequency
+	// and rejects aliases by about 80 dB. It is the default.
+	ResampleQualityMedium ResampleQuality = iota
+	// ResampleQualityLow passes up to about 80% of the Nyquist frequency and
+	// rejects aliases by about 55 dB, at a third of the cost.
+	ResampleQualityLow
+	// ResampleQualityHigh passes up to about 93% of the Nyquist frequency and
+	// rejects aliases by about 100 dB, at twice the cost.
+	ResampleQualityHigh
+)
+
+func (q ResampleQuality) sincQuality() (sincresample.Quality, error) {
+	switch q {
+	case ResampleQualityLow:
+		return sincresample.QualityLow, nil
+	case ResampleQualityMedium:
+		return sincresample.QualityMedium, nil
+	case ResampleQualityHigh:
+		return sincresample.QualityHigh, nil
+	default:
+		return 0, errInvalidResampleQuality
+	}
+}
+
+// isNativeSampleRate reports whether Opus decodes at sampleRate without an
+// extra resampling stage.
+func isNativeSampleRate(sampleRate int) bool {
+	switch sampleRate {
+	case 8000, 12000, 16000, 24000, 48000:
+		return true
+	default:
+		return false
+	}
+}
+
+// isResampledOutputRate reports whether sampleRate can be reached by
+// resampling 48 kHz output. The rate must hold a whole number of samples per
+// 10 ms, so PLC and FEC, which work in 10 ms steps, always fill their output
+// exactly.
+func isResampledOutputRate(sampleRate int) bool {
+	return sampleRate >= minResampledOutputRate &&
+		sampleRate <= maxResampledOutputRate &&
+		sampleRate%100 == 0
+}
+
+// SetResampleQuality selects the filter used when the output rate is not one
+// Opus decodes at natively, ResampleQualityMedium by default. It restarts the
+// resampler, so it is best called before decoding.
+func (d *Decoder) SetResampleQuality(quality ResampleQuality) error {
+	if _, err := quality.sincQuality(); err != nil {
+		return err
+	}
+	d.resampleQuality = quality
+	if d.outputSampleRate == 0 {
+		return nil
+	}
+
+	return d.initOutputResampler()
+}
+
+// ResampleDelay returns how many samples per channel, at the output rate, the
+// resampling stage delays the audio by. It is 0 at the rates Opus decodes at
+// natively, where there is no such stage.
+func (d *Decoder) ResampleDelay() int {
+	if d.outputSampleRate == 0 {
+		return 0
+	}
+
+	return int(math.Round(d.outputResampler.Delay()))
+}
+
+func (d *Decoder) initOutputResampler() error {
+	quality, err := d.resampleQuality.sincQuality()
+	if err != nil {
+		return err
+	}
+
+	return d.outputResampler.Init(d.sampleRate, d.outputSampleRate, d.channels, quality)
+}
+
+// outputRate is the rate the caller receives audio at.
+func (d *Decoder) outputRate() int {
+	if d.outputSampleRate != 0 {
+		return d.outputSampleRate
+	}
+
+	return d.sampleRate
+}
+
+// decodeResampledToFloat32 decodes in at the decoding rate and resamples it
+// into out.
+func (d *Decoder) decodeResampledToFloat32(
+	in []byte,
+	out []float32,
+) (samplesPerChannel int, bandwidth Bandwidth, isStereo bool, err error) {
+	decodedSamples, err := PacketSampleCount(in, d.sampleRate)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	if len(out) < d.outputResampler.OutputSamples(decodedSamples)*d.channels {
+		return 0, 0, false, errOutBufferTooSmall
+	}
+
+	decoded := resizeFloat32Buffer(&d.outputResampleBuffer, decodedSamples*d.channels)
+	decodedSamples, bandwidth, isStereo, err = d.decodePacketToFloat32(in, decoded)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	samplesPerChannel, err = d.outputResampler.Resample(decoded[:decodedSamples*d.channels], out)
+	if err != nil {
+		return 0, 0, false, err
+	}
+
+	return samplesPerChannel, bandwidth, isStereo, nil
+}
+
+// resampleOutput has produce write samplesPerChannel samples at the decoding
+// rate, a multiple of 10 ms, and resamples them into out.
+func (d *Decoder) resampleOutput(out []float32, samplesPerChannel int, produce func([]float32) error) error {
+	decoded := resizeFloat32Buffer(&d.outputResampleBuffer, samplesPerChannel*d.channels)
+	if err := produce(decoded); err != nil {
+		return err
+	}
+	_, err := d.outputResampler.Resample(decoded, out)
+
+	return err
+}
@@ -0,0 +1,81 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package opus
+
+import (
+	"testing"
+
+	sincresample "github.com/pion/opus/internal/resample/sinc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDecodeAt44100 decodes a stream, with a loss concealed and one recovered
+// with FEC, at 44.1 kHz and checks it against the same stream decoded at
+// 48 kHz and resampled separately.
+func TestDecodeAt44100(t *testing.T) {
+	enc, err := NewEncoder(WithInbandFEC(true))
+	require.NoError(t, err)
+	require.NoError(t, enc.SetLossRate(20))
+	packets := encodeSILKVoice(t, enc, BandwidthWideband, 8)
+
+	dec, err := NewDecoderWithOutput(44100, 2)
+	require.NoError(t, err)
+	require.NoError(t, dec.SetResampleQuality(ResampleQualityHigh))
+	reference, err := NewDecoderWithOutput(48000, 2)
+	require.NoError(t, err)
+	var resampler sincresample.Resampler
+	require.NoError(t, resampler.Init(48000, 44100, 2, sincresample.QualityHigh))
+	assert.Equal(t, int(resampler.Delay()+0.5), dec.ResampleDelay())
+	assert.Positive(t, dec.ResampleDelay())
+
+	const frame = 882
+	referenceOut := make([]float32, 2*960)
+	for i, packet := range packets {
+		out := make([]float32, 2*frame)
+		switch i {
+		case 3:
+			require.NoError(t, dec.decodePLCToFloat32(out))
+			require.NoError(t, reference.decodePLCToFloat32(referenceOut))
+		case 5:
+			require.NoError(t, dec.DecodeFECToFloat32(packets[6], out))
+			require.NoError(t, reference.DecodeFECToFloat32(packets[6], referenceOut))
+		default:
+			n, err := dec.DecodeToFloat32(packet, out)
+			require.NoError(t, err)
+			assert.Equal(t, frame, n)
+			_, err = reference.DecodeToFloat32(packet, referenceOut)
+			require.NoError(t, err)
+		}
+
+		want := make([]float32, 2*frame)
+		n, err := resampler.Resample(referenceOut, want)
+		require.NoError(t, err)
+		require.Equal(t, frame, n)
+		assert.InDeltaSlice(t, want, out, 1e-6, "packet %d", i)
+	}
+}
+
+func TestDecodeResampledValidation(t *testing.T) {
+	dec, err := NewDecoderWithOutput(44100, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 48000, dec.sampleRate)
+	assert.Equal(t, 44100, dec.outputSampleRate)
+	assert.ErrorIs(t, dec.SetResampleQuality(ResampleQuality(9)), errInvalidResampleQuality)
+
+	packet := []byte{byte(silkOnlyWideband20msConfig << 3), 0}
+	_, err = dec.DecodeToFloat32(packet, make([]float32, 881))
+	assert.ErrorIs(t, err, errOutBufferTooSmall)
+	assert.ErrorIs(t, dec.DecodePLC(make([]int16, 960)), errInvalidPLCFrameSize)
+	assert.ErrorIs(t, dec.DecodeFEC(packet, make([]int16, 480)), errInvalidFECFrameSize)
+
+	native := NewDecoder()
+	assert.Zero(t, native.ResampleDelay())
+	require.NoError(t, native.SetResampleQuality(ResampleQualityLow))
+
+	for _, sampleRate := range []int{7900, 22050, 192100} {
+		_, err = NewDecoderWithOutput(sampleRate, 1)
+		assert.ErrorIs(t, err, errInvalidSampleRate)
+	}
+}
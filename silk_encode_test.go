@@ -111,7 +111,7 @@ func TestSILKDCBlockRemovesConstantOffset(t *testing.T) {
 		pcm[i] = 10000
 	}
 	var mem float32
-	out := applySILKDCBlock(pcm, sampleRate, &mem)
+	out := applySILKDCBlock(pcm, make([]int16, len(pcm)), sampleRate, &mem)
 
 	var sum float64
 	for i := len(out) - 1600; i < len(out); i++ {
@@ -122,12 +122,12 @@ func TestSILKDCBlockRemovesConstantOffset(t *testing.T) {
 }
 
 // TestSILKDCBlockPreservesInput checks the original pcm slice is untouched
-// (applySILKDCBlock returns a new slice, unlike celt's in-place applyDCBlock).
+// (applySILKDCBlock writes to a separate slice, unlike celt's in-place applyDCBlock).
 func TestSILKDCBlockPreservesInput(t *testing.T) {
 	pcm := []int16{5000, 5000, 5000, 5000}
 	original := append([]int16(nil), pcm...)
 	var mem float32
-	applySILKDCBlock(pcm, 16000, &mem)
+	applySILKDCBlock(pcm, make([]int16, len(pcm)), 16000, &mem)
 	assert.Equal(t, original, pcm)
 }
 
@@ -136,11 +136,11 @@ func TestSILKDCBlockPreservesInput(t *testing.T) {
 // [-32768, 32767], which must saturate instead of wrapping.
 func TestSILKDCBlockSaturates(t *testing.T) {
 	memHigh := float32(-32768)
-	outHigh := applySILKDCBlock([]int16{32767}, 16000, &memHigh)
+	outHigh := applySILKDCBlock([]int16{32767}, make([]int16, 1), 16000, &memHigh)
 	assert.Equal(t, int16(32767), outHigh[0])
 
 	memLow := float32(32767)
-	outLow := applySILKDCBlock([]int16{-32768}, 16000, &memLow)
+	outLow := applySILKDCBlock([]int16{-32768}, make([]int16, 1), 16000, &memLow)
 	assert.Equal(t, int16(-32768), outLow[0])
 }
 
@@ -154,12 +154,147 @@ func TestSILKDCBlockMultiFrameState(t *testing.T) {
 	}
 
 	var memFull float32
-	fullOut := applySILKDCBlock(pcm, sampleRate, &memFull)
+	fullOut := applySILKDCBlock(pcm, make([]int16, len(pcm)), sampleRate, &memFull)
 
 	var memSplit float32
-	out1 := applySILKDCBlock(pcm[:160], sampleRate, &memSplit)
-	out2 := applySILKDCBlock(pcm[160:], sampleRate, &memSplit)
+	out1 := applySILKDCBlock(pcm[:160], make([]int16, 160), sampleRate, &memSplit)
+	out2 := applySILKDCBlock(pcm[160:], make([]int16, 160), sampleRate, &memSplit)
 	splitOut := append(append([]int16(nil), out1...), out2...)
 
 	assert.Equal(t, fullOut, splitOut)
 }
+
+func TestDecoderPitchLag(t *testing.T) {
+	dec, err := NewDecoderWithOutput(48000, 1)
+	require.NoError(t, err)
+	_, voiced := dec.PitchLag()
+	assert.False(t, voiced)
+
+	// A 160 Hz tone has a 100-sample period at 16 kHz, 300 samples at 48 kHz.
+	enc, err := NewEncoder()
+	require.NoError(t, err)
+	out := make([]int16, 960)
+	for frame := range 3 {
+		pcm := make([]int16, 320)
+		for i := range pcm {
+			phase := 2 * math.Pi * float64(frame*320+i) / 100
+			pcm[i] = int16(6000*math.Sin(phase) + 2500*math.Sin(2*phase))
+		}
+		packet := make([]byte, maxOpusFrameSize)
+		n, err := enc.EncodeSILK(pcm, BandwidthWideband, packet)
+		require.NoError(t, err)
+		_, err = dec.DecodeToInt16(packet[:n], out)
+		require.NoError(t, err)
+	}
+	lag, voiced := dec.PitchLag()
+	assert.True(t, voiced)
+	assert.InDelta(t, 300, lag, 9)
+}
+
+// TestEncodeSILKDownsamplesInput encodes 20 ms frames above the SILK internal
+// rate and checks they come out as the requested bandwidth with the pitch
+// intact, up to the octave errors the pitch search makes at 12 kHz either way.
+func TestEncodeSILKDownsamplesInput(t *testing.T) {
+	for _, test := range []struct {
+		inputRate int
+		bandwidth Bandwidth
+	}{
+		{16000, BandwidthNarrowband},
+		{16000, BandwidthMediumband},
+		{24000, BandwidthWideband},
+		{48000, BandwidthNarrowband},
+		{48000, BandwidthMediumband},
+		{48000, BandwidthWideband},
+	} {
+		enc, err := NewEncoder(WithSILKInputRate(test.inputRate))
+		require.NoError(t, err)
+		dec, err := NewDecoderWithOutput(48000, 1)
+		require.NoError(t, err)
+
+		// A 160 Hz tone has a 300-sample period at 48 kHz.
+		frameSamples := test.inputRate / 50
+		out := make([]int16, 960)
+		for frame := range 4 {
+			pcm := make([]int16, frameSamples)
+			for i := range pcm {
+				phase := 2 * math.Pi * 160 * float64(frame*frameSamples+i) / float64(test.inputRate)
+				pcm[i] = int16(6000*math.Sin(phase) + 2500*math.Sin(2*phase))
+			}
+			packet := make([]byte, maxOpusFrameSize)
+			n, err := enc.EncodeSILK(pcm, test.bandwidth, packet)
+			require.NoError(t, err, "%d Hz to %v", test.inputRate, test.bandwidth)
+			assert.Equal(t, test.bandwidth, tableOfContentsHeader(packet[0]).configuration().bandwidth())
+			_, err = dec.DecodeToInt16(packet[:n], out)
+			require.NoError(t, err)
+		}
+		lag, voiced := dec.PitchLag()
+		assert.True(t, voiced, "%d Hz to %v", test.inputRate, test.bandwidth)
+		assert.InDelta(t, 0, math.Remainder(float64(lag), 300), 9, "%d Hz to %v: lag %d", test.inputRate, test.bandwidth, lag)
+	}
+
+	enc, err := NewEncoder()
+	require.NoError(t, err)
+	_, err = enc.EncodeSILK(make([]int16, 960), BandwidthWideband, make([]byte, maxOpusFrameSize))
+	assert.ErrorIs(t, err, errInvalidFrameSize, "48 kHz input needs WithSILKInputRate")
+	require.NoError(t, enc.SetSILKInputRate(12000))
+	assert.Equal(t, 12000, enc.SILKInputRate())
+	_, err = enc.EncodeSILK(make([]int16, 240), BandwidthWideband, make([]byte, maxOpusFrameSize))
+	assert.ErrorIs(t, err, errInvalidSampleRate, "12 kHz is below the wideband internal rate")
+	require.NoError(t, enc.SetSILKInputRate(48000))
+	_, err = enc.EncodeSILK(make([]int16, 320), BandwidthWideband, make([]byte, maxOpusFrameSize))
+	assert.ErrorIs(t, err, errInvalidFrameSize, "the length does not pick the rate")
+	_, err = enc.EncodeSILK(make([]int16, 882), BandwidthWideband, make([]byte, maxOpusFrameSize))
+	assert.ErrorIs(t, err, errInvalidFrameSize)
+	assert.ErrorIs(t, enc.SetSILKInputRate(44100), errInvalidSampleRate)
+}
+
+// TestEncodeSILKDelayMatchesLookahead runs a chirp through the encoder's
+// delay compensation and downsampler and the decoder's upsampler, and checks
+// the SILK path then lags its input by Lookahead, as CELT does.
+func TestEncodeSILKDelayMatchesLookahead(t *testing.T) {
+	chirp := func(sampleRate int) []int16 {
+		out := make([]int16, sampleRate/2)
+		for i := range out {
+			ts := float64(i) / float64(sampleRate)
+			out[i] = int16(8000 * math.Sin(2*math.Pi*(300*ts+2000*ts*ts)))
+		}
+
+		return out
+	}
+
+	for _, bandwidth := range []Bandwidth{BandwidthNarrowband, BandwidthMediumband, BandwidthWideband} {
+		for _, inputRate := range []int{24000, 48000} {
+			enc, err := NewEncoder()
+			require.NoError(t, err)
+			dec := NewDecoder()
+			require.NoError(t, dec.silkResampler[0].Init(bandwidth.SampleRate(), celtSampleRate))
+
+			in := chirp(inputRate)
+			reference := chirp(celtSampleRate)
+			var decoded []float32
+			for offset := 0; offset < len(in); offset += inputRate / 50 {
+				internal, err := enc.prepareSILKInput(in[offset:offset+inputRate/50], inputRate, bandwidth.SampleRate())
+				require.NoError(t, err)
+				silkIn := make([]float32, len(internal))
+				for i, sample := range internal {
+					silkIn[i] = float32(sample) / 32768
+				}
+				silkOut := make([]float32, 960)
+				require.NoError(t, dec.silkResampler[0].Resample(silkIn, silkOut))
+				decoded = append(decoded, silkOut...)
+			}
+
+			bestLag, bestCorrelation := 0, math.Inf(-1)
+			for lag := range 240 {
+				var correlation float64
+				for i := 4800; i < len(reference)/2; i++ {
+					correlation += float64(reference[i]) * float64(decoded[i+lag])
+				}
+				if correlation > bestCorrelation {
+					bestLag, bestCorrelation = lag, correlation
+				}
+			}
+			assert.InDelta(t, enc.Lookahead(), bestLag, 6, "%d Hz to %v", inputRate, bandwidth)
+		}
+	}
+}